@@ -0,0 +1,192 @@
+/*
+Package accesslog implements a middleware that writes structured access logs through [log.NewLogger].
+
+Each access log contains the method, route template, path, status, response size, duration, client IP, request ID
+and trace ID. Requests with 5xx statuses are logged at error level, 4xx at warn level, and others at info level:
+
+	mw, err := accesslog.NewMiddleware(cfg)
+
+	mux := http.NewServeMux()
+	mux.Handle("GET /users/{id}", getUser)
+	http.ListenAndServe(":8080", mw(mux))
+
+The route template is taken from [http.Request.Pattern], which is set by [http.ServeMux]. To record trace IDs, the
+middleware should be wrapped by the tracing middleware so that the span is available in the request context.
+*/
+package accesslog
+
+import (
+	"log/slog"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sainnhe/go-common/pkg/constant"
+	"github.com/sainnhe/go-common/pkg/log"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const pkgName = "github.com/sainnhe/go-common/pkg/accesslog"
+
+// Option is the option used to customize the middleware.
+type Option func(m *middleware)
+
+// WithAttrs adds custom attributes to each access log, for example the authenticated user. f is called after the next
+// handler returns, with the request and the response status.
+func WithAttrs(f func(r *http.Request, status int) []slog.Attr) Option {
+	return func(m *middleware) {
+		if f != nil {
+			m.attrFuncs = append(m.attrFuncs, f)
+		}
+	}
+}
+
+type middleware struct {
+	cfg       *Config
+	l         *slog.Logger
+	attrFuncs []func(r *http.Request, status int) []slog.Attr
+}
+
+/*
+NewMiddleware initializes an access log middleware.
+
+Params:
+  - cfg: The config.
+  - opts: The options.
+
+Returns:
+  - func(http.Handler) http.Handler: The middleware.
+  - error: [constant.ErrNilDeps] if cfg is nil.
+*/
+func NewMiddleware(cfg *Config, opts ...Option) (func(http.Handler) http.Handler, error) {
+	if cfg == nil {
+		return nil, constant.ErrNilDeps
+	}
+	m := &middleware{cfg, log.NewLogger(pkgName), nil}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if isExcluded(cfg.ExcludedPaths, r.URL.Path) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			rw := &responseWriter{w, http.StatusOK, 0, false}
+			start := time.Now()
+			next.ServeHTTP(rw, r)
+			m.log(r, rw, time.Since(start))
+		})
+	}, nil
+}
+
+// log writes the access log of a request if it's sampled.
+func (m *middleware) log(r *http.Request, rw *responseWriter, d time.Duration) {
+	if rate := m.sampleRate(rw.status); rate < 1 && rand.Float64() >= rate { // nolint:gosec
+		return
+	}
+
+	attrs := make([]slog.Attr, 0, 10) // nolint:mnd
+	attrs = append(attrs,
+		slog.String(constant.LogAttrMethod, r.Method),
+		slog.String("route", r.Pattern),
+		slog.String("path", r.URL.Path),
+		slog.Int("status", rw.status),
+		slog.Int64("bytes", rw.bytes),
+		slog.Duration("duration", d),
+		slog.String("client_ip", m.clientIP(r)),
+	)
+	requestID := r.Header.Get(m.cfg.RequestIDHeader)
+	if len(requestID) == 0 {
+		requestID = rw.Header().Get(m.cfg.RequestIDHeader)
+	}
+	if len(requestID) > 0 {
+		attrs = append(attrs, slog.String("request_id", requestID))
+	}
+	if sc := trace.SpanContextFromContext(r.Context()); sc.HasTraceID() {
+		attrs = append(attrs, slog.String("trace_id", sc.TraceID().String()))
+	}
+	for _, f := range m.attrFuncs {
+		attrs = append(attrs, f(r, rw.status)...)
+	}
+
+	level := slog.LevelInfo
+	switch {
+	case rw.status >= http.StatusInternalServerError:
+		level = slog.LevelError
+	case rw.status >= http.StatusBadRequest:
+		level = slog.LevelWarn
+	}
+	m.l.LogAttrs(r.Context(), level, "Access.", attrs...)
+}
+
+// sampleRate returns the sample rate of the given status.
+func (m *middleware) sampleRate(status int) float64 {
+	if rate, ok := m.cfg.SampleRates[strconv.Itoa(status)]; ok {
+		return rate
+	}
+	if rate, ok := m.cfg.SampleRates[strconv.Itoa(status/100)+"xx"]; ok { // nolint:mnd
+		return rate
+	}
+	return m.cfg.SampleRate
+}
+
+// clientIP returns the IP of the client.
+func (m *middleware) clientIP(r *http.Request) string {
+	if m.cfg.TrustProxy {
+		if xff := r.Header.Get("X-Forwarded-For"); len(xff) > 0 {
+			ip, _, _ := strings.Cut(xff, ",")
+			return strings.TrimSpace(ip)
+		}
+		if ip := r.Header.Get("X-Real-Ip"); len(ip) > 0 {
+			return ip
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// isExcluded reports whether path is excluded from access logs.
+func isExcluded(excludedPaths []string, path string) bool {
+	for _, excluded := range excludedPaths {
+		if path == excluded || (strings.HasSuffix(excluded, "/") && strings.HasPrefix(path, excluded)) {
+			return true
+		}
+	}
+	return false
+}
+
+// responseWriter records the status and the number of bytes written.
+type responseWriter struct {
+	http.ResponseWriter
+	status      int
+	bytes       int64
+	wroteHeader bool
+}
+
+func (w *responseWriter) WriteHeader(status int) {
+	// Informational statuses are followed by the final status.
+	if !w.wroteHeader && status >= http.StatusOK {
+		w.status = status
+		w.wroteHeader = true
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *responseWriter) Write(b []byte) (int, error) {
+	w.wroteHeader = true
+	n, err := w.ResponseWriter.Write(b)
+	w.bytes += int64(n)
+	return n, err
+}
+
+// Unwrap returns the underlying response writer, which is used by [http.ResponseController].
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,99 @@
+package accesslog_test
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sainnhe/go-common/pkg/accesslog"
+	"github.com/sainnhe/go-common/pkg/constant"
+	"github.com/sainnhe/go-common/pkg/encoding"
+	"github.com/sainnhe/go-common/pkg/log"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestNewMiddleware(t *testing.T) { // nolint:paralleltest
+	if _, err := accesslog.NewMiddleware(nil); !errors.Is(err, constant.ErrNilDeps) {
+		t.Fatalf("Expect error %+v, got %+v", constant.ErrNilDeps, err)
+	}
+
+	path := filepath.Join(t.TempDir(), "log")
+	cleanup, err := log.SetGlobalConfig(&log.Config{
+		Type:  "local",
+		Level: "debug",
+		Local: log.LocalConfig{Path: path, MaxSizeMB: 1},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer log.SetGlobalConfig(&log.Config{Type: "light", Level: "debug"}) // nolint:errcheck
+
+	cfg, err := encoding.LoadConfig[accesslog.Config](nil, encoding.TypeNil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.SampleRate != 1 || len(cfg.ExcludedPaths) != 3 || cfg.RequestIDHeader != "X-Request-Id" {
+		t.Fatalf("Unexpected default config %+v", cfg)
+	}
+	cfg.SampleRates = map[string]float64{"4xx": 0, "418": 1}
+	cfg.TrustProxy = true
+	mw, err := accesslog.NewMiddleware(cfg, accesslog.WithAttrs(func(r *http.Request, _ int) []slog.Attr {
+		return []slog.Attr{slog.String("user", r.Header.Get("X-User"))}
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Request-Id", "generated-id")
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte("user " + r.PathValue("id")))
+	})
+	mux.HandleFunc("/teapot", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	})
+	mux.HandleFunc("/healthz", func(http.ResponseWriter, *http.Request) {})
+	h := mw(mux)
+
+	traceID := trace.TraceID{1}
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: traceID,
+		SpanID:  trace.SpanID{1},
+	}))
+	r := httptest.NewRequestWithContext(ctx, http.MethodGet, "/users/42", nil)
+	r.Header.Set("X-Forwarded-For", "203.0.113.1, 10.0.0.1")
+	r.Header.Set("X-User", "alice")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	if w.Code != http.StatusCreated || w.Body.String() != "user 42" {
+		t.Fatalf("Unexpected response %d %s", w.Code, w.Body)
+	}
+	for _, target := range []string{"/healthz", "/not-found", "/teapot"} {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequestWithContext(ctx, http.MethodGet, target, nil))
+	}
+	cleanup()
+
+	content, err := os.ReadFile(path) // nolint:gosec
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := string(content)
+	if n := strings.Count(s, "Access."); n != 2 {
+		t.Fatalf("Expect 2 access logs, got %s", s)
+	}
+	for _, want := range []string{"/users/{id}", "/users/42", "201", "203.0.113.1", "generated-id", "alice",
+		traceID.String(), "418"} {
+		if !strings.Contains(s, want) {
+			t.Fatalf("Expect %s in access logs, got %s", want, s)
+		}
+	}
+	if strings.Contains(s, "/healthz") || strings.Contains(s, "/not-found") {
+		t.Fatalf("Unexpected access logs %s", s)
+	}
+}
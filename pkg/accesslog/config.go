@@ -0,0 +1,23 @@
+package accesslog
+
+// Config defines the config model for access logs.
+type Config struct {
+	// SampleRate is the fraction of requests to log, from 0 to 1, for statuses not listed in SampleRates.
+	SampleRate float64 `json:"sample_rate" yaml:"sample_rate" toml:"sample_rate" xml:"sample_rate" env:"ACCESS_LOG_SAMPLE_RATE" default:"1"` // nolint:lll
+
+	// SampleRates overrides SampleRate for specific statuses. A key is either a status code like "404" or a status
+	// class like "2xx", and status codes take precedence over status classes.
+	SampleRates map[string]float64 `json:"sample_rates" yaml:"sample_rates" toml:"sample_rates" xml:"sample_rates" env:"ACCESS_LOG_SAMPLE_RATES" default:"{}"` // nolint:lll
+
+	// ExcludedPaths are the URL paths that are never logged, such as health checks. A path ending with "/" matches all
+	// paths under it.
+	ExcludedPaths []string `json:"excluded_paths" yaml:"excluded_paths" toml:"excluded_paths" xml:"excluded_paths" env:"ACCESS_LOG_EXCLUDED_PATHS" default:"[\"/healthz\",\"/readyz\",\"/livez\"]"` // nolint:lll
+
+	// RequestIDHeader is the header that carries the request ID. It's read from the request, or from the response if
+	// the request ID is generated by the next handler.
+	RequestIDHeader string `json:"request_id_header" yaml:"request_id_header" toml:"request_id_header" xml:"request_id_header" env:"ACCESS_LOG_REQUEST_ID_HEADER" default:"X-Request-Id"` // nolint:lll
+
+	// TrustProxy indicates whether to take the client IP from the X-Forwarded-For and X-Real-IP headers. Enable it
+	// only if the service is behind a trusted reverse proxy, otherwise clients can spoof their IPs.
+	TrustProxy bool `json:"trust_proxy" yaml:"trust_proxy" toml:"trust_proxy" xml:"trust_proxy" env:"ACCESS_LOG_TRUST_PROXY" default:"false"` // nolint:lll
+}
@@ -0,0 +1,269 @@
+/*
+Package anonymize implements helpers to produce production-like but safe datasets, for example when dumping or exporting
+databases for development and analytics.
+
+It provides 3 kinds of helpers:
+
+  - Pseudonymization via [Anonymizer], which deterministically replaces identifiers with keyed pseudonyms, so that the
+    same value is replaced with the same pseudonym across tables and dumps and relations are kept.
+  - Masking via [Anonymizer.Preserve], [Anonymizer.Email] and [Mask], which keep the format of values, such as lengths,
+    letter cases and separators.
+  - k-anonymity via [GeneralizeInt], [KAnonymity] and [Suppress], which make sure that every record is
+    indistinguishable from at least k-1 others by its quasi-identifiers like age and ZIP code.
+
+For example, rows scanned via sqlx can be anonymized by columns:
+
+	a, err := anonymize.NewAnonymizer(&anonymize.Config{
+		Secret:        secret,
+		Columns:       map[string]string{"users.id": "pseudonym", "users.email": "email", "phone": "preserve"},
+		DefaultMethod: "null",
+	})
+	row := map[string]any{}
+	err = rows.MapScan(row)
+	a.Row("users", row)
+
+Pseudonyms are derived with HMAC-SHA256, so they can't be reversed or recomputed without the secret. Note that
+pseudonymized data may still be personal data if the secret is available, so keep the secret away from the datasets.
+*/
+package anonymize
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"strings"
+
+	"github.com/sainnhe/go-common/pkg/constant"
+)
+
+// Methods used in [Config.Columns].
+const (
+	// MethodKeep keeps the value as is.
+	MethodKeep = "keep"
+
+	// MethodNull replaces the value with nil.
+	MethodNull = "null"
+
+	// MethodPseudonym replaces strings with [Anonymizer.Pseudonym] and integers with [Anonymizer.PseudonymInt].
+	MethodPseudonym = "pseudonym"
+
+	// MethodPreserve replaces strings with [Anonymizer.Preserve].
+	MethodPreserve = "preserve"
+
+	// MethodEmail replaces strings with [Anonymizer.Email].
+	MethodEmail = "email"
+
+	// MethodMask replaces strings with [Mask] that keeps the last 4 characters.
+	MethodMask = "mask"
+)
+
+// maskKeep is the number of trailing characters kept by [MethodMask].
+const maskKeep = 4
+
+var (
+	// ErrEmptySecret indicates that the secret in config is empty.
+	ErrEmptySecret = errors.New("empty secret")
+
+	// ErrUnknownMethod indicates that a method in config is unknown.
+	ErrUnknownMethod = errors.New("unknown method")
+)
+
+// Anonymizer anonymizes values with a secret key. It's safe for concurrent use.
+type Anonymizer struct {
+	key           []byte
+	columns       map[string]string
+	defaultMethod string
+}
+
+// NewAnonymizer initializes a new [Anonymizer]. [constant.ErrNilDeps] is returned if cfg is nil, [ErrEmptySecret] is
+// returned if the secret is empty, and an error wrapping [ErrUnknownMethod] is returned if any method in
+// [Config.Columns] or [Config.DefaultMethod] is unknown. An empty [Config.DefaultMethod] means [MethodNull].
+func NewAnonymizer(cfg *Config) (*Anonymizer, error) {
+	if cfg == nil {
+		return nil, constant.ErrNilDeps
+	}
+	if cfg.Secret == "" {
+		return nil, ErrEmptySecret
+	}
+	for col, method := range cfg.Columns {
+		if !validMethod(method) {
+			return nil, fmt.Errorf("%w: %s for column %s", ErrUnknownMethod, method, col)
+		}
+	}
+	defaultMethod := cfg.DefaultMethod
+	if defaultMethod == "" {
+		defaultMethod = MethodNull
+	}
+	if !validMethod(defaultMethod) {
+		return nil, fmt.Errorf("%w: %s for default method", ErrUnknownMethod, defaultMethod)
+	}
+	return &Anonymizer{[]byte(cfg.Secret), cfg.Columns, defaultMethod}, nil
+}
+
+func validMethod(method string) bool {
+	switch method {
+	case MethodKeep, MethodNull, MethodPseudonym, MethodPreserve, MethodEmail, MethodMask:
+		return true
+	default:
+		return false
+	}
+}
+
+// Pseudonym returns the pseudonym of s, which is 16 hex characters. The same s always produces the same pseudonym.
+func (a *Anonymizer) Pseudonym(s string) string {
+	sum := a.sum("pseudonym", s)
+	return hex.EncodeToString(sum[:8])
+}
+
+// PseudonymInt returns the positive pseudonym of n, which can be used to replace IDs. The same n always produces the
+// same pseudonym, while different values may collide with a negligible probability.
+func (a *Anonymizer) PseudonymInt(n int64) int64 {
+	sum := a.sum("pseudonym_int", fmt.Sprint(n))
+	return max(int64(binary.BigEndian.Uint64(sum)>>1), 1) // nolint:gosec
+}
+
+/*
+Preserve replaces the characters of s deterministically while preserving its format: ASCII letters are replaced with
+letters of the same case, digits with digits, and CJK ideographs with CJK ideographs. Other characters like spaces and
+punctuations are kept. For example, "Alice Smith, +1 555-0100" may be replaced with "Qzmrt Hbvla, +7 031-9482".
+
+Unlike format-preserving encryption, the result can't be decrypted.
+*/
+func (a *Anonymizer) Preserve(s string) string {
+	st := &stream{mac: hmac.New(sha256.New, a.key), seed: "preserve\x00" + s}
+	var b strings.Builder
+	b.Grow(len(s))
+	for _, r := range s {
+		switch {
+		case r >= 'a' && r <= 'z':
+			r = 'a' + rune(st.next()%26) // nolint:mnd
+		case r >= 'A' && r <= 'Z':
+			r = 'A' + rune(st.next()%26) // nolint:mnd
+		case r >= '0' && r <= '9':
+			r = '0' + rune(st.next()%10) // nolint:mnd
+		case r >= cjkFirst && r <= cjkLast:
+			r = cjkFirst + rune(st.next()%(cjkLast-cjkFirst+1))
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// The range of CJK Unified Ideographs.
+const (
+	cjkFirst = 0x4E00
+	cjkLast  = 0x9FFF
+)
+
+// Email replaces the local part of an email address with [Anonymizer.Preserve] and keeps the domain, e.g.
+// "alice@example.com" may be replaced with "qzmrt@example.com". If s is not an email address, the whole string is
+// replaced.
+func (a *Anonymizer) Email(s string) string {
+	i := strings.LastIndexByte(s, '@')
+	if i < 0 {
+		return a.Preserve(s)
+	}
+	return a.Preserve(s[:i]) + s[i:]
+}
+
+/*
+Row anonymizes the columns of a row in place with the methods in [Config.Columns], which is useful for rows scanned via
+sqlx.Rows.MapScan. Columns that are not listed are anonymized with [Config.DefaultMethod]. The methods are:
+
+  - [MethodKeep]: Keep the value as is.
+  - [MethodNull]: Replace the value with nil.
+  - [MethodPseudonym]: Replace strings with [Anonymizer.Pseudonym] and integers with [Anonymizer.PseudonymInt].
+  - [MethodPreserve]: Replace strings with [Anonymizer.Preserve].
+  - [MethodEmail]: Replace strings with [Anonymizer.Email].
+  - [MethodMask]: Replace strings with [Mask] that keeps the last 4 characters.
+
+Byte slices are treated as strings, and nil values are kept. Values of other types are replaced with nil unless the
+method is [MethodKeep], so that unexpected types don't leak.
+*/
+func (a *Anonymizer) Row(tbl string, row map[string]any) {
+	for col, v := range row {
+		method, ok := a.columns[tbl+"."+col]
+		if !ok {
+			if method, ok = a.columns[col]; !ok {
+				method = a.defaultMethod
+			}
+		}
+		if method == MethodKeep || v == nil {
+			continue
+		}
+		row[col] = a.anonymize(method, v)
+	}
+}
+
+func (a *Anonymizer) anonymize(method string, v any) any {
+	if n, ok := v.(int64); ok && method == MethodPseudonym {
+		return a.PseudonymInt(n)
+	}
+	var s string
+	switch v := v.(type) {
+	case string:
+		s = v
+	case []byte:
+		s = string(v)
+	default:
+		return nil
+	}
+	switch method {
+	case MethodPseudonym:
+		return a.Pseudonym(s)
+	case MethodPreserve:
+		return a.Preserve(s)
+	case MethodEmail:
+		return a.Email(s)
+	case MethodMask:
+		return Mask(s, 0, maskKeep)
+	default:
+		return nil
+	}
+}
+
+// sum returns the HMAC of s in the given domain, so that different methods produce unrelated outputs.
+func (a *Anonymizer) sum(domain, s string) []byte {
+	mac := hmac.New(sha256.New, a.key)
+	mac.Write([]byte(domain + "\x00" + s))
+	return mac.Sum(nil)
+}
+
+// stream is a deterministic pseudo-random stream derived from HMAC in counter mode.
+type stream struct {
+	mac     hash.Hash
+	seed    string
+	buf     []byte
+	counter uint32
+}
+
+func (s *stream) next() uint32 {
+	if len(s.buf) < 4 { // nolint:mnd
+		s.mac.Reset()
+		s.mac.Write([]byte(s.seed))
+		s.mac.Write(binary.BigEndian.AppendUint32(nil, s.counter))
+		s.buf = s.mac.Sum(nil)
+		s.counter++
+	}
+	v := binary.BigEndian.Uint32(s.buf)
+	s.buf = s.buf[4:]
+	return v
+}
+
+// Mask replaces the characters of s with "*" except for the first keepPrefix and the last keepSuffix characters, e.g.
+// Mask("4111111111111111", 0, 4) returns "************1111", and Mask("94107", 3, 0) returns "941**". If s is not
+// longer than keepPrefix+keepSuffix, all characters are masked.
+func Mask(s string, keepPrefix, keepSuffix int) string {
+	runes := []rune(s)
+	if len(runes) <= keepPrefix+keepSuffix {
+		return strings.Repeat("*", len(runes))
+	}
+	for i := keepPrefix; i < len(runes)-keepSuffix; i++ {
+		runes[i] = '*'
+	}
+	return string(runes)
+}
@@ -0,0 +1,173 @@
+package anonymize_test
+
+import (
+	"errors"
+	"reflect"
+	"regexp"
+	"testing"
+
+	"github.com/sainnhe/go-common/pkg/anonymize"
+	"github.com/sainnhe/go-common/pkg/constant"
+)
+
+func newAnonymizer(t *testing.T, secret string, columns map[string]string) *anonymize.Anonymizer {
+	t.Helper()
+
+	a, err := anonymize.NewAnonymizer(&anonymize.Config{Secret: secret, Columns: columns})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return a
+}
+
+func TestNewAnonymizer(t *testing.T) {
+	t.Parallel()
+
+	if _, err := anonymize.NewAnonymizer(nil); !errors.Is(err, constant.ErrNilDeps) {
+		t.Fatalf("Expect error %+v, got %+v", constant.ErrNilDeps, err)
+	}
+	if _, err := anonymize.NewAnonymizer(&anonymize.Config{}); !errors.Is(err, anonymize.ErrEmptySecret) {
+		t.Fatalf("Expect error %+v, got %+v", anonymize.ErrEmptySecret, err)
+	}
+	cfg := &anonymize.Config{Secret: "foo", Columns: map[string]string{"a": "hash"}}
+	if _, err := anonymize.NewAnonymizer(cfg); !errors.Is(err, anonymize.ErrUnknownMethod) {
+		t.Fatalf("Expect error %+v, got %+v", anonymize.ErrUnknownMethod, err)
+	}
+	cfg = &anonymize.Config{Secret: "foo", DefaultMethod: "hash"}
+	if _, err := anonymize.NewAnonymizer(cfg); !errors.Is(err, anonymize.ErrUnknownMethod) {
+		t.Fatalf("Expect error %+v, got %+v", anonymize.ErrUnknownMethod, err)
+	}
+}
+
+func TestAnonymizer_Pseudonym(t *testing.T) {
+	t.Parallel()
+
+	a := newAnonymizer(t, "foo", nil)
+	b := newAnonymizer(t, "bar", nil)
+
+	p := a.Pseudonym("alice")
+	if !regexp.MustCompile(`^[0-9a-f]{16}$`).MatchString(p) {
+		t.Fatalf("Unexpected pseudonym %q", p)
+	}
+	if a.Pseudonym("alice") != p || a.Pseudonym("bob") == p || b.Pseudonym("alice") == p {
+		t.Fatal("Expect pseudonyms to be deterministic and keyed")
+	}
+
+	for _, n := range []int64{0, 1, -1, 42} {
+		got := a.PseudonymInt(n)
+		if got <= 0 || got != a.PseudonymInt(n) || got == b.PseudonymInt(n) {
+			t.Fatalf("Unexpected pseudonym %d of %d", got, n)
+		}
+	}
+}
+
+func TestAnonymizer_Preserve(t *testing.T) {
+	t.Parallel()
+
+	a := newAnonymizer(t, "foo", nil)
+	format := func(s string) string {
+		return regexp.MustCompile(`\p{Han}`).ReplaceAllString(regexp.MustCompile(`[0-9]`).ReplaceAllString(
+			regexp.MustCompile(`[A-Z]`).ReplaceAllString(regexp.MustCompile(`[a-z]`).ReplaceAllString(s, "a"), "A"),
+			"0"), "中")
+	}
+	for _, s := range []string{"", "Alice Smith, +1 555-0100", "张三 Road 42", "!@#"} {
+		got := a.Preserve(s)
+		if format(got) != format(s) || got != a.Preserve(s) {
+			t.Fatalf("Expect %q to preserve the format of %q", got, s)
+		}
+		if len(s) > 3 && got == s {
+			t.Fatalf("Expect %q to be replaced", s)
+		}
+	}
+
+	got := a.Email("Alice.Smith@example.com")
+	if !regexp.MustCompile(`^[A-Z][a-z]{4}\.[A-Z][a-z]{4}@example\.com$`).MatchString(got) ||
+		got == "Alice.Smith@example.com" {
+		t.Fatalf("Unexpected email %q", got)
+	}
+	if got := a.Email("alice"); got != a.Preserve("alice") {
+		t.Fatalf("Unexpected email %q", got)
+	}
+}
+
+func TestAnonymizer_Row(t *testing.T) {
+	t.Parallel()
+
+	a := newAnonymizer(t, "foo", map[string]string{
+		"users.id":    "pseudonym",
+		"id":          "keep",
+		"email":       "email",
+		"users.phone": "mask",
+		"password":    "null",
+		"name":        "preserve",
+		"token":       "pseudonym",
+		"age":         "preserve",
+	})
+	row := map[string]any{
+		"id":       int64(1),
+		"email":    []byte("alice@example.com"),
+		"phone":    "555-0100",
+		"password": "secret",
+		"name":     nil,
+		"token":    "abc",
+		"age":      30,
+		"other":    "unlisted",
+	}
+	a.Row("users", row)
+	want := map[string]any{
+		"id":       a.PseudonymInt(1),
+		"email":    a.Email("alice@example.com"),
+		"phone":    "****0100",
+		"password": nil,
+		"name":     nil,
+		"token":    a.Pseudonym("abc"),
+		"age":      nil,
+		"other":    nil,
+	}
+	if !reflect.DeepEqual(row, want) {
+		t.Fatalf("Expect %v, got %v", want, row)
+	}
+
+	row = map[string]any{"id": int64(1), "phone": "555-0100"}
+	a.Row("orders", row)
+	if !reflect.DeepEqual(row, map[string]any{"id": int64(1), "phone": nil}) {
+		t.Fatalf("Unexpected row %v", row)
+	}
+
+	// Unlisted columns can be kept explicitly.
+	a, err := anonymize.NewAnonymizer(&anonymize.Config{
+		Secret:        "foo",
+		Columns:       map[string]string{"email": "email"},
+		DefaultMethod: anonymize.MethodKeep,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	row = map[string]any{"email": "alice@example.com", "other": "kept"}
+	a.Row("users", row)
+	if !reflect.DeepEqual(row, map[string]any{"email": a.Email("alice@example.com"), "other": "kept"}) {
+		t.Fatalf("Unexpected row %v", row)
+	}
+}
+
+func TestMask(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		s      string
+		prefix int
+		suffix int
+		want   string
+	}{
+		{"4111111111111111", 0, 4, "************1111"},
+		{"94107", 3, 0, "941**"},
+		{"张三丰", 1, 0, "张**"},
+		{"abc", 2, 2, "***"},
+		{"", 0, 0, ""},
+	}
+	for _, tt := range tests {
+		if got := anonymize.Mask(tt.s, tt.prefix, tt.suffix); got != tt.want {
+			t.Fatalf("Expect %q, got %q", tt.want, got)
+		}
+	}
+}
@@ -0,0 +1,17 @@
+package anonymize
+
+// Config defines the config model for anonymize.
+type Config struct {
+	// Secret is the secret key of pseudonymization. The same secret always produces the same pseudonyms, so that
+	// relations between anonymized records are kept. It must not be empty.
+	Secret string `json:"secret" yaml:"secret" toml:"secret" xml:"secret" env:"ANONYMIZE_SECRET" default:"" secret:"true"` // nolint:lll
+
+	// Columns maps column names to the methods used by [Anonymizer.Row], where the keys are either "table.column" or
+	// "column", and the former takes precedence. Columns that are not listed are anonymized with DefaultMethod. See
+	// [Anonymizer.Row] for the methods.
+	Columns map[string]string `json:"columns" yaml:"columns" toml:"columns" xml:"columns" env:"ANONYMIZE_COLUMNS" default:"{}"` // nolint:lll
+
+	// DefaultMethod is the method used by [Anonymizer.Row] for columns that are not listed in Columns. Defaults to
+	// "null", so that newly added columns don't leak into anonymized datasets. Set to "keep" to keep them as is.
+	DefaultMethod string `json:"default_method" yaml:"default_method" toml:"default_method" xml:"default_method" env:"ANONYMIZE_DEFAULT_METHOD" default:"null"` // nolint:lll
+}
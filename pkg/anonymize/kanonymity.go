@@ -0,0 +1,63 @@
+package anonymize
+
+import (
+	"fmt"
+	"strings"
+)
+
+// GeneralizeInt generalizes n to the range of the given width that contains it, e.g. GeneralizeInt(34, 10) returns
+// "30-39", which is useful to reduce the precision of quasi-identifiers like ages. n is returned as is if width is not
+// positive.
+func GeneralizeInt(n, width int64) string {
+	if width <= 0 {
+		return fmt.Sprint(n)
+	}
+	lower := n / width * width
+	if n < 0 && n%width != 0 {
+		lower -= width
+	}
+	return fmt.Sprintf("%d-%d", lower, lower+width-1)
+}
+
+// KAnonymity returns k of the records, which is the size of the smallest equivalence class, i.e. the smallest group of
+// records that share the same quasi-identifiers returned by quasi. It returns 0 if there are no records.
+func KAnonymity[T any](records []T, quasi func(T) []string) int {
+	k := 0
+	for _, n := range classes(records, quasi) {
+		if k == 0 || n < k {
+			k = n
+		}
+	}
+	return k
+}
+
+// Suppress removes the records in equivalence classes smaller than k, so that the remaining records are k-anonymous.
+// The order of the remaining records is kept.
+func Suppress[T any](records []T, k int, quasi func(T) []string) []T {
+	counts := classes(records, quasi)
+	kept := make([]T, 0, len(records))
+	for _, r := range records {
+		if counts[classKey(quasi(r))] >= k {
+			kept = append(kept, r)
+		}
+	}
+	return kept
+}
+
+// classes returns the sizes of the equivalence classes keyed by [classKey].
+func classes[T any](records []T, quasi func(T) []string) map[string]int {
+	counts := map[string]int{}
+	for _, r := range records {
+		counts[classKey(quasi(r))]++
+	}
+	return counts
+}
+
+// classKey joins quasi-identifiers unambiguously.
+func classKey(values []string) string {
+	var b strings.Builder
+	for _, v := range values {
+		fmt.Fprintf(&b, "%d:%s", len(v), v)
+	}
+	return b.String()
+}
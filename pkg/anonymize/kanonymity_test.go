@@ -0,0 +1,63 @@
+package anonymize_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/sainnhe/go-common/pkg/anonymize"
+)
+
+func TestGeneralizeInt(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		n     int64
+		width int64
+		want  string
+	}{
+		{34, 10, "30-39"},
+		{30, 10, "30-39"},
+		{0, 5, "0-4"},
+		{-1, 10, "-10--1"},
+		{-10, 10, "-10--1"},
+		{7, 0, "7"},
+	}
+	for _, tt := range tests {
+		if got := anonymize.GeneralizeInt(tt.n, tt.width); got != tt.want {
+			t.Fatalf("Expect %q, got %q", tt.want, got)
+		}
+	}
+}
+
+func TestKAnonymity(t *testing.T) {
+	t.Parallel()
+
+	type person struct {
+		Age int64
+		Zip string
+	}
+	quasi := func(p person) []string {
+		return []string{anonymize.GeneralizeInt(p.Age, 10), anonymize.Mask(p.Zip, 3, 0)}
+	}
+	records := []person{{31, "94107"}, {35, "94110"}, {38, "94103"}, {42, "94107"}, {45, "94105"}, {52, "10001"}}
+
+	if k := anonymize.KAnonymity(records, quasi); k != 1 {
+		t.Fatalf("Expect k = 1, got %d", k)
+	}
+	if k := anonymize.KAnonymity([]person{}, quasi); k != 0 {
+		t.Fatalf("Expect k = 0, got %d", k)
+	}
+	kept := anonymize.Suppress(records, 2, quasi)
+	if !reflect.DeepEqual(kept, records[:5]) {
+		t.Fatalf("Unexpected records %v", kept)
+	}
+	if k := anonymize.KAnonymity(kept, quasi); k != 2 {
+		t.Fatalf("Expect k = 2, got %d", k)
+	}
+
+	// Quasi-identifiers are compared as a whole.
+	joined := func(s []string) []string { return s }
+	if k := anonymize.KAnonymity([][]string{{"a", "bc"}, {"ab", "c"}}, joined); k != 1 {
+		t.Fatalf("Expect k = 1, got %d", k)
+	}
+}
@@ -0,0 +1,274 @@
+/*
+Package bind populates structs from the query parameters, path parameters and headers of HTTP requests.
+
+Fields are bound according to their tags, and the "default" tag is applied when the parameter is missing, like the
+config loader in pkg/encoding:
+
+	type ListUsersRequest struct {
+		OrgID    int64     `path:"org_id"`
+		Status   []string  `query:"status"`
+		Since    time.Time `query:"since"`
+		PageSize int       `query:"page_size" default:"20"`
+		TraceID  string    `header:"X-Trace-Id"`
+	}
+
+	// GET /orgs/{org_id}/users?status=active,pending&since=2024-01-02T15:04:05Z
+	req, err := bind.Bind[ListUsersRequest](r)
+
+Supported field types are strings, booleans, numbers, [time.Time] in RFC 3339, [time.Duration], types implementing
+[encoding.TextUnmarshaler], pointers to them and slices of them. Slices are bound from repeated parameters like
+"?status=active&status=pending" or comma separated values like "?status=active,pending". Untagged struct fields are
+bound recursively.
+*/
+package bind
+
+import (
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrNotStruct indicates that the target is not a struct.
+	ErrNotStruct = errors.New("bind target must be a struct")
+
+	// ErrInvalidValue indicates that a parameter can't be parsed into the type of the field.
+	ErrInvalidValue = errors.New("invalid value")
+)
+
+// FieldError is the error of binding a field, which wraps [ErrInvalidValue].
+type FieldError struct {
+	// Field is the name of the struct field, where names of nested fields are joined with ".".
+	Field string
+
+	// Source is the source of the parameter, which is "query", "path", "header" or "default".
+	Source string
+
+	// Param is the name of the parameter.
+	Param string
+
+	// Value is the invalid value.
+	Value string
+
+	// Err is the parse error.
+	Err error
+}
+
+func (e *FieldError) Error() string {
+	return fmt.Sprintf("%s: %s parameter %q of field %s: %q: %v", ErrInvalidValue, e.Source, e.Param, e.Field, e.Value,
+		e.Err)
+}
+
+// Unwrap returns [ErrInvalidValue] and the parse error.
+func (e *FieldError) Unwrap() []error {
+	return []error{ErrInvalidValue, e.Err}
+}
+
+// sources are the tags of parameter sources, in the order of lookup.
+var sources = []string{"path", "query", "header"}
+
+/*
+Bind initializes a new T and populates it from r.
+
+Params:
+  - r: The request.
+
+Returns:
+  - *T: The populated struct.
+  - error: [ErrNotStruct] if T is not a struct, or the joined [FieldError] of all invalid fields.
+*/
+func Bind[T any](r *http.Request) (*T, error) {
+	v := new(T)
+	val := reflect.ValueOf(v).Elem()
+	if val.Kind() != reflect.Struct {
+		return nil, ErrNotStruct
+	}
+	if err := bindStruct(r, r.URL.Query(), val, ""); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// bindStruct binds the fields of val, where prefix is the path of val.
+func bindStruct(r *http.Request, query map[string][]string, val reflect.Value, prefix string) error {
+	var errs []error
+	typ := val.Type()
+	for i := range typ.NumField() {
+		field := typ.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name := prefix + field.Name
+		source, param, vals := lookup(r, query, field)
+		if len(vals) == 0 {
+			if def, ok := field.Tag.Lookup("default"); ok {
+				source, vals = "default", []string{def}
+			}
+		}
+		fieldVal := val.Field(i)
+		if len(vals) == 0 {
+			if len(source) == 0 && isNested(field.Type) {
+				if err := bindStruct(r, query, nestedStruct(fieldVal), name+"."); err != nil {
+					errs = append(errs, err)
+				}
+			}
+			continue
+		}
+		if err := setVal(fieldVal, vals); err != nil {
+			errs = append(errs, &FieldError{name, source, param, strings.Join(vals, ","), err})
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// lookup returns the source, the parameter name and the values of field. Sources are empty if field has no tag, and
+// values are empty if the parameter is missing.
+func lookup(r *http.Request, query map[string][]string, field reflect.StructField) (source, param string,
+	vals []string) {
+	for _, source := range sources {
+		param, ok := field.Tag.Lookup(source)
+		if !ok || len(param) == 0 || param == "-" {
+			continue
+		}
+		switch source {
+		case "path":
+			if val := r.PathValue(param); len(val) > 0 {
+				vals = []string{val}
+			}
+		case "query":
+			vals = query[param]
+		case "header":
+			vals = r.Header.Values(param)
+		}
+		return source, param, vals
+	}
+	return "", "", nil
+}
+
+var (
+	timeType            = reflect.TypeFor[time.Time]()
+	durationType        = reflect.TypeFor[time.Duration]()
+	textUnmarshalerType = reflect.TypeFor[encoding.TextUnmarshaler]()
+)
+
+// isNested reports whether typ is a struct or a pointer to struct that should be bound recursively.
+func isNested(typ reflect.Type) bool {
+	if typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+	return typ.Kind() == reflect.Struct && typ != timeType && !reflect.PointerTo(typ).Implements(textUnmarshalerType)
+}
+
+// nestedStruct returns the struct of val, allocating it if val is a nil pointer.
+func nestedStruct(val reflect.Value) reflect.Value {
+	if val.Kind() != reflect.Pointer {
+		return val
+	}
+	if val.IsNil() {
+		val.Set(reflect.New(val.Type().Elem()))
+	}
+	return val.Elem()
+}
+
+// setVal parses vals and sets them to field. Slices are set from all values split by commas, or from a JSON array, and
+// other types are set from the first value.
+func setVal(field reflect.Value, vals []string) error {
+	if field.Kind() != reflect.Slice || reflect.PointerTo(field.Type()).Implements(textUnmarshalerType) {
+		return setScalar(field, vals[0])
+	}
+	if len(vals) == 1 && strings.HasPrefix(vals[0], "[") {
+		// JSON arrays are accepted for compatibility with the "default" tag of configs.
+		target := reflect.New(field.Type())
+		if err := json.Unmarshal([]byte(vals[0]), target.Interface()); err != nil {
+			return err
+		}
+		field.Set(target.Elem())
+		return nil
+	}
+	var items []string
+	for _, val := range vals {
+		for item := range strings.SplitSeq(val, ",") {
+			if item = strings.TrimSpace(item); len(item) > 0 {
+				items = append(items, item)
+			}
+		}
+	}
+	slice := reflect.MakeSlice(field.Type(), len(items), len(items))
+	for i, item := range items {
+		if err := setScalar(slice.Index(i), item); err != nil {
+			return err
+		}
+	}
+	field.Set(slice)
+	return nil
+}
+
+// setScalar parses val and sets it to field.
+func setScalar(field reflect.Value, val string) error {
+	if field.Kind() == reflect.Pointer {
+		ptr := reflect.New(field.Type().Elem())
+		if err := setScalar(ptr.Elem(), val); err != nil {
+			return err
+		}
+		field.Set(ptr)
+		return nil
+	}
+	if u, ok := field.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return u.UnmarshalText([]byte(val))
+	}
+
+	switch field.Type() {
+	case timeType:
+		t, err := time.Parse(time.RFC3339, val)
+		if err != nil {
+			return err
+		}
+		field.Set(reflect.ValueOf(t))
+		return nil
+	case durationType:
+		d, err := time.ParseDuration(val)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(d))
+		return nil
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(val)
+	case reflect.Bool:
+		boolVal, err := strconv.ParseBool(val)
+		if err != nil {
+			return err
+		}
+		field.SetBool(boolVal)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		intVal, err := strconv.ParseInt(val, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetInt(intVal)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		uintVal, err := strconv.ParseUint(val, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetUint(uintVal)
+	case reflect.Float32, reflect.Float64:
+		floatVal, err := strconv.ParseFloat(val, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetFloat(floatVal)
+	default:
+		return fmt.Errorf("unsupported type %s", field.Type())
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package bind_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/sainnhe/go-common/pkg/bind"
+)
+
+type page struct {
+	Size   int    `query:"page_size" default:"20"`
+	Cursor *int64 `query:"cursor"`
+}
+
+type request struct {
+	OrgID   int64         `path:"org_id"`
+	Status  []string      `query:"status"`
+	Tags    []string      `query:"tag" default:"[\"a\",\"b\"]"`
+	Since   time.Time     `query:"since"`
+	Timeout time.Duration `query:"timeout" default:"5s"`
+	Debug   *bool         `query:"debug"`
+	IP      net.IP        `header:"X-Real-Ip"`
+	TraceID string        `header:"X-Trace-Id"`
+	Page    page
+	ignored string `query:"ignored"`
+}
+
+func serve(t *testing.T, target string, header http.Header) (req *request, err error) {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /orgs/{org_id}/users", func(_ http.ResponseWriter, r *http.Request) {
+		req, err = bind.Bind[request](r)
+	})
+	r := httptest.NewRequestWithContext(context.Background(), http.MethodGet, target, nil)
+	r.Header = header
+	mux.ServeHTTP(httptest.NewRecorder(), r)
+	return
+}
+
+func TestBind(t *testing.T) {
+	t.Parallel()
+
+	req, err := serve(t, "/orgs/42/users?status=active,pending&status=banned&since=2024-01-02T15:04:05Z&debug=true"+
+		"&cursor=7&ignored=x", http.Header{"X-Real-Ip": {"203.0.113.1"}, "X-Trace-Id": {"abc"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if req.OrgID != 42 || !slices.Equal(req.Status, []string{"active", "pending", "banned"}) ||
+		!slices.Equal(req.Tags, []string{"a", "b"}) || req.Since.Year() != 2024 || req.Timeout != 5*time.Second ||
+		req.Debug == nil || !*req.Debug || req.IP.String() != "203.0.113.1" || req.TraceID != "abc" ||
+		req.Page.Size != 20 || req.Page.Cursor == nil || *req.Page.Cursor != 7 || len(req.ignored) > 0 {
+		t.Fatalf("Unexpected request %+v", req)
+	}
+
+	_, err = serve(t, "/orgs/foo/users?since=yesterday&page_size=x", http.Header{})
+	if !errors.Is(err, bind.ErrInvalidValue) {
+		t.Fatalf("Want %+v, got %+v", bind.ErrInvalidValue, err)
+	}
+	var fieldErr *bind.FieldError
+	if !errors.As(err, &fieldErr) || fieldErr.Field != "OrgID" || fieldErr.Source != "path" || fieldErr.Value != "foo" {
+		t.Fatalf("Unexpected error %+v", fieldErr)
+	}
+	if joined, ok := err.(interface{ Unwrap() []error }); !ok || len(joined.Unwrap()) != 3 {
+		t.Fatalf("Expect 3 errors, got %+v", err)
+	}
+
+	if _, err := bind.Bind[int](httptest.NewRequestWithContext(context.Background(), http.MethodGet, "/",
+		nil)); !errors.Is(err, bind.ErrNotStruct) {
+		t.Fatalf("Want %+v, got %+v", bind.ErrNotStruct, err)
+	}
+}
@@ -0,0 +1,181 @@
+// Package bufpool implements size-classed pools of byte buffers and builders, which reduces GC pressure of code that
+// frequently builds short-lived byte slices or strings, for example log records and encoded payloads.
+//
+// Buffers are grouped into size classes from [MinSize] to [MaxSize]. [Get] and [GetBuilder] return a buffer whose
+// capacity is at least the given size hint, and [Put] and [PutBuilder] return it to the class that fits its capacity,
+// so a buffer that grew while in use will be reused for larger requests later. Buffers larger than [MaxSize] are not
+// pooled to avoid holding huge buffers forever.
+//
+// Use [CheckLeaks] in tests to verify that every buffer is returned to the pool.
+package bufpool
+
+import (
+	"bytes"
+	"sync"
+	"unicode/utf8"
+)
+
+const (
+	// MinSize is the capacity of the smallest size class.
+	MinSize = 256
+
+	// MaxSize is the capacity of the largest size class. Buffers larger than this are not pooled.
+	MaxSize = 64 << 10
+)
+
+// classSizes are the capacities of size classes.
+var classSizes = [...]int{MinSize, 1 << 10, 4 << 10, 16 << 10, MaxSize}
+
+var (
+	bufferPools  [len(classSizes)]sync.Pool
+	builderPools [len(classSizes)]sync.Pool
+)
+
+// getClass returns the index of the smallest size class that can hold size bytes, or -1 if size exceeds [MaxSize].
+func getClass(size int) int {
+	for i, classSize := range classSizes {
+		if size <= classSize {
+			return i
+		}
+	}
+	return -1
+}
+
+// putClass returns the index of the largest size class that a buffer with the given capacity can serve, or -1 if the
+// buffer shouldn't be pooled.
+func putClass(capacity int) int {
+	if capacity > MaxSize {
+		return -1
+	}
+	for i := len(classSizes) - 1; i >= 0; i-- {
+		if capacity >= classSizes[i] {
+			return i
+		}
+	}
+	return -1
+}
+
+// Get returns an empty buffer whose capacity is at least size. The buffer should be returned via [Put] when it's no
+// longer used.
+func Get(size int) *bytes.Buffer {
+	var buf *bytes.Buffer
+	if i := getClass(size); i < 0 {
+		buf = bytes.NewBuffer(make([]byte, 0, size))
+	} else if v, ok := bufferPools[i].Get().(*bytes.Buffer); ok {
+		buf = v
+	} else {
+		buf = bytes.NewBuffer(make([]byte, 0, classSizes[i]))
+	}
+	track(buf)
+	return buf
+}
+
+// Put resets the buffer and returns it to the pool. The buffer and the slices returned by its methods must not be used
+// after calling this function.
+func Put(buf *bytes.Buffer) {
+	if buf == nil {
+		return
+	}
+	untrack(buf)
+	i := putClass(buf.Cap())
+	if i < 0 {
+		return
+	}
+	buf.Reset()
+	bufferPools[i].Put(buf)
+}
+
+// Builder is an append-only byte buffer used to build strings and byte slices. Unlike [strings.Builder], it can be
+// reused after [Builder.String] is called, which makes it suitable for pooling.
+type Builder struct {
+	buf []byte
+}
+
+// GetBuilder returns an empty builder whose capacity is at least size. The builder should be returned via
+// [PutBuilder] when it's no longer used.
+func GetBuilder(size int) *Builder {
+	var b *Builder
+	if i := getClass(size); i < 0 {
+		b = &Builder{make([]byte, 0, size)}
+	} else if v, ok := builderPools[i].Get().(*Builder); ok {
+		b = v
+	} else {
+		b = &Builder{make([]byte, 0, classSizes[i])}
+	}
+	track(b)
+	return b
+}
+
+// PutBuilder resets the builder and returns it to the pool. The builder and the slices returned by [Builder.Bytes]
+// must not be used after calling this function.
+func PutBuilder(b *Builder) {
+	if b == nil {
+		return
+	}
+	untrack(b)
+	i := putClass(cap(b.buf))
+	if i < 0 {
+		return
+	}
+	b.buf = b.buf[:0]
+	builderPools[i].Put(b)
+}
+
+// Write appends p to the builder. It always returns len(p) and a nil error.
+func (b *Builder) Write(p []byte) (int, error) {
+	b.buf = append(b.buf, p...)
+	return len(p), nil
+}
+
+// WriteString appends s to the builder. It always returns len(s) and a nil error.
+func (b *Builder) WriteString(s string) (int, error) {
+	b.buf = append(b.buf, s...)
+	return len(s), nil
+}
+
+// WriteByte appends c to the builder. It always returns a nil error.
+func (b *Builder) WriteByte(c byte) error {
+	b.buf = append(b.buf, c)
+	return nil
+}
+
+// WriteRune appends the UTF-8 encoding of r to the builder. It always returns a nil error.
+func (b *Builder) WriteRune(r rune) (int, error) {
+	n := len(b.buf)
+	b.buf = utf8.AppendRune(b.buf, r)
+	return len(b.buf) - n, nil
+}
+
+// Append calls f with the underlying byte slice and replaces it with the returned one, which allows append-style
+// functions like [strconv.AppendInt] to write to the builder without allocation, e.g.
+//
+//	b.Append(func(buf []byte) []byte { return strconv.AppendInt(buf, 42, 10) })
+func (b *Builder) Append(f func(buf []byte) []byte) {
+	b.buf = f(b.buf)
+}
+
+// Len returns the number of bytes written.
+func (b *Builder) Len() int {
+	return len(b.buf)
+}
+
+// Cap returns the capacity of the underlying byte slice.
+func (b *Builder) Cap() int {
+	return cap(b.buf)
+}
+
+// Reset discards the written bytes but keeps the underlying storage.
+func (b *Builder) Reset() {
+	b.buf = b.buf[:0]
+}
+
+// Bytes returns the written bytes. The slice is only valid until the next modification or [PutBuilder].
+func (b *Builder) Bytes() []byte {
+	return b.buf
+}
+
+// String returns a copy of the written bytes as a string, which is still valid after the builder is returned to the
+// pool.
+func (b *Builder) String() string {
+	return string(b.buf)
+}
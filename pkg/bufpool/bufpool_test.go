@@ -0,0 +1,141 @@
+package bufpool_test
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/sainnhe/go-common/pkg/bufpool"
+)
+
+type fakeTB struct {
+	cleanups []func()
+	errs     []string
+}
+
+func (*fakeTB) Helper() {}
+
+func (tb *fakeTB) Cleanup(f func()) {
+	tb.cleanups = append(tb.cleanups, f)
+}
+
+func (tb *fakeTB) Errorf(format string, args ...any) {
+	tb.errs = append(tb.errs, fmt.Sprintf(format, args...))
+}
+
+func (tb *fakeTB) finish() {
+	for i := len(tb.cleanups) - 1; i >= 0; i-- {
+		tb.cleanups[i]()
+	}
+}
+
+func TestGet(t *testing.T) { // nolint:paralleltest
+	bufpool.CheckLeaks(t)
+
+	tests := []struct {
+		size   int
+		minCap int
+	}{
+		{0, bufpool.MinSize},
+		{bufpool.MinSize + 1, bufpool.MinSize + 1},
+		{bufpool.MaxSize, bufpool.MaxSize},
+		{bufpool.MaxSize + 1, bufpool.MaxSize + 1},
+	}
+
+	for _, tt := range tests {
+		buf := bufpool.Get(tt.size)
+		if buf.Len() != 0 || buf.Cap() < tt.minCap {
+			t.Errorf("Get(%d): expect empty buffer with cap >= %d, got len = %d, cap = %d",
+				tt.size, tt.minCap, buf.Len(), buf.Cap())
+		}
+		buf.WriteString("foo")
+		bufpool.Put(buf)
+
+		b := bufpool.GetBuilder(tt.size)
+		if b.Len() != 0 || b.Cap() < tt.minCap {
+			t.Errorf("GetBuilder(%d): expect empty builder with cap >= %d, got len = %d, cap = %d",
+				tt.size, tt.minCap, b.Len(), b.Cap())
+		}
+		b.WriteString("foo") // nolint:errcheck
+		bufpool.PutBuilder(b)
+	}
+
+	// Returning nil should be a no-op.
+	bufpool.Put(nil)
+	bufpool.PutBuilder(nil)
+}
+
+func TestBuilder(t *testing.T) { // nolint:paralleltest
+	bufpool.CheckLeaks(t)
+
+	b := bufpool.GetBuilder(0)
+	defer bufpool.PutBuilder(b)
+
+	b.WriteString("foo") // nolint:errcheck
+	b.WriteByte(' ')     // nolint:errcheck
+	b.Write([]byte("b")) // nolint:errcheck
+	b.WriteRune('ä')     // nolint:errcheck
+	b.WriteByte(' ')     // nolint:errcheck
+	b.Append(func(buf []byte) []byte {
+		return strconv.AppendInt(buf, 42, 10)
+	})
+	if s, expected := b.String(), "foo bä 42"; s != expected {
+		t.Fatalf("Expect %q, got %q", expected, s)
+	}
+	if string(b.Bytes()) != b.String() || b.Len() != len(b.String()) {
+		t.Fatalf("Bytes() and Len() are inconsistent with String()")
+	}
+
+	s := b.String()
+	b.Reset()
+	if b.Len() != 0 {
+		t.Fatalf("Expect empty builder after Reset, got len = %d", b.Len())
+	}
+	b.WriteString("bar") // nolint:errcheck
+	if s != "foo bä 42" {
+		t.Fatalf("String result is modified after reusing the builder: %q", s)
+	}
+}
+
+func TestCheckLeaks(t *testing.T) { // nolint:paralleltest
+	tb := &fakeTB{}
+	bufpool.CheckLeaks(tb)
+
+	returned := bufpool.Get(0)
+	bufpool.Put(returned)
+	leaked := bufpool.Get(0)
+	leakedBuilder := bufpool.GetBuilder(0)
+
+	tb.finish()
+	if len(tb.errs) != 2 { // nolint:mnd
+		t.Fatalf("Expect 2 leaks, got %d: %v", len(tb.errs), tb.errs)
+	}
+	if !strings.Contains(tb.errs[0], "TestCheckLeaks") {
+		t.Errorf("Expect stack trace to contain the caller, got %s", tb.errs[0])
+	}
+
+	// Buffers returned after leak detection is disabled shouldn't cause any problem.
+	bufpool.Put(leaked)
+	bufpool.PutBuilder(leakedBuilder)
+
+	// Buffers taken before calling CheckLeaks shouldn't be reported.
+	early := bufpool.Get(0)
+	tb = &fakeTB{}
+	bufpool.CheckLeaks(tb)
+	tb.finish()
+	bufpool.Put(early)
+	if len(tb.errs) != 0 {
+		t.Fatalf("Expect no leaks, got %v", tb.errs)
+	}
+}
+
+func BenchmarkBuilder(b *testing.B) {
+	b.ReportAllocs()
+	for range b.N {
+		buf := bufpool.GetBuilder(0)
+		buf.WriteString("key=") // nolint:errcheck
+		buf.Append(func(p []byte) []byte { return strconv.AppendInt(p, int64(b.N), 10) })
+		bufpool.PutBuilder(buf)
+	}
+}
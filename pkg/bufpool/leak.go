@@ -0,0 +1,98 @@
+package bufpool
+
+import (
+	"cmp"
+	"runtime/debug"
+	"slices"
+	"sync"
+	"sync/atomic"
+)
+
+// TB is the subset of [testing.TB] used by [CheckLeaks].
+type TB interface {
+	Helper()
+	Cleanup(f func())
+	Errorf(format string, args ...any)
+}
+
+// leak is a buffer that has been taken from the pool but not returned yet.
+type leak struct {
+	seq   uint64
+	stack string
+}
+
+// tracker records the buffers taken from the pool while leak detection is enabled.
+var tracker struct {
+	enabled atomic.Int32
+	mu      sync.Mutex
+	seq     uint64
+	live    map[any]leak
+}
+
+/*
+CheckLeaks enables leak detection until the test finishes, and reports an error for every buffer or builder that was
+taken from the pools after this call but not returned when the test finishes, along with the stack trace where it was
+taken.
+
+Since the pools are global, buffers taken by other tests running at the same time are tracked as well, so tests that
+call this function shouldn't run in parallel with other tests that use the pools.
+
+Leak detection has no overhead other than an atomic load when it's not enabled.
+
+Params:
+  - t [TB]: The test, which is usually a [testing.TB].
+*/
+func CheckLeaks(t TB) {
+	t.Helper()
+
+	tracker.mu.Lock()
+	if tracker.enabled.Add(1) == 1 {
+		tracker.live = map[any]leak{}
+	}
+	start := tracker.seq
+	tracker.mu.Unlock()
+
+	t.Cleanup(func() {
+		tracker.mu.Lock()
+		var leaks []leak
+		for _, l := range tracker.live {
+			if l.seq > start {
+				leaks = append(leaks, l)
+			}
+		}
+		if tracker.enabled.Add(-1) == 0 {
+			tracker.live = nil
+		}
+		tracker.mu.Unlock()
+
+		slices.SortFunc(leaks, func(a, b leak) int {
+			return cmp.Compare(a.seq, b.seq)
+		})
+		for _, l := range leaks {
+			t.Errorf("Buffer is not returned to the pool, taken at:\n%s", l.stack)
+		}
+	})
+}
+
+func track(p any) {
+	if tracker.enabled.Load() == 0 {
+		return
+	}
+	stack := string(debug.Stack())
+	tracker.mu.Lock()
+	defer tracker.mu.Unlock()
+	if tracker.live == nil {
+		return
+	}
+	tracker.seq++
+	tracker.live[p] = leak{tracker.seq, stack}
+}
+
+func untrack(p any) {
+	if tracker.enabled.Load() == 0 {
+		return
+	}
+	tracker.mu.Lock()
+	defer tracker.mu.Unlock()
+	delete(tracker.live, p)
+}
@@ -0,0 +1,229 @@
+/*
+Package bulk implements batch operations with partial success semantics, where each item of a batch succeeds or fails
+independently.
+
+[Do] runs the items through a bounded worker pool and collects the result of each item, and [NewHandler] exposes it as
+an HTTP endpoint that accepts a JSON array and responds with 207 Multi-Status:
+
+	h, err := bulk.NewHandler(cfg, func(ctx context.Context, req *CreateUserRequest) (*User, error) {
+		if len(req.Name) == 0 {
+			return nil, bulk.Error(http.StatusBadRequest, errors.New("name is required"))
+		}
+		return s.CreateUser(ctx, req)
+	})
+	mux.Handle("POST /users:batchCreate", h)
+
+The response body looks like:
+
+	{
+		"results": [
+			{"index": 0, "status": 200, "data": {"id": 1, "name": "Alice"}},
+			{"index": 1, "status": 400, "error": "name is required"}
+		],
+		"succeeded": 1,
+		"failed": 1
+	}
+*/
+package bulk
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/sainnhe/go-common/pkg/concurrent"
+	"github.com/sainnhe/go-common/pkg/constant"
+	"github.com/sainnhe/go-common/pkg/log"
+	"github.com/sainnhe/go-common/pkg/respond"
+)
+
+const pkgName = "github.com/sainnhe/go-common/pkg/bulk"
+
+var (
+	// ErrEmptyBatch indicates that a batch has no items.
+	ErrEmptyBatch = errors.New("empty batch")
+
+	// ErrBatchTooLarge indicates that a batch has more items than [Config.MaxBatchSize].
+	ErrBatchTooLarge = errors.New("batch too large")
+)
+
+// StatusError is an error with the HTTP status of an item.
+type StatusError struct {
+	Status int
+	Err    error
+}
+
+// Error returns a [StatusError], which sets the status and the error message of the item that fails with it. Items
+// failing with other errors have the status 500, and their messages are replaced by the status text so that internal
+// details are not leaked to clients.
+func Error(status int, err error) error {
+	return &StatusError{status, err}
+}
+
+func (e *StatusError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *StatusError) Unwrap() error {
+	return e.Err
+}
+
+// Result is the result of an item.
+type Result[Resp any] struct {
+	// Index is the index of the item in the request.
+	Index int `json:"index"`
+
+	// Status is the HTTP status of the item.
+	Status int `json:"status"`
+
+	// Data is the response of the item if it succeeds.
+	Data Resp `json:"data,omitzero"`
+
+	// Error is the error message of the item if it fails.
+	Error string `json:"error,omitempty"`
+}
+
+// Response is the aggregated results of a batch.
+type Response[Resp any] struct {
+	// Results are the results of the items in the order of the request.
+	Results []Result[Resp] `json:"results"`
+
+	// Succeeded is the number of succeeded items.
+	Succeeded int `json:"succeeded"`
+
+	// Failed is the number of failed items.
+	Failed int `json:"failed"`
+}
+
+/*
+Do processes the items concurrently with f and aggregates the results.
+
+Items that have not started when ctx is done fail with the error of ctx, and panics in f are recovered as failures with
+the status 500.
+
+Params:
+  - ctx: The context.
+  - cfg: The config.
+  - items: The items.
+  - f: The function that processes an item. Return an error created by [Error] to set the status of a failed item.
+
+Returns:
+  - *Response[Resp]: The aggregated results.
+  - error: [constant.ErrNilDeps] if cfg or f is nil, [ErrEmptyBatch] if there are no items, or [ErrBatchTooLarge] if
+    there are more than [Config.MaxBatchSize] items.
+*/
+func Do[Req, Resp any](ctx context.Context, cfg *Config, items []Req,
+	f func(ctx context.Context, item Req) (Resp, error)) (*Response[Resp], error) {
+	if cfg == nil || f == nil {
+		return nil, constant.ErrNilDeps
+	}
+	if len(items) == 0 {
+		return nil, ErrEmptyBatch
+	}
+	if cfg.MaxBatchSize > 0 && len(items) > cfg.MaxBatchSize {
+		return nil, fmt.Errorf("%w: %d items exceed the limit %d", ErrBatchTooLarge, len(items), cfg.MaxBatchSize)
+	}
+
+	type indexed struct {
+		i    int
+		item Req
+	}
+	args := make([]indexed, len(items))
+	for i, item := range items {
+		args[i] = indexed{i, item}
+	}
+	l := log.NewLogger(pkgName)
+	results := concurrent.Run(max(cfg.Concurrency, 1), args, func(arg indexed) (result Result[Resp]) {
+		result.Index = arg.i
+		defer func() {
+			if r := recover(); r != nil {
+				l.ErrorContext(ctx, "Bulk item panicked.", "index", arg.i, constant.LogAttrError, r)
+				result.Status = http.StatusInternalServerError
+				result.Error = http.StatusText(http.StatusInternalServerError)
+			}
+		}()
+
+		err := ctx.Err()
+		if err == nil {
+			result.Data, err = f(ctx, arg.item)
+		}
+		result.Status = http.StatusOK
+		if err != nil {
+			var zero Resp
+			result.Data = zero
+			result.Status = http.StatusInternalServerError
+			result.Error = http.StatusText(http.StatusInternalServerError)
+			var statusErr *StatusError
+			if errors.As(err, &statusErr) {
+				result.Status = statusErr.Status
+				result.Error = statusErr.Error()
+			} else {
+				l.ErrorContext(ctx, "Bulk item failed.", "index", arg.i, constant.LogAttrError, err)
+			}
+		}
+		return
+	})
+
+	resp := &Response[Resp]{Results: results}
+	for _, result := range results {
+		if result.Status < http.StatusBadRequest {
+			resp.Succeeded++
+		} else {
+			resp.Failed++
+		}
+	}
+	return resp, nil
+}
+
+/*
+NewHandler initializes a handler that decodes a JSON array of items from the request body, processes them with [Do],
+and responds with 207 Multi-Status and [Response] in JSON.
+
+Requests whose bodies can't be decoded or have no items are responded with 400 Bad Request, and requests with too many
+items or too large bodies are responded with 413 Content Too Large, see [respond.Error].
+
+Params:
+  - cfg: The config.
+  - f: The function that processes an item.
+
+Returns:
+  - http.Handler: The handler.
+  - error: [constant.ErrNilDeps] if cfg or f is nil.
+*/
+func NewHandler[Req, Resp any](cfg *Config, f func(ctx context.Context, item Req) (Resp, error)) (http.Handler,
+	error) {
+	if cfg == nil || f == nil {
+		return nil, constant.ErrNilDeps
+	}
+	l := log.NewLogger(pkgName)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body := r.Body
+		if cfg.MaxBodyBytes > 0 {
+			body = http.MaxBytesReader(w, body, cfg.MaxBodyBytes)
+		}
+		var items []Req
+		if err := json.NewDecoder(body).Decode(&items); err != nil {
+			if maxBytesErr := (*http.MaxBytesError)(nil); errors.As(err, &maxBytesErr) {
+				_ = respond.Error(w, r, http.StatusRequestEntityTooLarge, err)
+				return
+			}
+			_ = respond.Error(w, r, http.StatusBadRequest, err)
+			return
+		}
+
+		resp, err := Do(r.Context(), cfg, items, f)
+		switch {
+		case errors.Is(err, ErrBatchTooLarge):
+			_ = respond.Error(w, r, http.StatusRequestEntityTooLarge, err)
+			return
+		case err != nil:
+			_ = respond.Error(w, r, http.StatusBadRequest, err)
+			return
+		}
+		if err := respond.JSON(w, r, http.StatusMultiStatus, resp); err != nil {
+			l.ErrorContext(r.Context(), "Write bulk response failed.", constant.LogAttrError, err)
+		}
+	}), nil
+}
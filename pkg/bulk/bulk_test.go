@@ -0,0 +1,110 @@
+package bulk_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sainnhe/go-common/pkg/bulk"
+	"github.com/sainnhe/go-common/pkg/constant"
+	"github.com/sainnhe/go-common/pkg/encoding"
+)
+
+func double(_ context.Context, n int) (int, error) {
+	switch {
+	case n < 0:
+		return 0, bulk.Error(http.StatusBadRequest, errors.New("negative"))
+	case n == 0:
+		panic("zero")
+	case n > 1000:
+		return 0, bulk.Error(http.StatusServiceUnavailable, errors.New("unavailable"))
+	case n > 100:
+		return 0, errors.New("too big")
+	}
+	return 2 * n, nil
+}
+
+func TestDo(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	cfg, err := encoding.LoadConfig[bulk.Config](nil, encoding.TypeNil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.MaxBatchSize != 100 || cfg.Concurrency != 8 {
+		t.Fatalf("Unexpected default config %+v", cfg)
+	}
+
+	if _, err := bulk.Do[int, int](ctx, nil, nil, double); !errors.Is(err, constant.ErrNilDeps) {
+		t.Fatalf("Want %+v, got %+v", constant.ErrNilDeps, err)
+	}
+	if _, err := bulk.Do(ctx, cfg, []int{}, double); !errors.Is(err, bulk.ErrEmptyBatch) {
+		t.Fatalf("Want %+v, got %+v", bulk.ErrEmptyBatch, err)
+	}
+	if _, err := bulk.Do(ctx, cfg, make([]int, 101), double); !errors.Is(err, bulk.ErrBatchTooLarge) {
+		t.Fatalf("Want %+v, got %+v", bulk.ErrBatchTooLarge, err)
+	}
+
+	resp, err := bulk.Do(ctx, cfg, []int{1, -1, 0, 200, 3, 2000}, double)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantStatus := []int{200, 400, 500, 500, 200, 503}
+	for i, result := range resp.Results {
+		if result.Index != i || result.Status != wantStatus[i] {
+			t.Fatalf("Unexpected result %d: %+v", i, result)
+		}
+	}
+	if resp.Results[4].Data != 6 || resp.Results[3].Error != http.StatusText(http.StatusInternalServerError) ||
+		resp.Results[5].Error != "unavailable" || resp.Succeeded != 2 || resp.Failed != 4 {
+		t.Fatalf("Unexpected response %+v", resp)
+	}
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	if resp, err = bulk.Do(canceled, cfg, []int{1}, double); err != nil || resp.Failed != 1 {
+		t.Fatalf("Unexpected response %+v, %+v", resp, err)
+	}
+}
+
+func TestNewHandler(t *testing.T) {
+	t.Parallel()
+
+	if _, err := bulk.NewHandler[int, int](nil, double); !errors.Is(err, constant.ErrNilDeps) {
+		t.Fatalf("Want %+v, got %+v", constant.ErrNilDeps, err)
+	}
+	h, err := bulk.NewHandler(&bulk.Config{MaxBatchSize: 3, Concurrency: 2, MaxBodyBytes: 32}, double)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name   string
+		body   string
+		status int
+		want   string
+	}{
+		{"Partial success", "[1, -1]", http.StatusMultiStatus, `{"results":[{"index":0,"status":200,"data":2},` +
+			`{"index":1,"status":400,"error":"negative"}],"succeeded":1,"failed":1}`},
+		{"Invalid body", "{", http.StatusBadRequest, ""},
+		{"Empty batch", "[]", http.StatusBadRequest, ""},
+		{"Too many items", "[1, 2, 3, 4]", http.StatusRequestEntityTooLarge, ""},
+		{"Too large body", "[" + strings.Repeat(" ", 32) + "1]", http.StatusRequestEntityTooLarge, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := httptest.NewRequestWithContext(context.Background(), http.MethodPost, "/", strings.NewReader(tt.body))
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+			if w.Code != tt.status || (len(tt.want) > 0 && w.Body.String() != tt.want) {
+				t.Fatalf("Unexpected response %d %s", w.Code, w.Body)
+			}
+		})
+	}
+}
@@ -0,0 +1,13 @@
+package bulk
+
+// Config defines the config model for bulk requests.
+type Config struct {
+	// MaxBatchSize is the maximum number of items in a request. Larger requests are rejected as a whole.
+	MaxBatchSize int `json:"max_batch_size" yaml:"max_batch_size" toml:"max_batch_size" xml:"max_batch_size" env:"BULK_MAX_BATCH_SIZE" default:"100"` // nolint:lll
+
+	// Concurrency is the maximum number of items processed concurrently in a request.
+	Concurrency int32 `json:"concurrency" yaml:"concurrency" toml:"concurrency" xml:"concurrency" env:"BULK_CONCURRENCY" default:"8"` // nolint:lll
+
+	// MaxBodyBytes is the maximum size of a request body in bytes.
+	MaxBodyBytes int64 `json:"max_body_bytes" yaml:"max_body_bytes" toml:"max_body_bytes" xml:"max_body_bytes" env:"BULK_MAX_BODY_BYTES" default:"1048576"` // nolint:lll
+}
@@ -0,0 +1,335 @@
+//go:generate mockgen -write_package_comment=false -source=claims.go -destination=claims_mock.go -package claims
+
+/*
+Package claims splits a set of resources like shards or queues across instances, so that each resource is processed by
+a single instance at a time:
+
+	locker, err := dlock.NewService(lockCfg, rc)
+	s, err := claims.NewService(cfg, locker)
+	stop := s.Distribute(shards, 4, func(ctx context.Context, l *claims.Lease) {
+		// Process the shard until ctx is done, and pass l.Token to downstream systems.
+	})
+	defer stop()
+
+A resource is claimed by acquiring a [dlock] key with a fencing token, and the lease is renewed in background until it's
+released or lost. If an instance crashes, its leases expire after the expiration of the dlock keys, and the resources
+are reclaimed by other instances automatically.
+
+Since a lease may be lost between two renewals, e.g. after a long GC pause, downstream systems should reject requests
+carrying a token smaller than the largest one they have seen if the resources must never be processed concurrently.
+*/
+package claims
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/sainnhe/go-common/pkg/clock"
+	"github.com/sainnhe/go-common/pkg/constant"
+	"github.com/sainnhe/go-common/pkg/dlock"
+	"github.com/sainnhe/go-common/pkg/log"
+	"github.com/sainnhe/go-common/pkg/util"
+)
+
+const pkgName = "github.com/sainnhe/go-common/pkg/claims"
+
+var (
+	// ErrClaimed indicates that the resource is claimed by others.
+	ErrClaimed = errors.New("resource is claimed by others")
+
+	// ErrInvalidLease indicates that the lease duration derived from the locker is invalid.
+	ErrInvalidLease = errors.New("invalid lease duration")
+)
+
+// Lease is a claim of a resource held by the instance.
+type Lease struct {
+	// ResourceID is the ID of the claimed resource.
+	ResourceID string
+
+	// Token is the fencing token of the lease, see [dlock.Service.AcquireToken].
+	Token string
+
+	stop     chan struct{}
+	stopOnce sync.Once
+	done     chan struct{}
+	doneOnce sync.Once
+}
+
+// Done returns a channel that is closed when the lease ends, i.e. it's released or lost. Work on the resource should
+// stop when it's closed.
+func (l *Lease) Done() <-chan struct{} {
+	return l.done
+}
+
+// Service is the claims service of an instance.
+type Service interface {
+	// Claim claims a resource. If it's claimed by others, including the instance itself, wait and retry until it's
+	// released or its lease expires, or ctx is cancelled. The lease is renewed in background until it's released via
+	// [Service.Release] or lost.
+	Claim(ctx context.Context, resourceID string) (*Lease, error)
+
+	// TryClaim is like [Service.Claim], but returns [ErrClaimed] immediately if the resource is claimed by others.
+	TryClaim(ctx context.Context, resourceID string) (*Lease, error)
+
+	// Release stops renewing the lease and releases the resource. It's a no-op if the lease has ended.
+	Release(ctx context.Context, l *Lease) error
+
+	// Leases returns the leases held by the instance in the order of resource IDs.
+	Leases() []*Lease
+
+	// Distribute claims the resources that are not claimed by others every [Config.ClaimIntervalMs], holding at most
+	// limit leases at the same time, and runs fn in a new goroutine with each lease. A non-positive limit means no
+	// limit, and a limit like ceil(len(resourceIDs) / instances) spreads the resources evenly across instances.
+	//
+	// The context passed to fn is cancelled when the lease is lost or stop is called, and fn should return by then. The
+	// lease is released after fn returns, so that the resource can be claimed again by any instance.
+	//
+	// The returned stop function stops claiming, and waits for all fn to return and their leases to be released.
+	Distribute(resourceIDs []string, limit int, fn func(ctx context.Context, l *Lease)) (stop func())
+}
+
+type serviceImpl struct {
+	cfg    *Config
+	locker dlock.Service
+	lease  time.Duration
+	clk    clock.Clock
+	l      *slog.Logger
+
+	mu     sync.Mutex
+	leases map[string]*Lease
+}
+
+// Option is the option used to customize the claims service.
+type Option func(s *serviceImpl)
+
+// WithClock sets the clock used to wait between renewals and claims. Defaults to [clock.Real].
+func WithClock(clk clock.Clock) Option {
+	return func(s *serviceImpl) {
+		if clk != nil {
+			s.clk = clk
+		}
+	}
+}
+
+/*
+NewService initializes a new claims service.
+
+Params:
+  - cfg: The config.
+  - locker: The dlock service used to acquire leases. Its watchdog needn't be enabled, since leases are renewed by this
+    service.
+  - opts: The options.
+
+Returns:
+  - Service: The claims service.
+  - error: [constant.ErrNilDeps] if any dependency is nil, or [ErrInvalidLease] if the expiration of the locker is not
+    positive.
+*/
+func NewService(cfg *Config, locker dlock.Service, opts ...Option) (Service, error) {
+	if cfg == nil || locker == nil {
+		return nil, constant.ErrNilDeps
+	}
+	lease := locker.Expiration()
+	if lease <= 0 {
+		return nil, ErrInvalidLease
+	}
+	s := &serviceImpl{
+		cfg:    cfg,
+		locker: locker,
+		lease:  lease,
+		clk:    clock.Real(),
+		l:      log.NewLogger(pkgName),
+		leases: map[string]*Lease{},
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s, nil
+}
+
+func (s *serviceImpl) Claim(ctx context.Context, resourceID string) (*Lease, error) {
+	start := s.clk.Now()
+	token, err := s.locker.AcquireToken(ctx, resourceID)
+	if err != nil {
+		return nil, err
+	}
+	return s.newLease(resourceID, token, start), nil
+}
+
+func (s *serviceImpl) TryClaim(ctx context.Context, resourceID string) (*Lease, error) {
+	start := s.clk.Now()
+	token, ok, err := s.locker.AcquireTokenNoWait(ctx, resourceID)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrClaimed
+	}
+	return s.newLease(resourceID, token, start), nil
+}
+
+func (s *serviceImpl) Release(ctx context.Context, l *Lease) error {
+	select {
+	case <-l.done:
+		return nil
+	default:
+	}
+	l.stopOnce.Do(func() {
+		close(l.stop)
+	})
+	s.end(l)
+	return s.locker.ReleaseToken(ctx, l.ResourceID, l.Token)
+}
+
+func (s *serviceImpl) Leases() []*Lease {
+	s.mu.Lock()
+	leases := make([]*Lease, 0, len(s.leases))
+	for _, l := range s.leases {
+		leases = append(leases, l)
+	}
+	s.mu.Unlock()
+	slices.SortFunc(leases, func(a, b *Lease) int {
+		return cmp.Compare(a.ResourceID, b.ResourceID)
+	})
+	return leases
+}
+
+func (s *serviceImpl) Distribute(resourceIDs []string, limit int, fn func(ctx context.Context, l *Lease)) (
+	stop func()) {
+	ids := slices.Clone(resourceIDs)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	wg := &sync.WaitGroup{}
+	go func() {
+		defer close(done)
+		defer util.Recover()
+		interval := max(time.Duration(s.cfg.ClaimIntervalMs)*time.Millisecond, time.Millisecond)
+		held := map[string]*Lease{}
+		for {
+			for id, l := range held {
+				select {
+				case <-l.Done():
+					delete(held, id)
+				default:
+				}
+			}
+			for _, id := range ids {
+				if limit > 0 && len(held) >= limit {
+					break
+				}
+				if _, ok := held[id]; ok {
+					continue
+				}
+				l, err := s.TryClaim(ctx, id)
+				if errors.Is(err, ErrClaimed) {
+					continue
+				}
+				if err != nil {
+					if ctx.Err() == nil {
+						s.l.Error("Claim resource failed.", "resource", id, constant.LogAttrError, err)
+					}
+					continue
+				}
+				held[id] = l
+				wg.Add(1)
+				go s.work(ctx, l, fn, wg)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-s.clk.After(interval):
+			}
+		}
+	}()
+	return func() {
+		cancel()
+		<-done
+		wg.Wait()
+	}
+}
+
+// work runs fn with the lease, and releases it after fn returns.
+func (s *serviceImpl) work(ctx context.Context, l *Lease, fn func(ctx context.Context, l *Lease), wg *sync.WaitGroup) {
+	defer wg.Done()
+	ctx, cancel := context.WithCancel(ctx)
+	defer func() {
+		cancel()
+		// Release the lease even if the distribution is stopped.
+		if err := s.Release(context.WithoutCancel(ctx), l); err != nil {
+			s.l.Error("Release lease failed.", "resource", l.ResourceID, constant.LogAttrError, err)
+		}
+	}()
+	defer util.Recover()
+	go func() {
+		select {
+		case <-l.Done():
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	fn(ctx, l)
+}
+
+// newLease registers a lease acquired at start, and starts renewing it.
+func (s *serviceImpl) newLease(resourceID, token string, start time.Time) *Lease {
+	l := &Lease{
+		ResourceID: resourceID,
+		Token:      token,
+		stop:       make(chan struct{}),
+		done:       make(chan struct{}),
+	}
+	s.mu.Lock()
+	s.leases[resourceID] = l
+	s.mu.Unlock()
+	go s.renew(l, start)
+	return l
+}
+
+// renew renews the lease every third of the lease duration until it's released, or lost because it's held by others
+// or hasn't been renewed within the lease duration since lastRenewal.
+func (s *serviceImpl) renew(l *Lease, lastRenewal time.Time) {
+	defer s.end(l)
+	defer util.Recover()
+	lease := s.lease
+	interval := max(lease/3, time.Millisecond) // nolint:mnd
+	logger := s.l.With("resource", l.ResourceID)
+	for {
+		select {
+		case <-l.stop:
+			return
+		case <-s.clk.After(interval):
+		}
+		start := s.clk.Now()
+		err := s.locker.RenewToken(context.Background(), l.ResourceID, l.Token)
+		switch {
+		case err == nil:
+			lastRenewal = start
+		case errors.Is(err, dlock.ErrKeyNotExists), errors.Is(err, dlock.ErrNotHolder):
+			logger.Warn("Lease lost.", constant.LogAttrError, err)
+			return
+		default:
+			logger.Error("Renew lease failed.", constant.LogAttrError, err)
+			if s.clk.Now().Sub(lastRenewal) >= lease {
+				logger.Warn("Lease expired before renewal.")
+				return
+			}
+		}
+	}
+}
+
+// end marks the lease as ended and unregisters it.
+func (s *serviceImpl) end(l *Lease) {
+	l.doneOnce.Do(func() {
+		s.mu.Lock()
+		if s.leases[l.ResourceID] == l {
+			delete(s.leases, l.ResourceID)
+		}
+		s.mu.Unlock()
+		close(l.done)
+	})
+}
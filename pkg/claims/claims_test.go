@@ -0,0 +1,160 @@
+package claims_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/sainnhe/go-common/pkg/claims"
+	"github.com/sainnhe/go-common/pkg/clock/testclock"
+	"github.com/sainnhe/go-common/pkg/constant"
+	"github.com/sainnhe/go-common/pkg/dlock"
+	"go.uber.org/mock/gomock"
+)
+
+func TestNewService(t *testing.T) {
+	t.Parallel()
+
+	if _, err := claims.NewService(nil, nil); !errors.Is(err, constant.ErrNilDeps) {
+		t.Fatalf("Expect error %+v, got %+v", constant.ErrNilDeps, err)
+	}
+	locker := dlock.NewMockService(gomock.NewController(t))
+	locker.EXPECT().Expiration().Return(time.Duration(0))
+	if _, err := claims.NewService(&claims.Config{}, locker); !errors.Is(err, claims.ErrInvalidLease) {
+		t.Fatalf("Expect error %+v, got %+v", claims.ErrInvalidLease, err)
+	}
+}
+
+func TestService_lease(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	locker := dlock.NewMockService(ctrl)
+	clk := testclock.Freeze(time.Now())
+	locker.EXPECT().Expiration().Return(300 * time.Millisecond)
+	s, err := claims.NewService(&claims.Config{ClaimIntervalMs: 1000}, locker, claims.WithClock(clk))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	boom := errors.New("boom")
+
+	// Resources claimed by others can't be claimed.
+	locker.EXPECT().AcquireTokenNoWait(gomock.Any(), "a").Return("1", true, nil)
+	locker.EXPECT().AcquireTokenNoWait(gomock.Any(), "b").Return("", false, nil)
+	a, err := s.TryClaim(ctx, "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.ResourceID != "a" || a.Token != "1" {
+		t.Fatalf("Unexpected lease %+v", a)
+	}
+	if _, err = s.TryClaim(ctx, "b"); !errors.Is(err, claims.ErrClaimed) {
+		t.Fatalf("Expect error %+v, got %+v", claims.ErrClaimed, err)
+	}
+	if leases := s.Leases(); len(leases) != 1 || leases[0] != a {
+		t.Fatalf("Unexpected leases %+v", leases)
+	}
+
+	// The lease is lost if it can't be renewed within the lease duration since the last renewal.
+	locker.EXPECT().RenewToken(gomock.Any(), "a", "1").Return(nil)
+	locker.EXPECT().RenewToken(gomock.Any(), "a", "1").Return(boom).Times(3)
+	for range 3 {
+		clk.BlockUntil(1)
+		select {
+		case <-a.Done():
+			t.Fatal("Expect lease not to end")
+		default:
+		}
+		clk.Advance(100 * time.Millisecond)
+	}
+	clk.BlockUntil(1)
+	clk.Advance(100 * time.Millisecond)
+	<-a.Done()
+	if err = s.Release(ctx, a); err != nil {
+		t.Fatal(err)
+	}
+
+	// Released leases end immediately.
+	locker.EXPECT().AcquireToken(gomock.Any(), "c").Return("2", nil)
+	locker.EXPECT().ReleaseToken(gomock.Any(), "c", "2").Return(nil)
+	c, err := s.Claim(ctx, "c")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = s.Release(ctx, c); err != nil {
+		t.Fatal(err)
+	}
+	<-c.Done()
+
+	// The lease is lost once the resource is held by others.
+	locker.EXPECT().AcquireToken(gomock.Any(), "d").Return("3", nil)
+	locker.EXPECT().RenewToken(gomock.Any(), "d", "3").Return(dlock.ErrNotHolder)
+	d, err := s.Claim(ctx, "d")
+	if err != nil {
+		t.Fatal(err)
+	}
+	clk.BlockUntil(2)
+	clk.Advance(100 * time.Millisecond)
+	<-d.Done()
+	if leases := s.Leases(); len(leases) != 0 {
+		t.Fatalf("Expect no leases, got %+v", leases)
+	}
+}
+
+func TestService_Distribute(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	locker := dlock.NewMockService(ctrl)
+	clk := testclock.Freeze(time.Now())
+	locker.EXPECT().Expiration().Return(300 * time.Millisecond)
+	s, err := claims.NewService(&claims.Config{ClaimIntervalMs: 200}, locker, claims.WithClock(clk))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// At most 2 resources are claimed, skipping those claimed by others.
+	locker.EXPECT().AcquireTokenNoWait(gomock.Any(), "a").Return("1", true, nil)
+	locker.EXPECT().AcquireTokenNoWait(gomock.Any(), "b").Return("", false, nil)
+	locker.EXPECT().AcquireTokenNoWait(gomock.Any(), "c").Return("2", true, nil)
+	locker.EXPECT().RenewToken(gomock.Any(), "c", "2").Return(nil).AnyTimes()
+	started := make(chan string, 3)
+	returned := make(chan string, 3)
+	stop := s.Distribute([]string{"a", "b", "c"}, 2, func(ctx context.Context, l *claims.Lease) {
+		started <- l.ResourceID
+		<-ctx.Done()
+		returned <- l.ResourceID
+	})
+	ids := []string{<-started, <-started}
+	slices.Sort(ids)
+	if !slices.Equal(ids, []string{"a", "c"}) {
+		t.Fatalf("Unexpected resources %v", ids)
+	}
+
+	// Lost leases are reclaimed in the next round.
+	locker.EXPECT().RenewToken(gomock.Any(), "a", "1").Return(dlock.ErrNotHolder)
+	clk.BlockUntil(3)
+	clk.Advance(100 * time.Millisecond)
+	if id := <-returned; id != "a" {
+		t.Fatalf("Expect a to return, got %s", id)
+	}
+	locker.EXPECT().AcquireTokenNoWait(gomock.Any(), "a").Return("", false, nil)
+	locker.EXPECT().AcquireTokenNoWait(gomock.Any(), "b").Return("3", true, nil)
+	locker.EXPECT().RenewToken(gomock.Any(), "b", "3").Return(nil).AnyTimes()
+	clk.BlockUntil(2)
+	clk.Advance(100 * time.Millisecond)
+	if id := <-started; id != "b" {
+		t.Fatalf("Expect b to start, got %s", id)
+	}
+
+	// Leases are released after fn returns.
+	locker.EXPECT().ReleaseToken(gomock.Any(), "b", "3").Return(nil)
+	locker.EXPECT().ReleaseToken(gomock.Any(), "c", "2").Return(nil)
+	stop()
+	if leases := s.Leases(); len(leases) != 0 {
+		t.Fatalf("Expect no leases, got %+v", leases)
+	}
+}
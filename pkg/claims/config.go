@@ -0,0 +1,8 @@
+package claims
+
+// Config defines the config model for claims.
+type Config struct {
+	// ClaimIntervalMs is the interval of claiming unclaimed resources in [Service.Distribute] in milliseconds, which is
+	// also the maximum delay of reclaiming the resources of crashed instances after their leases expire.
+	ClaimIntervalMs int64 `json:"claim_interval_ms" yaml:"claim_interval_ms" toml:"claim_interval_ms" xml:"claim_interval_ms" env:"CLAIMS_CLAIM_INTERVAL_MS" default:"1000"` // nolint:lll
+}
@@ -0,0 +1,45 @@
+// Package clock defines an abstraction of time, so that time-dependent code can be tested deterministically.
+//
+// Production code should use [Real], and tests can use the fake clock implemented in
+// [github.com/sainnhe/go-common/pkg/clock/testclock].
+package clock
+
+import "time"
+
+// Clock tells the time and sleeps.
+type Clock interface {
+	// Now returns the current time.
+	Now() time.Time
+
+	// Since returns the time elapsed since t.
+	Since(t time.Time) time.Duration
+
+	// Sleep pauses the current goroutine for at least the duration d.
+	Sleep(d time.Duration)
+
+	// After waits for the duration to elapse and then sends the current time on the returned channel.
+	After(d time.Duration) <-chan time.Time
+}
+
+type realClock struct{}
+
+// Real returns a [Clock] backed by the [time] package.
+func Real() Clock {
+	return realClock{}
+}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) Since(t time.Time) time.Duration {
+	return time.Since(t)
+}
+
+func (realClock) Sleep(d time.Duration) {
+	time.Sleep(d)
+}
+
+func (realClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
@@ -0,0 +1,20 @@
+package clock_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sainnhe/go-common/pkg/clock"
+)
+
+func TestReal(t *testing.T) {
+	t.Parallel()
+
+	c := clock.Real()
+	start := c.Now()
+	c.Sleep(time.Millisecond)
+	<-c.After(time.Millisecond)
+	if d := c.Since(start); d < 2*time.Millisecond {
+		t.Fatalf("Expect at least 2ms elapsed, got %s", d)
+	}
+}
@@ -0,0 +1,131 @@
+// Package testclock implements a fake [clock.Clock] whose time only moves when told to, so that time-dependent tests
+// don't need to sleep in real time.
+//
+// There are two modes:
+//
+//   - By default, [Clock.Sleep] and [Clock.After] block until the clock is moved past their deadline via
+//     [Clock.Advance] or [Clock.Set]. Use [Clock.BlockUntil] to wait for goroutines to start sleeping before advancing.
+//   - With auto advance enabled via [Clock.WithAutoAdvance], sleeping advances the clock immediately and returns,
+//     which is convenient when the code under test sleeps between retries.
+package testclock
+
+import (
+	"sync"
+	"time"
+
+	"github.com/sainnhe/go-common/pkg/clock"
+)
+
+// Clock is a fake [clock.Clock]. It's safe for concurrent use.
+type Clock struct {
+	now         time.Time
+	autoAdvance bool
+	waiters     []*waiter
+	mu          sync.Mutex
+	cond        *sync.Cond
+}
+
+type waiter struct {
+	deadline time.Time
+	ch       chan time.Time
+}
+
+var _ clock.Clock = (*Clock)(nil)
+
+// Freeze initializes a new [Clock] frozen at the given time.
+func Freeze(t time.Time) *Clock {
+	c := &Clock{now: t}
+	c.cond = sync.NewCond(&c.mu)
+	return c
+}
+
+// WithAutoAdvance enables auto advance mode and returns the clock itself.
+func (c *Clock) WithAutoAdvance() *Clock {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.autoAdvance = true
+	return c
+}
+
+// Now returns the current fake time.
+func (c *Clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// Since returns the fake time elapsed since t.
+func (c *Clock) Since(t time.Time) time.Duration {
+	return c.Now().Sub(t)
+}
+
+// Sleep blocks until the clock is moved forward by at least d, or advances the clock by d immediately in auto advance
+// mode.
+func (c *Clock) Sleep(d time.Duration) {
+	<-c.After(d)
+}
+
+// After returns a channel that receives the fake time once the clock is moved forward by at least d.
+// In auto advance mode, the clock is advanced by d immediately.
+func (c *Clock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ch := make(chan time.Time, 1)
+	if d <= 0 {
+		ch <- c.now
+		return ch
+	}
+	if c.autoAdvance {
+		c.setLocked(c.now.Add(d))
+		ch <- c.now
+		return ch
+	}
+	c.waiters = append(c.waiters, &waiter{c.now.Add(d), ch})
+	c.cond.Broadcast()
+	return ch
+}
+
+// Advance moves the clock forward by d and wakes up goroutines whose deadline is reached.
+func (c *Clock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.setLocked(c.now.Add(d))
+}
+
+// Set sets the clock to t and wakes up goroutines whose deadline is reached.
+func (c *Clock) Set(t time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.setLocked(t)
+}
+
+// Waiters returns the number of goroutines that are waiting in [Clock.Sleep] or [Clock.After].
+func (c *Clock) Waiters() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.waiters)
+}
+
+// BlockUntil blocks until at least n goroutines are waiting in [Clock.Sleep] or [Clock.After].
+func (c *Clock) BlockUntil(n int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for len(c.waiters) < n {
+		c.cond.Wait()
+	}
+}
+
+func (c *Clock) setLocked(t time.Time) {
+	c.now = t
+	remaining := c.waiters[:0]
+	for _, w := range c.waiters {
+		if !w.deadline.After(c.now) {
+			w.ch <- c.now
+			continue
+		}
+		remaining = append(remaining, w)
+	}
+	c.waiters = remaining
+	c.cond.Broadcast()
+}
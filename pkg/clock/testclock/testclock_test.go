@@ -0,0 +1,66 @@
+package testclock_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sainnhe/go-common/pkg/clock/testclock"
+)
+
+func TestClock(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := testclock.Freeze(start)
+
+	if !c.Now().Equal(start) {
+		t.Fatalf("Want %s, got %s", start, c.Now())
+	}
+
+	// Sleep blocks until advanced
+	done := make(chan struct{})
+	go func() {
+		c.Sleep(time.Minute)
+		close(done)
+	}()
+	c.BlockUntil(1)
+	c.Advance(30 * time.Second)
+	select {
+	case <-done:
+		t.Fatal("Sleep returned before deadline")
+	default:
+	}
+	if c.Waiters() != 1 {
+		t.Fatalf("Want 1 waiter, got %d", c.Waiters())
+	}
+	c.Advance(30 * time.Second)
+	<-done
+	if d := c.Since(start); d != time.Minute {
+		t.Fatalf("Want 1m, got %s", d)
+	}
+
+	// After with non-positive duration fires immediately
+	<-c.After(0)
+
+	// Set wakes up waiters
+	ch := c.After(time.Hour)
+	c.Set(start.Add(2 * time.Hour))
+	if got := <-ch; !got.Equal(start.Add(2 * time.Hour)) {
+		t.Fatalf("Got %s", got)
+	}
+}
+
+func TestClock_autoAdvance(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := testclock.Freeze(start).WithAutoAdvance()
+
+	c.Sleep(time.Hour)
+	if d := c.Since(start); d != time.Hour {
+		t.Fatalf("Want 1h, got %s", d)
+	}
+	if c.Waiters() != 0 {
+		t.Fatalf("Want 0 waiters, got %d", c.Waiters())
+	}
+}
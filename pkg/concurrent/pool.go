@@ -0,0 +1,212 @@
+package concurrent
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"sync"
+	"sync/atomic"
+
+	"github.com/sainnhe/go-common/pkg/constant"
+	"github.com/sainnhe/go-common/pkg/util"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+const (
+	// MetricPoolQueued is the name of the up-down counter of tasks waiting in the queue of a [Pool], with the
+	// attribute "pool".
+	MetricPoolQueued = "concurrent.pool.queued"
+
+	// MetricPoolRunning is the name of the up-down counter of tasks running in a [Pool], with the attribute "pool".
+	MetricPoolRunning = "concurrent.pool.running"
+
+	// MetricPoolCompleted is the name of the counter of tasks completed by a [Pool], including the panicked ones, with
+	// the attribute "pool".
+	MetricPoolCompleted = "concurrent.pool.completed"
+)
+
+// ErrPoolClosed indicates that the pool is draining or drained, so that no more tasks can be submitted.
+var ErrPoolClosed = errors.New("pool closed")
+
+// PoolConfig defines the config model for [Pool].
+type PoolConfig struct {
+	// Name is the identifier of the pool that will be used in metrics.
+	Name string `json:"name" yaml:"name" toml:"name" xml:"name" env:"POOL_NAME" default:"default"`
+
+	// Workers is the number of workers. Defaults to [runtime.GOMAXPROCS] if not positive.
+	Workers int `json:"workers" yaml:"workers" toml:"workers" xml:"workers" env:"POOL_WORKERS" default:"8"`
+
+	// QueueSize is the maximum number of tasks waiting for workers. When the queue is full, [Pool.Submit] blocks until
+	// there is room. Set to 0 to hand tasks over to workers directly.
+	QueueSize int `json:"queue_size" yaml:"queue_size" toml:"queue_size" xml:"queue_size" env:"POOL_QUEUE_SIZE" default:"64"` // nolint:lll
+}
+
+// PoolStats is the statistics of a [Pool].
+type PoolStats struct {
+	// Queued is the number of tasks waiting in the queue.
+	Queued int64
+
+	// Running is the number of tasks being run by workers.
+	Running int64
+
+	// Completed is the number of tasks that have finished, including the panicked ones.
+	Completed int64
+}
+
+// Pool is a goroutine pool that runs submitted tasks with a fixed number of workers and a bounded queue. Panics in
+// tasks are recovered and logged via [util.Recover].
+//
+// [Pool.Drain] has the same signature as the function of graceful.RegisterStage, so that the pool can be drained as a
+// shutdown stage:
+//
+//	graceful.RegisterStage("drain-pool", 10, 5*time.Second, pool.Drain)
+type Pool struct {
+	tasks     chan func()
+	quit      chan struct{}
+	done      chan struct{}
+	quitOnce  sync.Once
+	mu        sync.RWMutex
+	wg        sync.WaitGroup
+	queued    atomic.Int64
+	running   atomic.Int64
+	completed atomic.Int64
+	attrs     metric.MeasurementOption
+
+	queuedCounter    metric.Int64UpDownCounter
+	runningCounter   metric.Int64UpDownCounter
+	completedCounter metric.Int64Counter
+}
+
+/*
+NewPool initializes a new pool and starts its workers.
+
+Params:
+  - cfg: The config.
+
+Returns:
+  - *Pool: The pool.
+  - error: [constant.ErrNilDeps] if cfg is nil, or an error if the metrics can't be created.
+*/
+func NewPool(cfg *PoolConfig) (*Pool, error) {
+	if cfg == nil {
+		return nil, constant.ErrNilDeps
+	}
+	workers := cfg.Workers
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	p := &Pool{
+		tasks: make(chan func(), max(cfg.QueueSize, 0)),
+		quit:  make(chan struct{}),
+		done:  make(chan struct{}),
+		attrs: metric.WithAttributes(attribute.String("pool", cfg.Name)),
+	}
+	meter := otel.Meter("github.com/sainnhe/go-common/pkg/concurrent")
+	var err error
+	if p.queuedCounter, err = meter.Int64UpDownCounter(MetricPoolQueued,
+		metric.WithDescription("The number of tasks waiting in the queue of the pool."),
+		metric.WithUnit("{task}")); err != nil {
+		return nil, err
+	}
+	if p.runningCounter, err = meter.Int64UpDownCounter(MetricPoolRunning,
+		metric.WithDescription("The number of tasks running in the pool."),
+		metric.WithUnit("{task}")); err != nil {
+		return nil, err
+	}
+	if p.completedCounter, err = meter.Int64Counter(MetricPoolCompleted,
+		metric.WithDescription("The number of tasks completed by the pool."),
+		metric.WithUnit("{task}")); err != nil {
+		return nil, err
+	}
+
+	p.wg.Add(workers)
+	for range workers {
+		go p.work()
+	}
+	go func() {
+		p.wg.Wait()
+		close(p.done)
+	}()
+	return p, nil
+}
+
+// Submit submits the task to the pool. It blocks until the task is queued, and returns the error of ctx if ctx is
+// done before that, or [ErrPoolClosed] if the pool is draining or drained.
+func (p *Pool) Submit(ctx context.Context, task func()) error {
+	if task == nil {
+		return nil
+	}
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	select {
+	case <-p.quit:
+		return ErrPoolClosed
+	default:
+	}
+	p.addQueued(ctx, 1)
+	select {
+	case p.tasks <- task:
+		return nil
+	case <-ctx.Done():
+		p.addQueued(ctx, -1)
+		return ctx.Err()
+	case <-p.quit:
+		p.addQueued(ctx, -1)
+		return ErrPoolClosed
+	}
+}
+
+// Drain stops accepting new tasks, and waits for the queued and running tasks to finish. It returns the error of ctx
+// if ctx is done before that, in which case the remaining tasks keep running in background. It's safe to call Drain
+// more than once.
+func (p *Pool) Drain(ctx context.Context) error {
+	p.quitOnce.Do(func() {
+		close(p.quit)
+		// Wait for blocked submitters to return, so that no task is sent after the channel is closed.
+		p.mu.Lock()
+		close(p.tasks)
+		p.mu.Unlock()
+	})
+	select {
+	case <-p.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Stats returns the statistics of the pool.
+func (p *Pool) Stats() PoolStats {
+	return PoolStats{
+		Queued:    p.queued.Load(),
+		Running:   p.running.Load(),
+		Completed: p.completed.Load(),
+	}
+}
+
+func (p *Pool) work() {
+	defer p.wg.Done()
+	ctx := context.Background()
+	for task := range p.tasks {
+		p.addQueued(ctx, -1)
+		p.running.Add(1)
+		p.runningCounter.Add(ctx, 1, p.attrs)
+		p.run(task)
+		p.running.Add(-1)
+		p.runningCounter.Add(ctx, -1, p.attrs)
+		p.completed.Add(1)
+		p.completedCounter.Add(ctx, 1, p.attrs)
+	}
+}
+
+func (p *Pool) run(task func()) {
+	defer util.Recover()
+	task()
+}
+
+func (p *Pool) addQueued(ctx context.Context, n int64) {
+	p.queued.Add(n)
+	p.queuedCounter.Add(ctx, n, p.attrs)
+}
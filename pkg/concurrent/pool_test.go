@@ -0,0 +1,98 @@
+package concurrent_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sainnhe/go-common/pkg/concurrent"
+	"github.com/sainnhe/go-common/pkg/constant"
+)
+
+func TestNewPool(t *testing.T) {
+	t.Parallel()
+
+	if _, err := concurrent.NewPool(nil); !errors.Is(err, constant.ErrNilDeps) {
+		t.Fatalf("Expect error %+v, got %+v", constant.ErrNilDeps, err)
+	}
+}
+
+func TestPool(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	p, err := concurrent.NewPool(&concurrent.PoolConfig{Name: "test", Workers: 2, QueueSize: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Block both workers and fill the queue.
+	release := make(chan struct{})
+	started := make(chan struct{}, 2)
+	for range 3 {
+		if err = p.Submit(ctx, func() {
+			started <- struct{}{}
+			<-release
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	<-started
+	<-started
+	if stats := p.Stats(); stats != (concurrent.PoolStats{Queued: 1, Running: 2}) {
+		t.Fatalf("Unexpected stats %+v", stats)
+	}
+
+	// Submit blocks when the queue is full.
+	timeoutCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	if err = p.Submit(timeoutCtx, func() {}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expect error %+v, got %+v", context.DeadlineExceeded, err)
+	}
+
+	// Drain times out while tasks are running, and new tasks are rejected.
+	timeoutCtx, cancel = context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	if err = p.Drain(timeoutCtx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expect error %+v, got %+v", context.DeadlineExceeded, err)
+	}
+	if err = p.Submit(ctx, func() {}); !errors.Is(err, concurrent.ErrPoolClosed) {
+		t.Fatalf("Expect error %+v, got %+v", concurrent.ErrPoolClosed, err)
+	}
+
+	// Queued tasks still run after draining starts.
+	close(release)
+	if err = p.Drain(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if stats := p.Stats(); stats != (concurrent.PoolStats{Completed: 3}) {
+		t.Fatalf("Unexpected stats %+v", stats)
+	}
+}
+
+func TestPool_panic(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	p, err := concurrent.NewPool(&concurrent.PoolConfig{Workers: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := atomic.Int64{}
+	for range 2 {
+		if err = p.Submit(ctx, func() {
+			n.Add(1)
+			panic("test")
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = p.Drain(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if n.Load() != 2 || p.Stats().Completed != 2 {
+		t.Fatalf("Expect 2 completed tasks, got %d %+v", n.Load(), p.Stats())
+	}
+}
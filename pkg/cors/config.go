@@ -0,0 +1,29 @@
+package cors
+
+// Config defines the config model for CORS.
+type Config struct {
+	// AllowedOrigins are the origins allowed to make cross-origin requests. "*" allows all origins, and a single
+	// wildcard like "https://*.example.com" matches any subdomain.
+	AllowedOrigins []string `json:"allowed_origins" yaml:"allowed_origins" toml:"allowed_origins" xml:"allowed_origins" env:"CORS_ALLOWED_ORIGINS" default:"[\"*\"]"` // nolint:lll
+
+	// AllowedOriginPatterns are regular expressions matched against the whole lowercased origin, in addition to
+	// AllowedOrigins.
+	AllowedOriginPatterns []string `json:"allowed_origin_patterns" yaml:"allowed_origin_patterns" toml:"allowed_origin_patterns" xml:"allowed_origin_patterns" env:"CORS_ALLOWED_ORIGIN_PATTERNS" default:"[]"` // nolint:lll
+
+	// AllowedMethods are the methods allowed in cross-origin requests.
+	AllowedMethods []string `json:"allowed_methods" yaml:"allowed_methods" toml:"allowed_methods" xml:"allowed_methods" env:"CORS_ALLOWED_METHODS" default:"[\"GET\",\"HEAD\",\"POST\",\"PUT\",\"PATCH\",\"DELETE\"]"` // nolint:lll
+
+	// AllowedHeaders are the request headers allowed in cross-origin requests. "*" allows all headers requested in
+	// preflight requests.
+	AllowedHeaders []string `json:"allowed_headers" yaml:"allowed_headers" toml:"allowed_headers" xml:"allowed_headers" env:"CORS_ALLOWED_HEADERS" default:"[\"*\"]"` // nolint:lll
+
+	// ExposedHeaders are the response headers that browsers are allowed to access.
+	ExposedHeaders []string `json:"exposed_headers" yaml:"exposed_headers" toml:"exposed_headers" xml:"exposed_headers" env:"CORS_EXPOSED_HEADERS" default:"[]"` // nolint:lll
+
+	// AllowCredentials indicates whether cookies and authorization headers are allowed in cross-origin requests. If
+	// enabled, the request origin is echoed, and AllowedOrigins must not contain "*".
+	AllowCredentials bool `json:"allow_credentials" yaml:"allow_credentials" toml:"allow_credentials" xml:"allow_credentials" env:"CORS_ALLOW_CREDENTIALS" default:"false"` // nolint:lll
+
+	// MaxAgeSec is how long browsers can cache preflight results in seconds. Set to 0 to omit the header.
+	MaxAgeSec int `json:"max_age_sec" yaml:"max_age_sec" toml:"max_age_sec" xml:"max_age_sec" env:"CORS_MAX_AGE_SEC" default:"600"` // nolint:lll
+}
@@ -0,0 +1,189 @@
+// Package cors implements a Cross-Origin Resource Sharing (CORS) middleware.
+package cors
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/sainnhe/go-common/pkg/constant"
+)
+
+// ErrWildcardCredentials indicates that all origins are allowed together with credentials, which would let any website
+// make credentialed requests.
+var ErrWildcardCredentials = errors.New("wildcard origin with credentials")
+
+// policy is the compiled [Config].
+type policy struct {
+	cfg            *Config
+	allowAll       bool
+	origins        []string
+	wildcards      [][2]string
+	patterns       []*regexp.Regexp
+	allowedHeaders map[string]struct{}
+	allowAllHeader bool
+	methods        string
+	exposedHeaders string
+	maxAge         string
+}
+
+/*
+NewMiddleware initializes a CORS middleware.
+
+Preflight requests, which are OPTIONS requests with the Access-Control-Request-Method header, are responded with 204 No
+Content and are not passed to the next handler. Other requests are passed to the next handler, with CORS headers added
+if the origin is allowed.
+
+Params:
+  - cfg: The config.
+
+Returns:
+  - func(http.Handler) http.Handler: The middleware.
+  - error: [constant.ErrNilDeps] if cfg is nil, [ErrWildcardCredentials] if "*" is allowed together with credentials,
+    or an error if an origin pattern is not a valid regular expression.
+*/
+func NewMiddleware(cfg *Config) (func(http.Handler) http.Handler, error) {
+	if cfg == nil {
+		return nil, constant.ErrNilDeps
+	}
+	p := &policy{
+		cfg:            cfg,
+		allowedHeaders: map[string]struct{}{},
+		methods:        strings.Join(cfg.AllowedMethods, ", "),
+		exposedHeaders: strings.Join(cfg.ExposedHeaders, ", "),
+	}
+	for _, origin := range cfg.AllowedOrigins {
+		switch prefix, suffix, ok := strings.Cut(origin, "*"); {
+		case origin == "*":
+			if cfg.AllowCredentials {
+				return nil, ErrWildcardCredentials
+			}
+			p.allowAll = true
+		case ok:
+			p.wildcards = append(p.wildcards, [2]string{strings.ToLower(prefix), strings.ToLower(suffix)})
+		default:
+			p.origins = append(p.origins, strings.ToLower(origin))
+		}
+	}
+	for _, pattern := range cfg.AllowedOriginPatterns {
+		re, err := regexp.Compile("^(?:" + pattern + ")$")
+		if err != nil {
+			return nil, fmt.Errorf("invalid origin pattern %q: %w", pattern, err)
+		}
+		p.patterns = append(p.patterns, re)
+	}
+	for _, header := range cfg.AllowedHeaders {
+		if header == "*" {
+			p.allowAllHeader = true
+			continue
+		}
+		p.allowedHeaders[http.CanonicalHeaderKey(header)] = struct{}{}
+	}
+	if cfg.MaxAgeSec > 0 {
+		p.maxAge = strconv.Itoa(cfg.MaxAgeSec)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodOptions && len(r.Header.Get("Access-Control-Request-Method")) > 0 {
+				p.handlePreflight(w, r)
+				return
+			}
+			p.handleActual(w, r)
+			next.ServeHTTP(w, r)
+		})
+	}, nil
+}
+
+// allowOrigin reports whether origin is allowed.
+func (p *policy) allowOrigin(origin string) bool {
+	if len(origin) == 0 {
+		return false
+	}
+	if p.allowAll {
+		return true
+	}
+	origin = strings.ToLower(origin)
+	if slices.Contains(p.origins, origin) {
+		return true
+	}
+	for _, w := range p.wildcards {
+		if len(origin) > len(w[0])+len(w[1]) && strings.HasPrefix(origin, w[0]) && strings.HasSuffix(origin, w[1]) {
+			return true
+		}
+	}
+	for _, re := range p.patterns {
+		if re.MatchString(origin) {
+			return true
+		}
+	}
+	return false
+}
+
+// setAllowOrigin sets the Access-Control-Allow-Origin and Access-Control-Allow-Credentials headers.
+func (p *policy) setAllowOrigin(h http.Header, origin string) {
+	if p.allowAll {
+		h.Set("Access-Control-Allow-Origin", "*")
+	} else {
+		h.Set("Access-Control-Allow-Origin", origin)
+	}
+	if p.cfg.AllowCredentials {
+		h.Set("Access-Control-Allow-Credentials", "true")
+	}
+}
+
+// handlePreflight responds to a preflight request.
+func (p *policy) handlePreflight(w http.ResponseWriter, r *http.Request) {
+	h := w.Header()
+	h.Add("Vary", "Origin")
+	h.Add("Vary", "Access-Control-Request-Method")
+	h.Add("Vary", "Access-Control-Request-Headers")
+	defer w.WriteHeader(http.StatusNoContent)
+
+	origin := r.Header.Get("Origin")
+	if !p.allowOrigin(origin) {
+		return
+	}
+	method := r.Header.Get("Access-Control-Request-Method")
+	if !slices.Contains(p.cfg.AllowedMethods, method) {
+		return
+	}
+	requested := r.Header.Get("Access-Control-Request-Headers")
+	if !p.allowAllHeader {
+		for header := range strings.SplitSeq(requested, ",") {
+			header = http.CanonicalHeaderKey(strings.TrimSpace(header))
+			if _, ok := p.allowedHeaders[header]; !ok && len(header) > 0 {
+				return
+			}
+		}
+	}
+
+	p.setAllowOrigin(h, origin)
+	h.Set("Access-Control-Allow-Methods", p.methods)
+	if len(requested) > 0 {
+		h.Set("Access-Control-Allow-Headers", requested)
+	}
+	if len(p.maxAge) > 0 {
+		h.Set("Access-Control-Max-Age", p.maxAge)
+	}
+}
+
+// handleActual adds CORS headers to the response of an actual request.
+func (p *policy) handleActual(w http.ResponseWriter, r *http.Request) {
+	h := w.Header()
+	if !p.allowAll {
+		h.Add("Vary", "Origin")
+	}
+	origin := r.Header.Get("Origin")
+	if !p.allowOrigin(origin) {
+		return
+	}
+	p.setAllowOrigin(h, origin)
+	if len(p.exposedHeaders) > 0 {
+		h.Set("Access-Control-Expose-Headers", p.exposedHeaders)
+	}
+}
@@ -0,0 +1,132 @@
+package cors_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sainnhe/go-common/pkg/constant"
+	"github.com/sainnhe/go-common/pkg/cors"
+	"github.com/sainnhe/go-common/pkg/encoding"
+)
+
+func TestNewMiddleware(t *testing.T) {
+	t.Parallel()
+
+	if _, err := cors.NewMiddleware(nil); !errors.Is(err, constant.ErrNilDeps) {
+		t.Fatalf("Expect error %+v, got %+v", constant.ErrNilDeps, err)
+	}
+	if _, err := cors.NewMiddleware(&cors.Config{AllowedOriginPatterns: []string{"("}}); err == nil {
+		t.Fatal("Expect error, got nil")
+	}
+	if _, err := cors.NewMiddleware(&cors.Config{AllowedOrigins: []string{"*"}, AllowCredentials: true}); !errors.Is(
+		err, cors.ErrWildcardCredentials) {
+		t.Fatalf("Expect error %+v, got %+v", cors.ErrWildcardCredentials, err)
+	}
+
+	// Default config
+	cfg, err := encoding.LoadConfig[cors.Config](nil, encoding.TypeNil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cfg.AllowedOrigins) != 1 || cfg.MaxAgeSec != 600 {
+		t.Fatalf("Unexpected default config %+v", cfg)
+	}
+	h := newHandler(t, cfg)
+	w := serve(h, http.MethodGet, "https://foo.com", "", "")
+	if w.Code != http.StatusOK || w.Header().Get("Access-Control-Allow-Origin") != "*" {
+		t.Fatalf("Unexpected response %d %v", w.Code, w.Header())
+	}
+	w = serve(h, http.MethodOptions, "https://foo.com", http.MethodPut, "X-Request-Id, Content-Type")
+	if w.Code != http.StatusNoContent || w.Header().Get("Access-Control-Allow-Headers") != "X-Request-Id, Content-Type" ||
+		w.Header().Get("Access-Control-Max-Age") != "600" {
+		t.Fatalf("Unexpected response %d %v", w.Code, w.Header())
+	}
+}
+
+func TestMiddleware_origins(t *testing.T) {
+	t.Parallel()
+
+	h := newHandler(t, &cors.Config{
+		AllowedOrigins:        []string{"https://foo.com", "https://*.bar.com"},
+		AllowedOriginPatterns: []string{`https://[a-z]+\.baz\.io`},
+		AllowedMethods:        []string{http.MethodGet, http.MethodPost},
+		AllowedHeaders:        []string{"content-type"},
+		ExposedHeaders:        []string{"X-Total-Count"},
+		AllowCredentials:      true,
+	})
+	for origin, allowed := range map[string]bool{
+		"https://foo.com":     true,
+		"https://FOO.com":     true,
+		"https://a.bar.com":   true,
+		"https://a.b.bar.com": true,
+		"https://.bar.com":    false,
+		"https://bar.com":     false,
+		"https://qux.baz.io":  true,
+		"https://qux.baz.io1": false,
+		"http://foo.com":      false,
+		"":                    false,
+	} {
+		w := serve(h, http.MethodGet, origin, "", "")
+		if got := w.Header().Get("Access-Control-Allow-Origin") == origin && len(origin) > 0; got != allowed {
+			t.Fatalf("Expect origin %q allowed = %t, got headers %v", origin, allowed, w.Header())
+		}
+		if allowed && (w.Header().Get("Access-Control-Allow-Credentials") != "true" ||
+			w.Header().Get("Access-Control-Expose-Headers") != "X-Total-Count") {
+			t.Fatalf("Unexpected headers %v", w.Header())
+		}
+		if w.Header().Get("Vary") != "Origin" {
+			t.Fatalf("Expect Vary header, got %v", w.Header())
+		}
+	}
+
+	// Preflight
+	tests := []struct {
+		method  string
+		headers string
+		allowed bool
+	}{
+		{http.MethodPost, "Content-Type", true},
+		{http.MethodDelete, "", false},
+		{http.MethodGet, "Content-Type, Authorization", false},
+	}
+	for _, tt := range tests {
+		w := serve(h, http.MethodOptions, "https://foo.com", tt.method, tt.headers)
+		if w.Code != http.StatusNoContent {
+			t.Fatalf("Expect status %d, got %d", http.StatusNoContent, w.Code)
+		}
+		if got := len(w.Header().Get("Access-Control-Allow-Origin")) > 0; got != tt.allowed {
+			t.Fatalf("Expect %s with %q allowed = %t, got headers %v", tt.method, tt.headers, tt.allowed, w.Header())
+		}
+	}
+}
+
+func newHandler(t *testing.T, cfg *cors.Config) http.Handler {
+	t.Helper()
+
+	mw, err := cors.NewMiddleware(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return mw(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+}
+
+func serve(h http.Handler, method, origin, reqMethod, reqHeaders string) *httptest.ResponseRecorder {
+	r := httptest.NewRequestWithContext(context.Background(), method, "/", nil)
+	if len(origin) > 0 {
+		r.Header.Set("Origin", origin)
+	}
+	if len(reqMethod) > 0 {
+		r.Header.Set("Access-Control-Request-Method", reqMethod)
+	}
+	if len(reqHeaders) > 0 {
+		r.Header.Set("Access-Control-Request-Headers", reqHeaders)
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
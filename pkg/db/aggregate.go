@@ -0,0 +1,81 @@
+package db
+
+import "strings"
+
+// Count returns the selected column "COUNT(col)", where col can be "*".
+func Count(col string) string {
+	return "COUNT(" + col + ")"
+}
+
+// CountDistinct returns the selected column "COUNT(DISTINCT col)".
+func CountDistinct(col string) string {
+	return "COUNT(DISTINCT " + col + ")"
+}
+
+// Sum returns the selected column "SUM(col)".
+func Sum(col string) string {
+	return "SUM(" + col + ")"
+}
+
+// Avg returns the selected column "AVG(col)".
+func Avg(col string) string {
+	return "AVG(" + col + ")"
+}
+
+// Min returns the selected column "MIN(col)".
+func Min(col string) string {
+	return "MIN(" + col + ")"
+}
+
+// Max returns the selected column "MAX(col)".
+func Max(col string) string {
+	return "MAX(" + col + ")"
+}
+
+// As returns the selected column "col AS alias", where col can be a column name or an aggregate function.
+func As(col, alias string) string {
+	return col + " AS " + alias
+}
+
+// expr writes name if it's an expression in the form of "FUNC(col)", "FUNC(DISTINCT col)" or "expr AS alias", and
+// reports whether it's written. Function names are written as is, while column names and aliases are escaped. Other
+// arguments like "1" or "a + b" are written as is.
+func (w *stmtWriter) expr(name string) bool {
+	if i := strings.LastIndex(name, " AS "); i > 0 && isPlainIdent(name[i+len(" AS "):]) {
+		w.col(name[:i])
+		w.buf = append(w.buf, " AS "...)
+		w.ident(name[i+len(" AS "):])
+		return true
+	}
+
+	if len(name) == 0 || name[len(name)-1] != ')' {
+		return false
+	}
+	i := strings.IndexByte(name, '(')
+	if i <= 0 || !isPlainIdent(name[:i]) {
+		return false
+	}
+	w.buf = append(w.buf, name[:i+1]...)
+	arg := name[i+1 : len(name)-1]
+	if rest, ok := strings.CutPrefix(arg, "DISTINCT "); ok {
+		w.buf = append(w.buf, "DISTINCT "...)
+		arg = rest
+	}
+	if arg == "*" || isQualifiedIdent(arg) {
+		w.col(arg)
+	} else {
+		w.str(arg)
+	}
+	w.buf = append(w.buf, ')')
+	return true
+}
+
+// isQualifiedIdent reports whether name consists of plain identifiers separated by dots, e.g. "users.id".
+func isQualifiedIdent(name string) bool {
+	for part := range strings.SplitSeq(name, ".") {
+		if len(part) == 0 || !isPlainIdent(part) {
+			return false
+		}
+	}
+	return true
+}
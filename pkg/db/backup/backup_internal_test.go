@@ -0,0 +1,63 @@
+package backup // nolint:testpackage
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestMysqlArgs(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		dsn      string
+		wantEnv  []string
+		wantArgs []string
+		wantErr  bool
+	}{
+		{"u:p@tcp(h:3306)/db", []string{"MYSQL_PWD=p"}, []string{"--user=u", "--host=h", "--port=3306", "db"}, false},
+		{"u:p@tcp(h)/db?loc=Asia/Shanghai", []string{"MYSQL_PWD=p"}, []string{"--user=u", "--host=h", "db"}, false},
+		{"u:p/?@x@unix(/tmp/mysql.sock)/db?parseTime=true", []string{"MYSQL_PWD=p/?@x"},
+			[]string{"--user=u", "--socket=/tmp/mysql.sock", "db"}, false},
+		{"u@tcp(h)/db", nil, []string{"--user=u", "--host=h", "db"}, false},
+		{"u:p@tcp(h)?loc=Asia/Shanghai", nil, nil, true},
+	}
+	for _, tt := range tests {
+		env, args, err := mysqlArgs(tt.dsn)
+		if (err != nil) != tt.wantErr {
+			t.Fatalf("[%s] Want error = %t, got %+v", tt.dsn, tt.wantErr, err)
+		}
+		if !slices.Equal(env, tt.wantEnv) || !slices.Equal(args, tt.wantArgs) {
+			t.Fatalf("[%s] Want %v %v, got %v %v", tt.dsn, tt.wantEnv, tt.wantArgs, env, args)
+		}
+	}
+}
+
+func TestPgArgs(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		dsn     string
+		wantEnv []string
+		wantDSN string
+		wantErr bool
+	}{
+		{"postgres://u:p%40ss@h:5432/db?sslmode=disable", []string{"PGPASSWORD=p@ss"},
+			"postgres://u@h:5432/db?sslmode=disable", false},
+		{"postgresql://u@h/db?password=p", []string{"PGPASSWORD=p"}, "postgresql://u@h/db", false},
+		{"postgres://u@h/db", nil, "postgres://u@h/db", false},
+		{"host=h password='p \\' q' dbname=db", []string{"PGPASSWORD=p ' q"}, "host=h dbname=db", false},
+		{"host=h  password = p  application_name='a b'", []string{"PGPASSWORD=p"}, "host=h application_name='a b'",
+			false},
+		{"host=h password='p", nil, "", true},
+		{"host", nil, "", true},
+	}
+	for _, tt := range tests {
+		env, dsn, err := pgArgs(tt.dsn)
+		if (err != nil) != tt.wantErr {
+			t.Fatalf("[%s] Want error = %t, got %+v", tt.dsn, tt.wantErr, err)
+		}
+		if !slices.Equal(env, tt.wantEnv) || dsn != tt.wantDSN {
+			t.Fatalf("[%s] Want %v %s, got %v %s", tt.dsn, tt.wantEnv, tt.wantDSN, env, dsn)
+		}
+	}
+}
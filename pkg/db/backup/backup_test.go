@@ -0,0 +1,139 @@
+package backup_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+	_ "github.com/mattn/go-sqlite3" // Register the sqlite3 driver.
+	"github.com/sainnhe/go-common/pkg/constant"
+	"github.com/sainnhe/go-common/pkg/db"
+	"github.com/sainnhe/go-common/pkg/db/backup"
+)
+
+func TestBackup(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	src := &db.Config{Driver: "sqlite3", DSN: filepath.Join(t.TempDir(), "src.db")}
+	dst := &db.Config{Driver: "sqlite3", DSN: filepath.Join(t.TempDir(), "dst.db")}
+	exec := func(cfg *db.Config, stmts ...string) *sqlx.DB {
+		t.Helper()
+
+		pool, err := sqlx.Open(cfg.Driver, cfg.DSN)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() {
+			_ = pool.Close()
+		})
+		for _, stmt := range stmts {
+			if _, err := pool.ExecContext(ctx, stmt); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return pool
+	}
+	exec(src,
+		"CREATE TABLE users (id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT NOT NULL)",
+		"CREATE INDEX idx_users_name ON users (name)",
+		"CREATE TABLE logs (id INTEGER PRIMARY KEY, user_id INTEGER NOT NULL REFERENCES users (id))",
+		"CREATE TRIGGER trg_users AFTER INSERT ON users BEGIN INSERT INTO logs (user_id) VALUES (NEW.id); END",
+		"INSERT INTO users (name) VALUES ('alice'), ('bob')",
+		"DELETE FROM users WHERE name = 'bob'",
+	)
+	// The target database has stale tables and data to be replaced.
+	pool := exec(dst,
+		"CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT NOT NULL, age INTEGER)",
+		"CREATE TABLE stale (id INTEGER PRIMARY KEY)",
+		"CREATE VIEW v_users AS SELECT name FROM users",
+		"INSERT INTO users (name) VALUES ('carol')",
+	)
+
+	buf := &bytes.Buffer{}
+	if err := backup.Dump(ctx, src, buf); err != nil {
+		t.Fatal(err)
+	}
+	if err := backup.Verify(ctx, src, bytes.NewReader(buf.Bytes())); err != nil {
+		t.Fatal(err)
+	}
+	if err := backup.Restore(ctx, dst, bytes.NewReader(buf.Bytes())); err != nil {
+		t.Fatal(err)
+	}
+
+	names := []string{}
+	stmt := "SELECT name FROM sqlite_master WHERE name NOT LIKE 'sqlite_%' ORDER BY name"
+	if err := pool.Select(&names, stmt); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"idx_users_name", "logs", "trg_users", "users"}; !reflect.DeepEqual(names, want) {
+		t.Fatalf("Expect objects %v, got %v", want, names)
+	}
+	var logs int
+	if err := pool.Get(&logs, "SELECT COUNT(*) FROM logs"); err != nil || logs != 2 {
+		t.Fatalf("Expect 2 logs, got %d, err = %+v", logs, err)
+	}
+	// The AUTOINCREMENT sequence and triggers are restored.
+	if _, err := pool.Exec("INSERT INTO users (name) VALUES ('dave')"); err != nil {
+		t.Fatal(err)
+	}
+	ids := []int64{}
+	if err := pool.Select(&ids, "SELECT id FROM users ORDER BY id"); err != nil {
+		t.Fatal(err)
+	}
+	if want := []int64{1, 3}; !reflect.DeepEqual(ids, want) {
+		t.Fatalf("Expect IDs %v, got %v", want, ids)
+	}
+	if err := pool.Get(&logs, "SELECT COUNT(*) FROM logs"); err != nil || logs != 3 {
+		t.Fatalf("Expect 3 logs, got %d, err = %+v", logs, err)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	tests := []struct {
+		driver string
+		backup string
+		err    error
+	}{
+		{"sqlite3", "SQLite format 3\x00", backup.ErrCorrupted},
+		{"sqlite3", "CREATE TABLE users", backup.ErrCorrupted},
+		{"pgx", "--\n-- PostgreSQL database dump\n--\nCREATE TABLE users", backup.ErrCorrupted},
+		{"pgx", "CREATE TABLE users;\n" + strings.Repeat("\n", 5000) + "-- PostgreSQL database dump complete\n", nil},
+		{"mysql", "CREATE TABLE users;\n-- Dump completed on 2026-01-01  0:00:00\n", nil},
+		{"mysql", "CREATE TABLE users;\n", backup.ErrCorrupted},
+		{"unknown", "", db.ErrUnsupportedDriver},
+	}
+	for _, tt := range tests {
+		err := backup.Verify(ctx, &db.Config{Driver: tt.driver}, strings.NewReader(tt.backup))
+		if !errors.Is(err, tt.err) {
+			t.Fatalf("Expect error %+v for %q, got %+v", tt.err, tt.backup, err)
+		}
+	}
+}
+
+func TestUnsupported(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	cfg := &db.Config{Driver: "unknown"}
+	if err := backup.Dump(ctx, nil, &bytes.Buffer{}); !errors.Is(err, constant.ErrNilDeps) {
+		t.Fatalf("Expect error %+v, got %+v", constant.ErrNilDeps, err)
+	}
+	if err := backup.Dump(ctx, cfg, &bytes.Buffer{}); !errors.Is(err, db.ErrUnsupportedDriver) {
+		t.Fatalf("Expect error %+v, got %+v", db.ErrUnsupportedDriver, err)
+	}
+	if err := backup.Restore(ctx, cfg, &bytes.Buffer{}); !errors.Is(err, db.ErrUnsupportedDriver) {
+		t.Fatalf("Expect error %+v, got %+v", db.ErrUnsupportedDriver, err)
+	}
+	if err := backup.Restore(ctx, &db.Config{Driver: "mysql", DSN: "invalid"}, &bytes.Buffer{}); err == nil {
+		t.Fatal("Expect error for invalid DSN")
+	}
+}
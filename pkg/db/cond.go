@@ -0,0 +1,145 @@
+package db
+
+/*
+Cond is a condition tree used to build WHERE clauses, which can be built via [And], [Or], [Not], [Cmp] and [KV].
+
+For example, the following condition:
+
+	db.And(
+		db.Or(db.KV{Key: "a", Val: "?"}, db.KV{Key: "b", Val: "?"}),
+		db.Cmp("c", ">", "?"),
+	)
+
+is built as "(a = ? OR b = ?) AND c > ?".
+
+Like mapped building, keys and values are written to the statement as is, so make sure they are safe and use
+placeholders for untrusted values. See [StmtBuilder] for more details.
+*/
+type Cond interface {
+	// empty reports whether the condition doesn't write anything, in which case the WHERE clause is omitted.
+	empty() bool
+
+	// write writes the condition. If nested is true, the condition is an operand of another condition and should be
+	// wrapped in parentheses if it contains multiple operands.
+	write(w *stmtWriter, nested bool)
+}
+
+func (kv KV) empty() bool {
+	return false
+}
+
+// write writes "key = val".
+func (kv KV) write(w *stmtWriter, _ bool) {
+	w.str(kv.Key)
+	w.buf = append(w.buf, " = "...)
+	w.str(kv.Val)
+}
+
+// cmpCond is a binary comparison.
+type cmpCond struct {
+	key string
+	op  string
+	val string
+}
+
+// Cmp returns a condition that compares key and val with the given operator, for example Cmp("age", ">=", "?") is built
+// as "age >= ?". Other binary operators like "LIKE", "IN" and "IS" can be used as well, e.g. Cmp("id", "IN", "(?, ?)").
+func Cmp(key, op, val string) Cond {
+	return cmpCond{key, op, val}
+}
+
+func (c cmpCond) empty() bool {
+	return false
+}
+
+func (c cmpCond) write(w *stmtWriter, _ bool) {
+	w.str(c.key)
+	w.buf = append(w.buf, ' ')
+	w.buf = append(w.buf, c.op...)
+	w.buf = append(w.buf, ' ')
+	w.str(c.val)
+}
+
+// groupCond joins conditions with a logical operator.
+type groupCond struct {
+	op    string
+	conds []Cond
+}
+
+// And returns a condition that is true if all of the given conditions are true. Nil and empty conditions are ignored.
+func And(conds ...Cond) Cond {
+	return groupCond{" AND ", conds}
+}
+
+// Or returns a condition that is true if any of the given conditions is true. Nil and empty conditions are ignored.
+func Or(conds ...Cond) Cond {
+	return groupCond{" OR ", conds}
+}
+
+// operands returns the number of non-empty conditions.
+func (g groupCond) operands() int {
+	n := 0
+	for _, c := range g.conds {
+		if c != nil && !c.empty() {
+			n++
+		}
+	}
+	return n
+}
+
+func (g groupCond) empty() bool {
+	return g.operands() == 0
+}
+
+func (g groupCond) write(w *stmtWriter, nested bool) {
+	n := g.operands()
+	paren := nested && n > 1
+	if paren {
+		w.buf = append(w.buf, '(')
+	}
+	i := 0
+	for _, c := range g.conds {
+		if c == nil || c.empty() {
+			continue
+		}
+		if i > 0 {
+			w.buf = append(w.buf, g.op...)
+		}
+		// A single operand is written as if it were not grouped.
+		c.write(w, nested || n > 1)
+		i++
+	}
+	if paren {
+		w.buf = append(w.buf, ')')
+	}
+}
+
+// notCond negates a condition.
+type notCond struct {
+	cond Cond
+}
+
+// Not returns a condition that negates the given condition. If the given condition is nil or empty, the returned
+// condition is empty as well.
+func Not(cond Cond) Cond {
+	return notCond{cond}
+}
+
+func (c notCond) empty() bool {
+	return c.cond == nil || c.cond.empty()
+}
+
+func (c notCond) write(w *stmtWriter, _ bool) {
+	w.buf = append(w.buf, "NOT ("...)
+	c.cond.write(w, false)
+	w.buf = append(w.buf, ')')
+}
+
+// cond writes the WHERE clause of the given condition.
+func (w *stmtWriter) cond(cond Cond) {
+	if cond == nil || cond.empty() {
+		return
+	}
+	w.buf = append(w.buf, " WHERE "...)
+	cond.write(w, false)
+}
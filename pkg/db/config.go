@@ -5,5 +5,20 @@ package db
 // [github.com/go-sql-driver/mysql].
 type Config struct {
 	Driver string `json:"driver,omitempty" yaml:"driver" toml:"driver" xml:"driver"`
-	DSN    string `json:"dsn,omitempty" yaml:"dsn" toml:"dsn" xml:"dsn"`
+	DSN    string `json:"dsn,omitempty" yaml:"dsn" toml:"dsn" xml:"dsn" secret:"true"`
+
+	// MaxOpenConns is the maximum number of open connections. Set to 0 for unlimited.
+	MaxOpenConns int `json:"max_open_conns" yaml:"max_open_conns" toml:"max_open_conns" xml:"max_open_conns" env:"DB_MAX_OPEN_CONNS" default:"25"` // nolint:lll
+
+	// MaxIdleConns is the maximum number of idle connections. It should be less than or equal to MaxOpenConns. Set to 0
+	// to keep the default of database/sql, which is 2.
+	MaxIdleConns int `json:"max_idle_conns" yaml:"max_idle_conns" toml:"max_idle_conns" xml:"max_idle_conns" env:"DB_MAX_IDLE_CONNS" default:"25"` // nolint:lll
+
+	// ConnMaxLifetimeMs is the maximum amount of time a connection may be reused in milliseconds. Set to 0 to reuse
+	// connections forever.
+	ConnMaxLifetimeMs int64 `json:"conn_max_lifetime_ms" yaml:"conn_max_lifetime_ms" toml:"conn_max_lifetime_ms" xml:"conn_max_lifetime_ms" env:"DB_CONN_MAX_LIFETIME_MS" default:"1800000"` // nolint:lll
+
+	// ConnMaxIdleTimeMs is the maximum amount of time a connection may be idle in milliseconds. Set to 0 to keep idle
+	// connections forever.
+	ConnMaxIdleTimeMs int64 `json:"conn_max_idle_time_ms" yaml:"conn_max_idle_time_ms" toml:"conn_max_idle_time_ms" xml:"conn_max_idle_time_ms" env:"DB_CONN_MAX_IDLE_TIME_MS" default:"300000"` // nolint:lll
 }
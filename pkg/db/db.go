@@ -30,6 +30,32 @@ type Repo[DO any] interface {
 
 	// BeginTx begins a transaction.
 	BeginTx(ctx context.Context, opts *sql.TxOptions) (*sqlx.Tx, error)
+
+	// SoftDelete marks a record as deleted by setting its delete time, see [SoftDeleteDO]. Soft-deleted records are
+	// excluded from queries.
+	// If the data object doesn't have a *time.Time field tagged with `db:"delete_time"`, return [ErrNoDeleteTimeField].
+	// If no record is found or it has been soft deleted, return [sql.ErrNoRows].
+	SoftDelete(ctx context.Context, d *DO) error
+
+	// Restore restores a soft-deleted record by clearing its delete time.
+	// If the data object doesn't have a *time.Time field tagged with `db:"delete_time"`, return [ErrNoDeleteTimeField].
+	// If no record is found or it is not soft deleted, return [sql.ErrNoRows].
+	Restore(ctx context.Context, d *DO) error
+}
+
+// ConditionalUpdater is an optional interface of [Repo] implementations that support optimistic concurrency, where the
+// update time is the version of a record. [SQLRepo] and [MemoryRepo] implement it, and it can be detected via a type
+// assertion:
+//
+//	if u, ok := repo.(db.ConditionalUpdater[User]); ok {
+//		err = u.UpdateIfUnmodified(ctx, user)
+//	}
+type ConditionalUpdater[DO any] interface {
+	// UpdateIfUnmodified updates a record only if its update time is still the update time of d.
+	// If the data object doesn't have a time.Time field tagged with `db:"update_time"`, return [ErrNoUpdateTimeField].
+	// If no record is found, it has been soft deleted or it has been modified since d was queried, return
+	// [ErrConflict].
+	UpdateIfUnmodified(ctx context.Context, d *DO) error
 }
 
 // DO defines a common data object. You should embed this struct in your own data object.
@@ -51,6 +77,23 @@ type DO struct {
 	Ext string `db:"ext"`
 }
 
+// DeleteTimeCol is the column of the delete time of [SoftDeleteDO]. [SQLRepo] and [MemoryRepo] support soft delete if
+// the data object has a *time.Time field tagged with it, and it can be passed to [StmtBuilder.WithSoftDelete] when
+// building custom statements.
+const DeleteTimeCol = "delete_time"
+
+// SoftDeleteDO is a [DO] that supports soft delete via [Repo.SoftDelete]. You should embed this struct instead of [DO]
+// in your own data object if records should be soft deleted.
+type SoftDeleteDO struct {
+	DO
+
+	// DeleteTime is the time when a record is soft deleted, which is nil if the record is not deleted.
+	//
+	// NOTE: The delete_time column should be nullable, and it's recommended to index it together with frequently
+	// queried columns.
+	DeleteTime *time.Time `db:"delete_time"`
+}
+
 // DOCols contains the column names of [DO].
 var DOCols = []string{
 	"id",
@@ -59,7 +102,17 @@ var DOCols = []string{
 	"ext",
 }
 
-// NewPool initializes a new database connection pool.
+// SoftDeleteDOCols contains the column names of [SoftDeleteDO].
+var SoftDeleteDOCols = []string{
+	"id",
+	"create_time",
+	"update_time",
+	"ext",
+	DeleteTimeCol,
+}
+
+// NewPool initializes a new database connection pool, applies the pool settings in [Config], and checks the connection
+// with [HealthCheck].
 func NewPool(cfg *Config) (pool *sqlx.DB, cleanup func(), err error) {
 	if cfg == nil {
 		err = constant.ErrNilDeps
@@ -69,9 +122,13 @@ func NewPool(cfg *Config) (pool *sqlx.DB, cleanup func(), err error) {
 	if err != nil {
 		return
 	}
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(3)*time.Second) // nolint:mnd
-	defer cancel()
-	err = pool.PingContext(ctx)
+	pool.SetMaxOpenConns(cfg.MaxOpenConns)
+	if cfg.MaxIdleConns > 0 {
+		pool.SetMaxIdleConns(cfg.MaxIdleConns)
+	}
+	pool.SetConnMaxLifetime(time.Duration(cfg.ConnMaxLifetimeMs) * time.Millisecond)
+	pool.SetConnMaxIdleTime(time.Duration(cfg.ConnMaxIdleTimeMs) * time.Millisecond)
+	err = HealthCheck(context.Background(), pool)
 	cleanup = func() {
 		if err := pool.Close(); err != nil {
 			log.NewLogger("github.com/sainnhe/go-common/pkg/db").Error(
@@ -80,3 +137,20 @@ func NewPool(cfg *Config) (pool *sqlx.DB, cleanup func(), err error) {
 	}
 	return
 }
+
+// defaultPingTimeout is the timeout of [HealthCheck] if the context has no deadline.
+const defaultPingTimeout = 3 * time.Second
+
+// HealthCheck pings the database to verify that it's reachable, which is suitable for readiness probes. If ctx has no
+// deadline, a default timeout of 3 seconds is applied.
+func HealthCheck(ctx context.Context, pool *sqlx.DB) error {
+	if pool == nil {
+		return constant.ErrNilDeps
+	}
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, defaultPingTimeout)
+		defer cancel()
+	}
+	return pool.PingContext(ctx)
+}
@@ -99,6 +99,34 @@ func (mr *MockRepoMockRecorder[DO]) QueryByID(ctx, id any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "QueryByID", reflect.TypeOf((*MockRepo[DO])(nil).QueryByID), ctx, id)
 }
 
+// Restore mocks base method.
+func (m *MockRepo[DO]) Restore(ctx context.Context, d *DO) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Restore", ctx, d)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Restore indicates an expected call of Restore.
+func (mr *MockRepoMockRecorder[DO]) Restore(ctx, d any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Restore", reflect.TypeOf((*MockRepo[DO])(nil).Restore), ctx, d)
+}
+
+// SoftDelete mocks base method.
+func (m *MockRepo[DO]) SoftDelete(ctx context.Context, d *DO) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SoftDelete", ctx, d)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SoftDelete indicates an expected call of SoftDelete.
+func (mr *MockRepoMockRecorder[DO]) SoftDelete(ctx, d any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SoftDelete", reflect.TypeOf((*MockRepo[DO])(nil).SoftDelete), ctx, d)
+}
+
 // Update mocks base method.
 func (m *MockRepo[DO]) Update(ctx context.Context, d *DO) error {
 	m.ctrl.T.Helper()
@@ -112,3 +140,41 @@ func (mr *MockRepoMockRecorder[DO]) Update(ctx, d any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockRepo[DO])(nil).Update), ctx, d)
 }
+
+// MockConditionalUpdater is a mock of ConditionalUpdater interface.
+type MockConditionalUpdater[DO any] struct {
+	ctrl     *gomock.Controller
+	recorder *MockConditionalUpdaterMockRecorder[DO]
+	isgomock struct{}
+}
+
+// MockConditionalUpdaterMockRecorder is the mock recorder for MockConditionalUpdater.
+type MockConditionalUpdaterMockRecorder[DO any] struct {
+	mock *MockConditionalUpdater[DO]
+}
+
+// NewMockConditionalUpdater creates a new mock instance.
+func NewMockConditionalUpdater[DO any](ctrl *gomock.Controller) *MockConditionalUpdater[DO] {
+	mock := &MockConditionalUpdater[DO]{ctrl: ctrl}
+	mock.recorder = &MockConditionalUpdaterMockRecorder[DO]{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockConditionalUpdater[DO]) EXPECT() *MockConditionalUpdaterMockRecorder[DO] {
+	return m.recorder
+}
+
+// UpdateIfUnmodified mocks base method.
+func (m *MockConditionalUpdater[DO]) UpdateIfUnmodified(ctx context.Context, d *DO) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateIfUnmodified", ctx, d)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateIfUnmodified indicates an expected call of UpdateIfUnmodified.
+func (mr *MockConditionalUpdaterMockRecorder[DO]) UpdateIfUnmodified(ctx, d any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateIfUnmodified", reflect.TypeOf((*MockConditionalUpdater[DO])(nil).UpdateIfUnmodified), ctx, d)
+}
@@ -0,0 +1,91 @@
+/*
+Package dbtest implements a test harness backed by in-memory SQLite databases, so that repo logic can be tested without
+running a MySQL or PostgreSQL server:
+
+	func TestUserRepo(t *testing.T) {
+		repo := dbtest.NewRepo[User](t, "users", []string{`CREATE TABLE users (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			create_time DATETIME NOT NULL,
+			update_time DATETIME NOT NULL,
+			ext TEXT NOT NULL DEFAULT '',
+			name TEXT NOT NULL
+		)`})
+		...
+	}
+
+Each call creates a new database, which is closed when the test and its subtests complete. Note that SQLite differs from
+other databases in types and locking, so statements specific to a database should still be tested against it.
+
+This package requires cgo since it uses github.com/mattn/go-sqlite3.
+*/
+package dbtest
+
+import (
+	"fmt"
+	"sync/atomic"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+	_ "github.com/mattn/go-sqlite3" // Register the sqlite3 driver.
+	"github.com/sainnhe/go-common/pkg/db"
+)
+
+// DriverName is the driver name of pools created by [NewPool].
+const DriverName = "sqlite3"
+
+var seq atomic.Int64
+
+/*
+NewPool opens a new in-memory SQLite database and runs the given DDL statements in order. The test fails immediately if
+any of them fails.
+
+The pool is limited to a single connection, since every connection to an in-memory database opens a separate database.
+As a result, statements executed outside a transaction block until the transaction completes.
+
+Params:
+  - t: The test.
+  - ddl: The DDL statements like CREATE TABLE.
+
+Returns:
+  - *sqlx.DB: The connection pool, which is closed by [testing.TB.Cleanup].
+*/
+func NewPool(t testing.TB, ddl ...string) *sqlx.DB {
+	t.Helper()
+
+	pool, err := sqlx.Open(DriverName, fmt.Sprintf("file:dbtest%d?mode=memory&_foreign_keys=on", seq.Add(1)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool.SetMaxOpenConns(1)
+	t.Cleanup(func() {
+		_ = pool.Close()
+	})
+	for _, stmt := range ddl {
+		if _, err := pool.Exec(stmt); err != nil {
+			t.Fatalf("Execute DDL failed: %+v\n%s", err, stmt)
+		}
+	}
+	return pool
+}
+
+/*
+NewRepo opens a new in-memory SQLite database with [NewPool], and initializes a [db.SQLRepo] of table tbl on it.
+
+Params:
+  - t: The test.
+  - tbl: The table name.
+  - ddl: The DDL statements like CREATE TABLE, which should create tbl.
+  - opts: The options of the repo.
+
+Returns:
+  - *db.SQLRepo[DO]: The repo.
+*/
+func NewRepo[DO any](t testing.TB, tbl string, ddl []string, opts ...db.RepoOption) *db.SQLRepo[DO] {
+	t.Helper()
+
+	repo, err := db.NewRepo[DO](NewPool(t, ddl...), tbl, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return repo
+}
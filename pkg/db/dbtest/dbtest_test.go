@@ -0,0 +1,125 @@
+package dbtest_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/sainnhe/go-common/pkg/db"
+	"github.com/sainnhe/go-common/pkg/db/dbtest"
+)
+
+const ddl = `CREATE TABLE users (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	create_time DATETIME NOT NULL,
+	update_time DATETIME NOT NULL,
+	ext TEXT NOT NULL DEFAULT '',
+	delete_time DATETIME,
+	name TEXT NOT NULL
+)`
+
+type user struct {
+	db.SoftDeleteDO
+	Name string `db:"name"`
+}
+
+func TestNewRepo(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	repo := dbtest.NewRepo[user](t, "users", []string{ddl})
+
+	// Insert
+	u := &user{Name: "Alice"}
+	if err := repo.Insert(ctx, u); err != nil {
+		t.Fatal(err)
+	}
+	if u.ID != 1 || u.CreateTime.IsZero() {
+		t.Fatalf("Unexpected user %+v", u)
+	}
+
+	// Update
+	u.Name = "Bob"
+	if err := repo.Update(ctx, u); err != nil {
+		t.Fatal(err)
+	}
+	got, err := repo.QueryByID(ctx, u.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Name != "Bob" || !got.CreateTime.Equal(u.CreateTime) {
+		t.Fatalf("Unexpected user %+v", got)
+	}
+
+	// Soft delete
+	if err = repo.SoftDelete(ctx, u); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = repo.QueryByID(ctx, u.ID); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("Want %+v, got %+v", sql.ErrNoRows, err)
+	}
+	deleted := &user{SoftDeleteDO: db.SoftDeleteDO{DO: db.DO{ID: u.ID}}, Name: "Carol"}
+	if err = repo.Update(ctx, deleted); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("Want %+v, got %+v", sql.ErrNoRows, err)
+	}
+
+	// Restore
+	if err = repo.Restore(ctx, u); err != nil || u.DeleteTime != nil {
+		t.Fatalf("Unexpected user %+v, %+v", u, err)
+	}
+	if err = repo.Restore(ctx, u); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("Want %+v, got %+v", sql.ErrNoRows, err)
+	}
+	if got, err = repo.QueryByID(ctx, u.ID); err != nil || got.Name != "Bob" {
+		t.Fatalf("Unexpected user %+v, %+v", got, err)
+	}
+
+	// Delete
+	if err = repo.Delete(ctx, u); err != nil {
+		t.Fatal(err)
+	}
+	if err = repo.Delete(ctx, u); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("Want %+v, got %+v", sql.ErrNoRows, err)
+	}
+}
+
+func TestNewPool(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	pool := dbtest.NewPool(t, ddl)
+	if pool.DriverName() != dbtest.DriverName {
+		t.Fatalf("Unexpected driver %s", pool.DriverName())
+	}
+
+	// Databases are isolated from each other.
+	if _, err := dbtest.NewPool(t).ExecContext(ctx, "SELECT * FROM users"); err == nil {
+		t.Fatal("Expect error, got nil")
+	}
+
+	// Transactions are supported.
+	repo, err := db.NewRepo[user](pool, "users")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = db.WithTx(ctx, pool, nil, func(tx *sqlx.Tx) error {
+		_, err := tx.ExecContext(ctx, repo.StmtBuilder().BuildMappedInsertStmt([]db.KV{
+			{Key: "create_time", Val: "CURRENT_TIMESTAMP"},
+			{Key: "update_time", Val: "CURRENT_TIMESTAMP"},
+			{Key: "name", Val: db.Placeholder},
+		}), "Alice")
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var users []user
+	if err = pool.SelectContext(ctx, &users, repo.StmtBuilder().BuildKeysetQueryStmt(nil, "id", nil, 10)); err != nil {
+		t.Fatal(err)
+	}
+	if len(users) != 1 || users[0].Name != "Alice" {
+		t.Fatalf("Unexpected users %+v", users)
+	}
+}
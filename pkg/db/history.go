@@ -0,0 +1,141 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"reflect"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// HistoryTableSuffix is appended to the table name of a repo to get the name of its history table, see [WithHistory].
+const HistoryTableSuffix = "_history"
+
+// HistoryCols contains the column names of history tables written by [WithHistory].
+var HistoryCols = []string{
+	"record_id",
+	"operation",
+	"old_values",
+	"actor",
+	"create_time",
+}
+
+// history writes history rows of a repo.
+type history struct {
+	queryStmt  string
+	insertStmt string
+	actor      func(ctx context.Context) string
+}
+
+/*
+WithHistory makes [SQLRepo.Update] and [SQLRepo.Delete] write a history row into the table named after the repo table
+with [HistoryTableSuffix], which is useful for lightweight auditing without triggers. The history row contains the
+record ID, the operation ("Update" or "Delete"), the old values in a JSON object keyed by column names, the actor and
+the time of the change. For example, the history table of "users" in PostgreSQL can be created by:
+
+	CREATE TABLE users_history (
+		id BIGSERIAL PRIMARY KEY,
+		record_id BIGINT NOT NULL,
+		operation VARCHAR(16) NOT NULL,
+		old_values TEXT NOT NULL,
+		actor VARCHAR(255) NOT NULL,
+		create_time TIMESTAMP NOT NULL
+	);
+
+The old values are read, the record is changed and the history row is written in the same transaction, so the change
+is rolled back if writing the history row fails.
+
+Params:
+  - actor: The function that returns the actor of the change from the context, like the authenticated user. If nil,
+    the actor is empty.
+*/
+func WithHistory(actor func(ctx context.Context) string) RepoOption {
+	return func(opts *repoOptions) {
+		opts.history = true
+		opts.actor = actor
+	}
+}
+
+// newHistory initializes the history writer of the table of sb, where cols are the columns of the table.
+func newHistory(sb StmtBuilder, cols []string, actor func(ctx context.Context) string) *history {
+	historySB := NewStmtBuilder(sb.GetTbl()+HistoryTableSuffix, sb.GetDri())
+	vals := make([]KV, 0, len(HistoryCols))
+	for _, col := range HistoryCols {
+		vals = append(vals, KV{Key: col, Val: Placeholder})
+	}
+	return &history{
+		sb.BuildQueryCondStmt(cols, KV{Key: "id", Val: Placeholder}, WithDeleted()),
+		historySB.BuildMappedInsertStmt(vals),
+		actor,
+	}
+}
+
+// execWithHistory executes a named statement like exec, and writes the old values of d into the history table in the
+// same transaction.
+func (r *SQLRepo[DO]) execWithHistory(ctx context.Context, stmt string, args int, d *DO, arg any, op string) error {
+	id, ok := taggedField(d, "id")
+	if !ok {
+		return ErrNoIDField
+	}
+	return WithTx(ctx, r.pool, nil, func(tx *sqlx.Tx) error {
+		old := new(DO)
+		if err := tx.GetContext(ctx, old, r.history.queryStmt, id.Int()); err != nil {
+			return err
+		}
+
+		start := time.Now()
+		result, err := tx.NamedExecContext(ctx, stmt, arg)
+		r.runHooks(ctx, stmt, args, start, err)
+		if err = checkRowsAffected(result, err); err != nil {
+			return err
+		}
+
+		oldValues, err := json.Marshal(taggedValues(reflect.ValueOf(old).Elem()))
+		if err != nil {
+			return err
+		}
+		actor := ""
+		if r.history.actor != nil {
+			actor = r.history.actor(ctx)
+		}
+		_, err = tx.ExecContext(ctx, r.history.insertStmt, id.Int(), op, string(oldValues), actor, time.Now())
+		return err
+	})
+}
+
+// checkRowsAffected returns err if it's not nil, or [sql.ErrNoRows] if no rows are affected.
+func checkRowsAffected(result sql.Result, err error) error {
+	if err != nil {
+		return err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// taggedValues returns the values of the fields of val keyed by their db tags, including fields of embedded structs.
+func taggedValues(val reflect.Value) map[string]any {
+	values := map[string]any{}
+	typ := val.Type()
+	for i := range typ.NumField() {
+		field := typ.Field(i)
+		tag := field.Tag.Get("db")
+		switch {
+		case tag == "-":
+		case len(tag) > 0:
+			values[tag] = val.Field(i).Interface()
+		case field.Anonymous && field.Type.Kind() == reflect.Struct:
+			for k, v := range taggedValues(val.Field(i)) {
+				values[k] = v
+			}
+		}
+	}
+	return values
+}
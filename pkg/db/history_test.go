@@ -0,0 +1,96 @@
+package db_test
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/sainnhe/go-common/pkg/db"
+	"github.com/sainnhe/go-common/pkg/db/dbtest"
+)
+
+type actorKey struct{}
+
+func TestWithHistory(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.WithValue(context.Background(), actorKey{}, "alice")
+	pool := dbtest.NewPool(t, `CREATE TABLE users (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		create_time DATETIME NOT NULL,
+		update_time DATETIME NOT NULL,
+		ext TEXT NOT NULL DEFAULT '',
+		name TEXT NOT NULL,
+		age INTEGER NOT NULL
+	)`, `CREATE TABLE users_history (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		record_id INTEGER NOT NULL,
+		operation TEXT NOT NULL,
+		old_values TEXT NOT NULL,
+		actor TEXT NOT NULL,
+		create_time DATETIME NOT NULL
+	)`)
+	repo, err := db.NewRepo[sqlUser](pool, "users", db.WithHistory(func(ctx context.Context) string {
+		actor, _ := ctx.Value(actorKey{}).(string)
+		return actor
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	u := &sqlUser{Name: "Alice"}
+	if err = repo.Insert(ctx, u); err != nil {
+		t.Fatal(err)
+	}
+	u.Name = "Bob"
+	if err = repo.Update(ctx, u); err != nil {
+		t.Fatal(err)
+	}
+	if err = repo.Delete(ctx, u); err != nil {
+		t.Fatal(err)
+	}
+	if err = repo.Delete(ctx, u); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("Want %+v, got %+v", sql.ErrNoRows, err)
+	}
+
+	var rows []struct {
+		RecordID  int64  `db:"record_id"`
+		Operation string `db:"operation"`
+		OldValues string `db:"old_values"`
+		Actor     string `db:"actor"`
+	}
+	if err = pool.SelectContext(ctx, &rows,
+		"SELECT record_id, operation, old_values, actor FROM users_history ORDER BY id"); err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 2 || rows[0].Operation != "Update" || rows[1].Operation != "Delete" ||
+		rows[0].RecordID != u.ID || rows[0].Actor != "alice" {
+		t.Fatalf("Unexpected history %+v", rows)
+	}
+	for i, want := range []string{"Alice", "Bob"} {
+		var old map[string]any
+		if err = json.Unmarshal([]byte(rows[i].OldValues), &old); err != nil {
+			t.Fatal(err)
+		}
+		if old["name"] != want || old["id"] != float64(u.ID) {
+			t.Fatalf("Unexpected old values %+v", old)
+		}
+	}
+
+	// The change is rolled back if the history can't be written.
+	if _, err = pool.ExecContext(ctx, "DROP TABLE users_history"); err != nil {
+		t.Fatal(err)
+	}
+	u = &sqlUser{Name: "Carol"}
+	if err = repo.Insert(ctx, u); err != nil {
+		t.Fatal(err)
+	}
+	if err = repo.Delete(ctx, u); err == nil {
+		t.Fatal("Expect error, got nil")
+	}
+	if _, err = repo.QueryByID(ctx, u.ID); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,44 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/sainnhe/go-common/pkg/constant"
+	"github.com/sainnhe/go-common/pkg/log"
+)
+
+// QueryEvent describes an executed statement.
+type QueryEvent struct {
+	// Stmt is the statement.
+	Stmt string
+
+	// Args is the number of bound parameters.
+	Args int
+
+	// Duration is how long the statement takes.
+	Duration time.Duration
+
+	// Err is the error returned by the driver, if any.
+	Err error
+}
+
+// QueryHook is called after a statement is executed, see [WithQueryHook].
+type QueryHook func(ctx context.Context, e *QueryEvent)
+
+// NewSlowQueryHook returns a [QueryHook] that logs statements taking at least threshold at warn level, with the
+// statement, the number of bound parameters, the duration and the error if any. Bound values are not logged since
+// they may contain sensitive data.
+func NewSlowQueryHook(threshold time.Duration) QueryHook {
+	l := log.NewLogger(pkgName)
+	return func(ctx context.Context, e *QueryEvent) {
+		if e.Duration < threshold {
+			return
+		}
+		attrs := []any{"stmt", e.Stmt, "args", e.Args, "duration", e.Duration}
+		if e.Err != nil {
+			attrs = append(attrs, constant.LogAttrError, e.Err)
+		}
+		l.WarnContext(ctx, "Slow query.", attrs...)
+	}
+}
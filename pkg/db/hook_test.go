@@ -0,0 +1,41 @@
+package db_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sainnhe/go-common/pkg/db"
+	"github.com/sainnhe/go-common/pkg/log"
+)
+
+func TestNewSlowQueryHook(t *testing.T) { // nolint:paralleltest
+	path := filepath.Join(t.TempDir(), "log")
+	cleanup, err := log.SetGlobalConfig(&log.Config{
+		Type:  "local",
+		Level: "debug",
+		Local: log.LocalConfig{Path: path, MaxSizeMB: 1},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer log.SetGlobalConfig(&log.Config{Type: "light", Level: "debug"}) // nolint:errcheck
+
+	hook := db.NewSlowQueryHook(time.Second)
+	ctx := context.Background()
+	hook(ctx, &db.QueryEvent{Stmt: "SELECT 1", Duration: time.Millisecond})
+	hook(ctx, &db.QueryEvent{Stmt: "SELECT pg_sleep(2)", Duration: 2 * time.Second, Err: errors.New("canceled")})
+	cleanup()
+
+	content, err := os.ReadFile(path) // nolint:gosec
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(string(content), "Slow query."); n != 1 || !strings.Contains(string(content), "pg_sleep") {
+		t.Fatalf("Expect 1 slow query log, got %s", content)
+	}
+}
@@ -0,0 +1,181 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/noop"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const pkgName = "github.com/sainnhe/go-common/pkg/db"
+
+// instruments are the metric instruments shared by all instrumented operations. They're created from the global meter
+// provider on first use.
+var instruments = sync.OnceValue(func() (i struct {
+	duration metric.Float64Histogram
+	errors   metric.Int64Counter
+}) {
+	meter := otel.Meter(pkgName)
+	var err error
+	if i.duration, err = meter.Float64Histogram("db.client.operation.duration",
+		metric.WithDescription("Duration of database client operations."),
+		metric.WithUnit("s")); err != nil {
+		i.duration = noop.Float64Histogram{}
+	}
+	if i.errors, err = meter.Int64Counter("db.client.operation.errors",
+		metric.WithDescription("The number of failed database client operations."),
+		metric.WithUnit("{operation}")); err != nil {
+		i.errors = noop.Int64Counter{}
+	}
+	return
+})
+
+// dbSystem maps a driver name to the "db.system" attribute.
+func dbSystem(driver string) attribute.KeyValue {
+	switch driver {
+	case "postgres", "pgx":
+		return semconv.DBSystemPostgreSQL
+	case "mysql":
+		return semconv.DBSystemMySQL
+	case "sqlite3":
+		return semconv.DBSystemSqlite
+	default:
+		return semconv.DBSystemOtherSQL
+	}
+}
+
+/*
+Instrument runs fn in a span of the global tracer provider, and records its duration and errors to the
+"db.client.operation.duration" histogram and the "db.client.operation.errors" counter of the global meter provider.
+It's useful for custom queries that are not covered by [NewInstrumentedRepo], for example:
+
+	err := db.Instrument(ctx, pool.DriverName(), "SELECT", "users", func(ctx context.Context) error {
+		return pool.SelectContext(ctx, &users, stmt, args...)
+	})
+
+[sql.ErrNoRows] is not considered an error.
+
+Params:
+  - ctx: The context that carries the parent span.
+  - driver: The driver name, which is mapped to the "db.system" attribute.
+  - operation: The operation name like "SELECT" or "InsertUser", which is used as the "db.operation.name" attribute.
+  - tbl: The table name, which is used as the "db.collection.name" attribute. It can be empty.
+  - fn: The operation, which should use the given context to propagate the span.
+
+Returns:
+  - error: The error returned by fn.
+*/
+func Instrument(ctx context.Context, driver, operation, tbl string, fn func(ctx context.Context) error) error {
+	attrs := []attribute.KeyValue{dbSystem(driver), semconv.DBOperationName(operation)}
+	spanName := operation
+	if len(tbl) > 0 {
+		attrs = append(attrs, semconv.DBCollectionName(tbl))
+		spanName += " " + tbl
+	}
+	ctx, span := otel.Tracer(pkgName).Start(ctx, spanName,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attrs...))
+	defer span.End()
+
+	start := time.Now()
+	err := fn(ctx)
+	i := instruments()
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		attrs = append(attrs, semconv.ErrorTypeKey.String(fmt.Sprintf("%T", err)))
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		i.errors.Add(ctx, 1, metric.WithAttributes(attrs...))
+	}
+	i.duration.Record(ctx, time.Since(start).Seconds(), metric.WithAttributes(attrs...))
+	return err
+}
+
+// instrumentedRepo wraps a [Repo] with [Instrument].
+type instrumentedRepo[DO any] struct {
+	repo   Repo[DO]
+	driver string
+	tbl    string
+}
+
+// NewInstrumentedRepo wraps repo so that every operation is instrumented with [Instrument], using the method name as
+// the operation name, e.g. "Insert" or "QueryByID".
+//
+// If repo implements [ConditionalUpdater], so does the returned repo.
+//
+// Note that only [Repo.BeginTx] itself is instrumented, and statements executed in the returned transaction are not.
+func NewInstrumentedRepo[DO any](repo Repo[DO], driver, tbl string) Repo[DO] {
+	r := &instrumentedRepo[DO]{repo, driver, tbl}
+	if u, ok := repo.(ConditionalUpdater[DO]); ok {
+		return &instrumentedConditionalRepo[DO]{r, u}
+	}
+	return r
+}
+
+// instrumentedConditionalRepo is an [instrumentedRepo] whose underlying repo implements [ConditionalUpdater].
+type instrumentedConditionalRepo[DO any] struct {
+	*instrumentedRepo[DO]
+	updater ConditionalUpdater[DO]
+}
+
+func (r *instrumentedConditionalRepo[DO]) UpdateIfUnmodified(ctx context.Context, d *DO) error {
+	return Instrument(ctx, r.driver, "UpdateIfUnmodified", r.tbl, func(ctx context.Context) error {
+		return r.updater.UpdateIfUnmodified(ctx, d)
+	})
+}
+
+func (r *instrumentedRepo[DO]) Insert(ctx context.Context, d *DO) error {
+	return Instrument(ctx, r.driver, "Insert", r.tbl, func(ctx context.Context) error {
+		return r.repo.Insert(ctx, d)
+	})
+}
+
+func (r *instrumentedRepo[DO]) QueryByID(ctx context.Context, id int64) (d *DO, err error) {
+	err = Instrument(ctx, r.driver, "QueryByID", r.tbl, func(ctx context.Context) (err error) {
+		d, err = r.repo.QueryByID(ctx, id)
+		return
+	})
+	return
+}
+
+func (r *instrumentedRepo[DO]) Update(ctx context.Context, d *DO) error {
+	return Instrument(ctx, r.driver, "Update", r.tbl, func(ctx context.Context) error {
+		return r.repo.Update(ctx, d)
+	})
+}
+
+func (r *instrumentedRepo[DO]) Delete(ctx context.Context, d *DO) error {
+	return Instrument(ctx, r.driver, "Delete", r.tbl, func(ctx context.Context) error {
+		return r.repo.Delete(ctx, d)
+	})
+}
+
+func (r *instrumentedRepo[DO]) SoftDelete(ctx context.Context, d *DO) error {
+	return Instrument(ctx, r.driver, "SoftDelete", r.tbl, func(ctx context.Context) error {
+		return r.repo.SoftDelete(ctx, d)
+	})
+}
+
+func (r *instrumentedRepo[DO]) Restore(ctx context.Context, d *DO) error {
+	return Instrument(ctx, r.driver, "Restore", r.tbl, func(ctx context.Context) error {
+		return r.repo.Restore(ctx, d)
+	})
+}
+
+func (r *instrumentedRepo[DO]) BeginTx(ctx context.Context, opts *sql.TxOptions) (tx *sqlx.Tx, err error) {
+	err = Instrument(ctx, r.driver, "BeginTx", r.tbl, func(ctx context.Context) (err error) {
+		tx, err = r.repo.BeginTx(ctx, opts)
+		return
+	})
+	return
+}
@@ -0,0 +1,89 @@
+package db_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/sainnhe/go-common/pkg/db"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+)
+
+type instrumentedUser struct {
+	db.DO
+	Name string `db:"name"`
+}
+
+func TestNewInstrumentedRepo(t *testing.T) { // nolint:paralleltest
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	reader := sdkmetric.NewManualReader()
+	otel.SetMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)))
+
+	repo := db.NewInstrumentedRepo[instrumentedUser](db.NewMemoryRepo[instrumentedUser](), "pgx", "users")
+	ctx := context.Background()
+	u := &instrumentedUser{Name: "foo"}
+	if err := repo.Insert(ctx, u); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := repo.QueryByID(ctx, u.ID); err != nil || got.Name != "foo" {
+		t.Fatalf("Expect record %+v, got %+v, err = %+v", u, got, err)
+	}
+	if _, err := repo.QueryByID(ctx, u.ID+1); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("Expect error %+v, got %+v", sql.ErrNoRows, err)
+	}
+	failed := errors.New("failed")
+	if err := db.Instrument(ctx, "mysql", "SELECT", "", func(context.Context) error {
+		return failed
+	}); !errors.Is(err, failed) {
+		t.Fatalf("Expect error %+v, got %+v", failed, err)
+	}
+
+	// Spans
+	spans := recorder.Ended()
+	if len(spans) != 4 || spans[0].Name() != "Insert users" || spans[3].Name() != "SELECT" {
+		t.Fatalf("Unexpected spans %+v", spans)
+	}
+	if spans[2].Status().Code == codes.Error || spans[3].Status().Code != codes.Error {
+		t.Fatalf("Expect only the last span to fail, got %+v and %+v", spans[2].Status(), spans[3].Status())
+	}
+	attrs := attribute.NewSet(spans[0].Attributes()...)
+	if v, ok := attrs.Value(semconv.DBSystemKey); !ok || v.AsString() != "postgresql" {
+		t.Fatalf("Expect db.system postgresql, got %+v", spans[0].Attributes())
+	}
+
+	// Metrics
+	rm := metricdata.ResourceMetrics{}
+	if err := reader.Collect(ctx, &rm); err != nil {
+		t.Fatal(err)
+	}
+	for _, m := range rm.ScopeMetrics[0].Metrics {
+		switch data := m.Data.(type) {
+		case metricdata.Histogram[float64]:
+			if count := len(data.DataPoints); count != 3 { // nolint:mnd
+				t.Fatalf("Expect 3 data points of different operations, got %d", count)
+			}
+		case metricdata.Sum[int64]:
+			if len(data.DataPoints) != 1 || data.DataPoints[0].Value != 1 {
+				t.Fatalf("Expect 1 error, got %+v", data.DataPoints)
+			}
+		}
+	}
+
+	// Optional interfaces
+	if _, ok := repo.(db.ConditionalUpdater[instrumentedUser]); !ok {
+		t.Fatal("Expect ConditionalUpdater to be kept")
+	}
+	plain := db.NewInstrumentedRepo[instrumentedUser](db.NewMockRepo[instrumentedUser](nil), "pgx", "users")
+	if _, ok := plain.(db.ConditionalUpdater[instrumentedUser]); ok {
+		t.Fatal("Expect ConditionalUpdater not to be implemented")
+	}
+}
@@ -0,0 +1,69 @@
+package db
+
+// join is a JOIN clause of query statements.
+type join struct {
+	kind string
+	tbl  string
+	on   Cond
+}
+
+func (s *stmtBuilderImpl) Join(tbl string, on Cond) StmtBuilder {
+	return s.join(" INNER JOIN ", tbl, on)
+}
+
+func (s *stmtBuilderImpl) LeftJoin(tbl string, on Cond) StmtBuilder {
+	return s.join(" LEFT JOIN ", tbl, on)
+}
+
+func (s *stmtBuilderImpl) RightJoin(tbl string, on Cond) StmtBuilder {
+	return s.join(" RIGHT JOIN ", tbl, on)
+}
+
+// join returns a copy of s with the given join appended, so that the original builder can still be shared.
+func (s *stmtBuilderImpl) join(kind, tbl string, on Cond) StmtBuilder {
+	if len(tbl) == 0 {
+		return s
+	}
+	c := *s
+	c.joins = append(s.joins[:len(s.joins):len(s.joins)], join{kind, tbl, on})
+	return &c
+}
+
+// from writes the FROM clause, including joins.
+func (s *stmtBuilderImpl) from(w *stmtWriter) {
+	w.buf = append(w.buf, " FROM "...)
+	w.str(s.tbl)
+	for _, j := range s.joins {
+		w.buf = append(w.buf, j.kind...)
+		w.str(j.tbl)
+		if j.on == nil || j.on.empty() {
+			continue
+		}
+		w.buf = append(w.buf, " ON "...)
+		j.on.write(w, false)
+	}
+}
+
+// colCond compares two columns.
+type colCond struct {
+	left  string
+	op    string
+	right string
+}
+
+// EqCol returns a condition that checks whether two columns are equal, where both columns are escaped, for example
+// EqCol("users.id", "orders.user_id") is built as "`users`.`id` = `orders`.`user_id`" in MySQL. It's mainly used in
+// the ON clause of joins.
+func EqCol(left, right string) Cond {
+	return colCond{left, " = ", right}
+}
+
+func (c colCond) empty() bool {
+	return false
+}
+
+func (c colCond) write(w *stmtWriter, _ bool) {
+	w.col(c.left)
+	w.buf = append(w.buf, c.op...)
+	w.col(c.right)
+}
@@ -0,0 +1,64 @@
+package db
+
+// Page is a page of keyset pagination, see [StmtBuilder.BuildKeysetQueryStmt].
+type Page[T any] struct {
+	// Items are the items of this page.
+	Items []T `json:"items"`
+
+	// NextCursor is the cursor of the next page, which is nil if this is the last page.
+	NextCursor any `json:"next_cursor,omitempty"`
+}
+
+/*
+NewPage builds a [Page] from the rows queried by the statement built by [StmtBuilder.BuildKeysetQueryStmt], for example:
+
+	stmt := sb.BuildKeysetQueryStmt(nil, "id", cursor, limit)
+	var users []*User
+	err := pool.SelectContext(ctx, &users, stmt, args...)
+	page := db.NewPage(users, limit, func(u *User) any { return u.ID })
+
+Params:
+  - items: The queried rows, which may contain an extra row fetched to tell whether there is a next page.
+  - limit: The limit passed to [StmtBuilder.BuildKeysetQueryStmt].
+  - cursor: The function that returns the value of the order column of an item.
+
+Returns:
+  - *Page[T]: The page, where the extra row is dropped and the next cursor is taken from the last item.
+*/
+func NewPage[T any](items []T, limit int, cursor func(item T) any) *Page[T] {
+	p := &Page[T]{Items: items}
+	if limit > 0 && len(items) > limit {
+		p.Items = items[:limit]
+		p.NextCursor = cursor(items[limit-1])
+	}
+	return p
+}
+
+func (s *stmtBuilderImpl) BuildKeysetQueryStmt(selectedCols []string, orderCol string, cursor any, limit int,
+	opts ...QueryOption) string {
+	var cond Cond
+	if cursor != nil {
+		cond = keysetCond{orderCol}
+	}
+	keysetOpts := make([]QueryOption, 0, len(opts)+2) // nolint:mnd
+	keysetOpts = append(keysetOpts, WithOrderBy(Asc(orderCol)))
+	keysetOpts = append(keysetOpts, opts...)
+	if limit > 0 {
+		keysetOpts = append(keysetOpts, WithLimit(limit+1))
+	}
+	return s.BuildQueryCondStmt(selectedCols, cond, keysetOpts...)
+}
+
+// keysetCond seeks past the cursor of keyset pagination.
+type keysetCond struct {
+	col string
+}
+
+func (c keysetCond) empty() bool {
+	return false
+}
+
+func (c keysetCond) write(w *stmtWriter, _ bool) {
+	w.col(c.col)
+	w.str(" > " + Placeholder)
+}
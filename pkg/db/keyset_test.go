@@ -0,0 +1,81 @@
+package db_test
+
+import (
+	"testing"
+
+	"github.com/sainnhe/go-common/pkg/db"
+)
+
+func TestBuildKeysetQueryStmt(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		dri    string
+		cursor any
+		limit  int
+		opts   []db.QueryOption
+		want   string
+	}{
+		{
+			name:  "First page",
+			dri:   "mysql",
+			limit: 10, // nolint:mnd
+			want:  "SELECT `id`, `name` FROM users ORDER BY `id` ASC LIMIT 11",
+		},
+		{
+			name:   "Next page",
+			dri:    "pgx",
+			cursor: int64(42), // nolint:mnd
+			limit:  10,        // nolint:mnd
+			want:   "SELECT \"id\", \"name\" FROM users WHERE \"id\" > $1 ORDER BY \"id\" ASC LIMIT 11",
+		},
+		{
+			name:   "No limit with extra orders",
+			dri:    "pgx",
+			cursor: int64(42), // nolint:mnd
+			opts:   []db.QueryOption{db.WithOrderBy(db.Desc("name"))},
+			want:   "SELECT \"id\", \"name\" FROM users WHERE \"id\" > $1 ORDER BY \"id\" ASC, \"name\" DESC",
+		},
+		{
+			name:   "SQL Server",
+			dri:    "sqlserver",
+			cursor: int64(42), // nolint:mnd
+			limit:  10,        // nolint:mnd
+			want:   "SELECT id, name FROM users WHERE id > @p1 ORDER BY id ASC OFFSET 0 ROWS FETCH NEXT 11 ROWS ONLY",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			sb := db.NewStmtBuilder("users", tt.dri)
+			if s := sb.BuildKeysetQueryStmt([]string{"id", "name"}, "id", tt.cursor, tt.limit, tt.opts...); s !=
+				tt.want {
+				t.Fatalf("Want %s\nGot %s", tt.want, s)
+			}
+		})
+	}
+
+	sb := db.NewStmtBuilder("users", "pgx").WithSoftDelete("delete_time")
+	if s := sb.BuildKeysetQueryStmt(nil, "id", int64(1), 1); s !=
+		"SELECT * FROM users WHERE \"id\" > $1 AND \"delete_time\" IS NULL ORDER BY \"id\" ASC LIMIT 2" {
+		t.Fatalf("Got %s", s)
+	}
+}
+
+func TestNewPage(t *testing.T) {
+	t.Parallel()
+
+	cursor := func(item int) any { return item }
+	if p := db.NewPage([]int{1, 2, 3}, 2, cursor); len(p.Items) != 2 || p.NextCursor != 2 {
+		t.Fatalf("Got %+v", p)
+	}
+	if p := db.NewPage([]int{1, 2}, 2, cursor); len(p.Items) != 2 || p.NextCursor != nil {
+		t.Fatalf("Got %+v", p)
+	}
+	if p := db.NewPage([]int{1, 2, 3}, 0, cursor); len(p.Items) != 3 || p.NextCursor != nil {
+		t.Fatalf("Got %+v", p)
+	}
+}
@@ -0,0 +1,353 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/sainnhe/go-common/pkg/constant"
+)
+
+var (
+	// ErrTxNotSupported indicates that transactions are not supported by the repo.
+	ErrTxNotSupported = errors.New("transaction not supported")
+
+	// ErrNoIDField indicates that the data object doesn't have an int64 field tagged with `db:"id"`.
+	ErrNoIDField = errors.New("no id field")
+
+	// ErrNoDeleteTimeField indicates that the data object doesn't have a *time.Time field tagged with
+	// `db:"delete_time"`.
+	ErrNoDeleteTimeField = errors.New("no delete_time field")
+
+	// ErrNoUpdateTimeField indicates that the data object doesn't have a time.Time field tagged with
+	// `db:"update_time"`.
+	ErrNoUpdateTimeField = errors.New("no update_time field")
+
+	// ErrConflict indicates that the record has been modified or deleted since it was queried, see
+	// [ConditionalUpdater].
+	ErrConflict = errors.New("record has been modified")
+)
+
+// MemoryRepo is an in-memory implementation of [Repo] backed by a map, which is intended to be used in tests.
+//
+// Records are copied on write and on read, so modifying a returned data object won't affect the stored one. Note that
+// the copy is shallow, which means slices, maps and pointers inside a data object are still shared.
+//
+// The DO generic should be a struct that has an int64 field tagged with `db:"id"`, for example a struct that embeds
+// [DO]. If time.Time fields tagged with `db:"create_time"` and `db:"update_time"` exist, they will be maintained too.
+// If a *time.Time field tagged with `db:"delete_time"` exists, for example in [SoftDeleteDO], soft-deleted records are
+// excluded from queries.
+type MemoryRepo[DO any] struct {
+	records map[int64]DO
+	nextID  int64
+	mu      sync.RWMutex
+}
+
+// MemoryQueryOption is the option used in [MemoryRepo.List].
+type MemoryQueryOption[DO any] func(*memoryQuery[DO])
+
+type memoryQuery[DO any] struct {
+	filters     []func(*DO) bool
+	limit       int
+	deleted     bool
+	onlyDeleted bool
+}
+
+// WithMemoryFilter filters records by the given function. Only records for which f returns true will be listed.
+// Multiple filters are combined with AND.
+func WithMemoryFilter[DO any](f func(d *DO) bool) MemoryQueryOption[DO] {
+	return func(q *memoryQuery[DO]) {
+		if f != nil {
+			q.filters = append(q.filters, f)
+		}
+	}
+}
+
+// WithMemoryLimit limits the number of listed records. A non-positive n means no limit.
+func WithMemoryLimit[DO any](n int) MemoryQueryOption[DO] {
+	return func(q *memoryQuery[DO]) {
+		q.limit = n
+	}
+}
+
+// WithMemoryDeleted includes soft-deleted records.
+func WithMemoryDeleted[DO any]() MemoryQueryOption[DO] {
+	return func(q *memoryQuery[DO]) {
+		q.deleted = true
+	}
+}
+
+// WithMemoryOnlyDeleted only lists soft-deleted records, which takes precedence over [WithMemoryDeleted].
+func WithMemoryOnlyDeleted[DO any]() MemoryQueryOption[DO] {
+	return func(q *memoryQuery[DO]) {
+		q.onlyDeleted = true
+	}
+}
+
+// NewMemoryRepo initializes a new [MemoryRepo].
+func NewMemoryRepo[DO any]() *MemoryRepo[DO] {
+	return &MemoryRepo[DO]{
+		records: map[int64]DO{},
+	}
+}
+
+// Insert inserts a record and assigns an auto-incremented ID to the given data object.
+func (r *MemoryRepo[DO]) Insert(_ context.Context, d *DO) error {
+	if d == nil {
+		return constant.ErrNilDeps
+	}
+	id, ok := taggedField(d, "id")
+	if !ok {
+		return ErrNoIDField
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.nextID++
+	id.SetInt(r.nextID)
+	now := time.Now()
+	if f, ok := taggedField(d, "create_time"); ok {
+		f.Set(reflect.ValueOf(now))
+	}
+	if f, ok := taggedField(d, "update_time"); ok {
+		f.Set(reflect.ValueOf(now))
+	}
+	r.records[r.nextID] = *d
+	return nil
+}
+
+// QueryByID queries record by ID. If no record is found, [sql.ErrNoRows] will be returned.
+func (r *MemoryRepo[DO]) QueryByID(_ context.Context, id int64) (*DO, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	d, ok := r.records[id]
+	if !ok || isSoftDeleted(&d) {
+		return nil, sql.ErrNoRows
+	}
+	return &d, nil
+}
+
+// Update updates a record. If no record is found or it has been soft deleted, [sql.ErrNoRows] will be returned.
+func (r *MemoryRepo[DO]) Update(_ context.Context, d *DO) error {
+	if d == nil {
+		return constant.ErrNilDeps
+	}
+	id, ok := taggedField(d, "id")
+	if !ok {
+		return ErrNoIDField
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	old, exists := r.records[id.Int()]
+	if !exists || isSoftDeleted(&old) {
+		return sql.ErrNoRows
+	}
+	r.update(d, &old)
+	return nil
+}
+
+// UpdateIfUnmodified updates a record like [MemoryRepo.Update], but only if its update time is still the update time of
+// d. If the data object doesn't have an update_time field, [ErrNoUpdateTimeField] will be returned. If no record is
+// found, it has been soft deleted or it has been modified since d was queried, [ErrConflict] will be returned.
+func (r *MemoryRepo[DO]) UpdateIfUnmodified(_ context.Context, d *DO) error {
+	if d == nil {
+		return constant.ErrNilDeps
+	}
+	id, ok := taggedField(d, "id")
+	if !ok {
+		return ErrNoIDField
+	}
+	updateTime, ok := taggedField(d, "update_time")
+	if !ok {
+		return ErrNoUpdateTimeField
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	old, exists := r.records[id.Int()]
+	if !exists || isSoftDeleted(&old) {
+		return ErrConflict
+	}
+	oldUpdateTime, _ := taggedField(&old, "update_time")
+	if !oldUpdateTime.Interface().(time.Time).Equal(updateTime.Interface().(time.Time)) { // nolint:forcetypeassert
+		return ErrConflict
+	}
+	r.update(d, &old)
+	return nil
+}
+
+// update replaces the old record with d, where the create time is left unchanged and the update time is set to now.
+// The caller must hold the lock.
+func (r *MemoryRepo[DO]) update(d, old *DO) {
+	id, _ := taggedField(d, "id")
+	if f, ok := taggedField(d, "create_time"); ok {
+		oldF, _ := taggedField(old, "create_time")
+		f.Set(oldF)
+	}
+	if f, ok := taggedField(d, "update_time"); ok {
+		f.Set(reflect.ValueOf(time.Now()))
+	}
+	r.records[id.Int()] = *d
+}
+
+// Delete deletes a record. If no record is found, [sql.ErrNoRows] will be returned.
+func (r *MemoryRepo[DO]) Delete(_ context.Context, d *DO) error {
+	if d == nil {
+		return constant.ErrNilDeps
+	}
+	id, ok := taggedField(d, "id")
+	if !ok {
+		return ErrNoIDField
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.records[id.Int()]; !exists {
+		return sql.ErrNoRows
+	}
+	delete(r.records, id.Int())
+	return nil
+}
+
+// SoftDelete marks a record as deleted by setting its delete time. If no record is found or it has been soft deleted,
+// [sql.ErrNoRows] will be returned.
+func (r *MemoryRepo[DO]) SoftDelete(_ context.Context, d *DO) error {
+	return r.setDeleteTime(d, true)
+}
+
+// Restore restores a soft-deleted record by clearing its delete time. If no record is found or it is not soft deleted,
+// [sql.ErrNoRows] will be returned.
+func (r *MemoryRepo[DO]) Restore(_ context.Context, d *DO) error {
+	return r.setDeleteTime(d, false)
+}
+
+// setDeleteTime sets the delete time of the record and d to now if deleted is true, or nil otherwise.
+func (r *MemoryRepo[DO]) setDeleteTime(d *DO, deleted bool) error {
+	if d == nil {
+		return constant.ErrNilDeps
+	}
+	id, ok := taggedField(d, "id")
+	if !ok {
+		return ErrNoIDField
+	}
+	deleteTime, ok := taggedField(d, DeleteTimeCol)
+	if !ok {
+		return ErrNoDeleteTimeField
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	old, exists := r.records[id.Int()]
+	if !exists || isSoftDeleted(&old) == deleted {
+		return sql.ErrNoRows
+	}
+	now := time.Now()
+	oldDeleteTime, _ := taggedField(&old, DeleteTimeCol)
+	if deleted {
+		oldDeleteTime.Set(reflect.ValueOf(&now))
+		deleteTime.Set(reflect.ValueOf(&now))
+	} else {
+		oldDeleteTime.SetZero()
+		deleteTime.SetZero()
+	}
+	if f, ok := taggedField(&old, "update_time"); ok {
+		f.Set(reflect.ValueOf(now))
+		f, _ = taggedField(d, "update_time")
+		f.Set(reflect.ValueOf(now))
+	}
+	r.records[id.Int()] = old
+	return nil
+}
+
+// BeginTx always returns [ErrTxNotSupported] since there is no underlying database.
+func (r *MemoryRepo[DO]) BeginTx(_ context.Context, _ *sql.TxOptions) (*sqlx.Tx, error) {
+	return nil, ErrTxNotSupported
+}
+
+// List lists records ordered by ID.
+func (r *MemoryRepo[DO]) List(_ context.Context, opts ...MemoryQueryOption[DO]) ([]*DO, error) {
+	q := &memoryQuery[DO]{}
+	for _, opt := range opts {
+		opt(q)
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	results := make([]*DO, 0, len(r.records))
+	for id := int64(1); id <= r.nextID; id++ {
+		d, ok := r.records[id]
+		if !ok || (q.onlyDeleted && !isSoftDeleted(&d)) || (!q.deleted && !q.onlyDeleted && isSoftDeleted(&d)) {
+			continue
+		}
+		matched := true
+		for _, f := range q.filters {
+			if !f(&d) {
+				matched = false
+				break
+			}
+		}
+		if !matched {
+			continue
+		}
+		results = append(results, &d)
+		if q.limit > 0 && len(results) >= q.limit {
+			break
+		}
+	}
+	return results, nil
+}
+
+// taggedField finds the settable field tagged with the given db tag name, including fields of embedded structs.
+func taggedField[DO any](d *DO, tag string) (reflect.Value, bool) {
+	val := reflect.ValueOf(d).Elem()
+	if val.Kind() != reflect.Struct {
+		return reflect.Value{}, false
+	}
+	f, ok := findTaggedField(val, tag)
+	if !ok {
+		return reflect.Value{}, false
+	}
+	switch tag {
+	case "id":
+		ok = f.Kind() == reflect.Int64
+	case DeleteTimeCol:
+		ok = f.Type() == reflect.TypeOf((*time.Time)(nil))
+	default:
+		ok = f.Type() == reflect.TypeOf(time.Time{})
+	}
+	return f, ok && f.CanSet()
+}
+
+// isSoftDeleted reports whether the delete time of d is set.
+func isSoftDeleted[DO any](d *DO) bool {
+	f, ok := taggedField(d, DeleteTimeCol)
+	return ok && !f.IsNil()
+}
+
+// findTaggedField finds the field tagged with the given db tag name in val recursively.
+func findTaggedField(val reflect.Value, tag string) (reflect.Value, bool) {
+	for i := range val.NumField() {
+		field := val.Type().Field(i)
+		if field.Tag.Get("db") == tag {
+			return val.Field(i), true
+		}
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			if f, ok := findTaggedField(val.Field(i), tag); ok {
+				return f, true
+			}
+		}
+	}
+	return reflect.Value{}, false
+}
@@ -0,0 +1,210 @@
+package db_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/sainnhe/go-common/pkg/constant"
+	"github.com/sainnhe/go-common/pkg/db"
+)
+
+type memoryUser struct {
+	db.DO
+	Name string `db:"name"`
+	Age  int    `db:"age"`
+}
+
+func TestMemoryRepo(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	var repo db.Repo[memoryUser] = db.NewMemoryRepo[memoryUser]()
+
+	// Insert
+	u := &memoryUser{Name: "foo", Age: 20}
+	if err := repo.Insert(ctx, u); err != nil {
+		t.Fatal(err)
+	}
+	if u.ID != 1 || u.CreateTime.IsZero() || u.UpdateTime.IsZero() {
+		t.Fatalf("Got %+v", u)
+	}
+
+	// Query
+	got, err := repo.QueryByID(ctx, u.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Name != "foo" {
+		t.Fatalf("Want foo, got %s", got.Name)
+	}
+	got.Name = "modified"
+	if got, _ = repo.QueryByID(ctx, u.ID); got.Name != "foo" {
+		t.Fatalf("Expect copy on read, got %s", got.Name)
+	}
+	if _, err = repo.QueryByID(ctx, 100); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("Want %+v, got %+v", sql.ErrNoRows, err)
+	}
+
+	// Update
+	u.Name = "bar"
+	if err = repo.Update(ctx, u); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ = repo.QueryByID(ctx, u.ID); got.Name != "bar" {
+		t.Fatalf("Want bar, got %s", got.Name)
+	}
+	if err = repo.Update(ctx, &memoryUser{DO: db.DO{ID: 100}}); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("Want %+v, got %+v", sql.ErrNoRows, err)
+	}
+
+	// Update if unmodified
+	updater, ok := repo.(db.ConditionalUpdater[memoryUser])
+	if !ok {
+		t.Fatal("Expect MemoryRepo to implement ConditionalUpdater")
+	}
+	stale, _ := repo.QueryByID(ctx, u.ID)
+	if got, _ = repo.QueryByID(ctx, u.ID); got == nil {
+		t.Fatal("Expect record")
+	}
+	got.Name = "baz"
+	if err = updater.UpdateIfUnmodified(ctx, got); err != nil {
+		t.Fatal(err)
+	}
+	if err = updater.UpdateIfUnmodified(ctx, stale); !errors.Is(err, db.ErrConflict) {
+		t.Fatalf("Want %+v, got %+v", db.ErrConflict, err)
+	}
+	if err = updater.UpdateIfUnmodified(ctx, &memoryUser{DO: db.DO{ID: 100}}); !errors.Is(err, db.ErrConflict) {
+		t.Fatalf("Want %+v, got %+v", db.ErrConflict, err)
+	}
+	if got, _ = repo.QueryByID(ctx, u.ID); got.Name != "baz" {
+		t.Fatalf("Want baz, got %s", got.Name)
+	}
+
+	// Delete
+	if err = repo.Delete(ctx, u); err != nil {
+		t.Fatal(err)
+	}
+	if err = repo.Delete(ctx, u); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("Want %+v, got %+v", sql.ErrNoRows, err)
+	}
+
+	// Nil data object
+	for _, f := range []func(context.Context, *memoryUser) error{
+		repo.Insert, repo.Update, updater.UpdateIfUnmodified, repo.Delete,
+	} {
+		if err = f(ctx, nil); !errors.Is(err, constant.ErrNilDeps) {
+			t.Fatalf("Want %+v, got %+v", constant.ErrNilDeps, err)
+		}
+	}
+
+	// Transaction
+	if _, err = repo.BeginTx(ctx, nil); !errors.Is(err, db.ErrTxNotSupported) {
+		t.Fatalf("Want %+v, got %+v", db.ErrTxNotSupported, err)
+	}
+}
+
+func TestMemoryRepo_List(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	repo := db.NewMemoryRepo[memoryUser]()
+	for i := range 5 {
+		if err := repo.Insert(ctx, &memoryUser{Age: 20 + i}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	all, err := repo.List(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 5 || all[0].ID != 1 || all[4].ID != 5 {
+		t.Fatalf("Got %+v", all)
+	}
+
+	filtered, err := repo.List(ctx,
+		db.WithMemoryFilter(func(d *memoryUser) bool { return d.Age >= 22 }),
+		db.WithMemoryLimit[memoryUser](2),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(filtered) != 2 || filtered[0].Age != 22 || filtered[1].Age != 23 {
+		t.Fatalf("Got %+v", filtered)
+	}
+}
+
+func TestMemoryRepo_noIDField(t *testing.T) {
+	t.Parallel()
+
+	type noID struct {
+		Name string `db:"name"`
+	}
+
+	repo := db.NewMemoryRepo[noID]()
+	if err := repo.Insert(context.Background(), &noID{}); !errors.Is(err, db.ErrNoIDField) {
+		t.Fatalf("Want %+v, got %+v", db.ErrNoIDField, err)
+	}
+}
+
+func TestMemoryRepo_SoftDelete(t *testing.T) {
+	t.Parallel()
+
+	type softDeleteUser struct {
+		db.SoftDeleteDO
+		Name string `db:"name"`
+	}
+
+	ctx := context.Background()
+	repo := db.NewMemoryRepo[softDeleteUser]()
+	u := &softDeleteUser{Name: "Alice"}
+	if err := repo.Insert(ctx, u); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.SoftDelete(ctx, u); err != nil {
+		t.Fatal(err)
+	}
+	if u.DeleteTime == nil || u.UpdateTime.Before(u.CreateTime) {
+		t.Fatalf("Got %+v", u)
+	}
+	if err := repo.SoftDelete(ctx, u); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("Want %+v, got %+v", sql.ErrNoRows, err)
+	}
+	if err := repo.Update(ctx, u); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("Want %+v, got %+v", sql.ErrNoRows, err)
+	}
+	if _, err := repo.QueryByID(ctx, u.ID); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("Want %+v, got %+v", sql.ErrNoRows, err)
+	}
+	if list, err := repo.List(ctx); err != nil || len(list) != 0 {
+		t.Fatalf("Got %+v, %+v", list, err)
+	}
+	list, err := repo.List(ctx, db.WithMemoryDeleted[softDeleteUser]())
+	if err != nil || len(list) != 1 || list[0].DeleteTime == nil {
+		t.Fatalf("Got %+v, %+v", list, err)
+	}
+	if list, err = repo.List(ctx, db.WithMemoryOnlyDeleted[softDeleteUser]()); err != nil || len(list) != 1 {
+		t.Fatalf("Got %+v, %+v", list, err)
+	}
+
+	// Restore
+	if err = repo.Restore(ctx, u); err != nil || u.DeleteTime != nil {
+		t.Fatalf("Got %+v, %+v", u, err)
+	}
+	if err = repo.Restore(ctx, u); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("Want %+v, got %+v", sql.ErrNoRows, err)
+	}
+	if _, err = repo.QueryByID(ctx, u.ID); err != nil {
+		t.Fatal(err)
+	}
+	if list, err = repo.List(ctx, db.WithMemoryOnlyDeleted[softDeleteUser]()); err != nil || len(list) != 0 {
+		t.Fatalf("Got %+v, %+v", list, err)
+	}
+
+	if err := db.NewMemoryRepo[memoryUser]().SoftDelete(ctx, &memoryUser{}); !errors.Is(err,
+		db.ErrNoDeleteTimeField) {
+		t.Fatalf("Want %+v, got %+v", db.ErrNoDeleteTimeField, err)
+	}
+}
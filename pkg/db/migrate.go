@@ -0,0 +1,250 @@
+package db
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/sainnhe/go-common/pkg/constant"
+)
+
+var (
+	// ErrInvalidMigration indicates that a migration file is invalid, e.g. its name doesn't match the pattern or its
+	// version is duplicated.
+	ErrInvalidMigration = errors.New("invalid migration")
+
+	// ErrIrreversibleMigration indicates that a migration to be rolled back doesn't have a down file.
+	ErrIrreversibleMigration = errors.New("irreversible migration")
+)
+
+// DefaultMigrationTable is the default name of the table that records applied migrations.
+const DefaultMigrationTable = "schema_migrations"
+
+// Migration is a database migration.
+type Migration struct {
+	// Version is the version of the migration, which determines the order of migrations.
+	Version int64
+
+	// Name is the name of the migration.
+	Name string
+
+	// Up is the SQL that applies the migration.
+	Up string
+
+	// Down is the SQL that rolls back the migration. It's empty if there is no down file.
+	Down string
+}
+
+/*
+Migrator applies SQL migrations, and records the applied versions in a table, see [DefaultMigrationTable].
+
+Migrations are read from files named "<version>_<name>.up.sql" and "<version>_<name>.down.sql", where version is a
+positive integer like 1 or 20250101120000. Down files are optional. Files are usually embedded, for example:
+
+	//go:embed migrations/*.sql
+	var migrations embed.FS
+
+	pool, cleanup, err := db.NewPool(cfg)
+	sub, err := fs.Sub(migrations, "migrations")
+	m, err := db.NewMigrator(pool, sub)
+	applied, err := m.Up(ctx)
+
+Each migration runs in a transaction together with the update of the migration table. Supported drivers are MySQL,
+PostgreSQL and SQLite. Note that MySQL commits DDL statements implicitly, so failed migrations may be applied partially,
+and files containing multiple statements require the "multiStatements=true" DSN parameter.
+
+NOTE: Migrator doesn't lock the database, so run migrations from a single instance, e.g. an init container.
+*/
+type Migrator struct {
+	pool       *sqlx.DB
+	sb         StmtBuilder
+	migrations []Migration
+}
+
+// MigratorOption is the option used to customize [Migrator].
+type MigratorOption func(opts *migratorOptions)
+
+type migratorOptions struct {
+	table string
+}
+
+// WithMigrationTable sets the name of the table that records applied migrations. Defaults to
+// [DefaultMigrationTable].
+func WithMigrationTable(table string) MigratorOption {
+	return func(opts *migratorOptions) {
+		if len(table) > 0 {
+			opts.table = table
+		}
+	}
+}
+
+// NewMigrator initializes a new [Migrator] that reads migration files from the root of fsys. Files that don't end with
+// ".sql" are ignored.
+func NewMigrator(pool *sqlx.DB, fsys fs.FS, opts ...MigratorOption) (*Migrator, error) {
+	if pool == nil || fsys == nil {
+		return nil, constant.ErrNilDeps
+	}
+	o := migratorOptions{DefaultMigrationTable}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	sb := NewStmtBuilder(o.table, pool.DriverName())
+	if sb == nil {
+		return nil, ErrUnsupportedDriver
+	}
+	migrations, err := readMigrations(fsys)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{pool, sb, migrations}, nil
+}
+
+// readMigrations reads migrations from fsys and sorts them by version.
+func readMigrations(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+	byVersion := map[int64]*Migration{}
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".sql") {
+			continue
+		}
+		base, up := strings.CutSuffix(entry.Name(), ".up.sql")
+		if !up {
+			var down bool
+			if base, down = strings.CutSuffix(entry.Name(), ".down.sql"); !down {
+				return nil, fmt.Errorf("%w: %s doesn't end with .up.sql or .down.sql", ErrInvalidMigration, entry.Name())
+			}
+		}
+		versionStr, name, _ := strings.Cut(base, "_")
+		version, err := strconv.ParseInt(versionStr, 10, 64)
+		if err != nil || version <= 0 {
+			return nil, fmt.Errorf("%w: %s doesn't start with a positive version", ErrInvalidMigration, entry.Name())
+		}
+		content, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: name}
+			byVersion[version] = m
+		} else if m.Name != name {
+			return nil, fmt.Errorf("%w: version %d is duplicated", ErrInvalidMigration, version)
+		}
+		if up {
+			m.Up = string(content)
+		} else {
+			m.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if len(m.Up) == 0 {
+			return nil, fmt.Errorf("%w: version %d doesn't have an up file", ErrInvalidMigration, m.Version)
+		}
+		migrations = append(migrations, *m)
+	}
+	slices.SortFunc(migrations, func(a, b Migration) int {
+		return cmp.Compare(a.Version, b.Version)
+	})
+	return migrations, nil
+}
+
+// Migrations returns all migrations sorted by version.
+func (m *Migrator) Migrations() []Migration {
+	return slices.Clone(m.migrations)
+}
+
+// Up applies all pending migrations in order, and returns the number of applied migrations.
+func (m *Migrator) Up(ctx context.Context) (int, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return 0, err
+	}
+	n := 0
+	for _, migration := range m.migrations {
+		if slices.Contains(applied, migration.Version) {
+			continue
+		}
+		if err := WithTx(ctx, m.pool, nil, func(tx *sqlx.Tx) error {
+			if _, err := tx.ExecContext(ctx, migration.Up); err != nil {
+				return err
+			}
+			_, err := tx.ExecContext(ctx, m.sb.BuildMappedInsertStmt([]KV{
+				{"version", Placeholder},
+				{"name", Placeholder},
+				{"applied_time", Placeholder},
+			}), migration.Version, migration.Name, time.Now())
+			return err
+		}); err != nil {
+			return n, fmt.Errorf("apply migration %d: %w", migration.Version, err)
+		}
+		n++
+	}
+	return n, nil
+}
+
+// Down rolls back the latest steps applied migrations in reverse order, and returns the number of rolled back
+// migrations. If a migration doesn't have a down file, [ErrIrreversibleMigration] will be returned.
+func (m *Migrator) Down(ctx context.Context, steps int) (int, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return 0, err
+	}
+	n := 0
+	for i := len(applied) - 1; i >= 0 && n < steps; i-- {
+		idx := slices.IndexFunc(m.migrations, func(migration Migration) bool {
+			return migration.Version == applied[i]
+		})
+		if idx == -1 || len(m.migrations[idx].Down) == 0 {
+			return n, fmt.Errorf("%w: %d", ErrIrreversibleMigration, applied[i])
+		}
+		migration := m.migrations[idx]
+		if err := WithTx(ctx, m.pool, nil, func(tx *sqlx.Tx) error {
+			if _, err := tx.ExecContext(ctx, migration.Down); err != nil {
+				return err
+			}
+			_, err := tx.ExecContext(ctx, m.sb.BuildMappedDeleteStmt([]KV{{"version", Placeholder}}),
+				migration.Version)
+			return err
+		}); err != nil {
+			return n, fmt.Errorf("roll back migration %d: %w", migration.Version, err)
+		}
+		n++
+	}
+	return n, nil
+}
+
+// Version returns the latest applied version, or 0 if no migration is applied.
+func (m *Migrator) Version(ctx context.Context) (int64, error) {
+	applied, err := m.applied(ctx)
+	if err != nil || len(applied) == 0 {
+		return 0, err
+	}
+	return applied[len(applied)-1], nil
+}
+
+// applied creates the migration table if not exists, and returns the applied versions in ascending order.
+func (m *Migrator) applied(ctx context.Context) ([]int64, error) {
+	if _, err := m.pool.ExecContext(ctx, "CREATE TABLE IF NOT EXISTS "+m.sb.GetTbl()+
+		" (version BIGINT PRIMARY KEY, name VARCHAR(255) NOT NULL, applied_time TIMESTAMP NOT NULL)"); err != nil {
+		return nil, err
+	}
+	versions := []int64{}
+	if err := m.pool.SelectContext(ctx, &versions, m.sb.BuildMappedQueryStmt([]string{"version"}, nil,
+		WithOrderBy(Asc("version")))); err != nil {
+		return nil, err
+	}
+	return versions, nil
+}
@@ -0,0 +1,95 @@
+package db_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"testing/fstest"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/sainnhe/go-common/pkg/constant"
+	"github.com/sainnhe/go-common/pkg/db"
+	"github.com/sainnhe/go-common/pkg/db/dbtest"
+)
+
+func TestNewMigrator(t *testing.T) {
+	t.Parallel()
+
+	pool := sqlx.NewDb(&sql.DB{}, "pgx")
+	if _, err := db.NewMigrator(nil, fstest.MapFS{}); !errors.Is(err, constant.ErrNilDeps) {
+		t.Fatalf("Expect error %+v, got %+v", constant.ErrNilDeps, err)
+	}
+	if _, err := db.NewMigrator(sqlx.NewDb(&sql.DB{}, "unknown"), fstest.MapFS{}); !errors.Is(err,
+		db.ErrUnsupportedDriver) {
+		t.Fatalf("Expect error %+v, got %+v", db.ErrUnsupportedDriver, err)
+	}
+
+	invalid := []fstest.MapFS{
+		{"0001_init.sql": {Data: []byte("SELECT 1")}},
+		{"init.up.sql": {Data: []byte("SELECT 1")}},
+		{"0_init.up.sql": {Data: []byte("SELECT 1")}},
+		{"0001_init.down.sql": {Data: []byte("SELECT 1")}},
+		{"0001_foo.up.sql": {Data: []byte("SELECT 1")}, "1_bar.up.sql": {Data: []byte("SELECT 1")}},
+	}
+	for _, fsys := range invalid {
+		if _, err := db.NewMigrator(pool, fsys); !errors.Is(err, db.ErrInvalidMigration) {
+			t.Fatalf("Expect error %+v for %+v, got %+v", db.ErrInvalidMigration, fsys, err)
+		}
+	}
+
+	m, err := db.NewMigrator(pool, fstest.MapFS{
+		"0010_add_age.up.sql":  {Data: []byte("ALTER TABLE users ADD age INT")},
+		"0002_init.up.sql":     {Data: []byte("CREATE TABLE users (id INT)")},
+		"0002_init.down.sql":   {Data: []byte("DROP TABLE users")},
+		"README.md":            {Data: []byte("# Migrations")},
+		"sub/0003_skip.up.sql": {Data: []byte("SELECT 1")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	migrations := m.Migrations()
+	if len(migrations) != 2 || migrations[0].Version != 2 || migrations[0].Name != "init" ||
+		migrations[0].Down != "DROP TABLE users" || migrations[1].Version != 10 || migrations[1].Down != "" {
+		t.Fatalf("Unexpected migrations %+v", migrations)
+	}
+}
+
+func TestMigrator(t *testing.T) {
+	t.Parallel()
+
+	pool := dbtest.NewPool(t)
+	ctx := context.Background()
+
+	m, err := db.NewMigrator(pool, fstest.MapFS{
+		"1_init.up.sql":      {Data: []byte("CREATE TABLE migrator_users (id INT PRIMARY KEY)")},
+		"1_init.down.sql":    {Data: []byte("DROP TABLE migrator_users")},
+		"2_add_age.up.sql":   {Data: []byte("ALTER TABLE migrator_users ADD age INT")},
+		"2_add_age.down.sql": {Data: []byte("ALTER TABLE migrator_users DROP age")},
+	}, db.WithMigrationTable("migrator_migrations"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n, err := m.Up(ctx); n != 2 || err != nil {
+		t.Fatalf("Expect 2 applied, got %d, err = %+v", n, err)
+	}
+	if n, err := m.Up(ctx); n != 0 || err != nil {
+		t.Fatalf("Expect 0 applied, got %d, err = %+v", n, err)
+	}
+	if v, err := m.Version(ctx); v != 2 || err != nil {
+		t.Fatalf("Expect version 2, got %d, err = %+v", v, err)
+	}
+	if n, err := m.Down(ctx, 1); n != 1 || err != nil {
+		t.Fatalf("Expect 1 rolled back, got %d, err = %+v", n, err)
+	}
+	if v, err := m.Version(ctx); v != 1 || err != nil {
+		t.Fatalf("Expect version 1, got %d, err = %+v", v, err)
+	}
+	if n, err := m.Down(ctx, 10); n != 1 || err != nil { // nolint:mnd
+		t.Fatalf("Expect 1 rolled back, got %d, err = %+v", n, err)
+	}
+	if v, err := m.Version(ctx); v != 0 || err != nil {
+		t.Fatalf("Expect version 0, got %d, err = %+v", v, err)
+	}
+}
@@ -0,0 +1,172 @@
+package db
+
+import (
+	"strconv"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Order is an item of the ORDER BY clause.
+type Order struct {
+	// Col is the column name, which will be escaped.
+	Col string
+
+	// Desc indicates whether to sort in descending order.
+	Desc bool
+}
+
+// Asc returns an [Order] that sorts col in ascending order.
+func Asc(col string) Order {
+	return Order{col, false}
+}
+
+// Desc returns an [Order] that sorts col in descending order.
+func Desc(col string) Order {
+	return Order{col, true}
+}
+
+// QueryOption is the option used to customize query statements built by [StmtBuilder].
+type QueryOption func(opts *queryOptions)
+
+type queryOptions struct {
+	groupBy     []string
+	having      Cond
+	orders      []Order
+	limit       int
+	offset      int
+	deleted     bool
+	onlyDeleted bool
+}
+
+// newQueryOptions applies the given options.
+func newQueryOptions(opts []QueryOption) *queryOptions {
+	o := &queryOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// WithGroupBy appends columns to the GROUP BY clause. The columns are escaped like selected columns.
+func WithGroupBy(cols ...string) QueryOption {
+	return func(opts *queryOptions) {
+		opts.groupBy = append(opts.groupBy, cols...)
+	}
+}
+
+// WithHaving sets the condition of the HAVING clause, for example WithHaving(Cmp(Count("*"), ">", "?")). If the given
+// cond is nil or empty, the HAVING clause will be omitted.
+func WithHaving(cond Cond) QueryOption {
+	return func(opts *queryOptions) {
+		opts.having = cond
+	}
+}
+
+// WithOrderBy appends items to the ORDER BY clause.
+func WithOrderBy(orders ...Order) QueryOption {
+	return func(opts *queryOptions) {
+		opts.orders = append(opts.orders, orders...)
+	}
+}
+
+// WithLimit sets the maximum number of rows to return. Non-positive values mean no limit.
+func WithLimit(limit int) QueryOption {
+	return func(opts *queryOptions) {
+		opts.limit = limit
+	}
+}
+
+// WithOffset sets the number of rows to skip. Non-positive values mean no offset.
+func WithOffset(offset int) QueryOption {
+	return func(opts *queryOptions) {
+		opts.offset = offset
+	}
+}
+
+// WithDeleted includes soft-deleted rows, which are excluded by builders returned by [StmtBuilder.WithSoftDelete].
+func WithDeleted() QueryOption {
+	return func(opts *queryOptions) {
+		opts.deleted = true
+	}
+}
+
+// WithOnlyDeleted only queries soft-deleted rows, which takes precedence over [WithDeleted]. It has no effect on
+// builders without soft delete.
+func WithOnlyDeleted() QueryOption {
+	return func(opts *queryOptions) {
+		opts.onlyDeleted = true
+	}
+}
+
+// WithPage sets the limit and offset of the given 1-based page. Pages less than 1 are treated as 1, and non-positive
+// page sizes mean no limit.
+func WithPage(page, pageSize int) QueryOption {
+	return func(opts *queryOptions) {
+		if pageSize <= 0 {
+			opts.limit, opts.offset = 0, 0
+			return
+		}
+		opts.limit = pageSize
+		opts.offset = (max(page, 1) - 1) * pageSize
+	}
+}
+
+// queryClauses writes the GROUP BY, HAVING, ORDER BY, LIMIT and OFFSET clauses according to the driver.
+func (s *stmtBuilderImpl) queryClauses(w *stmtWriter, o *queryOptions) {
+	if len(o.groupBy) > 0 {
+		w.buf = append(w.buf, " GROUP BY "...)
+		w.cols(o.groupBy)
+	}
+	if o.having != nil && !o.having.empty() {
+		w.buf = append(w.buf, " HAVING "...)
+		o.having.write(w, false)
+	}
+
+	// SQL Server and Oracle use "OFFSET m ROWS FETCH NEXT n ROWS ONLY", and SQL Server requires an ORDER BY clause.
+	fetch := s.bindType == sqlx.AT || s.bindType == sqlx.NAMED
+	if len(o.orders) > 0 {
+		w.buf = append(w.buf, " ORDER BY "...)
+		for i, order := range o.orders {
+			if i > 0 {
+				w.buf = append(w.buf, ", "...)
+			}
+			w.col(order.Col)
+			if order.Desc {
+				w.buf = append(w.buf, " DESC"...)
+			} else {
+				w.buf = append(w.buf, " ASC"...)
+			}
+		}
+	} else if s.bindType == sqlx.AT && (o.limit > 0 || o.offset > 0) {
+		w.buf = append(w.buf, " ORDER BY (SELECT NULL)"...)
+	}
+
+	if fetch {
+		if o.limit > 0 || o.offset > 0 {
+			w.buf = append(w.buf, " OFFSET "...)
+			w.buf = strconv.AppendInt(w.buf, int64(max(o.offset, 0)), 10)
+			w.buf = append(w.buf, " ROWS"...)
+		}
+		if o.limit > 0 {
+			w.buf = append(w.buf, " FETCH NEXT "...)
+			w.buf = strconv.AppendInt(w.buf, int64(o.limit), 10)
+			w.buf = append(w.buf, " ROWS ONLY"...)
+		}
+		return
+	}
+
+	switch {
+	case o.limit > 0:
+		w.buf = append(w.buf, " LIMIT "...)
+		w.buf = strconv.AppendInt(w.buf, int64(o.limit), 10)
+	case o.offset > 0 && s.quote == quoteBacktick:
+		// MySQL doesn't support OFFSET without LIMIT, so use the maximum value as suggested by the documentation.
+		w.buf = append(w.buf, " LIMIT 18446744073709551615"...)
+	case o.offset > 0 && s.dri == "sqlite3":
+		w.buf = append(w.buf, " LIMIT -1"...)
+	}
+	if o.offset > 0 {
+		w.buf = append(w.buf, " OFFSET "...)
+		w.buf = strconv.AppendInt(w.buf, int64(o.offset), 10)
+	}
+}
@@ -0,0 +1,56 @@
+package db
+
+func (s *stmtBuilderImpl) WithSoftDelete(col string) StmtBuilder {
+	c := *s
+	c.softDel = col
+	return &c
+}
+
+// softDeleteCond writes the soft delete condition of query statements. If hasWhere is true, the condition is appended
+// to the existing WHERE clause with AND.
+func (s *stmtBuilderImpl) softDeleteCond(w *stmtWriter, o *queryOptions, hasWhere bool) {
+	cond := s.softDeleteFilter(o)
+	if cond == nil {
+		return
+	}
+	if hasWhere {
+		w.buf = append(w.buf, " AND "...)
+	} else {
+		w.buf = append(w.buf, " WHERE "...)
+	}
+	cond.write(w, false)
+}
+
+// softDeleteFilter returns the soft delete condition according to the options, or nil if soft-deleted rows are not
+// filtered.
+func (s *stmtBuilderImpl) softDeleteFilter(o *queryOptions) Cond {
+	switch {
+	case len(s.softDel) == 0:
+		return nil
+	case o.onlyDeleted:
+		return isNullCond{s.softDel, true}
+	case o.deleted:
+		return nil
+	default:
+		return isNullCond{s.softDel, false}
+	}
+}
+
+// isNullCond checks whether a column is NULL, or NOT NULL if not is true.
+type isNullCond struct {
+	col string
+	not bool
+}
+
+func (c isNullCond) empty() bool {
+	return false
+}
+
+func (c isNullCond) write(w *stmtWriter, _ bool) {
+	w.col(c.col)
+	if c.not {
+		w.buf = append(w.buf, " IS NOT NULL"...)
+	} else {
+		w.buf = append(w.buf, " IS NULL"...)
+	}
+}
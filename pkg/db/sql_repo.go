@@ -0,0 +1,362 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"reflect"
+	"slices"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/sainnhe/go-common/pkg/constant"
+)
+
+// ErrUnsupportedDriver indicates that the driver of the connection pool is not supported by [StmtBuilder].
+var ErrUnsupportedDriver = errors.New("unsupported driver")
+
+// expectedUpdateTimeParam is the named parameter of the update time that [SQLRepo.UpdateIfUnmodified] expects.
+const expectedUpdateTimeParam = "expected_update_time"
+
+// SQLRepo is an implementation of [Repo] backed by [StmtBuilder] and sqlx.
+//
+// The columns are collected from the fields of DO tagged with `db:"..."`, including fields of embedded structs. DO
+// must have an int64 field tagged with `db:"id"`, which is the auto-generated primary key, for example a struct that
+// embeds [DO]. If time.Time fields tagged with `db:"create_time"` and `db:"update_time"` exist, they will be
+// maintained too. If a *time.Time field tagged with `db:"delete_time"` exists, for example in [SoftDeleteDO],
+// soft-deleted records are excluded from queries, and the builder returned by [SQLRepo.StmtBuilder] excludes them as
+// well, see [StmtBuilder.WithSoftDelete].
+type SQLRepo[DO any] struct {
+	pool         *sqlx.DB
+	sb           StmtBuilder
+	insertStmt   string
+	queryStmt    string
+	updateStmt   string
+	updateIfStmt string
+	deleteStmt   string
+	softDelStmt  string
+	restoreStmt  string
+	returningID  bool
+	insertArgs   int
+	updateArgs   int
+	hooks        []QueryHook
+	history      *history
+}
+
+// RepoOption is the option used to customize [SQLRepo].
+type RepoOption func(opts *repoOptions)
+
+type repoOptions struct {
+	hooks   []QueryHook
+	history bool
+	actor   func(ctx context.Context) string
+}
+
+// WithQueryHook adds hooks that are called after each statement executed by [SQLRepo], for example
+// [NewSlowQueryHook]. Statements executed in transactions returned by [SQLRepo.BeginTx] are not covered.
+func WithQueryHook(hooks ...QueryHook) RepoOption {
+	return func(opts *repoOptions) {
+		for _, hook := range hooks {
+			if hook != nil {
+				opts.hooks = append(opts.hooks, hook)
+			}
+		}
+	}
+}
+
+// NewRepo initializes a new [SQLRepo] of table tbl, where the statements are built according to the driver name of the
+// given pool. [ErrNoIDField] is returned if DO doesn't have an int64 field tagged with `db:"id"`.
+func NewRepo[DO any](pool *sqlx.DB, tbl string, opts ...RepoOption) (*SQLRepo[DO], error) {
+	if pool == nil {
+		return nil, constant.ErrNilDeps
+	}
+	o := repoOptions{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	sb := NewStmtBuilder(tbl, pool.DriverName())
+	if sb == nil {
+		return nil, ErrUnsupportedDriver
+	}
+	cols := taggedCols(reflect.TypeFor[DO]())
+	// The ID field must be a settable int64, otherwise it can't be scanned into or passed as the ID.
+	if _, ok := taggedField(new(DO), "id"); !ok {
+		return nil, ErrNoIDField
+	}
+	softDelStmt, restoreStmt := "", ""
+	if slices.Contains(cols, DeleteTimeCol) {
+		sb = sb.WithSoftDelete(DeleteTimeCol)
+		softDelCols := []KV{{Key: DeleteTimeCol, Val: Placeholder}}
+		restoreCols := []KV{{Key: DeleteTimeCol, Val: "NULL"}}
+		if slices.Contains(cols, "update_time") {
+			softDelCols = append(softDelCols, KV{Key: "update_time", Val: Placeholder})
+			restoreCols = append(restoreCols, KV{Key: "update_time", Val: Placeholder})
+		}
+		softDelStmt = sb.BuildUpdateCondStmt(softDelCols, And(KV{Key: "id", Val: Placeholder},
+			isNullCond{DeleteTimeCol, false}))
+		restoreStmt = sb.BuildUpdateCondStmt(restoreCols, And(KV{Key: "id", Val: Placeholder},
+			isNullCond{DeleteTimeCol, true}))
+	}
+	insertCols := slices.DeleteFunc(slices.Clone(cols), func(col string) bool {
+		return col == "id"
+	})
+	updateCols := slices.DeleteFunc(slices.Clone(insertCols), func(col string) bool {
+		return col == "create_time"
+	})
+	set := make([]KV, 0, len(updateCols))
+	for _, col := range updateCols {
+		set = append(set, KV{Key: col, Val: ":" + col})
+	}
+	updateStmt := sb.BuildNamedUpdateStmt(updateCols, []string{"id"})
+	updateConds := []Cond{KV{Key: "id", Val: ":id"}}
+	if len(softDelStmt) > 0 {
+		// Soft-deleted records can't be updated.
+		updateConds = append(updateConds, isNullCond{DeleteTimeCol, false})
+		updateStmt = sb.BuildUpdateCondStmt(set, And(updateConds...))
+	}
+	updateIfStmt := ""
+	if slices.Contains(cols, "update_time") {
+		updateIfStmt = sb.BuildUpdateCondStmt(set, And(append(updateConds,
+			KV{Key: "update_time", Val: ":" + expectedUpdateTimeParam})...))
+	}
+	var h *history
+	if o.history {
+		h = newHistory(sb, cols, o.actor)
+	}
+	return &SQLRepo[DO]{
+		pool,
+		sb,
+		sb.BuildNamedInsertStmtReturning(insertCols, []string{"id"}),
+		sb.BuildQueryCondStmt(cols, KV{Key: "id", Val: Placeholder}),
+		updateStmt,
+		updateIfStmt,
+		sb.BuildNamedDeleteStmt([]string{"id"}),
+		softDelStmt,
+		restoreStmt,
+		sb.SupportsReturning(),
+		len(insertCols),
+		len(updateCols) + 1,
+		o.hooks,
+		h,
+	}, nil
+}
+
+// StmtBuilder returns the statement builder used by this repo, which is useful for building custom statements.
+func (r *SQLRepo[DO]) StmtBuilder() StmtBuilder {
+	return r.sb
+}
+
+// Insert inserts a record and updates the ID field of the given data object based on returned ID.
+func (r *SQLRepo[DO]) Insert(ctx context.Context, d *DO) error {
+	if d == nil {
+		return constant.ErrNilDeps
+	}
+	id, ok := taggedField(d, "id")
+	if !ok {
+		return ErrNoIDField
+	}
+	now := time.Now()
+	if f, ok := taggedField(d, "create_time"); ok {
+		f.Set(reflect.ValueOf(now))
+	}
+	if f, ok := taggedField(d, "update_time"); ok {
+		f.Set(reflect.ValueOf(now))
+	}
+
+	start := time.Now()
+	if !r.returningID {
+		result, err := r.pool.NamedExecContext(ctx, r.insertStmt, d)
+		r.runHooks(ctx, r.insertStmt, r.insertArgs, start, err)
+		if err != nil {
+			return err
+		}
+		lastID, err := result.LastInsertId()
+		if err != nil {
+			return err
+		}
+		id.SetInt(lastID)
+		return nil
+	}
+
+	rows, err := r.pool.NamedQueryContext(ctx, r.insertStmt, d)
+	r.runHooks(ctx, r.insertStmt, r.insertArgs, start, err)
+	if err != nil {
+		return err
+	}
+	defer rows.Close() // nolint:errcheck
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		return sql.ErrNoRows
+	}
+	var lastID int64
+	if err := rows.Scan(&lastID); err != nil {
+		return err
+	}
+	id.SetInt(lastID)
+	return rows.Err()
+}
+
+// QueryByID queries record by ID. If no record is found, [sql.ErrNoRows] will be returned.
+func (r *SQLRepo[DO]) QueryByID(ctx context.Context, id int64) (*DO, error) {
+	d := new(DO)
+	start := time.Now()
+	err := r.pool.GetContext(ctx, d, r.queryStmt, id)
+	r.runHooks(ctx, r.queryStmt, 1, start, err)
+	if err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// Update updates a record, where the create time is left unchanged. If no record is found or it has been soft deleted,
+// [sql.ErrNoRows] will be returned.
+func (r *SQLRepo[DO]) Update(ctx context.Context, d *DO) error {
+	if d == nil {
+		return constant.ErrNilDeps
+	}
+	if f, ok := taggedField(d, "update_time"); ok {
+		f.Set(reflect.ValueOf(time.Now()))
+	}
+	return r.exec(ctx, r.updateStmt, r.updateArgs, d, d, "Update")
+}
+
+// UpdateIfUnmodified updates a record like [SQLRepo.Update], but only if its update time in the database is still the
+// update time of d, which is checked atomically in the WHERE clause of the UPDATE statement. d should be queried from
+// the database, so that its update time has the precision of the database. If the data object doesn't have an
+// update_time field, [ErrNoUpdateTimeField] will be returned. If no record is found, it has been soft deleted or it has
+// been modified since d was queried, [ErrConflict] will be returned.
+func (r *SQLRepo[DO]) UpdateIfUnmodified(ctx context.Context, d *DO) error {
+	if d == nil {
+		return constant.ErrNilDeps
+	}
+	updateTime, ok := taggedField(d, "update_time")
+	if !ok || len(r.updateIfStmt) == 0 {
+		return ErrNoUpdateTimeField
+	}
+	arg := taggedValues(reflect.ValueOf(d).Elem())
+	arg[expectedUpdateTimeParam] = updateTime.Interface()
+	now := time.Now()
+	arg["update_time"] = now
+	err := r.exec(ctx, r.updateIfStmt, r.updateArgs+1, d, arg, "Update")
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrConflict
+	}
+	if err != nil {
+		return err
+	}
+	updateTime.Set(reflect.ValueOf(now))
+	return nil
+}
+
+// Delete deletes a record. If no record is found, [sql.ErrNoRows] will be returned.
+func (r *SQLRepo[DO]) Delete(ctx context.Context, d *DO) error {
+	if d == nil {
+		return constant.ErrNilDeps
+	}
+	return r.exec(ctx, r.deleteStmt, 1, d, d, "Delete")
+}
+
+// SoftDelete marks a record as deleted by setting its delete time. If the data object doesn't have a delete_time field,
+// [ErrNoDeleteTimeField] will be returned. If no record is found or it has been soft deleted, [sql.ErrNoRows] will be
+// returned.
+func (r *SQLRepo[DO]) SoftDelete(ctx context.Context, d *DO) error {
+	return r.setDeleteTime(ctx, d, r.softDelStmt, true)
+}
+
+// Restore restores a soft-deleted record by clearing its delete time. If the data object doesn't have a delete_time
+// field, [ErrNoDeleteTimeField] will be returned. If no record is found or it is not soft deleted, [sql.ErrNoRows] will
+// be returned.
+func (r *SQLRepo[DO]) Restore(ctx context.Context, d *DO) error {
+	return r.setDeleteTime(ctx, d, r.restoreStmt, false)
+}
+
+// setDeleteTime executes the soft delete or restore statement, and sets the delete time of d to now if deleted is true,
+// or nil otherwise.
+func (r *SQLRepo[DO]) setDeleteTime(ctx context.Context, d *DO, stmt string, deleted bool) error {
+	if d == nil {
+		return constant.ErrNilDeps
+	}
+	id, ok := taggedField(d, "id")
+	if !ok {
+		return ErrNoIDField
+	}
+	deleteTime, ok := taggedField(d, DeleteTimeCol)
+	if !ok || len(stmt) == 0 {
+		return ErrNoDeleteTimeField
+	}
+	now := time.Now()
+	args := make([]any, 0, 3) // nolint:mnd
+	if deleted {
+		args = append(args, now)
+	}
+	updateTime, hasUpdateTime := taggedField(d, "update_time")
+	if hasUpdateTime {
+		args = append(args, now)
+	}
+	args = append(args, id.Int())
+
+	result, err := r.pool.ExecContext(ctx, stmt, args...)
+	r.runHooks(ctx, stmt, len(args), now, err)
+	if err = checkRowsAffected(result, err); err != nil {
+		return err
+	}
+	if deleted {
+		deleteTime.Set(reflect.ValueOf(&now))
+	} else {
+		deleteTime.SetZero()
+	}
+	if hasUpdateTime {
+		updateTime.Set(reflect.ValueOf(now))
+	}
+	return nil
+}
+
+// BeginTx begins a transaction.
+func (r *SQLRepo[DO]) BeginTx(ctx context.Context, opts *sql.TxOptions) (*sqlx.Tx, error) {
+	return r.pool.BeginTxx(ctx, opts)
+}
+
+// exec executes a named statement of the operation op on d with the named parameters in arg, and returns
+// [sql.ErrNoRows] if no rows are affected. If [WithHistory] is used, a history row is written as well.
+func (r *SQLRepo[DO]) exec(ctx context.Context, stmt string, args int, d *DO, arg any, op string) error {
+	if r.history != nil {
+		return r.execWithHistory(ctx, stmt, args, d, arg, op)
+	}
+	start := time.Now()
+	result, err := r.pool.NamedExecContext(ctx, stmt, arg)
+	r.runHooks(ctx, stmt, args, start, err)
+	return checkRowsAffected(result, err)
+}
+
+// runHooks calls the query hooks with the executed statement.
+func (r *SQLRepo[DO]) runHooks(ctx context.Context, stmt string, args int, start time.Time, err error) {
+	if len(r.hooks) == 0 {
+		return
+	}
+	e := &QueryEvent{stmt, args, time.Since(start), err}
+	for _, hook := range r.hooks {
+		hook(ctx, e)
+	}
+}
+
+// taggedCols returns the db tags of the fields of typ in order, including fields of embedded structs.
+func taggedCols(typ reflect.Type) []string {
+	if typ.Kind() != reflect.Struct {
+		return nil
+	}
+	cols := make([]string, 0, typ.NumField())
+	for i := range typ.NumField() {
+		field := typ.Field(i)
+		tag := field.Tag.Get("db")
+		switch {
+		case tag == "-":
+		case len(tag) > 0:
+			cols = append(cols, tag)
+		case field.Anonymous && field.Type.Kind() == reflect.Struct:
+			cols = append(cols, taggedCols(field.Type)...)
+		}
+	}
+	return cols
+}
@@ -1,9 +1,9 @@
 package db
 
 import (
-	"fmt"
-	"slices"
+	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/jmoiron/sqlx"
 )
@@ -51,6 +51,43 @@ For example "WHERE `name` = :name" will bind the value of a struct that has stru
 
 As a rule of thumb, use mapped building when you are targeting at a set of specific columns,
 and use named building when you are targeting at a set of specific columns or all columns.
+
+# Condition trees
+
+Both mapped and named building join conditions with AND. For more complex WHERE clauses, for example
+"(a = ? OR b = ?) AND c > ?", build a [Cond] via [And], [Or], [Not] and [Cmp], and pass it to the Build*CondStmt
+methods. Like mapped building, the conditions are written as is and placeholders are rebound.
+
+# Joins
+
+Query statements can join other tables via Join, LeftJoin and RightJoin, for example:
+
+	db.NewStmtBuilder("users", "pgx").
+		LeftJoin("orders", db.EqCol("users.id", "orders.user_id")).
+		BuildQueryCondStmt([]string{"users.name", "orders.amount"}, db.Cmp("orders.amount", ">", "?"))
+
+is built as `SELECT "users"."name", "orders"."amount" FROM users LEFT JOIN orders ON "users"."id" = "orders"."user_id"
+WHERE orders.amount > $1`.
+
+# Aggregates
+
+Aggregate functions like [Count] and [Sum] can be selected, and grouped via [WithGroupBy] and [WithHaving], for example:
+
+	db.NewStmtBuilder("users", "mysql").BuildMappedQueryStmt(
+		[]string{"dept", db.As(db.Count("*"), "cnt")}, nil,
+		db.WithGroupBy("dept"), db.WithHaving(db.Cmp(db.Count("*"), ">", "?")))
+
+is built as "SELECT `dept`, COUNT(*) AS `cnt` FROM users GROUP BY `dept` HAVING COUNT(*) > ?".
+
+# Soft delete
+
+Builders returned by WithSoftDelete exclude soft-deleted rows from query statements, for example:
+
+	db.NewStmtBuilder("users", "mysql").WithSoftDelete("delete_time").
+		BuildMappedQueryStmt([]string{"name"}, []db.KV{{Key: "age", Val: "?"}})
+
+is built as "SELECT `name` FROM users WHERE age = ? AND `delete_time` IS NULL". Pass [WithDeleted] to include
+soft-deleted rows, or [WithOnlyDeleted] to query soft-deleted rows only.
 */
 type StmtBuilder interface {
 	// GetTbl returns the table name used in this builder.
@@ -63,9 +100,17 @@ type StmtBuilder interface {
 	// If the given cols is empty, an empty string will be returned.
 	BuildMappedInsertStmt(cols []KV) string
 
+	// BuildMappedBatchInsertStmt builds mapped insert statement of multiple rows in the form of
+	// "INSERT ... VALUES (...), (...)". The columns are taken from the first row, and all rows must have the same keys in
+	// the same order, otherwise an empty string will be returned. Placeholders are numbered across rows, e.g. "($1, $2),
+	// ($3, $4)" in PostgreSQL.
+	// If the given rows is empty, an empty string will be returned.
+	BuildMappedBatchInsertStmt(rows [][]KV) string
+
 	// BuildMappedQueryStmt builds mapped query statement.
-	// If the given selectedCols is empty, ["*"] will be used.
-	BuildMappedQueryStmt(selectedCols []string, conds []KV) string
+	// If the given selectedCols is empty, ["*"] will be used. Options like [WithOrderBy] and [WithLimit] can be used to
+	// append more clauses.
+	BuildMappedQueryStmt(selectedCols []string, conds []KV, opts ...QueryOption) string
 
 	// BuildMappedUpdateStmt builds mapped update statement.
 	// If the given cols is empty, an empty string will be returned.
@@ -78,9 +123,33 @@ type StmtBuilder interface {
 	// If the given cols is empty, an empty string will be returned.
 	BuildNamedInsertStmt(cols []string) string
 
+	// BuildNamedInsertStmtReturning builds named insert statement that returns the given columns of the inserted row,
+	// e.g. generated IDs. It uses "RETURNING" in PostgreSQL and SQLite, and "OUTPUT INSERTED" in SQL Server. Other
+	// drivers like MySQL don't support it, in which case the statement is the same as BuildNamedInsertStmt, and the
+	// generated ID should be retrieved via [database/sql.Result.LastInsertId] instead. Use SupportsReturning to check
+	// which one applies.
+	// If the given cols is empty, an empty string will be returned. If the given returning is empty, the statement is
+	// the same as BuildNamedInsertStmt.
+	BuildNamedInsertStmtReturning(cols, returning []string) string
+
+	// SupportsReturning reports whether the driver supports returning columns of inserted rows, i.e. whether
+	// BuildNamedInsertStmtReturning builds statements that return rows.
+	SupportsReturning() bool
+
+	// BuildNamedBatchInsertStmt builds insert statement of n rows in the form of "INSERT ... VALUES (...), (...)".
+	// Since named arguments can't distinguish rows, every value is a placeholder rebound according to the driver, and
+	// the arguments should be passed row by row in the order of cols.
+	// If the given cols is empty or n is not positive, an empty string will be returned.
+	//
+	// NOTE: sqlx already expands the statement built by BuildNamedInsertStmt when a slice is passed to NamedExec, so
+	// this method is mainly useful for drivers or clients that work with positional arguments. Databases limit the
+	// number of arguments in a statement (e.g. 65535 in PostgreSQL), so huge batches should be split.
+	BuildNamedBatchInsertStmt(cols []string, n int) string
+
 	// BuildNamedQueryStmt builds named query statement.
-	// If the given selectedCols is empty, ["*"] will be used.
-	BuildNamedQueryStmt(selectedCols, conds []string) string
+	// If the given selectedCols is empty, ["*"] will be used. Options like [WithOrderBy] and [WithLimit] can be used to
+	// append more clauses.
+	BuildNamedQueryStmt(selectedCols, conds []string, opts ...QueryOption) string
 
 	// BuildNamedUpdateStmt builds named update statement.
 	// If the given cols is empty, an empty string will be returned.
@@ -88,22 +157,93 @@ type StmtBuilder interface {
 
 	// BuildNamedDeleteStmt builds named delete statement.
 	BuildNamedDeleteStmt(conds []string) string
+
+	// BuildQueryCondStmt builds query statement with a condition tree.
+	// If the given selectedCols is empty, ["*"] will be used. If the given cond is nil or empty, the WHERE clause will
+	// be omitted. Options like [WithOrderBy] and [WithLimit] can be used to append more clauses.
+	BuildQueryCondStmt(selectedCols []string, cond Cond, opts ...QueryOption) string
+
+	// BuildPagedQueryStmt builds mapped query statement of the given 1-based page, which is a shortcut of
+	// BuildMappedQueryStmt with [WithPage]. The LIMIT and OFFSET syntax is chosen according to the driver.
+	BuildPagedQueryStmt(selectedCols []string, conds []KV, page, pageSize int, opts ...QueryOption) string
+
+	// BuildKeysetQueryStmt builds query statement of keyset pagination, which seeks past the cursor instead of scanning
+	// skipped rows like OFFSET. Rows are sorted by orderCol in ascending order, which should be unique and indexed, like
+	// the primary key. If cursor is nil, the first page is queried, otherwise "orderCol > ?" is used as the condition
+	// and the cursor should be passed as the only argument. If limit is positive, limit+1 rows are fetched so that
+	// [NewPage] can tell whether there is a next page.
+	// If the given selectedCols is empty, ["*"] will be used. Options like [WithDeleted] can be used to customize the
+	// statement, and orders added by [WithOrderBy] are appended after orderCol.
+	BuildKeysetQueryStmt(selectedCols []string, orderCol string, cursor any, limit int, opts ...QueryOption) string
+
+	// BuildUpdateCondStmt builds update statement with a condition tree.
+	// If the given cols is empty, an empty string will be returned. If the given cond is nil or empty, the WHERE clause
+	// will be omitted.
+	BuildUpdateCondStmt(cols []KV, cond Cond) string
+
+	// BuildDeleteCondStmt builds delete statement with a condition tree.
+	// If the given cond is nil or empty, the WHERE clause will be omitted.
+	BuildDeleteCondStmt(cond Cond) string
+
+	// Join returns a copy of this builder whose query statements join tbl with "INNER JOIN ... ON cond". Use qualified
+	// column names like "users.id" to select and order columns, which are escaped part by part, and use [EqCol] to
+	// compare columns of different tables. Joins are only applied to query statements.
+	// If the given tbl is empty, this builder will be returned as is. If the given on is nil or empty, the ON clause
+	// will be omitted.
+	Join(tbl string, on Cond) StmtBuilder
+
+	// LeftJoin is like Join but uses "LEFT JOIN".
+	LeftJoin(tbl string, on Cond) StmtBuilder
+
+	// RightJoin is like Join but uses "RIGHT JOIN".
+	RightJoin(tbl string, on Cond) StmtBuilder
+
+	// WithSoftDelete returns a copy of this builder whose query statements exclude soft-deleted rows with
+	// "col IS NULL", unless [WithDeleted] or [WithOnlyDeleted] is passed. Use a qualified column name like
+	// "users.delete_time" together with joins. Other statements are not affected.
+	// If the given col is empty, soft delete filtering will be disabled.
+	WithSoftDelete(col string) StmtBuilder
 }
 
+// quoteStyle is the style used to escape column names.
+type quoteStyle int
+
+const (
+	quoteNone     quoteStyle = 0
+	quoteBacktick quoteStyle = 1
+	quoteDouble   quoteStyle = 2
+)
+
 type stmtBuilderImpl struct {
-	tbl string
-	dri string
+	tbl      string
+	dri      string
+	bindType int
+	quote    quoteStyle
+	joins    []join
+	softDel  string
 }
 
 // NewStmtBuilder initializes a new [StmtBuilder], where tbl is the table name, and dri is the driver name.
 // Nil will be returned if one of the given arguments is invalid.
 func NewStmtBuilder(tbl string, dri string) StmtBuilder {
-	if len(tbl) == 0 || sqlx.BindType(dri) == sqlx.UNKNOWN {
+	bindType := sqlx.BindType(dri)
+	if len(tbl) == 0 || bindType == sqlx.UNKNOWN {
 		return nil
 	}
+	quote := quoteNone
+	switch dri {
+	case "mysql":
+		quote = quoteBacktick
+	case "postgres", "pgx", "sqlite3":
+		quote = quoteDouble
+	}
 	return &stmtBuilderImpl{
 		tbl,
 		dri,
+		bindType,
+		quote,
+		nil,
+		"",
 	}
 }
 
@@ -115,168 +255,436 @@ func (s *stmtBuilderImpl) GetDri() string {
 	return s.dri
 }
 
-func (s *stmtBuilderImpl) escapeColNames(colNames []string) {
-	for i := range colNames {
-		if colNames[i] == "*" {
-			continue
+// stmtWriterMaxCap is the maximum capacity of a buffer that will be put back to the pool, which avoids holding huge
+// buffers forever.
+const stmtWriterMaxCap = 64 * 1024
+
+var stmtWriterPool = sync.Pool{
+	New: func() any {
+		return &stmtWriter{buf: make([]byte, 0, 256)} // nolint:mnd
+	},
+}
+
+// stmtWriter writes SQL statements into a pooled buffer, and rebinds placeholders on the fly so that building a
+// statement only allocates the final string.
+type stmtWriter struct {
+	buf      []byte
+	bindType int
+	rebind   bool
+	quote    quoteStyle
+	n        int
+}
+
+// newWriter gets a writer from the pool. If rebind is true, placeholders will be rebound according to the driver.
+func (s *stmtBuilderImpl) newWriter(rebind bool) *stmtWriter {
+	w := stmtWriterPool.Get().(*stmtWriter) // nolint:errcheck
+	w.buf = w.buf[:0]
+	w.bindType = s.bindType
+	w.rebind = rebind && s.bindType != sqlx.QUESTION
+	w.quote = s.quote
+	w.n = 0
+	return w
+}
+
+// done returns the built statement and puts the writer back to the pool.
+func (w *stmtWriter) done() string {
+	stmt := string(w.buf)
+	if cap(w.buf) <= stmtWriterMaxCap {
+		stmtWriterPool.Put(w)
+	}
+	return stmt
+}
+
+// str writes a string, and rebinds placeholders if needed.
+func (w *stmtWriter) str(str string) {
+	if !w.rebind {
+		w.buf = append(w.buf, str...)
+		return
+	}
+	for i := strings.IndexByte(str, '?'); i != -1; i = strings.IndexByte(str, '?') {
+		w.buf = append(w.buf, str[:i]...)
+		switch w.bindType {
+		case sqlx.DOLLAR:
+			w.buf = append(w.buf, '$')
+		case sqlx.NAMED:
+			w.buf = append(w.buf, ":arg"...)
+		case sqlx.AT:
+			w.buf = append(w.buf, "@p"...)
+		}
+		w.n++
+		w.buf = strconv.AppendInt(w.buf, int64(w.n), 10)
+		str = str[i+1:]
+	}
+	w.buf = append(w.buf, str...)
+}
+
+// col writes an escaped column name. Qualified names like "users.id" are escaped part by part, and expressions built by
+// aggregate functions like [Count] and [As] are written with their column names escaped.
+func (w *stmtWriter) col(name string) {
+	if name == "*" {
+		w.buf = append(w.buf, '*')
+		return
+	}
+	if w.expr(name) {
+		return
+	}
+	if i := strings.IndexByte(name, '.'); i > 0 && w.quote != quoteNone {
+		w.ident(name[:i])
+		w.buf = append(w.buf, '.')
+		w.col(name[i+1:])
+		return
+	}
+	w.ident(name)
+}
+
+// ident writes an escaped identifier.
+func (w *stmtWriter) ident(name string) {
+	switch w.quote {
+	case quoteBacktick:
+		w.buf = append(w.buf, '`')
+		w.str(name)
+		w.buf = append(w.buf, '`')
+	case quoteDouble:
+		if isPlainIdent(name) {
+			w.buf = append(w.buf, '"')
+			w.buf = append(w.buf, name...)
+			w.buf = append(w.buf, '"')
+			return
 		}
-		switch s.dri {
-		case "mysql":
-			colNames[i] = fmt.Sprintf("`%s`", colNames[i])
-		case "postgres", "pgx", "sqlite3":
-			colNames[i] = fmt.Sprintf("%q", colNames[i])
+		start := len(w.buf)
+		w.buf = strconv.AppendQuote(w.buf, name)
+		if w.rebind && strings.IndexByte(name, '?') != -1 {
+			quoted := string(w.buf[start:])
+			w.buf = w.buf[:start]
+			w.str(quoted)
 		}
+	default:
+		w.str(name)
 	}
 }
 
-func (s *stmtBuilderImpl) buildMappedConds(conds []KV) string {
+// isPlainIdent reports whether name only contains ASCII letters, digits and underscores, in which case quoting it with
+// double quotes doesn't need any escaping.
+func isPlainIdent(name string) bool {
+	for i := range len(name) {
+		c := name[i]
+		if (c < 'a' || c > 'z') && (c < 'A' || c > 'Z') && (c < '0' || c > '9') && c != '_' {
+			return false
+		}
+	}
+	return true
+}
+
+// cols writes escaped column names separated by commas. If names is empty, "*" will be written.
+func (w *stmtWriter) cols(names []string) {
+	if len(names) == 0 {
+		w.buf = append(w.buf, '*')
+		return
+	}
+	for i, name := range names {
+		if i > 0 {
+			w.buf = append(w.buf, ", "...)
+		}
+		w.col(name)
+	}
+}
+
+// mappedConds writes mapped conditions.
+func (w *stmtWriter) mappedConds(conds []KV) {
 	if len(conds) == 0 {
-		return ""
+		return
+	}
+	w.buf = append(w.buf, " WHERE "...)
+	for i, kv := range conds {
+		if i > 0 {
+			w.buf = append(w.buf, " AND "...)
+		}
+		w.str(kv.Key)
+		w.buf = append(w.buf, " = "...)
+		w.str(kv.Val)
 	}
-	eqs := make([]string, 0, len(conds))
-	for _, kv := range conds {
-		val := kv.Val
-		eqs = append(eqs, fmt.Sprintf("%s = %s", kv.Key, val))
+}
+
+// mappedSet writes the SET clause of mapped update statements.
+func (w *stmtWriter) mappedSet(cols []KV) {
+	w.buf = append(w.buf, " SET "...)
+	for i, col := range cols {
+		if i > 0 {
+			w.buf = append(w.buf, ", "...)
+		}
+		w.col(col.Key)
+		w.buf = append(w.buf, " = "...)
+		w.str(col.Val)
 	}
-	return fmt.Sprintf(" WHERE %s", strings.Join(eqs, " AND "))
 }
 
-func (s *stmtBuilderImpl) buildNamedConds(conds []string) string {
+// namedConds writes named conditions.
+func (w *stmtWriter) namedConds(conds []string) {
 	if len(conds) == 0 {
-		return ""
+		return
 	}
-	eqs := make([]string, 0, len(conds))
-	for _, cond := range conds {
-		switch s.dri {
-		case "mysql":
-			eqs = append(eqs, fmt.Sprintf("`%s` = :%s", cond, cond))
-		case "postgres", "pgx", "sqlite3":
-			eqs = append(eqs, fmt.Sprintf("%q = :%s", cond, cond))
+	w.buf = append(w.buf, " WHERE "...)
+	for i, cond := range conds {
+		if i > 0 {
+			w.buf = append(w.buf, " AND "...)
 		}
+		w.namedEq(cond)
 	}
-	return fmt.Sprintf(" WHERE %s", strings.Join(eqs, " AND "))
+}
+
+// namedEq writes "col = :col".
+func (w *stmtWriter) namedEq(col string) {
+	w.col(col)
+	w.buf = append(w.buf, " = :"...)
+	w.buf = append(w.buf, col...)
 }
 
 func (s *stmtBuilderImpl) BuildMappedInsertStmt(cols []KV) string {
 	if len(cols) == 0 {
 		return ""
 	}
-	colNames := make([]string, 0, len(cols))
-	colVals := make([]string, 0, len(cols))
-	for _, col := range cols {
-		colNames = append(colNames, col.Key)
-		colVals = append(colVals, col.Val)
+	w := s.newWriter(true)
+	w.buf = append(w.buf, "INSERT INTO "...)
+	w.str(s.tbl)
+	w.buf = append(w.buf, " ("...)
+	for i, col := range cols {
+		if i > 0 {
+			w.buf = append(w.buf, ", "...)
+		}
+		w.col(col.Key)
+	}
+	w.buf = append(w.buf, ") VALUES ("...)
+	for i, col := range cols {
+		if i > 0 {
+			w.buf = append(w.buf, ", "...)
+		}
+		w.str(col.Val)
 	}
-	s.escapeColNames(colNames)
-	query := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)",
-		s.tbl, strings.Join(colNames, ", "), strings.Join(colVals, ", "))
-	return sqlx.Rebind(sqlx.BindType(s.dri), query)
+	w.buf = append(w.buf, ')')
+	return w.done()
 }
 
-func (s *stmtBuilderImpl) BuildMappedQueryStmt(selectedCols []string, conds []KV) string {
-	selectedCols = slices.Clone(selectedCols)
-	if len(selectedCols) == 0 {
-		selectedCols = []string{"*"}
+func (s *stmtBuilderImpl) BuildMappedBatchInsertStmt(rows [][]KV) string {
+	if len(rows) == 0 || len(rows[0]) == 0 {
+		return ""
 	}
-	s.escapeColNames(selectedCols)
-	query := fmt.Sprintf("SELECT %s FROM %s%s",
-		strings.Join(selectedCols, ", "),
-		s.tbl,
-		s.buildMappedConds(conds),
-	)
-	return sqlx.Rebind(sqlx.BindType(s.dri), query)
+	cols := rows[0]
+	for _, row := range rows[1:] {
+		if len(row) != len(cols) {
+			return ""
+		}
+		for i := range row {
+			if row[i].Key != cols[i].Key {
+				return ""
+			}
+		}
+	}
+	w := s.newWriter(true)
+	w.buf = append(w.buf, "INSERT INTO "...)
+	w.str(s.tbl)
+	w.buf = append(w.buf, " ("...)
+	for i, col := range cols {
+		if i > 0 {
+			w.buf = append(w.buf, ", "...)
+		}
+		w.col(col.Key)
+	}
+	w.buf = append(w.buf, ") VALUES "...)
+	for i, row := range rows {
+		if i > 0 {
+			w.buf = append(w.buf, ", "...)
+		}
+		w.buf = append(w.buf, '(')
+		for j, col := range row {
+			if j > 0 {
+				w.buf = append(w.buf, ", "...)
+			}
+			w.str(col.Val)
+		}
+		w.buf = append(w.buf, ')')
+	}
+	return w.done()
+}
+
+func (s *stmtBuilderImpl) BuildMappedQueryStmt(selectedCols []string, conds []KV, opts ...QueryOption) string {
+	w := s.newWriter(true)
+	w.buf = append(w.buf, "SELECT "...)
+	w.cols(selectedCols)
+	s.from(w)
+	w.mappedConds(conds)
+	o := newQueryOptions(opts)
+	s.softDeleteCond(w, o, len(conds) > 0)
+	s.queryClauses(w, o)
+	return w.done()
+}
+
+func (s *stmtBuilderImpl) BuildPagedQueryStmt(selectedCols []string, conds []KV, page, pageSize int,
+	opts ...QueryOption) string {
+	return s.BuildMappedQueryStmt(selectedCols, conds, append(opts[:len(opts):len(opts)], WithPage(page, pageSize))...)
 }
 
 func (s *stmtBuilderImpl) BuildMappedUpdateStmt(cols, conds []KV) string {
 	if len(cols) == 0 {
 		return ""
 	}
-
-	// Build columns
-	colNames := make([]string, 0, len(cols))
-	colVals := make([]string, 0, len(cols))
-	for _, col := range cols {
-		colNames = append(colNames, col.Key)
-		colVals = append(colVals, col.Val)
-	}
-	s.escapeColNames(colNames)
-	colEqs := make([]string, 0, len(cols))
-	for i := range cols {
-		colEqs = append(colEqs, fmt.Sprintf("%s = %s", colNames[i], colVals[i]))
-	}
-
-	query := fmt.Sprintf("UPDATE %s SET %s%s",
-		s.tbl,
-		strings.Join(colEqs, ", "),
-		s.buildMappedConds(conds),
-	)
-	return sqlx.Rebind(sqlx.BindType(s.dri), query)
+	w := s.newWriter(true)
+	w.buf = append(w.buf, "UPDATE "...)
+	w.str(s.tbl)
+	w.mappedSet(cols)
+	w.mappedConds(conds)
+	return w.done()
 }
 
 func (s *stmtBuilderImpl) BuildMappedDeleteStmt(conds []KV) string {
-	query := fmt.Sprintf("DELETE FROM %s%s",
-		s.tbl,
-		s.buildMappedConds(conds),
-	)
-	return sqlx.Rebind(sqlx.BindType(s.dri), query)
+	w := s.newWriter(true)
+	w.buf = append(w.buf, "DELETE FROM "...)
+	w.str(s.tbl)
+	w.mappedConds(conds)
+	return w.done()
 }
 
 func (s *stmtBuilderImpl) BuildNamedInsertStmt(cols []string) string {
+	return s.BuildNamedInsertStmtReturning(cols, nil)
+}
+
+func (s *stmtBuilderImpl) BuildNamedInsertStmtReturning(cols, returning []string) string {
 	if len(cols) == 0 {
 		return ""
 	}
-	colNames := make([]string, 0, len(cols))
-	colVals := make([]string, 0, len(cols))
-	for _, col := range cols {
-		colNames = append(colNames, col)
-		colVals = append(colVals, fmt.Sprintf(":%s", col))
+	if !s.SupportsReturning() {
+		returning = nil
+	}
+	w := s.newWriter(false)
+	w.buf = append(w.buf, "INSERT INTO "...)
+	w.buf = append(w.buf, s.tbl...)
+	w.buf = append(w.buf, " ("...)
+	w.cols(cols)
+	w.buf = append(w.buf, ')')
+	if len(returning) > 0 && s.bindType == sqlx.AT {
+		w.buf = append(w.buf, " OUTPUT "...)
+		for i, col := range returning {
+			if i > 0 {
+				w.buf = append(w.buf, ", "...)
+			}
+			w.buf = append(w.buf, "INSERTED."...)
+			w.col(col)
+		}
 	}
-	s.escapeColNames(colNames)
-	return fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)",
-		s.tbl, strings.Join(colNames, ", "), strings.Join(colVals, ", "))
+	w.buf = append(w.buf, " VALUES ("...)
+	for i, col := range cols {
+		if i > 0 {
+			w.buf = append(w.buf, ", "...)
+		}
+		w.buf = append(w.buf, ':')
+		w.buf = append(w.buf, col...)
+	}
+	w.buf = append(w.buf, ')')
+	if len(returning) > 0 && s.bindType != sqlx.AT {
+		w.buf = append(w.buf, " RETURNING "...)
+		w.cols(returning)
+	}
+	return w.done()
 }
 
-func (s *stmtBuilderImpl) BuildNamedQueryStmt(selectedCols, conds []string) string {
-	selectedCols = slices.Clone(selectedCols)
-	if len(selectedCols) == 0 {
-		selectedCols = []string{"*"}
+func (s *stmtBuilderImpl) SupportsReturning() bool {
+	return s.quote == quoteDouble || s.bindType == sqlx.AT
+}
+
+func (s *stmtBuilderImpl) BuildNamedBatchInsertStmt(cols []string, n int) string {
+	if len(cols) == 0 || n <= 0 {
+		return ""
+	}
+	w := s.newWriter(true)
+	w.buf = append(w.buf, "INSERT INTO "...)
+	w.buf = append(w.buf, s.tbl...)
+	w.buf = append(w.buf, " ("...)
+	w.cols(cols)
+	w.buf = append(w.buf, ") VALUES "...)
+	for i := range n {
+		if i > 0 {
+			w.buf = append(w.buf, ", "...)
+		}
+		w.buf = append(w.buf, '(')
+		for j := range cols {
+			if j > 0 {
+				w.buf = append(w.buf, ", "...)
+			}
+			w.str(Placeholder)
+		}
+		w.buf = append(w.buf, ')')
 	}
-	s.escapeColNames(selectedCols)
-	return fmt.Sprintf("SELECT %s FROM %s%s",
-		strings.Join(selectedCols, ", "),
-		s.tbl,
-		s.buildNamedConds(conds),
-	)
+	return w.done()
+}
+
+func (s *stmtBuilderImpl) BuildNamedQueryStmt(selectedCols, conds []string, opts ...QueryOption) string {
+	w := s.newWriter(false)
+	w.buf = append(w.buf, "SELECT "...)
+	w.cols(selectedCols)
+	s.from(w)
+	w.namedConds(conds)
+	o := newQueryOptions(opts)
+	s.softDeleteCond(w, o, len(conds) > 0)
+	s.queryClauses(w, o)
+	return w.done()
 }
 
 func (s *stmtBuilderImpl) BuildNamedUpdateStmt(cols, conds []string) string {
 	if len(cols) == 0 {
 		return ""
 	}
-
-	// Build columns
-	colNames := make([]string, 0, len(cols))
-	colVals := make([]string, 0, len(cols))
-	for _, col := range cols {
-		colNames = append(colNames, col)
-		colVals = append(colVals, fmt.Sprintf(":%s", col))
+	w := s.newWriter(false)
+	w.buf = append(w.buf, "UPDATE "...)
+	w.buf = append(w.buf, s.tbl...)
+	w.buf = append(w.buf, " SET "...)
+	for i, col := range cols {
+		if i > 0 {
+			w.buf = append(w.buf, ", "...)
+		}
+		w.namedEq(col)
 	}
-	s.escapeColNames(colNames)
-	colEqs := make([]string, 0, len(cols))
-	for i := range cols {
-		colEqs = append(colEqs, fmt.Sprintf("%s = %s", colNames[i], colVals[i]))
+	w.namedConds(conds)
+	return w.done()
+}
+
+func (s *stmtBuilderImpl) BuildNamedDeleteStmt(conds []string) string {
+	w := s.newWriter(false)
+	w.buf = append(w.buf, "DELETE FROM "...)
+	w.buf = append(w.buf, s.tbl...)
+	w.namedConds(conds)
+	return w.done()
+}
+
+func (s *stmtBuilderImpl) BuildQueryCondStmt(selectedCols []string, cond Cond, opts ...QueryOption) string {
+	w := s.newWriter(true)
+	w.buf = append(w.buf, "SELECT "...)
+	w.cols(selectedCols)
+	s.from(w)
+	o := newQueryOptions(opts)
+	if softDelCond := s.softDeleteFilter(o); softDelCond != nil {
+		// Wrap the condition so that OR conditions don't take precedence over the soft delete condition.
+		cond = And(cond, softDelCond)
 	}
+	w.cond(cond)
+	s.queryClauses(w, o)
+	return w.done()
+}
 
-	return fmt.Sprintf("UPDATE %s SET %s%s",
-		s.tbl,
-		strings.Join(colEqs, ", "),
-		s.buildNamedConds(conds),
-	)
+func (s *stmtBuilderImpl) BuildUpdateCondStmt(cols []KV, cond Cond) string {
+	if len(cols) == 0 {
+		return ""
+	}
+	w := s.newWriter(true)
+	w.buf = append(w.buf, "UPDATE "...)
+	w.str(s.tbl)
+	w.mappedSet(cols)
+	w.cond(cond)
+	return w.done()
 }
 
-func (s *stmtBuilderImpl) BuildNamedDeleteStmt(conds []string) string {
-	return fmt.Sprintf("DELETE FROM %s%s",
-		s.tbl,
-		s.buildNamedConds(conds),
-	)
+func (s *stmtBuilderImpl) BuildDeleteCondStmt(cond Cond) string {
+	w := s.newWriter(true)
+	w.buf = append(w.buf, "DELETE FROM "...)
+	w.str(s.tbl)
+	w.cond(cond)
+	return w.done()
 }
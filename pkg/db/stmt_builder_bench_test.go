@@ -0,0 +1,77 @@
+package db_test
+
+import (
+	"testing"
+
+	"github.com/sainnhe/go-common/pkg/db"
+)
+
+var (
+	benchCols  = []string{"id", "create_time", "update_time", "ext", "username", "email", "age"}
+	benchConds = []string{"id", "status"}
+	benchKVs   = []db.KV{
+		{Key: "username", Val: "?"},
+		{Key: "email", Val: "?"},
+		{Key: "age", Val: "20"},
+		{Key: "update_time", Val: "NOW()"},
+	}
+	benchCondKVs = []db.KV{
+		{Key: "id", Val: "?"},
+		{Key: "status", Val: "'active'"},
+	}
+)
+
+func BenchmarkStmtBuilder(b *testing.B) {
+	for _, dri := range []string{"mysql", "pgx"} {
+		sb := db.NewStmtBuilder("users", dri)
+
+		b.Run(dri+"/MappedInsert", func(b *testing.B) {
+			b.ReportAllocs()
+			for range b.N {
+				_ = sb.BuildMappedInsertStmt(benchKVs)
+			}
+		})
+		b.Run(dri+"/MappedQuery", func(b *testing.B) {
+			b.ReportAllocs()
+			for range b.N {
+				_ = sb.BuildMappedQueryStmt(benchCols, benchCondKVs)
+			}
+		})
+		b.Run(dri+"/MappedUpdate", func(b *testing.B) {
+			b.ReportAllocs()
+			for range b.N {
+				_ = sb.BuildMappedUpdateStmt(benchKVs, benchCondKVs)
+			}
+		})
+		b.Run(dri+"/MappedDelete", func(b *testing.B) {
+			b.ReportAllocs()
+			for range b.N {
+				_ = sb.BuildMappedDeleteStmt(benchCondKVs)
+			}
+		})
+		b.Run(dri+"/NamedInsert", func(b *testing.B) {
+			b.ReportAllocs()
+			for range b.N {
+				_ = sb.BuildNamedInsertStmt(benchCols)
+			}
+		})
+		b.Run(dri+"/NamedQuery", func(b *testing.B) {
+			b.ReportAllocs()
+			for range b.N {
+				_ = sb.BuildNamedQueryStmt(benchCols, benchConds)
+			}
+		})
+		b.Run(dri+"/NamedUpdate", func(b *testing.B) {
+			b.ReportAllocs()
+			for range b.N {
+				_ = sb.BuildNamedUpdateStmt(benchCols, benchConds)
+			}
+		})
+		b.Run(dri+"/NamedDelete", func(b *testing.B) {
+			b.ReportAllocs()
+			for range b.N {
+				_ = sb.BuildNamedDeleteStmt(benchConds)
+			}
+		})
+	}
+}
@@ -1,11 +1,36 @@
 package db_test
 
 import (
+	"flag"
+	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/sainnhe/go-common/pkg/db"
+	"github.com/sainnhe/go-common/pkg/testutil/golden"
 )
 
+// Run tests with -update to update the golden files of statements in testdata, see [golden.Updating].
+var _ = flag.Bool("update", false, "update golden files")
+
+// stmtCase is a statement built by a test case.
+type stmtCase struct {
+	name string
+	stmt string
+}
+
+// assertStmts compares the statements with the golden file of the given name, where each statement is preceded by a
+// "-- <case name>" line.
+func assertStmts(t *testing.T, name string, cases []stmtCase) {
+	t.Helper()
+
+	b := strings.Builder{}
+	for _, c := range cases {
+		fmt.Fprintf(&b, "-- %s\n%s\n\n", c.name, c.stmt)
+	}
+	golden.AssertString(t, name, b.String())
+}
+
 func TestNewStmtBuilder(t *testing.T) {
 	t.Parallel()
 
@@ -494,3 +519,438 @@ func TestBuildNamedDeleteStmt(t *testing.T) {
 		})
 	}
 }
+
+func TestStmtBuilder_bindTypes(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		dri  string
+		cols []db.KV
+		want string
+	}{
+		{
+			name: "SQL Server",
+			dri:  "sqlserver",
+			cols: []db.KV{{"name", "?"}, {"age", "?"}},
+			want: "INSERT INTO users (name, age) VALUES (@p1, @p2)",
+		},
+		{
+			name: "Oracle",
+			dri:  "godror",
+			cols: []db.KV{{"name", "?"}, {"age", "?"}},
+			want: "INSERT INTO users (name, age) VALUES (:arg1, :arg2)",
+		},
+		{
+			name: "Escaped column names",
+			dri:  "pgx",
+			cols: []db.KV{{"na\"me?", "?"}, {"ä", "?"}},
+			want: "INSERT INTO users (\"na\\\"me$1\", \"ä\") VALUES ($2, $3)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if s := db.NewStmtBuilder("users", tt.dri).BuildMappedInsertStmt(tt.cols); s != tt.want {
+				t.Fatalf("Want %s\nGot %s", tt.want, s)
+			}
+		})
+	}
+
+	if s := db.NewStmtBuilder("users", "sqlserver").BuildNamedQueryStmt(nil, []string{"id"}); s !=
+		"SELECT * FROM users WHERE id = :id" {
+		t.Fatalf("Got %s", s)
+	}
+}
+
+func TestBuildCondStmt(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name           string
+		cond           db.Cond
+		wantMySQL      string
+		wantPostgreSQL string
+	}{
+		{
+			name:           "Nil condition",
+			cond:           nil,
+			wantMySQL:      "",
+			wantPostgreSQL: "",
+		},
+		{
+			name:           "Empty groups",
+			cond:           db.And(db.Or(), nil, db.Not(db.And())),
+			wantMySQL:      "",
+			wantPostgreSQL: "",
+		},
+		{
+			name: "OR group in AND",
+			cond: db.And(
+				db.Or(db.KV{Key: "a", Val: "?"}, db.KV{Key: "b", Val: "?"}),
+				db.Cmp("c", ">", "?"),
+			),
+			wantMySQL:      " WHERE (a = ? OR b = ?) AND c > ?",
+			wantPostgreSQL: " WHERE (a = $1 OR b = $2) AND c > $3",
+		},
+		{
+			name: "Single operands are not parenthesized",
+			cond: db.And(db.Or(db.KV{Key: "a", Val: "?"}), db.And(db.Or(db.KV{Key: "b", Val: "1"},
+				db.Cmp("c", "IS", "NULL")))),
+			wantMySQL:      " WHERE a = ? AND (b = 1 OR c IS NULL)",
+			wantPostgreSQL: " WHERE a = $1 AND (b = 1 OR c IS NULL)",
+		},
+		{
+			name: "Nested NOT",
+			cond: db.Or(
+				db.Not(db.And(db.KV{Key: "a", Val: "?"}, db.Cmp("b", "IN", "(?, ?)"))),
+				db.And(db.Cmp("c", "<", "?"), db.Cmp("d", "LIKE", "'x%'")),
+			),
+			wantMySQL:      " WHERE NOT (a = ? AND b IN (?, ?)) OR (c < ? AND d LIKE 'x%')",
+			wantPostgreSQL: " WHERE NOT (a = $1 AND b IN ($2, $3)) OR (c < $4 AND d LIKE 'x%')",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mysqlBuilder := db.NewStmtBuilder("users", "mysql")
+			postgresqlBuilder := db.NewStmtBuilder("users", "pgx")
+
+			if s := mysqlBuilder.BuildQueryCondStmt(nil, tt.cond); s != "SELECT * FROM users"+tt.wantMySQL {
+				t.Fatalf("Want %s\nGot %s", tt.wantMySQL, s)
+			}
+			if s := postgresqlBuilder.BuildQueryCondStmt([]string{"id"}, tt.cond); s !=
+				"SELECT \"id\" FROM users"+tt.wantPostgreSQL {
+				t.Fatalf("Want %s\nGot %s", tt.wantPostgreSQL, s)
+			}
+			if s := mysqlBuilder.BuildDeleteCondStmt(tt.cond); s != "DELETE FROM users"+tt.wantMySQL {
+				t.Fatalf("Want %s\nGot %s", tt.wantMySQL, s)
+			}
+			if s := mysqlBuilder.BuildUpdateCondStmt([]db.KV{{"name", "?"}}, tt.cond); s !=
+				"UPDATE users SET `name` = ?"+tt.wantMySQL {
+				t.Fatalf("Want %s\nGot %s", tt.wantMySQL, s)
+			}
+		})
+	}
+
+	if s := db.NewStmtBuilder("users", "mysql").BuildUpdateCondStmt(nil, db.KV{Key: "id", Val: "1"}); s != "" {
+		t.Fatalf("Expect empty statement, got %s", s)
+	}
+	if s := db.NewStmtBuilder("users", "pgx").BuildUpdateCondStmt([]db.KV{{"name", "?"}},
+		db.KV{Key: "id", Val: "?"}); s != "UPDATE users SET \"name\" = $1 WHERE id = $2" {
+		t.Fatalf("Got %s", s)
+	}
+}
+
+func TestBuildQueryStmt_options(t *testing.T) {
+	t.Parallel()
+
+	orderBy := db.WithOrderBy(db.Desc("create_time"), db.Asc("id"))
+	tests := []struct {
+		name string
+		dri  string
+		opts []db.QueryOption
+	}{
+		{
+			name: "MySQL order, limit and offset",
+			dri:  "mysql",
+			opts: []db.QueryOption{orderBy, db.WithLimit(10), db.WithOffset(20)},
+		},
+		{
+			name: "MySQL offset only",
+			dri:  "mysql",
+			opts: []db.QueryOption{db.WithOffset(5)},
+		},
+		{
+			name: "PostgreSQL page",
+			dri:  "pgx",
+			opts: []db.QueryOption{orderBy, db.WithPage(3, 10)},
+		},
+		{
+			name: "PostgreSQL offset only",
+			dri:  "pgx",
+			opts: []db.QueryOption{db.WithOffset(5)},
+		},
+		{
+			name: "SQLite offset only",
+			dri:  "sqlite3",
+			opts: []db.QueryOption{db.WithOffset(5)},
+		},
+		{
+			name: "SQLite first page",
+			dri:  "sqlite3",
+			opts: []db.QueryOption{db.WithPage(0, 10)},
+		},
+		{
+			name: "SQL Server without order",
+			dri:  "sqlserver",
+			opts: []db.QueryOption{db.WithPage(2, 10)},
+		},
+		{
+			name: "Oracle order and limit",
+			dri:  "godror",
+			opts: []db.QueryOption{db.WithOrderBy(db.Asc("id")), db.WithLimit(5)},
+		},
+		{
+			name: "No page size",
+			dri:  "mysql",
+			opts: []db.QueryOption{db.WithLimit(10), db.WithPage(2, 0)},
+		},
+	}
+
+	cases := make([]stmtCase, 0, len(tests))
+	for _, tt := range tests {
+		sb := db.NewStmtBuilder("users", tt.dri)
+		s := sb.BuildMappedQueryStmt(nil, []db.KV{{"status", "?"}}, tt.opts...)
+		if cond := sb.BuildQueryCondStmt(nil, db.KV{Key: "status", Val: "?"}, tt.opts...); cond != s {
+			t.Fatalf("[%s] Want %s\nGot %s", tt.name, s, cond)
+		}
+		cases = append(cases, stmtCase{tt.name, s})
+	}
+	assertStmts(t, "query_options", cases)
+
+	sb := db.NewStmtBuilder("users", "pgx")
+	if s := sb.BuildPagedQueryStmt([]string{"id"}, []db.KV{{"status", "?"}}, 2, 20, orderBy); s !=
+		"SELECT \"id\" FROM users WHERE status = $1 ORDER BY \"create_time\" DESC, \"id\" ASC LIMIT 20 OFFSET 20" {
+		t.Fatalf("Got %s", s)
+	}
+	if s := sb.BuildNamedQueryStmt(nil, []string{"status"}, db.WithPage(1, 10)); s !=
+		"SELECT * FROM users WHERE \"status\" = :status LIMIT 10" {
+		t.Fatalf("Got %s", s)
+	}
+}
+
+func TestBuildBatchInsertStmt(t *testing.T) {
+	t.Parallel()
+
+	rows := [][]db.KV{
+		{{"name", "?"}, {"age", "?"}},
+		{{"name", "?"}, {"age", "20"}},
+		{{"name", "'guest'"}, {"age", "?"}},
+	}
+	tests := []struct {
+		name       string
+		dri        string
+		wantMapped string
+		wantNamed  string
+	}{
+		{
+			name:       "MySQL",
+			dri:        "mysql",
+			wantMapped: "INSERT INTO users (`name`, `age`) VALUES (?, ?), (?, 20), ('guest', ?)",
+			wantNamed:  "INSERT INTO users (`name`, `age`) VALUES (?, ?), (?, ?)",
+		},
+		{
+			name:       "PostgreSQL",
+			dri:        "pgx",
+			wantMapped: "INSERT INTO users (\"name\", \"age\") VALUES ($1, $2), ($3, 20), ('guest', $4)",
+			wantNamed:  "INSERT INTO users (\"name\", \"age\") VALUES ($1, $2), ($3, $4)",
+		},
+		{
+			name:       "SQL Server",
+			dri:        "sqlserver",
+			wantMapped: "INSERT INTO users (name, age) VALUES (@p1, @p2), (@p3, 20), ('guest', @p4)",
+			wantNamed:  "INSERT INTO users (name, age) VALUES (@p1, @p2), (@p3, @p4)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			sb := db.NewStmtBuilder("users", tt.dri)
+			if s := sb.BuildMappedBatchInsertStmt(rows); s != tt.wantMapped {
+				t.Fatalf("Want %s\nGot %s", tt.wantMapped, s)
+			}
+			if s := sb.BuildNamedBatchInsertStmt([]string{"name", "age"}, 2); s != tt.wantNamed { // nolint:mnd
+				t.Fatalf("Want %s\nGot %s", tt.wantNamed, s)
+			}
+		})
+	}
+
+	sb := db.NewStmtBuilder("users", "pgx")
+	for _, rows := range [][][]db.KV{
+		nil,
+		{{}},
+		{{{"name", "?"}}, {{"name", "?"}, {"age", "?"}}},
+		{{{"name", "?"}}, {{"age", "?"}}},
+	} {
+		if s := sb.BuildMappedBatchInsertStmt(rows); len(s) > 0 {
+			t.Fatalf("Expect empty statement of rows %+v, got %s", rows, s)
+		}
+	}
+	if s := sb.BuildNamedBatchInsertStmt(nil, 1); len(s) > 0 {
+		t.Fatalf("Expect empty statement without columns, got %s", s)
+	}
+	if s := sb.BuildNamedBatchInsertStmt([]string{"name"}, 0); len(s) > 0 {
+		t.Fatalf("Expect empty statement without rows, got %s", s)
+	}
+}
+
+func TestBuildNamedInsertStmtReturning(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		dri       string
+		want      string
+		returning bool
+	}{
+		{"pgx", "INSERT INTO users (\"name\", \"age\") VALUES (:name, :age) RETURNING \"id\", \"create_time\"", true},
+		{"sqlite3", "INSERT INTO users (\"name\", \"age\") VALUES (:name, :age) RETURNING \"id\", \"create_time\"", true},
+		{"sqlserver", "INSERT INTO users (name, age) OUTPUT INSERTED.id, INSERTED.create_time VALUES (:name, :age)", true},
+		{"mysql", "INSERT INTO users (`name`, `age`) VALUES (:name, :age)", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.dri, func(t *testing.T) {
+			t.Parallel()
+
+			sb := db.NewStmtBuilder("users", tt.dri)
+			if s := sb.BuildNamedInsertStmtReturning([]string{"name", "age"}, []string{"id", "create_time"}); s != tt.want {
+				t.Fatalf("Want %s\nGot %s", tt.want, s)
+			}
+			if sb.SupportsReturning() != tt.returning {
+				t.Fatalf("Expect SupportsReturning() = %t", tt.returning)
+			}
+			want := sb.BuildNamedInsertStmt([]string{"name"})
+			if s := sb.BuildNamedInsertStmtReturning([]string{"name"}, nil); s != want {
+				t.Fatalf("Want %s\nGot %s", want, s)
+			}
+			if s := sb.BuildNamedInsertStmtReturning(nil, []string{"id"}); len(s) > 0 {
+				t.Fatalf("Expect empty statement without columns, got %s", s)
+			}
+		})
+	}
+}
+
+func TestStmtBuilder_join(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		sb   func() db.StmtBuilder
+	}{
+		{
+			name: "PostgreSQL left join",
+			sb: func() db.StmtBuilder {
+				return db.NewStmtBuilder("users", "pgx").LeftJoin("orders", db.EqCol("users.id", "orders.user_id"))
+			},
+		},
+		{
+			name: "MySQL multiple joins",
+			sb: func() db.StmtBuilder {
+				return db.NewStmtBuilder("users", "mysql").
+					Join("orders", db.And(db.EqCol("users.id", "orders.user_id"), db.Cmp("orders.amount", ">", "?"))).
+					RightJoin("items", db.EqCol("orders.id", "items.order_id"))
+			},
+		},
+		{
+			name: "Join without condition",
+			sb: func() db.StmtBuilder {
+				return db.NewStmtBuilder("users", "sqlite3").Join("orders", nil)
+			},
+		},
+	}
+
+	cases := make([]stmtCase, 0, len(tests))
+	for _, tt := range tests {
+		s := tt.sb().BuildMappedQueryStmt([]string{"users.name", "orders.*"}, []db.KV{{"users.status", "?"}},
+			db.WithOrderBy(db.Desc("orders.amount")))
+		cases = append(cases, stmtCase{tt.name, s})
+	}
+	assertStmts(t, "join", cases)
+
+	// Joins don't modify the original builder, and are not applied to other statements.
+	sb := db.NewStmtBuilder("users", "pgx")
+	if joined := sb.Join("orders", db.EqCol("users.id", "orders.user_id")); joined.BuildMappedDeleteStmt(nil) !=
+		"DELETE FROM users" || joined.GetTbl() != "users" {
+		t.Fatal("Expect joins to be ignored in delete statements")
+	}
+	if s := sb.BuildNamedQueryStmt(nil, nil); s != "SELECT * FROM users" {
+		t.Fatalf("Expect the original builder to be unchanged, got %s", s)
+	}
+	if joined := sb.Join("", nil); joined != sb {
+		t.Fatal("Expect the builder to be returned as is")
+	}
+}
+
+func TestBuildQueryStmt_aggregate(t *testing.T) {
+	t.Parallel()
+
+	cols := []string{"dept", db.As(db.Count("*"), "cnt"), db.Sum("orders.amount"), db.CountDistinct("user_id"),
+		db.Avg("age + 1"), "CAST(age AS TEXT)"}
+	opts := []db.QueryOption{
+		db.WithGroupBy("dept"),
+		db.WithHaving(db.And(db.Cmp(db.Count("*"), ">", "?"), db.Cmp(db.Max("age"), "<", "?"))),
+		db.WithOrderBy(db.Desc(db.Count("*"))),
+		db.WithLimit(10), // nolint:mnd
+	}
+	cases := []stmtCase{}
+	for _, dri := range []string{"mysql", "pgx"} {
+		s := db.NewStmtBuilder("users", dri).BuildMappedQueryStmt(cols, []db.KV{{"status", "?"}}, opts...)
+		cases = append(cases, stmtCase{dri, s})
+	}
+	assertStmts(t, "aggregate", cases)
+}
+
+func TestStmtBuilder_softDelete(t *testing.T) {
+	t.Parallel()
+
+	sb := db.NewStmtBuilder("users", "pgx").WithSoftDelete("delete_time")
+	tests := []struct {
+		name string
+		got  string
+	}{
+		{
+			name: "Mapped",
+			got:  sb.BuildMappedQueryStmt([]string{"id"}, []db.KV{{"status", "?"}}, db.WithLimit(1)),
+		},
+		{
+			name: "Mapped without conditions",
+			got:  sb.BuildMappedQueryStmt(nil, nil),
+		},
+		{
+			name: "Named",
+			got:  sb.BuildNamedQueryStmt(nil, []string{"status"}),
+		},
+		{
+			name: "Cond",
+			got:  sb.BuildQueryCondStmt(nil, db.Or(db.KV{Key: "a", Val: "?"}, db.KV{Key: "b", Val: "?"})),
+		},
+		{
+			name: "With deleted",
+			got:  sb.BuildMappedQueryStmt(nil, []db.KV{{"status", "?"}}, db.WithDeleted()),
+		},
+		{
+			name: "Cond with deleted",
+			got:  sb.BuildQueryCondStmt(nil, db.KV{Key: "status", Val: "?"}, db.WithDeleted()),
+		},
+		{
+			name: "Only deleted",
+			got:  sb.BuildMappedQueryStmt(nil, []db.KV{{"status", "?"}}, db.WithDeleted(), db.WithOnlyDeleted()),
+		},
+		{
+			name: "Cond with only deleted",
+			got: sb.BuildQueryCondStmt(nil, db.Or(db.KV{Key: "a", Val: "?"}, db.KV{Key: "b", Val: "?"}),
+				db.WithOnlyDeleted()),
+		},
+		{
+			name: "Disabled",
+			got:  sb.WithSoftDelete("").BuildMappedQueryStmt(nil, nil),
+		},
+		{
+			name: "Original builder",
+			got:  db.NewStmtBuilder("users", "pgx").BuildMappedQueryStmt(nil, nil),
+		},
+	}
+
+	cases := make([]stmtCase, 0, len(tests))
+	for _, tt := range tests {
+		cases = append(cases, stmtCase{tt.name, tt.got})
+	}
+	assertStmts(t, "soft_delete", cases)
+}
@@ -0,0 +1,122 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/sainnhe/go-common/pkg/constant"
+)
+
+// TxOption is the option used to customize [WithTx].
+type TxOption func(opts *txOptions)
+
+type txOptions struct {
+	maxAttempts int
+	delay       time.Duration
+	retryable   func(err error) bool
+}
+
+// WithTxRetry retries the whole transaction up to maxAttempts times in total if it fails with a retryable error, where
+// the delay before the n-th retry is delay * 2^(n-1). By default, transactions are not retried.
+func WithTxRetry(maxAttempts int, delay time.Duration) TxOption {
+	return func(opts *txOptions) {
+		opts.maxAttempts = maxAttempts
+		opts.delay = delay
+	}
+}
+
+// WithTxRetryIf sets the function that reports whether an error is retryable. Defaults to [IsRetryableTxError].
+func WithTxRetryIf(retryable func(err error) bool) TxOption {
+	return func(opts *txOptions) {
+		if retryable != nil {
+			opts.retryable = retryable
+		}
+	}
+}
+
+/*
+WithTx runs fn in a transaction, which is committed if fn returns nil, and rolled back if fn returns an error or panics.
+In case of panics, the panic is propagated after rolling back.
+
+Since fn may be called multiple times when [WithTxRetry] is used, it should not have side effects outside of the
+transaction.
+
+Params:
+  - ctx: The context used to begin the transaction and wait between retries.
+  - pool: The database connection pool.
+  - txOpts: The options of the transaction, which can be nil.
+  - fn: The function to run in the transaction.
+  - opts: The options to customize retries.
+
+Returns:
+  - error: The error returned by fn, or the error that occurs when beginning or committing the transaction.
+*/
+func WithTx(ctx context.Context, pool *sqlx.DB, txOpts *sql.TxOptions, fn func(tx *sqlx.Tx) error,
+	opts ...TxOption) error {
+	if pool == nil || fn == nil {
+		return constant.ErrNilDeps
+	}
+	o := txOptions{maxAttempts: 1, retryable: IsRetryableTxError}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	delay := o.delay
+	for attempt := 1; ; attempt++ {
+		err := runTx(ctx, pool, txOpts, fn)
+		if err == nil || attempt >= o.maxAttempts || !o.retryable(err) {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return errors.Join(err, ctx.Err())
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
+
+// runTx runs fn in a transaction once.
+func runTx(ctx context.Context, pool *sqlx.DB, txOpts *sql.TxOptions, fn func(tx *sqlx.Tx) error) (err error) {
+	tx, err := pool.BeginTxx(ctx, txOpts)
+	if err != nil {
+		return
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			_ = tx.Rollback()
+			panic(p)
+		}
+	}()
+	if err = fn(tx); err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil {
+			err = errors.Join(err, rbErr)
+		}
+		return
+	}
+	return tx.Commit()
+}
+
+// IsRetryableTxError reports whether err is caused by a serialization failure or a deadlock, in which case the
+// transaction can be retried. It recognizes SQLSTATE 40001 and 40P01 of drivers like pgx, and error 1213 and 1205 of
+// MySQL.
+func IsRetryableTxError(err error) bool {
+	if err == nil {
+		return false
+	}
+	var sqlStateErr interface{ SQLState() string }
+	if errors.As(err, &sqlStateErr) {
+		switch sqlStateErr.SQLState() {
+		case "40001", "40P01":
+			return true
+		}
+		return false
+	}
+	// The MySQL driver formats errors as "Error <number> (<SQLSTATE>): <message>".
+	msg := err.Error()
+	return strings.Contains(msg, "Error 1213") || strings.Contains(msg, "Error 1205")
+}
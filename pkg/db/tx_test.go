@@ -0,0 +1,100 @@
+package db_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jmoiron/sqlx"
+	"github.com/sainnhe/go-common/pkg/constant"
+	"github.com/sainnhe/go-common/pkg/db"
+	"github.com/sainnhe/go-common/pkg/db/dbtest"
+)
+
+func TestIsRetryableTxError(t *testing.T) {
+	t.Parallel()
+
+	for err, expected := range map[error]bool{
+		nil:                              false,
+		errors.New("connection refused"): false,
+		&pgconn.PgError{Code: "40001"}:   true,
+		fmt.Errorf("wrap: %w", &pgconn.PgError{Code: "40P01"}):                   true,
+		&pgconn.PgError{Code: "23505"}:                                           false,
+		errors.New("Error 1213 (40001): Deadlock found when trying to get lock"): true,
+		errors.New("Error 1205 (HY000): Lock wait timeout exceeded"):             true,
+	} {
+		if actual := db.IsRetryableTxError(err); actual != expected {
+			t.Errorf("Expect IsRetryableTxError(%v) = %t, got %t", err, expected, actual)
+		}
+	}
+}
+
+func TestWithTx(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	if err := db.WithTx(ctx, nil, nil, nil); !errors.Is(err, constant.ErrNilDeps) {
+		t.Fatalf("Expect error %+v, got %+v", constant.ErrNilDeps, err)
+	}
+
+	pool := dbtest.NewPool(t, "CREATE TABLE with_tx_test (id INT PRIMARY KEY)")
+	insert := func(id int) func(tx *sqlx.Tx) error {
+		return func(tx *sqlx.Tx) error {
+			_, err := tx.ExecContext(ctx, "INSERT INTO with_tx_test (id) VALUES (?)", id)
+			return err
+		}
+	}
+	count := func() (n int) {
+		if err := pool.GetContext(ctx, &n, "SELECT COUNT(*) FROM with_tx_test"); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	// Commit
+	if err := db.WithTx(ctx, pool, nil, insert(1)); err != nil || count() != 1 {
+		t.Fatalf("Expect committed, got err = %+v", err)
+	}
+
+	// Rollback on error
+	errFailed := errors.New("failed")
+	if err := db.WithTx(ctx, pool, nil, func(tx *sqlx.Tx) error {
+		if err := insert(2)(tx); err != nil {
+			return err
+		}
+		return errFailed
+	}); !errors.Is(err, errFailed) || count() != 1 {
+		t.Fatalf("Expect rolled back, got err = %+v", err)
+	}
+
+	// Rollback on panic
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Fatal("Expect panic to be propagated")
+			}
+		}()
+		_ = db.WithTx(ctx, pool, nil, func(tx *sqlx.Tx) error {
+			_ = insert(3)(tx)
+			panic("boom")
+		})
+	}()
+	if count() != 1 {
+		t.Fatal("Expect rolled back on panic")
+	}
+
+	// Retry
+	attempts := 0
+	if err := db.WithTx(ctx, pool, nil, func(tx *sqlx.Tx) error {
+		attempts++
+		if attempts < 3 { // nolint:mnd
+			return &pgconn.PgError{Code: "40001"}
+		}
+		return insert(4)(tx)
+	}, db.WithTxRetry(3, time.Millisecond)); err != nil || attempts != 3 || count() != 2 {
+		t.Fatalf("Expect committed after 3 attempts, got err = %+v, attempts = %d", err, attempts)
+	}
+}
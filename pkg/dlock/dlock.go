@@ -5,16 +5,66 @@ package dlock
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"log/slog"
+	"strconv"
+	"sync"
 	"time"
 
 	"github.com/redis/rueidis"
+	"github.com/sainnhe/go-common/pkg/clock"
 	"github.com/sainnhe/go-common/pkg/constant"
+	"github.com/sainnhe/go-common/pkg/log"
 )
 
-// ErrKeyNotExists indicates that the key doesn't exist.
-var ErrKeyNotExists = errors.New("key doesn't exist")
+const pkgName = "github.com/sainnhe/go-common/pkg/dlock"
+
+var (
+	// ErrKeyNotExists indicates that the key doesn't exist.
+	ErrKeyNotExists = errors.New("key doesn't exist")
+
+	// ErrNotHolder indicates that the key is held by others.
+	ErrNotHolder = errors.New("key is held by others")
+)
+
+var (
+	// acquireTokenScript acquires KEYS[1] with a fencing token incremented from KEYS[2], and returns the token, or nil
+	// if KEYS[1] has been acquired.
+	acquireTokenScript = rueidis.NewLuaScript(`
+if redis.call("EXISTS", KEYS[1]) == 1 then
+	return false
+end
+local token = redis.call("INCR", KEYS[2])
+redis.call("SET", KEYS[1], token, "PX", ARGV[1])
+return token`)
+
+	// releaseTokenScript deletes KEYS[1] if its value is ARGV[1], and returns 1 if it's deleted, 0 if it doesn't exist,
+	// or -1 if it's held by others.
+	releaseTokenScript = rueidis.NewLuaScript(`
+local value = redis.call("GET", KEYS[1])
+if not value then
+	return 0
+end
+if value ~= ARGV[1] then
+	return -1
+end
+return redis.call("DEL", KEYS[1])`)
+
+	// renewScript sets the expiration of KEYS[1] to ARGV[2] milliseconds if its value is ARGV[1], and returns 1 if it's
+	// renewed, 0 if it doesn't exist, or -1 if it's held by others.
+	renewScript = rueidis.NewLuaScript(`
+local value = redis.call("GET", KEYS[1])
+if not value then
+	return 0
+end
+if value ~= ARGV[1] then
+	return -1
+end
+return redis.call("PEXPIRE", KEYS[1], ARGV[2])`)
+)
 
 // Service is the distributed lock service.
 type Service interface {
@@ -23,27 +73,113 @@ type Service interface {
 
 	// Acquire acquires a key.
 	// If it's already acquired by others, wait and retry until ctx is cancelled.
+	// If the watchdog is enabled via [WithWatchdog], the key is renewed in background until it's released or ctx is
+	// cancelled.
 	Acquire(ctx context.Context, key string) error
 
-	// Release releases a key.
+	// Release releases a key, and stops renewing it if the watchdog is enabled.
 	// [ErrKeyNotExists] might be returned if it doesn't exist.
 	Release(ctx context.Context, key string) error
+
+	// AcquireToken acquires a key like [Service.Acquire], and returns a fencing token, which is a decimal integer that
+	// increases every time the key is acquired. Downstream systems can reject requests carrying a token smaller than
+	// the largest one they have seen, so that a holder that has lost the lock, e.g. after a long GC pause, can't
+	// overwrite the changes made by the next holder.
+	//
+	// The token is stored in a counter key with the suffix ":fence" that never expires. In Redis Cluster, the key
+	// should contain a hash tag like "{order:1}" so that both keys are in the same slot. Don't mix it with
+	// [Service.Acquire] and [Service.Release] on the same key.
+	AcquireToken(ctx context.Context, key string) (token string, err error)
+
+	// AcquireTokenNoWait is like [Service.AcquireToken], but returns immediately with ok being false instead of waiting
+	// if it's already acquired by others. Unlike [Service.TryAcquire], the key is acquired if ok is true.
+	AcquireTokenNoWait(ctx context.Context, key string) (token string, ok bool, err error)
+
+	// RenewToken resets the expiration of a key acquired via [Service.AcquireToken] to ExpireMs only if it's still held
+	// with the token, which is useful to renew a key manually without the watchdog.
+	// [ErrKeyNotExists] is returned if it doesn't exist, and [ErrNotHolder] is returned if it's held by others.
+	RenewToken(ctx context.Context, key, token string) error
+
+	// ReleaseToken releases a key acquired via [Service.AcquireToken] only if it's still held with the token, and stops
+	// renewing it if the watchdog is enabled.
+	// [ErrKeyNotExists] is returned if it doesn't exist, and [ErrNotHolder] is returned if it's held by others.
+	ReleaseToken(ctx context.Context, key, token string) error
+
+	// WithLock acquires a key via [Service.AcquireToken], runs fn, and then releases the key via [Service.ReleaseToken]
+	// even if fn panics. The fencing token can be retrieved in fn via [TokenFromContext]. Errors returned by fn and
+	// releasing are joined.
+	WithLock(ctx context.Context, key string, fn func(ctx context.Context) error) error
+
+	// RWLock returns the read-write lock of a key, see [RWLock].
+	RWLock(key string) RWLock
+
+	// Expiration returns the expiration of keys, i.e. ExpireMs in the config. Keys renewed manually via
+	// [Service.RenewToken] should be renewed well within it.
+	Expiration() time.Duration
+}
+
+type tokenKey struct{}
+
+// TokenFromContext returns the fencing token of the lock held by [Service.WithLock], and reports whether it exists.
+func TokenFromContext(ctx context.Context) (string, bool) {
+	token, ok := ctx.Value(tokenKey{}).(string)
+	return token, ok
 }
 
 type serviceImpl struct {
 	cfg *Config
 	rc  rueidis.Client
+	clk clock.Clock
+	l   *slog.Logger
+
+	watchdog bool
+	mu       sync.Mutex
+	renewals map[string]*renewal
+}
+
+// renewal is a running watchdog of a key.
+type renewal struct {
+	cancel context.CancelFunc
+}
+
+// Option is the option used to customize the dlock service.
+type Option func(s *serviceImpl)
+
+// WithClock sets the clock used to sleep before retrying. Defaults to [clock.Real].
+func WithClock(clk clock.Clock) Option {
+	return func(s *serviceImpl) {
+		if clk != nil {
+			s.clk = clk
+		}
+	}
+}
+
+// WithWatchdog enables the watchdog, which renews the expiration of a key every ExpireMs/3 after it's acquired via
+// [Service.Acquire] or [Service.AcquireToken], until it's released or the context passed to the acquiring method is
+// cancelled. This prevents long critical sections from losing the lock in the middle, while the lock still expires soon
+// if the holder crashes.
+func WithWatchdog() Option {
+	return func(s *serviceImpl) {
+		s.watchdog = true
+	}
 }
 
 // NewService initializes a new dlock service.
-func NewService(cfg *Config, rc rueidis.Client) (Service, error) {
+func NewService(cfg *Config, rc rueidis.Client, opts ...Option) (Service, error) {
 	if cfg == nil || rc == nil {
 		return nil, constant.ErrNilDeps
 	}
-	return &serviceImpl{
-		cfg,
-		rc,
-	}, nil
+	s := &serviceImpl{
+		cfg:      cfg,
+		rc:       rc,
+		clk:      clock.Real(),
+		l:        log.NewLogger(pkgName),
+		renewals: map[string]*renewal{},
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s, nil
 }
 
 func (s *serviceImpl) TryAcquire(ctx context.Context, key string) (bool, error) {
@@ -59,19 +195,28 @@ func (s *serviceImpl) TryAcquire(ctx context.Context, key string) (bool, error)
 }
 
 func (s *serviceImpl) Acquire(ctx context.Context, key string) error {
+	// Each acquisition has its own value, so that the watchdog never renews a key acquired by others after it expires.
+	b := make([]byte, 16) // nolint:mnd
+	if _, err := rand.Read(b); err != nil {
+		return err
+	}
+	value := hex.EncodeToString(b)
 	for {
 		err := s.rc.Do(ctx, s.rc.B().
 			Set().
 			Key(s.getKey(key)).
-			Value("1").
+			Value(value).
 			Nx().
 			PxMilliseconds(s.cfg.ExpireMs).
 			Build()).Error()
 		switch err {
 		case rueidis.Nil:
-			time.Sleep(time.Duration(s.cfg.RetryAfterMs) * time.Millisecond)
+			s.clk.Sleep(time.Duration(s.cfg.RetryAfterMs) * time.Millisecond)
 			continue
 		case nil:
+			if s.watchdog {
+				s.startRenewal(ctx, "Acquire", key, value)
+			}
 			return nil
 		default:
 			return err
@@ -80,6 +225,7 @@ func (s *serviceImpl) Acquire(ctx context.Context, key string) error {
 }
 
 func (s *serviceImpl) Release(ctx context.Context, key string) error {
+	s.stopRenewal(key)
 	v, err := s.rc.Do(ctx, s.rc.B().
 		Del().
 		Key(s.getKey(key)).
@@ -93,6 +239,170 @@ func (s *serviceImpl) Release(ctx context.Context, key string) error {
 	return ErrKeyNotExists
 }
 
+func (s *serviceImpl) AcquireToken(ctx context.Context, key string) (string, error) {
+	token, err := s.acquireToken(ctx, acquireTokenScript, []string{s.getKey(key), s.getFenceKey(key)})
+	if err == nil && s.watchdog {
+		s.startRenewal(ctx, "AcquireToken", key, token)
+	}
+	return token, err
+}
+
+func (s *serviceImpl) AcquireTokenNoWait(ctx context.Context, key string) (string, bool, error) {
+	token, err := acquireTokenScript.Exec(ctx, s.rc, []string{s.getKey(key), s.getFenceKey(key)},
+		[]string{strconv.FormatInt(s.cfg.ExpireMs, 10)}).AsInt64()
+	switch err {
+	case rueidis.Nil:
+		return "", false, nil
+	case nil:
+		t := strconv.FormatInt(token, 10)
+		if s.watchdog {
+			s.startRenewal(ctx, "AcquireTokenNoWait", key, t)
+		}
+		return t, true, nil
+	default:
+		return "", false, err
+	}
+}
+
+func (s *serviceImpl) RenewToken(ctx context.Context, key, token string) error {
+	v, err := renewScript.Exec(ctx, s.rc, []string{s.getKey(key)},
+		[]string{token, strconv.FormatInt(s.cfg.ExpireMs, 10)}).AsInt64()
+	if err != nil {
+		return err
+	}
+	switch v {
+	case 1:
+		return nil
+	case 0:
+		return ErrKeyNotExists
+	default:
+		return ErrNotHolder
+	}
+}
+
+func (s *serviceImpl) ReleaseToken(ctx context.Context, key, token string) error {
+	v, err := releaseTokenScript.Exec(ctx, s.rc, []string{s.getKey(key)}, []string{token}).AsInt64()
+	if err != nil {
+		return err
+	}
+	switch v {
+	case 1:
+		s.stopRenewal(key)
+		return nil
+	case 0:
+		s.stopRenewal(key)
+		return ErrKeyNotExists
+	default:
+		return ErrNotHolder
+	}
+}
+
+func (s *serviceImpl) WithLock(ctx context.Context, key string, fn func(ctx context.Context) error) (err error) {
+	token, err := s.AcquireToken(ctx, key)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		// Release the key even if ctx is cancelled.
+		if releaseErr := s.ReleaseToken(context.WithoutCancel(ctx), key, token); releaseErr != nil {
+			err = errors.Join(err, releaseErr)
+		}
+	}()
+	return fn(context.WithValue(ctx, tokenKey{}, token))
+}
+
+// acquireToken runs the script with the keys and ExpireMs until it returns a token instead of nil, or ctx is cancelled.
+func (s *serviceImpl) acquireToken(ctx context.Context, script *rueidis.Lua, keys []string) (string, error) {
+	args := []string{strconv.FormatInt(s.cfg.ExpireMs, 10)}
+	for {
+		token, err := script.Exec(ctx, s.rc, keys, args).AsInt64()
+		switch err {
+		case rueidis.Nil:
+			s.clk.Sleep(time.Duration(s.cfg.RetryAfterMs) * time.Millisecond)
+			continue
+		case nil:
+			return strconv.FormatInt(token, 10), nil
+		default:
+			return "", err
+		}
+	}
+}
+
+// startRenewal starts renewing the key in background until ctx is cancelled or [serviceImpl.stopRenewal] is called.
+// The key is only renewed while its value is still the given value, and method is the name of the calling method used
+// in logs.
+func (s *serviceImpl) startRenewal(ctx context.Context, method, key, value string) {
+	keys := []string{s.getKey(key)}
+	args := []string{value, strconv.FormatInt(s.cfg.ExpireMs, 10)}
+	s.startRenewalFunc(ctx, method, key, func(ctx context.Context) (bool, error) {
+		renewed, err := renewScript.Exec(ctx, s.rc, keys, args).AsInt64()
+		return renewed == 1, err
+	})
+}
+
+// startRenewalFunc starts calling renew every ExpireMs/3 in background until ctx is cancelled, renew reports that the
+// key is lost, or [serviceImpl.stopRenewal] is called.
+func (s *serviceImpl) startRenewalFunc(ctx context.Context, method, key string,
+	renew func(ctx context.Context) (bool, error)) {
+	ctx, cancel := context.WithCancel(ctx)
+	r := &renewal{cancel}
+	s.mu.Lock()
+	if prev, ok := s.renewals[key]; ok {
+		prev.cancel()
+	}
+	s.renewals[key] = r
+	s.mu.Unlock()
+
+	interval := max(time.Duration(s.cfg.ExpireMs)*time.Millisecond/3, time.Millisecond) // nolint:mnd
+	logger := s.l.With(constant.LogAttrMethod, method, "key", key)
+	go func() {
+		defer func() {
+			cancel()
+			s.mu.Lock()
+			if s.renewals[key] == r {
+				delete(s.renewals, key)
+			}
+			s.mu.Unlock()
+		}()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-s.clk.After(interval):
+			}
+			renewed, err := renew(ctx)
+			if err != nil {
+				if ctx.Err() == nil {
+					logger.ErrorContext(ctx, "Renew lock failed.", constant.LogAttrError, err)
+				}
+				continue
+			}
+			if !renewed {
+				logger.WarnContext(ctx, "Lock lost before renewal. Stopping watchdog...")
+				return
+			}
+		}
+	}()
+}
+
+// stopRenewal stops renewing the key.
+func (s *serviceImpl) stopRenewal(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if r, ok := s.renewals[key]; ok {
+		r.cancel()
+		delete(s.renewals, key)
+	}
+}
+
+func (s *serviceImpl) Expiration() time.Duration {
+	return time.Duration(s.cfg.ExpireMs) * time.Millisecond
+}
+
 func (s *serviceImpl) getKey(key string) string {
 	return fmt.Sprintf("%s:%s", s.cfg.Prefix, key)
 }
+
+func (s *serviceImpl) getFenceKey(key string) string {
+	return s.getKey(key) + ":fence"
+}
@@ -11,6 +11,7 @@ package dlock
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	gomock "go.uber.org/mock/gomock"
 )
@@ -53,6 +54,65 @@ func (mr *MockServiceMockRecorder) Acquire(ctx, key any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Acquire", reflect.TypeOf((*MockService)(nil).Acquire), ctx, key)
 }
 
+// AcquireToken mocks base method.
+func (m *MockService) AcquireToken(ctx context.Context, key string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AcquireToken", ctx, key)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AcquireToken indicates an expected call of AcquireToken.
+func (mr *MockServiceMockRecorder) AcquireToken(ctx, key any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AcquireToken", reflect.TypeOf((*MockService)(nil).AcquireToken), ctx, key)
+}
+
+// AcquireTokenNoWait mocks base method.
+func (m *MockService) AcquireTokenNoWait(ctx context.Context, key string) (string, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AcquireTokenNoWait", ctx, key)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// AcquireTokenNoWait indicates an expected call of AcquireTokenNoWait.
+func (mr *MockServiceMockRecorder) AcquireTokenNoWait(ctx, key any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AcquireTokenNoWait", reflect.TypeOf((*MockService)(nil).AcquireTokenNoWait), ctx, key)
+}
+
+// Expiration mocks base method.
+func (m *MockService) Expiration() time.Duration {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Expiration")
+	ret0, _ := ret[0].(time.Duration)
+	return ret0
+}
+
+// Expiration indicates an expected call of Expiration.
+func (mr *MockServiceMockRecorder) Expiration() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Expiration", reflect.TypeOf((*MockService)(nil).Expiration))
+}
+
+// RWLock mocks base method.
+func (m *MockService) RWLock(key string) RWLock {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RWLock", key)
+	ret0, _ := ret[0].(RWLock)
+	return ret0
+}
+
+// RWLock indicates an expected call of RWLock.
+func (mr *MockServiceMockRecorder) RWLock(key any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RWLock", reflect.TypeOf((*MockService)(nil).RWLock), key)
+}
+
 // Release mocks base method.
 func (m *MockService) Release(ctx context.Context, key string) error {
 	m.ctrl.T.Helper()
@@ -67,6 +127,34 @@ func (mr *MockServiceMockRecorder) Release(ctx, key any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Release", reflect.TypeOf((*MockService)(nil).Release), ctx, key)
 }
 
+// ReleaseToken mocks base method.
+func (m *MockService) ReleaseToken(ctx context.Context, key, token string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReleaseToken", ctx, key, token)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReleaseToken indicates an expected call of ReleaseToken.
+func (mr *MockServiceMockRecorder) ReleaseToken(ctx, key, token any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReleaseToken", reflect.TypeOf((*MockService)(nil).ReleaseToken), ctx, key, token)
+}
+
+// RenewToken mocks base method.
+func (m *MockService) RenewToken(ctx context.Context, key, token string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RenewToken", ctx, key, token)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RenewToken indicates an expected call of RenewToken.
+func (mr *MockServiceMockRecorder) RenewToken(ctx, key, token any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RenewToken", reflect.TypeOf((*MockService)(nil).RenewToken), ctx, key, token)
+}
+
 // TryAcquire mocks base method.
 func (m *MockService) TryAcquire(ctx context.Context, key string) (bool, error) {
 	m.ctrl.T.Helper()
@@ -81,3 +169,17 @@ func (mr *MockServiceMockRecorder) TryAcquire(ctx, key any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TryAcquire", reflect.TypeOf((*MockService)(nil).TryAcquire), ctx, key)
 }
+
+// WithLock mocks base method.
+func (m *MockService) WithLock(ctx context.Context, key string, fn func(context.Context) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WithLock", ctx, key, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WithLock indicates an expected call of WithLock.
+func (mr *MockServiceMockRecorder) WithLock(ctx, key, fn any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WithLock", reflect.TypeOf((*MockService)(nil).WithLock), ctx, key, fn)
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strconv"
 	"sync"
 	"testing"
 	"time"
@@ -44,6 +45,9 @@ func TestDlock(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
+	if locker.Expiration() != 2*time.Second {
+		t.Fatalf("Expect expiration 2s, got %s", locker.Expiration())
+	}
 
 	// Init wait group
 	wg := &sync.WaitGroup{}
@@ -170,3 +174,201 @@ func TestDlock(t *testing.T) {
 		t.Fatalf("Errors: %+v", errs)
 	}
 }
+
+func TestDlock_watchdog(t *testing.T) {
+	t.Parallel()
+
+	rc, err := rueidis.NewClient(rueidis.ClientOption{
+		InitAddress: []string{"localhost:6379"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rc.Close()
+
+	cfg := &dlock.Config{
+		Prefix:       "test_dlock_watchdog",
+		ExpireMs:     300,
+		RetryAfterMs: 30,
+	}
+	locker, err := dlock.NewService(cfg, rc, dlock.WithWatchdog())
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectAcquirable := func(key string, want bool) {
+		t.Helper()
+
+		success, err := locker.TryAcquire(context.Background(), key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if success != want {
+			t.Fatalf("Expect success = %t, got %t", want, success)
+		}
+	}
+
+	// The key is renewed until it's released.
+	if err := locker.Acquire(context.Background(), "foo"); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(time.Second)
+	expectAcquirable("foo", false)
+	if err := locker.Release(context.Background(), "foo"); err != nil {
+		t.Fatal(err)
+	}
+	expectAcquirable("foo", true)
+
+	// The key is renewed until ctx is cancelled.
+	ctx, cancel := context.WithCancel(context.Background())
+	if err := locker.Acquire(ctx, "bar"); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(time.Second)
+	expectAcquirable("bar", false)
+	cancel()
+	time.Sleep(time.Second)
+	expectAcquirable("bar", true)
+
+	// The key is not renewed after it's acquired by others.
+	if err := locker.Acquire(context.Background(), "baz"); err != nil {
+		t.Fatal(err)
+	}
+	if err := rc.Do(context.Background(), rc.B().Set().Key(cfg.Prefix+":baz").Value("other").PxMilliseconds(
+		cfg.ExpireMs).Build()).Error(); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(time.Second)
+	expectAcquirable("baz", true)
+}
+
+func TestDlock_token(t *testing.T) {
+	t.Parallel()
+
+	rc, err := rueidis.NewClient(rueidis.ClientOption{
+		InitAddress: []string{"localhost:6379"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rc.Close()
+
+	cfg := &dlock.Config{
+		Prefix:       "test_dlock_token",
+		ExpireMs:     2000,
+		RetryAfterMs: 30,
+	}
+	locker, err := dlock.NewService(cfg, rc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	token1, err := locker.AcquireToken(ctx, "foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := locker.ReleaseToken(ctx, "foo", token1+"0"); !errors.Is(err, dlock.ErrNotHolder) {
+		t.Fatalf("Expect error %+v, got %+v", dlock.ErrNotHolder, err)
+	}
+	if err := locker.ReleaseToken(ctx, "foo", token1); err != nil {
+		t.Fatal(err)
+	}
+	if err := locker.ReleaseToken(ctx, "foo", token1); !errors.Is(err, dlock.ErrKeyNotExists) {
+		t.Fatalf("Expect error %+v, got %+v", dlock.ErrKeyNotExists, err)
+	}
+
+	// Tokens increase monotonically.
+	token2, err := locker.AcquireToken(ctx, "foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	n1, _ := strconv.ParseInt(token1, 10, 64)
+	n2, _ := strconv.ParseInt(token2, 10, 64)
+	if n2 <= n1 {
+		t.Fatalf("Expect token %s > %s", token2, token1)
+	}
+
+	// The key can't be acquired until it's released.
+	timeout, cancel := context.WithTimeout(ctx, 200*time.Millisecond)
+	defer cancel()
+	if _, err := locker.AcquireToken(timeout, "foo"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expect error %+v, got %+v", context.DeadlineExceeded, err)
+	}
+	if _, ok, err := locker.AcquireTokenNoWait(ctx, "foo"); ok || err != nil {
+		t.Fatalf("Expect ok = false and nil error, got %v and %+v", ok, err)
+	}
+
+	// Only the holder can renew the key.
+	if err := locker.RenewToken(ctx, "foo", token2); err != nil {
+		t.Fatal(err)
+	}
+	if err := locker.RenewToken(ctx, "foo", token1); !errors.Is(err, dlock.ErrNotHolder) {
+		t.Fatalf("Expect error %+v, got %+v", dlock.ErrNotHolder, err)
+	}
+	if err := locker.ReleaseToken(ctx, "foo", token2); err != nil {
+		t.Fatal(err)
+	}
+	if err := locker.RenewToken(ctx, "foo", token2); !errors.Is(err, dlock.ErrKeyNotExists) {
+		t.Fatalf("Expect error %+v, got %+v", dlock.ErrKeyNotExists, err)
+	}
+
+	// The key can be acquired immediately after it's released.
+	token3, ok, err := locker.AcquireTokenNoWait(ctx, "foo")
+	if !ok || err != nil {
+		t.Fatalf("Expect ok = true and nil error, got %v and %+v", ok, err)
+	}
+	if err := locker.ReleaseToken(ctx, "foo", token3); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDlock_WithLock(t *testing.T) {
+	t.Parallel()
+
+	rc, err := rueidis.NewClient(rueidis.ClientOption{
+		InitAddress: []string{"localhost:6379"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rc.Close()
+
+	locker, err := dlock.NewService(&dlock.Config{
+		Prefix:       "test_dlock_with_lock",
+		ExpireMs:     2000,
+		RetryAfterMs: 30,
+	}, rc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	failed := errors.New("failed")
+	err = locker.WithLock(ctx, "foo", func(ctx context.Context) error {
+		if token, ok := dlock.TokenFromContext(ctx); !ok || token == "" {
+			t.Errorf("Expect token in context, got %q", token)
+		}
+		if success, err := locker.TryAcquire(ctx, "foo"); success || err != nil {
+			t.Errorf("Expect the key to be held, got success = %t, err = %+v", success, err)
+		}
+		return failed
+	})
+	if !errors.Is(err, failed) {
+		t.Fatalf("Expect error %+v, got %+v", failed, err)
+	}
+
+	// The key is released even if fn panics.
+	func() {
+		defer func() {
+			if r := recover(); r == nil {
+				t.Fatal("Expect panic")
+			}
+		}()
+		_ = locker.WithLock(ctx, "foo", func(context.Context) error {
+			panic("oops")
+		})
+	}()
+	if success, err := locker.TryAcquire(ctx, "foo"); !success || err != nil {
+		t.Fatalf("Expect the key to be released, got success = %t, err = %+v", success, err)
+	}
+}
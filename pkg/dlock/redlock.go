@@ -0,0 +1,275 @@
+package dlock
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/redis/rueidis"
+	"github.com/sainnhe/go-common/pkg/clock"
+	"github.com/sainnhe/go-common/pkg/constant"
+	"github.com/sainnhe/go-common/pkg/log"
+)
+
+// clockDriftFactor is the factor of ExpireMs added to the clock drift between nodes, as suggested by the Redlock
+// algorithm.
+const clockDriftFactor = 0.01
+
+// redlockImpl implements [Service] with the Redlock algorithm on independent nodes. The embedded serviceImpl provides
+// the config, clock, logger and watchdog, and all methods of [Service] are overridden.
+type redlockImpl struct {
+	*serviceImpl
+	rcs    []rueidis.Client
+	quorum int
+
+	valuesMu sync.Mutex
+	values   map[string]string
+}
+
+/*
+NewRedlockService initializes a new dlock service in Redlock mode, where a key is acquired only if it's acquired on a
+majority of the given independent nodes within its expiration, so that the lock survives the failure of a minority of
+nodes. The nodes should be independent masters rather than replicas or nodes of the same cluster.
+
+Each acquisition sets the key to a random value, which is remembered by the service until [Service.Release] is called,
+so keys must be released by the same service that acquired them. Since the counters of fencing tokens can't be kept
+increasing across independent nodes, the token methods like [Service.AcquireToken] and [RWLock] return
+[errors.ErrUnsupported], and [Service.WithLock] doesn't provide a token via [TokenFromContext].
+
+Params:
+  - cfg: The config.
+  - rcs: The clients of the nodes. An odd number of nodes like 3 or 5 is recommended.
+  - opts: The options.
+
+Returns:
+  - Service: The dlock service.
+  - error: [constant.ErrNilDeps] if cfg is nil, rcs is empty, or any client is nil.
+*/
+func NewRedlockService(cfg *Config, rcs []rueidis.Client, opts ...Option) (Service, error) {
+	if cfg == nil || len(rcs) == 0 {
+		return nil, constant.ErrNilDeps
+	}
+	for _, rc := range rcs {
+		if rc == nil {
+			return nil, constant.ErrNilDeps
+		}
+	}
+	s := &serviceImpl{
+		cfg:      cfg,
+		rc:       rcs[0],
+		clk:      clock.Real(),
+		l:        log.NewLogger(pkgName),
+		renewals: map[string]*renewal{},
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return &redlockImpl{
+		serviceImpl: s,
+		rcs:         rcs,
+		quorum:      len(rcs)/2 + 1, // nolint:mnd
+		values:      map[string]string{},
+	}, nil
+}
+
+func (s *redlockImpl) TryAcquire(ctx context.Context, key string) (bool, error) {
+	k := s.getKey(key)
+	free, err := s.each(ctx, func(ctx context.Context, rc rueidis.Client) (bool, error) {
+		err := rc.Do(ctx, rc.B().Get().Key(k).Build()).Error()
+		if rueidis.IsRedisNil(err) {
+			return true, nil
+		}
+		return false, err
+	})
+	if free >= s.quorum {
+		return true, nil
+	}
+	return false, err
+}
+
+func (s *redlockImpl) Acquire(ctx context.Context, key string) error {
+	value := rand.Text()
+	for {
+		acquired, err := s.lock(ctx, key, value)
+		if err != nil {
+			return err
+		}
+		if !acquired {
+			s.clk.Sleep(time.Duration(s.cfg.RetryAfterMs) * time.Millisecond)
+			continue
+		}
+		s.valuesMu.Lock()
+		s.values[key] = value
+		s.valuesMu.Unlock()
+		if s.watchdog {
+			s.startRenewalFunc(ctx, "Acquire", key, func(ctx context.Context) (bool, error) {
+				return s.renew(ctx, key, value)
+			})
+		}
+		return nil
+	}
+}
+
+func (s *redlockImpl) Release(ctx context.Context, key string) error {
+	s.stopRenewal(key)
+	s.valuesMu.Lock()
+	value, ok := s.values[key]
+	delete(s.values, key)
+	s.valuesMu.Unlock()
+	if !ok {
+		return ErrKeyNotExists
+	}
+	released, err := s.unlock(ctx, key, value)
+	if released > 0 {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return ErrKeyNotExists
+}
+
+func (s *redlockImpl) AcquireToken(context.Context, string) (string, error) {
+	return "", errors.ErrUnsupported
+}
+
+func (s *redlockImpl) AcquireTokenNoWait(context.Context, string) (string, bool, error) {
+	return "", false, errors.ErrUnsupported
+}
+
+func (s *redlockImpl) RenewToken(context.Context, string, string) error {
+	return errors.ErrUnsupported
+}
+
+func (s *redlockImpl) ReleaseToken(context.Context, string, string) error {
+	return errors.ErrUnsupported
+}
+
+func (s *redlockImpl) WithLock(ctx context.Context, key string, fn func(ctx context.Context) error) (err error) {
+	if err = s.Acquire(ctx, key); err != nil {
+		return err
+	}
+	defer func() {
+		// Release the key even if ctx is cancelled.
+		if releaseErr := s.Release(context.WithoutCancel(ctx), key); releaseErr != nil {
+			err = errors.Join(err, releaseErr)
+		}
+	}()
+	return fn(ctx)
+}
+
+func (s *redlockImpl) Expiration() time.Duration {
+	return time.Duration(s.cfg.ExpireMs) * time.Millisecond
+}
+
+func (s *redlockImpl) RWLock(string) RWLock {
+	return unsupportedRWLock{}
+}
+
+// lock tries to set the key to value on all nodes, and reports whether it's acquired on a quorum of nodes before it
+// expires. Otherwise, the key is unlocked on all nodes. An error is returned only if the quorum can't be reached due to
+// errors rather than contention.
+func (s *redlockImpl) lock(ctx context.Context, key, value string) (bool, error) {
+	k := s.getKey(key)
+	start := s.clk.Now()
+	acquired, err := s.each(ctx, func(ctx context.Context, rc rueidis.Client) (bool, error) {
+		err := rc.Do(ctx, rc.B().Set().Key(k).Value(value).Nx().PxMilliseconds(s.cfg.ExpireMs).Build()).Error()
+		if rueidis.IsRedisNil(err) {
+			return false, nil
+		}
+		return err == nil, err
+	})
+	expire := time.Duration(s.cfg.ExpireMs) * time.Millisecond
+	drift := time.Duration(float64(expire)*clockDriftFactor) + 2*time.Millisecond // nolint:mnd
+	if acquired >= s.quorum && expire-s.clk.Since(start)-drift > 0 {
+		return true, nil
+	}
+	// Release the partially acquired key even if ctx is cancelled, so that others don't wait for it to expire.
+	_, _ = s.unlock(context.WithoutCancel(ctx), key, value)
+	if err != nil && errorCount(err) > len(s.rcs)-s.quorum {
+		return false, err
+	}
+	return false, nil
+}
+
+// unlock deletes the key on all nodes where its value is still value, and returns the number of deleted keys.
+func (s *redlockImpl) unlock(ctx context.Context, key, value string) (int, error) {
+	keys := []string{s.getKey(key)}
+	args := []string{value}
+	return s.each(ctx, func(ctx context.Context, rc rueidis.Client) (bool, error) {
+		v, err := releaseTokenScript.Exec(ctx, rc, keys, args).AsInt64()
+		return v == 1, err
+	})
+}
+
+// renew renews the key on all nodes where its value is still value, and reports whether it's renewed on a quorum of
+// nodes.
+func (s *redlockImpl) renew(ctx context.Context, key, value string) (bool, error) {
+	keys := []string{s.getKey(key)}
+	args := []string{value, strconv.FormatInt(s.cfg.ExpireMs, 10)}
+	renewed, err := s.each(ctx, func(ctx context.Context, rc rueidis.Client) (bool, error) {
+		v, err := renewScript.Exec(ctx, rc, keys, args).AsInt64()
+		return v == 1, err
+	})
+	if renewed >= s.quorum {
+		return true, nil
+	}
+	if err != nil && errorCount(err) > len(s.rcs)-s.quorum {
+		return false, err
+	}
+	return false, nil
+}
+
+// each runs fn on all nodes concurrently, and returns the number of nodes where fn returns true, along with the joined
+// errors of the others.
+func (s *redlockImpl) each(ctx context.Context, fn func(ctx context.Context, rc rueidis.Client) (bool, error)) (
+	int, error) {
+	oks := make([]bool, len(s.rcs))
+	errs := make([]error, len(s.rcs))
+	wg := sync.WaitGroup{}
+	for i, rc := range s.rcs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			oks[i], errs[i] = fn(ctx, rc)
+		}()
+	}
+	wg.Wait()
+	n := 0
+	for _, ok := range oks {
+		if ok {
+			n++
+		}
+	}
+	return n, errors.Join(errs...)
+}
+
+// errorCount returns the number of errors joined by [errors.Join].
+func errorCount(err error) int {
+	if joined, ok := err.(interface{ Unwrap() []error }); ok { // nolint:errorlint
+		return len(joined.Unwrap())
+	}
+	return 1
+}
+
+// unsupportedRWLock is the [RWLock] returned in Redlock mode.
+type unsupportedRWLock struct{}
+
+func (unsupportedRWLock) RLock(context.Context) (string, error) {
+	return "", errors.ErrUnsupported
+}
+
+func (unsupportedRWLock) RUnlock(context.Context, string) error {
+	return errors.ErrUnsupported
+}
+
+func (unsupportedRWLock) Lock(context.Context) (string, error) {
+	return "", errors.ErrUnsupported
+}
+
+func (unsupportedRWLock) Unlock(context.Context, string) error {
+	return errors.ErrUnsupported
+}
@@ -0,0 +1,94 @@
+package dlock_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/redis/rueidis"
+	"github.com/sainnhe/go-common/pkg/constant"
+	"github.com/sainnhe/go-common/pkg/dlock"
+)
+
+func TestNewRedlockService(t *testing.T) {
+	t.Parallel()
+
+	cfg := &dlock.Config{}
+	for _, rcs := range [][]rueidis.Client{nil, {nil}} {
+		if _, err := dlock.NewRedlockService(cfg, rcs); !errors.Is(err, constant.ErrNilDeps) {
+			t.Fatalf("Expect error %+v, got %+v", constant.ErrNilDeps, err)
+		}
+	}
+}
+
+func TestRedlock(t *testing.T) {
+	t.Parallel()
+
+	// Use different databases as independent nodes.
+	rcs := []rueidis.Client{}
+	for db := 1; db <= 3; db++ {
+		rc, err := rueidis.NewClient(rueidis.ClientOption{
+			InitAddress: []string{"localhost:6379"},
+			SelectDB:    db,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer rc.Close()
+		rcs = append(rcs, rc)
+	}
+	cfg := &dlock.Config{
+		Prefix:       "test_dlock_redlock",
+		ExpireMs:     2000,
+		RetryAfterMs: 30,
+	}
+	locker, err := dlock.NewRedlockService(cfg, rcs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := dlock.NewRedlockService(cfg, rcs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	if err := locker.Acquire(ctx, "foo"); err != nil {
+		t.Fatal(err)
+	}
+	if success, err := other.TryAcquire(ctx, "foo"); success || err != nil {
+		t.Fatalf("Expect the key to be held, got success = %t, err = %+v", success, err)
+	}
+	// The key held by others can't be released.
+	if err := other.Release(ctx, "foo"); !errors.Is(err, dlock.ErrKeyNotExists) {
+		t.Fatalf("Expect error %+v, got %+v", dlock.ErrKeyNotExists, err)
+	}
+	// The key held on a minority of nodes can be acquired.
+	if err := rcs[0].Do(ctx, rcs[0].B().Set().Key("test_dlock_redlock:bar").Value("x").Build()).Error(); err != nil {
+		t.Fatal(err)
+	}
+	defer rcs[0].Do(ctx, rcs[0].B().Del().Key("test_dlock_redlock:bar").Build())
+	err = other.WithLock(ctx, "bar", func(ctx context.Context) error {
+		if _, ok := dlock.TokenFromContext(ctx); ok {
+			t.Error("Expect no token in Redlock mode")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := locker.Release(ctx, "foo"); err != nil {
+		t.Fatal(err)
+	}
+	if success, err := other.TryAcquire(ctx, "foo"); !success || err != nil {
+		t.Fatalf("Expect the key to be released, got success = %t, err = %+v", success, err)
+	}
+
+	// Fencing tokens are not supported.
+	if _, err := locker.AcquireToken(ctx, "foo"); !errors.Is(err, errors.ErrUnsupported) {
+		t.Fatalf("Expect error %+v, got %+v", errors.ErrUnsupported, err)
+	}
+	if _, err := locker.RWLock("foo").RLock(ctx); !errors.Is(err, errors.ErrUnsupported) {
+		t.Fatalf("Expect error %+v, got %+v", errors.ErrUnsupported, err)
+	}
+}
@@ -0,0 +1,115 @@
+//go:generate mockgen -write_package_comment=false -source=rwlock.go -destination=rwlock_mock.go -package dlock
+
+package dlock
+
+import (
+	"context"
+
+	"github.com/redis/rueidis"
+)
+
+var (
+	// rlockScript adds a reader to the sorted set KEYS[2] scored by its expiration time, unless the writer KEYS[1] or
+	// a waiting writer KEYS[3] exists. It returns the token incremented from KEYS[4], or nil if it can't be acquired.
+	rlockScript = rueidis.NewLuaScript(`
+if redis.call("EXISTS", KEYS[1]) == 1 or redis.call("EXISTS", KEYS[3]) == 1 then
+	return false
+end
+local time = redis.call("TIME")
+local now = time[1] * 1000 + math.floor(time[2] / 1000)
+local token = redis.call("INCR", KEYS[4])
+redis.call("ZADD", KEYS[2], now + ARGV[1], token)
+redis.call("PEXPIRE", KEYS[2], ARGV[1])
+return token`)
+
+	// lockScript sets the writer KEYS[1] if neither the writer nor unexpired readers in KEYS[2] exist. Otherwise, it
+	// marks a waiting writer KEYS[3] if readers exist, so that new readers wait for the writer. It returns the token
+	// incremented from KEYS[4], or nil if it can't be acquired.
+	lockScript = rueidis.NewLuaScript(`
+if redis.call("EXISTS", KEYS[1]) == 1 then
+	return false
+end
+local time = redis.call("TIME")
+local now = time[1] * 1000 + math.floor(time[2] / 1000)
+redis.call("ZREMRANGEBYSCORE", KEYS[2], "-inf", now)
+if redis.call("ZCARD", KEYS[2]) > 0 then
+	redis.call("SET", KEYS[3], 1, "PX", ARGV[1])
+	return false
+end
+local token = redis.call("INCR", KEYS[4])
+redis.call("SET", KEYS[1], token, "PX", ARGV[1])
+redis.call("DEL", KEYS[3])
+return token`)
+)
+
+/*
+RWLock is a distributed read-write lock, which can be held by multiple readers or a single writer at a time. It's
+writer-preferring: once a writer is waiting, new readers wait until the writer releases the lock, so that writers are
+not starved by continuous readers.
+
+Like [Service.AcquireToken], the lock and each reader expire after ExpireMs, and a fencing token is returned when it's
+acquired. Readers and writers share the same increasing token sequence. The watchdog is not supported.
+
+The lock is stored in the keys with the suffixes ":rw:writer", ":rw:readers", ":rw:wait" and ":rw:fence". In Redis
+Cluster, the key should contain a hash tag like "{order:1}" so that all keys are in the same slot.
+*/
+type RWLock interface {
+	// RLock acquires the lock for reading. If it's held or waited by a writer, wait and retry until ctx is cancelled.
+	RLock(ctx context.Context) (token string, err error)
+
+	// RUnlock releases the lock acquired for reading with the token.
+	// [ErrKeyNotExists] is returned if the reader doesn't exist, e.g. it has expired.
+	RUnlock(ctx context.Context, token string) error
+
+	// Lock acquires the lock for writing. If it's held by others, wait and retry until ctx is cancelled.
+	Lock(ctx context.Context) (token string, err error)
+
+	// Unlock releases the lock acquired for writing with the token.
+	// [ErrKeyNotExists] is returned if it doesn't exist, and [ErrNotHolder] is returned if it's held by others.
+	Unlock(ctx context.Context, token string) error
+}
+
+type rwLockImpl struct {
+	s    *serviceImpl
+	keys []string
+}
+
+func (s *serviceImpl) RWLock(key string) RWLock {
+	prefix := s.getKey(key) + ":rw:"
+	return &rwLockImpl{s, []string{prefix + "writer", prefix + "readers", prefix + "wait", prefix + "fence"}}
+}
+
+func (l *rwLockImpl) RLock(ctx context.Context) (string, error) {
+	return l.s.acquireToken(ctx, rlockScript, l.keys)
+}
+
+func (l *rwLockImpl) RUnlock(ctx context.Context, token string) error {
+	s := l.s
+	n, err := s.rc.Do(ctx, s.rc.B().Zrem().Key(l.keys[1]).Member(token).Build()).AsInt64()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrKeyNotExists
+	}
+	return nil
+}
+
+func (l *rwLockImpl) Lock(ctx context.Context) (string, error) {
+	return l.s.acquireToken(ctx, lockScript, l.keys)
+}
+
+func (l *rwLockImpl) Unlock(ctx context.Context, token string) error {
+	v, err := releaseTokenScript.Exec(ctx, l.s.rc, l.keys[:1], []string{token}).AsInt64()
+	if err != nil {
+		return err
+	}
+	switch v {
+	case 1:
+		return nil
+	case 0:
+		return ErrKeyNotExists
+	default:
+		return ErrNotHolder
+	}
+}
@@ -0,0 +1,93 @@
+package dlock_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/redis/rueidis"
+	"github.com/sainnhe/go-common/pkg/dlock"
+)
+
+func TestRWLock(t *testing.T) {
+	t.Parallel()
+
+	rc, err := rueidis.NewClient(rueidis.ClientOption{
+		InitAddress: []string{"localhost:6379"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rc.Close()
+
+	locker, err := dlock.NewService(&dlock.Config{
+		Prefix:       "test_dlock_rwlock",
+		ExpireMs:     2000,
+		RetryAfterMs: 30,
+	}, rc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := locker.RWLock("foo")
+	ctx := context.Background()
+	expectBlocked := func(acquire func(ctx context.Context) (string, error)) {
+		t.Helper()
+
+		ctx, cancel := context.WithTimeout(ctx, 200*time.Millisecond)
+		defer cancel()
+		if _, err := acquire(ctx); !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("Expect error %+v, got %+v", context.DeadlineExceeded, err)
+		}
+	}
+
+	// Multiple readers
+	r1, err := l.RLock(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r2, err := l.RLock(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r1 == r2 {
+		t.Fatalf("Expect different tokens, got %s", r1)
+	}
+
+	// Writers wait for readers, and new readers wait for waiting writers.
+	expectBlocked(l.Lock)
+	expectBlocked(l.RLock)
+	if err := l.RUnlock(ctx, r1); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.RUnlock(ctx, r2); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.RUnlock(ctx, r2); !errors.Is(err, dlock.ErrKeyNotExists) {
+		t.Fatalf("Expect error %+v, got %+v", dlock.ErrKeyNotExists, err)
+	}
+
+	// Single writer
+	w, err := l.Lock(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectBlocked(l.Lock)
+	expectBlocked(l.RLock)
+	if err := l.Unlock(ctx, r1); !errors.Is(err, dlock.ErrNotHolder) {
+		t.Fatalf("Expect error %+v, got %+v", dlock.ErrNotHolder, err)
+	}
+	if err := l.Unlock(ctx, w); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Unlock(ctx, w); !errors.Is(err, dlock.ErrKeyNotExists) {
+		t.Fatalf("Expect error %+v, got %+v", dlock.ErrKeyNotExists, err)
+	}
+	r3, err := l.RLock(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := l.RUnlock(ctx, r3); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,249 @@
+package encoding
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/sainnhe/go-common/pkg/constant"
+)
+
+const (
+	// defaultSourceTimeout is the timeout used by config sources when no HTTP client is specified.
+	defaultSourceTimeout = 10 * time.Second
+
+	// maxSourceSize is the maximum size of config content loaded by config sources.
+	maxSourceSize = 10 << 20
+)
+
+var (
+	// ErrConfigSourceNotFound indicates an error that the config can't be found in the config source.
+	ErrConfigSourceNotFound = errors.New("config not found in source")
+
+	// ErrConfigSourceInvalid indicates an error that the config source is not properly configured.
+	ErrConfigSourceInvalid = errors.New("invalid config source")
+
+	// ErrConfigSourceTooLarge indicates an error that the config content in the config source exceeds 10 MiB.
+	ErrConfigSourceTooLarge = errors.New("config in source too large")
+)
+
+// ConfigSource is the source where the config content is loaded from.
+type ConfigSource interface {
+	// Load loads the raw config content from the source.
+	Load(ctx context.Context) ([]byte, error)
+}
+
+/*
+LoadConfigFrom loads config content from the given source, and parses it via [LoadConfig].
+
+Params:
+  - ctx [context.Context]: The context passed to the source, which can be used to cancel loading.
+  - source [ConfigSource]: The source where the config content is loaded from, for example [FileSource],
+    [HTTPSource], [EtcdSource] or [ConsulSource].
+  - typ [Type]: The config type.
+  - opts ...[LoadOption]: The options passed to [LoadConfig].
+
+Returns:
+  - *Config: The config struct.
+  - error: The error occurred during the execution, which may be [constant.ErrNilDeps], [ErrConfigSourceNotFound],
+    [ErrConfigSourceInvalid], [ErrConfigSourceTooLarge], errors returned by [LoadConfig] or other runtime errors.
+*/
+func LoadConfigFrom[Config any](ctx context.Context, source ConfigSource, typ Type, opts ...LoadOption) (*Config,
+	error) {
+	if source == nil {
+		return nil, constant.ErrNilDeps
+	}
+	content, err := source.Load(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return LoadConfig[Config](content, typ, opts...)
+}
+
+// FileSource loads config content from a local file.
+type FileSource struct {
+	// Path is the path of the config file.
+	Path string
+}
+
+// Load implements [ConfigSource]. [ErrConfigSourceNotFound] will be returned if the file doesn't exist.
+func (s *FileSource) Load(_ context.Context) ([]byte, error) {
+	if len(s.Path) == 0 {
+		return nil, ErrConfigSourceInvalid
+	}
+	f, err := os.Open(s.Path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", ErrConfigSourceNotFound, s.Path)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close() // nolint:errcheck
+	return readSource(f)
+}
+
+// HTTPSource loads config content from an HTTP(S) URL via GET requests.
+type HTTPSource struct {
+	// URL is the URL of the config file.
+	URL string
+
+	// Header is the optional header sent along with the request, for example an authorization header.
+	Header http.Header
+
+	// Client is the optional HTTP client. A client with a default timeout will be used if it's nil.
+	Client *http.Client
+}
+
+// Load implements [ConfigSource].
+func (s *HTTPSource) Load(ctx context.Context) ([]byte, error) {
+	if len(s.URL) == 0 {
+		return nil, ErrConfigSourceInvalid
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range s.Header {
+		req.Header[k] = v
+	}
+	return doSourceRequest(s.Client, req)
+}
+
+// EtcdSource loads config content from an etcd v3 key via the JSON gRPC gateway.
+type EtcdSource struct {
+	// Endpoint is the endpoint of the etcd server, for example "http://localhost:2379".
+	Endpoint string
+
+	// Key is the key that stores the config content.
+	Key string
+
+	// Token is the optional auth token returned by etcd's authenticate API.
+	Token string
+
+	// Client is the optional HTTP client. A client with a default timeout will be used if it's nil.
+	Client *http.Client
+}
+
+// Load implements [ConfigSource].
+func (s *EtcdSource) Load(ctx context.Context) ([]byte, error) {
+	if len(s.Endpoint) == 0 || len(s.Key) == 0 {
+		return nil, ErrConfigSourceInvalid
+	}
+
+	// Build request
+	body, err := json.Marshal(map[string]string{
+		"key": base64.StdEncoding.EncodeToString([]byte(s.Key)),
+	})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost,
+		strings.TrimSuffix(s.Endpoint, "/")+"/v3/kv/range", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if len(s.Token) > 0 {
+		req.Header.Set("Authorization", s.Token)
+	}
+	rspBody, err := doSourceRequest(s.Client, req)
+	if err != nil {
+		return nil, err
+	}
+
+	// Parse response
+	var rsp struct {
+		Kvs []struct {
+			Value string `json:"value"`
+		} `json:"kvs"`
+	}
+	if err = json.Unmarshal(rspBody, &rsp); err != nil {
+		return nil, err
+	}
+	if len(rsp.Kvs) == 0 {
+		return nil, ErrConfigSourceNotFound
+	}
+	return base64.StdEncoding.DecodeString(rsp.Kvs[0].Value)
+}
+
+// ConsulSource loads config content from a Consul KV key via the HTTP API.
+type ConsulSource struct {
+	// Address is the address of the Consul agent, for example "http://localhost:8500".
+	Address string
+
+	// Key is the key that stores the config content.
+	Key string
+
+	// Token is the optional ACL token.
+	Token string
+
+	// Datacenter is the optional datacenter to query. The datacenter of the agent will be used if it's empty.
+	Datacenter string
+
+	// Client is the optional HTTP client. A client with a default timeout will be used if it's nil.
+	Client *http.Client
+}
+
+// Load implements [ConfigSource].
+func (s *ConsulSource) Load(ctx context.Context) ([]byte, error) {
+	if len(s.Address) == 0 || len(s.Key) == 0 {
+		return nil, ErrConfigSourceInvalid
+	}
+	query := url.Values{}
+	query.Set("raw", "true")
+	if len(s.Datacenter) > 0 {
+		query.Set("dc", s.Datacenter)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+		fmt.Sprintf("%s/v1/kv/%s?%s",
+			strings.TrimSuffix(s.Address, "/"), strings.TrimPrefix(s.Key, "/"), query.Encode()), nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(s.Token) > 0 {
+		req.Header.Set("X-Consul-Token", s.Token)
+	}
+	return doSourceRequest(s.Client, req)
+}
+
+// doSourceRequest sends the request and returns the response body.
+func doSourceRequest(client *http.Client, req *http.Request) ([]byte, error) {
+	if client == nil {
+		client = &http.Client{Timeout: defaultSourceTimeout}
+	}
+	rsp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer rsp.Body.Close() // nolint:errcheck
+
+	switch {
+	case rsp.StatusCode == http.StatusNotFound:
+		return nil, ErrConfigSourceNotFound
+	case rsp.StatusCode < http.StatusOK || rsp.StatusCode >= http.StatusMultipleChoices:
+		return nil, fmt.Errorf("unexpected status code %d from %s", rsp.StatusCode, req.URL.Redacted())
+	}
+	return readSource(rsp.Body)
+}
+
+// readSource reads config content from r, and returns [ErrConfigSourceTooLarge] if it exceeds [maxSourceSize].
+func readSource(r io.Reader) ([]byte, error) {
+	content, err := io.ReadAll(io.LimitReader(r, maxSourceSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(content) > maxSourceSize {
+		return nil, ErrConfigSourceTooLarge
+	}
+	return content, nil
+}
@@ -0,0 +1,200 @@
+package encoding_test
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sainnhe/go-common/pkg/constant"
+	"github.com/sainnhe/go-common/pkg/encoding"
+)
+
+type sourceTestConfig struct {
+	Name string `json:"name"`
+	Num  int    `json:"num" default:"1"`
+}
+
+func TestLoadConfigFrom(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	t.Run("Nil source", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := encoding.LoadConfigFrom[sourceTestConfig](ctx, nil, encoding.TypeJSON)
+		if !errors.Is(err, constant.ErrNilDeps) {
+			t.Fatalf("Want %+v, got %+v", constant.ErrNilDeps, err)
+		}
+	})
+
+	t.Run("File", func(t *testing.T) {
+		t.Parallel()
+
+		path := filepath.Join(t.TempDir(), "config.yaml")
+		if err := os.WriteFile(path, []byte("name: file\n"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		cfg, err := encoding.LoadConfigFrom[sourceTestConfig](ctx, &encoding.FileSource{Path: path}, encoding.TypeYAML)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if cfg.Name != "file" || cfg.Num != 1 {
+			t.Fatalf("Got %+v", cfg)
+		}
+
+		_, err = encoding.LoadConfigFrom[sourceTestConfig](ctx, &encoding.FileSource{Path: path + ".none"}, encoding.TypeYAML)
+		if !errors.Is(err, encoding.ErrConfigSourceNotFound) {
+			t.Fatalf("Want %+v, got %+v", encoding.ErrConfigSourceNotFound, err)
+		}
+		_, err = encoding.LoadConfigFrom[sourceTestConfig](ctx, &encoding.FileSource{}, encoding.TypeYAML)
+		if !errors.Is(err, encoding.ErrConfigSourceInvalid) {
+			t.Fatalf("Want %+v, got %+v", encoding.ErrConfigSourceInvalid, err)
+		}
+	})
+
+	t.Run("HTTP", func(t *testing.T) {
+		t.Parallel()
+
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/large" {
+				_, _ = w.Write(make([]byte, 10<<20+1))
+				return
+			}
+			if r.Header.Get("Authorization") != "Bearer foo" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			_, _ = w.Write([]byte(`{"name": "http"}`))
+		}))
+		defer srv.Close()
+
+		cfg, err := encoding.LoadConfigFrom[sourceTestConfig](ctx, &encoding.HTTPSource{
+			URL:    srv.URL,
+			Header: http.Header{"Authorization": []string{"Bearer foo"}},
+		}, encoding.TypeJSON)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if cfg.Name != "http" || cfg.Num != 1 {
+			t.Fatalf("Got %+v", cfg)
+		}
+
+		_, err = encoding.LoadConfigFrom[sourceTestConfig](ctx, &encoding.HTTPSource{URL: srv.URL}, encoding.TypeJSON)
+		if err == nil {
+			t.Fatal("Expect error, got nil")
+		}
+
+		_, err = encoding.LoadConfigFrom[sourceTestConfig](ctx, &encoding.HTTPSource{}, encoding.TypeJSON)
+		if !errors.Is(err, encoding.ErrConfigSourceInvalid) {
+			t.Fatalf("Want %+v, got %+v", encoding.ErrConfigSourceInvalid, err)
+		}
+
+		_, err = encoding.LoadConfigFrom[sourceTestConfig](ctx, &encoding.HTTPSource{URL: srv.URL + "/large"},
+			encoding.TypeJSON)
+		if !errors.Is(err, encoding.ErrConfigSourceTooLarge) {
+			t.Fatalf("Want %+v, got %+v", encoding.ErrConfigSourceTooLarge, err)
+		}
+
+		cancelled, cancel := context.WithCancel(ctx)
+		cancel()
+		_, err = encoding.LoadConfigFrom[sourceTestConfig](cancelled, &encoding.HTTPSource{URL: srv.URL},
+			encoding.TypeJSON)
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("Want %+v, got %+v", context.Canceled, err)
+		}
+	})
+
+	t.Run("Etcd", func(t *testing.T) {
+		t.Parallel()
+
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var req struct {
+				Key string `json:"key"`
+			}
+			if r.URL.Path != "/v3/kv/range" || json.NewDecoder(r.Body).Decode(&req) != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			key, _ := base64.StdEncoding.DecodeString(req.Key)
+			if string(key) != "/config/app" {
+				_, _ = w.Write([]byte(`{"header": {}}`))
+				return
+			}
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"kvs": []map[string]string{
+					{"value": base64.StdEncoding.EncodeToString([]byte(`{"name": "etcd", "num": 2}`))},
+				},
+			})
+		}))
+		defer srv.Close()
+
+		cfg, err := encoding.LoadConfigFrom[sourceTestConfig](ctx, &encoding.EtcdSource{
+			Endpoint: srv.URL,
+			Key:      "/config/app",
+		}, encoding.TypeJSON)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if cfg.Name != "etcd" || cfg.Num != 2 {
+			t.Fatalf("Got %+v", cfg)
+		}
+
+		_, err = encoding.LoadConfigFrom[sourceTestConfig](ctx, &encoding.EtcdSource{
+			Endpoint: srv.URL,
+			Key:      "/config/none",
+		}, encoding.TypeJSON)
+		if !errors.Is(err, encoding.ErrConfigSourceNotFound) {
+			t.Fatalf("Want %+v, got %+v", encoding.ErrConfigSourceNotFound, err)
+		}
+
+		_, err = encoding.LoadConfigFrom[sourceTestConfig](ctx, &encoding.EtcdSource{Endpoint: srv.URL}, encoding.TypeJSON)
+		if !errors.Is(err, encoding.ErrConfigSourceInvalid) {
+			t.Fatalf("Want %+v, got %+v", encoding.ErrConfigSourceInvalid, err)
+		}
+	})
+
+	t.Run("Consul", func(t *testing.T) {
+		t.Parallel()
+
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/v1/kv/config/app" || r.URL.Query().Get("raw") != "true" {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			if r.Header.Get("X-Consul-Token") != "token" || r.URL.Query().Get("dc") != "dc1" {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			_, _ = w.Write([]byte("name: consul\nnum: 3\n"))
+		}))
+		defer srv.Close()
+
+		cfg, err := encoding.LoadConfigFrom[sourceTestConfig](ctx, &encoding.ConsulSource{
+			Address:    srv.URL,
+			Key:        "/config/app",
+			Token:      "token",
+			Datacenter: "dc1",
+		}, encoding.TypeYAML)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if cfg.Name != "consul" || cfg.Num != 3 {
+			t.Fatalf("Got %+v", cfg)
+		}
+
+		_, err = encoding.LoadConfigFrom[sourceTestConfig](ctx, &encoding.ConsulSource{
+			Address: srv.URL,
+			Key:     "config/none",
+		}, encoding.TypeYAML)
+		if !errors.Is(err, encoding.ErrConfigSourceNotFound) {
+			t.Fatalf("Want %+v, got %+v", encoding.ErrConfigSourceNotFound, err)
+		}
+	})
+}
@@ -0,0 +1,142 @@
+package encoding
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/sainnhe/go-common/pkg/bufpool"
+	"github.com/sainnhe/go-common/pkg/constant"
+)
+
+// RedactedValue is the value used to mask secret fields in [DumpConfig].
+const RedactedValue = "******"
+
+/*
+DumpConfig marshals a config into a JSON string that is safe for logging.
+
+Fields tagged with `secret:"true"` will be masked with [RedactedValue] if they are not zero values, including nested
+fields. The JSON field names respect the "json" struct tag, and fields tagged with `json:"-"` or unexported fields will
+be omitted.
+
+Params:
+  - cfg any: The config, which is usually a struct or a pointer to a struct.
+
+Returns:
+  - string: The JSON representation of the config with secrets masked.
+  - error: The error occurred during the execution, which may be [constant.ErrNilDeps] or marshaling errors.
+*/
+func DumpConfig(cfg any) (string, error) {
+	if cfg == nil {
+		return "", constant.ErrNilDeps
+	}
+	b, err := json.Marshal(redact(reflect.ValueOf(cfg)))
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// redactedField is a field in redactedStruct.
+type redactedField struct {
+	name string
+	val  any
+}
+
+// redactedStruct is a struct whose fields are marshaled in the order of declaration.
+type redactedStruct []redactedField
+
+func (s redactedStruct) MarshalJSON() ([]byte, error) {
+	buf := bufpool.GetBuilder(0)
+	defer bufpool.PutBuilder(buf)
+	buf.WriteByte('{') // nolint:errcheck
+	for i, f := range s {
+		if i > 0 {
+			buf.WriteByte(',') // nolint:errcheck
+		}
+		name, err := json.Marshal(f.name)
+		if err != nil {
+			return nil, err
+		}
+		val, err := json.Marshal(f.val)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(name)    // nolint:errcheck
+		buf.WriteByte(':') // nolint:errcheck
+		buf.Write(val)     // nolint:errcheck
+	}
+	buf.WriteByte('}') // nolint:errcheck
+	return bytes.Clone(buf.Bytes()), nil
+}
+
+var jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+
+// redact converts val into a value that can be marshaled with secrets masked.
+func redact(val reflect.Value) any {
+	if !val.IsValid() {
+		return nil
+	}
+	if val.Type().Implements(jsonMarshalerType) && val.CanInterface() {
+		return val.Interface()
+	}
+	switch val.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if val.IsNil() {
+			return nil
+		}
+		return redact(val.Elem())
+	case reflect.Struct:
+		fields := make(redactedStruct, 0, val.NumField())
+		for i := range val.NumField() {
+			field := val.Type().Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			name := field.Name
+			if tag, ok := field.Tag.Lookup("json"); ok {
+				if tag == "-" {
+					continue
+				}
+				if n, _, _ := strings.Cut(tag, ","); len(n) > 0 {
+					name = n
+				}
+			}
+			if field.Tag.Get("secret") == "true" && !val.Field(i).IsZero() {
+				fields = append(fields, redactedField{name, RedactedValue})
+				continue
+			}
+			fields = append(fields, redactedField{name, redact(val.Field(i))})
+		}
+		return fields
+	case reflect.Slice, reflect.Array:
+		if val.Kind() == reflect.Slice && val.IsNil() {
+			return nil
+		}
+		elems := make([]any, 0, val.Len())
+		for i := range val.Len() {
+			elems = append(elems, redact(val.Index(i)))
+		}
+		return elems
+	case reflect.Map:
+		if val.IsNil() {
+			return nil
+		}
+		m := make(map[string]any, val.Len())
+		for _, key := range val.MapKeys() {
+			m[fmt.Sprintf("%v", key.Interface())] = redact(val.MapIndex(key))
+		}
+		return m
+	case reflect.Complex64, reflect.Complex128:
+		return fmt.Sprintf("%v", val.Complex())
+	case reflect.Func, reflect.Chan, reflect.UnsafePointer:
+		return nil
+	default:
+		if !val.CanInterface() {
+			return nil
+		}
+		return val.Interface()
+	}
+}
@@ -0,0 +1,62 @@
+package encoding_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/sainnhe/go-common/pkg/constant"
+	"github.com/sainnhe/go-common/pkg/encoding"
+)
+
+func TestDumpConfig(t *testing.T) {
+	t.Parallel()
+
+	type Auth struct {
+		Username string `json:"username"`
+		Password string `json:"password" secret:"true"`
+	}
+
+	type Config struct {
+		Name     string            `json:"name,omitempty"`
+		Token    string            `json:"token" secret:"true"`
+		Empty    string            `json:"empty" secret:"true"`
+		Headers  map[string]string `json:"headers" secret:"true"`
+		Auth     *Auth             `json:"auth"`
+		Replicas []Auth            `json:"replicas"`
+		Timeout  time.Duration     `json:"timeout"`
+		Created  time.Time         `json:"created"`
+		Complex  complex128
+		Ignored  string `json:"-"`
+		internal string
+	}
+
+	cfg := &Config{
+		Name:     "app",
+		Token:    "token",
+		Headers:  map[string]string{"Authorization": "Bearer foo"},
+		Auth:     &Auth{"user", "pass"},
+		Replicas: []Auth{{"replica", "pass"}},
+		Timeout:  time.Second,
+		Created:  time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+		Complex:  complex(1, 1),
+		Ignored:  "ignored",
+		internal: "internal",
+	}
+
+	want := `{"name":"app","token":"******","empty":"","headers":"******",` +
+		`"auth":{"username":"user","password":"******"},"replicas":[{"username":"replica","password":"******"}],` +
+		`"timeout":1000000000,"created":"2025-01-01T00:00:00Z","Complex":"(1+1i)"}`
+
+	got, err := encoding.DumpConfig(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Fatalf("Want %s\nGot %s", want, got)
+	}
+
+	if _, err = encoding.DumpConfig(nil); !errors.Is(err, constant.ErrNilDeps) {
+		t.Fatalf("Want %+v, got %+v", constant.ErrNilDeps, err)
+	}
+}
@@ -19,4 +19,10 @@ const (
 
 	// TypeXML is the XML type.
 	TypeXML Type = 4
+
+	// TypeHCL is the HashiCorp Configuration Language (HCL) type.
+	TypeHCL Type = 5
+
+	// TypeDotenv is the dotenv type, i.e. the format of .env files.
+	TypeDotenv Type = 6
 )
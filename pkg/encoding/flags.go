@@ -0,0 +1,147 @@
+package encoding
+
+import (
+	"flag"
+	"fmt"
+	"reflect"
+)
+
+// flagValue is a [flag.Value] bound to a config field. The value is validated when the flag is parsed, but it's only
+// assigned to the field in [LoadConfig].
+type flagValue struct {
+	typ reflect.Type
+	raw string
+	set bool
+}
+
+func (v *flagValue) String() string {
+	if v == nil {
+		return ""
+	}
+	return v.raw
+}
+
+func (v *flagValue) Set(s string) error {
+	if _, err := parseTagValue(v.typ, s); err != nil {
+		return err
+	}
+	v.raw = s
+	v.set = true
+	return nil
+}
+
+// IsBoolFlag allows boolean flags to be passed without values, e.g. "-debug" instead of "-debug=true".
+func (v *flagValue) IsBoolFlag() bool {
+	return v.typ.Kind() == reflect.Bool
+}
+
+/*
+BindFlags registers command-line flags for the fields of the Config struct tagged with "flag", and returns a
+[LoadOption] that applies the flags set on the command line to the config. Flags have the highest priority, so they
+override default values, config content and environment variables.
+
+For example, the following field can be set via "-log-level=info" or "--log-level info":
+
+	Level string `json:"level" flag:"log-level" usage:"The log level." default:"debug"`
+
+The "usage" tag is used as the usage message, and the "default" tag is shown as the default value in the help message.
+Values are parsed in the same way as environment variables, and invalid values are reported when parsing flags.
+
+Fields of nested structs and pointers to structs are registered as well. Registering a flag name that already exists
+in fs panics, see [flag.FlagSet.Var].
+
+Params:
+  - fs [*flag.FlagSet]: The flag set to register flags to. If nil, [flag.CommandLine] is used. The returned option
+    should be passed to [LoadConfig] after fs is parsed.
+
+Returns:
+  - [LoadOption]: The option that applies the flags.
+*/
+func BindFlags[Config any](fs *flag.FlagSet) LoadOption {
+	if fs == nil {
+		fs = flag.CommandLine
+	}
+	typ := reflect.TypeFor[Config]()
+	if typ.Kind() == reflect.Struct {
+		registerFlags(fs, typ)
+	}
+	return func(opts *loadOptions) {
+		opts.flagSet = fs
+	}
+}
+
+// registerFlags registers the fields of the struct type typ tagged with "flag" to fs.
+func registerFlags(fs *flag.FlagSet, typ reflect.Type) {
+	for i := range typ.NumField() {
+		field := typ.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		ft := field.Type
+		if ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+		if name := field.Tag.Get("flag"); len(name) > 0 {
+			fs.Var(&flagValue{typ: ft, raw: field.Tag.Get("default")}, name, field.Tag.Get("usage"))
+			continue
+		}
+		if ft.Kind() == reflect.Struct {
+			registerFlags(fs, ft)
+		}
+	}
+}
+
+// overrideWithFlags assigns the flags set on the command line to the fields of cfg.
+func overrideWithFlags(cfg any, opts *loadOptions) error {
+	if opts.flagSet == nil {
+		return nil
+	}
+	cfgVal := reflect.ValueOf(cfg).Elem()
+
+	// Iterate over each field
+	for i := range cfgVal.NumField() {
+		val := cfgVal.Field(i)
+		field := cfgVal.Type().Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		// Handle flag override if the flag is set
+		if name := field.Tag.Get("flag"); len(name) > 0 {
+			f := opts.flagSet.Lookup(name)
+			if f == nil {
+				continue
+			}
+			fv, ok := f.Value.(*flagValue)
+			if !ok || !fv.set {
+				continue
+			}
+			var err error
+			if val.Kind() == reflect.Pointer {
+				err = setVal(val.Elem(), fv.raw)
+			} else {
+				err = setVal(val, fv.raw)
+			}
+			if err != nil {
+				return fmt.Errorf("%w: flag %s: %w", ErrLoadConfigInvalidValue, name, err)
+			}
+			continue
+		}
+
+		// Now handle recursive structs or pointers
+		var err error
+		switch val.Kind() {
+		case reflect.Struct:
+			err = overrideWithFlags(val.Addr().Interface(), opts)
+		case reflect.Pointer:
+			if val.Elem().Kind() == reflect.Struct {
+				err = overrideWithFlags(val.Interface(), opts)
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,65 @@
+package encoding_test
+
+import (
+	"errors"
+	"flag"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sainnhe/go-common/pkg/encoding"
+)
+
+type flagsLog struct {
+	Level string `json:"level" flag:"log-level" usage:"The log level." default:"debug"`
+}
+
+type flagsConfig struct {
+	Name    string        `json:"name" env:"FLAGS_TEST_NAME" flag:"name" default:"app"`
+	Port    int           `json:"port" flag:"port" default:"80"`
+	Debug   bool          `json:"debug" flag:"debug"`
+	Timeout time.Duration `json:"timeout" flag:"timeout"`
+	Hosts   []string      `json:"hosts" flag:"hosts"`
+	Log     *flagsLog     `json:"log"`
+	NoFlag  string        `json:"no_flag" default:"x"`
+}
+
+func TestBindFlags(t *testing.T) { // nolint:paralleltest
+	t.Setenv("FLAGS_TEST_NAME", "env")
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	opt := encoding.BindFlags[flagsConfig](fs)
+	if f := fs.Lookup("log-level"); f == nil || f.Usage != "The log level." || f.DefValue != "debug" {
+		t.Fatalf("Unexpected flag: %+v", f)
+	}
+
+	err := fs.Parse([]string{"-name=flag", "-debug", "--log-level", "warn", "-hosts", `["a","b"]`, "-timeout=5"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := encoding.LoadConfig[flagsConfig]([]byte(`{"port": 8080, "name": "content"}`), encoding.TypeJSON, opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Name != "flag" || cfg.Port != 8080 || !cfg.Debug || cfg.Timeout != 5 || cfg.Log.Level != "warn" ||
+		strings.Join(cfg.Hosts, ",") != "a,b" || cfg.NoFlag != "x" {
+		t.Fatalf("Unexpected config: %+v, log = %+v", cfg, cfg.Log)
+	}
+
+	// Invalid values are reported when parsing flags.
+	fs = flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	encoding.BindFlags[flagsConfig](fs)
+	if err := fs.Parse([]string{"-port=abc"}); err == nil {
+		t.Fatal("Expect error for invalid flag value")
+	}
+
+	// Non-struct configs don't register any flags.
+	fs = flag.NewFlagSet("test", flag.ContinueOnError)
+	opt = encoding.BindFlags[int](fs)
+	if _, err := encoding.LoadConfig[int](nil, encoding.TypeNil, opt); !errors.Is(err, encoding.ErrLoadConfigNotStruct) {
+		t.Fatalf("Expect ErrLoadConfigNotStruct, got %v", err)
+	}
+}
@@ -0,0 +1,129 @@
+package encoding
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/hashicorp/hcl"
+	"github.com/hashicorp/hcl/hcl/ast"
+	"github.com/hashicorp/hcl/hcl/token"
+)
+
+// hclToJSON converts HCL content to JSON, so that it can be decoded via "json" tags with the same semantics as JSON
+// content.
+//
+// Blocks are converted to objects, and block labels are converted to nested keys, e.g. `service "web" { port = 80 }`
+// is converted to {"service": {"web": {"port": 80}}}. Blocks with different labels are merged into the same object, and
+// a key that is assigned multiple times at the same path, e.g. a repeated block, is converted to an array of all the
+// values.
+func hclToJSON(content []byte) ([]byte, error) {
+	file, err := hcl.ParseBytes(content)
+	if err != nil {
+		return nil, err
+	}
+	list, ok := file.Node.(*ast.ObjectList)
+	if !ok {
+		return nil, fmt.Errorf("unexpected HCL root node %T", file.Node)
+	}
+	obj, err := hclObject(list)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(obj)
+}
+
+func hclObject(list *ast.ObjectList) (map[string]any, error) {
+	obj := make(map[string]any, len(list.Items))
+	for _, item := range list.Items {
+		val, err := hclValue(item.Val)
+		if err != nil {
+			return nil, err
+		}
+
+		// Walk through the keys, the last one is the key of the value and the others are block labels
+		cur := obj
+		for i, key := range item.Keys {
+			name, ok := key.Token.Value().(string)
+			if !ok {
+				return nil, fmt.Errorf("%s: invalid key %s", key.Pos(), key.Token.Text)
+			}
+			if i == len(item.Keys)-1 {
+				hclSet(cur, name, val)
+				break
+			}
+			next, ok := cur[name].(map[string]any)
+			if !ok {
+				next = map[string]any{}
+				hclSet(cur, name, next)
+			}
+			cur = next
+		}
+	}
+	return obj, nil
+}
+
+// hclSet sets val to obj[key], or collects it into an array if the key already exists.
+func hclSet(obj map[string]any, key string, val any) {
+	old, ok := obj[key]
+	if !ok {
+		obj[key] = val
+		return
+	}
+	if arr, isArr := old.(hclArray); isArr {
+		obj[key] = append(arr, val)
+		return
+	}
+	obj[key] = hclArray{old, val}
+}
+
+// hclArray is an array collected from repeated keys, which is distinguished from list values so that a list assigned
+// multiple times is collected instead of flattened.
+type hclArray []any
+
+func hclValue(node ast.Node) (any, error) {
+	switch n := node.(type) {
+	case *ast.LiteralType:
+		return hclLiteral(n.Token)
+	case *ast.ListType:
+		arr := make([]any, 0, len(n.List))
+		for _, elem := range n.List {
+			val, err := hclValue(elem)
+			if err != nil {
+				return nil, err
+			}
+			arr = append(arr, val)
+		}
+		return arr, nil
+	case *ast.ObjectType:
+		return hclObject(n.List)
+	default:
+		return nil, fmt.Errorf("%s: unsupported HCL node %T", node.Pos(), node)
+	}
+}
+
+// hclLiteral returns the value of a literal token. Numbers are parsed here instead of via [token.Token.Value], which
+// panics on overflow.
+func hclLiteral(tok token.Token) (any, error) {
+	switch tok.Type {
+	case token.NUMBER:
+		if v, err := strconv.ParseInt(tok.Text, 0, 64); err == nil {
+			return v, nil
+		}
+		v, err := strconv.ParseUint(tok.Text, 0, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s: invalid number %s: %w", tok.Pos, tok.Text, err)
+		}
+		return v, nil
+	case token.FLOAT:
+		v, err := strconv.ParseFloat(tok.Text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s: invalid number %s: %w", tok.Pos, tok.Text, err)
+		}
+		return v, nil
+	case token.BOOL, token.STRING, token.HEREDOC:
+		return tok.Value(), nil
+	default:
+		return nil, fmt.Errorf("%s: unsupported HCL literal %s", tok.Pos, tok.Text)
+	}
+}
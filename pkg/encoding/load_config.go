@@ -1,13 +1,21 @@
 package encoding
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"encoding/xml"
 	"errors"
+	"flag"
+	"fmt"
 	"os"
+	"path/filepath"
 	"reflect"
 	"strconv"
+	"strings"
+	"unicode"
 
+	"github.com/joho/godotenv"
 	"github.com/pelletier/go-toml/v2"
 	"gopkg.in/yaml.v2"
 )
@@ -18,20 +26,80 @@ var (
 
 	// ErrLoadConfigUnsupportedType indicates an error that the type is unsupported.
 	ErrLoadConfigUnsupportedType = errors.New("unsupported type")
+
+	// ErrLoadConfigInvalidValue indicates an error that a "default" tag or an environment variable can't be parsed.
+	// It's only returned in strict mode, see [WithStrict].
+	ErrLoadConfigInvalidValue = errors.New("invalid value")
 )
 
+// LoadOption is the option used to customize the behavior of [LoadConfig].
+type LoadOption func(opts *loadOptions)
+
+type loadOptions struct {
+	envPrefix string
+	autoEnv   bool
+	strict    bool
+	flagSet   *flag.FlagSet
+
+	secretResolvers []SecretResolver
+}
+
+// WithStrict enables strict mode, which returns errors instead of silently ignoring them in the following cases:
+//
+//   - The JSON, YAML, TOML or HCL config content contains keys that don't exist in the Config struct. XML is not
+//     checked since [xml.Unmarshal] doesn't support it, and dotenv is not checked since unrelated variables are common.
+//   - A "default" tag can't be parsed into the field type.
+//   - An environment variable or a dotenv variable can't be parsed into the field type.
+//
+// The latter two cases return errors wrapping [ErrLoadConfigInvalidValue].
+func WithStrict() LoadOption {
+	return func(opts *loadOptions) {
+		opts.strict = true
+	}
+}
+
+// WithEnvPrefix prepends the given prefix to the names of all environment variables, including the ones specified via
+// "env" tags. It also enables automatic environment variable derivation, see [WithAutoEnv].
+//
+// For example, with prefix "MYAPP_", a field tagged with `env:"LOG_LEVEL"` will be read from "MYAPP_LOG_LEVEL".
+func WithEnvPrefix(prefix string) LoadOption {
+	return func(opts *loadOptions) {
+		opts.envPrefix = prefix
+		opts.autoEnv = true
+	}
+}
+
+// WithAutoEnv enables automatic environment variable derivation for fields that don't have an "env" tag.
+//
+// The name is derived from the path of field names converted to upper snake case, for example the Host field of a
+// nested Conn struct will be read from "CONN_HOST", and a field named MaxSizeMB will be read from "MAX_SIZE_MB".
+// Struct fields are not derived themselves, but their nested fields are.
+func WithAutoEnv() LoadOption {
+	return func(opts *loadOptions) {
+		opts.autoEnv = true
+	}
+}
+
 /*
 LoadConfig loads config by reading the config content and environment variables.
 
-The Config generic should be a struct and supports 6 struct tags:
+The Config generic should be a struct and supports 6 struct tags, plus the "flag" and "usage" tags used by
+[BindFlags]:
 
- 1. "json": Used to mark JSON fields.
+ 1. "json": Used to mark JSON and HCL fields.
  2. "yaml": Used to mark YAML fields.
  3. "toml": Used to mark TOML fields.
  4. "xml": Used to mark XML fields.
- 5. "env": Used to mark environment variable fields.
+ 5. "env": Used to mark environment variable and dotenv fields.
  6. "default": Used to mark the default value of a field.
 
+HCL content is converted to JSON before unmarshalling, where blocks become objects, block labels become nested keys and
+repeated blocks become arrays. For example, `server "web" { port = 80 }` is unmarshalled like
+{"server": {"web": {"port": 80}}}.
+
+Dotenv content is treated as a set of environment variables with lower priority than the real ones, so the variables
+are matched against the "env" tags, and the names derived by [WithAutoEnv] and [WithEnvPrefix] if enabled.
+
 The "env" and "default" tag is parsed using [strconv] for basic data types, and [json.Unmarshal] for arrays, slices,
 maps and structs.
 
@@ -42,19 +110,117 @@ The parsing process is as follows:
     step if such field exists in the config content.
  3. Read the environment variables and assign it to corresponding fields. This will override the values assigned in the
     previous step if such environment variable exists.
+ 4. Assign the command-line flags set via [BindFlags] to corresponding fields. This will override the values
+    assigned in the previous steps.
+ 5. Resolve secret references in string fields, for example "${file:/run/secrets/db_password}", if enabled via
+    [WithSecretResolvers] or [WithFileSecrets].
 
 Params:
   - content []byte: The config content. For example, you can use [os.ReadFile] to read the content from a local file.
     If this argument is nil or an empty slice, only default values and environment variables will be used.
   - typ [Type]: The config type. If [TypeNil] is passed, only default values and environment variables will be used.
+  - opts ...[LoadOption]: The options, for example [WithEnvPrefix].
+
+Returns:
+  - *Config: The config struct.
+  - error: The error occurred during the execution, which may be [ErrLoadConfigNotStruct],
+    [ErrLoadConfigUnsupportedType] or other runtime errors.
+*/
+func LoadConfig[Config any](content []byte, typ Type, opts ...LoadOption) (*Config, error) {
+	return loadConfig[Config]([]configLayer{{content, typ}}, opts)
+}
+
+/*
+LoadConfigLayered is like [LoadConfig], but loads multiple config documents of the same type in order. Later documents
+override the fields set by earlier ones before environment variables apply, which is useful for a base config with
+per-environment overlays.
+
+Fields that don't exist in a later document keep their values, and maps are merged, but slices and arrays are replaced
+as a whole. The only exception is XML, where [xml.Unmarshal] appends to slices instead of replacing them.
+
+Params:
+  - contents [][]byte: The config documents, from the lowest priority to the highest priority. Empty documents are
+    skipped.
+  - typ [Type]: The config type.
+  - opts ...[LoadOption]: The options, for example [WithEnvPrefix].
 
 Returns:
   - *Config: The config struct.
   - error: The error occurred during the execution, which may be [ErrLoadConfigNotStruct],
     [ErrLoadConfigUnsupportedType] or other runtime errors.
 */
-func LoadConfig[Config any](content []byte, typ Type) (*Config, error) {
+func LoadConfigLayered[Config any](contents [][]byte, typ Type, opts ...LoadOption) (*Config, error) {
+	layers := make([]configLayer, 0, len(contents))
+	for _, content := range contents {
+		layers = append(layers, configLayer{content, typ})
+	}
+	return loadConfig[Config](layers, opts)
+}
+
+/*
+LoadConfigFiles is like [LoadConfigLayered], but reads config documents from files. The type of each file is detected
+via [TypeFromPath], so different formats can be mixed.
+
+Params:
+  - paths []string: The paths of config files, from the lowest priority to the highest priority.
+  - opts ...[LoadOption]: The options, for example [WithEnvPrefix].
+
+Returns:
+  - *Config: The config struct.
+  - error: The error occurred during the execution, which may be [ErrLoadConfigNotStruct],
+    [ErrLoadConfigUnsupportedType], file system errors or other runtime errors.
+*/
+func LoadConfigFiles[Config any](paths []string, opts ...LoadOption) (*Config, error) {
+	layers := make([]configLayer, 0, len(paths))
+	for _, path := range paths {
+		typ := TypeFromPath(path)
+		if typ == TypeNil {
+			return nil, fmt.Errorf("%w: %s", ErrLoadConfigUnsupportedType, path)
+		}
+		content, err := os.ReadFile(path) // nolint:gosec
+		if err != nil {
+			return nil, err
+		}
+		layers = append(layers, configLayer{content, typ})
+	}
+	return loadConfig[Config](layers, opts)
+}
+
+// TypeFromPath detects the config type from the file extension of the given path. Dotenv files with a suffix like
+// ".env.local" are also detected. [TypeNil] will be returned if the extension is unknown.
+func TypeFromPath(path string) Type {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return TypeJSON
+	case ".yaml", ".yml":
+		return TypeYAML
+	case ".toml":
+		return TypeTOML
+	case ".xml":
+		return TypeXML
+	case ".hcl":
+		return TypeHCL
+	case ".env":
+		return TypeDotenv
+	}
+	if strings.HasPrefix(filepath.Base(path), ".env.") {
+		return TypeDotenv
+	}
+	return TypeNil
+}
+
+// configLayer is a config document to be loaded.
+type configLayer struct {
+	content []byte
+	typ     Type
+}
+
+func loadConfig[Config any](layers []configLayer, opts []LoadOption) (*Config, error) {
 	var cfg Config
+	o := &loadOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
 
 	// Config must be a struct
 	if reflect.ValueOf(cfg).Kind() != reflect.Struct {
@@ -65,44 +231,80 @@ func LoadConfig[Config any](content []byte, typ Type) (*Config, error) {
 	initNilPointers(reflect.ValueOf(&cfg).Elem())
 
 	// Override default values
-	overrideWithDefaultValues(&cfg)
+	if err := overrideWithDefaultValues(&cfg, o); err != nil {
+		return nil, err
+	}
 
 	// Load config content
-	if len(content) > 0 {
-		switch typ {
-		case TypeNil:
-			break
-		case TypeJSON:
-			err := json.Unmarshal(content, &cfg)
-			if err != nil {
-				return nil, err
-			}
-		case TypeYAML:
-			err := yaml.Unmarshal(content, &cfg)
-			if err != nil {
-				return nil, err
-			}
-		case TypeTOML:
-			err := toml.Unmarshal(content, &cfg)
-			if err != nil {
-				return nil, err
-			}
-		case TypeXML:
-			err := xml.Unmarshal(content, &cfg)
-			if err != nil {
-				return nil, err
-			}
-		default:
-			return nil, ErrLoadConfigUnsupportedType
+	for _, layer := range layers {
+		if err := unmarshal(layer.content, layer.typ, &cfg, o); err != nil {
+			return nil, err
 		}
 	}
 
 	// Override with environment variables.
-	overrideWithEnvVars(&cfg)
+	if err := overrideWithEnvVars(&cfg, o, os.Getenv, ""); err != nil {
+		return nil, err
+	}
+
+	// Override with command-line flags.
+	if err := overrideWithFlags(&cfg, o); err != nil {
+		return nil, err
+	}
+
+	// Resolve secret references.
+	if err := resolveSecrets(context.Background(), reflect.ValueOf(&cfg).Elem(), o.secretResolvers); err != nil {
+		return nil, err
+	}
 
 	return &cfg, nil
 }
 
+// unmarshal unmarshals the content of the given type into cfg. Unknown keys are rejected in strict mode.
+func unmarshal(content []byte, typ Type, cfg any, opts *loadOptions) error {
+	if len(content) == 0 {
+		return nil
+	}
+	strict := opts.strict
+	switch typ {
+	case TypeNil:
+		return nil
+	case TypeJSON:
+		if strict {
+			decoder := json.NewDecoder(bytes.NewReader(content))
+			decoder.DisallowUnknownFields()
+			return decoder.Decode(cfg)
+		}
+		return json.Unmarshal(content, cfg)
+	case TypeYAML:
+		if strict {
+			return yaml.UnmarshalStrict(content, cfg)
+		}
+		return yaml.Unmarshal(content, cfg)
+	case TypeTOML:
+		if strict {
+			return toml.NewDecoder(bytes.NewReader(content)).DisallowUnknownFields().Decode(cfg)
+		}
+		return toml.Unmarshal(content, cfg)
+	case TypeXML:
+		return xml.Unmarshal(content, cfg)
+	case TypeHCL:
+		jsonContent, err := hclToJSON(content)
+		if err != nil {
+			return err
+		}
+		return unmarshal(jsonContent, TypeJSON, cfg, opts)
+	case TypeDotenv:
+		vars, err := godotenv.UnmarshalBytes(content)
+		if err != nil {
+			return err
+		}
+		return overrideWithEnvVars(cfg, opts, func(key string) string { return vars[key] }, "")
+	default:
+		return ErrLoadConfigUnsupportedType
+	}
+}
+
 // initNilPointers initializes nil pointers recursively.
 func initNilPointers(val reflect.Value) {
 
@@ -134,103 +336,165 @@ func initNilPointers(val reflect.Value) {
 	}
 }
 
-func overrideWithDefaultValues(cfg any) {
+func overrideWithDefaultValues(cfg any, opts *loadOptions) error {
 	cfgVal := reflect.ValueOf(cfg).Elem()
 
 	// Iterate over each field
 	for i := range cfgVal.NumField() {
 		val := cfgVal.Field(i)
-		defaultTag := cfgVal.Type().Field(i).Tag.Get("default")
+		field := cfgVal.Type().Field(i)
+		defaultTag := field.Tag.Get("default")
 
 		// Handle default value override if defaultVal is set
 		if len(defaultTag) > 0 {
+			var err error
 			if val.Kind() == reflect.Pointer {
-				setVal(val.Elem(), defaultTag)
+				err = setVal(val.Elem(), defaultTag)
 			} else {
-				setVal(val, defaultTag)
+				err = setVal(val, defaultTag)
+			}
+			if err != nil && opts.strict {
+				return fmt.Errorf("%w: default value of field %s: %w", ErrLoadConfigInvalidValue, field.Name, err)
 			}
 		}
 
 		// Now handle recursive structs or pointers
+		var err error
 		switch val.Kind() {
 		case reflect.Struct:
-			overrideWithDefaultValues(val.Addr().Interface())
+			err = overrideWithDefaultValues(val.Addr().Interface(), opts)
 		case reflect.Pointer:
 			if val.Elem().Kind() == reflect.Struct {
-				overrideWithDefaultValues(val.Interface())
+				err = overrideWithDefaultValues(val.Interface(), opts)
 			}
 		}
+		if err != nil {
+			return err
+		}
 	}
+
+	return nil
 }
 
-func overrideWithEnvVars(cfg any) {
+// overrideWithEnvVars assigns the environment variables returned by getenv to the fields of cfg.
+func overrideWithEnvVars(cfg any, opts *loadOptions, getenv func(key string) string, path string) error {
 	cfgVal := reflect.ValueOf(cfg).Elem()
 
 	// Iterate over each field
 	for i := range cfgVal.NumField() {
 		val := cfgVal.Field(i)
-		envTag := cfgVal.Type().Field(i).Tag.Get("env")
+		field := cfgVal.Type().Field(i)
+		envTag := field.Tag.Get("env")
+		fieldPath := field.Name
+		if opts.autoEnv {
+			fieldPath = toUpperSnake(field.Name)
+			if len(path) > 0 {
+				fieldPath = path + "_" + fieldPath
+			}
+		}
+
+		// Derive environment variable name if envTag is not set
+		isStruct := val.Kind() == reflect.Struct ||
+			(val.Kind() == reflect.Pointer && val.Elem().Kind() == reflect.Struct)
+		if len(envTag) == 0 && opts.autoEnv && !isStruct && field.IsExported() {
+			envTag = fieldPath
+		}
 
 		// Handle environment variable override if envTag is set
 		if len(envTag) != 0 {
-			envVal := os.Getenv(envTag)
+			envVal := getenv(opts.envPrefix + envTag)
 			if len(envVal) != 0 {
+				var err error
 				if val.Kind() == reflect.Pointer {
-					setVal(val.Elem(), envVal)
+					err = setVal(val.Elem(), envVal)
 				} else {
-					setVal(val, envVal)
+					err = setVal(val, envVal)
+				}
+				if err != nil && opts.strict {
+					return fmt.Errorf("%w: environment variable %s: %w", ErrLoadConfigInvalidValue,
+						opts.envPrefix+envTag, err)
 				}
 			}
 		}
 
 		// Now handle recursive structs or pointers
+		var err error
 		switch val.Kind() {
 		case reflect.Struct:
-			overrideWithEnvVars(val.Addr().Interface())
+			err = overrideWithEnvVars(val.Addr().Interface(), opts, getenv, fieldPath)
 		case reflect.Pointer:
 			if val.Elem().Kind() == reflect.Struct {
-				overrideWithEnvVars(val.Interface())
+				err = overrideWithEnvVars(val.Interface(), opts, getenv, fieldPath)
 			}
 		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// toUpperSnake converts a Go identifier to upper snake case, e.g. "MaxSizeMB" to "MAX_SIZE_MB".
+func toUpperSnake(name string) string {
+	runes := []rune(name)
+	b := strings.Builder{}
+	for i, r := range runes {
+		if i > 0 && unicode.IsUpper(r) &&
+			(unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1]) ||
+				(i+1 < len(runes) && unicode.IsLower(runes[i+1]))) {
+			b.WriteByte('_')
+		}
+		b.WriteRune(unicode.ToUpper(r))
 	}
+	return b.String()
 }
 
-func setVal(field reflect.Value, val string) {
+// setVal parses val and sets it to field. An error will be returned if val can't be parsed, in which case field won't
+// be modified.
+func setVal(field reflect.Value, val string) error {
 	if !field.CanSet() {
-		return
+		return nil
 	}
 	switch field.Kind() {
 	case reflect.String:
 		field.SetString(val)
 	case reflect.Bool:
 		boolVal, err := strconv.ParseBool(val)
-		if err == nil {
-			field.SetBool(boolVal)
+		if err != nil {
+			return err
 		}
+		field.SetBool(boolVal)
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
 		intVal, err := strconv.ParseInt(val, 10, field.Type().Bits())
-		if err == nil {
-			field.SetInt(intVal)
+		if err != nil {
+			return err
 		}
+		field.SetInt(intVal)
 	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
 		uintVal, err := strconv.ParseUint(val, 10, field.Type().Bits())
-		if err == nil {
-			field.SetUint(uintVal)
+		if err != nil {
+			return err
 		}
+		field.SetUint(uintVal)
 	case reflect.Float32, reflect.Float64:
 		floatVal, err := strconv.ParseFloat(val, field.Type().Bits())
-		if err == nil {
-			field.SetFloat(floatVal)
+		if err != nil {
+			return err
 		}
+		field.SetFloat(floatVal)
 	case reflect.Complex64, reflect.Complex128:
 		complexVal, err := strconv.ParseComplex(val, field.Type().Bits())
-		if err == nil {
-			field.SetComplex(complexVal)
+		if err != nil {
+			return err
 		}
+		field.SetComplex(complexVal)
 	case reflect.Slice, reflect.Array, reflect.Map, reflect.Struct:
 		target := reflect.New(field.Type()).Interface()
-		if json.Unmarshal([]byte(val), target) == nil {
-			field.Set(reflect.ValueOf(target).Elem())
+		if err := json.Unmarshal([]byte(val), target); err != nil {
+			return err
 		}
+		field.Set(reflect.ValueOf(target).Elem())
 	}
+	return nil
 }
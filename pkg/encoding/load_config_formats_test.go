@@ -0,0 +1,149 @@
+package encoding_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/sainnhe/go-common/pkg/encoding"
+)
+
+type hclServer struct {
+	Port int `json:"port"`
+}
+
+type hclRule struct {
+	Allow string `json:"allow"`
+}
+
+type hclLog struct {
+	Level string `json:"level" default:"debug"`
+	Path  string `json:"path"`
+}
+
+type hclConfig struct {
+	Name    string               `json:"name" default:"app"`
+	Port    int                  `json:"port"`
+	Ratio   float64              `json:"ratio"`
+	Debug   bool                 `json:"debug"`
+	Hosts   []string             `json:"hosts"`
+	Script  string               `json:"script"`
+	Log     *hclLog              `json:"log"`
+	Servers map[string]hclServer `json:"server"`
+	Rules   []hclRule            `json:"rule"`
+}
+
+func TestLoadConfig_hcl(t *testing.T) {
+	t.Parallel()
+
+	content := []byte(`
+# comment
+name  = "svc"
+port  = 0x1F90
+ratio = 0.5
+debug = true
+hosts = ["a", "b"]
+
+script = <<EOT
+echo hi
+EOT
+
+// Defaults of fields not in this block are kept
+log {
+  path = "/tmp"
+}
+
+server "web" {
+  port = 80
+}
+
+server "api" {
+  port = 8080
+}
+
+/* Repeated blocks are collected */
+rule { allow = "x" }
+rule { allow = "y" }
+`)
+
+	got, err := encoding.LoadConfig[hclConfig](content, encoding.TypeHCL, encoding.WithStrict())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := hclConfig{
+		Name:    "svc",
+		Port:    8080,
+		Ratio:   0.5,
+		Debug:   true,
+		Hosts:   []string{"a", "b"},
+		Script:  "echo hi\n",
+		Log:     &hclLog{Level: "debug", Path: "/tmp"},
+		Servers: map[string]hclServer{"web": {80}, "api": {8080}},
+		Rules:   []hclRule{{"x"}, {"y"}},
+	}
+	if !reflect.DeepEqual(want, *got) {
+		t.Fatalf("Want %+v\nGot %+v", want, *got)
+	}
+
+	tests := []struct {
+		name    string
+		content string
+		opts    []encoding.LoadOption
+	}{
+		{"Syntax error", `name = "svc`, nil},
+		{"Number overflow", `port = 99999999999999999999`, nil},
+		{"Unknown key in strict mode", `unknown = 1`, []encoding.LoadOption{encoding.WithStrict()}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if _, err := encoding.LoadConfig[hclConfig]([]byte(tt.content), encoding.TypeHCL, tt.opts...); err == nil {
+				t.Fatal("Expect error, got nil")
+			}
+		})
+	}
+}
+
+type dotenvConfig struct {
+	Name  string `env:"NAME" default:"app"`
+	Port  int    `env:"PORT"`
+	Debug bool
+	Log   struct {
+		Level string `default:"debug"`
+	}
+}
+
+func TestLoadConfig_dotenv(t *testing.T) {
+	content := []byte(`
+# comment
+NAME=svc
+export PORT=8080
+DEBUG=true
+LOG_LEVEL='info'
+`)
+
+	got, err := encoding.LoadConfig[dotenvConfig](content, encoding.TypeDotenv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Name != "svc" || got.Port != 8080 || got.Debug || got.Log.Level != "debug" {
+		t.Fatalf("Got %+v", *got)
+	}
+
+	// Auto derived names, where real environment variables take precedence
+	t.Setenv("PORT", "9090")
+	got, err = encoding.LoadConfig[dotenvConfig](content, encoding.TypeDotenv, encoding.WithAutoEnv())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Name != "svc" || got.Port != 9090 || !got.Debug || got.Log.Level != "info" {
+		t.Fatalf("Got %+v", *got)
+	}
+
+	// Invalid values
+	_, err = encoding.LoadConfig[dotenvConfig]([]byte("PORT=abc"), encoding.TypeDotenv, encoding.WithStrict())
+	if !errors.Is(err, encoding.ErrLoadConfigInvalidValue) {
+		t.Fatalf("Want %+v, got %+v", encoding.ErrLoadConfigInvalidValue, err)
+	}
+}
@@ -0,0 +1,125 @@
+package encoding_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/sainnhe/go-common/pkg/encoding"
+)
+
+type layeredConfig struct {
+	Name  string            `json:"name" yaml:"name" toml:"name" default:"app"`
+	Port  int               `json:"port" yaml:"port" toml:"port" default:"80"`
+	Hosts []string          `json:"hosts" yaml:"hosts" toml:"hosts"`
+	Tags  map[string]string `json:"tags" yaml:"tags" toml:"tags"`
+	Log   struct {
+		Level string `json:"level" yaml:"level" toml:"level" default:"debug"`
+		Path  string `json:"path" yaml:"path" toml:"path"`
+	} `json:"log" yaml:"log" toml:"log"`
+}
+
+func TestLoadConfigLayered(t *testing.T) {
+	t.Parallel()
+
+	base := []byte(`{
+		"port": 8080,
+		"hosts": ["a", "b"],
+		"tags": {"env": "base", "team": "infra"},
+		"log": {"path": "/tmp"}
+	}`)
+	overlay := []byte(`{"hosts": ["c"], "tags": {"env": "prod"}, "log": {"level": "info"}}`)
+
+	got, err := encoding.LoadConfigLayered[layeredConfig]([][]byte{base, nil, overlay}, encoding.TypeJSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := layeredConfig{
+		Name:  "app",
+		Port:  8080,
+		Hosts: []string{"c"},
+		Tags:  map[string]string{"env": "prod", "team": "infra"},
+	}
+	want.Log.Level = "info"
+	want.Log.Path = "/tmp"
+	if !reflect.DeepEqual(want, *got) {
+		t.Fatalf("Want %+v\nGot %+v", want, *got)
+	}
+
+	if _, err = encoding.LoadConfigLayered[layeredConfig]([][]byte{base, []byte("{")}, encoding.TypeJSON); err == nil {
+		t.Fatal("Expect error, got nil")
+	}
+}
+
+func TestLoadConfigFiles(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	files := map[string]string{
+		"base.yaml":  "name: base\nport: 8080\nlog:\n  path: /tmp\n",
+		"prod.toml":  "port = 443\n[log]\nlevel = \"warn\"\n",
+		"local.json": `{"name": "local"}`,
+		"bad.ini":    "",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := encoding.LoadConfigFiles[layeredConfig]([]string{
+		filepath.Join(dir, "base.yaml"),
+		filepath.Join(dir, "prod.toml"),
+		filepath.Join(dir, "local.json"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Name != "local" || got.Port != 443 || got.Log.Level != "warn" || got.Log.Path != "/tmp" {
+		t.Fatalf("Got %+v", *got)
+	}
+
+	_, err = encoding.LoadConfigFiles[layeredConfig]([]string{filepath.Join(dir, "bad.ini")})
+	if !errors.Is(err, encoding.ErrLoadConfigUnsupportedType) {
+		t.Fatalf("Want %+v, got %+v", encoding.ErrLoadConfigUnsupportedType, err)
+	}
+
+	_, err = encoding.LoadConfigFiles[layeredConfig]([]string{filepath.Join(dir, "none.json")})
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("Want %+v, got %+v", os.ErrNotExist, err)
+	}
+}
+
+func TestTypeFromPath(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		path string
+		want encoding.Type
+	}{
+		{"config.json", encoding.TypeJSON},
+		{"config.YAML", encoding.TypeYAML},
+		{"config.yml", encoding.TypeYAML},
+		{"config.toml", encoding.TypeTOML},
+		{"config.xml", encoding.TypeXML},
+		{"main.hcl", encoding.TypeHCL},
+		{".env", encoding.TypeDotenv},
+		{"dir/.env.local", encoding.TypeDotenv},
+		{"app.env", encoding.TypeDotenv},
+		{".envrc", encoding.TypeNil},
+		{"config", encoding.TypeNil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			t.Parallel()
+
+			if got := encoding.TypeFromPath(tt.path); got != tt.want {
+				t.Fatalf("Want %d, got %d", tt.want, got)
+			}
+		})
+	}
+}
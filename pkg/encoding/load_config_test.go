@@ -270,3 +270,156 @@ num = 2
 		t.Fatalf("Want %+v, got %+v", wantDefaultConfig, defaultConfig)
 	}
 }
+
+func TestLoadConfig_envPrefix(t *testing.T) {
+	t.Parallel()
+
+	type Conn struct {
+		Host      string `json:"host" default:"localhost"`
+		EnableTLS bool   `json:"enable_tls"`
+	}
+
+	type Config struct {
+		Level     string `json:"level" env:"LEVEL" default:"debug"`
+		MaxSizeMB int    `json:"max_size_mb"`
+		Conn      Conn   `json:"conn"`
+		ConnPtr   *Conn  `json:"conn_ptr"`
+	}
+
+	if err := errors.Join(
+		os.Setenv("TEST_PREFIX_LEVEL", "info"),
+		os.Setenv("TEST_PREFIX_MAX_SIZE_MB", "10"),
+		os.Setenv("TEST_PREFIX_CONN_HOST", "example.com"),
+		os.Setenv("TEST_PREFIX_CONN_ENABLE_TLS", "true"),
+		os.Setenv("TEST_PREFIX_CONN_PTR_HOST", "ptr.example.com"),
+	); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := encoding.LoadConfig[Config](nil, encoding.TypeNil, encoding.WithEnvPrefix("TEST_PREFIX_"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Config{
+		Level:     "info",
+		MaxSizeMB: 10,
+		Conn:      Conn{"example.com", true},
+		ConnPtr:   &Conn{"ptr.example.com", false},
+	}
+	if !reflect.DeepEqual(want, *got) {
+		t.Fatalf("Want %+v\nGot %+v", want, *got)
+	}
+
+	// Without options, only env tags are respected
+	got, err = encoding.LoadConfig[Config](nil, encoding.TypeNil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.MaxSizeMB != 0 || got.Conn.Host != "localhost" {
+		t.Fatalf("Got %+v", *got)
+	}
+}
+
+func TestLoadConfig_autoEnv(t *testing.T) {
+	t.Parallel()
+
+	type Config struct {
+		TestAutoEnvHTTPPort int `json:"port"`
+	}
+
+	if err := os.Setenv("TEST_AUTO_ENV_HTTP_PORT", "8080"); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := encoding.LoadConfig[Config](nil, encoding.TypeNil, encoding.WithAutoEnv())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.TestAutoEnvHTTPPort != 8080 {
+		t.Fatalf("Want 8080, got %d", got.TestAutoEnvHTTPPort)
+	}
+}
+
+func TestLoadConfig_strict(t *testing.T) {
+	t.Parallel()
+
+	type Config struct {
+		Num int `json:"num" yaml:"num" toml:"num" xml:"num" default:"1"`
+	}
+
+	tests := []struct {
+		name    string
+		content string
+		typ     encoding.Type
+	}{
+		{"JSON", `{"num": 2, "unknown": 1}`, encoding.TypeJSON},
+		{"YAML", "num: 2\nunknown: 1\n", encoding.TypeYAML},
+		{"TOML", "num = 2\nunknown = 1\n", encoding.TypeTOML},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			cfg, err := encoding.LoadConfig[Config]([]byte(tt.content), tt.typ)
+			if err != nil || cfg.Num != 2 {
+				t.Fatalf("Expect unknown keys to be ignored, got cfg = %+v, err = %+v", cfg, err)
+			}
+			if _, err = encoding.LoadConfig[Config]([]byte(tt.content), tt.typ, encoding.WithStrict()); err == nil {
+				t.Fatal("Expect error, got nil")
+			}
+		})
+	}
+
+	t.Run("XML", func(t *testing.T) {
+		t.Parallel()
+
+		cfg, err := encoding.LoadConfig[Config]([]byte("<config><num>2</num></config>"), encoding.TypeXML,
+			encoding.WithStrict())
+		if err != nil || cfg.Num != 2 {
+			t.Fatalf("Got cfg = %+v, err = %+v", cfg, err)
+		}
+	})
+
+	t.Run("Invalid default", func(t *testing.T) {
+		t.Parallel()
+
+		type InvalidDefault struct {
+			Inner struct {
+				Num int `default:"abc"`
+			}
+		}
+
+		cfg, err := encoding.LoadConfig[InvalidDefault](nil, encoding.TypeNil)
+		if err != nil || cfg.Inner.Num != 0 {
+			t.Fatalf("Got cfg = %+v, err = %+v", cfg, err)
+		}
+		_, err = encoding.LoadConfig[InvalidDefault](nil, encoding.TypeNil, encoding.WithStrict())
+		if !errors.Is(err, encoding.ErrLoadConfigInvalidValue) {
+			t.Fatalf("Want %+v, got %+v", encoding.ErrLoadConfigInvalidValue, err)
+		}
+	})
+
+	t.Run("Invalid env", func(t *testing.T) {
+		t.Parallel()
+
+		type InvalidEnv struct {
+			Inner *struct {
+				Enable bool `env:"TEST_STRICT_ENABLE" default:"true"`
+			}
+		}
+
+		if err := os.Setenv("TEST_STRICT_ENABLE", "maybe"); err != nil {
+			t.Fatal(err)
+		}
+
+		cfg, err := encoding.LoadConfig[InvalidEnv](nil, encoding.TypeNil)
+		if err != nil || !cfg.Inner.Enable {
+			t.Fatalf("Got cfg = %+v, err = %+v", cfg, err)
+		}
+		_, err = encoding.LoadConfig[InvalidEnv](nil, encoding.TypeNil, encoding.WithStrict())
+		if !errors.Is(err, encoding.ErrLoadConfigInvalidValue) {
+			t.Fatalf("Want %+v, got %+v", encoding.ErrLoadConfigInvalidValue, err)
+		}
+	})
+}
@@ -0,0 +1,286 @@
+package encoding
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SchemaDialect is the JSON Schema dialect of the documents generated by [GenerateSchema].
+const SchemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+// jsonSchema is a JSON Schema document.
+type jsonSchema struct {
+	Schema               string                 `json:"$schema,omitempty"`
+	Type                 string                 `json:"type,omitempty"`
+	Format               string                 `json:"format,omitempty"`
+	Properties           map[string]*jsonSchema `json:"properties,omitempty"`
+	Required             []string               `json:"required,omitempty"`
+	AdditionalProperties any                    `json:"additionalProperties,omitempty"`
+	Items                *jsonSchema            `json:"items,omitempty"`
+	Enum                 []any                  `json:"enum,omitempty"`
+	Default              json.RawMessage        `json:"default,omitempty"`
+	Minimum              json.Number            `json:"minimum,omitempty"`
+	Maximum              json.Number            `json:"maximum,omitempty"`
+	ExclusiveMinimum     json.Number            `json:"exclusiveMinimum,omitempty"`
+	ExclusiveMaximum     json.Number            `json:"exclusiveMaximum,omitempty"`
+	MinLength            *uint64                `json:"minLength,omitempty"`
+	MaxLength            *uint64                `json:"maxLength,omitempty"`
+	MinItems             *uint64                `json:"minItems,omitempty"`
+	MaxItems             *uint64                `json:"maxItems,omitempty"`
+	Env                  string                 `json:"x-env,omitempty"`
+}
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	durationType = reflect.TypeOf(time.Duration(0))
+)
+
+/*
+GenerateSchema generates a JSON Schema document describing the JSON representation of the Config struct, which can be
+published for editor autocompletion and used to validate config files in CI.
+
+The schema is generated from the following struct tags:
+
+ 1. "json": The property names. Fields tagged with `json:"-"` and unexported fields are omitted, and embedded structs
+    without a name are flattened like [json.Marshal] does.
+ 2. "default": The "default" keyword, parsed the same way as [LoadConfig] does.
+ 3. "env": The "x-env" extension keyword, which is the name of the environment variable that overrides this field.
+ 4. "validate": Validation rules in the syntax of github.com/go-playground/validator. The rules "required", "min",
+    "max", "len", "gt", "gte", "lt", "lte", "oneof", "email", "url", "uri", "hostname", "ipv4", "ipv6" and "uuid" are
+    converted to the corresponding keywords, and other rules are ignored. Rules after "dive" are ignored as well.
+
+Since unknown keys are rejected by [LoadConfig] in strict mode, objects generated from structs don't allow additional
+properties.
+
+Returns:
+  - []byte: The JSON Schema document.
+  - error: The error occurred during the execution, which may be [ErrLoadConfigNotStruct], or errors wrapping
+    [ErrLoadConfigInvalidValue] if a "default" tag or a "validate" rule can't be parsed.
+*/
+func GenerateSchema[Config any]() ([]byte, error) {
+	typ := reflect.TypeFor[Config]()
+	if typ.Kind() != reflect.Struct {
+		return nil, ErrLoadConfigNotStruct
+	}
+	s, err := typeSchema(typ, map[reflect.Type]bool{})
+	if err != nil {
+		return nil, err
+	}
+	s.Schema = SchemaDialect
+	return json.MarshalIndent(s, "", "  ")
+}
+
+// typeSchema generates the schema of typ. The visiting map records the struct types on the current path, which is used
+// to stop at recursive types.
+func typeSchema(typ reflect.Type, visiting map[reflect.Type]bool) (*jsonSchema, error) {
+	if typ.Implements(jsonMarshalerType) || reflect.PointerTo(typ).Implements(jsonMarshalerType) {
+		if typ == timeType {
+			return &jsonSchema{Type: "string", Format: "date-time"}, nil
+		}
+		// The representation is unknown, so accept anything.
+		return &jsonSchema{}, nil
+	}
+	switch typ.Kind() {
+	case reflect.Pointer:
+		return typeSchema(typ.Elem(), visiting)
+	case reflect.String:
+		return &jsonSchema{Type: "string"}, nil
+	case reflect.Bool:
+		return &jsonSchema{Type: "boolean"}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return &jsonSchema{Type: "integer"}, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return &jsonSchema{Type: "integer", Minimum: "0"}, nil
+	case reflect.Float32, reflect.Float64:
+		return &jsonSchema{Type: "number"}, nil
+	case reflect.Slice, reflect.Array:
+		if typ.Kind() == reflect.Slice && typ.Elem().Kind() == reflect.Uint8 {
+			// Byte slices are encoded as base64 strings.
+			return &jsonSchema{Type: "string"}, nil
+		}
+		items, err := typeSchema(typ.Elem(), visiting)
+		if err != nil {
+			return nil, err
+		}
+		s := &jsonSchema{Type: "array", Items: items}
+		if typ.Kind() == reflect.Array {
+			n := uint64(typ.Len())
+			s.MinItems, s.MaxItems = &n, &n
+		}
+		return s, nil
+	case reflect.Map:
+		values, err := typeSchema(typ.Elem(), visiting)
+		if err != nil {
+			return nil, err
+		}
+		return &jsonSchema{Type: "object", AdditionalProperties: values}, nil
+	case reflect.Struct:
+		if visiting[typ] {
+			return &jsonSchema{Type: "object"}, nil
+		}
+		visiting[typ] = true
+		defer delete(visiting, typ)
+		s := &jsonSchema{Type: "object", Properties: map[string]*jsonSchema{}, AdditionalProperties: false}
+		if err := addProperties(s, typ, visiting); err != nil {
+			return nil, err
+		}
+		return s, nil
+	default:
+		// Interfaces accept anything. Complex numbers, functions and channels can't be represented in JSON, but they
+		// are unlikely to appear in config structs.
+		return &jsonSchema{}, nil
+	}
+}
+
+// addProperties adds the fields of the struct type typ to the properties of s.
+func addProperties(s *jsonSchema, typ reflect.Type, visiting map[reflect.Type]bool) error {
+	for i := range typ.NumField() {
+		field := typ.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+
+		// Flatten embedded structs without a name.
+		if field.Anonymous && len(name) == 0 {
+			ft := field.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				if err := addProperties(s, ft, visiting); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if len(name) == 0 {
+			name = field.Name
+		}
+		switch field.Type.Kind() {
+		case reflect.Complex64, reflect.Complex128, reflect.Func, reflect.Chan, reflect.UnsafePointer:
+			continue
+		}
+
+		prop, err := typeSchema(field.Type, visiting)
+		if err != nil {
+			return err
+		}
+		prop.Env = field.Tag.Get("env")
+		if defaultTag := field.Tag.Get("default"); len(defaultTag) > 0 {
+			val, err := parseTagValue(field.Type, defaultTag)
+			if err != nil {
+				return fmt.Errorf("%w: default value of field %s: %w", ErrLoadConfigInvalidValue, field.Name, err)
+			}
+			if prop.Default, err = json.Marshal(val); err != nil {
+				return err
+			}
+		}
+		required, err := applyValidateRules(prop, field.Type, field.Tag.Get("validate"))
+		if err != nil {
+			return fmt.Errorf("%w: validate rule of field %s: %w", ErrLoadConfigInvalidValue, field.Name, err)
+		}
+		if required {
+			s.Required = append(s.Required, name)
+		}
+		s.Properties[name] = prop
+	}
+	return nil
+}
+
+// applyValidateRules converts the validate tag into keywords of s, and reports whether the field is required.
+func applyValidateRules(s *jsonSchema, typ reflect.Type, tag string) (required bool, err error) {
+	if len(tag) == 0 {
+		return false, nil
+	}
+	if typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+	for rule := range strings.SplitSeq(tag, ",") {
+		key, param, _ := strings.Cut(strings.TrimSpace(rule), "=")
+		switch key {
+		case "dive":
+			return required, nil
+		case "required":
+			required = true
+		case "min", "gte":
+			err = setBound(s, typ, param, &s.Minimum, &s.MinLength, &s.MinItems)
+		case "max", "lte":
+			err = setBound(s, typ, param, &s.Maximum, &s.MaxLength, &s.MaxItems)
+		case "gt":
+			err = setBound(s, typ, param, &s.ExclusiveMinimum, nil, nil)
+		case "lt":
+			err = setBound(s, typ, param, &s.ExclusiveMaximum, nil, nil)
+		case "len":
+			if err = setBound(s, typ, param, &s.Minimum, &s.MinLength, &s.MinItems); err == nil {
+				err = setBound(s, typ, param, &s.Maximum, &s.MaxLength, &s.MaxItems)
+			}
+		case "oneof":
+			s.Enum = nil
+			for opt := range strings.FieldsSeq(param) {
+				val, parseErr := parseTagValue(typ, opt)
+				if parseErr != nil {
+					return false, parseErr
+				}
+				s.Enum = append(s.Enum, val)
+			}
+		case "email", "hostname", "ipv4", "ipv6", "uuid":
+			s.Format = key
+		case "url", "uri":
+			s.Format = "uri"
+		}
+		if err != nil {
+			return false, err
+		}
+	}
+	return required, nil
+}
+
+// setBound sets param to the keyword that matches s: num for numbers, length for strings and items for arrays.
+// Keywords passed as nil are not supported by the rule, in which case the rule is ignored.
+func setBound(s *jsonSchema, typ reflect.Type, param string, num *json.Number, length, items **uint64) error {
+	switch s.Type {
+	case "integer", "number":
+		if typ == durationType {
+			d, err := time.ParseDuration(param)
+			if err != nil {
+				return err
+			}
+			param = strconv.FormatInt(int64(d), 10)
+		} else if _, err := strconv.ParseFloat(param, 64); err != nil {
+			return err
+		}
+		*num = json.Number(param)
+	case "string", "array":
+		n, err := strconv.ParseUint(param, 10, 64)
+		if err != nil {
+			return err
+		}
+		if s.Type == "string" && length != nil {
+			*length = &n
+		} else if s.Type == "array" && items != nil {
+			*items = &n
+		}
+	}
+	return nil
+}
+
+// parseTagValue parses val into a value of typ in the same way as [LoadConfig].
+func parseTagValue(typ reflect.Type, val string) (any, error) {
+	if typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+	v := reflect.New(typ).Elem()
+	if err := setVal(v, val); err != nil {
+		return nil, err
+	}
+	return v.Interface(), nil
+}
@@ -0,0 +1,72 @@
+package encoding_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/sainnhe/go-common/pkg/encoding"
+	"github.com/sainnhe/go-common/pkg/testutil/golden"
+)
+
+type schemaBase struct {
+	ID string `json:"id" validate:"required,uuid"`
+}
+
+type schemaNode struct {
+	Name     string        `json:"name"`
+	Children []*schemaNode `json:"children"`
+}
+
+type schemaConfig struct {
+	schemaBase
+	Name     string            `json:"name" default:"app" env:"APP_NAME" validate:"required,min=1,max=32"`
+	Level    string            `json:"level" default:"info" validate:"oneof=debug info warn error"`
+	Port     uint16            `json:"port" default:"8080" validate:"gte=1"`
+	Ratio    float64           `json:"ratio" validate:"gt=0,lt=1"`
+	Debug    bool              `json:"debug" default:"false"`
+	Timeout  time.Duration     `json:"timeout" validate:"min=1s"`
+	Created  time.Time         `json:"created"`
+	Email    *string           `json:"email,omitempty" validate:"omitempty,email"`
+	Hosts    []string          `json:"hosts" default:"[\"localhost\"]" validate:"min=1,dive,hostname"`
+	Pair     [2]int            `json:"pair"`
+	Labels   map[string]string `json:"labels"`
+	Data     []byte            `json:"data"`
+	Tree     schemaNode        `json:"tree"`
+	Extra    any               `json:"extra"`
+	Ignored  string            `json:"-"`
+	Callback func()
+	internal string
+}
+
+func TestGenerateSchema(t *testing.T) {
+	t.Parallel()
+
+	b, err := encoding.GenerateSchema[schemaConfig]()
+	if err != nil {
+		t.Fatal(err)
+	}
+	golden.Assert(t, "schema", b, golden.WithJSON())
+}
+
+func TestGenerateSchema_error(t *testing.T) {
+	t.Parallel()
+
+	if _, err := encoding.GenerateSchema[int](); !errors.Is(err, encoding.ErrLoadConfigNotStruct) {
+		t.Errorf("Expect ErrLoadConfigNotStruct, got %v", err)
+	}
+
+	type badDefault struct {
+		Port int `json:"port" default:"abc"`
+	}
+	if _, err := encoding.GenerateSchema[badDefault](); !errors.Is(err, encoding.ErrLoadConfigInvalidValue) {
+		t.Errorf("Expect ErrLoadConfigInvalidValue for bad default, got %v", err)
+	}
+
+	type badRule struct {
+		Name string `json:"name" validate:"max=abc"`
+	}
+	if _, err := encoding.GenerateSchema[badRule](); !errors.Is(err, encoding.ErrLoadConfigInvalidValue) {
+		t.Errorf("Expect ErrLoadConfigInvalidValue for bad rule, got %v", err)
+	}
+}
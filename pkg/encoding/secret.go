@@ -0,0 +1,235 @@
+package encoding
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+var (
+	// ErrSecretNotFound indicates an error that the referenced secret can't be found.
+	ErrSecretNotFound = errors.New("secret not found")
+
+	// ErrSecretNotAllowed indicates an error that the referenced secret is outside the allowed scope.
+	ErrSecretNotAllowed = errors.New("secret not allowed")
+)
+
+// SecretResolver resolves secret references inside string values of a config.
+type SecretResolver interface {
+	// Resolve resolves secret references in the given value and returns the resolved value.
+	// The value should be returned as is if it doesn't contain any reference that can be handled by this resolver.
+	Resolve(ctx context.Context, val string) (string, error)
+}
+
+// WithSecretResolvers enables resolving secret references in string fields of the config using the given resolvers,
+// which are applied in order. Secret references are not resolved unless this option or [WithFileSecrets] is used, so
+// that remote config content can't read secrets unless the caller opts in.
+func WithSecretResolvers(resolvers ...SecretResolver) LoadOption {
+	return func(opts *loadOptions) {
+		for _, resolver := range resolvers {
+			if resolver != nil {
+				opts.secretResolvers = append(opts.secretResolvers, resolver)
+			}
+		}
+	}
+}
+
+// WithFileSecrets enables resolving file secret references inside baseDir, which is a shortcut of
+// [WithSecretResolvers] with a [FileSecretResolver].
+func WithFileSecrets(baseDir string) LoadOption {
+	return WithSecretResolvers(&FileSecretResolver{BaseDir: baseDir})
+}
+
+// fileSecretRegexp matches references like ${file:/run/secrets/db_password}.
+var fileSecretRegexp = regexp.MustCompile(`\$\{file:([^}]+)\}`)
+
+// FileSecretResolver resolves references like "${file:/run/secrets/db_password}" by replacing them with the content of
+// the given file. Trailing newlines of the file content are trimmed. A value may contain multiple references, for
+// example "postgres://user:${file:/run/secrets/db_password}@localhost:5432/db".
+//
+// Only files inside BaseDir can be referenced, and an error wrapping [ErrSecretNotAllowed] is returned for other files.
+type FileSecretResolver struct {
+	// BaseDir is the directory containing secret files, for example "/run/secrets". Relative references are resolved
+	// against it. No file can be referenced if it's empty.
+	BaseDir string
+}
+
+// Resolve implements [SecretResolver].
+func (r *FileSecretResolver) Resolve(_ context.Context, val string) (string, error) {
+	var errs []error
+	resolved := fileSecretRegexp.ReplaceAllStringFunc(val, func(ref string) string {
+		path, err := r.allowedPath(fileSecretRegexp.FindStringSubmatch(ref)[1])
+		if err != nil {
+			errs = append(errs, err)
+			return ref
+		}
+		content, err := os.ReadFile(path) // nolint:gosec
+		if err != nil {
+			errs = append(errs, err)
+			return ref
+		}
+		return strings.TrimRight(string(content), "\r\n")
+	})
+	if len(errs) > 0 {
+		return "", errors.Join(errs...)
+	}
+	return resolved, nil
+}
+
+// allowedPath returns the cleaned absolute path of the reference if it's inside BaseDir. Symbolic links are evaluated
+// so that they can't point outside BaseDir.
+func (r *FileSecretResolver) allowedPath(ref string) (string, error) {
+	if r.BaseDir == "" {
+		return "", fmt.Errorf("%w: %s", ErrSecretNotAllowed, ref)
+	}
+	base, err := filepath.Abs(r.BaseDir)
+	if err != nil {
+		return "", err
+	}
+	path := ref
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(base, path)
+	}
+	if base, err = filepath.EvalSymlinks(base); err != nil {
+		return "", err
+	}
+	if path, err = filepath.EvalSymlinks(path); err != nil {
+		return "", err
+	}
+	rel, err := filepath.Rel(base, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%w: %s", ErrSecretNotAllowed, ref)
+	}
+	return path, nil
+}
+
+// VaultSecretResolver resolves references like "vault://secret/data/db#password" by reading the given key from
+// HashiCorp Vault via the HTTP API. Both KV version 1 and version 2 secret engines are supported.
+//
+// The whole value must be a reference, otherwise it will be returned as is.
+type VaultSecretResolver struct {
+	// Address is the address of Vault server, for example "https://vault.example.com:8200".
+	Address string
+
+	// Token is the Vault token.
+	Token string
+
+	// Namespace is the optional Vault Enterprise namespace.
+	Namespace string
+
+	// Client is the optional HTTP client. A client with a default timeout will be used if it's nil.
+	Client *http.Client
+}
+
+// Resolve implements [SecretResolver].
+func (r *VaultSecretResolver) Resolve(ctx context.Context, val string) (string, error) {
+	ref, ok := strings.CutPrefix(val, "vault://")
+	if !ok {
+		return val, nil
+	}
+	path, key, ok := strings.Cut(ref, "#")
+	if !ok || len(path) == 0 || len(key) == 0 {
+		return "", fmt.Errorf("invalid vault reference %q", val)
+	}
+
+	// Send request
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+		fmt.Sprintf("%s/v1/%s", strings.TrimSuffix(r.Address, "/"), strings.TrimPrefix(path, "/")), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("X-Vault-Token", r.Token)
+	if len(r.Namespace) > 0 {
+		req.Header.Set("X-Vault-Namespace", r.Namespace)
+	}
+	body, err := doSourceRequest(r.Client, req)
+	if errors.Is(err, ErrConfigSourceNotFound) {
+		return "", fmt.Errorf("%w: %s", ErrSecretNotFound, val)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	// Parse response. KV v2 nests the secret in data.data, while KV v1 puts it in data.
+	var rsp struct {
+		Data map[string]any `json:"data"`
+	}
+	if err = json.Unmarshal(body, &rsp); err != nil {
+		return "", err
+	}
+	data := rsp.Data
+	if nested, ok := data["data"].(map[string]any); ok {
+		if _, hasMeta := data["metadata"]; hasMeta {
+			data = nested
+		}
+	}
+	secret, ok := data[key]
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrSecretNotFound, val)
+	}
+	if s, ok := secret.(string); ok {
+		return s, nil
+	}
+	return fmt.Sprintf("%v", secret), nil
+}
+
+// resolveSecrets resolves secret references in string values recursively using the given resolvers.
+func resolveSecrets(ctx context.Context, val reflect.Value, resolvers []SecretResolver) error {
+	if len(resolvers) == 0 {
+		return nil
+	}
+	return walkSecrets(ctx, val, resolvers)
+}
+
+func walkSecrets(ctx context.Context, val reflect.Value, resolvers []SecretResolver) error {
+	switch val.Kind() {
+	case reflect.String:
+		if !val.CanSet() || len(val.String()) == 0 {
+			return nil
+		}
+		s := val.String()
+		for _, resolver := range resolvers {
+			var err error
+			if s, err = resolver.Resolve(ctx, s); err != nil {
+				return err
+			}
+		}
+		val.SetString(s)
+	case reflect.Pointer:
+		if !val.IsNil() {
+			return walkSecrets(ctx, val.Elem(), resolvers)
+		}
+	case reflect.Struct:
+		for i := range val.NumField() {
+			if err := walkSecrets(ctx, val.Field(i), resolvers); err != nil {
+				return err
+			}
+		}
+	case reflect.Array, reflect.Slice:
+		for i := range val.Len() {
+			if err := walkSecrets(ctx, val.Index(i), resolvers); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		if !val.CanSet() || val.Type().Elem().Kind() != reflect.String {
+			return nil
+		}
+		for _, key := range val.MapKeys() {
+			elem := reflect.New(val.Type().Elem()).Elem()
+			elem.Set(val.MapIndex(key))
+			if err := walkSecrets(ctx, elem, resolvers); err != nil {
+				return err
+			}
+			val.SetMapIndex(key, elem)
+		}
+	}
+	return nil
+}
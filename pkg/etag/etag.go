@@ -0,0 +1,158 @@
+/*
+Package etag implements helpers of ETags and conditional requests.
+
+[CheckNotModified] responds with 304 Not Modified to conditional GET and HEAD requests, and [CheckMatch] implements
+optimistic concurrency for PUT, PATCH and DELETE requests with If-Match:
+
+	func getUser(w http.ResponseWriter, r *http.Request) {
+		u, err := repo.QueryByID(r.Context(), id)
+		...
+		if etag.CheckNotModified(w, r, etag.FromTime(u.UpdateTime), u.UpdateTime) {
+			return
+		}
+		json.NewEncoder(w).Encode(u)
+	}
+
+	func putUser(w http.ResponseWriter, r *http.Request) {
+		u, err := repo.QueryByID(r.Context(), id)
+		...
+		if !etag.CheckMatch(w, r, etag.FromTime(u.UpdateTime), true) {
+			return
+		}
+		...
+		err = repo.UpdateIfUnmodified(r.Context(), u)
+		if errors.Is(err, db.ErrConflict) {
+			w.WriteHeader(http.StatusPreconditionFailed)
+			return
+		}
+		...
+	}
+
+[CheckMatch] alone doesn't prevent lost updates, since another writer may update the record between the check and the
+update. db.ConditionalUpdater.UpdateIfUnmodified, which is implemented by db.SQLRepo, closes the gap by conditioning the
+UPDATE statement on the update time that the ETag was computed from, and returns db.ErrConflict if the record has been
+modified in the meantime.
+*/
+package etag
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Strong returns a strong ETag computed from the SHA-256 hash of b, which changes whenever b changes.
+func Strong(b []byte) string {
+	sum := sha256.Sum256(b)
+	return `"` + base64.RawURLEncoding.EncodeToString(sum[:16]) + `"`
+}
+
+// Weak returns a weak ETag computed from the SHA-256 hash of b, which indicates semantic equivalence, for example
+// between compressed and uncompressed representations.
+func Weak(b []byte) string {
+	return "W/" + Strong(b)
+}
+
+// FromTime returns a strong ETag of a record version identified by its update time, such as the UpdateTime field
+// of db.DO.
+func FromTime(t time.Time) string {
+	return `"` + strconv.FormatInt(t.UnixNano(), 36) + `"` // nolint:mnd
+}
+
+/*
+CheckNotModified sets the ETag and Last-Modified headers, and responds with 304 Not Modified if the client's cached
+representation is still fresh. It only applies to GET and HEAD requests.
+
+If-None-Match takes precedence over If-Modified-Since as defined in RFC 9110, and ETags are compared weakly.
+
+Params:
+  - w: The response writer.
+  - r: The request.
+  - etag: The current ETag. If empty, the ETag header is not set and If-None-Match is ignored.
+  - modTime: The last modification time. If zero, the Last-Modified header is not set and If-Modified-Since is ignored.
+
+Returns:
+  - bool: Whether 304 Not Modified has been written, in which case the handler should return immediately.
+*/
+func CheckNotModified(w http.ResponseWriter, r *http.Request, etag string, modTime time.Time) bool {
+	if len(etag) > 0 {
+		w.Header().Set("ETag", etag)
+	}
+	if !modTime.IsZero() {
+		w.Header().Set("Last-Modified", modTime.UTC().Format(http.TimeFormat))
+	}
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+
+	notModified := false
+	if inm := r.Header.Get("If-None-Match"); len(inm) > 0 {
+		notModified = len(etag) > 0 && matchAny(inm, etag, false)
+	} else if ims := r.Header.Get("If-Modified-Since"); len(ims) > 0 && !modTime.IsZero() {
+		t, err := http.ParseTime(ims)
+		notModified = err == nil && !modTime.Truncate(time.Second).After(t)
+	}
+	if notModified {
+		h := w.Header()
+		h.Del("Content-Type")
+		h.Del("Content-Length")
+		w.WriteHeader(http.StatusNotModified)
+	}
+	return notModified
+}
+
+/*
+CheckMatch checks the If-Match header of a state-changing request for optimistic concurrency, and responds with 412
+Precondition Failed if the client's representation is stale. ETags are compared strongly, and "*" matches any existing
+resource.
+
+Params:
+  - w: The response writer.
+  - r: The request.
+  - etag: The current ETag. If empty, the resource is considered nonexistent.
+  - required: Whether If-Match is required. If true and the header is missing, 428 Precondition Required is written.
+
+Returns:
+  - bool: Whether the request can proceed. If false, an error status has been written and the handler should return
+    immediately.
+*/
+func CheckMatch(w http.ResponseWriter, r *http.Request, etag string, required bool) bool {
+	im := r.Header.Get("If-Match")
+	if len(im) == 0 {
+		if required {
+			http.Error(w, http.StatusText(http.StatusPreconditionRequired), http.StatusPreconditionRequired)
+			return false
+		}
+		return true
+	}
+	if len(etag) == 0 || !matchAny(im, etag, true) {
+		http.Error(w, http.StatusText(http.StatusPreconditionFailed), http.StatusPreconditionFailed)
+		return false
+	}
+	return true
+}
+
+// matchAny reports whether the list of ETags in a conditional header matches etag. Weak ETags never match in strong
+// comparison.
+func matchAny(list, etag string, strong bool) bool {
+	if strings.TrimSpace(list) == "*" {
+		return true
+	}
+	if strong && strings.HasPrefix(etag, "W/") {
+		return false
+	}
+	etag = strings.TrimPrefix(etag, "W/")
+	for candidate := range strings.SplitSeq(list, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if strong && strings.HasPrefix(candidate, "W/") {
+			continue
+		}
+		if strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,113 @@
+package etag_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/sainnhe/go-common/pkg/etag"
+)
+
+func TestETag(t *testing.T) {
+	t.Parallel()
+
+	strong := etag.Strong([]byte("hello"))
+	if strong[0] != '"' || strong == etag.Strong([]byte("world")) {
+		t.Fatalf("Unexpected strong ETag %s", strong)
+	}
+	if weak := etag.Weak([]byte("hello")); weak != "W/"+strong {
+		t.Fatalf("Unexpected weak ETag %s", weak)
+	}
+	now := time.Now()
+	if etag.FromTime(now) == etag.FromTime(now.Add(time.Nanosecond)) {
+		t.Fatal("Expect different ETags")
+	}
+}
+
+func TestCheckNotModified(t *testing.T) {
+	t.Parallel()
+
+	tag := etag.Strong([]byte("hello"))
+	modTime := time.Date(2024, 1, 2, 3, 4, 5, 6, time.UTC)
+	tests := []struct {
+		name   string
+		method string
+		header map[string]string
+		want   bool
+	}{
+		{"No condition", http.MethodGet, nil, false},
+		{"Matched ETag", http.MethodGet, map[string]string{"If-None-Match": `"foo", ` + tag}, true},
+		{"Weakly matched ETag", http.MethodHead, map[string]string{"If-None-Match": "W/" + tag}, true},
+		{"Wildcard", http.MethodGet, map[string]string{"If-None-Match": "*"}, true},
+		{"Mismatched ETag", http.MethodGet, map[string]string{"If-None-Match": `"foo"`}, false},
+		{"Not modified since", http.MethodGet, map[string]string{"If-Modified-Since": modTime.Format(http.TimeFormat)},
+			true},
+		{"Modified since", http.MethodGet,
+			map[string]string{"If-Modified-Since": modTime.Add(-time.Hour).Format(http.TimeFormat)}, false},
+		{"ETag takes precedence", http.MethodGet, map[string]string{
+			"If-None-Match":     `"foo"`,
+			"If-Modified-Since": modTime.Format(http.TimeFormat),
+		}, false},
+		{"Not GET", http.MethodPost, map[string]string{"If-None-Match": tag}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := httptest.NewRequestWithContext(context.Background(), tt.method, "/", nil)
+			for k, v := range tt.header {
+				r.Header.Set(k, v)
+			}
+			w := httptest.NewRecorder()
+			if got := etag.CheckNotModified(w, r, tag, modTime); got != tt.want {
+				t.Fatalf("Want %v, got %v", tt.want, got)
+			}
+			if w.Header().Get("ETag") != tag || w.Header().Get("Last-Modified") != modTime.Format(http.TimeFormat) {
+				t.Fatalf("Unexpected headers %v", w.Header())
+			}
+			if tt.want && w.Code != http.StatusNotModified {
+				t.Fatalf("Want status %d, got %d", http.StatusNotModified, w.Code)
+			}
+		})
+	}
+}
+
+func TestCheckMatch(t *testing.T) {
+	t.Parallel()
+
+	tag := etag.FromTime(time.Now())
+	tests := []struct {
+		name     string
+		ifMatch  string
+		etag     string
+		required bool
+		want     bool
+		status   int
+	}{
+		{"Optional", "", tag, false, true, http.StatusOK},
+		{"Required", "", tag, true, false, http.StatusPreconditionRequired},
+		{"Matched", `"foo", ` + tag, tag, true, true, http.StatusOK},
+		{"Weak never matches", "W/" + tag, tag, true, false, http.StatusPreconditionFailed},
+		{"Stale", `"foo"`, tag, true, false, http.StatusPreconditionFailed},
+		{"Wildcard", "*", tag, true, true, http.StatusOK},
+		{"Wildcard without resource", "*", "", true, false, http.StatusPreconditionFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := httptest.NewRequestWithContext(context.Background(), http.MethodPut, "/", nil)
+			if len(tt.ifMatch) > 0 {
+				r.Header.Set("If-Match", tt.ifMatch)
+			}
+			w := httptest.NewRecorder()
+			if got := etag.CheckMatch(w, r, tt.etag, tt.required); got != tt.want || w.Code != tt.status {
+				t.Fatalf("Want %v %d, got %v %d", tt.want, tt.status, got, w.Code)
+			}
+		})
+	}
+}
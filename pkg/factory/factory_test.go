@@ -0,0 +1,132 @@
+package factory_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sainnhe/go-common/pkg/db"
+	"github.com/sainnhe/go-common/pkg/factory"
+)
+
+type user struct {
+	db.DO
+	Name  string `db:"name"`
+	Email string `db:"email"`
+	Role  string `db:"role"`
+	Age   int    `db:"age"`
+}
+
+func newUserFactory() *factory.Factory[user] {
+	return factory.New(func(seq int64, f *factory.Faker) user {
+		return user{
+			Name:  f.Name(),
+			Email: fmt.Sprintf("user%d@example.com", seq),
+			Role:  "member",
+			Age:   f.Int(18, 60),
+		}
+	})
+}
+
+func TestFactory_Build(t *testing.T) {
+	t.Parallel()
+
+	users := newUserFactory()
+	admin := factory.Trait(func(u *user) { u.Role = "admin" })
+
+	u := users.Build()
+	if len(u.Name) == 0 || !strings.Contains(u.Email, "@example.") || u.Role != "member" || u.Age < 18 || u.Age > 60 {
+		t.Fatalf("Got %+v", u)
+	}
+
+	u = users.Build(admin, func(u *user) { u.Age = 30 }, nil)
+	if u.Role != "admin" || u.Age != 30 {
+		t.Fatalf("Got %+v", u)
+	}
+
+	if users.Seq() != 2 {
+		t.Fatalf("Want seq 2, got %d", users.Seq())
+	}
+	users.Reset()
+	if users.Seq() != 0 {
+		t.Fatalf("Want seq 0, got %d", users.Seq())
+	}
+
+	list := users.BuildList(3, admin)
+	if len(list) != 3 || list[2].Role != "admin" {
+		t.Fatalf("Got %+v", list)
+	}
+
+	// Same seed generates the same data
+	if a, b := newUserFactory().Build(), newUserFactory().Build(); *a != *b {
+		t.Fatalf("Expect deterministic data, got %+v and %+v", a, b)
+	}
+}
+
+func TestFactory_Create(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	users := newUserFactory()
+	repo := db.NewMemoryRepo[user]()
+
+	u, err := users.Create(ctx, repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.ID != 1 {
+		t.Fatalf("Want ID 1, got %d", u.ID)
+	}
+
+	list, err := users.CreateList(ctx, repo, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 2 || list[1].ID != 3 {
+		t.Fatalf("Got %+v", list)
+	}
+
+	type noID struct {
+		Name string
+	}
+	_, err = factory.New[noID](nil).CreateList(ctx, db.NewMemoryRepo[noID](), 1)
+	if !errors.Is(err, db.ErrNoIDField) {
+		t.Fatalf("Want %+v, got %+v", db.ErrNoIDField, err)
+	}
+}
+
+func TestFaker(t *testing.T) {
+	t.Parallel()
+
+	f := factory.NewFaker(1)
+	now := time.Now()
+
+	if s := f.Sentence(3); len(strings.Fields(s)) != 3 || !strings.HasSuffix(s, ".") {
+		t.Fatalf("Got %q", s)
+	}
+	if s := f.Sentence(0); len(s) != 0 {
+		t.Fatalf("Got %q", s)
+	}
+	if v := f.Int(5, 1); v != 5 {
+		t.Fatalf("Want 5, got %d", v)
+	}
+	if v := f.Past(time.Hour); v.After(time.Now()) || v.Before(now.Add(-time.Hour)) {
+		t.Fatalf("Got %s", v)
+	}
+	if v := f.Future(time.Hour); v.Before(now) || v.After(time.Now().Add(time.Hour)) {
+		t.Fatalf("Got %s", v)
+	}
+	if v := f.Time(now, now); !v.Equal(now) {
+		t.Fatalf("Want %s, got %s", now, v)
+	}
+	if v := f.Phone(); !strings.HasPrefix(v, "+1-555-") {
+		t.Fatalf("Got %s", v)
+	}
+	if !strings.Contains(f.Email(), "@example.") || len(f.Word()) == 0 {
+		t.Fatal("Expect non-empty values")
+	}
+	_ = f.Bool()
+}
@@ -0,0 +1,122 @@
+package factory
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"strings"
+	"time"
+)
+
+var (
+	firstNames = []string{
+		"Alice", "Bob", "Carol", "David", "Emma", "Frank", "Grace", "Henry", "Isabel", "Jack",
+		"Karen", "Leo", "Mia", "Noah", "Olivia", "Peter", "Quinn", "Rose", "Sam", "Tina",
+	}
+	lastNames = []string{
+		"Anderson", "Brown", "Clark", "Davis", "Evans", "Foster", "Garcia", "Harris", "Jones", "King",
+		"Lee", "Miller", "Nelson", "Owens", "Parker", "Roberts", "Smith", "Taylor", "Walker", "Young",
+	}
+	domains = []string{"example.com", "example.net", "example.org"}
+	words   = []string{
+		"alpha", "bravo", "cloud", "delta", "echo", "forest", "garden", "harbor", "island", "jungle",
+		"kernel", "lemon", "meadow", "nectar", "ocean", "pepper", "quartz", "river", "stone", "tiger",
+	}
+)
+
+// Faker generates fake data. It's not safe for concurrent use, but the faker passed to the defaults function of a
+// [Factory] is already synchronized by the factory.
+type Faker struct {
+	r *rand.Rand
+}
+
+// NewFaker initializes a new [Faker] with the given seed.
+func NewFaker(seed uint64) *Faker {
+	return &Faker{
+		rand.New(rand.NewPCG(seed, seed)), // nolint:gosec
+	}
+}
+
+// FirstName returns a fake first name.
+func (f *Faker) FirstName() string {
+	return pick(f, firstNames)
+}
+
+// LastName returns a fake last name.
+func (f *Faker) LastName() string {
+	return pick(f, lastNames)
+}
+
+// Name returns a fake full name.
+func (f *Faker) Name() string {
+	return fmt.Sprintf("%s %s", f.FirstName(), f.LastName())
+}
+
+// Username returns a fake username.
+func (f *Faker) Username() string {
+	return fmt.Sprintf("%s%d", strings.ToLower(f.FirstName()), f.Int(1, 9999)) // nolint:mnd
+}
+
+// Email returns a fake email address under a reserved example domain.
+func (f *Faker) Email() string {
+	return fmt.Sprintf("%s@%s", f.Username(), pick(f, domains))
+}
+
+// Word returns a fake word.
+func (f *Faker) Word() string {
+	return pick(f, words)
+}
+
+// Sentence returns a fake sentence that contains n words.
+func (f *Faker) Sentence(n int) string {
+	ws := make([]string, 0, max(n, 0))
+	for range n {
+		ws = append(ws, f.Word())
+	}
+	s := strings.Join(ws, " ")
+	if len(s) == 0 {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:] + "."
+}
+
+// Int returns a fake integer in [min, max]. If max < min, min will be returned.
+func (f *Faker) Int(minVal, maxVal int) int {
+	if maxVal <= minVal {
+		return minVal
+	}
+	return minVal + f.r.IntN(maxVal-minVal+1)
+}
+
+// Bool returns a fake boolean.
+func (f *Faker) Bool() bool {
+	return f.r.IntN(2) == 1 // nolint:mnd
+}
+
+// Time returns a fake time in [start, end). If end is not after start, start will be returned.
+func (f *Faker) Time(start, end time.Time) time.Time {
+	if !end.After(start) {
+		return start
+	}
+	return start.Add(time.Duration(f.r.Int64N(int64(end.Sub(start)))))
+}
+
+// Past returns a fake time within the duration d before now.
+func (f *Faker) Past(d time.Duration) time.Time {
+	now := time.Now()
+	return f.Time(now.Add(-d), now)
+}
+
+// Future returns a fake time within the duration d after now.
+func (f *Faker) Future(d time.Duration) time.Time {
+	now := time.Now()
+	return f.Time(now, now.Add(d))
+}
+
+// Phone returns a fake phone number in the reserved 555 range.
+func (f *Faker) Phone() string {
+	return fmt.Sprintf("+1-555-%03d-%04d", f.Int(100, 999), f.Int(0, 9999)) // nolint:mnd
+}
+
+func pick(f *Faker, s []string) string {
+	return s[f.r.IntN(len(s))]
+}
@@ -0,0 +1,16 @@
+package flash
+
+// Config defines the config model for flash.
+type Config struct {
+	// Secret is the secret key used to encrypt flash cookies. It must not be empty.
+	Secret string `json:"secret" yaml:"secret" toml:"secret" xml:"secret" env:"FLASH_SECRET" default:"" secret:"true"` // nolint:lll
+
+	// Cookie is the name of the flash cookie.
+	Cookie string `json:"cookie" yaml:"cookie" toml:"cookie" xml:"cookie" env:"FLASH_COOKIE" default:"flash"`
+
+	// TTLSec is how long flash messages are kept in seconds if they are not read.
+	TTLSec int64 `json:"ttl_sec" yaml:"ttl_sec" toml:"ttl_sec" xml:"ttl_sec" env:"FLASH_TTL_SEC" default:"300"`
+
+	// InsecureCookie disables the Secure attribute of the cookie, which is useful for local development over HTTP.
+	InsecureCookie bool `json:"insecure_cookie" yaml:"insecure_cookie" toml:"insecure_cookie" xml:"insecure_cookie" env:"FLASH_INSECURE_COOKIE" default:"false"` // nolint:lll
+}
@@ -0,0 +1,218 @@
+/*
+Package flash implements flash messages, which are one-off messages carried over to the next request via an encrypted
+cookie, typically used to show the result of a form submission after a redirect:
+
+	f, err := flash.New(cfg)
+	// In the POST handler:
+	err = f.Add(w, r, flash.Message{Level: flash.LevelSuccess, Text: "Profile updated."})
+	http.Redirect(w, r, "/profile", http.StatusSeeOther)
+	// In the GET handler:
+	msgs, err := f.Pop(w, r)
+
+The cookie is encrypted and authenticated with AES-256-GCM, so that its content can't be read or forged by clients.
+*/
+package flash
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/sainnhe/go-common/pkg/clock"
+	"github.com/sainnhe/go-common/pkg/constant"
+)
+
+const (
+	// LevelInfo is the level of informational messages.
+	LevelInfo = "info"
+
+	// LevelSuccess is the level of success messages.
+	LevelSuccess = "success"
+
+	// LevelWarning is the level of warning messages.
+	LevelWarning = "warning"
+
+	// LevelError is the level of error messages.
+	LevelError = "error"
+)
+
+var (
+	// ErrEmptySecret indicates that the secret in config is empty.
+	ErrEmptySecret = errors.New("empty secret")
+
+	// ErrInvalidConfig indicates that the config is invalid.
+	ErrInvalidConfig = errors.New("invalid config")
+
+	// ErrInvalidCookie indicates that the flash cookie can't be decrypted or has expired.
+	ErrInvalidCookie = errors.New("invalid cookie")
+)
+
+// Message is a flash message.
+type Message struct {
+	// Level is the level of the message, e.g. [LevelSuccess].
+	Level string `json:"l"`
+
+	// Text is the text of the message.
+	Text string `json:"t"`
+}
+
+// payload is the plaintext of the flash cookie.
+type payload struct {
+	Messages   []Message `json:"m"`
+	ExpireTime int64     `json:"e"`
+}
+
+// Flash reads and writes flash messages.
+type Flash struct {
+	cfg  *Config
+	aead cipher.AEAD
+	clk  clock.Clock
+}
+
+// Option is the option used to customize [Flash].
+type Option func(f *Flash)
+
+// WithClock sets the clock used to determine expiration time. Defaults to [clock.Real].
+func WithClock(clk clock.Clock) Option {
+	return func(f *Flash) {
+		if clk != nil {
+			f.clk = clk
+		}
+	}
+}
+
+/*
+New initializes a new [Flash].
+
+Params:
+  - cfg: The config.
+  - opts: The options.
+
+Returns:
+  - *Flash: The initialized [Flash].
+  - error: [constant.ErrNilDeps] if cfg is nil, [ErrEmptySecret] if [Config.Secret] is empty, or [ErrInvalidConfig]
+    if [Config.TTLSec] is not positive.
+*/
+func New(cfg *Config, opts ...Option) (*Flash, error) {
+	if cfg == nil {
+		return nil, constant.ErrNilDeps
+	}
+	if cfg.Secret == "" {
+		return nil, ErrEmptySecret
+	}
+	if cfg.TTLSec <= 0 {
+		return nil, ErrInvalidConfig
+	}
+	key := sha256.Sum256([]byte(cfg.Secret))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	f := &Flash{cfg, aead, clock.Real()}
+	for _, opt := range opts {
+		opt(f)
+	}
+	return f, nil
+}
+
+// Add appends messages to the flash cookie. Messages that are added earlier in the same response or have not been
+// read yet are kept.
+func (f *Flash) Add(w http.ResponseWriter, r *http.Request, msgs ...Message) error {
+	if len(msgs) == 0 {
+		return nil
+	}
+	pending, err := f.pending(w, r)
+	if err != nil && !errors.Is(err, http.ErrNoCookie) && !errors.Is(err, ErrInvalidCookie) {
+		return err
+	}
+	ttl := time.Duration(f.cfg.TTLSec) * time.Second
+	plaintext, err := json.Marshal(&payload{append(pending, msgs...), f.clk.Now().Add(ttl).Unix()})
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, f.aead.NonceSize())
+	if _, err = rand.Read(nonce); err != nil {
+		return err
+	}
+	f.setCookie(w, base64.RawURLEncoding.EncodeToString(f.aead.Seal(nonce, nonce, plaintext, []byte(f.cfg.Cookie))),
+		int(ttl.Seconds()))
+	return nil
+}
+
+// Pop returns the messages in the flash cookie and clears it, so that they are only shown once. No messages are
+// returned if the cookie doesn't exist, has expired or has been tampered with.
+func (f *Flash) Pop(w http.ResponseWriter, r *http.Request) ([]Message, error) {
+	cookie, err := r.Cookie(f.cfg.Cookie)
+	if err != nil {
+		return nil, nil // nolint:nilerr
+	}
+	f.setCookie(w, "", -1)
+	msgs, err := f.decrypt(cookie.Value)
+	if errors.Is(err, ErrInvalidCookie) {
+		return nil, nil
+	}
+	return msgs, err
+}
+
+// pending returns the messages set earlier in the same response, or the unread messages in the request.
+func (f *Flash) pending(w http.ResponseWriter, r *http.Request) ([]Message, error) {
+	headers := w.Header().Values("Set-Cookie")
+	for i := len(headers) - 1; i >= 0; i-- {
+		cookie, err := http.ParseSetCookie(headers[i])
+		if err != nil || cookie.Name != f.cfg.Cookie {
+			continue
+		}
+		if cookie.MaxAge < 0 {
+			return nil, nil
+		}
+		return f.decrypt(cookie.Value)
+	}
+	cookie, err := r.Cookie(f.cfg.Cookie)
+	if err != nil {
+		return nil, err
+	}
+	return f.decrypt(cookie.Value)
+}
+
+// decrypt decrypts the value of a flash cookie and returns its messages.
+func (f *Flash) decrypt(value string) ([]Message, error) {
+	ciphertext, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil || len(ciphertext) < f.aead.NonceSize() {
+		return nil, ErrInvalidCookie
+	}
+	nonce, ciphertext := ciphertext[:f.aead.NonceSize()], ciphertext[f.aead.NonceSize():]
+	plaintext, err := f.aead.Open(nil, nonce, ciphertext, []byte(f.cfg.Cookie))
+	if err != nil {
+		return nil, ErrInvalidCookie
+	}
+	p := &payload{}
+	if err = json.Unmarshal(plaintext, p); err != nil {
+		return nil, err
+	}
+	if p.ExpireTime <= f.clk.Now().Unix() {
+		return nil, ErrInvalidCookie
+	}
+	return p.Messages, nil
+}
+
+func (f *Flash) setCookie(w http.ResponseWriter, value string, maxAge int) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     f.cfg.Cookie,
+		Value:    value,
+		Path:     "/",
+		MaxAge:   maxAge,
+		Secure:   !f.cfg.InsecureCookie,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
@@ -0,0 +1,130 @@
+package flash_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/sainnhe/go-common/pkg/clock/testclock"
+	"github.com/sainnhe/go-common/pkg/constant"
+	"github.com/sainnhe/go-common/pkg/encoding"
+	"github.com/sainnhe/go-common/pkg/flash"
+)
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	if _, err := flash.New(nil); !errors.Is(err, constant.ErrNilDeps) {
+		t.Fatalf("Expect error %+v, got %+v", constant.ErrNilDeps, err)
+	}
+	cfg, err := encoding.LoadConfig[flash.Config](nil, encoding.TypeNil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Cookie != "flash" || cfg.TTLSec != 300 {
+		t.Fatalf("Unexpected default config %+v", cfg)
+	}
+	if _, err := flash.New(cfg); !errors.Is(err, flash.ErrEmptySecret) {
+		t.Fatalf("Expect error %+v, got %+v", flash.ErrEmptySecret, err)
+	}
+	cfg.Secret = "foo"
+	for _, ttl := range []int64{0, -1} {
+		cfg.TTLSec = ttl
+		if _, err := flash.New(cfg); !errors.Is(err, flash.ErrInvalidConfig) {
+			t.Fatalf("Expect error %+v, got %+v", flash.ErrInvalidConfig, err)
+		}
+	}
+}
+
+func TestFlash(t *testing.T) {
+	t.Parallel()
+
+	clk := testclock.Freeze(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC))
+	cfg := &flash.Config{Secret: "foo", Cookie: "flash", TTLSec: 60}
+	f, err := flash.New(cfg, flash.WithClock(clk))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Messages added in the same response are kept.
+	msg1 := flash.Message{Level: flash.LevelSuccess, Text: "Saved."}
+	msg2 := flash.Message{Level: flash.LevelWarning, Text: "Quota almost exceeded."}
+	w := httptest.NewRecorder()
+	r := newRequest(nil)
+	if err = f.Add(w, r, msg1); err != nil {
+		t.Fatal(err)
+	}
+	if err = f.Add(w, r, msg2); err != nil {
+		t.Fatal(err)
+	}
+	cookies := w.Result().Cookies() // nolint:bodyclose
+	cookie := cookies[len(cookies)-1]
+	if cookie.Value == "" || !cookie.HttpOnly || !cookie.Secure {
+		t.Fatalf("Unexpected cookie %+v", cookie)
+	}
+
+	// Messages are popped once.
+	w = httptest.NewRecorder()
+	msgs, err := f.Pop(w, newRequest(cookie))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(msgs, []flash.Message{msg1, msg2}) {
+		t.Fatalf("Unexpected messages %+v", msgs)
+	}
+	if cleared := w.Result().Cookies(); len(cleared) != 1 || cleared[0].MaxAge >= 0 { // nolint:bodyclose
+		t.Fatalf("Expect cookie to be cleared, got %+v", cleared)
+	}
+	if msgs, err = f.Pop(httptest.NewRecorder(), newRequest(nil)); err != nil || msgs != nil {
+		t.Fatalf("Unexpected messages %+v, %+v", msgs, err)
+	}
+
+	// Unread messages are kept when adding new ones.
+	w = httptest.NewRecorder()
+	if err = f.Add(w, newRequest(cookie), msg1); err != nil {
+		t.Fatal(err)
+	}
+	if msgs, err = f.Pop(httptest.NewRecorder(), newRequest(w.Result().Cookies()[0])); err != nil || // nolint:bodyclose
+		len(msgs) != 3 {
+		t.Fatalf("Unexpected messages %+v, %+v", msgs, err)
+	}
+
+	// Tampered, foreign and expired cookies are ignored.
+	other, err := flash.New(&flash.Config{Secret: "bar", Cookie: "flash", TTLSec: 60}, flash.WithClock(clk))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tampered := *cookie
+	tampered.Value = "A" + cookie.Value[1:]
+	if cookie.Value[0] == 'A' {
+		tampered.Value = "B" + cookie.Value[1:]
+	}
+	for name, tt := range map[string]struct {
+		f      *flash.Flash
+		cookie *http.Cookie
+	}{
+		"Tampered": {f, &tampered},
+		"Foreign":  {other, cookie},
+		"Invalid":  {f, &http.Cookie{Name: "flash", Value: "!"}},
+	} {
+		if msgs, err = tt.f.Pop(httptest.NewRecorder(), newRequest(tt.cookie)); err != nil || msgs != nil {
+			t.Fatalf("[%s] Unexpected messages %+v, %+v", name, msgs, err)
+		}
+	}
+	clk.Advance(time.Minute)
+	if msgs, err = f.Pop(httptest.NewRecorder(), newRequest(cookie)); err != nil || msgs != nil {
+		t.Fatalf("Unexpected messages %+v, %+v", msgs, err)
+	}
+}
+
+func newRequest(cookie *http.Cookie) *http.Request {
+	r := httptest.NewRequestWithContext(context.Background(), http.MethodGet, "/", nil)
+	if cookie != nil {
+		r.AddCookie(cookie)
+	}
+	return r
+}
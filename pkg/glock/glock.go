@@ -1,7 +1,21 @@
-// Package glock implements goroutine lock.
+/*
+Package glock implements goroutine lock.
+
+Locks can be acquired anonymously via [Lock] and [Unlock], or with a name via [Hold], so that the locks still held can
+be listed via [Snapshot] when waiting for them takes too long:
+
+	release := glock.Hold("kafka-consumer")
+	defer release()
+*/
 package glock
 
 import (
+	"maps"
+	"runtime/debug"
+	"slices"
+	"sync"
+	"time"
+
 	"github.com/sainnhe/go-common/pkg/concurrent"
 	"github.com/sainnhe/go-common/pkg/log"
 )
@@ -12,6 +26,25 @@ var wg = &concurrent.WaitGroup{
 	Logger: log.NewLogger("github.com/sainnhe/go-common/pkg/glock"),
 }
 
+var (
+	// heldMutex guards held and nextID.
+	heldMutex sync.Mutex
+	held      = map[uint64]*Held{}
+	nextID    uint64
+)
+
+// Held is a named goroutine lock that is being held.
+type Held struct {
+	// Name is the name passed to [Hold].
+	Name string
+
+	// AcquiredAt is when the lock is acquired.
+	AcquiredAt time.Time
+
+	// Stack is the stack trace of the goroutine that acquired the lock.
+	Stack string
+}
+
 // Lock locks goroutine to ensure that the task won't be interrupted.
 func Lock() {
 	wg.Add(1)
@@ -24,10 +57,58 @@ func Unlock() {
 	wg.Done()
 }
 
+// Hold is like [Lock], but the lock is named and listed by [Snapshot] until the returned release function is called.
+// Calling release more than once is a no-op.
+//
+// NOTE: The release function must be called via defer for the same reason as [Unlock].
+func Hold(name string) (release func()) {
+	h := &Held{
+		Name:       name,
+		AcquiredAt: time.Now(),
+		Stack:      string(debug.Stack()),
+	}
+	wg.Add(1)
+	heldMutex.Lock()
+	id := nextID
+	nextID++
+	held[id] = h
+	heldMutex.Unlock()
+
+	once := sync.Once{}
+	return func() {
+		once.Do(func() {
+			heldMutex.Lock()
+			delete(held, id)
+			heldMutex.Unlock()
+			wg.Done()
+		})
+	}
+}
+
+// Snapshot returns the named locks that are being held in the order of acquisition. Locks acquired via [Lock] are not
+// listed.
+func Snapshot() []Held {
+	heldMutex.Lock()
+	snapshot := make([]Held, 0, len(held))
+	// Collect in the order of IDs, so that locks acquired at the same time are ordered by ID after the stable sort.
+	for _, id := range slices.Sorted(maps.Keys(held)) {
+		snapshot = append(snapshot, *held[id])
+	}
+	heldMutex.Unlock()
+	slices.SortStableFunc(snapshot, func(a, b Held) int {
+		return a.AcquiredAt.Compare(b.AcquiredAt)
+	})
+	return snapshot
+}
+
 // Wait waits for all goroutine locks to be released.
 func Wait() {
 	if count := wg.GetCount(); count > 0 {
-		wg.Logger.Info("Waiting for goroutine locks to be released...", "count", count)
+		names := []string{}
+		for _, h := range Snapshot() {
+			names = append(names, h.Name)
+		}
+		wg.Logger.Info("Waiting for goroutine locks to be released...", "count", count, "names", names)
 		wg.Wait()
 	}
 }
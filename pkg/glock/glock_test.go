@@ -1,6 +1,7 @@
 package glock_test
 
 import (
+	"strings"
 	"testing"
 	"time"
 
@@ -22,3 +23,30 @@ func TestGlock(t *testing.T) {
 		t.Fatalf("Expect duration < %+v", sleepTime)
 	}
 }
+
+// nolint:paralleltest
+func TestHold(t *testing.T) {
+	// Locks acquired in the same clock tick are still listed in the order of acquisition.
+	release1 := glock.Hold("first")
+	release2 := glock.Hold("second")
+
+	snapshot := glock.Snapshot()
+	if len(snapshot) != 2 || snapshot[0].Name != "first" || snapshot[1].Name != "second" {
+		t.Fatalf("Unexpected snapshot %+v", snapshot)
+	}
+	if !strings.Contains(snapshot[0].Stack, "TestHold") {
+		t.Fatalf("Expect stack to contain TestHold, got %s", snapshot[0].Stack)
+	}
+
+	// Releasing more than once is a no-op.
+	release1()
+	release1()
+	if snapshot = glock.Snapshot(); len(snapshot) != 1 || snapshot[0].Name != "second" {
+		t.Fatalf("Unexpected snapshot %+v", snapshot)
+	}
+
+	release2()
+	if snapshot = glock.Snapshot(); len(snapshot) != 0 {
+		t.Fatalf("Unexpected snapshot %+v", snapshot)
+	}
+}
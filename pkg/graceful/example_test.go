@@ -7,7 +7,6 @@ import (
 	"fmt"
 	"net/http"
 	"os"
-	"syscall"
 	"time"
 
 	"github.com/sainnhe/go-common/pkg/constant"
@@ -55,14 +54,12 @@ func Example_gracefulShutdown() {
 		logger.Info("Cleaning up...")
 	})
 
-	// Before starting the server, let's launch a goroutine that will send a kill signal after 500ms. We need to launch
-	// it before starting the server because server.ListenAndServe() will block further operations until the server is
-	// closed.
+	// Before starting the server, let's launch a goroutine that will trigger the shutdown after 500ms, which is the same
+	// as sending a kill signal to the process. We need to launch it before starting the server because
+	// server.ListenAndServe() will block further operations until the server is closed.
 	go func() {
 		time.Sleep(time.Duration(500) * time.Millisecond)
-		if err := syscall.Kill(os.Getpid(), syscall.SIGINT); err != nil {
-			logger.Error("Send kill signal failed.", constant.LogAttrError, err.Error())
-		}
+		graceful.Shutdown("example")
 	}()
 
 	// Start the server.
@@ -75,6 +72,9 @@ func Example_gracefulShutdown() {
 		}
 	}
 
+	// Wait for the shutdown process to complete, since the server is closed before that.
+	<-graceful.Done()
+
 	// This message will be printed if the kill signal is successfully captured.
 	fmt.Println("Shutdown completed.")
 
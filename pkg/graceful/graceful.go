@@ -3,37 +3,78 @@
 // The idea of graceful shutdown is that when a kill signal like [syscall.SIGINT] is received, instead of exiting
 // directly, the program will perform a custom cleanup process to release resources.
 //
-// This package provides 3 functions to complete this task:
+// This package provides 5 functions to complete this task:
 //
 //   - [RegisterShutdown]: Registers a custom shutdown function that will be executed when a kill signal is received.
+//   - [RegisterStage]: Registers a named shutdown stage with its own timeout, e.g. stopping servers before closing
+//     database pools.
 //   - [RegisterPreShutdownHook]: Register a hook that will be run before shutdown.
 //   - [RegisterPostShutdownHook]: Register a hook that will be run after shutdown.
+//   - [RegisterCleanupHook]: Register a hook that will be run after everything else has finished.
 //
-// The registered hook functions will be executed in the order of registration.
+// Each of them has a Context variant like [RegisterShutdownContext], whose function receives a context bound to the
+// deadline of the whole shutdown process, so that it can stop its work cooperatively before the process is forced to
+// exit.
+//
+// The pre-shutdown and post-shutdown hook functions will be executed in the order of registration, while the cleanup
+// hook functions will be executed in the reverse order of registration, just like deferred functions. Stages are
+// executed in the order of stage numbers after the shutdown function.
+//
+// Besides kill signals, the shutdown process can also be triggered programmatically by [Shutdown], e.g. on fatal
+// internal errors, and [Done] can be used to wait for it to complete. The signals can be changed via [SetSignals].
+//
+// After the cleanup hooks, the records queued by the async writer of the global logger are written via [log.Flush], so
+// that logs produced during the shutdown process are not lost.
+//
+// [Readyz] and [Livez] provide probe handlers reporting the state of the shutdown process, so that load balancers stop
+// sending traffic to the process as soon as the shutdown process starts.
 package graceful
 
 import (
+	"cmp"
 	"context"
+	"errors"
+	"fmt"
+	"log/slog"
 	"os"
 	"os/signal"
+	"slices"
 	"sync"
-	"syscall"
 	"time"
 
+	"github.com/sainnhe/go-common/pkg/constant"
 	"github.com/sainnhe/go-common/pkg/glock"
 	"github.com/sainnhe/go-common/pkg/log"
 	"github.com/sainnhe/go-common/pkg/util"
 )
 
 var (
-	preShutdownHooks     []func()
-	postShutdownHooks    []func()
-	hooksMutex           sync.RWMutex
-	registerShutdownOnce sync.Once
+	preShutdownHooks  []func(ctx context.Context)
+	postShutdownHooks []func(ctx context.Context)
+	cleanupHooks      []func(ctx context.Context)
+	stages            []*shutdownStage
+	shutdownFunc      func(ctx context.Context)
+	shutdownTimeout   time.Duration
+	hooksMutex        sync.RWMutex
+	listenOnce        sync.Once
+	shutdownCh        = make(chan string, 1)
+	doneCh            = make(chan struct{})
+	signals           = defaultSignals
+	signalCh          = make(chan os.Signal, 1)
+	listening         bool
 )
 
 // RegisterPreShutdownHook registers a hook function that will be run before shutdown.
 func RegisterPreShutdownHook(hook func()) {
+	if hook == nil {
+		return
+	}
+	RegisterPreShutdownHookContext(func(context.Context) { hook() })
+}
+
+// RegisterPreShutdownHookContext is like [RegisterPreShutdownHook], but the hook receives a context that is cancelled
+// when the shutdown times out, see [RegisterShutdownContext].
+func RegisterPreShutdownHookContext(hook func(ctx context.Context)) {
 	if hook == nil {
 		return
 	}
@@ -44,6 +85,15 @@ func RegisterPreShutdownHook(hook func()) {
 
 // RegisterPostShutdownHook register a hook function that will be run after shutdown.
 func RegisterPostShutdownHook(hook func()) {
+	if hook == nil {
+		return
+	}
+	RegisterPostShutdownHookContext(func(context.Context) { hook() })
+}
+
+// RegisterPostShutdownHookContext is like [RegisterPostShutdownHook], but the hook receives a context that is
+// cancelled when the shutdown times out, see [RegisterShutdownContext].
+func RegisterPostShutdownHookContext(hook func(ctx context.Context)) {
 	if hook == nil {
 		return
 	}
@@ -52,11 +102,35 @@ func RegisterPostShutdownHook(hook func()) {
 	postShutdownHooks = append(postShutdownHooks, hook)
 }
 
-// RegisterShutdown registers a function that will run when the process receives a kill signal. To be precise, these
-// signals include [syscall.SIGINT], [syscall.SIGTERM] and [syscall.SIGQUIT].
+// RegisterCleanupHook registers a hook function that will be run after the post-shutdown hooks have finished and the
+// goroutine locks implemented in [glock] have been released. It's useful for flushing telemetry data like spans and
+// logs, which may still be produced by the application until then.
+func RegisterCleanupHook(hook func()) {
+	if hook == nil {
+		return
+	}
+	RegisterCleanupHookContext(func(context.Context) { hook() })
+}
+
+// RegisterCleanupHookContext is like [RegisterCleanupHook], but the hook receives a context that is cancelled when the
+// shutdown times out, see [RegisterShutdownContext].
+func RegisterCleanupHookContext(hook func(ctx context.Context)) {
+	if hook == nil {
+		return
+	}
+	hooksMutex.Lock()
+	defer hooksMutex.Unlock()
+	cleanupHooks = append(cleanupHooks, hook)
+}
+
+// RegisterShutdown registers a function that will run when the process receives a kill signal. By default, these
+// signals include [syscall.SIGINT], [syscall.SIGTERM] and [syscall.SIGQUIT], or [os.Interrupt] and [syscall.SIGTERM] on
+// Windows, which can be changed via [SetSignals]. Only the first registered function runs.
 //
 // There is also a timeout time to control the maximum running time of the function. If this time is exceeded, execution
-// will be forced to be interrupted.
+// will be forced to be interrupted. The timeouts of stages registered via [RegisterStage] are added to it. If the total
+// timeout is not positive, the deadline is exceeded as soon as the shutdown process starts, so the process exits
+// immediately.
 //
 // One common way of using RegisterShutdown is to register a function that stops your server.
 //
@@ -66,62 +140,338 @@ func RegisterShutdown(timeout time.Duration, shutdown func()) {
 	if shutdown == nil {
 		return
 	}
-	registerShutdownOnce.Do(func() {
-		go func() {
-			l := log.NewLogger("github.com/sainnhe/go-common/pkg/graceful")
-			signalCtx, signalCancel := signal.NotifyContext(context.Background(),
-				syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT)
-			defer signalCancel()
-
-			// Wait for signals and start graceful shutdown.
-			<-signalCtx.Done()
-			l.Info("Graceful shutdown started.")
-			startTime := time.Now()
-			timeoutCtx, timeoutCancel := context.WithTimeout(context.Background(), timeout)
-			defer timeoutCancel()
-
-			// Run shutdown function.
-			shutdownCtx, shutdownCancel := context.WithCancel(context.Background())
-			go func() {
-				// We must use defer to avoid panic when running shutdown() and hooks.
-				defer shutdownCancel()
-				defer util.Recover()
-
-				// Run hooks and the shutdown function.
-				hooksMutex.RLock()
-				for _, hook := range preShutdownHooks {
-					hook()
-				}
-				hooksMutex.RUnlock()
-				shutdown()
-				hooksMutex.RLock()
-				for _, hook := range postShutdownHooks {
-					hook()
-				}
-				hooksMutex.RUnlock()
-			}()
+	RegisterShutdownContext(timeout, func(context.Context) { shutdown() })
+}
 
-			// Wait for shutdown function.
-			select {
-			case <-shutdownCtx.Done():
-			case <-timeoutCtx.Done():
-				l.Error("Shutdown times out.", "cost", util.ToStr(time.Since(startTime)))
-				os.Exit(1)
-			}
+// RegisterShutdownContext is like [RegisterShutdown], but the function receives a context whose deadline is the
+// deadline of the whole shutdown process. The same context is passed to hooks registered via the Context variants, so
+// that they can stop their work cooperatively, e.g. by passing it to [net/http.Server.Shutdown], before the process is
+// forced to exit when the deadline is exceeded.
+func RegisterShutdownContext(timeout time.Duration, shutdown func(ctx context.Context)) {
+	if shutdown == nil {
+		return
+	}
+	hooksMutex.Lock()
+	if shutdownFunc == nil {
+		shutdownFunc = shutdown
+		shutdownTimeout = timeout
+	}
+	hooksMutex.Unlock()
+	startListening()
+}
+
+// RegisterStage registers a named shutdown stage that will run after the function registered via [RegisterShutdown]
+// and before the post-shutdown hooks. Like RegisterShutdown, it starts listening for kill signals.
+//
+// Stages run in the ascending order of stage numbers, e.g. a stage "stop-http" with number 10 runs before a stage
+// "close-db" with number 20. Stages with the same number run concurrently.
+//
+// The context passed to fn is cancelled after the timeout, or when the whole shutdown process times out. If fn doesn't
+// return by then, it's reported as timed out and the next stages start without waiting for it. A non-positive timeout
+// means that the stage has no deadline of its own, and is only bound by the deadline of the whole shutdown process. A
+// report of all stages is logged after they finish.
+func RegisterStage(name string, stage int, timeout time.Duration, fn func(ctx context.Context) error) {
+	if fn == nil {
+		return
+	}
+	hooksMutex.Lock()
+	stages = append(stages, &shutdownStage{name, stage, timeout, fn})
+	hooksMutex.Unlock()
+	startListening()
+}
+
+// SetSignals sets the signals that trigger the shutdown process, replacing the default ones. It can be called before or
+// after registering functions. If no signal is given, the shutdown process can only be triggered by [Shutdown].
+func SetSignals(sigs ...os.Signal) {
+	hooksMutex.Lock()
+	defer hooksMutex.Unlock()
+	signals = slices.Clone(sigs)
+	if listening {
+		notify()
+	}
+}
+
+// notify relays the configured signals to signalCh. The caller must hold hooksMutex.
+func notify() {
+	signal.Stop(signalCh)
+	// signal.Notify relays all signals if none is given.
+	if len(signals) > 0 {
+		signal.Notify(signalCh, signals...)
+	}
+}
 
-			// Wait for goroutine locks.
-			glCtx, glCancel := context.WithCancel(context.Background())
+// Shutdown triggers the shutdown process as if a kill signal is received, e.g. on fatal internal errors. The reason is
+// logged when the shutdown starts. It returns immediately, and only the first call or signal triggers the shutdown.
+// Use [Done] to wait for the shutdown to complete.
+//
+// If no function is registered via [RegisterShutdown] and no stage with a timeout is registered via [RegisterStage],
+// the shutdown process has no deadline.
+func Shutdown(reason string) {
+	startListening()
+	select {
+	case shutdownCh <- reason:
+	default:
+	}
+}
+
+// Done returns a channel that is closed when the shutdown process completes, i.e. after the cleanup hooks have run.
+// It's never closed if the shutdown times out, since the process exits in that case. The main goroutine can block on
+// it instead of sleeping forever, so that the process exits right after the shutdown.
+func Done() <-chan struct{} {
+	hooksMutex.RLock()
+	defer hooksMutex.RUnlock()
+	return doneCh
+}
+
+// startListening starts listening for kill signals in background if it hasn't started.
+func startListening() {
+	listenOnce.Do(func() {
+		state.Store(int32(StateRunning))
+		go listen()
+	})
+}
+
+// listen waits for kill signals or [Shutdown], and runs the shutdown process.
+func listen() {
+	l := log.NewLogger("github.com/sainnhe/go-common/pkg/graceful")
+	hooksMutex.Lock()
+	listening = true
+	notify()
+	hooksMutex.Unlock()
+	defer func() {
+		hooksMutex.Lock()
+		defer hooksMutex.Unlock()
+		listening = false
+		signal.Stop(signalCh)
+	}()
+
+	// Wait for signals and start graceful shutdown.
+	var reason string
+	select {
+	case sig := <-signalCh:
+		reason = sig.String()
+	case reason = <-shutdownCh:
+	}
+	state.Store(int32(StateDraining))
+	l.Info("Graceful shutdown started.", "reason", reason)
+	startTime := time.Now()
+	hooksMutex.RLock()
+	timeoutCtx, timeoutCancel := shutdownContext()
+	done := doneCh
+	hooksMutex.RUnlock()
+	defer timeoutCancel()
+
+	// Run shutdown function.
+	shutdownCtx, shutdownCancel := context.WithCancel(context.Background())
+	go func() {
+		// We must use defer to avoid panic when running shutdown() and hooks.
+		defer shutdownCancel()
+		defer util.Recover()
+
+		// Run hooks, the shutdown function and stages. They run without holding hooksMutex, so that they can register
+		// other hooks.
+		hooksMutex.RLock()
+		preHooks := slices.Clone(preShutdownHooks)
+		shutdown := shutdownFunc
+		hooksMutex.RUnlock()
+		for _, hook := range preHooks {
+			hook(timeoutCtx)
+		}
+		if shutdown != nil {
+			shutdown(timeoutCtx)
+		}
+		logStageResults(l, runStages(timeoutCtx))
+		hooksMutex.RLock()
+		postHooks := slices.Clone(postShutdownHooks)
+		hooksMutex.RUnlock()
+		for _, hook := range postHooks {
+			hook(timeoutCtx)
+		}
+	}()
+
+	// Wait for shutdown function.
+	select {
+	case <-shutdownCtx.Done():
+	case <-timeoutCtx.Done():
+		l.Error("Shutdown times out.", "cost", util.ToStr(time.Since(startTime)))
+		os.Exit(1)
+	}
+
+	// Wait for goroutine locks.
+	glCtx, glCancel := context.WithCancel(context.Background())
+	go func() {
+		defer glCancel()
+		glock.Wait()
+	}()
+	select {
+	case <-glCtx.Done():
+	case <-timeoutCtx.Done():
+		l.Error("Wait for goroutine locks times out.", "cost", util.ToStr(time.Since(startTime)))
+		for _, h := range glock.Snapshot() {
+			l.Error("Goroutine lock is still held.", "name", h.Name, "held", util.ToStr(time.Since(h.AcquiredAt)),
+				"stack", h.Stack)
+		}
+		os.Exit(1)
+	}
+
+	// Run cleanup hooks.
+	cleanupCtx, cleanupCancel := context.WithCancel(context.Background())
+	go func() {
+		defer cleanupCancel()
+		defer util.Recover()
+		runCleanupHooks(timeoutCtx)
+	}()
+	select {
+	case <-cleanupCtx.Done():
+		l.Info("Graceful shutdown finish.", "cost", util.ToStr(time.Since(startTime)))
+		// Write the records queued by the async log writer, including the one above.
+		if err := log.Flush(timeoutCtx); err != nil {
+			l.Error("Flush logs failed.", constant.LogAttrError, err)
+		}
+		state.Store(int32(StateStopped))
+		close(done)
+	case <-timeoutCtx.Done():
+		l.Error("Cleanup times out.", "cost", util.ToStr(time.Since(startTime)))
+		os.Exit(1)
+	}
+}
+
+// shutdownContext returns the context of the whole shutdown process. If a function is registered via
+// [RegisterShutdown], its deadline is the sum of the shutdown timeout and the timeouts of stages, which is exceeded
+// immediately if it's not positive. Otherwise, the context has no deadline unless stages have timeouts. The
+// caller must hold hooksMutex.
+func shutdownContext() (context.Context, context.CancelFunc) {
+	timeout := shutdownTimeout + stagesTimeout()
+	if timeout > 0 || shutdownFunc != nil {
+		return context.WithTimeout(context.Background(), timeout)
+	}
+	return context.WithCancel(context.Background())
+}
+
+// sortedStages returns the registered stages grouped by stage numbers in ascending order. The caller must hold
+// hooksMutex.
+func sortedStages() [][]*shutdownStage {
+	sorted := slices.Clone(stages)
+	slices.SortStableFunc(sorted, func(a, b *shutdownStage) int {
+		return cmp.Compare(a.stage, b.stage)
+	})
+	groups := [][]*shutdownStage{}
+	for i, s := range sorted {
+		if i == 0 || s.stage != sorted[i-1].stage {
+			groups = append(groups, nil)
+		}
+		groups[len(groups)-1] = append(groups[len(groups)-1], s)
+	}
+	return groups
+}
+
+// stagesTimeout returns the maximum running time of all stages, which is the sum of the maximum timeout of each stage
+// number. The caller must hold hooksMutex.
+func stagesTimeout() time.Duration {
+	var total time.Duration
+	for _, group := range sortedStages() {
+		var longest time.Duration
+		for _, s := range group {
+			longest = max(longest, s.timeout)
+		}
+		total += longest
+	}
+	return total
+}
+
+// runStages runs the registered stages with contexts derived from ctx, and returns their results in the order of stage
+// numbers and registration.
+func runStages(ctx context.Context) []stageResult {
+	hooksMutex.RLock()
+	groups := sortedStages()
+	hooksMutex.RUnlock()
+	results := []stageResult{}
+	for _, group := range groups {
+		groupResults := make([]stageResult, len(group))
+		wg := sync.WaitGroup{}
+		for i, s := range group {
+			wg.Add(1)
 			go func() {
-				defer glCancel()
-				glock.Wait()
+				defer wg.Done()
+				groupResults[i] = s.run(ctx)
 			}()
-			select {
-			case <-glCtx.Done():
-				l.Info("Graceful shutdown finish.", "cost", util.ToStr(time.Since(startTime)))
-			case <-timeoutCtx.Done():
-				l.Error("Wait for goroutine locks times out.", "cost", util.ToStr(time.Since(startTime)))
-				os.Exit(1)
+		}
+		wg.Wait()
+		results = append(results, groupResults...)
+	}
+	return results
+}
+
+// logStageResults logs the report of stages, which is an error if any stage failed.
+func logStageResults(l *slog.Logger, results []stageResult) {
+	if len(results) == 0 {
+		return
+	}
+	level := slog.LevelInfo
+	attrs := make([]any, 0, len(results))
+	for _, r := range results {
+		group := []any{"stage", r.stage, "cost", util.ToStr(r.cost)}
+		if r.err != nil {
+			level = slog.LevelError
+			group = append(group, constant.LogAttrError, r.err)
+		}
+		attrs = append(attrs, slog.Group(r.name, group...))
+	}
+	l.Log(context.Background(), level, "Shutdown stages finished.", attrs...)
+}
+
+// shutdownStage is a stage registered via [RegisterStage].
+type shutdownStage struct {
+	name    string
+	stage   int
+	timeout time.Duration
+	fn      func(ctx context.Context) error
+}
+
+// stageResult is the result of a stage. err is [context.DeadlineExceeded] if the stage times out, or an error wrapping
+// [errPanic] if it panics.
+type stageResult struct {
+	name  string
+	stage int
+	cost  time.Duration
+	err   error
+}
+
+// errPanic indicates that a stage panics.
+var errPanic = errors.New("panic")
+
+// run runs the stage until it returns or times out, where ctx is the context of the whole shutdown process.
+func (s *shutdownStage) run(ctx context.Context) stageResult {
+	start := time.Now()
+	ctx, cancel := context.WithCancel(ctx)
+	if s.timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, s.timeout)
+	}
+	defer cancel()
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- fmt.Errorf("%w: %+v", errPanic, r)
 			}
 		}()
-	})
+		done <- s.fn(ctx)
+	}()
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	return stageResult{s.name, s.stage, time.Since(start), err}
+}
+
+// runCleanupHooks runs the cleanup hooks with ctx in the reverse order of registration.
+func runCleanupHooks(ctx context.Context) {
+	hooksMutex.RLock()
+	hooks := slices.Clone(cleanupHooks)
+	hooksMutex.RUnlock()
+	for i := len(hooks) - 1; i >= 0; i-- {
+		hooks[i](ctx)
+	}
 }
@@ -1,15 +1,262 @@
 package graceful // nolint:testpackage
 
-import "testing"
+import (
+	"context"
+	"errors"
+	"os"
+	"runtime"
+	"slices"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/sainnhe/go-common/pkg/log"
+)
 
 func TestGraceful_nilHooks(t *testing.T) {
 	t.Parallel()
 
 	RegisterPreShutdownHook(nil)
 	RegisterPostShutdownHook(nil)
+	RegisterCleanupHook(nil)
+
+	if len(preShutdownHooks)+len(postShutdownHooks)+len(cleanupHooks) != 0 {
+		t.Fatalf("Expect len(preShutdownHooks) + len(postShutdownHooks) + len(cleanupHooks) == 0, got %d",
+			len(preShutdownHooks)+len(postShutdownHooks)+len(cleanupHooks))
+	}
+}
+
+func TestGraceful_cleanupHooks(t *testing.T) { // nolint:paralleltest
+	t.Cleanup(func() {
+		cleanupHooks = nil
+	})
+
+	type ctxKey struct{}
+	ctx := context.WithValue(context.Background(), ctxKey{}, "shutdown")
+	order := []int{}
+	RegisterCleanupHook(func() { order = append(order, 1) })
+	RegisterCleanupHookContext(func(ctx context.Context) {
+		if ctx.Value(ctxKey{}) != "shutdown" {
+			t.Error("Expect the context of the shutdown process")
+		}
+		order = append(order, 2)
+	})
+	RegisterCleanupHookContext(nil)
+	// Hooks can register other hooks without deadlocks, which run in the next shutdown only.
+	RegisterCleanupHook(func() { RegisterCleanupHook(func() { order = append(order, 4) }) })
+	runCleanupHooks(ctx)
+
+	if !slices.Equal(order, []int{2, 1}) {
+		t.Fatalf("Expect cleanup hooks to run in reverse order, got %v", order)
+	}
+}
+
+func TestGraceful_shutdownContext(t *testing.T) { // nolint:paralleltest
+	t.Cleanup(func() {
+		hooksMutex.Lock()
+		shutdownFunc = nil
+		shutdownTimeout = 0
+		stages = nil
+		hooksMutex.Unlock()
+	})
+
+	hooksMutex.Lock()
+	defer hooksMutex.Unlock()
+
+	// No deadline without a shutdown function or stage timeouts.
+	ctx, cancel := shutdownContext()
+	defer cancel()
+	if _, ok := ctx.Deadline(); ok {
+		t.Fatal("Expect no deadline")
+	}
+
+	// A shutdown function registered with a zero timeout exits immediately.
+	shutdownFunc = func(context.Context) {}
+	ctx, cancel = shutdownContext()
+	defer cancel()
+	if !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		t.Fatalf("Expect error %+v, got %+v", context.DeadlineExceeded, ctx.Err())
+	}
+
+	// Stage timeouts are added to the shutdown timeout.
+	shutdownTimeout = time.Second
+	stages = []*shutdownStage{{"stage", 0, time.Minute, nil}}
+	ctx, cancel = shutdownContext()
+	defer cancel()
+	if deadline, ok := ctx.Deadline(); !ok || time.Until(deadline) <= time.Minute {
+		t.Fatalf("Expect deadline after 1m1s, got %s", deadline)
+	}
+}
+
+func TestGraceful_stages(t *testing.T) { // nolint:paralleltest
+	t.Cleanup(func() {
+		hooksMutex.Lock()
+		stages = nil
+		hooksMutex.Unlock()
+	})
+
+	mu := sync.Mutex{}
+	order := []string{}
+	stage := func(name string, d time.Duration, err error) func(ctx context.Context) error {
+		return func(ctx context.Context) error {
+			select {
+			case <-time.After(d):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			order = append(order, name)
+			return err
+		}
+	}
+	failure := errors.New("failure")
+	RegisterStage("nil", 0, time.Second, nil)
+	RegisterStage("close-db", 20, time.Second, stage("close-db", 0, failure))
+	RegisterStage("no-deadline", 20, 0, stage("no-deadline", 10*time.Millisecond, nil))
+	RegisterStage("stop-grpc", 10, time.Second, stage("stop-grpc", 50*time.Millisecond, nil))
+	RegisterStage("stop-http", 10, 2*time.Second, stage("stop-http", 0, nil))
+	RegisterStage("flush", 30, 10*time.Millisecond, stage("flush", time.Hour, nil))
+	RegisterStage("panic", 30, time.Second, func(context.Context) error {
+		panic("oops")
+	})
+
+	hooksMutex.RLock()
+	timeout := stagesTimeout()
+	hooksMutex.RUnlock()
+	if want := 4 * time.Second; timeout != want {
+		t.Fatalf("Expect timeout %s, got %s", want, timeout)
+	}
+
+	results := runStages(context.Background())
+	type result struct {
+		name  string
+		stage int
+		err   error
+	}
+	got := []result{}
+	for _, r := range results {
+		got = append(got, result{r.name, r.stage, r.err})
+	}
+	want := []result{
+		{"stop-grpc", 10, nil},
+		{"stop-http", 10, nil},
+		{"close-db", 20, failure},
+		{"no-deadline", 20, nil},
+		{"flush", 30, context.DeadlineExceeded},
+		{"panic", 30, errPanic},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("Expect results %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i].name != want[i].name || got[i].stage != want[i].stage || !errors.Is(got[i].err, want[i].err) {
+			t.Fatalf("Expect results %v, got %v", want, got)
+		}
+	}
+	// Stages with the same number run concurrently, and the next stages wait for them.
+	// Stages without timeouts are not cancelled before they run.
+	if !slices.Equal(order, []string{"stop-http", "stop-grpc", "close-db", "no-deadline"}) {
+		t.Fatalf("Unexpected order %v", order)
+	}
+	logStageResults(log.GetGlobalLogger(), results)
+}
+
+func TestGraceful_stagesCancelled(t *testing.T) { // nolint:paralleltest
+	t.Cleanup(func() {
+		hooksMutex.Lock()
+		stages = nil
+		hooksMutex.Unlock()
+	})
+
+	// Stages are cancelled when the whole shutdown process times out.
+	RegisterStage("wait", 0, time.Hour, func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	results := runStages(ctx)
+	if len(results) != 1 || !errors.Is(results[0].err, context.DeadlineExceeded) {
+		t.Fatalf("Expect error %+v, got %+v", context.DeadlineExceeded, results)
+	}
+}
+
+func TestGraceful_shutdown(t *testing.T) { // nolint:paralleltest
+	t.Cleanup(func() {
+		hooksMutex.Lock()
+		preShutdownHooks = nil
+		cleanupHooks = nil
+		listenOnce = sync.Once{}
+		state.Store(int32(StateNotStarted))
+		shutdownCh = make(chan string, 1)
+		doneCh = make(chan struct{})
+		hooksMutex.Unlock()
+	})
 
-	if len(preShutdownHooks)+len(postShutdownHooks) != 0 {
-		t.Fatalf("Expect len(preShutdownHooks) + len(postShutdownHooks) == 0, got %d",
-			len(preShutdownHooks)+len(postShutdownHooks))
+	order := []string{}
+	RegisterPreShutdownHook(func() { order = append(order, "pre-shutdown") })
+	RegisterCleanupHook(func() { order = append(order, "cleanup") })
+
+	// Only the first call triggers the shutdown, and the others return immediately.
+	Shutdown("test")
+	Shutdown("test again")
+	select {
+	case <-Done():
+	case <-time.After(time.Second):
+		t.Fatal("Expect shutdown to complete")
+	}
+	if !slices.Equal(order, []string{"pre-shutdown", "cleanup"}) {
+		t.Fatalf("Unexpected order %v", order)
+	}
+	if s := GetState(); s != StateStopped {
+		t.Fatalf("Expect state %s, got %s", StateStopped, s)
+	}
+}
+
+func TestGraceful_setSignals(t *testing.T) { // nolint:paralleltest
+	if runtime.GOOS == "windows" {
+		t.Skip("Sending signals is not supported on Windows")
+	}
+	t.Cleanup(func() {
+		SetSignals(defaultSignals...)
+		hooksMutex.Lock()
+		shutdownFunc = nil
+		shutdownTimeout = 0
+		listenOnce = sync.Once{}
+		state.Store(int32(StateNotStarted))
+		shutdownCh = make(chan string, 1)
+		doneCh = make(chan struct{})
+		hooksMutex.Unlock()
+	})
+
+	// Signals can be changed after the listener starts.
+	called := false
+	RegisterShutdown(time.Second, func() { called = true })
+	for {
+		hooksMutex.RLock()
+		started := listening
+		hooksMutex.RUnlock()
+		if started {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	SetSignals(syscall.SIGHUP)
+	p, err := os.FindProcess(os.Getpid())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = p.Signal(syscall.SIGHUP); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-Done():
+	case <-time.After(time.Second):
+		t.Fatal("Expect shutdown to complete")
+	}
+	if !called {
+		t.Fatal("Expect shutdown function to be called")
 	}
 }
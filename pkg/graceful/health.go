@@ -0,0 +1,78 @@
+package graceful
+
+import (
+	"io"
+	"net/http"
+	"sync/atomic"
+)
+
+// State is the state of the shutdown process.
+type State int32
+
+const (
+	// StateNotStarted means that no function is registered yet, so kill signals are not captured.
+	StateNotStarted State = iota
+
+	// StateRunning means that kill signals are being captured, and the shutdown process hasn't started.
+	StateRunning
+
+	// StateDraining means that the shutdown process has started.
+	StateDraining
+
+	// StateStopped means that the shutdown process has completed.
+	StateStopped
+)
+
+var state atomic.Int32
+
+// String returns the name of the state.
+func (s State) String() string {
+	switch s {
+	case StateNotStarted:
+		return "not_started"
+	case StateRunning:
+		return "running"
+	case StateDraining:
+		return "draining"
+	case StateStopped:
+		return "stopped"
+	default:
+		return "unknown"
+	}
+}
+
+// GetState returns the current state of the shutdown process.
+func GetState() State {
+	return State(state.Load())
+}
+
+// Readyz returns a readiness probe handler, which responds with 200 OK before the shutdown process starts, and 503
+// Service Unavailable after that, so that load balancers stop sending traffic to the process while it's draining. The
+// body is the name of the current state.
+func Readyz() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		s := GetState()
+		writeState(w, s, s < StateDraining)
+	})
+}
+
+// Livez returns a liveness probe handler, which responds with 200 OK until the shutdown process completes, and 503
+// Service Unavailable after that. Unlike [Readyz], it doesn't fail while draining, so that the process isn't restarted
+// in the middle of the shutdown process. The body is the name of the current state.
+func Livez() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		s := GetState()
+		writeState(w, s, s < StateStopped)
+	})
+}
+
+func writeState(w http.ResponseWriter, s State, ok bool) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	if ok {
+		w.WriteHeader(http.StatusOK)
+	} else {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	_, _ = io.WriteString(w, s.String())
+}
@@ -0,0 +1,43 @@
+package graceful // nolint:testpackage
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHealth(t *testing.T) { // nolint:paralleltest
+	t.Cleanup(func() {
+		state.Store(int32(StateNotStarted))
+	})
+
+	tests := []struct {
+		state        State
+		readyzStatus int
+		livezStatus  int
+	}{
+		{StateNotStarted, http.StatusOK, http.StatusOK},
+		{StateRunning, http.StatusOK, http.StatusOK},
+		{StateDraining, http.StatusServiceUnavailable, http.StatusOK},
+		{StateStopped, http.StatusServiceUnavailable, http.StatusServiceUnavailable},
+	}
+	for _, tt := range tests {
+		state.Store(int32(tt.state))
+		if s := GetState(); s != tt.state {
+			t.Fatalf("Expect state %s, got %s", tt.state, s)
+		}
+		for _, c := range []struct {
+			h      http.Handler
+			status int
+		}{{Readyz(), tt.readyzStatus}, {Livez(), tt.livezStatus}} {
+			w := httptest.NewRecorder()
+			c.h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+			if w.Code != c.status || w.Body.String() != tt.state.String() {
+				t.Fatalf("Expect %d %s, got %d %s", c.status, tt.state, w.Code, w.Body.String())
+			}
+		}
+	}
+	if s := State(-1).String(); s != "unknown" {
+		t.Fatalf("Expect unknown, got %s", s)
+	}
+}
@@ -0,0 +1,11 @@
+//go:build !windows
+
+package graceful
+
+import (
+	"os"
+	"syscall"
+)
+
+// defaultSignals are the signals that trigger the shutdown process by default.
+var defaultSignals = []os.Signal{syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT}
@@ -0,0 +1,10 @@
+package graceful
+
+import (
+	"os"
+	"syscall"
+)
+
+// defaultSignals are the signals that trigger the shutdown process by default. On Windows, Ctrl+C is delivered as
+// [os.Interrupt], and closing the console, logging off or shutting down the system are delivered as [syscall.SIGTERM].
+var defaultSignals = []os.Signal{os.Interrupt, syscall.SIGTERM}
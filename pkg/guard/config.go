@@ -0,0 +1,23 @@
+package guard
+
+// Config defines the config model for request guards. Zero values disable the corresponding guards, so that different
+// routes can be wrapped by middlewares initialized with different configs.
+type Config struct {
+	// Methods are the allowed request methods. Empty means all methods are allowed.
+	Methods []string `json:"methods" yaml:"methods" toml:"methods" xml:"methods" env:"GUARD_METHODS" default:"[]"`
+
+	// ContentTypes are the allowed media types of request bodies, e.g. "application/json". Parameters like charset are
+	// ignored, and a type like "image/*" matches all its subtypes. Requests without bodies are not checked. Empty means
+	// all media types are allowed.
+	ContentTypes []string `json:"content_types" yaml:"content_types" toml:"content_types" xml:"content_types" env:"GUARD_CONTENT_TYPES" default:"[]"` // nolint:lll
+
+	// MaxBodyBytes is the maximum size of request bodies in bytes. Set to 0 to disable the limit.
+	MaxBodyBytes int64 `json:"max_body_bytes" yaml:"max_body_bytes" toml:"max_body_bytes" xml:"max_body_bytes" env:"GUARD_MAX_BODY_BYTES" default:"0"` // nolint:lll
+
+	// ReadTimeoutMs is the deadline for reading request bodies in milliseconds, counted from when the request is
+	// received by the middleware. Set to 0 to disable the timeout.
+	ReadTimeoutMs int64 `json:"read_timeout_ms" yaml:"read_timeout_ms" toml:"read_timeout_ms" xml:"read_timeout_ms" env:"GUARD_READ_TIMEOUT_MS" default:"0"` // nolint:lll
+
+	// TimeoutMs is the deadline of the request context in milliseconds. Set to 0 to disable the timeout.
+	TimeoutMs int64 `json:"timeout_ms" yaml:"timeout_ms" toml:"timeout_ms" xml:"timeout_ms" env:"GUARD_TIMEOUT_MS" default:"0"` // nolint:lll
+}
@@ -0,0 +1,165 @@
+/*
+Package guard implements a middleware that enforces per-route request guards, including allowed methods, allowed
+content types, body size limits and timeouts, so that handlers don't need to duplicate these checks.
+
+Rejected requests are responded with [respond.Error]:
+
+  - 405 Method Not Allowed with the Allow header if the method is not allowed.
+  - 415 Unsupported Media Type if the media type of the body is not allowed.
+  - 413 Content Too Large if the Content-Length header exceeds the limit. Bodies without Content-Length are limited by
+    [http.MaxBytesReader], which makes reads fail with [*http.MaxBytesError] once the limit is exceeded.
+  - 503 Service Unavailable if the request context times out before the handler writes a response.
+
+Each route can be wrapped with a middleware initialized with its own config:
+
+	upload, err := guard.NewMiddleware(&guard.Config{
+		Methods:      []string{http.MethodPost},
+		ContentTypes: []string{"image/*"},
+		MaxBodyBytes: 10 << 20,
+		TimeoutMs:    30000,
+	})
+
+	mux := http.NewServeMux()
+	mux.Handle("/avatar", upload(avatarHandler))
+*/
+package guard
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/sainnhe/go-common/pkg/constant"
+	"github.com/sainnhe/go-common/pkg/log"
+	"github.com/sainnhe/go-common/pkg/respond"
+)
+
+const pkgName = "github.com/sainnhe/go-common/pkg/guard"
+
+var (
+	// ErrMethodNotAllowed indicates that the request method is not allowed.
+	ErrMethodNotAllowed = errors.New("method not allowed")
+
+	// ErrUnsupportedMediaType indicates that the media type of the request body is not allowed.
+	ErrUnsupportedMediaType = errors.New("unsupported media type")
+
+	// ErrBodyTooLarge indicates that the request body exceeds [Config.MaxBodyBytes].
+	ErrBodyTooLarge = errors.New("request body too large")
+
+	// ErrTimeout indicates that the request context times out before a response is written.
+	ErrTimeout = errors.New("request timeout")
+)
+
+/*
+NewMiddleware initializes a request guard middleware.
+
+Params:
+  - cfg: The config.
+
+Returns:
+  - func(http.Handler) http.Handler: The middleware.
+  - error: [constant.ErrNilDeps] if cfg is nil.
+*/
+func NewMiddleware(cfg *Config) (func(http.Handler) http.Handler, error) {
+	if cfg == nil {
+		return nil, constant.ErrNilDeps
+	}
+	l := log.NewLogger(pkgName)
+	allow := strings.Join(cfg.Methods, ", ")
+	writeError := func(w http.ResponseWriter, r *http.Request, status int, err error) {
+		if writeErr := respond.Error(w, r, status, err); writeErr != nil {
+			l.ErrorContext(r.Context(), "Write error response failed.", constant.LogAttrError, writeErr)
+		}
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if len(cfg.Methods) > 0 && !slices.Contains(cfg.Methods, r.Method) {
+				w.Header().Set("Allow", allow)
+				writeError(w, r, http.StatusMethodNotAllowed, fmt.Errorf("%w: %s", ErrMethodNotAllowed, r.Method))
+				return
+			}
+			if len(cfg.ContentTypes) > 0 && hasBody(r) {
+				if mediaType := r.Header.Get("Content-Type"); !allowMediaType(cfg.ContentTypes, mediaType) {
+					writeError(w, r, http.StatusUnsupportedMediaType,
+						fmt.Errorf("%w: %q", ErrUnsupportedMediaType, mediaType))
+					return
+				}
+			}
+			if cfg.MaxBodyBytes > 0 {
+				if r.ContentLength > cfg.MaxBodyBytes {
+					writeError(w, r, http.StatusRequestEntityTooLarge,
+						fmt.Errorf("%w: limit is %d bytes", ErrBodyTooLarge, cfg.MaxBodyBytes))
+					return
+				}
+				r.Body = http.MaxBytesReader(w, r.Body, cfg.MaxBodyBytes)
+			}
+			if cfg.ReadTimeoutMs > 0 {
+				// Not all response writers support read deadlines, e.g. httptest.ResponseRecorder.
+				deadline := time.Now().Add(time.Duration(cfg.ReadTimeoutMs) * time.Millisecond)
+				_ = http.NewResponseController(w).SetReadDeadline(deadline)
+			}
+			if cfg.TimeoutMs <= 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ctx, cancel := context.WithTimeout(r.Context(), time.Duration(cfg.TimeoutMs)*time.Millisecond)
+			defer cancel()
+			rw := &responseWriter{w, false}
+			next.ServeHTTP(rw, r.WithContext(ctx))
+			if !rw.wroteHeader && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				writeError(w, r, http.StatusServiceUnavailable, ErrTimeout)
+			}
+		})
+	}, nil
+}
+
+// hasBody reports whether the request may have a body.
+func hasBody(r *http.Request) bool {
+	return r.Body != nil && r.Body != http.NoBody && r.ContentLength != 0
+}
+
+// allowMediaType reports whether the media type of the Content-Type header is allowed.
+func allowMediaType(allowed []string, contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	for _, a := range allowed {
+		a = strings.ToLower(a)
+		if prefix, ok := strings.CutSuffix(a, "/*"); ok {
+			if strings.HasPrefix(mediaType, prefix+"/") {
+				return true
+			}
+		} else if mediaType == a {
+			return true
+		}
+	}
+	return false
+}
+
+type responseWriter struct {
+	http.ResponseWriter
+	wroteHeader bool
+}
+
+func (w *responseWriter) WriteHeader(status int) {
+	w.wroteHeader = true
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *responseWriter) Write(b []byte) (int, error) {
+	w.wroteHeader = true
+	return w.ResponseWriter.Write(b)
+}
+
+// Unwrap returns the underlying response writer, which is used by [http.ResponseController].
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,143 @@
+package guard_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sainnhe/go-common/pkg/constant"
+	"github.com/sainnhe/go-common/pkg/encoding"
+	"github.com/sainnhe/go-common/pkg/guard"
+)
+
+func TestNewMiddleware(t *testing.T) {
+	t.Parallel()
+
+	if _, err := guard.NewMiddleware(nil); !errors.Is(err, constant.ErrNilDeps) {
+		t.Fatalf("Expect error %+v, got %+v", constant.ErrNilDeps, err)
+	}
+
+	// Default config disables all guards.
+	cfg, err := encoding.LoadConfig[guard.Config](nil, encoding.TypeNil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := newHandler(t, cfg, echo)
+	if w := serve(h, http.MethodPatch, "text/plain", strings.Repeat("a", 1024)); w.Code != http.StatusOK {
+		t.Fatalf("Unexpected response %d %s", w.Code, w.Body)
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	t.Parallel()
+
+	h := newHandler(t, &guard.Config{
+		Methods:      []string{http.MethodGet, http.MethodPost},
+		ContentTypes: []string{"application/json", "image/*"},
+		MaxBodyBytes: 8,
+	}, echo)
+	tests := []struct {
+		name        string
+		method      string
+		contentType string
+		body        string
+		status      int
+	}{
+		{"Allowed", http.MethodPost, "application/json; charset=utf-8", "{}", http.StatusOK},
+		{"Wildcard media type", http.MethodPost, "image/png", "png", http.StatusOK},
+		{"No body", http.MethodGet, "", "", http.StatusOK},
+		{"Method not allowed", http.MethodDelete, "", "", http.StatusMethodNotAllowed},
+		{"Unsupported media type", http.MethodPost, "text/plain", "{}", http.StatusUnsupportedMediaType},
+		{"Missing media type", http.MethodPost, "", "{}", http.StatusUnsupportedMediaType},
+		{"Body too large", http.MethodPost, "application/json", "[1,2,3,4,5]", http.StatusRequestEntityTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			w := serve(h, tt.method, tt.contentType, tt.body)
+			if w.Code != tt.status {
+				t.Fatalf("Expect status %d, got %d %s", tt.status, w.Code, w.Body)
+			}
+			if tt.status == http.StatusMethodNotAllowed && w.Header().Get("Allow") != "GET, POST" {
+				t.Fatalf("Unexpected headers %v", w.Header())
+			}
+		})
+	}
+
+	// Bodies without Content-Length are limited while reading.
+	r := httptest.NewRequestWithContext(context.Background(), http.MethodPost, "/",
+		io.MultiReader(strings.NewReader("[1,2,3,4,5]")))
+	r.Header.Set("Content-Type", "application/json")
+	r.ContentLength = -1
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("Unexpected response %d %s", w.Code, w.Body)
+	}
+}
+
+func TestMiddleware_timeout(t *testing.T) {
+	t.Parallel()
+
+	h := newHandler(t, &guard.Config{TimeoutMs: 10, ReadTimeoutMs: 1000}, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Has("fast") {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		<-r.Context().Done()
+	})
+
+	r := httptest.NewRequestWithContext(context.Background(), http.MethodGet, "/?fast", nil)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("Unexpected response %d %s", w.Code, w.Body)
+	}
+
+	start := time.Now()
+	w = serve(h, http.MethodGet, "", "")
+	if w.Code != http.StatusServiceUnavailable || time.Since(start) > time.Second {
+		t.Fatalf("Unexpected response %d %s", w.Code, w.Body)
+	}
+}
+
+// echo writes the request body back, and responds with 413 if the body exceeds the limit.
+func echo(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if maxBytesErr := new(http.MaxBytesError); errors.As(err, &maxBytesErr) {
+		w.WriteHeader(http.StatusRequestEntityTooLarge)
+		return
+	}
+	_, _ = w.Write(body)
+}
+
+func newHandler(t *testing.T, cfg *guard.Config, h http.HandlerFunc) http.Handler {
+	t.Helper()
+
+	mw, err := guard.NewMiddleware(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return mw(h)
+}
+
+func serve(h http.Handler, method, contentType, body string) *httptest.ResponseRecorder {
+	var reader io.Reader
+	if len(body) > 0 {
+		reader = strings.NewReader(body)
+	}
+	r := httptest.NewRequestWithContext(context.Background(), method, "/", reader)
+	if len(contentType) > 0 {
+		r.Header.Set("Content-Type", contentType)
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
@@ -0,0 +1,15 @@
+package inbox
+
+// Config defines the config model for inbox.
+type Config struct {
+	// Table is the name of the inbox table.
+	Table string `json:"table" yaml:"table" toml:"table" xml:"table" env:"INBOX_TABLE" default:"inbox"`
+
+	// RetentionMs is how long processed message IDs are kept in milliseconds. It should be longer than the maximum
+	// redelivery delay of the message queue, otherwise duplicates may be processed again after pruning.
+	RetentionMs int64 `json:"retention_ms" yaml:"retention_ms" toml:"retention_ms" xml:"retention_ms" env:"INBOX_RETENTION_MS" default:"604800000"` // nolint:lll
+
+	// PruneIntervalMs is the interval of pruning expired message IDs in milliseconds. Set to 0 to disable periodic
+	// pruning, in which case [Service.Prune] should be called manually.
+	PruneIntervalMs int64 `json:"prune_interval_ms" yaml:"prune_interval_ms" toml:"prune_interval_ms" xml:"prune_interval_ms" env:"INBOX_PRUNE_INTERVAL_MS" default:"3600000"` // nolint:lll
+}
@@ -0,0 +1,189 @@
+//go:generate mockgen -write_package_comment=false -source=inbox.go -destination=inbox_mock.go -package inbox
+
+/*
+Package inbox implements the transactional inbox pattern for exactly-once message processing.
+
+Message queues usually deliver messages at least once, which means consumers may receive the same message multiple
+times. With a transactional inbox, consumers record the IDs of processed messages in the same database transaction as
+their side effects, so that a redelivered message is detected and skipped, and a failed transaction can be retried
+safely.
+
+The inbox table should be created in advance, for example in PostgreSQL:
+
+	CREATE TABLE inbox (
+		id VARCHAR(255) PRIMARY KEY,
+		processed_time TIMESTAMPTZ NOT NULL
+	);
+	CREATE INDEX inbox_processed_time_idx ON inbox (processed_time);
+
+Processed message IDs are pruned periodically after the retention time, see [Config].
+*/
+package inbox
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/sainnhe/go-common/pkg/clock"
+	"github.com/sainnhe/go-common/pkg/constant"
+	"github.com/sainnhe/go-common/pkg/db"
+	"github.com/sainnhe/go-common/pkg/log"
+	"github.com/sainnhe/go-common/pkg/util"
+)
+
+const pkgName = "github.com/sainnhe/go-common/pkg/inbox"
+
+// Handler handles a message within the given transaction. The transaction is committed if nil is returned, and rolled
+// back otherwise.
+type Handler func(ctx context.Context, tx *sqlx.Tx) error
+
+// Service is the inbox service.
+type Service interface {
+	// Process records the message ID and runs the handler in the same transaction.
+	//
+	// If the message has been processed, the handler won't run and processed will be false. If the handler returns an
+	// error, the transaction is rolled back so that the message can be processed again on redelivery.
+	Process(ctx context.Context, msgID string, handle Handler) (processed bool, err error)
+
+	// Prune deletes the message IDs that are processed before the retention time, and returns the number of deleted
+	// message IDs.
+	Prune(ctx context.Context) (int64, error)
+}
+
+type serviceImpl struct {
+	pool       *sqlx.DB
+	cfg        *Config
+	clk        clock.Clock
+	l          *slog.Logger
+	insertStmt string
+	pruneStmt  string
+}
+
+// Option is the option used to customize the inbox service.
+type Option func(s *serviceImpl)
+
+// WithClock sets the clock used to record processed time and prune expired message IDs. Defaults to [clock.Real].
+func WithClock(clk clock.Clock) Option {
+	return func(s *serviceImpl) {
+		if clk != nil {
+			s.clk = clk
+		}
+	}
+}
+
+// NewService initializes a new inbox service. Supported drivers are PostgreSQL, SQLite and MySQL, otherwise
+// [db.ErrUnsupportedDriver] will be returned.
+//
+// If [Config.PruneIntervalMs] is positive, expired message IDs are pruned in background until cleanup is called. It's
+// safe to call cleanup multiple times.
+func NewService(cfg *Config, pool *sqlx.DB, opts ...Option) (s Service, cleanup func(), err error) {
+	if cfg == nil || pool == nil {
+		err = constant.ErrNilDeps
+		return
+	}
+	sb := db.NewStmtBuilder(cfg.Table, pool.DriverName())
+	if sb == nil {
+		err = db.ErrUnsupportedDriver
+		return
+	}
+
+	// Skip duplicated message IDs without failing the transaction.
+	insertStmt := sb.BuildMappedInsertStmt([]db.KV{{Key: "id", Val: db.Placeholder}, {Key: "processed_time",
+		Val: db.Placeholder}})
+	switch pool.DriverName() {
+	case "postgres", "pgx", "sqlite3":
+		insertStmt += " ON CONFLICT DO NOTHING"
+	case "mysql":
+		insertStmt = "INSERT IGNORE" + insertStmt[len("INSERT"):]
+	default:
+		err = db.ErrUnsupportedDriver
+		return
+	}
+
+	impl := &serviceImpl{
+		pool,
+		cfg,
+		clock.Real(),
+		log.NewLogger(pkgName),
+		insertStmt,
+		sb.BuildDeleteCondStmt(db.Cmp("processed_time", "<", db.Placeholder)),
+	}
+	for _, opt := range opts {
+		opt(impl)
+	}
+	s = impl
+
+	cleanup = func() {}
+	if cfg.PruneIntervalMs > 0 {
+		stop := make(chan struct{})
+		done := make(chan struct{})
+		go impl.prune(time.Duration(cfg.PruneIntervalMs)*time.Millisecond, stop, done)
+		var once sync.Once
+		cleanup = func() {
+			once.Do(func() { close(stop) })
+			<-done
+		}
+	}
+	return
+}
+
+func (s *serviceImpl) Process(ctx context.Context, msgID string, handle Handler) (processed bool, err error) {
+	if handle == nil {
+		err = constant.ErrNilDeps
+		return
+	}
+	tx, err := s.pool.BeginTxx(ctx, nil)
+	if err != nil {
+		return
+	}
+	// Rolling back a committed transaction is a no-op, and it also rolls back if the handler panics.
+	defer tx.Rollback() // nolint:errcheck
+
+	result, err := tx.ExecContext(ctx, s.insertStmt, msgID, s.clk.Now())
+	if err != nil {
+		return
+	}
+	n, err := result.RowsAffected()
+	if err != nil || n == 0 {
+		return
+	}
+	if err = handle(ctx, tx); err != nil {
+		return
+	}
+	if err = tx.Commit(); err != nil {
+		return
+	}
+	processed = true
+	return
+}
+
+func (s *serviceImpl) Prune(ctx context.Context) (int64, error) {
+	cutoff := s.clk.Now().Add(-time.Duration(s.cfg.RetentionMs) * time.Millisecond)
+	result, err := s.pool.ExecContext(ctx, s.pruneStmt, cutoff)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// prune prunes expired message IDs every interval until stop is closed.
+func (s *serviceImpl) prune(interval time.Duration, stop <-chan struct{}, done chan<- struct{}) {
+	defer close(done)
+	defer util.Recover()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-s.clk.After(interval):
+		}
+		n, err := s.Prune(context.Background())
+		if err != nil {
+			s.l.Error("Prune inbox failed.", constant.LogAttrError, err)
+			continue
+		}
+		s.l.Debug("Inbox pruned.", constant.LogAttrResult, n)
+	}
+}
@@ -0,0 +1,14 @@
+package kv
+
+// Config defines the config model for kv.
+type Config struct {
+	// Backend is where values are stored, one of [BackendValkey], [BackendSQLite] and [BackendMemory].
+	Backend string `json:"backend" yaml:"backend" toml:"backend" xml:"backend" env:"KV_BACKEND" default:"valkey"`
+
+	// Prefix is the prefix for redis keys of [BackendValkey]. Use different keys in different scenarios to avoid
+	// conflicts.
+	Prefix string `json:"prefix" yaml:"prefix" toml:"prefix" xml:"prefix" env:"KV_PREFIX" default:"kv"`
+
+	// Table is the name of the table of [BackendSQLite].
+	Table string `json:"table" yaml:"table" toml:"table" xml:"table" env:"KV_TABLE" default:"kv"`
+}
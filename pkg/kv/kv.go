@@ -0,0 +1,158 @@
+//go:generate mockgen -write_package_comment=false -source=kv.go -destination=kv_mock.go -package kv
+
+/*
+Package kv implements a key-value store with interchangeable backends, so that the same code can run with Valkey in
+clustered deployments and without any external service in single-binary edge deployments:
+
+	store, err := kv.NewStore(cfg, rc, pool)
+	ok, err := store.SetNX(ctx, "job:42", []byte("worker-1"), time.Minute)
+	n, err := store.IncrBy(ctx, "visits:2026-01-01", 1, 24*time.Hour)
+
+The backend is selected by [Config.Backend]:
+
+  - [BackendValkey] stores values in Valkey, so that they are shared across processes.
+  - [BackendSQLite] stores values in an embedded SQLite database, so that they survive restarts of a single process.
+  - [BackendMemory] stores values in memory, which is useful for unit tests and local development.
+
+The table of [BackendSQLite] should be created in advance:
+
+	CREATE TABLE kv (
+		name TEXT PRIMARY KEY,
+		value BLOB NOT NULL,
+		expire_ms INTEGER NOT NULL DEFAULT 0
+	);
+	CREATE INDEX kv_expire_ms_idx ON kv (expire_ms);
+
+Expired values are never returned. In [BackendSQLite] and [BackendMemory], they are deleted when overwritten, and swept
+at most once per minute when values are written.
+*/
+package kv
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/redis/rueidis"
+	"github.com/sainnhe/go-common/pkg/clock"
+	"github.com/sainnhe/go-common/pkg/constant"
+)
+
+const (
+	pkgName = "github.com/sainnhe/go-common/pkg/kv"
+
+	// BackendValkey stores values in Valkey.
+	BackendValkey = "valkey"
+
+	// BackendSQLite stores values in a SQLite database.
+	BackendSQLite = "sqlite"
+
+	// BackendMemory stores values in memory.
+	BackendMemory = "memory"
+
+	// sweepInterval is the minimum interval of sweeping expired values.
+	sweepInterval = time.Minute
+)
+
+var (
+	// ErrUnknownBackend indicates that the backend in config is unknown.
+	ErrUnknownBackend = errors.New("unknown backend")
+
+	// ErrNotFound indicates that the key doesn't exist or has expired.
+	ErrNotFound = errors.New("key not found")
+
+	// ErrNotInteger indicates that the value can't be incremented since it's not an integer.
+	ErrNotInteger = errors.New("value is not an integer")
+)
+
+// Store is a key-value store. A non-positive TTL means that the value never expires.
+type Store interface {
+	// Get returns the value of the key. [ErrNotFound] is returned if it doesn't exist or has expired.
+	Get(ctx context.Context, key string) ([]byte, error)
+
+	// Set sets the value of the key with the TTL, overwriting the existing value.
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+
+	// SetNX sets the value of the key with the TTL only if it doesn't exist or has expired, and reports whether it's
+	// set.
+	SetNX(ctx context.Context, key string, value []byte, ttl time.Duration) (bool, error)
+
+	// Delete deletes the key. It's a no-op if the key doesn't exist.
+	Delete(ctx context.Context, key string) error
+
+	// IncrBy increments the integer value of the key by n, and returns the incremented value. If the key doesn't exist
+	// or has expired, it's set to n with the TTL. Otherwise, its TTL is unchanged, which makes it a fixed window
+	// counter. An error wrapping [ErrNotInteger] is returned if the value is not a decimal integer.
+	IncrBy(ctx context.Context, key string, n int64, ttl time.Duration) (int64, error)
+}
+
+type options struct {
+	clk clock.Clock
+}
+
+// Option is the option used to customize the store.
+type Option func(o *options)
+
+// WithClock sets the clock used to expire values of [BackendSQLite] and [BackendMemory]. Values of [BackendValkey]
+// are expired by the server. Defaults to [clock.Real].
+func WithClock(clk clock.Clock) Option {
+	return func(o *options) {
+		if clk != nil {
+			o.clk = clk
+		}
+	}
+}
+
+/*
+NewStore initializes a new store.
+
+Params:
+  - cfg: The config.
+  - rc: The rueidis client, which is required by [BackendValkey] and can be nil otherwise.
+  - pool: The connection pool of a SQLite database, which is required by [BackendSQLite] and can be nil otherwise.
+  - opts: The options.
+
+Returns:
+  - Store: The store.
+  - error: [constant.ErrNilDeps] if any required dependency is nil, an error wrapping [ErrUnknownBackend] if the
+    backend is unknown, or [db.ErrUnsupportedDriver] if the driver of pool is not SQLite.
+*/
+func NewStore(cfg *Config, rc rueidis.Client, pool *sqlx.DB, opts ...Option) (Store, error) {
+	if cfg == nil {
+		return nil, constant.ErrNilDeps
+	}
+	o := &options{clock.Real()}
+	for _, opt := range opts {
+		opt(o)
+	}
+	switch cfg.Backend {
+	case "", BackendValkey:
+		if rc == nil {
+			return nil, constant.ErrNilDeps
+		}
+		return newValkeyStore(rc, cfg.Prefix), nil
+	case BackendSQLite:
+		if pool == nil {
+			return nil, constant.ErrNilDeps
+		}
+		s, err := newSQLiteStore(pool, cfg.Table, o.clk)
+		if err != nil {
+			return nil, err
+		}
+		return s, nil
+	case BackendMemory:
+		return newMemoryStore(o.clk), nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownBackend, cfg.Backend)
+	}
+}
+
+// expireAt returns the expiration time of a value set at now with the TTL, which is zero if ttl is not positive.
+func expireAt(now time.Time, ttl time.Duration) time.Time {
+	if ttl <= 0 {
+		return time.Time{}
+	}
+	return now.Add(ttl)
+}
@@ -0,0 +1,176 @@
+package kv_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/redis/rueidis"
+	"github.com/sainnhe/go-common/pkg/clock/testclock"
+	"github.com/sainnhe/go-common/pkg/constant"
+	"github.com/sainnhe/go-common/pkg/db"
+	"github.com/sainnhe/go-common/pkg/db/dbtest"
+	"github.com/sainnhe/go-common/pkg/kv"
+)
+
+const ddl = `CREATE TABLE kv (
+	name TEXT PRIMARY KEY,
+	value BLOB NOT NULL,
+	expire_ms INTEGER NOT NULL DEFAULT 0
+)`
+
+func TestNewStore(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		cfg  *kv.Config
+		pool *sqlx.DB
+		err  error
+	}{
+		{nil, nil, constant.ErrNilDeps},
+		{&kv.Config{}, nil, constant.ErrNilDeps},
+		{&kv.Config{Backend: kv.BackendValkey}, nil, constant.ErrNilDeps},
+		{&kv.Config{Backend: kv.BackendSQLite}, nil, constant.ErrNilDeps},
+		{&kv.Config{Backend: kv.BackendSQLite}, sqlx.NewDb(&sql.DB{}, "pgx"), db.ErrUnsupportedDriver},
+		{&kv.Config{Backend: "etcd"}, nil, kv.ErrUnknownBackend},
+	}
+	for _, tt := range tests {
+		if _, err := kv.NewStore(tt.cfg, nil, tt.pool); !errors.Is(err, tt.err) {
+			t.Fatalf("Expect error %+v, got %+v", tt.err, err)
+		}
+	}
+}
+
+func TestStore_memory(t *testing.T) {
+	t.Parallel()
+
+	clk := testclock.Freeze(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	s, err := kv.NewStore(&kv.Config{Backend: kv.BackendMemory}, nil, nil, kv.WithClock(clk))
+	if err != nil {
+		t.Fatal(err)
+	}
+	testStore(t, s, clk.Advance)
+}
+
+func TestStore_sqlite(t *testing.T) {
+	t.Parallel()
+
+	clk := testclock.Freeze(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	pool := dbtest.NewPool(t, ddl)
+	s, err := kv.NewStore(&kv.Config{Backend: kv.BackendSQLite, Table: "kv"}, nil, pool, kv.WithClock(clk))
+	if err != nil {
+		t.Fatal(err)
+	}
+	testStore(t, s, clk.Advance)
+
+	// Expired values are swept.
+	clk.Advance(time.Hour)
+	if err := s.Set(context.Background(), "x", []byte("1"), 0); err != nil {
+		t.Fatal(err)
+	}
+	var n int
+	if err := pool.Get(&n, "SELECT COUNT(*) FROM kv WHERE expire_ms != 0"); err != nil || n != 0 {
+		t.Fatalf("Expect expired values to be swept, got %d, err = %+v", n, err)
+	}
+}
+
+func TestStore_valkey(t *testing.T) {
+	t.Parallel()
+
+	rc, err := rueidis.NewClient(rueidis.ClientOption{
+		InitAddress: []string{"localhost:6379"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rc.Close()
+	s, err := kv.NewStore(&kv.Config{Backend: kv.BackendValkey, Prefix: "test_kv"}, rc, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testStore(t, s, time.Sleep)
+}
+
+// testStore tests the store, where advance advances the time seen by the store.
+func testStore(t *testing.T, s kv.Store, advance func(d time.Duration)) {
+	t.Helper()
+
+	ctx := context.Background()
+	for _, key := range []string{"foo", "bar", "counter", "ttl"} {
+		if err := s.Delete(ctx, key); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Get and set
+	if _, err := s.Get(ctx, "foo"); !errors.Is(err, kv.ErrNotFound) {
+		t.Fatalf("Expect error %+v, got %+v", kv.ErrNotFound, err)
+	}
+	if err := s.Set(ctx, "foo", []byte("1"), 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Set(ctx, "foo", []byte("2"), 0); err != nil {
+		t.Fatal(err)
+	}
+	if v, err := s.Get(ctx, "foo"); err != nil || string(v) != "2" {
+		t.Fatalf("Expect value 2, got %s, err = %+v", v, err)
+	}
+
+	// SetNX
+	if ok, err := s.SetNX(ctx, "foo", []byte("3"), 0); ok || err != nil {
+		t.Fatalf("Expect not set, got ok = %t, err = %+v", ok, err)
+	}
+	if ok, err := s.SetNX(ctx, "bar", []byte("3"), 0); !ok || err != nil {
+		t.Fatalf("Expect set, got ok = %t, err = %+v", ok, err)
+	}
+
+	// IncrBy
+	if _, err := s.IncrBy(ctx, "bar", 1, 0); err != nil {
+		t.Fatal(err)
+	}
+	for i, want := range []int64{5, 2} {
+		if v, err := s.IncrBy(ctx, "counter", []int64{5, -3}[i], 0); err != nil || v != want {
+			t.Fatalf("Expect %d, got %d, err = %+v", want, v, err)
+		}
+	}
+	if v, err := s.Get(ctx, "counter"); err != nil || string(v) != "2" {
+		t.Fatalf("Expect value 2, got %s, err = %+v", v, err)
+	}
+	if err := s.Set(ctx, "bar", []byte("abc"), 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.IncrBy(ctx, "bar", 1, 0); !errors.Is(err, kv.ErrNotInteger) {
+		t.Fatalf("Expect error %+v, got %+v", kv.ErrNotInteger, err)
+	}
+	if v, err := s.Get(ctx, "bar"); err != nil || string(v) != "abc" {
+		t.Fatalf("Expect value abc, got %s, err = %+v", v, err)
+	}
+
+	// TTL
+	ttl := 200 * time.Millisecond
+	if v, err := s.IncrBy(ctx, "ttl", 1, ttl); err != nil || v != 1 {
+		t.Fatalf("Expect 1, got %d, err = %+v", v, err)
+	}
+	// The TTL is unchanged when incremented.
+	advance(ttl / 2)
+	if v, err := s.IncrBy(ctx, "ttl", 1, ttl); err != nil || v != 2 {
+		t.Fatalf("Expect 2, got %d, err = %+v", v, err)
+	}
+	advance(ttl)
+	if _, err := s.Get(ctx, "ttl"); !errors.Is(err, kv.ErrNotFound) {
+		t.Fatalf("Expect error %+v, got %+v", kv.ErrNotFound, err)
+	}
+	if v, err := s.IncrBy(ctx, "ttl", 1, ttl); err != nil || v != 1 {
+		t.Fatalf("Expect 1, got %d, err = %+v", v, err)
+	}
+	advance(ttl)
+	if ok, err := s.SetNX(ctx, "ttl", []byte("x"), ttl); !ok || err != nil {
+		t.Fatalf("Expect expired key to be set, got ok = %t, err = %+v", ok, err)
+	}
+	if v, err := s.Get(ctx, "ttl"); err != nil || string(v) != "x" {
+		t.Fatalf("Expect value x, got %s, err = %+v", v, err)
+	}
+}
@@ -0,0 +1,113 @@
+package kv
+
+import (
+	"bytes"
+	"context"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/sainnhe/go-common/pkg/clock"
+)
+
+// memoryStore is a [Store] of [BackendMemory].
+type memoryStore struct {
+	mu        sync.Mutex
+	clk       clock.Clock
+	entries   map[string]*memoryEntry
+	lastSweep time.Time
+}
+
+type memoryEntry struct {
+	value []byte
+
+	// expireAt is zero if the entry never expires.
+	expireAt time.Time
+}
+
+func (e *memoryEntry) expired(now time.Time) bool {
+	return !e.expireAt.IsZero() && !e.expireAt.After(now)
+}
+
+func newMemoryStore(clk clock.Clock) *memoryStore {
+	return &memoryStore{
+		clk:       clk,
+		entries:   map[string]*memoryEntry{},
+		lastSweep: clk.Now(),
+	}
+}
+
+func (s *memoryStore) Get(_ context.Context, key string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.entries[key]
+	if !ok || e.expired(s.clk.Now()) {
+		return nil, ErrNotFound
+	}
+	return bytes.Clone(e.value), nil
+}
+
+func (s *memoryStore) Set(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.clk.Now()
+	s.sweep(now)
+	s.entries[key] = &memoryEntry{bytes.Clone(value), expireAt(now, ttl)}
+	return nil
+}
+
+func (s *memoryStore) SetNX(_ context.Context, key string, value []byte, ttl time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.clk.Now()
+	s.sweep(now)
+	if e, ok := s.entries[key]; ok && !e.expired(now) {
+		return false, nil
+	}
+	s.entries[key] = &memoryEntry{bytes.Clone(value), expireAt(now, ttl)}
+	return true, nil
+}
+
+func (s *memoryStore) Delete(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.entries, key)
+	return nil
+}
+
+func (s *memoryStore) IncrBy(_ context.Context, key string, n int64, ttl time.Duration) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.clk.Now()
+	s.sweep(now)
+	e, ok := s.entries[key]
+	if !ok || e.expired(now) {
+		s.entries[key] = &memoryEntry{strconv.AppendInt(nil, n, 10), expireAt(now, ttl)}
+		return n, nil
+	}
+	v, err := strconv.ParseInt(string(e.value), 10, 64)
+	if err != nil {
+		return 0, ErrNotInteger
+	}
+	v += n
+	e.value = strconv.AppendInt(nil, v, 10)
+	return v, nil
+}
+
+// sweep deletes expired entries if they haven't been swept within sweepInterval.
+func (s *memoryStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < sweepInterval {
+		return
+	}
+	for key, e := range s.entries {
+		if e.expired(now) {
+			delete(s.entries, key)
+		}
+	}
+	s.lastSweep = now
+}
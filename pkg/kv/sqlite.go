@@ -0,0 +1,139 @@
+package kv
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/sainnhe/go-common/pkg/clock"
+	"github.com/sainnhe/go-common/pkg/constant"
+	"github.com/sainnhe/go-common/pkg/db"
+	"github.com/sainnhe/go-common/pkg/log"
+)
+
+// sqliteStore is a [Store] of [BackendSQLite]. Expiration times are stored as Unix milliseconds, where 0 means that
+// the value never expires.
+type sqliteStore struct {
+	pool *sqlx.DB
+	clk  clock.Clock
+	l    *slog.Logger
+
+	getStmt    string
+	setStmt    string
+	setNXStmt  string
+	deleteStmt string
+	incrByStmt string
+	sweepStmt  string
+
+	mu        sync.Mutex
+	lastSweep time.Time
+}
+
+func newSQLiteStore(pool *sqlx.DB, table string, clk clock.Clock) (*sqliteStore, error) {
+	switch pool.DriverName() {
+	case "sqlite3", "sqlite":
+	default:
+		return nil, db.ErrUnsupportedDriver
+	}
+	insert := fmt.Sprintf("INSERT INTO %[1]s (name, value, expire_ms) VALUES (?, ?, ?) ON CONFLICT (name) DO UPDATE SET",
+		table)
+	expired := fmt.Sprintf("%[1]s.expire_ms != 0 AND %[1]s.expire_ms <= ?", table)
+	return &sqliteStore{
+		pool: pool,
+		clk:  clk,
+		l:    log.NewLogger(pkgName),
+		getStmt: fmt.Sprintf("SELECT value FROM %s WHERE name = ? AND (expire_ms = 0 OR expire_ms > ?)",
+			table),
+		setStmt:    insert + " value = excluded.value, expire_ms = excluded.expire_ms",
+		setNXStmt:  insert + " value = excluded.value, expire_ms = excluded.expire_ms WHERE " + expired,
+		deleteStmt: fmt.Sprintf("DELETE FROM %s WHERE name = ?", table),
+		// Non-integer values are returned unchanged, so that they can be reported as ErrNotInteger.
+		incrByStmt: fmt.Sprintf(`%[1]s
+	value = CASE
+		WHEN %[2]s THEN excluded.value
+		WHEN CAST(CAST(%[3]s.value AS INTEGER) AS TEXT) = CAST(%[3]s.value AS TEXT)
+			THEN CAST(%[3]s.value AS INTEGER) + excluded.value
+		ELSE %[3]s.value
+	END,
+	expire_ms = CASE WHEN %[2]s THEN excluded.expire_ms ELSE %[3]s.expire_ms END
+RETURNING value`, insert, expired, table),
+		sweepStmt: fmt.Sprintf("DELETE FROM %s WHERE expire_ms != 0 AND expire_ms <= ?", table),
+		lastSweep: clk.Now(),
+	}, nil
+}
+
+func (s *sqliteStore) Get(ctx context.Context, key string) ([]byte, error) {
+	var value []byte
+	err := s.pool.GetContext(ctx, &value, s.getStmt, key, s.clk.Now().UnixMilli())
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return value, err
+}
+
+func (s *sqliteStore) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	now := s.clk.Now()
+	s.sweep(ctx, now)
+	_, err := s.pool.ExecContext(ctx, s.setStmt, key, value, unixMilli(expireAt(now, ttl)))
+	return err
+}
+
+func (s *sqliteStore) SetNX(ctx context.Context, key string, value []byte, ttl time.Duration) (bool, error) {
+	now := s.clk.Now()
+	s.sweep(ctx, now)
+	result, err := s.pool.ExecContext(ctx, s.setNXStmt, key, value, unixMilli(expireAt(now, ttl)), now.UnixMilli())
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n == 1, err
+}
+
+func (s *sqliteStore) Delete(ctx context.Context, key string) error {
+	_, err := s.pool.ExecContext(ctx, s.deleteStmt, key)
+	return err
+}
+
+func (s *sqliteStore) IncrBy(ctx context.Context, key string, n int64, ttl time.Duration) (int64, error) {
+	now := s.clk.Now()
+	s.sweep(ctx, now)
+	var value []byte
+	if err := s.pool.GetContext(ctx, &value, s.incrByStmt, key, n, unixMilli(expireAt(now, ttl)),
+		now.UnixMilli(), now.UnixMilli()); err != nil {
+		return 0, err
+	}
+	v, err := strconv.ParseInt(string(value), 10, 64)
+	if err != nil {
+		return 0, ErrNotInteger
+	}
+	return v, nil
+}
+
+// sweep deletes expired values if they haven't been swept within sweepInterval. Errors are logged rather than
+// returned, since they don't affect the correctness of the store.
+func (s *sqliteStore) sweep(ctx context.Context, now time.Time) {
+	s.mu.Lock()
+	if now.Sub(s.lastSweep) < sweepInterval {
+		s.mu.Unlock()
+		return
+	}
+	s.lastSweep = now
+	s.mu.Unlock()
+	if _, err := s.pool.ExecContext(ctx, s.sweepStmt, now.UnixMilli()); err != nil {
+		s.l.ErrorContext(ctx, "Sweep expired values failed.", constant.LogAttrError, err)
+	}
+}
+
+// unixMilli returns the Unix milliseconds of t, or 0 if t is zero.
+func unixMilli(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixMilli()
+}
@@ -0,0 +1,82 @@
+package kv
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/rueidis"
+)
+
+// incrByScript increments KEYS[1] by ARGV[1], and sets its expiration to ARGV[2] milliseconds if it's created and
+// ARGV[2] is positive.
+var incrByScript = rueidis.NewLuaScript(`
+local created = redis.call("EXISTS", KEYS[1]) == 0
+local v = redis.call("INCRBY", KEYS[1], ARGV[1])
+if created and tonumber(ARGV[2]) > 0 then
+	redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return v`)
+
+// valkeyStore is a [Store] of [BackendValkey].
+type valkeyStore struct {
+	rc     rueidis.Client
+	prefix string
+}
+
+func newValkeyStore(rc rueidis.Client, prefix string) *valkeyStore {
+	return &valkeyStore{rc, prefix}
+}
+
+func (s *valkeyStore) Get(ctx context.Context, key string) ([]byte, error) {
+	v, err := s.rc.Do(ctx, s.rc.B().Get().Key(s.getKey(key)).Build()).AsBytes()
+	if rueidis.IsRedisNil(err) {
+		return nil, ErrNotFound
+	}
+	return v, err
+}
+
+func (s *valkeyStore) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	cmd := s.rc.B().Set().Key(s.getKey(key)).Value(rueidis.BinaryString(value))
+	if ttl > 0 {
+		return s.rc.Do(ctx, cmd.PxMilliseconds(ttl.Milliseconds()).Build()).Error()
+	}
+	return s.rc.Do(ctx, cmd.Build()).Error()
+}
+
+func (s *valkeyStore) SetNX(ctx context.Context, key string, value []byte, ttl time.Duration) (bool, error) {
+	cmd := s.rc.B().Set().Key(s.getKey(key)).Value(rueidis.BinaryString(value)).Nx()
+	var err error
+	if ttl > 0 {
+		err = s.rc.Do(ctx, cmd.PxMilliseconds(ttl.Milliseconds()).Build()).Error()
+	} else {
+		err = s.rc.Do(ctx, cmd.Build()).Error()
+	}
+	switch {
+	case rueidis.IsRedisNil(err):
+		return false, nil
+	case err != nil:
+		return false, err
+	default:
+		return true, nil
+	}
+}
+
+func (s *valkeyStore) Delete(ctx context.Context, key string) error {
+	return s.rc.Do(ctx, s.rc.B().Del().Key(s.getKey(key)).Build()).Error()
+}
+
+func (s *valkeyStore) IncrBy(ctx context.Context, key string, n int64, ttl time.Duration) (int64, error) {
+	v, err := incrByScript.Exec(ctx, s.rc, []string{s.getKey(key)},
+		[]string{strconv.FormatInt(n, 10), strconv.FormatInt(max(ttl.Milliseconds(), 0), 10)}).AsInt64()
+	if e, ok := rueidis.IsRedisErr(err); ok && strings.Contains(e.Error(), "not an integer") {
+		return 0, fmt.Errorf("%w: %w", ErrNotInteger, err)
+	}
+	return v, err
+}
+
+func (s *valkeyStore) getKey(key string) string {
+	return fmt.Sprintf("%s:%s", s.prefix, key)
+}
@@ -5,6 +5,9 @@ type Config struct {
 	// Enable indicates whether to enable limiter.
 	Enable bool `json:"enable" yaml:"enable" toml:"enable" xml:"enable" env:"LIMITER_ENABLE" default:"true"`
 
+	// Backend is where counters are stored, either [BackendValkey] or [BackendMemory].
+	Backend string `json:"backend" yaml:"backend" toml:"backend" xml:"backend" env:"LIMITER_BACKEND" default:"valkey"` // nolint:lll
+
 	// Prefix is the prefix for redis keys. Use different keys in different scenarios to avoid conflicts.
 	Prefix string `json:"prefix" yaml:"prefix" toml:"prefix" xml:"prefix" env:"LIMITER_PREFIX" default:"*"`
 
@@ -14,6 +17,11 @@ type Config struct {
 	// WindowMs is the time window for measurement in milliseconds.
 	WindowMs int `json:"window_ms" yaml:"window_ms" toml:"window_ms" xml:"window_ms" env:"LIMITER_WINDOW_MS" default:"1000"` // nolint:lll
 
+	// Limits overrides Limit and WindowMs for identifiers matching the keys, which are patterns with the syntax of
+	// [path.Match], e.g. "user:premium:*". Exact matches take precedence over patterns, and longer patterns take
+	// precedence over shorter ones.
+	Limits map[string]LimitRule `json:"limits" yaml:"limits" toml:"limits" xml:"limits" env:"LIMITER_LIMITS" default:"{}"` // nolint:lll
+
 	// MaxAttempts is the maximum number of attempts.
 	// Setting it to 0 will disable peak shaving and degrade the limiter to traditional rate limiter.
 	MaxAttempts int `json:"max_attempts" yaml:"max_attempts" toml:"max_attempts" xml:"max_attempts" env:"LIMITER_MAX_ATTEMPTS" default:"0"` // nolint:lll
@@ -24,3 +32,12 @@ type Config struct {
 	// EnableLog indicates whether to output logs when sleeping and retrying.
 	EnableLog bool `json:"enable_log" yaml:"enable_log" toml:"enable_log" xml:"enable_log" env:"LIMITER_ENABLE_LOG" default:"true"` // nolint:lll
 }
+
+// LimitRule defines the limit of identifiers matching a pattern.
+type LimitRule struct {
+	// Limit is the limit of request volume within the specified time window.
+	Limit int `json:"limit" yaml:"limit" toml:"limit" xml:"limit"`
+
+	// WindowMs is the time window for measurement in milliseconds.
+	WindowMs int `json:"window_ms" yaml:"window_ms" toml:"window_ms" xml:"window_ms"`
+}
@@ -0,0 +1,52 @@
+package limiter
+
+import (
+	"context"
+
+	"github.com/sainnhe/go-common/pkg/constant"
+	"github.com/sainnhe/go-common/pkg/log"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// UnaryServerInterceptor initializes a gRPC unary server interceptor that limits calls via [Service.AllowResult],
+// and returns RESOURCE_EXHAUSTED with the retry-after header if the call is rejected. keyFunc returns the identifier of
+// a call, and calls with empty keys are not limited. Calls are also passed through if the limiter fails, as
+// [HTTPMiddleware] does.
+func UnaryServerInterceptor(svc Service,
+	keyFunc func(ctx context.Context, fullMethod string) string) grpc.UnaryServerInterceptor {
+	l := log.NewLogger(pkgName).With(constant.LogAttrMethod, "UnaryServerInterceptor")
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		result, limited := allow(ctx, svc, keyFunc(ctx, info.FullMethod), l)
+		if limited && !result.Allowed {
+			_ = grpc.SetHeader(ctx, retryAfter(result))
+			return nil, errResourceExhausted()
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor initializes a gRPC stream server interceptor that limits streams via [Service.AllowResult]
+// when they are opened. See [UnaryServerInterceptor] for details.
+func StreamServerInterceptor(svc Service,
+	keyFunc func(ctx context.Context, fullMethod string) string) grpc.StreamServerInterceptor {
+	l := log.NewLogger(pkgName).With(constant.LogAttrMethod, "StreamServerInterceptor")
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		result, limited := allow(ss.Context(), svc, keyFunc(ss.Context(), info.FullMethod), l)
+		if limited && !result.Allowed {
+			_ = ss.SetHeader(retryAfter(result))
+			return errResourceExhausted()
+		}
+		return handler(srv, ss)
+	}
+}
+
+func retryAfter(result Result) metadata.MD {
+	return metadata.Pairs("retry-after", resetSeconds(result))
+}
+
+func errResourceExhausted() error {
+	return status.Error(codes.ResourceExhausted, "rate limit exceeded")
+}
@@ -0,0 +1,66 @@
+package limiter_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/sainnhe/go-common/pkg/limiter"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+type serverStream struct {
+	grpc.ServerStream
+	header metadata.MD
+}
+
+func (s *serverStream) Context() context.Context {
+	return context.Background()
+}
+
+func (s *serverStream) SetHeader(md metadata.MD) error {
+	s.header = metadata.Join(s.header, md)
+	return nil
+}
+
+func keyByMethod(_ context.Context, fullMethod string) string {
+	return fullMethod
+}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	t.Parallel()
+
+	interceptor := limiter.UnaryServerInterceptor(newMemoryService(t, 1), keyByMethod)
+	info := &grpc.UnaryServerInfo{FullMethod: "/foo.Bar/Baz"}
+	handler := func(context.Context, any) (any, error) {
+		return "ok", nil
+	}
+	if resp, err := interceptor(context.Background(), nil, info, handler); resp != "ok" || err != nil {
+		t.Fatalf("Unexpected response %+v, err = %+v", resp, err)
+	}
+	if _, err := interceptor(context.Background(), nil, info, handler); status.Code(err) != codes.ResourceExhausted {
+		t.Fatalf("Expect code %s, got %+v", codes.ResourceExhausted, err)
+	}
+}
+
+func TestStreamServerInterceptor(t *testing.T) {
+	t.Parallel()
+
+	interceptor := limiter.StreamServerInterceptor(newMemoryService(t, 1), keyByMethod)
+	info := &grpc.StreamServerInfo{FullMethod: "/foo.Bar/Baz"}
+	handler := func(any, grpc.ServerStream) error {
+		return nil
+	}
+	if err := interceptor(nil, &serverStream{}, info, handler); err != nil {
+		t.Fatal(err)
+	}
+	ss := &serverStream{}
+	if err := interceptor(nil, ss, info, handler); status.Code(err) != codes.ResourceExhausted {
+		t.Fatalf("Expect code %s, got %+v", codes.ResourceExhausted, err)
+	}
+	if v := ss.header.Get("retry-after"); len(v) != 1 || v[0] != "60" {
+		t.Fatalf("Unexpected header %+v", ss.header)
+	}
+}
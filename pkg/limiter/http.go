@@ -0,0 +1,114 @@
+package limiter
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/sainnhe/go-common/pkg/constant"
+	"github.com/sainnhe/go-common/pkg/log"
+)
+
+const (
+	// HeaderLimit is the header of the limit of the current window.
+	HeaderLimit = "RateLimit-Limit"
+
+	// HeaderRemaining is the header of the remaining quota in the current window.
+	HeaderRemaining = "RateLimit-Remaining"
+
+	// HeaderReset is the header of the number of seconds until the current window resets.
+	HeaderReset = "RateLimit-Reset"
+
+	// HeaderRetryAfter is the header of the number of seconds to wait before retrying a rejected request.
+	HeaderRetryAfter = "Retry-After"
+
+	// HeaderXLimit is the legacy header of the limit of the current window, see [Result.Headers].
+	HeaderXLimit = "X-RateLimit-Limit"
+
+	// HeaderXRemaining is the legacy header of the remaining quota in the current window, see [Result.Headers].
+	HeaderXRemaining = "X-RateLimit-Remaining"
+
+	// HeaderXReset is the legacy header of the number of seconds until the current window resets, see
+	// [Result.Headers].
+	HeaderXReset = "X-RateLimit-Reset"
+)
+
+/*
+HTTPMiddleware initializes a middleware that limits requests via [Service.AllowResult].
+
+Rejected requests are responded with 429 Too Many Requests and the Retry-After header. The RateLimit-Limit,
+RateLimit-Remaining and RateLimit-Reset headers are set on all limited responses.
+
+Requests are passed through without limiting if the key is empty, or if the limiter fails, so that an unavailable
+limiter backend doesn't take down the service. Failures are logged.
+
+Params:
+  - svc: The limiter service.
+  - keyFunc: The function that returns the identifier of a request, e.g. the user ID or the client IP.
+
+Returns:
+  - func(http.Handler) http.Handler: The middleware.
+*/
+func HTTPMiddleware(svc Service, keyFunc func(*http.Request) string) func(http.Handler) http.Handler {
+	l := log.NewLogger(pkgName).With(constant.LogAttrMethod, "HTTPMiddleware")
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			result, limited := allow(r.Context(), svc, keyFunc(r), l)
+			if !limited {
+				next.ServeHTTP(w, r)
+				return
+			}
+			reset := resetSeconds(result)
+			w.Header().Set(HeaderLimit, strconv.FormatInt(result.Limit, 10))
+			w.Header().Set(HeaderRemaining, strconv.FormatInt(result.Remaining, 10))
+			w.Header().Set(HeaderReset, reset)
+			if !result.Allowed {
+				w.Header().Set(HeaderRetryAfter, reset)
+				http.Error(w, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// Headers returns the X-RateLimit-Limit, X-RateLimit-Remaining and X-RateLimit-Reset headers of the result, as well as
+// the Retry-After header if the request is not allowed, which is useful for API gateways that don't use
+// [HTTPMiddleware]. The reset time is in seconds until the window resets like RateLimit-Reset, rather than a Unix
+// timestamp. An empty header is returned if the limiter is disabled.
+func (r Result) Headers() http.Header {
+	h := http.Header{}
+	if r.Limit == 0 && r.Window == 0 {
+		return h
+	}
+	reset := resetSeconds(r)
+	h.Set(HeaderXLimit, strconv.FormatInt(r.Limit, 10))
+	h.Set(HeaderXRemaining, strconv.FormatInt(r.Remaining, 10))
+	h.Set(HeaderXReset, reset)
+	if !r.Allowed {
+		h.Set(HeaderRetryAfter, reset)
+	}
+	return h
+}
+
+// allow calls [Service.AllowResult], and returns whether the request is limited, i.e. the key is not empty and the
+// limiter doesn't fail.
+func allow(ctx context.Context, svc Service, key string, l *slog.Logger) (Result, bool) {
+	if key == "" {
+		return Result{}, false
+	}
+	result, err := svc.AllowResult(ctx, key)
+	if err != nil {
+		l.ErrorContext(ctx, "Limiter failed. Passing through...", "identifier", key, constant.LogAttrError, err)
+		return result, false
+	}
+	return result, true
+}
+
+// resetSeconds returns the number of seconds until the window of result resets, rounded up.
+func resetSeconds(result Result) string {
+	ms := max(time.Until(time.UnixMilli(result.ResetAtMs)).Milliseconds(), 0)
+	return strconv.FormatInt((ms+999)/1000, 10) // nolint:mnd
+}
@@ -0,0 +1,132 @@
+package limiter_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/sainnhe/go-common/pkg/limiter"
+	"go.uber.org/mock/gomock"
+)
+
+func newMemoryService(t *testing.T, limit int) limiter.Service {
+	t.Helper()
+
+	s, err := limiter.NewService(&limiter.Config{
+		Enable:   true,
+		Backend:  limiter.BackendMemory,
+		Limit:    limit,
+		WindowMs: 60000,
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestHTTPMiddleware(t *testing.T) {
+	t.Parallel()
+
+	h := limiter.HTTPMiddleware(newMemoryService(t, 1), func(r *http.Request) string {
+		return r.Header.Get("X-User")
+	})(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	serve := func(user string) *httptest.ResponseRecorder {
+		r := httptest.NewRequestWithContext(context.Background(), http.MethodGet, "/", nil)
+		r.Header.Set("X-User", user)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w
+	}
+
+	w := serve("foo")
+	if w.Code != http.StatusNoContent || w.Header().Get(limiter.HeaderLimit) != "1" ||
+		w.Header().Get(limiter.HeaderRemaining) != "0" || w.Header().Get(limiter.HeaderReset) != "60" {
+		t.Fatalf("Unexpected response %d %+v", w.Code, w.Header())
+	}
+	w = serve("foo")
+	if w.Code != http.StatusTooManyRequests || w.Header().Get(limiter.HeaderRetryAfter) != "60" {
+		t.Fatalf("Unexpected response %d %+v", w.Code, w.Header())
+	}
+	for range 2 {
+		if w = serve(""); w.Code != http.StatusNoContent || w.Header().Get(limiter.HeaderRemaining) != "" {
+			t.Fatalf("Expect empty key not to be limited, got %d %+v", w.Code, w.Header())
+		}
+	}
+}
+
+func TestResult_Headers(t *testing.T) {
+	t.Parallel()
+
+	s, err := limiter.NewService(&limiter.Config{
+		Enable:   true,
+		Backend:  limiter.BackendMemory,
+		Limit:    2,
+		WindowMs: 60000,
+		Limits:   map[string]limiter.LimitRule{"admin:*": {Limit: 10, WindowMs: 1000}},
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	result, err := s.AllowResult(ctx, "user:1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Limit != 2 || result.Window != time.Minute {
+		t.Fatalf("Unexpected result %+v", result)
+	}
+	h := result.Headers()
+	if h.Get(limiter.HeaderXLimit) != "2" || h.Get(limiter.HeaderXRemaining) != "1" ||
+		h.Get(limiter.HeaderXReset) != "60" || h.Get(limiter.HeaderRetryAfter) != "" {
+		t.Fatalf("Unexpected headers %+v", h)
+	}
+	if _, err := s.AllowResult(ctx, "user:1"); err != nil {
+		t.Fatal(err)
+	}
+	if result, err = s.CheckResult(ctx, "user:1"); err != nil {
+		t.Fatal(err)
+	}
+	if h := result.Headers(); h.Get(limiter.HeaderXRemaining) != "0" || h.Get(limiter.HeaderRetryAfter) != "60" {
+		t.Fatalf("Unexpected headers %+v", h)
+	}
+
+	if result, err = s.AllowResult(ctx, "admin:1"); err != nil {
+		t.Fatal(err)
+	}
+	if result.Limit != 10 || result.Window != time.Second || result.Headers().Get(limiter.HeaderXLimit) != "10" {
+		t.Fatalf("Unexpected result %+v", result)
+	}
+	if result, err = s.AllowResult(ctx, "user:2", limiter.WithCustomRateLimit(5, time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if result.Limit != 5 || result.Window != time.Hour {
+		t.Fatalf("Unexpected result %+v", result)
+	}
+
+	if h := (limiter.Result{}).Headers(); len(h) != 0 {
+		t.Fatalf("Expect empty headers, got %+v", h)
+	}
+}
+
+func TestHTTPMiddleware_failOpen(t *testing.T) {
+	t.Parallel()
+
+	svc := limiter.NewMockService(gomock.NewController(t))
+	svc.EXPECT().AllowResult(gomock.Any(), "foo").Return(limiter.Result{}, errors.New("unavailable"))
+	h := limiter.HTTPMiddleware(svc, func(*http.Request) string {
+		return "foo"
+	})(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequestWithContext(context.Background(), http.MethodGet, "/", nil))
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("Expect status %d, got %d", http.StatusNoContent, w.Code)
+	}
+}
@@ -15,22 +15,129 @@ package limiter
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log/slog"
+	"path"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/redis/rueidis"
 	"github.com/redis/rueidis/rueidislimiter"
+	"github.com/sainnhe/go-common/pkg/clock"
 	"github.com/sainnhe/go-common/pkg/constant"
 	"github.com/sainnhe/go-common/pkg/log"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/noop"
+	"go.opentelemetry.io/otel/trace"
 )
 
-const pkgName = "github.com/sainnhe/go-common/pkg/limiter"
+const (
+	pkgName = "github.com/sainnhe/go-common/pkg/limiter"
+
+	// DefaultIdentifierClass is the identifier class of identifiers without a class prefix, see [WithIdentifierClass].
+	DefaultIdentifierClass = "default"
+
+	// EventRejected is the name of the span event added when a request is rejected.
+	EventRejected = "limiter.rejected"
+
+	// BackendValkey stores counters in Valkey, so that they are shared across processes.
+	BackendValkey = "valkey"
+
+	// BackendMemory stores counters in memory, which is useful for unit tests and local development.
+	BackendMemory = "memory"
+)
+
+var (
+	// ErrUnknownBackend indicates that the backend in config is unknown.
+	ErrUnknownBackend = errors.New("unknown backend")
+
+	// ErrUnsupportedOption indicates that rueidislimiter options are passed to a backend other than [BackendValkey].
+	ErrUnsupportedOption = errors.New("rueidislimiter options are only supported by the valkey backend")
+)
+
+// Result is the result of a limiter call.
+type Result struct {
+	rueidislimiter.Result
+
+	// Limit is the limit of the time window applied to the request, which is 0 if the limiter is disabled.
+	Limit int64
+
+	// Window is the time window applied to the request, which is 0 if the limiter is disabled.
+	Window time.Duration
+
+	// Wait is the total time spent waiting for retries before the result is returned.
+	Wait time.Duration
+}
+
+// RateLimitOption overrides the limit and time window of a request. If multiple options are passed, the last one is
+// applied.
+type RateLimitOption struct {
+	// Limit is the limit of request volume within the time window.
+	Limit int
+
+	// Window is the time window.
+	Window time.Duration
+}
+
+// WithCustomRateLimit returns a [RateLimitOption] that overrides the limit and time window of a request.
+func WithCustomRateLimit(limit int, window time.Duration) RateLimitOption {
+	return RateLimitOption{limit, window}
+}
+
+// rateLimiter counts requests of identifiers in fixed time windows.
+type rateLimiter interface {
+	AllowN(ctx context.Context, identifier string, n int64, options ...RateLimitOption) (rueidislimiter.Result, error)
+}
+
+// valkeyLimiter is a [rateLimiter] backed by rueidislimiter.
+type valkeyLimiter struct {
+	rl rueidislimiter.RateLimiterClient
+}
+
+func (l valkeyLimiter) AllowN(ctx context.Context, identifier string, n int64, options ...RateLimitOption) (
+	rueidislimiter.Result, error) {
+	if len(options) == 0 {
+		return l.rl.AllowN(ctx, identifier, n)
+	}
+	opt := options[len(options)-1]
+	return l.rl.AllowN(ctx, identifier, n, rueidislimiter.WithCustomRateLimit(opt.Limit, opt.Window))
+}
+
+// valkeyOptionLimiter is a [rateLimiter] backed by rueidislimiter, which applies rueidislimiter options instead of
+// [RateLimitOption].
+type valkeyOptionLimiter struct {
+	rl      rueidislimiter.RateLimiterClient
+	options []rueidislimiter.RateLimitOption
+}
+
+func (l valkeyOptionLimiter) AllowN(ctx context.Context, identifier string, n int64, _ ...RateLimitOption) (
+	rueidislimiter.Result, error) {
+	return l.rl.AllowN(ctx, identifier, n, l.options...)
+}
+
+// unsupportedLimiter is a [rateLimiter] that fails with [ErrUnsupportedOption].
+type unsupportedLimiter struct{}
+
+func (unsupportedLimiter) AllowN(_ context.Context, _ string, _ int64, _ ...RateLimitOption) (
+	rueidislimiter.Result, error) {
+	return rueidislimiter.Result{}, ErrUnsupportedOption
+}
+
+// allowed is the result returned when the limiter is disabled.
+var allowed = Result{Result: rueidislimiter.Result{Allowed: true}}
 
 // Service is the limiter service.
 type Service interface {
 	// Check checks if a request is allowed under the limit without incrementing the counter.
 	//
 	// The identifier is used to group traffics. Requests with the same identifier share the same counter.
+	//
+	// The options are only supported by [BackendValkey], and [ErrUnsupportedOption] is returned by other backends. Use
+	// [Service.CheckResult] to override limits regardless of the backend.
 	Check(ctx context.Context, identifier string, options ...rueidislimiter.RateLimitOption) (
 		rueidislimiter.Result, error)
 
@@ -38,7 +145,11 @@ type Service interface {
 	//
 	// The identifier is used to group traffics. Requests with the same identifier share the same counter.
 	//
-	// If the maximum number of attempts is reached, the result will be not allowed and the error will be nil.
+	// If the maximum number of attempts is reached, the result will be not allowed and the error will be nil. The error
+	// of ctx is returned if it's done while sleeping.
+	//
+	// The options are only supported by [BackendValkey], and [ErrUnsupportedOption] is returned by other backends. Use
+	// [Service.AllowResult] to override limits regardless of the backend.
 	Allow(ctx context.Context, identifier string, options ...rueidislimiter.RateLimitOption) (
 		rueidislimiter.Result, error)
 
@@ -46,77 +157,347 @@ type Service interface {
 	//
 	// The identifier is used to group traffics. Requests with the same identifier share the same counter.
 	//
-	// If the maximum number of attempts is reached, the result will be not allowed and the error will be nil.
+	// If the maximum number of attempts is reached, the result will be not allowed and the error will be nil. The error
+	// of ctx is returned if it's done while sleeping.
+	//
+	// The options are only supported by [BackendValkey], and [ErrUnsupportedOption] is returned by other backends. Use
+	// [Service.AllowNResult] to override limits regardless of the backend.
 	AllowN(ctx context.Context, identifier string, n int64, options ...rueidislimiter.RateLimitOption) (
 		rueidislimiter.Result, error)
+
+	// CheckResult is the same as [Service.Check], except that it takes [RateLimitOption] which is supported by all
+	// backends, and returns the limit and time window applied to the request.
+	CheckResult(ctx context.Context, identifier string, options ...RateLimitOption) (Result, error)
+
+	// AllowResult is the same as [Service.Allow], except that it takes [RateLimitOption] which is supported by all
+	// backends, and returns the limit and time window applied to the request as well as the time spent waiting.
+	AllowResult(ctx context.Context, identifier string, options ...RateLimitOption) (Result, error)
+
+	// AllowNResult is the same as [Service.AllowN], except that it takes [RateLimitOption] which is supported by all
+	// backends, and returns the limit and time window applied to the request as well as the time spent waiting.
+	AllowNResult(ctx context.Context, identifier string, n int64, options ...RateLimitOption) (Result, error)
+
+	// WaitAllow allows a single request, incrementing the counter if allowed, waiting until the time window resets and
+	// retrying otherwise, regardless of the maximum number of attempts.
+	//
+	// The identifier is used to group traffics. Requests with the same identifier share the same counter.
+	//
+	// The error of ctx is returned if it's done before the request is allowed.
+	WaitAllow(ctx context.Context, identifier string, options ...RateLimitOption) (Result, error)
 }
 
 type serviceImpl struct {
-	rl  rueidislimiter.RateLimiterClient
-	l   *slog.Logger
-	cfg *Config
+	rl       rateLimiter
+	l        *slog.Logger
+	cfg      *Config
+	clk      clock.Clock
+	classify func(identifier string) string
+	rejected metric.Int64Counter
+	patterns []string
+}
+
+// Option is the option used to customize the limiter service.
+type Option func(s *serviceImpl)
+
+// WithClock sets the clock used to sleep between attempts, and to measure time windows of [BackendMemory]. Defaults to
+// [clock.Real].
+func WithClock(clk clock.Clock) Option {
+	return func(s *serviceImpl) {
+		if clk != nil {
+			s.clk = clk
+		}
+	}
+}
+
+// WithIdentifierClass sets the function that maps identifiers to identifier classes, which are used as the
+// "identifier_class" dimension of the "limiter.rejected" metric and span events. Since identifiers usually contain user
+// IDs or IP addresses, the classes should have low cardinality.
+//
+// Defaults to the part before the first colon, e.g. "user" for "user:42", or [DefaultIdentifierClass] if the identifier
+// doesn't contain a colon.
+func WithIdentifierClass(classify func(identifier string) string) Option {
+	return func(s *serviceImpl) {
+		if classify != nil {
+			s.classify = classify
+		}
+	}
 }
 
-// NewService initializes a new limiter service.
-func NewService(cfg *Config, rc rueidis.Client) (Service, error) {
+/*
+NewService initializes a new limiter service.
+
+The rueidis client is required by [BackendValkey], and can be nil if the backend is [BackendMemory]. An empty backend
+is treated as [BackendValkey].
+
+Rejections are reported to OpenTelemetry in 2 ways:
+
+  - Every rejected attempt adds an [EventRejected] event to the active span in the context if any, with the attempt
+    count, the remaining quota and the reset time.
+  - Requests that are finally rejected increase the "limiter.rejected" counter of the global meter provider, with the
+    identifier class as a dimension, see [WithIdentifierClass].
+*/
+func NewService(cfg *Config, rc rueidis.Client, opts ...Option) (Service, error) {
 	// Check arguments
-	if cfg == nil || rc == nil {
+	if cfg == nil {
 		return nil, constant.ErrNilDeps
 	}
+	switch cfg.Backend {
+	case "", BackendValkey:
+		if rc == nil {
+			return nil, constant.ErrNilDeps
+		}
+	case BackendMemory:
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownBackend, cfg.Backend)
+	}
+	patterns, err := sortPatterns(cfg.Limits)
+	if err != nil {
+		return nil, err
+	}
 
-	// Initialize rueidis limiter
-	rl, _ := rueidislimiter.NewRateLimiter(rueidislimiter.RateLimiterOption{
-		ClientBuilder: func(_ rueidis.ClientOption) (rueidis.Client, error) { return rc, nil },
-		KeyPrefix:     "peak_" + cfg.Prefix,
-		Limit:         cfg.Limit,
-		Window:        time.Duration(cfg.WindowMs) * time.Millisecond,
-	})
+	// Initialize rejection counter
+	rejected, err := otel.Meter(pkgName).Int64Counter("limiter.rejected",
+		metric.WithDescription("The number of requests rejected by the limiter."),
+		metric.WithUnit("{request}"))
+	if err != nil {
+		rejected = noop.Int64Counter{}
+	}
 
 	// Initialize service
-	return &serviceImpl{
-		rl,
+	s := &serviceImpl{
+		nil,
 		log.NewLogger(pkgName),
 		cfg,
-	}, nil
+		clock.Real(),
+		defaultIdentifierClass,
+		rejected,
+		patterns,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	// Initialize rate limiter client
+	if cfg.Backend == BackendMemory {
+		s.rl = newMemoryLimiter(int64(cfg.Limit), time.Duration(cfg.WindowMs)*time.Millisecond, s.clk)
+	} else {
+		rl, _ := rueidislimiter.NewRateLimiter(rueidislimiter.RateLimiterOption{
+			ClientBuilder: func(_ rueidis.ClientOption) (rueidis.Client, error) { return rc, nil },
+			KeyPrefix:     "peak_" + cfg.Prefix,
+			Limit:         cfg.Limit,
+			Window:        time.Duration(cfg.WindowMs) * time.Millisecond,
+		})
+		s.rl = valkeyLimiter{rl}
+	}
+	return s, nil
 }
 
-func (s *serviceImpl) Check(ctx context.Context, identifier string, options ...rueidislimiter.RateLimitOption) (
-	rueidislimiter.Result, error) {
-	// Return if limiter is disabled
-	if !s.cfg.Enable {
-		if s.cfg.EnableLog {
-			s.l.DebugContext(ctx, "Limiter disabled. Skipping...")
+// sortPatterns validates the patterns of limit rules, and sorts them by precedence.
+func sortPatterns(limits map[string]LimitRule) ([]string, error) {
+	patterns := make([]string, 0, len(limits))
+	for pattern := range limits {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("%w: %s", err, pattern)
+		}
+		patterns = append(patterns, pattern)
+	}
+	slices.SortFunc(patterns, func(a, b string) int {
+		if len(a) != len(b) {
+			return len(b) - len(a)
+		}
+		return strings.Compare(a, b)
+	})
+	return patterns, nil
+}
+
+// rateLimitOptions returns the options of the limit rule matching identifier, unless options are specified explicitly.
+func (s *serviceImpl) rateLimitOptions(identifier string,
+	options []RateLimitOption) []RateLimitOption {
+	if len(options) > 0 || len(s.patterns) == 0 {
+		return options
+	}
+	rule, ok := s.cfg.Limits[identifier]
+	if !ok {
+		for _, pattern := range s.patterns {
+			if matched, _ := path.Match(pattern, identifier); matched {
+				rule, ok = s.cfg.Limits[pattern], true
+				break
+			}
 		}
-		return rueidislimiter.Result{Allowed: true}, nil
 	}
-	return s.rl.Check(ctx, identifier, options...)
+	if !ok {
+		return options
+	}
+	return []RateLimitOption{WithCustomRateLimit(rule.Limit, time.Duration(rule.WindowMs)*time.Millisecond)}
+}
+
+// newResult returns an empty result with the limit and window applied by the options.
+func (s *serviceImpl) newResult(options []RateLimitOption) Result {
+	if len(options) > 0 {
+		opt := options[len(options)-1]
+		return Result{Limit: int64(opt.Limit), Window: opt.Window}
+	}
+	return Result{Limit: int64(s.cfg.Limit), Window: time.Duration(s.cfg.WindowMs) * time.Millisecond}
+}
+
+func defaultIdentifierClass(identifier string) string {
+	if class, _, ok := strings.Cut(identifier, ":"); ok && len(class) > 0 {
+		return class
+	}
+	return DefaultIdentifierClass
+}
+
+// recordRejection adds a span event for the rejected attempt, and increases the rejection counter if the request is
+// finally rejected.
+func (s *serviceImpl) recordRejection(ctx context.Context, identifier string, attempt int,
+	result rueidislimiter.Result, final bool) {
+	class := attribute.String("identifier_class", s.classify(identifier))
+	if span := trace.SpanFromContext(ctx); span.IsRecording() {
+		span.AddEvent(EventRejected, trace.WithAttributes(
+			class,
+			attribute.Int("attempt", attempt),
+			attribute.Int64("remaining", result.Remaining),
+			attribute.String("reset_at", time.UnixMilli(result.ResetAtMs).UTC().Format(time.RFC3339Nano)),
+			attribute.Bool("final", final),
+		))
+	}
+	if final {
+		s.rejected.Add(ctx, 1, metric.WithAttributes(class))
+	}
+}
+
+// optionLimiter returns the [rateLimiter] that applies rueidislimiter options.
+func (s *serviceImpl) optionLimiter(options []rueidislimiter.RateLimitOption) rateLimiter {
+	if len(options) == 0 {
+		return s.rl
+	}
+	if vl, ok := s.rl.(valkeyLimiter); ok {
+		return valkeyOptionLimiter{vl.rl, options}
+	}
+	return unsupportedLimiter{}
+}
+
+func (s *serviceImpl) Check(ctx context.Context, identifier string, options ...rueidislimiter.RateLimitOption) (
+	rueidislimiter.Result, error) {
+	result, err := s.check(ctx, s.optionLimiter(options), identifier, nil)
+	return result.Result, err
 }
 
 func (s *serviceImpl) Allow(ctx context.Context, identifier string, options ...rueidislimiter.RateLimitOption) (
 	rueidislimiter.Result, error) {
 	logger := s.l.With(constant.LogAttrMethod, "Allow", "identifier", identifier)
-	return s.allowN(ctx, identifier, 1, logger, options...)
+	result, err := s.allowN(ctx, s.optionLimiter(options), identifier, 1, logger, nil)
+	return result.Result, err
 }
 
 func (s *serviceImpl) AllowN(ctx context.Context, identifier string, n int64,
 	options ...rueidislimiter.RateLimitOption) (rueidislimiter.Result, error) {
 	logger := s.l.With(constant.LogAttrMethod, "AllowN", "identifier", identifier, "n", n)
-	return s.allowN(ctx, identifier, n, logger, options...)
+	result, err := s.allowN(ctx, s.optionLimiter(options), identifier, n, logger, nil)
+	return result.Result, err
+}
+
+func (s *serviceImpl) CheckResult(ctx context.Context, identifier string, options ...RateLimitOption) (
+	Result, error) {
+	return s.check(ctx, s.rl, identifier, options)
+}
+
+func (s *serviceImpl) AllowResult(ctx context.Context, identifier string, options ...RateLimitOption) (
+	Result, error) {
+	logger := s.l.With(constant.LogAttrMethod, "AllowResult", "identifier", identifier)
+	return s.allowN(ctx, s.rl, identifier, 1, logger, options)
+}
+
+func (s *serviceImpl) AllowNResult(ctx context.Context, identifier string, n int64,
+	options ...RateLimitOption) (Result, error) {
+	logger := s.l.With(constant.LogAttrMethod, "AllowNResult", "identifier", identifier, "n", n)
+	return s.allowN(ctx, s.rl, identifier, n, logger, options)
+}
+
+func (s *serviceImpl) check(ctx context.Context, rl rateLimiter, identifier string,
+	options []RateLimitOption) (Result, error) {
+	// Return if limiter is disabled
+	if !s.cfg.Enable {
+		if s.cfg.EnableLog {
+			s.l.DebugContext(ctx, "Limiter disabled. Skipping...")
+		}
+		return allowed, nil
+	}
+	options = s.rateLimitOptions(identifier, options)
+	result := s.newResult(options)
+	var err error
+	result.Result, err = rl.AllowN(ctx, identifier, 0, options...)
+	return result, err
+}
+
+func (s *serviceImpl) WaitAllow(ctx context.Context, identifier string, options ...RateLimitOption) (
+	result Result, err error) {
+	logger := s.l.With(constant.LogAttrMethod, "WaitAllow", "identifier", identifier)
+
+	// Return if limiter is disabled
+	if !s.cfg.Enable {
+		if s.cfg.EnableLog {
+			logger.DebugContext(ctx, "Limiter disabled. Skipping...")
+		}
+		return allowed, nil
+	}
+	options = s.rateLimitOptions(identifier, options)
+	result = s.newResult(options)
+
+	for attempt := 1; ; attempt++ {
+		result.Result, err = s.rl.AllowN(ctx, identifier, 1, options...)
+		if err != nil {
+			if s.cfg.EnableLog {
+				logger.ErrorContext(ctx, "Wait allow error.", constant.LogAttrAttempt, attempt, constant.LogAttrError, err)
+			}
+			return
+		}
+		if result.Allowed {
+			if s.cfg.EnableLog {
+				logger.DebugContext(ctx, "Wait allow allowed.",
+					constant.LogAttrAttempt, attempt,
+					constant.LogAttrResult, result,
+				)
+			}
+			return
+		}
+
+		// Wait until the window resets, which happens 1 ms after the reset time
+		d := max(time.UnixMilli(result.ResetAtMs+1).Sub(s.clk.Now()), time.Millisecond)
+		if s.cfg.EnableLog {
+			logger.DebugContext(ctx, "Reached limit. Wait until reset.",
+				constant.LogAttrAttempt, attempt,
+				constant.LogAttrResult, result,
+				"wait", d,
+			)
+		}
+		err = s.wait(ctx, d)
+		s.recordRejection(ctx, identifier, attempt, result.Result, err != nil)
+		if err != nil {
+			return
+		}
+		result.Wait += d
+	}
 }
 
-func (s *serviceImpl) allowN(ctx context.Context, identifier string, n int64, logger *slog.Logger,
-	options ...rueidislimiter.RateLimitOption) (result rueidislimiter.Result, err error) {
+func (s *serviceImpl) allowN(ctx context.Context, rl rateLimiter, identifier string, n int64, logger *slog.Logger,
+	options []RateLimitOption) (result Result, err error) {
 	// Return if limiter is disabled
 	if !s.cfg.Enable {
 		if s.cfg.EnableLog {
 			logger.DebugContext(ctx, "Limiter disabled. Skipping...")
 		}
-		return rueidislimiter.Result{Allowed: true}, nil
+		return allowed, nil
 	}
+	options = s.rateLimitOptions(identifier, options)
+	result = s.newResult(options)
 
 	// If peak shaving is disabled
 	if s.cfg.MaxAttempts == 0 {
-		result, err = s.rl.AllowN(ctx, identifier, n, options...)
+		result.Result, err = rl.AllowN(ctx, identifier, n, options...)
+		if err == nil && !result.Allowed {
+			s.recordRejection(ctx, identifier, 1, result.Result, true)
+		}
 		if s.cfg.EnableLog {
 			if err != nil {
 				logger.ErrorContext(ctx, "Rate limit failed.", "result", result, constant.LogAttrError, err)
@@ -128,8 +509,9 @@ func (s *serviceImpl) allowN(ctx context.Context, identifier string, n int64, lo
 	}
 
 	// Attempt for N times
+	interval := time.Duration(s.cfg.AttemptIntervalMs) * time.Millisecond
 	for i := range s.cfg.MaxAttempts {
-		result, err = s.rl.AllowN(ctx, identifier, n, options...)
+		result.Result, err = rl.AllowN(ctx, identifier, n, options...)
 		if err != nil {
 			if s.cfg.EnableLog {
 				logger.ErrorContext(ctx, "Peak shaving error.",
@@ -153,7 +535,15 @@ func (s *serviceImpl) allowN(ctx context.Context, identifier string, n int64, lo
 				constant.LogAttrResult, result,
 			)
 		}
-		time.Sleep(time.Duration(s.cfg.AttemptIntervalMs) * time.Millisecond)
+		if err = s.wait(ctx, interval); err != nil {
+			s.recordRejection(ctx, identifier, i+1, result.Result, true)
+			if s.cfg.EnableLog {
+				logger.WarnContext(ctx, "Peak shaving cancelled.", constant.LogAttrError, err)
+			}
+			return
+		}
+		s.recordRejection(ctx, identifier, i+1, result.Result, i+1 == s.cfg.MaxAttempts)
+		result.Wait += interval
 	}
 	if s.cfg.EnableLog {
 		logger.ErrorContext(ctx, "Peak shaving hits max attempts.", constant.LogAttrResult, result)
@@ -161,3 +551,16 @@ func (s *serviceImpl) allowN(ctx context.Context, identifier string, n int64, lo
 
 	return
 }
+
+// wait waits for d, and returns the error of ctx if it's done first.
+func (s *serviceImpl) wait(ctx context.Context, d time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-s.clk.After(d):
+		return nil
+	}
+}
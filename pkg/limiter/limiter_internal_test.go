@@ -0,0 +1,112 @@
+package limiter
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/redis/rueidis/rueidislimiter"
+	"github.com/sainnhe/go-common/pkg/clock/testclock"
+	"github.com/sainnhe/go-common/pkg/log"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// rejectingClient is a [rateLimiter] that rejects all requests.
+type rejectingClient struct{}
+
+func (rejectingClient) AllowN(_ context.Context, _ string, _ int64, _ ...RateLimitOption) (
+	rueidislimiter.Result, error) {
+	return rueidislimiter.Result{Allowed: false, Remaining: 0, ResetAtMs: 1000}, nil
+}
+
+func TestLimiter_recordRejection(t *testing.T) {
+	t.Parallel()
+
+	reader := sdkmetric.NewManualReader()
+	rejected, err := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)).Meter(pkgName).
+		Int64Counter("limiter.rejected")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &serviceImpl{
+		rejectingClient{},
+		log.NewLogger(pkgName),
+		&Config{Enable: true, MaxAttempts: 2, AttemptIntervalMs: 10},
+		testclock.Freeze(time.Now()).WithAutoAdvance(),
+		defaultIdentifierClass,
+		rejected,
+		nil,
+	}
+
+	recorder := tracetest.NewSpanRecorder()
+	ctx, span := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("test").
+		Start(context.Background(), "test")
+	if result, err := s.Allow(ctx, "user:42"); result.Allowed || err != nil {
+		t.Fatalf("Expect rejected without error, got result = %+v, err = %+v", result, err)
+	}
+	span.End()
+
+	// Every attempt adds an event.
+	events := recorder.Ended()[0].Events()
+	if len(events) != 2 { // nolint:mnd
+		t.Fatalf("Expect 2 events, got %+v", events)
+	}
+	expected := []attribute.KeyValue{
+		attribute.String("identifier_class", "user"),
+		attribute.Int("attempt", 2),
+		attribute.Int64("remaining", 0),
+		attribute.String("reset_at", "1970-01-01T00:00:01Z"),
+		attribute.Bool("final", true),
+	}
+	got, want := attribute.NewSet(events[1].Attributes...), attribute.NewSet(expected...)
+	if events[1].Name != EventRejected || !got.Equals(&want) {
+		t.Fatalf("Expect event %s with attributes %+v, got %+v", EventRejected, expected, events[1])
+	}
+
+	// Only the final rejection is counted.
+	rm := metricdata.ResourceMetrics{}
+	if err := reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatal(err)
+	}
+	sum, ok := rm.ScopeMetrics[0].Metrics[0].Data.(metricdata.Sum[int64])
+	if !ok || len(sum.DataPoints) != 1 || sum.DataPoints[0].Value != 1 {
+		t.Fatalf("Expect 1 rejection, got %+v", rm.ScopeMetrics[0].Metrics[0].Data)
+	}
+	if v, _ := sum.DataPoints[0].Attributes.Value("identifier_class"); v.AsString() != "user" {
+		t.Fatalf("Expect identifier class user, got %s", v.AsString())
+	}
+
+	// Rate limit without peak shaving.
+	s.cfg.MaxAttempts = 0
+	if result, err := s.Allow(context.Background(), "ip"); result.Allowed || err != nil {
+		t.Fatalf("Expect rejected without error, got result = %+v, err = %+v", result, err)
+	}
+	if err := reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatal(err)
+	}
+	sum, _ = rm.ScopeMetrics[0].Metrics[0].Data.(metricdata.Sum[int64])
+	if len(sum.DataPoints) != 2 { // nolint:mnd
+		t.Fatalf("Expect data points of 2 identifier classes, got %+v", sum.DataPoints)
+	}
+}
+
+func TestDefaultIdentifierClass(t *testing.T) {
+	t.Parallel()
+
+	for identifier, expected := range map[string]string{
+		"user:42":  "user",
+		"ip:::1":   "ip",
+		":42":      DefaultIdentifierClass,
+		"login":    DefaultIdentifierClass,
+		"":         DefaultIdentifierClass,
+		"api:v1:x": "api",
+	} {
+		if class := defaultIdentifierClass(identifier); class != expected {
+			t.Errorf("Expect class of %q to be %q, got %q", identifier, expected, class)
+		}
+	}
+}
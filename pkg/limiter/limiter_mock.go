@@ -80,6 +80,46 @@ func (mr *MockServiceMockRecorder) AllowN(ctx, identifier, n any, options ...any
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AllowN", reflect.TypeOf((*MockService)(nil).AllowN), varargs...)
 }
 
+// AllowNResult mocks base method.
+func (m *MockService) AllowNResult(ctx context.Context, identifier string, n int64, options ...RateLimitOption) (Result, error) {
+	m.ctrl.T.Helper()
+	varargs := []any{ctx, identifier, n}
+	for _, a := range options {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "AllowNResult", varargs...)
+	ret0, _ := ret[0].(Result)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AllowNResult indicates an expected call of AllowNResult.
+func (mr *MockServiceMockRecorder) AllowNResult(ctx, identifier, n any, options ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{ctx, identifier, n}, options...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AllowNResult", reflect.TypeOf((*MockService)(nil).AllowNResult), varargs...)
+}
+
+// AllowResult mocks base method.
+func (m *MockService) AllowResult(ctx context.Context, identifier string, options ...RateLimitOption) (Result, error) {
+	m.ctrl.T.Helper()
+	varargs := []any{ctx, identifier}
+	for _, a := range options {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "AllowResult", varargs...)
+	ret0, _ := ret[0].(Result)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AllowResult indicates an expected call of AllowResult.
+func (mr *MockServiceMockRecorder) AllowResult(ctx, identifier any, options ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{ctx, identifier}, options...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AllowResult", reflect.TypeOf((*MockService)(nil).AllowResult), varargs...)
+}
+
 // Check mocks base method.
 func (m *MockService) Check(ctx context.Context, identifier string, options ...rueidislimiter.RateLimitOption) (rueidislimiter.Result, error) {
 	m.ctrl.T.Helper()
@@ -99,3 +139,43 @@ func (mr *MockServiceMockRecorder) Check(ctx, identifier any, options ...any) *g
 	varargs := append([]any{ctx, identifier}, options...)
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Check", reflect.TypeOf((*MockService)(nil).Check), varargs...)
 }
+
+// CheckResult mocks base method.
+func (m *MockService) CheckResult(ctx context.Context, identifier string, options ...RateLimitOption) (Result, error) {
+	m.ctrl.T.Helper()
+	varargs := []any{ctx, identifier}
+	for _, a := range options {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "CheckResult", varargs...)
+	ret0, _ := ret[0].(Result)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CheckResult indicates an expected call of CheckResult.
+func (mr *MockServiceMockRecorder) CheckResult(ctx, identifier any, options ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{ctx, identifier}, options...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckResult", reflect.TypeOf((*MockService)(nil).CheckResult), varargs...)
+}
+
+// WaitAllow mocks base method.
+func (m *MockService) WaitAllow(ctx context.Context, identifier string, options ...RateLimitOption) (Result, error) {
+	m.ctrl.T.Helper()
+	varargs := []any{ctx, identifier}
+	for _, a := range options {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "WaitAllow", varargs...)
+	ret0, _ := ret[0].(Result)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// WaitAllow indicates an expected call of WaitAllow.
+func (mr *MockServiceMockRecorder) WaitAllow(ctx, identifier any, options ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{ctx, identifier}, options...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WaitAllow", reflect.TypeOf((*MockService)(nil).WaitAllow), varargs...)
+}
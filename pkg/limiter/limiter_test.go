@@ -4,8 +4,11 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/redis/rueidis"
+	"github.com/redis/rueidis/rueidislimiter"
+	"github.com/sainnhe/go-common/pkg/clock/testclock"
 	"github.com/sainnhe/go-common/pkg/limiter"
 )
 
@@ -71,7 +74,7 @@ func TestLimiter_peakShavingFailed(t *testing.T) {
 			MaxAttempts:       2,
 			AttemptIntervalMs: 500,
 			EnableLog:         true,
-		}, rueidisClient)
+		}, rueidisClient, limiter.WithClock(testclock.Freeze(time.Now()).WithAutoAdvance()))
 	if s == nil || err != nil {
 		t.Fatalf("Got service = %+v, err = %+v", s, err)
 	}
@@ -82,3 +85,167 @@ func TestLimiter_peakShavingFailed(t *testing.T) {
 		t.Fatalf("Expect not allowed and nil error, got result = %+v, err = %+v", result, err)
 	}
 }
+
+func TestLimiter_unknownBackend(t *testing.T) {
+	t.Parallel()
+
+	if _, err := limiter.NewService(&limiter.Config{Backend: "foo"}, nil); !errors.Is(err, limiter.ErrUnknownBackend) {
+		t.Fatalf("Expect error %+v, got %+v", limiter.ErrUnknownBackend, err)
+	}
+	if _, err := limiter.NewService(&limiter.Config{Backend: limiter.BackendValkey}, nil); err == nil {
+		t.Fatal("Expect error, got nil")
+	}
+}
+
+func TestLimiter_memory(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	clk := testclock.Freeze(time.Now())
+	s, err := limiter.NewService(&limiter.Config{
+		Enable:   true,
+		Backend:  limiter.BackendMemory,
+		Limit:    2,
+		WindowMs: 1000,
+	}, nil, limiter.WithClock(clk))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if r, err := s.Allow(ctx, "foo"); err != nil || !r.Allowed || r.Remaining != 1 {
+		t.Fatalf("Expect allowed, got result = %+v, err = %+v", r, err)
+	}
+	if r, err := s.Check(ctx, "foo"); err != nil || !r.Allowed || r.Remaining != 1 {
+		t.Fatalf("Expect allowed, got result = %+v, err = %+v", r, err)
+	}
+	if r, err := s.AllowN(ctx, "foo", 2); err != nil || r.Allowed || r.Remaining != 0 {
+		t.Fatalf("Expect rejected, got result = %+v, err = %+v", r, err)
+	}
+	if r, err := s.Allow(ctx, "bar"); err != nil || !r.Allowed {
+		t.Fatalf("Expect allowed, got result = %+v, err = %+v", r, err)
+	}
+	if r, err := s.AllowResult(ctx, "bar", limiter.WithCustomRateLimit(1, time.Second)); err != nil || r.Allowed ||
+		r.Limit != 1 || r.Window != time.Second {
+		t.Fatalf("Expect rejected, got result = %+v, err = %+v", r, err)
+	}
+	_, err = s.Allow(ctx, "bar", rueidislimiter.WithCustomRateLimit(1, time.Second))
+	if !errors.Is(err, limiter.ErrUnsupportedOption) {
+		t.Fatalf("Expect error %+v, got %+v", limiter.ErrUnsupportedOption, err)
+	}
+	if _, err := s.AllowN(ctx, "foo", -1); !errors.Is(err, rueidislimiter.ErrInvalidTokens) {
+		t.Fatalf("Expect error %+v, got %+v", rueidislimiter.ErrInvalidTokens, err)
+	}
+
+	clk.Advance(time.Second + time.Millisecond)
+	if r, err := s.Allow(ctx, "foo"); err != nil || !r.Allowed || r.Remaining != 1 ||
+		r.ResetAtMs != clk.Now().Add(time.Second).UnixMilli() {
+		t.Fatalf("Expect allowed, got result = %+v, err = %+v", r, err)
+	}
+}
+
+func TestLimiter_limits(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	cfg := &limiter.Config{
+		Enable:   true,
+		Backend:  limiter.BackendMemory,
+		Limit:    1,
+		WindowMs: 1000,
+		Limits: map[string]limiter.LimitRule{
+			"user:*":         {Limit: 2, WindowMs: 1000},
+			"user:premium:*": {Limit: 3, WindowMs: 1000},
+			"user:admin":     {Limit: 4, WindowMs: 1000},
+		},
+	}
+	s, err := limiter.NewService(cfg, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		identifier string
+		options    []limiter.RateLimitOption
+		want       int
+	}{
+		{"anonymous", nil, 1},
+		{"user:42", nil, 2},
+		{"user:premium:42", nil, 3},
+		{"user:admin", nil, 4},
+		{"user:43", []limiter.RateLimitOption{limiter.WithCustomRateLimit(5, time.Second)}, 5},
+	}
+	for _, tt := range tests {
+		for i := range tt.want + 1 {
+			r, err := s.AllowResult(ctx, tt.identifier, tt.options...)
+			if err != nil || r.Allowed != (i < tt.want) {
+				t.Fatalf("[%s] Unexpected result %+v at attempt %d, err = %+v", tt.identifier, r, i+1, err)
+			}
+		}
+	}
+
+	cfg.Limits = map[string]limiter.LimitRule{"[": {}}
+	if _, err := limiter.NewService(cfg, nil); err == nil {
+		t.Fatal("Expect error, got nil")
+	}
+}
+
+func TestLimiter_waitAllow(t *testing.T) {
+	t.Parallel()
+
+	clk := testclock.Freeze(time.Now().Truncate(time.Millisecond)).WithAutoAdvance()
+	s, err := limiter.NewService(&limiter.Config{
+		Enable:   true,
+		Backend:  limiter.BackendMemory,
+		Limit:    1,
+		WindowMs: 1000,
+	}, nil, limiter.WithClock(clk))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	if r, err := s.WaitAllow(ctx, "foo"); err != nil || !r.Allowed || r.Wait != 0 {
+		t.Fatalf("Expect allowed without waiting, got result = %+v, err = %+v", r, err)
+	}
+	if r, err := s.WaitAllow(ctx, "foo"); err != nil || !r.Allowed || r.Wait != time.Second+time.Millisecond {
+		t.Fatalf("Expect allowed after waiting, got result = %+v, err = %+v", r, err)
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err := s.WaitAllow(cancelled, "foo"); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expect error %+v, got %+v", context.Canceled, err)
+	}
+}
+
+func TestLimiter_peakShavingCancelled(t *testing.T) {
+	t.Parallel()
+
+	clk := testclock.Freeze(time.Now())
+	s, err := limiter.NewService(&limiter.Config{
+		Enable:            true,
+		Backend:           limiter.BackendMemory,
+		Limit:             1,
+		WindowMs:          60000,
+		MaxAttempts:       3,
+		AttemptIntervalMs: 1000,
+	}, nil, limiter.WithClock(clk))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	if r, err := s.Allow(ctx, "foo"); err != nil || !r.Allowed {
+		t.Fatalf("Expect allowed, got result = %+v, err = %+v", r, err)
+	}
+
+	done := make(chan error)
+	go func() {
+		_, err := s.Allow(ctx, "foo")
+		done <- err
+	}()
+	clk.BlockUntil(1)
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expect error %+v, got %+v", context.Canceled, err)
+	}
+}
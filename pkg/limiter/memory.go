@@ -0,0 +1,91 @@
+package limiter
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/redis/rueidis/rueidislimiter"
+	"github.com/sainnhe/go-common/pkg/clock"
+)
+
+// memoryLimiter is an in-process [rateLimiter]. It implements the same fixed window algorithm as
+// rueidislimiter, so that it behaves the same as [BackendValkey] except that counters are not shared across processes.
+type memoryLimiter struct {
+	mu        sync.Mutex
+	clk       clock.Clock
+	limit     int64
+	window    time.Duration
+	windows   map[string]*memoryWindow
+	lastSweep time.Time
+}
+
+type memoryWindow struct {
+	current   int64
+	expiresAt time.Time
+}
+
+func newMemoryLimiter(limit int64, window time.Duration, clk clock.Clock) *memoryLimiter {
+	// Same as rueidislimiter.NewRateLimiter
+	if window < time.Millisecond {
+		window = time.Millisecond
+	}
+	if limit <= 0 {
+		limit = 1
+	}
+	return &memoryLimiter{
+		clk:       clk,
+		limit:     limit,
+		window:    window,
+		windows:   map[string]*memoryWindow{},
+		lastSweep: clk.Now(),
+	}
+}
+
+func (l *memoryLimiter) AllowN(_ context.Context, identifier string, n int64, options ...RateLimitOption) (
+	rueidislimiter.Result, error) {
+	if n < 0 {
+		return rueidislimiter.Result{}, rueidislimiter.ErrInvalidTokens
+	}
+	limit, window := l.limit, l.window
+	if len(options) > 0 {
+		opt := options[len(options)-1]
+		limit, window = int64(opt.Limit), opt.Window
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.clk.Now()
+	l.sweep(now)
+	w, ok := l.windows[identifier]
+	if !ok || w.expiresAt.Before(now) {
+		w = &memoryWindow{0, now.Add(window)}
+		l.windows[identifier] = w
+	}
+	w.current += n
+
+	allowed := w.current <= limit
+	if n == 0 {
+		allowed = w.current < limit
+	}
+	return rueidislimiter.Result{
+		Allowed:   allowed,
+		Remaining: max(limit-w.current, 0),
+		ResetAtMs: w.expiresAt.UnixMilli(),
+	}, nil
+}
+
+// sweep deletes expired windows at most once per default window, so that memory usage doesn't grow with the number of
+// identifiers over time.
+func (l *memoryLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < l.window {
+		return
+	}
+	for identifier, w := range l.windows {
+		if w.expiresAt.Before(now) {
+			delete(l.windows, identifier)
+		}
+	}
+	l.lastSweep = now
+}
@@ -0,0 +1,222 @@
+/*
+Package listparams parses the sort and filter parameters of list endpoints, and translates them into conditions and
+options of [db.StmtBuilder].
+
+Sort parameters are comma separated fields, where a leading "-" sorts in descending order, and filter parameters are
+like "filter[field]=value" or "filter[field][op]=value":
+
+	?sort=-created_at,name&filter[status]=active,pending&filter[age][gte]=18
+
+If [Schema.SoftDelete] is enabled, "include_deleted=true" includes soft-deleted rows and "only_deleted=true" queries
+soft-deleted rows only.
+
+Only fields in the [Schema] are accepted, and values are always passed as arguments, so the parameters can't inject
+SQL:
+
+	schema := &listparams.Schema{
+		Sorts:   map[string]string{"created_at": "create_time", "name": "name"},
+		Filters: map[string]string{"status": "status", "age": "age"},
+	}
+	p, err := listparams.Parse(r.URL.Query(), schema)
+	cond, args := p.Cond()
+	stmt := sb.BuildQueryCondStmt(nil, cond, p.QueryOptions()...)
+	err = pool.SelectContext(ctx, &users, stmt, args...)
+*/
+package listparams
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/sainnhe/go-common/pkg/constant"
+	"github.com/sainnhe/go-common/pkg/db"
+)
+
+var (
+	// ErrInvalidSort indicates that a sort field is not allowed.
+	ErrInvalidSort = errors.New("invalid sort")
+
+	// ErrInvalidFilter indicates that a filter field or operator is not allowed.
+	ErrInvalidFilter = errors.New("invalid filter")
+)
+
+// ops maps filter operators to SQL operators.
+var ops = map[string]string{
+	"eq":  "=",
+	"ne":  "<>",
+	"gt":  ">",
+	"gte": ">=",
+	"lt":  "<",
+	"lte": "<=",
+}
+
+// Schema is the allowlist of the parameters.
+type Schema struct {
+	// Sorts maps the sortable fields in requests to column names.
+	Sorts map[string]string
+
+	// Filters maps the filterable fields in requests to column names.
+	Filters map[string]string
+
+	// DefaultSorts are used if the sort parameter is missing.
+	DefaultSorts []db.Order
+
+	// MaxSorts is the maximum number of sort fields. Non-positive values mean no limit.
+	MaxSorts int
+
+	// SoftDelete enables the boolean "include_deleted" and "only_deleted" parameters, which take effect on builders
+	// returned by [db.StmtBuilder.WithSoftDelete].
+	SoftDelete bool
+}
+
+// Filter is a parsed filter.
+type Filter struct {
+	// Col is the column name.
+	Col string
+
+	// Op is the filter operator, which is one of "eq", "ne", "gt", "gte", "lt" and "lte".
+	Op string
+
+	// Values are the values to compare. Multiple values are combined with OR for "eq", and with AND for other
+	// operators.
+	Values []string
+}
+
+// Params are the parsed parameters.
+type Params struct {
+	// Sorts are the orders in the ORDER BY clause.
+	Sorts []db.Order
+
+	// Filters are the filters combined with AND.
+	Filters []Filter
+
+	// IncludeDeleted indicates whether to include soft-deleted rows.
+	IncludeDeleted bool
+
+	// OnlyDeleted indicates whether to query soft-deleted rows only.
+	OnlyDeleted bool
+}
+
+/*
+Parse parses the "sort" and "filter[...]" parameters in query according to schema.
+
+Params:
+  - query: The query parameters.
+  - schema: The allowlist.
+
+Returns:
+  - *Params: The parsed parameters, sorted by filter columns so that the built statement is stable.
+  - error: [constant.ErrNilDeps] if schema is nil, or [ErrInvalidSort] or [ErrInvalidFilter] if a field or operator
+    is not allowed.
+*/
+func Parse(query url.Values, schema *Schema) (*Params, error) {
+	if schema == nil {
+		return nil, constant.ErrNilDeps
+	}
+	p := &Params{}
+	if sort := query.Get("sort"); len(sort) > 0 {
+		for field := range strings.SplitSeq(sort, ",") {
+			field = strings.TrimSpace(field)
+			desc := strings.HasPrefix(field, "-")
+			field = strings.TrimPrefix(field, "-")
+			col, ok := schema.Sorts[field]
+			if !ok {
+				return nil, fmt.Errorf("%w: %q", ErrInvalidSort, field)
+			}
+			p.Sorts = append(p.Sorts, db.Order{Col: col, Desc: desc})
+		}
+		if schema.MaxSorts > 0 && len(p.Sorts) > schema.MaxSorts {
+			return nil, fmt.Errorf("%w: more than %d fields", ErrInvalidSort, schema.MaxSorts)
+		}
+	} else {
+		p.Sorts = schema.DefaultSorts
+	}
+
+	if schema.SoftDelete {
+		for param, dst := range map[string]*bool{"include_deleted": &p.IncludeDeleted, "only_deleted": &p.OnlyDeleted} {
+			if val := query.Get(param); len(val) > 0 {
+				b, err := strconv.ParseBool(val)
+				if err != nil {
+					return nil, fmt.Errorf("%w: %s=%q", ErrInvalidFilter, param, val)
+				}
+				*dst = b
+			}
+		}
+	}
+
+	for key, vals := range query {
+		rest, ok := strings.CutPrefix(key, "filter[")
+		if !ok {
+			continue
+		}
+		field, rest, ok := strings.Cut(rest, "]")
+		op := "eq"
+		switch {
+		case !ok:
+			return nil, fmt.Errorf("%w: %q", ErrInvalidFilter, key)
+		case len(rest) > 0:
+			if !strings.HasPrefix(rest, "[") || !strings.HasSuffix(rest, "]") {
+				return nil, fmt.Errorf("%w: %q", ErrInvalidFilter, key)
+			}
+			op = rest[1 : len(rest)-1]
+		}
+		col, ok := schema.Filters[field]
+		if !ok {
+			return nil, fmt.Errorf("%w: field %q", ErrInvalidFilter, field)
+		}
+		if _, ok := ops[op]; !ok {
+			return nil, fmt.Errorf("%w: operator %q", ErrInvalidFilter, op)
+		}
+		f := Filter{Col: col, Op: op}
+		for _, val := range vals {
+			for v := range strings.SplitSeq(val, ",") {
+				f.Values = append(f.Values, strings.TrimSpace(v))
+			}
+		}
+		p.Filters = append(p.Filters, f)
+	}
+	slices.SortFunc(p.Filters, func(a, b Filter) int {
+		return cmp.Or(cmp.Compare(a.Col, b.Col), cmp.Compare(a.Op, b.Op))
+	})
+	return p, nil
+}
+
+// Cond returns the condition of the filters, where values are replaced by [db.Placeholder] and returned as args in
+// order. If there are no filters, the returned condition is empty.
+func (p *Params) Cond() (cond db.Cond, args []any) {
+	conds := make([]db.Cond, 0, len(p.Filters))
+	for _, f := range p.Filters {
+		vals := make([]db.Cond, 0, len(f.Values))
+		for _, v := range f.Values {
+			vals = append(vals, db.Cmp(f.Col, ops[f.Op], db.Placeholder))
+			args = append(args, v)
+		}
+		if f.Op == "eq" {
+			conds = append(conds, db.Or(vals...))
+		} else {
+			conds = append(conds, db.And(vals...))
+		}
+	}
+	return db.And(conds...), args
+}
+
+// QueryOptions returns the options of the sorts and the soft delete mode, which can be passed to query statement
+// builders of [db.StmtBuilder].
+func (p *Params) QueryOptions() []db.QueryOption {
+	var opts []db.QueryOption
+	if len(p.Sorts) > 0 {
+		opts = append(opts, db.WithOrderBy(p.Sorts...))
+	}
+	switch {
+	case p.OnlyDeleted:
+		opts = append(opts, db.WithOnlyDeleted())
+	case p.IncludeDeleted:
+		opts = append(opts, db.WithDeleted())
+	}
+	return opts
+}
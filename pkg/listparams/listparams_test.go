@@ -0,0 +1,109 @@
+package listparams_test
+
+import (
+	"errors"
+	"net/url"
+	"slices"
+	"testing"
+
+	"github.com/sainnhe/go-common/pkg/db"
+	"github.com/sainnhe/go-common/pkg/listparams"
+)
+
+var schema = &listparams.Schema{
+	Sorts:        map[string]string{"created_at": "create_time", "name": "name"},
+	Filters:      map[string]string{"status": "status", "age": "age"},
+	DefaultSorts: []db.Order{db.Desc("id")},
+	MaxSorts:     2,
+	SoftDelete:   true,
+}
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	query, err := url.ParseQuery("sort=-created_at,name&filter[status]=active,pending&filter[age][gte]=18" +
+		"&filter[age][lt]=60&page=2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := listparams.Parse(query, schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cond, args := p.Cond()
+	sb := db.NewStmtBuilder("users", "pgx")
+	want := "SELECT * FROM users WHERE age >= $1 AND age < $2 AND (status = $3 OR status = $4) " +
+		"ORDER BY \"create_time\" DESC, \"name\" ASC"
+	if s := sb.BuildQueryCondStmt(nil, cond, p.QueryOptions()...); s != want {
+		t.Fatalf("Want %s\nGot %s", want, s)
+	}
+	if !slices.Equal(args, []any{"18", "60", "active", "pending"}) {
+		t.Fatalf("Unexpected args %v", args)
+	}
+
+	// Default sorts without filters
+	p, err = listparams.Parse(url.Values{}, schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cond, args = p.Cond()
+	if s := sb.BuildQueryCondStmt(nil, cond, p.QueryOptions()...); s != "SELECT * FROM users ORDER BY \"id\" DESC" ||
+		len(args) > 0 {
+		t.Fatalf("Got %s %v", s, args)
+	}
+
+	// Soft delete
+	sb = sb.WithSoftDelete("delete_time")
+	for query, want := range map[string]string{
+		"":                     "SELECT * FROM users WHERE \"delete_time\" IS NULL ORDER BY \"id\" DESC",
+		"include_deleted=true": "SELECT * FROM users ORDER BY \"id\" DESC",
+		"only_deleted=1":       "SELECT * FROM users WHERE \"delete_time\" IS NOT NULL ORDER BY \"id\" DESC",
+	} {
+		values, err := url.ParseQuery(query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if p, err = listparams.Parse(values, schema); err != nil {
+			t.Fatal(err)
+		}
+		cond, _ = p.Cond()
+		if s := sb.BuildQueryCondStmt(nil, cond, p.QueryOptions()...); s != want {
+			t.Fatalf("Want %s\nGot %s", want, s)
+		}
+	}
+	p, err = listparams.Parse(url.Values{"only_deleted": {"true"}}, &listparams.Schema{})
+	if err != nil || p.OnlyDeleted {
+		t.Fatalf("Expect only_deleted to be ignored, got %+v, %+v", p, err)
+	}
+}
+
+func TestParse_invalid(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		query string
+		want  error
+	}{
+		{"sort=password", listparams.ErrInvalidSort},
+		{"sort=name,-created_at,name", listparams.ErrInvalidSort},
+		{"filter[password]=x", listparams.ErrInvalidFilter},
+		{"filter[age][like]=1", listparams.ErrInvalidFilter},
+		{"filter[age]gte=1", listparams.ErrInvalidFilter},
+		{"filter[age=1", listparams.ErrInvalidFilter},
+		{"include_deleted=maybe", listparams.ErrInvalidFilter},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			t.Parallel()
+
+			query, err := url.ParseQuery(tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := listparams.Parse(query, schema); !errors.Is(err, tt.want) {
+				t.Fatalf("Want %+v, got %+v", tt.want, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,201 @@
+package log
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"sync"
+	"sync/atomic"
+
+	"github.com/sainnhe/go-common/pkg/bufpool"
+	"github.com/sainnhe/go-common/pkg/constant"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/noop"
+)
+
+const (
+	// AsyncPolicyDrop drops records when the queue of [AsyncWriter] is full.
+	AsyncPolicyDrop = "drop"
+
+	// AsyncPolicyBlock blocks the caller when the queue of [AsyncWriter] is full.
+	AsyncPolicyBlock = "block"
+
+	// defaultAsyncQueueSize is the queue size used when the given one is not positive.
+	defaultAsyncQueueSize = 1024
+)
+
+var (
+	// ErrInvalidAsyncPolicy indicates that the policy of [AsyncWriter] is neither [AsyncPolicyDrop] nor
+	// [AsyncPolicyBlock].
+	ErrInvalidAsyncPolicy = errors.New("invalid async policy")
+
+	droppedCounter     metric.Int64Counter
+	droppedCounterOnce sync.Once
+)
+
+// AsyncWriter is an [io.Writer] that writes to the underlying writer in a background goroutine, so that slow writers
+// like disk files don't add latency to the callers.
+//
+// Records are queued in a bounded queue. When the queue is full, they are either dropped or block the caller depending
+// on the policy. Dropped records are counted by [AsyncWriter.Dropped] and the "log.async.dropped" OpenTelemetry
+// counter.
+//
+// [AsyncWriter.Flush] or [AsyncWriter.Close] must be called to write queued records before the program exits. The
+// async writer of the global config is flushed by [Flush], which the graceful package calls at the end of the shutdown
+// process.
+type AsyncWriter struct {
+	w       io.Writer
+	queue   chan asyncRecord
+	block   bool
+	dropped atomic.Uint64
+	mu      sync.RWMutex
+	closed  bool
+	done    chan struct{}
+}
+
+// asyncRecord is an item in the queue of [AsyncWriter], which is either a record to write or a flush marker that is
+// closed once all records queued before it are written.
+type asyncRecord struct {
+	buf     *bytes.Buffer
+	flushed chan struct{}
+}
+
+/*
+NewAsyncWriter initializes a new [AsyncWriter] and starts its background goroutine.
+
+Params:
+  - w [io.Writer]: The underlying writer. Write errors are ignored since there is no caller to report them to.
+  - queueSize int: The maximum number of queued records. Defaults to 1024 if it's not positive.
+  - policy string: The policy when the queue is full, which should be [AsyncPolicyDrop] or [AsyncPolicyBlock]. Defaults
+    to [AsyncPolicyDrop] if it's empty.
+
+Returns:
+  - *AsyncWriter: The async writer.
+  - error: The error occurred during the execution, which may be [constant.ErrNilDeps] or [ErrInvalidAsyncPolicy].
+*/
+func NewAsyncWriter(w io.Writer, queueSize int, policy string) (*AsyncWriter, error) {
+	if w == nil {
+		return nil, constant.ErrNilDeps
+	}
+	var block bool
+	switch policy {
+	case AsyncPolicyDrop, "":
+	case AsyncPolicyBlock:
+		block = true
+	default:
+		return nil, ErrInvalidAsyncPolicy
+	}
+	if queueSize <= 0 {
+		queueSize = defaultAsyncQueueSize
+	}
+
+	aw := &AsyncWriter{
+		w:     w,
+		queue: make(chan asyncRecord, queueSize),
+		block: block,
+		done:  make(chan struct{}),
+	}
+	go aw.run()
+	return aw, nil
+}
+
+// Write queues a copy of p. It never returns an error unless the writer is closed, in which case [os.ErrClosed] is
+// returned.
+func (aw *AsyncWriter) Write(p []byte) (int, error) {
+	aw.mu.RLock()
+	defer aw.mu.RUnlock()
+	if aw.closed {
+		return 0, os.ErrClosed
+	}
+
+	buf := bufpool.Get(len(p))
+	buf.Write(p)
+	if aw.block {
+		aw.queue <- asyncRecord{buf: buf}
+		return len(p), nil
+	}
+	select {
+	case aw.queue <- asyncRecord{buf: buf}:
+	default:
+		bufpool.Put(buf)
+		aw.dropped.Add(1)
+		getDroppedCounter().Add(context.Background(), 1)
+	}
+	return len(p), nil
+}
+
+// Dropped returns the number of records dropped because the queue was full.
+func (aw *AsyncWriter) Dropped() uint64 {
+	return aw.dropped.Load()
+}
+
+// Flush blocks until all records queued before the call are written, or ctx is done. Unlike [AsyncWriter.Close], the
+// writer keeps accepting new records.
+func (aw *AsyncWriter) Flush(ctx context.Context) error {
+	aw.mu.RLock()
+	if aw.closed {
+		aw.mu.RUnlock()
+		select {
+		case <-aw.done:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	flushed := make(chan struct{})
+	select {
+	case aw.queue <- asyncRecord{flushed: flushed}:
+		aw.mu.RUnlock()
+	case <-ctx.Done():
+		aw.mu.RUnlock()
+		return ctx.Err()
+	}
+	select {
+	case <-flushed:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Close stops accepting new records and blocks until all queued records are written. It doesn't close the underlying
+// writer. It's safe to call Close multiple times.
+func (aw *AsyncWriter) Close() error {
+	aw.mu.Lock()
+	if !aw.closed {
+		aw.closed = true
+		close(aw.queue)
+	}
+	aw.mu.Unlock()
+	<-aw.done
+	return nil
+}
+
+func (aw *AsyncWriter) run() {
+	defer close(aw.done)
+	for rec := range aw.queue {
+		if rec.flushed != nil {
+			close(rec.flushed)
+			continue
+		}
+		_, _ = aw.w.Write(rec.buf.Bytes())
+		bufpool.Put(rec.buf)
+	}
+}
+
+func getDroppedCounter() metric.Int64Counter {
+	droppedCounterOnce.Do(func() {
+		// The global meter delegates to the meter provider registered later.
+		var err error
+		droppedCounter, err = otel.Meter("github.com/sainnhe/go-common/pkg/log").Int64Counter("log.async.dropped",
+			metric.WithDescription("The number of log records dropped because the async queue was full."),
+			metric.WithUnit("{record}"))
+		if err != nil {
+			droppedCounter = noop.Int64Counter{}
+		}
+	})
+	return droppedCounter
+}
@@ -0,0 +1,142 @@
+package log_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/sainnhe/go-common/pkg/constant"
+	"github.com/sainnhe/go-common/pkg/log"
+)
+
+// gatedWriter blocks writes until the gate is opened.
+type gatedWriter struct {
+	gate chan struct{}
+	mu   sync.Mutex
+	buf  bytes.Buffer
+}
+
+func (w *gatedWriter) Write(p []byte) (int, error) {
+	<-w.gate
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.Write(p)
+}
+
+func (w *gatedWriter) String() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.String()
+}
+
+func TestAsyncWriter(t *testing.T) {
+	t.Parallel()
+
+	if _, err := log.NewAsyncWriter(nil, 1, log.AsyncPolicyDrop); !errors.Is(err, constant.ErrNilDeps) {
+		t.Fatalf("Expect ErrNilDeps, got %v", err)
+	}
+	if _, err := log.NewAsyncWriter(&bytes.Buffer{}, 1, "nil"); !errors.Is(err, log.ErrInvalidAsyncPolicy) {
+		t.Fatalf("Expect ErrInvalidAsyncPolicy, got %v", err)
+	}
+
+	t.Run("drop", func(t *testing.T) {
+		t.Parallel()
+
+		w := &gatedWriter{gate: make(chan struct{})}
+		aw, err := log.NewAsyncWriter(w, 1, log.AsyncPolicyDrop)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// The first record may be taken by the background goroutine, and the second one fills the queue, so at least
+		// one of the rest records will be dropped.
+		for range 4 {
+			if n, err := aw.Write([]byte("a")); n != 1 || err != nil {
+				t.Fatalf("Expect n = 1 and nil error, got n = %d, err = %v", n, err)
+			}
+		}
+		if aw.Dropped() == 0 {
+			t.Fatal("Expect dropped records")
+		}
+
+		close(w.gate)
+		if err := aw.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if got := uint64(len(w.String())) + aw.Dropped(); got != 4 { // nolint:mnd
+			t.Fatalf("Expect written + dropped = 4, got %d", got)
+		}
+		if _, err := aw.Write([]byte("a")); !errors.Is(err, os.ErrClosed) {
+			t.Fatalf("Expect ErrClosed, got %v", err)
+		}
+		if err := aw.Close(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("block", func(t *testing.T) {
+		t.Parallel()
+
+		w := &gatedWriter{gate: make(chan struct{})}
+		aw, err := log.NewAsyncWriter(w, 1, log.AsyncPolicyBlock)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			for range 4 {
+				_, _ = aw.Write([]byte("a"))
+			}
+		}()
+		close(w.gate)
+		<-done
+		if err := aw.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if got := w.String(); got != strings.Repeat("a", 4) || aw.Dropped() != 0 {
+			t.Fatalf("Expect all records to be written, got %q and %d dropped", got, aw.Dropped())
+		}
+	})
+
+	t.Run("flush", func(t *testing.T) {
+		t.Parallel()
+
+		w := &gatedWriter{gate: make(chan struct{})}
+		aw, err := log.NewAsyncWriter(w, 4, log.AsyncPolicyBlock)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, _ = aw.Write([]byte("a"))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		if err := aw.Flush(ctx); !errors.Is(err, context.Canceled) {
+			t.Fatalf("Expect context.Canceled, got %v", err)
+		}
+
+		close(w.gate)
+		_, _ = aw.Write([]byte("b"))
+		if err := aw.Flush(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		if got := w.String(); got != "ab" {
+			t.Fatalf("Expect queued records to be written, got %q", got)
+		}
+		// The writer keeps accepting records after flushing, and flushing a closed writer waits for it to drain.
+		if _, err := aw.Write([]byte("c")); err != nil {
+			t.Fatal(err)
+		}
+		if err := aw.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if err := aw.Flush(context.Background()); err != nil || w.String() != "abc" {
+			t.Fatalf("Unexpected result %q, %v", w.String(), err)
+		}
+	})
+}
@@ -2,9 +2,10 @@ package log
 
 // Config defines the log config model.
 type Config struct {
-	// Type is the type of logger. Currently support "light", "local" and "otel".
-	// The "light" logger outputs logs to stderr, the "local" logger outputs logs to stderr and a local file, and the
-	// "otel" logger outputs logs to the global open telemetry logger provider.
+	// Type is the type of logger. Currently support "light", "local", "otel" and "fast".
+	// The "light" logger outputs logs to stderr, the "local" logger outputs logs to stderr and a local file, the
+	// "otel" logger outputs logs to the global open telemetry logger provider, and the "fast" logger outputs logs to
+	// stderr via [FastHandler], which is optimized for hot paths.
 	Type string `json:"type" yaml:"type" toml:"type" xml:"type" env:"LOG_TYPE" default:"light"`
 
 	// Level is the log level. Possible values are "debug", "info", "warn" and "error".
@@ -25,4 +26,19 @@ type LocalConfig struct {
 
 	// MaxBackups is the maximum number of old log files to retain.
 	MaxBackups int `json:"max_backups" yaml:"max_backups" toml:"max_backups" xml:"max_backups" env:"LOG_LOCAL_MAX_BACKUPS" default:"3"` // nolint:lll
+
+	// Async is the config of writing the local file asynchronously.
+	Async AsyncConfig `json:"async" yaml:"async" toml:"async" xml:"async"`
+}
+
+// AsyncConfig defines the config of writing logs asynchronously, see [AsyncWriter].
+type AsyncConfig struct {
+	// Enabled indicates whether to write the local file asynchronously.
+	Enabled bool `json:"enabled" yaml:"enabled" toml:"enabled" xml:"enabled" env:"LOG_LOCAL_ASYNC_ENABLED" default:"false"` // nolint:lll
+
+	// QueueSize is the maximum number of records waiting to be written.
+	QueueSize int `json:"queue_size" yaml:"queue_size" toml:"queue_size" xml:"queue_size" env:"LOG_LOCAL_ASYNC_QUEUE_SIZE" default:"1024"` // nolint:lll
+
+	// Policy is the policy when the queue is full. Possible values are "drop" and "block".
+	Policy string `json:"policy" yaml:"policy" toml:"policy" xml:"policy" env:"LOG_LOCAL_ASYNC_POLICY" default:"drop"`
 }
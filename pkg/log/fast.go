@@ -0,0 +1,194 @@
+package log
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strconv"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/sainnhe/go-common/pkg/bufpool"
+)
+
+// FastHandler is a [slog.Handler] optimized for hot paths. It writes records in a human readable text format similar to
+// the "light" logger but without colors and source locations, for example:
+//
+//	Jan  2 15:04:05.000 INF Request handled. package=http method=GET latency=1.5ms
+//
+// Attributes added via [slog.Logger.With] are formatted only once, records are formatted into pooled buffers, and
+// values implementing [slog.LogValuer] are resolved only when the record is enabled, so logging common attribute types
+// doesn't allocate.
+type FastHandler struct {
+	w      io.Writer
+	mu     *sync.Mutex
+	level  slog.Leveler
+	attrs  []byte
+	prefix string
+}
+
+// NewFastHandler initializes a new [FastHandler] that writes to w and discards records below level. If level is nil,
+// [slog.LevelInfo] is used.
+func NewFastHandler(w io.Writer, level slog.Leveler) *FastHandler {
+	if level == nil {
+		level = slog.LevelInfo
+	}
+	return &FastHandler{
+		w:     w,
+		mu:    &sync.Mutex{},
+		level: level,
+	}
+}
+
+// Enabled implements [slog.Handler].
+func (h *FastHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level.Level()
+}
+
+// Handle implements [slog.Handler].
+func (h *FastHandler) Handle(_ context.Context, r slog.Record) error {
+	buf := bufpool.GetBuilder(0)
+	defer bufpool.PutBuilder(buf)
+
+	if !r.Time.IsZero() {
+		buf.Append(func(b []byte) []byte { return r.Time.AppendFormat(b, time.StampMilli) })
+		buf.WriteByte(' ') // nolint:errcheck
+	}
+	buf.WriteString(levelString(r.Level)) // nolint:errcheck
+	buf.WriteByte(' ')                    // nolint:errcheck
+	buf.WriteString(r.Message)            // nolint:errcheck
+	buf.Write(h.attrs)                    // nolint:errcheck
+	r.Attrs(func(attr slog.Attr) bool {
+		appendAttr(buf, h.prefix, attr)
+		return true
+	})
+	buf.WriteByte('\n') // nolint:errcheck
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, err := h.w.Write(buf.Bytes())
+	return err
+}
+
+// WithAttrs implements [slog.Handler].
+func (h *FastHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	buf := bufpool.GetBuilder(0)
+	defer bufpool.PutBuilder(buf)
+	buf.Write(h.attrs) // nolint:errcheck
+	for _, attr := range attrs {
+		appendAttr(buf, h.prefix, attr)
+	}
+	h2 := *h
+	h2.attrs = []byte(buf.String())
+	return &h2
+}
+
+// WithGroup implements [slog.Handler].
+func (h *FastHandler) WithGroup(name string) slog.Handler {
+	if len(name) == 0 {
+		return h
+	}
+	h2 := *h
+	h2.prefix = h.prefix + name + "."
+	return &h2
+}
+
+func levelString(level slog.Level) string {
+	switch {
+	case level < slog.LevelInfo:
+		return "DBG"
+	case level < slog.LevelWarn:
+		return "INF"
+	case level < slog.LevelError:
+		return "WRN"
+	default:
+		return "ERR"
+	}
+}
+
+// appendAttr appends " key=value" to buf. Group attributes are flattened with dot-separated keys.
+func appendAttr(buf *bufpool.Builder, prefix string, attr slog.Attr) {
+	attr.Value = attr.Value.Resolve()
+	if attr.Equal(slog.Attr{}) {
+		return
+	}
+	if attr.Value.Kind() == slog.KindGroup {
+		if len(attr.Key) > 0 {
+			prefix = prefix + attr.Key + "."
+		}
+		for _, a := range attr.Value.Group() {
+			appendAttr(buf, prefix, a)
+		}
+		return
+	}
+	buf.WriteByte(' ')        // nolint:errcheck
+	buf.WriteString(prefix)   // nolint:errcheck
+	buf.WriteString(attr.Key) // nolint:errcheck
+	buf.WriteByte('=')        // nolint:errcheck
+	appendValue(buf, attr.Value)
+}
+
+func appendValue(buf *bufpool.Builder, v slog.Value) {
+	switch v.Kind() {
+	case slog.KindString:
+		appendString(buf, v.String())
+	case slog.KindInt64:
+		buf.Append(func(b []byte) []byte { return strconv.AppendInt(b, v.Int64(), 10) })
+	case slog.KindUint64:
+		buf.Append(func(b []byte) []byte { return strconv.AppendUint(b, v.Uint64(), 10) })
+	case slog.KindFloat64:
+		buf.Append(func(b []byte) []byte { return strconv.AppendFloat(b, v.Float64(), 'g', -1, 64) })
+	case slog.KindBool:
+		buf.Append(func(b []byte) []byte { return strconv.AppendBool(b, v.Bool()) })
+	case slog.KindDuration:
+		buf.WriteString(v.Duration().String()) // nolint:errcheck
+	case slog.KindTime:
+		buf.Append(func(b []byte) []byte { return v.Time().AppendFormat(b, time.RFC3339Nano) })
+	default:
+		switch a := v.Any().(type) {
+		case error:
+			appendString(buf, a.Error())
+		case fmt.Stringer:
+			appendString(buf, a.String())
+		default:
+			appendString(buf, fmt.Sprint(a))
+		}
+	}
+}
+
+// appendString appends s to buf, and quotes it if it's empty or contains spaces, quotes, equal signs or non-printable
+// characters.
+func appendString(buf *bufpool.Builder, s string) {
+	if needsQuoting(s) {
+		buf.Append(func(b []byte) []byte { return strconv.AppendQuote(b, s) })
+		return
+	}
+	buf.WriteString(s) // nolint:errcheck
+}
+
+func needsQuoting(s string) bool {
+	if len(s) == 0 {
+		return true
+	}
+	for i := 0; i < len(s); {
+		c := s[i]
+		if c < utf8.RuneSelf {
+			if c <= ' ' || c == '=' || c == '"' || c == 0x7f {
+				return true
+			}
+			i++
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError {
+			return true
+		}
+		i += size
+	}
+	return false
+}
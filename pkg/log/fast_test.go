@@ -0,0 +1,100 @@
+package log_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/lmittmann/tint"
+	"github.com/sainnhe/go-common/pkg/log"
+)
+
+type lazyValue struct {
+	resolved *bool
+}
+
+func (v lazyValue) LogValue() slog.Value {
+	*v.resolved = true
+	return slog.StringValue("resolved")
+}
+
+func TestFastHandler(t *testing.T) {
+	t.Parallel()
+
+	buf := &bytes.Buffer{}
+	logger := slog.New(log.NewFastHandler(buf, slog.LevelInfo)).With("package", "test")
+
+	// Disabled records shouldn't resolve values.
+	resolved := false
+	logger.Debug("Hidden.", "lazy", lazyValue{&resolved})
+	if buf.Len() != 0 || resolved {
+		t.Fatalf("Expect debug record to be discarded without resolving values, got %q", buf.String())
+	}
+
+	r := slog.NewRecord(time.Time{}, slog.LevelWarn, "Request handled.", 0)
+	r.AddAttrs(
+		slog.String("method", "GET"),
+		slog.String("path", "/a b"),
+		slog.String("empty", ""),
+		slog.Int("status", 200),
+		slog.Uint64("size", 42),
+		slog.Float64("ratio", 0.5),
+		slog.Bool("ok", true),
+		slog.Duration("latency", 1500*time.Microsecond),
+		slog.Time("at", time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)),
+		slog.Any("error", errors.New("boom")),
+		slog.Any("lazy", lazyValue{&resolved}),
+		slog.Group("user", slog.Int("id", 1)),
+		slog.Attr{},
+	)
+	if err := logger.WithGroup("req").Handler().Handle(context.Background(), r); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `WRN Request handled. package=test req.method=GET req.path="/a b" req.empty="" req.status=200 ` +
+		`req.size=42 req.ratio=0.5 req.ok=true req.latency=1.5ms req.at=2025-01-02T03:04:05Z req.error=boom ` +
+		`req.lazy=resolved req.user.id=1` + "\n"
+	if got := buf.String(); got != expected {
+		t.Fatalf("Expect %q, got %q", expected, got)
+	}
+}
+
+func TestFastHandler_config(t *testing.T) { // nolint:paralleltest
+	cleanup, err := log.SetGlobalConfig(&log.Config{Type: "fast", Level: "info"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanup()
+
+	if _, ok := log.NewLogger("test").Handler().(*log.FastHandler); !ok {
+		t.Fatalf("Expect FastHandler, got %T", log.NewLogger("test").Handler())
+	}
+}
+
+func BenchmarkHandler(b *testing.B) {
+	handlers := []struct {
+		name    string
+		handler slog.Handler
+	}{
+		{"fast", log.NewFastHandler(io.Discard, slog.LevelInfo)},
+		{"tint", tint.NewHandler(io.Discard, &tint.Options{Level: slog.LevelInfo, TimeFormat: time.StampMilli})},
+	}
+	for _, h := range handlers {
+		logger := slog.New(h.handler).With("package", "bench")
+		b.Run(h.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for range b.N {
+				logger.LogAttrs(context.Background(), slog.LevelInfo, "Request handled.",
+					slog.String("method", "GET"),
+					slog.Int("status", 200),
+					slog.Bool("cached", false),
+					slog.Float64("ratio", 0.5),
+				)
+			}
+		})
+	}
+}
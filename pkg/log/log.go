@@ -2,12 +2,12 @@
 package log
 
 import (
+	"context"
 	"errors"
 	"io"
 	"log/slog"
 	"os"
 	"sync"
-	"syscall"
 	"time"
 
 	"github.com/lmittmann/tint"
@@ -23,6 +23,7 @@ const (
 	loggerTypeLight = loggerTypeT(0)
 	loggerTypeLocal = loggerTypeT(1)
 	loggerTypeOTel  = loggerTypeT(2)
+	loggerTypeFast  = loggerTypeT(3)
 )
 
 var gCfg *Config
@@ -30,6 +31,7 @@ var gLogLevel slog.Level
 var gLoggerType loggerTypeT
 var gLogger *slog.Logger
 var gWriter io.Writer
+var gAsyncWriter *AsyncWriter
 var mu sync.Mutex
 var defaultCfg = &Config{
 	"light",
@@ -70,9 +72,14 @@ func handleSetGlobalConfig(cfg *Config) (cleanup func(), err error) {
 		loggerType = loggerTypeLight
 	case "local":
 		loggerType = loggerTypeLocal
-		cleanup = initMultiWriter(&cfg.Local)
+		if cleanup, err = initMultiWriter(&cfg.Local); err != nil {
+			cleanup = func() {}
+			return
+		}
 	case "otel":
 		loggerType = loggerTypeOTel
+	case "fast":
+		loggerType = loggerTypeFast
 	default:
 		err = errors.New("invalid logger type")
 		return
@@ -87,21 +94,35 @@ func handleSetGlobalConfig(cfg *Config) (cleanup func(), err error) {
 	return
 }
 
-func initMultiWriter(cfg *LocalConfig) (cleanup func()) {
+func initMultiWriter(cfg *LocalConfig) (cleanup func(), err error) {
 	consoleWriter := os.Stderr
 	fileWriter := &lumberjack.Logger{
 		Filename:   cfg.Path,
 		MaxSize:    cfg.MaxSizeMB,
 		MaxBackups: cfg.MaxBackups,
 	}
-	gWriter = io.MultiWriter(consoleWriter, fileWriter)
-	return func() {
+	var asyncWriter *AsyncWriter
+	if cfg.Async.Enabled {
+		if asyncWriter, err = NewAsyncWriter(fileWriter, cfg.Async.QueueSize, cfg.Async.Policy); err != nil {
+			_ = fileWriter.Close()
+			return
+		}
+		gWriter = io.MultiWriter(consoleWriter, asyncWriter)
+	} else {
+		gWriter = io.MultiWriter(consoleWriter, fileWriter)
+	}
+	gAsyncWriter = asyncWriter
+	cleanup = func() {
+		// Flush queued records before closing the file.
+		if asyncWriter != nil {
+			_ = asyncWriter.Close()
+		}
 		if err := errors.Join(consoleWriter.Close(), fileWriter.Close()); err != nil {
 			GetGlobalLogger().Error("Close logger writer failed.", constant.LogAttrError, err)
 		}
-		// syscall.Sync() returns an error on macOS but doesn't return anything on Linux, so let's disable errcheck here
-		syscall.Sync() // nolint:errcheck,gosec
+		syncFS()
 	}
+	return
 }
 
 func handleNewLogger(pkgName string) *slog.Logger {
@@ -115,6 +136,8 @@ func handleNewLogger(pkgName string) *slog.Logger {
 		})).With(constant.LogAttrPackage, pkgName)
 	case loggerTypeOTel:
 		return otelslog.NewLogger(pkgName, otelslog.WithSource(true))
+	case loggerTypeFast:
+		return slog.New(NewFastHandler(os.Stderr, gLogLevel)).With(constant.LogAttrPackage, pkgName)
 	default:
 		return slog.New(tint.NewHandler(os.Stderr, &tint.Options{
 			AddSource:  true,
@@ -136,6 +159,21 @@ func SetGlobalConfig(cfg *Config) (cleanup func(), err error) {
 	return handleSetGlobalConfig(cfg)
 }
 
+// Flush blocks until the records queued by the async writer of the global config are written, or ctx is done. It does
+// nothing if async writing is not enabled, see [AsyncConfig].
+//
+// Since the graceful package depends on this package, it calls Flush after its cleanup hooks instead of registering it
+// as a hook, so that the records logged during the shutdown process are written before the program exits.
+func Flush(ctx context.Context) error {
+	mu.Lock()
+	aw := gAsyncWriter
+	mu.Unlock()
+	if aw == nil {
+		return nil
+	}
+	return aw.Flush(ctx)
+}
+
 // GetGlobalLogger returns the global logger.
 // If the global logger is not set, initialize the global logger based on a default config and return it.
 func GetGlobalLogger() *slog.Logger {
@@ -88,6 +88,31 @@ func TestLog_NewLogger(t *testing.T) {
 			},
 			false,
 		},
+		{
+			"local async",
+			&log.Config{
+				Type:  "local",
+				Level: "debug",
+				Local: log.LocalConfig{
+					Path:       pathPrefix + "/testlog-async",
+					MaxSizeMB:  1,
+					MaxBackups: 3,
+					Async:      log.AsyncConfig{Enabled: true, QueueSize: 16, Policy: log.AsyncPolicyBlock},
+				},
+			},
+			false,
+		},
+		{
+			"invalid async policy",
+			&log.Config{
+				Type: "local",
+				Local: log.LocalConfig{
+					Path:  pathPrefix + "/testlog-async",
+					Async: log.AsyncConfig{Enabled: true, Policy: "nil"},
+				},
+			},
+			true,
+		},
 		{
 			"otel",
 			&log.Config{
@@ -165,6 +190,9 @@ func TestLog_NewLogger(t *testing.T) {
 
 			// Handle output
 			output(logger, msg, attrs)
+			if err = log.Flush(context.Background()); err != nil {
+				t.Fatal(err)
+			}
 
 			// Cleanup
 			cleanup()
@@ -0,0 +1,4 @@
+package log
+
+// syncFS is a no-op on Windows, since there is no equivalent of sync(2). Files are flushed when they're closed.
+func syncFS() {}
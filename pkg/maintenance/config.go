@@ -0,0 +1,18 @@
+package maintenance
+
+// Config defines the config model for maintenance mode.
+type Config struct {
+	// Key is the Valkey key that stores the maintenance status. Services sharing the same key are switched together.
+	Key string `json:"key" yaml:"key" toml:"key" xml:"key" env:"MAINTENANCE_KEY" default:"maintenance"`
+
+	// CacheTTLMs is how long the status is cached on the client side in milliseconds, which bounds the delay of
+	// switching. Set to 0 to read the status from Valkey on every request.
+	CacheTTLMs int64 `json:"cache_ttl_ms" yaml:"cache_ttl_ms" toml:"cache_ttl_ms" xml:"cache_ttl_ms" env:"MAINTENANCE_CACHE_TTL_MS" default:"1000"` // nolint:lll
+
+	// AllowedPaths are the URL paths that are served during maintenance, such as health checks and admin endpoints. A
+	// path ending with "/" matches all paths under it.
+	AllowedPaths []string `json:"allowed_paths" yaml:"allowed_paths" toml:"allowed_paths" xml:"allowed_paths" env:"MAINTENANCE_ALLOWED_PATHS" default:"[\"/healthz\",\"/readyz\",\"/livez\",\"/admin/\"]"` // nolint:lll
+
+	// RetryAfterSec is the value of the Retry-After header of 503 responses in seconds. Set to 0 to omit the header.
+	RetryAfterSec int `json:"retry_after_sec" yaml:"retry_after_sec" toml:"retry_after_sec" xml:"retry_after_sec" env:"MAINTENANCE_RETRY_AFTER_SEC" default:"60"` // nolint:lll
+}
@@ -0,0 +1,150 @@
+package maintenance
+
+import (
+	"encoding/json"
+	"html/template"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/sainnhe/go-common/pkg/constant"
+	"github.com/sainnhe/go-common/pkg/log"
+)
+
+// DefaultTemplate is the default template of the maintenance page, which is executed with [Status].
+var DefaultTemplate = template.Must(template.New("maintenance").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>Under Maintenance</title></head>
+<body>
+<h1>Under Maintenance</h1>
+<p>{{if .Message}}{{.Message}}{{else}}We'll be back soon.{{end}}</p>
+</body>
+</html>
+`))
+
+/*
+NewMiddleware initializes a middleware that responds with 503 Service Unavailable during maintenance, except for the
+paths in [Config.AllowedPaths].
+
+Requests accepting "text/html" receive the page rendered from tmpl with [Status], and other requests receive [Status]
+in JSON. If the status can't be read, requests are served as usual so that a Valkey outage doesn't take down the
+service.
+
+Params:
+  - cfg: The config.
+  - s: The maintenance service.
+  - tmpl: The template of the maintenance page. If nil, [DefaultTemplate] is used.
+
+Returns:
+  - func(http.Handler) http.Handler: The middleware.
+  - error: [constant.ErrNilDeps] if cfg or s is nil.
+*/
+func NewMiddleware(cfg *Config, s Service, tmpl *template.Template) (func(http.Handler) http.Handler, error) {
+	if cfg == nil || s == nil {
+		return nil, constant.ErrNilDeps
+	}
+	if tmpl == nil {
+		tmpl = DefaultTemplate
+	}
+	l := log.NewLogger(pkgName)
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if isAllowed(cfg.AllowedPaths, r.URL.Path) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			status, err := s.Status(r.Context())
+			if err != nil {
+				l.ErrorContext(r.Context(), "Get maintenance status failed.", constant.LogAttrError, err)
+				next.ServeHTTP(w, r)
+				return
+			}
+			if !status.Enabled {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if cfg.RetryAfterSec > 0 {
+				w.Header().Set("Retry-After", strconv.Itoa(cfg.RetryAfterSec))
+			}
+			if strings.Contains(r.Header.Get("Accept"), "text/html") {
+				w.Header().Set("Content-Type", "text/html; charset=utf-8")
+				w.WriteHeader(http.StatusServiceUnavailable)
+				if err := tmpl.Execute(w, status); err != nil {
+					l.ErrorContext(r.Context(), "Render maintenance page failed.", constant.LogAttrError, err)
+				}
+				return
+			}
+			writeJSON(w, http.StatusServiceUnavailable, status)
+		})
+	}, nil
+}
+
+// isAllowed reports whether path matches any of the allowed paths.
+func isAllowed(allowedPaths []string, path string) bool {
+	for _, allowed := range allowedPaths {
+		if path == allowed || (strings.HasSuffix(allowed, "/") && strings.HasPrefix(path, allowed)) {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+NewAdminHandler initializes a handler to switch maintenance mode, which should be mounted under an allowed path that is
+protected by authentication. It supports the following methods:
+
+  - GET: Respond with [Status] in JSON.
+  - PUT: Enable maintenance mode and respond with 204 No Content. The optional body is a JSON object like
+    {"message": "Back at 10:00 UTC."}.
+  - DELETE: Disable maintenance mode and respond with 204 No Content.
+
+Other methods are responded with 405 Method Not Allowed.
+*/
+func NewAdminHandler(s Service) (http.Handler, error) {
+	if s == nil {
+		return nil, constant.ErrNilDeps
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			status, err := s.Status(r.Context())
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			writeJSON(w, http.StatusOK, status)
+		case http.MethodPut:
+			var body struct {
+				Message string `json:"message"`
+			}
+			if r.ContentLength != 0 {
+				if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+					http.Error(w, err.Error(), http.StatusBadRequest)
+					return
+				}
+			}
+			if err := s.Enable(r.Context(), body.Message); err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+		case http.MethodDelete:
+			if err := s.Disable(r.Context()); err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.Header().Set("Allow", "GET, PUT, DELETE")
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		}
+	}), nil
+}
+
+// writeJSON writes v in JSON with the status code.
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,114 @@
+package maintenance_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sainnhe/go-common/pkg/constant"
+	"github.com/sainnhe/go-common/pkg/maintenance"
+	"go.uber.org/mock/gomock"
+)
+
+func TestNewMiddleware(t *testing.T) {
+	t.Parallel()
+
+	if _, err := maintenance.NewMiddleware(nil, nil, nil); !errors.Is(err, constant.ErrNilDeps) {
+		t.Fatalf("Expect error %+v, got %+v", constant.ErrNilDeps, err)
+	}
+
+	ctrl := gomock.NewController(t)
+	s := maintenance.NewMockService(ctrl)
+	mw, err := maintenance.NewMiddleware(&maintenance.Config{
+		AllowedPaths:  []string{"/healthz", "/admin/"},
+		RetryAfterSec: 60,
+	}, s, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler := mw(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	serve := func(path, accept string) *httptest.ResponseRecorder {
+		r := httptest.NewRequestWithContext(context.Background(), http.MethodGet, path, nil)
+		r.Header.Set("Accept", accept)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+
+	// Disabled
+	s.EXPECT().Status(gomock.Any()).Return(&maintenance.Status{}, nil)
+	if w := serve("/api", ""); w.Code != http.StatusOK {
+		t.Fatalf("Expect status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	// Status errors are ignored.
+	s.EXPECT().Status(gomock.Any()).Return(nil, errors.New("connection refused"))
+	if w := serve("/api", ""); w.Code != http.StatusOK {
+		t.Fatalf("Expect status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	// Enabled
+	s.EXPECT().Status(gomock.Any()).Return(&maintenance.Status{Enabled: true, Message: "Back at 10:00."}, nil).Times(2)
+	w := serve("/api", "application/json")
+	if w.Code != http.StatusServiceUnavailable || w.Header().Get("Retry-After") != "60" ||
+		!strings.Contains(w.Body.String(), `"message":"Back at 10:00."`) {
+		t.Fatalf("Unexpected response %d %v %s", w.Code, w.Header(), w.Body)
+	}
+	w = serve("/", "text/html,application/xhtml+xml")
+	if w.Code != http.StatusServiceUnavailable || !strings.Contains(w.Body.String(), "<p>Back at 10:00.</p>") {
+		t.Fatalf("Unexpected response %d %s", w.Code, w.Body)
+	}
+
+	// Allowed paths skip the status check.
+	for _, path := range []string{"/healthz", "/admin/maintenance"} {
+		if w := serve(path, ""); w.Code != http.StatusOK {
+			t.Fatalf("Expect status %d for %s, got %d", http.StatusOK, path, w.Code)
+		}
+	}
+}
+
+func TestNewAdminHandler(t *testing.T) {
+	t.Parallel()
+
+	if _, err := maintenance.NewAdminHandler(nil); !errors.Is(err, constant.ErrNilDeps) {
+		t.Fatalf("Expect error %+v, got %+v", constant.ErrNilDeps, err)
+	}
+
+	ctrl := gomock.NewController(t)
+	s := maintenance.NewMockService(ctrl)
+	handler, err := maintenance.NewAdminHandler(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	serve := func(method, body string) *httptest.ResponseRecorder {
+		r := httptest.NewRequestWithContext(context.Background(), method, "/admin/maintenance",
+			strings.NewReader(body))
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+
+	s.EXPECT().Enable(gomock.Any(), "Upgrading.").Return(nil)
+	if w := serve(http.MethodPut, `{"message":"Upgrading."}`); w.Code != http.StatusNoContent {
+		t.Fatalf("Expect status %d, got %d", http.StatusNoContent, w.Code)
+	}
+	if w := serve(http.MethodPut, `{`); w.Code != http.StatusBadRequest {
+		t.Fatalf("Expect status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+	s.EXPECT().Status(gomock.Any()).Return(&maintenance.Status{Enabled: true}, nil)
+	if w := serve(http.MethodGet, ""); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"enabled":true`) {
+		t.Fatalf("Unexpected response %d %s", w.Code, w.Body)
+	}
+	s.EXPECT().Disable(gomock.Any()).Return(errors.New("connection refused"))
+	if w := serve(http.MethodDelete, ""); w.Code != http.StatusInternalServerError {
+		t.Fatalf("Expect status %d, got %d", http.StatusInternalServerError, w.Code)
+	}
+	if w := serve(http.MethodPost, ""); w.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("Expect status %d, got %d", http.StatusMethodNotAllowed, w.Code)
+	}
+}
@@ -0,0 +1,57 @@
+package maintenance_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/redis/rueidis"
+	"github.com/sainnhe/go-common/pkg/clock/testclock"
+	"github.com/sainnhe/go-common/pkg/constant"
+	"github.com/sainnhe/go-common/pkg/maintenance"
+)
+
+func TestNewService(t *testing.T) {
+	t.Parallel()
+
+	if _, err := maintenance.NewService(nil, nil); !errors.Is(err, constant.ErrNilDeps) {
+		t.Fatalf("Expect error %+v, got %+v", constant.ErrNilDeps, err)
+	}
+}
+
+func TestService(t *testing.T) {
+	t.Parallel()
+
+	rc, err := rueidis.NewClient(rueidis.ClientOption{
+		InitAddress: []string{"localhost:6379"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rc.Close()
+
+	clk := testclock.Freeze(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	s, err := maintenance.NewService(&maintenance.Config{Key: "test_maintenance"}, rc, maintenance.WithClock(clk))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	if status, err := s.Status(ctx); err != nil || status.Enabled {
+		t.Fatalf("Expect disabled, got %+v, err = %+v", status, err)
+	}
+	if err := s.Enable(ctx, "Upgrading."); err != nil {
+		t.Fatal(err)
+	}
+	status, err := s.Status(ctx)
+	if err != nil || !status.Enabled || status.Message != "Upgrading." || !status.Since.Equal(clk.Now()) {
+		t.Fatalf("Expect enabled, got %+v, err = %+v", status, err)
+	}
+	if err := s.Disable(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if status, err := s.Status(ctx); err != nil || status.Enabled {
+		t.Fatalf("Expect disabled, got %+v, err = %+v", status, err)
+	}
+}
@@ -0,0 +1,15 @@
+package membership
+
+// Config defines the config model for membership.
+type Config struct {
+	// Key is the prefix of the Valkey keys that store the members. Instances sharing the same key are in the same
+	// cluster.
+	Key string `json:"key" yaml:"key" toml:"key" xml:"key" env:"MEMBERSHIP_KEY" default:"membership"`
+
+	// HeartbeatIntervalMs is the interval of sending heartbeats and refreshing members in milliseconds.
+	HeartbeatIntervalMs int64 `json:"heartbeat_interval_ms" yaml:"heartbeat_interval_ms" toml:"heartbeat_interval_ms" xml:"heartbeat_interval_ms" env:"MEMBERSHIP_HEARTBEAT_INTERVAL_MS" default:"1000"` // nolint:lll
+
+	// TTLMs is how long a member is considered alive after its last heartbeat in milliseconds. It should be several
+	// times HeartbeatIntervalMs so that a few missed heartbeats don't make the member leave.
+	TTLMs int64 `json:"ttl_ms" yaml:"ttl_ms" toml:"ttl_ms" xml:"ttl_ms" env:"MEMBERSHIP_TTL_MS" default:"5000"`
+}
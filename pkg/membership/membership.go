@@ -0,0 +1,336 @@
+//go:generate mockgen -write_package_comment=false -source=membership.go -destination=membership_mock.go -package membership
+
+/*
+Package membership tracks the alive instances of a cluster without gossip, by sending heartbeats to Valkey:
+
+	s, cleanup, err := membership.NewService(cfg, rc, hostname)
+	defer cleanup()
+
+	cancel := s.Watch(func(c membership.Change) {
+		// React to joined and left members.
+	})
+	defer cancel()
+
+	// Process the shard of the instance itself.
+	shard := s.Self().Index
+
+Each member is assigned a stable index, which is the smallest non-negative integer not used by other alive members when
+it joins, and is kept until it leaves. Indices are useful to distribute work over numbered shards, while the members
+can also be used for consistent hashing, see [github.com/sainnhe/go-common/pkg/partition].
+
+The members are stored in the Valkey keys with the suffixes ":members" and ":indices". In Redis Cluster, [Config.Key]
+should contain a hash tag like "{workers}" so that both keys are in the same slot.
+*/
+package membership
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"log/slog"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/redis/rueidis"
+	"github.com/sainnhe/go-common/pkg/clock"
+	"github.com/sainnhe/go-common/pkg/constant"
+	"github.com/sainnhe/go-common/pkg/log"
+	"github.com/sainnhe/go-common/pkg/util"
+)
+
+const pkgName = "github.com/sainnhe/go-common/pkg/membership"
+
+// ErrEmptyName indicates that the member name is empty.
+var ErrEmptyName = errors.New("empty member name")
+
+var (
+	// heartbeatScript sets the heartbeat of ARGV[1] in the sorted set KEYS[1] to the server time, removes the members
+	// whose heartbeats are older than ARGV[2] milliseconds along with their indices in the hash KEYS[2], assigns the
+	// smallest unused index to ARGV[1] if it doesn't have one, and returns the indices of all alive members.
+	heartbeatScript = rueidis.NewLuaScript(`
+local time = redis.call("TIME")
+local now = time[1] * 1000 + math.floor(time[2] / 1000)
+for _, m in ipairs(redis.call("ZRANGEBYSCORE", KEYS[1], "-inf", now - ARGV[2])) do
+	redis.call("ZREM", KEYS[1], m)
+	redis.call("HDEL", KEYS[2], m)
+end
+redis.call("ZADD", KEYS[1], now, ARGV[1])
+if redis.call("HEXISTS", KEYS[2], ARGV[1]) == 0 then
+	local used = {}
+	for _, i in ipairs(redis.call("HVALS", KEYS[2])) do
+		used[tonumber(i)] = true
+	end
+	local i = 0
+	while used[i] do
+		i = i + 1
+	end
+	redis.call("HSET", KEYS[2], ARGV[1], i)
+end
+redis.call("PEXPIRE", KEYS[1], ARGV[2])
+redis.call("PEXPIRE", KEYS[2], ARGV[2])
+return redis.call("HGETALL", KEYS[2])`)
+
+	// leaveScript removes ARGV[1] from the sorted set KEYS[1] and the hash KEYS[2].
+	leaveScript = rueidis.NewLuaScript(`
+redis.call("ZREM", KEYS[1], ARGV[1])
+return redis.call("HDEL", KEYS[2], ARGV[1])`)
+)
+
+// Member is a member of the cluster.
+type Member struct {
+	// Name is the unique name of the member.
+	Name string
+
+	// Index is the stable index of the member.
+	Index int
+}
+
+// Change is a change of members.
+type Change struct {
+	// Joined are the members that joined, in the order of indices.
+	Joined []Member
+
+	// Left are the members that left, in the order of indices. A member that rejoins with a different index, e.g. after
+	// missing heartbeats, leaves with the old index and joins with the new one.
+	Left []Member
+
+	// Members are the alive members after the change, in the order of indices.
+	Members []Member
+}
+
+// Service is the membership service of an instance.
+type Service interface {
+	// Self returns the instance itself. Its index is -1 if it's not considered alive, e.g. it has failed to send
+	// heartbeats for [Config.TTLMs].
+	Self() Member
+
+	// Members returns the alive members in the order of indices, which is empty if the instance itself is not
+	// considered alive, since its view of the cluster is stale.
+	Members() []Member
+
+	// Watch calls fn with each change of members, including the join of the instance itself if it's called before that,
+	// until cancel is called. Changes are delivered one by one in the heartbeat goroutine, so fn should return quickly.
+	Watch(fn func(c Change)) (cancel func())
+}
+
+type serviceImpl struct {
+	cfg  *Config
+	rc   rueidis.Client
+	name string
+	keys []string
+	clk  clock.Clock
+	l    *slog.Logger
+
+	mu            sync.RWMutex
+	members       []Member
+	lastHeartbeat time.Time
+
+	watchersMu sync.Mutex
+	watchers   map[int]func(c Change)
+	watcherID  int
+}
+
+// Option is the option used to customize the membership service.
+type Option func(s *serviceImpl)
+
+// WithClock sets the clock used to wait between heartbeats. Defaults to [clock.Real].
+func WithClock(clk clock.Clock) Option {
+	return func(s *serviceImpl) {
+		if clk != nil {
+			s.clk = clk
+		}
+	}
+}
+
+// WithWatch is like [Service.Watch], but registers fn before the instance joins, so that the join is delivered too.
+func WithWatch(fn func(c Change)) Option {
+	return func(s *serviceImpl) {
+		if fn != nil {
+			s.Watch(fn)
+		}
+	}
+}
+
+/*
+NewService joins the cluster and initializes a new membership service.
+
+Params:
+  - cfg: The config.
+  - rc: The rueidis client.
+  - name: The unique name of the instance, like the hostname or pod name.
+  - opts: The options.
+
+Returns:
+  - Service: The membership service, whose members are loaded before returning.
+  - func(): The cleanup function that stops sending heartbeats and leaves the cluster, so that other members are
+    notified immediately. It's safe to call it multiple times.
+  - error: [constant.ErrNilDeps] if any dependency is nil, [ErrEmptyName] if name is empty, or an error of the first
+    heartbeat.
+*/
+func NewService(cfg *Config, rc rueidis.Client, name string, opts ...Option) (s Service, cleanup func(), err error) {
+	if cfg == nil || rc == nil {
+		err = constant.ErrNilDeps
+		return
+	}
+	if name == "" {
+		err = ErrEmptyName
+		return
+	}
+	impl := &serviceImpl{
+		cfg:      cfg,
+		rc:       rc,
+		name:     name,
+		keys:     []string{cfg.Key + ":members", cfg.Key + ":indices"},
+		clk:      clock.Real(),
+		l:        log.NewLogger(pkgName),
+		watchers: map[int]func(c Change){},
+	}
+	for _, opt := range opts {
+		opt(impl)
+	}
+	if err = impl.heartbeat(context.Background()); err != nil {
+		return
+	}
+
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go impl.run(stop, done)
+	var once sync.Once
+	cleanup = func() {
+		once.Do(func() {
+			close(stop)
+			<-done
+			if err := leaveScript.Exec(context.Background(), rc, impl.keys, []string{name}).Error(); err != nil {
+				impl.l.Error("Leave cluster failed.", constant.LogAttrError, err)
+			}
+		})
+	}
+	s = impl
+	return
+}
+
+func (s *serviceImpl) Self() Member {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, m := range s.members {
+		if m.Name == s.name {
+			return m
+		}
+	}
+	return Member{s.name, -1}
+}
+
+func (s *serviceImpl) Members() []Member {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return slices.Clone(s.members)
+}
+
+func (s *serviceImpl) Watch(fn func(c Change)) (cancel func()) {
+	if fn == nil {
+		return func() {}
+	}
+	s.watchersMu.Lock()
+	defer s.watchersMu.Unlock()
+	id := s.watcherID
+	s.watcherID++
+	s.watchers[id] = fn
+	return func() {
+		s.watchersMu.Lock()
+		defer s.watchersMu.Unlock()
+		delete(s.watchers, id)
+	}
+}
+
+// heartbeat sends a heartbeat and refreshes members.
+func (s *serviceImpl) heartbeat(ctx context.Context) error {
+	indices, err := heartbeatScript.Exec(ctx, s.rc, s.keys,
+		[]string{s.name, strconv.FormatInt(s.cfg.TTLMs, 10)}).AsStrMap()
+	if err != nil {
+		return err
+	}
+	members := make([]Member, 0, len(indices))
+	for name, index := range indices {
+		i, err := strconv.Atoi(index)
+		if err != nil {
+			return err
+		}
+		members = append(members, Member{name, i})
+	}
+	slices.SortFunc(members, func(a, b Member) int {
+		return cmp.Compare(a.Index, b.Index)
+	})
+	s.setMembers(members, s.clk.Now())
+	return nil
+}
+
+// setMembers sets members and notifies watchers if they change. lastHeartbeat is updated unless it's zero.
+func (s *serviceImpl) setMembers(members []Member, lastHeartbeat time.Time) {
+	s.mu.Lock()
+	prev := s.members
+	s.members = members
+	if !lastHeartbeat.IsZero() {
+		s.lastHeartbeat = lastHeartbeat
+	}
+	s.mu.Unlock()
+
+	c := Change{Members: slices.Clone(members)}
+	for _, m := range members {
+		if !slices.Contains(prev, m) {
+			c.Joined = append(c.Joined, m)
+		}
+	}
+	for _, m := range prev {
+		if !slices.Contains(members, m) {
+			c.Left = append(c.Left, m)
+		}
+	}
+	if len(c.Joined) == 0 && len(c.Left) == 0 {
+		return
+	}
+	s.l.Info("Members changed.", "joined", c.Joined, "left", c.Left)
+
+	s.watchersMu.Lock()
+	ids := make([]int, 0, len(s.watchers))
+	for id := range s.watchers {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	watchers := make([]func(c Change), 0, len(ids))
+	for _, id := range ids {
+		watchers = append(watchers, s.watchers[id])
+	}
+	s.watchersMu.Unlock()
+	for _, fn := range watchers {
+		fn(c)
+	}
+}
+
+// run sends heartbeats every [Config.HeartbeatIntervalMs] until stop is closed.
+func (s *serviceImpl) run(stop <-chan struct{}, done chan<- struct{}) {
+	defer close(done)
+	defer util.Recover()
+	interval := max(time.Duration(s.cfg.HeartbeatIntervalMs)*time.Millisecond, time.Millisecond)
+	ttl := time.Duration(s.cfg.TTLMs) * time.Millisecond
+	for {
+		select {
+		case <-stop:
+			return
+		case <-s.clk.After(interval):
+		}
+		err := s.heartbeat(context.Background())
+		if err == nil {
+			continue
+		}
+		s.l.Error("Send heartbeat failed.", constant.LogAttrError, err)
+		s.mu.RLock()
+		expired := s.clk.Now().Sub(s.lastHeartbeat) >= ttl
+		s.mu.RUnlock()
+		if expired {
+			// Other members consider this instance dead, so its view of the cluster is stale.
+			s.setMembers(nil, time.Time{})
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package membership_test
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/redis/rueidis"
+	"github.com/sainnhe/go-common/pkg/constant"
+	"github.com/sainnhe/go-common/pkg/membership"
+)
+
+func TestNewService(t *testing.T) {
+	t.Parallel()
+
+	if _, _, err := membership.NewService(nil, nil, "a"); !errors.Is(err, constant.ErrNilDeps) {
+		t.Fatalf("Expect error %+v, got %+v", constant.ErrNilDeps, err)
+	}
+}
+
+func TestService(t *testing.T) {
+	t.Parallel()
+
+	rc, err := rueidis.NewClient(rueidis.ClientOption{
+		InitAddress: []string{"localhost:6379"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rc.Close()
+	cfg := &membership.Config{
+		Key:                 "test_membership",
+		HeartbeatIntervalMs: 20,
+		TTLMs:               100,
+	}
+	if _, _, err := membership.NewService(cfg, rc, ""); !errors.Is(err, membership.ErrEmptyName) {
+		t.Fatalf("Expect error %+v, got %+v", membership.ErrEmptyName, err)
+	}
+
+	mu := sync.Mutex{}
+	changes := []membership.Change{}
+	a, cleanupA, err := membership.NewService(cfg, rc, "a", membership.WithWatch(func(c membership.Change) {
+		mu.Lock()
+		defer mu.Unlock()
+		changes = append(changes, c)
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanupA()
+	b, cleanupB, err := membership.NewService(cfg, rc, "b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, cleanupC, err := membership.NewService(cfg, rc, "c")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanupC()
+	if a.Self() != (membership.Member{Name: "a", Index: 0}) || b.Self().Index != 1 || c.Self().Index != 2 {
+		t.Fatalf("Unexpected indices %+v %+v %+v", a.Self(), b.Self(), c.Self())
+	}
+
+	// The index of a left member is reused, while others keep their indices.
+	cleanupB()
+	cleanupB() // Calling cleanup again is a no-op.
+	d, cleanupD, err := membership.NewService(cfg, rc, "d")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanupD()
+	if d.Self().Index != 1 || c.Self().Index != 2 {
+		t.Fatalf("Unexpected indices %+v %+v", d.Self(), c.Self())
+	}
+	time.Sleep(100 * time.Millisecond)
+	want := []membership.Member{{"a", 0}, {"d", 1}, {"c", 2}}
+	if members := a.Members(); !reflect.DeepEqual(members, want) {
+		t.Fatalf("Expect members %+v, got %+v", want, members)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(changes) == 0 || !reflect.DeepEqual(changes[0].Joined, []membership.Member{{"a", 0}}) {
+		t.Fatalf("Expect the join of a itself first, got %+v", changes)
+	}
+	if last := changes[len(changes)-1]; !reflect.DeepEqual(last.Members, want) {
+		t.Fatalf("Expect members %+v, got %+v", want, last.Members)
+	}
+}
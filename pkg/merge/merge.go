@@ -0,0 +1,188 @@
+/*
+Package merge implements three-way merge of JSON documents, which is useful for APIs that accept concurrent edits, such
+as edits of the Ext column of db.DO.
+
+Given the base document that both sides started from, changes made by only one side are applied, and changes made by
+both sides are applied if they are identical, or reported as conflicts otherwise:
+
+	base := `{"theme": "light", "lang": "en"}`
+	ours := `{"theme": "dark", "lang": "en"}`
+	theirs := `{"theme": "light", "lang": "fr", "tz": "UTC"}`
+	r, err := merge.Merge([]byte(base), []byte(ours), []byte(theirs))
+	// r.Merged is {"lang":"fr","theme":"dark","tz":"UTC"}, and r.Conflicts is empty.
+
+Objects are merged key by key recursively, while arrays and other values are merged as a whole.
+*/
+package merge
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// ErrInvalidJSON indicates that a document is not valid JSON.
+var ErrInvalidJSON = errors.New("invalid json")
+
+// Conflict is a value changed differently by both sides.
+type Conflict struct {
+	// Path is the JSON Pointer (RFC 6901) of the value, which is empty for the root.
+	Path string `json:"path"`
+
+	// Base is the value in the base document, which is omitted if the value is absent.
+	Base json.RawMessage `json:"base,omitempty"`
+
+	// Ours is the value in our document, which is omitted if the value is deleted.
+	Ours json.RawMessage `json:"ours,omitempty"`
+
+	// Theirs is the value in their document, which is omitted if the value is deleted.
+	Theirs json.RawMessage `json:"theirs,omitempty"`
+}
+
+// Result is the result of a merge.
+type Result struct {
+	// Merged is the merged document, where conflicts are resolved according to the options. It's nil if the document is
+	// deleted by the merge.
+	Merged json.RawMessage `json:"merged"`
+
+	// Conflicts are the conflicts sorted by paths.
+	Conflicts []Conflict `json:"conflicts"`
+}
+
+// Option is the option used to customize the merge.
+type Option func(opts *options)
+
+type options struct {
+	preferTheirs bool
+}
+
+// WithPreferTheirs resolves conflicts with their values. By default, conflicts are resolved with our values.
+func WithPreferTheirs() Option {
+	return func(opts *options) {
+		opts.preferTheirs = true
+	}
+}
+
+// absent represents a value that doesn't exist.
+type absent struct{}
+
+/*
+Merge merges ours and theirs, which are derived from base.
+
+Params:
+  - base: The common ancestor document. Empty input is treated as an absent document.
+  - ours: Our document. Empty input is treated as a deleted document.
+  - theirs: Their document. Empty input is treated as a deleted document.
+  - opts: The options.
+
+Returns:
+  - *Result: The merged document and the conflicts.
+  - error: [ErrInvalidJSON] if a document is not valid JSON.
+*/
+func Merge(base, ours, theirs []byte, opts ...Option) (*Result, error) {
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	var docs [3]any
+	for i, doc := range [][]byte{base, ours, theirs} {
+		var err error
+		if docs[i], err = decode(doc); err != nil {
+			return nil, err
+		}
+	}
+
+	r := &Result{Conflicts: []Conflict{}}
+	merged := o.merge(r, "", docs[0], docs[1], docs[2])
+	if _, ok := merged.(absent); !ok {
+		b, err := json.Marshal(merged)
+		if err != nil {
+			return nil, err
+		}
+		r.Merged = b
+	}
+	slices.SortFunc(r.Conflicts, func(a, b Conflict) int {
+		return strings.Compare(a.Path, b.Path)
+	})
+	return r, nil
+}
+
+// decode decodes a document, where numbers are kept as [json.Number] to avoid losing precision.
+func decode(doc []byte) (any, error) {
+	if len(bytes.TrimSpace(doc)) == 0 {
+		return absent{}, nil
+	}
+	dec := json.NewDecoder(bytes.NewReader(doc))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidJSON, err)
+	}
+	if dec.More() {
+		return nil, fmt.Errorf("%w: unexpected data after the document", ErrInvalidJSON)
+	}
+	return v, nil
+}
+
+// merge merges the values at path, and records conflicts in r.
+func (o *options) merge(r *Result, path string, base, ours, theirs any) any {
+	switch {
+	case reflect.DeepEqual(ours, theirs):
+		return ours
+	case reflect.DeepEqual(base, ours):
+		return theirs
+	case reflect.DeepEqual(base, theirs):
+		return ours
+	}
+
+	oursObj, oursOK := ours.(map[string]any)
+	theirsObj, theirsOK := theirs.(map[string]any)
+	if oursOK && theirsOK {
+		// Keys added by both sides are merged against an empty base.
+		baseObj, _ := base.(map[string]any)
+		merged := map[string]any{}
+		keys := slices.Concat(slices.Collect(maps.Keys(baseObj)), slices.Collect(maps.Keys(oursObj)),
+			slices.Collect(maps.Keys(theirsObj)))
+		slices.Sort(keys)
+		for _, key := range slices.Compact(keys) {
+			v := o.merge(r, path+"/"+escape(key), get(baseObj, key), get(oursObj, key), get(theirsObj, key))
+			if _, ok := v.(absent); !ok {
+				merged[key] = v
+			}
+		}
+		return merged
+	}
+
+	r.Conflicts = append(r.Conflicts, Conflict{path, raw(base), raw(ours), raw(theirs)})
+	if o.preferTheirs {
+		return theirs
+	}
+	return ours
+}
+
+// get returns the value of key in obj, or [absent] if it doesn't exist.
+func get(obj map[string]any, key string) any {
+	if v, ok := obj[key]; ok {
+		return v
+	}
+	return absent{}
+}
+
+// raw encodes v, and returns nil if v is [absent].
+func raw(v any) json.RawMessage {
+	if _, ok := v.(absent); ok {
+		return nil
+	}
+	b, _ := json.Marshal(v) // nolint:errchkjson
+	return b
+}
+
+// escape escapes a reference token of JSON Pointer.
+func escape(token string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(token, "~", "~0"), "/", "~1")
+}
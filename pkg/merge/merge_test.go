@@ -0,0 +1,90 @@
+package merge_test
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/sainnhe/go-common/pkg/merge"
+)
+
+func TestMerge(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		base      string
+		ours      string
+		theirs    string
+		opts      []merge.Option
+		want      string
+		conflicts []string
+	}{
+		{"Unchanged", `{"a": 1}`, `{"a": 1}`, `{"a": 1}`, nil, `{"a":1}`, nil},
+		{"Changed by us", `{"a": 1}`, `{"a": 2}`, `{"a": 1}`, nil, `{"a":2}`, nil},
+		{"Changed by them", `{"a": 1}`, `{"a": 1}`, `{"a": 2}`, nil, `{"a":2}`, nil},
+		{"Changed identically", `{"a": 1}`, `{"a": 2}`, `{"a": 2}`, nil, `{"a":2}`, nil},
+		{"Different keys", `{"a": 1, "b": 1}`, `{"a": 2, "b": 1}`, `{"a": 1, "c": 3}`, nil, `{"a":2,"c":3}`, nil},
+		{"Nested objects", `{"a": {"b": 1, "c": 1}}`, `{"a": {"b": 2, "c": 1}}`, `{"a": {"b": 1, "c": 2}}`, nil,
+			`{"a":{"b":2,"c":2}}`, nil},
+		{"Added by both", `{}`, `{"a": {"b": 1}}`, `{"a": {"c": 2}}`, nil, `{"a":{"b":1,"c":2}}`, nil},
+		{"Empty base", ``, `{"a": 1}`, `{"b": 2}`, nil, `{"a":1,"b":2}`, nil},
+		{"Conflict", `{"a": 1}`, `{"a": 2}`, `{"a": 3}`, nil, `{"a":2}`, []string{"/a"}},
+		{"Prefer theirs", `{"a": 1}`, `{"a": 2}`, `{"a": 3}`, []merge.Option{merge.WithPreferTheirs()}, `{"a":3}`,
+			[]string{"/a"}},
+		{"Arrays are atomic", `{"a": [1]}`, `{"a": [1, 2]}`, `{"a": [1, 3]}`, nil, `{"a":[1,2]}`, []string{"/a"}},
+		{"Delete and modify", `{"a": 1}`, `{}`, `{"a": 2}`, nil, `{}`, []string{"/a"}},
+		{"Escaped path", `{"a/b": {"~": 1}}`, `{"a/b": {"~": 2}}`, `{"a/b": {"~": 3}}`, nil, `{"a/b":{"~":2}}`,
+			[]string{"/a~1b/~0"}},
+		{"Large numbers", `{"a": 1}`, `{"a": 12345678901234567890}`, `{"a": 1}`, nil, `{"a":12345678901234567890}`,
+			nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			r, err := merge.Merge([]byte(tt.base), []byte(tt.ours), []byte(tt.theirs), tt.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(r.Merged) != tt.want {
+				t.Fatalf("Expect %s, got %s", tt.want, r.Merged)
+			}
+			paths := []string{}
+			for _, c := range r.Conflicts {
+				paths = append(paths, c.Path)
+			}
+			if len(paths) != len(tt.conflicts) || (len(paths) > 0 && !reflect.DeepEqual(paths, tt.conflicts)) {
+				t.Fatalf("Expect conflicts %+v, got %+v", tt.conflicts, paths)
+			}
+		})
+	}
+}
+
+func TestMerge_Conflict(t *testing.T) {
+	t.Parallel()
+
+	r, err := merge.Merge(nil, []byte(`{"a": 1}`), []byte(`{"a": "x"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := json.Marshal(r.Conflicts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `[{"path":"/a","ours":1,"theirs":"x"}]`; string(b) != want {
+		t.Fatalf("Expect %s, got %s", want, b)
+	}
+
+	if r, err = merge.Merge([]byte(`{"a": 1}`), nil, nil); err != nil || r.Merged != nil {
+		t.Fatalf("Expect deleted document, got %+v, err = %+v", r, err)
+	}
+
+	for _, doc := range []string{`{`, `{} {}`} {
+		if _, err := merge.Merge([]byte(doc), nil, nil); !errors.Is(err, merge.ErrInvalidJSON) {
+			t.Fatalf("Expect error %+v, got %+v", merge.ErrInvalidJSON, err)
+		}
+	}
+}
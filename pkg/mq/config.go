@@ -0,0 +1,35 @@
+package mq
+
+// Config defines the config model for mq.
+type Config struct {
+	// DedupWindowMs is how long the IDs of published messages are remembered in milliseconds. A message re-sent within
+	// the window is dropped by the publisher returned by [NewDedupPublisher]. It should be longer than the maximum
+	// delay of re-sending, e.g. the retry backoff of an outbox relay.
+	DedupWindowMs int64 `json:"dedup_window_ms" yaml:"dedup_window_ms" toml:"dedup_window_ms" xml:"dedup_window_ms" env:"MQ_DEDUP_WINDOW_MS" default:"86400000"` // nolint:lll
+
+	// DedupPrefix is the prefix of keys of message IDs in the store. Use different prefixes for different publishers
+	// sharing the same store to avoid conflicts.
+	DedupPrefix string `json:"dedup_prefix" yaml:"dedup_prefix" toml:"dedup_prefix" xml:"dedup_prefix" env:"MQ_DEDUP_PREFIX" default:"mq:dedup:"` // nolint:lll
+
+	// MaxDeliveries is the maximum number of deliveries of a message before it's quarantined by the handler returned by
+	// [NewQuarantine].
+	MaxDeliveries int64 `json:"max_deliveries" yaml:"max_deliveries" toml:"max_deliveries" xml:"max_deliveries" env:"MQ_MAX_DELIVERIES" default:"5"` // nolint:lll
+
+	// RedeliveryDelayMs is the delay before the second delivery of a failed message in milliseconds. It doubles after
+	// every failed delivery, up to [Config.MaxRedeliveryDelayMs].
+	RedeliveryDelayMs int64 `json:"redelivery_delay_ms" yaml:"redelivery_delay_ms" toml:"redelivery_delay_ms" xml:"redelivery_delay_ms" env:"MQ_REDELIVERY_DELAY_MS" default:"1000"` // nolint:lll
+
+	// MaxRedeliveryDelayMs is the maximum delay before redelivering a failed message in milliseconds.
+	MaxRedeliveryDelayMs int64 `json:"max_redelivery_delay_ms" yaml:"max_redelivery_delay_ms" toml:"max_redelivery_delay_ms" xml:"max_redelivery_delay_ms" env:"MQ_MAX_REDELIVERY_DELAY_MS" default:"300000"` // nolint:lll
+
+	// QuarantineTopic is the topic that quarantined messages are published to, i.e. the dead letter queue.
+	QuarantineTopic string `json:"quarantine_topic" yaml:"quarantine_topic" toml:"quarantine_topic" xml:"quarantine_topic" env:"MQ_QUARANTINE_TOPIC" default:"quarantine"` // nolint:lll
+
+	// QuarantinePrefix is the prefix of keys of delivery counts and quarantined messages in the store. Use different
+	// prefixes for different consumer groups sharing the same store to avoid conflicts.
+	QuarantinePrefix string `json:"quarantine_prefix" yaml:"quarantine_prefix" toml:"quarantine_prefix" xml:"quarantine_prefix" env:"MQ_QUARANTINE_PREFIX" default:"mq:quarantine:"` // nolint:lll
+
+	// QuarantineTTLMs is how long quarantined messages can be requeued in milliseconds. It also bounds how long delivery
+	// counts are kept.
+	QuarantineTTLMs int64 `json:"quarantine_ttl_ms" yaml:"quarantine_ttl_ms" toml:"quarantine_ttl_ms" xml:"quarantine_ttl_ms" env:"MQ_QUARANTINE_TTL_MS" default:"604800000"` // nolint:lll
+}
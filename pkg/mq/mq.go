@@ -0,0 +1,162 @@
+//go:generate mockgen -write_package_comment=false -source=mq.go -destination=mq_mock.go -package mq
+
+/*
+Package mq implements helpers for publishing and consuming messages of message queues.
+
+The outbox relay or retry layer in front of a message queue usually re-sends a message when it's unsure whether the
+previous attempt succeeded, which results in duplicate emissions. [NewDedupPublisher] wraps a [Publisher] to drop
+messages whose IDs have been published within a configurable window:
+
+	store, err := kv.NewStore(kvCfg, rc, nil)
+	p, err := mq.NewDedupPublisher(cfg, kafkaPublisher, store)
+	err = p.Publish(ctx, &mq.Message{ID: "order-42-created", Topic: "orders", Payload: payload})
+
+The IDs are recorded in a [kv.Store], so that they are shared across processes with [kv.BackendValkey].
+
+On the consuming side, a malformed message that always fails can wedge a consumer group if it's redelivered forever.
+[NewQuarantine] wraps a [Handler] to count deliveries of each message, ask for redelivery with exponential delays via
+[RedeliveryError], and move the message to a quarantine topic once it reaches the maximum number of deliveries:
+
+	q, err := mq.NewQuarantine(cfg, handler, store, kafkaPublisher)
+	err = q.Handle(ctx, msg)
+	var re *mq.RedeliveryError
+	if errors.As(err, &re) {
+		// Nack the message and redeliver it after re.Delay.
+	}
+
+Quarantined messages can be published back to their original topics via [Quarantine.Requeue] once the cause is fixed.
+*/
+package mq
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/sainnhe/go-common/pkg/constant"
+	"github.com/sainnhe/go-common/pkg/kv"
+	"github.com/sainnhe/go-common/pkg/log"
+)
+
+const pkgName = "github.com/sainnhe/go-common/pkg/mq"
+
+// ErrMissingID indicates that the message has no ID, so that it can't be deduplicated.
+var ErrMissingID = errors.New("missing message id")
+
+// Message is a message to be published.
+type Message struct {
+	// ID is the unique ID of the message, which stays the same when the message is re-sent.
+	ID string
+
+	// Topic is the topic that the message is published to.
+	Topic string
+
+	// Key is the optional partition key.
+	Key []byte
+
+	// Payload is the body of the message.
+	Payload []byte
+
+	// Headers are the optional headers of the message.
+	Headers map[string]string
+}
+
+// Publisher publishes messages to a message queue.
+type Publisher interface {
+	// Publish publishes the message.
+	Publish(ctx context.Context, msg *Message) error
+}
+
+// PublisherFunc is an adapter to allow the use of ordinary functions as [Publisher].
+type PublisherFunc func(ctx context.Context, msg *Message) error
+
+// Publish calls f(ctx, msg).
+func (f PublisherFunc) Publish(ctx context.Context, msg *Message) error {
+	return f(ctx, msg)
+}
+
+// Handler handles messages consumed from a message queue.
+type Handler interface {
+	// Handle handles the message. The message is redelivered if an error is returned.
+	Handle(ctx context.Context, msg *Message) error
+}
+
+// HandlerFunc is an adapter to allow the use of ordinary functions as [Handler].
+type HandlerFunc func(ctx context.Context, msg *Message) error
+
+// Handle calls f(ctx, msg).
+func (f HandlerFunc) Handle(ctx context.Context, msg *Message) error {
+	return f(ctx, msg)
+}
+
+// Quarantine is a [Handler] that quarantines poison messages, see [NewQuarantine].
+type Quarantine interface {
+	Handler
+
+	// Requeue publishes the quarantined message of the topic and ID back to the topic, with its delivery count reset.
+	// [ErrNotQuarantined] is returned if the message is not quarantined or has expired.
+	Requeue(ctx context.Context, topic, id string) error
+}
+
+type dedupPublisher struct {
+	next   Publisher
+	store  kv.Store
+	window time.Duration
+	prefix string
+	l      *slog.Logger
+}
+
+/*
+NewDedupPublisher initializes a publisher that drops duplicate messages before they reach next.
+
+A message is published only if its ID is not published within [Config.DedupWindowMs], otherwise nil is returned
+without publishing it, so that the caller treats it as sent. The ID is claimed before publishing and released if next
+fails, so that the message can be re-sent. A message that is re-sent while the previous attempt is still in flight is
+dropped too, and it's up to the caller of the previous attempt to retry if that fails.
+
+Params:
+  - cfg: The config.
+  - next: The underlying publisher.
+  - store: The store of published message IDs.
+
+Returns:
+  - Publisher: The deduplicating publisher, whose Publish returns [ErrMissingID] for messages without ID.
+  - error: [constant.ErrNilDeps] if any dependency is nil.
+*/
+func NewDedupPublisher(cfg *Config, next Publisher, store kv.Store) (Publisher, error) {
+	if cfg == nil || next == nil || store == nil {
+		return nil, constant.ErrNilDeps
+	}
+	return &dedupPublisher{
+		next:   next,
+		store:  store,
+		window: time.Duration(cfg.DedupWindowMs) * time.Millisecond,
+		prefix: cfg.DedupPrefix,
+		l:      log.NewLogger(pkgName),
+	}, nil
+}
+
+func (p *dedupPublisher) Publish(ctx context.Context, msg *Message) error {
+	if msg == nil || msg.ID == "" {
+		return ErrMissingID
+	}
+	key := p.prefix + msg.Topic + ":" + msg.ID
+	ok, err := p.store.SetNX(ctx, key, []byte{1}, p.window)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		p.l.DebugContext(ctx, "Duplicate message dropped.", "topic", msg.Topic, "id", msg.ID)
+		return nil
+	}
+	if err = p.next.Publish(ctx, msg); err != nil {
+		// Use a context that won't be cancelled, otherwise the ID may stay claimed when ctx is done.
+		if delErr := p.store.Delete(context.WithoutCancel(ctx), key); delErr != nil {
+			p.l.ErrorContext(ctx, "Release message ID failed.", constant.LogAttrError, delErr, "topic", msg.Topic,
+				"id", msg.ID)
+		}
+		return err
+	}
+	return nil
+}
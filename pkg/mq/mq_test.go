@@ -0,0 +1,83 @@
+package mq_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/sainnhe/go-common/pkg/clock/testclock"
+	"github.com/sainnhe/go-common/pkg/constant"
+	"github.com/sainnhe/go-common/pkg/kv"
+	"github.com/sainnhe/go-common/pkg/mq"
+	"go.uber.org/mock/gomock"
+)
+
+func TestNewDedupPublisher(t *testing.T) {
+	t.Parallel()
+
+	store, err := kv.NewStore(&kv.Config{Backend: kv.BackendMemory}, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	next := mq.NewMockPublisher(gomock.NewController(t))
+	if _, err = mq.NewDedupPublisher(nil, next, store); !errors.Is(err, constant.ErrNilDeps) {
+		t.Fatalf("Expect error %+v, got %+v", constant.ErrNilDeps, err)
+	}
+	if _, err = mq.NewDedupPublisher(&mq.Config{}, nil, store); !errors.Is(err, constant.ErrNilDeps) {
+		t.Fatalf("Expect error %+v, got %+v", constant.ErrNilDeps, err)
+	}
+	if _, err = mq.NewDedupPublisher(&mq.Config{}, next, nil); !errors.Is(err, constant.ErrNilDeps) {
+		t.Fatalf("Expect error %+v, got %+v", constant.ErrNilDeps, err)
+	}
+}
+
+func TestDedupPublisher_Publish(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	clk := testclock.Freeze(time.Now())
+	store, err := kv.NewStore(&kv.Config{Backend: kv.BackendMemory}, nil, nil, kv.WithClock(clk))
+	if err != nil {
+		t.Fatal(err)
+	}
+	next := mq.NewMockPublisher(gomock.NewController(t))
+	p, err := mq.NewDedupPublisher(&mq.Config{DedupWindowMs: 1000, DedupPrefix: "test:"}, next, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := &mq.Message{ID: "1", Topic: "orders", Payload: []byte("hello")}
+
+	// Messages without ID are rejected.
+	if err = p.Publish(ctx, &mq.Message{Topic: "orders"}); !errors.Is(err, mq.ErrMissingID) {
+		t.Fatalf("Expect error %+v, got %+v", mq.ErrMissingID, err)
+	}
+
+	// Failed messages can be re-sent.
+	errPublish := errors.New("publish failed")
+	next.EXPECT().Publish(ctx, msg).Return(errPublish)
+	if err = p.Publish(ctx, msg); !errors.Is(err, errPublish) {
+		t.Fatalf("Expect error %+v, got %+v", errPublish, err)
+	}
+	next.EXPECT().Publish(ctx, msg).Return(nil)
+	if err = p.Publish(ctx, msg); err != nil {
+		t.Fatal(err)
+	}
+
+	// Duplicates within the window are dropped, while messages with the same ID in other topics are not.
+	if err = p.Publish(ctx, msg); err != nil {
+		t.Fatal(err)
+	}
+	other := &mq.Message{ID: "1", Topic: "payments"}
+	next.EXPECT().Publish(ctx, other).Return(nil)
+	if err = p.Publish(ctx, other); err != nil {
+		t.Fatal(err)
+	}
+
+	// Messages are published again after the window.
+	clk.Advance(time.Second)
+	next.EXPECT().Publish(ctx, msg).Return(nil)
+	if err = p.Publish(ctx, msg); err != nil {
+		t.Fatal(err)
+	}
+}
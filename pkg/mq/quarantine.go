@@ -0,0 +1,202 @@
+package mq
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"strconv"
+	"time"
+
+	"github.com/sainnhe/go-common/pkg/constant"
+	"github.com/sainnhe/go-common/pkg/kv"
+	"github.com/sainnhe/go-common/pkg/log"
+)
+
+const (
+	// HeaderQuarantineTopic is the header of quarantined messages that contains the original topic.
+	HeaderQuarantineTopic = "x-quarantine-topic"
+
+	// HeaderQuarantineDeliveries is the header of quarantined messages that contains the number of deliveries.
+	HeaderQuarantineDeliveries = "x-quarantine-deliveries"
+
+	// HeaderQuarantineError is the header of quarantined messages that contains the error of the last delivery.
+	HeaderQuarantineError = "x-quarantine-error"
+)
+
+var (
+	// ErrInvalidConfig indicates that the config is invalid.
+	ErrInvalidConfig = errors.New("invalid config")
+
+	// ErrNotQuarantined indicates that the message is not quarantined or has expired.
+	ErrNotQuarantined = errors.New("message not quarantined")
+)
+
+// RedeliveryError is returned by [Quarantine.Handle] if the message fails and should be redelivered. Consumers should
+// nack the message and have it redelivered after Delay, e.g. via the visibility timeout of SQS or a delayed retry topic
+// of Kafka.
+type RedeliveryError struct {
+	// Err is the error returned by the underlying handler.
+	Err error
+
+	// Deliveries is the number of deliveries of the message so far.
+	Deliveries int64
+
+	// Delay is the delay before redelivering the message.
+	Delay time.Duration
+}
+
+func (e *RedeliveryError) Error() string {
+	return fmt.Sprintf("redeliver after %s: %v", e.Delay, e.Err)
+}
+
+func (e *RedeliveryError) Unwrap() error {
+	return e.Err
+}
+
+type quarantine struct {
+	next     Handler
+	store    kv.Store
+	pub      Publisher
+	max      int64
+	delay    time.Duration
+	maxDelay time.Duration
+	topic    string
+	prefix   string
+	ttl      time.Duration
+	l        *slog.Logger
+}
+
+/*
+NewQuarantine initializes a handler that quarantines poison messages, so that one malformed message can't wedge a
+consumer group.
+
+Every delivery of a message increases its delivery count in the store. If next fails, a [RedeliveryError] is returned
+with a delay of [Config.RedeliveryDelayMs] doubled after every failed delivery. Once the message fails
+[Config.MaxDeliveries] times, it's saved in the store for [Config.QuarantineTTLMs] and published to
+[Config.QuarantineTopic] with the original topic, the number of deliveries and the error in its headers, and nil is
+returned so that the consumer acks it. The delivery count is reset when the message succeeds or is quarantined.
+
+Params:
+  - cfg: The config.
+  - next: The underlying handler.
+  - store: The store of delivery counts and quarantined messages.
+  - pub: The publisher of quarantined and requeued messages.
+
+Returns:
+  - Quarantine: The quarantine handler, whose Handle returns [ErrMissingID] for messages without ID.
+  - error: [constant.ErrNilDeps] if any dependency is nil, or [ErrInvalidConfig] if [Config.MaxDeliveries] is not
+    positive or [Config.QuarantineTopic] is empty.
+*/
+func NewQuarantine(cfg *Config, next Handler, store kv.Store, pub Publisher) (Quarantine, error) {
+	if cfg == nil || next == nil || store == nil || pub == nil {
+		return nil, constant.ErrNilDeps
+	}
+	if cfg.MaxDeliveries <= 0 || cfg.QuarantineTopic == "" {
+		return nil, ErrInvalidConfig
+	}
+	return &quarantine{
+		next:     next,
+		store:    store,
+		pub:      pub,
+		max:      cfg.MaxDeliveries,
+		delay:    time.Duration(cfg.RedeliveryDelayMs) * time.Millisecond,
+		maxDelay: time.Duration(cfg.MaxRedeliveryDelayMs) * time.Millisecond,
+		topic:    cfg.QuarantineTopic,
+		prefix:   cfg.QuarantinePrefix,
+		ttl:      time.Duration(cfg.QuarantineTTLMs) * time.Millisecond,
+		l:        log.NewLogger(pkgName),
+	}, nil
+}
+
+func (q *quarantine) Handle(ctx context.Context, msg *Message) error {
+	if msg == nil || msg.ID == "" {
+		return ErrMissingID
+	}
+	countKey := q.prefix + "deliveries:" + msg.Topic + ":" + msg.ID
+	deliveries, err := q.store.IncrBy(ctx, countKey, 1, q.ttl)
+	if err != nil {
+		return err
+	}
+
+	handleErr := q.next.Handle(ctx, msg)
+	if handleErr == nil {
+		q.reset(ctx, countKey, msg)
+		return nil
+	}
+	if deliveries < q.max {
+		return &RedeliveryError{handleErr, deliveries, q.redeliveryDelay(deliveries)}
+	}
+
+	if err = q.quarantine(ctx, msg, deliveries, handleErr); err != nil {
+		q.l.ErrorContext(ctx, "Quarantine message failed.", constant.LogAttrError, err, "topic", msg.Topic,
+			"id", msg.ID)
+		return &RedeliveryError{errors.Join(handleErr, err), deliveries, q.maxDelay}
+	}
+	q.l.WarnContext(ctx, "Message quarantined.", constant.LogAttrError, handleErr, "topic", msg.Topic, "id", msg.ID,
+		"deliveries", deliveries)
+	q.reset(ctx, countKey, msg)
+	return nil
+}
+
+func (q *quarantine) Requeue(ctx context.Context, topic, id string) error {
+	key := q.prefix + "messages:" + topic + ":" + id
+	data, err := q.store.Get(ctx, key)
+	if errors.Is(err, kv.ErrNotFound) {
+		return ErrNotQuarantined
+	}
+	if err != nil {
+		return err
+	}
+	msg := &Message{}
+	if err = json.Unmarshal(data, msg); err != nil {
+		return err
+	}
+	if err = q.pub.Publish(ctx, msg); err != nil {
+		return err
+	}
+	return q.store.Delete(ctx, key)
+}
+
+// redeliveryDelay returns the delay before redelivering a message that has failed the given number of times.
+func (q *quarantine) redeliveryDelay(deliveries int64) time.Duration {
+	delay := q.delay
+	for i := int64(1); i < deliveries && delay < q.maxDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, q.maxDelay)
+}
+
+// quarantine saves the message for requeueing, and publishes it to the quarantine topic.
+func (q *quarantine) quarantine(ctx context.Context, msg *Message, deliveries int64, handleErr error) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	if err = q.store.Set(ctx, q.prefix+"messages:"+msg.Topic+":"+msg.ID, data, q.ttl); err != nil {
+		return err
+	}
+	headers := make(map[string]string, len(msg.Headers)+3) // nolint:mnd
+	maps.Copy(headers, msg.Headers)
+	headers[HeaderQuarantineTopic] = msg.Topic
+	headers[HeaderQuarantineDeliveries] = strconv.FormatInt(deliveries, 10)
+	headers[HeaderQuarantineError] = handleErr.Error()
+	return q.pub.Publish(ctx, &Message{
+		ID:      msg.ID,
+		Topic:   q.topic,
+		Key:     msg.Key,
+		Payload: msg.Payload,
+		Headers: headers,
+	})
+}
+
+// reset deletes the delivery count of the message. Failures are only logged since the count expires anyway.
+func (q *quarantine) reset(ctx context.Context, countKey string, msg *Message) {
+	// Use a context that won't be cancelled, otherwise the count may be kept when ctx is done.
+	if err := q.store.Delete(context.WithoutCancel(ctx), countKey); err != nil {
+		q.l.ErrorContext(ctx, "Reset delivery count failed.", constant.LogAttrError, err, "topic", msg.Topic,
+			"id", msg.ID)
+	}
+}
@@ -0,0 +1,143 @@
+package mq_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/sainnhe/go-common/pkg/constant"
+	"github.com/sainnhe/go-common/pkg/kv"
+	"github.com/sainnhe/go-common/pkg/mq"
+	"go.uber.org/mock/gomock"
+)
+
+func TestNewQuarantine(t *testing.T) {
+	t.Parallel()
+
+	store, err := kv.NewStore(&kv.Config{Backend: kv.BackendMemory}, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctrl := gomock.NewController(t)
+	next, pub := mq.NewMockHandler(ctrl), mq.NewMockPublisher(ctrl)
+	cfg := &mq.Config{MaxDeliveries: 3, QuarantineTopic: "quarantine"}
+	if _, err = mq.NewQuarantine(nil, next, store, pub); !errors.Is(err, constant.ErrNilDeps) {
+		t.Fatalf("Expect error %+v, got %+v", constant.ErrNilDeps, err)
+	}
+	if _, err = mq.NewQuarantine(cfg, nil, store, pub); !errors.Is(err, constant.ErrNilDeps) {
+		t.Fatalf("Expect error %+v, got %+v", constant.ErrNilDeps, err)
+	}
+	if _, err = mq.NewQuarantine(cfg, next, nil, pub); !errors.Is(err, constant.ErrNilDeps) {
+		t.Fatalf("Expect error %+v, got %+v", constant.ErrNilDeps, err)
+	}
+	if _, err = mq.NewQuarantine(cfg, next, store, nil); !errors.Is(err, constant.ErrNilDeps) {
+		t.Fatalf("Expect error %+v, got %+v", constant.ErrNilDeps, err)
+	}
+	if _, err = mq.NewQuarantine(&mq.Config{QuarantineTopic: "quarantine"}, next, store, pub); !errors.Is(err,
+		mq.ErrInvalidConfig) {
+		t.Fatalf("Expect error %+v, got %+v", mq.ErrInvalidConfig, err)
+	}
+	if _, err = mq.NewQuarantine(&mq.Config{MaxDeliveries: 3}, next, store, pub); !errors.Is(err,
+		mq.ErrInvalidConfig) {
+		t.Fatalf("Expect error %+v, got %+v", mq.ErrInvalidConfig, err)
+	}
+}
+
+func TestQuarantine(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	store, err := kv.NewStore(&kv.Config{Backend: kv.BackendMemory}, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctrl := gomock.NewController(t)
+	next, pub := mq.NewMockHandler(ctrl), mq.NewMockPublisher(ctrl)
+	q, err := mq.NewQuarantine(&mq.Config{
+		MaxDeliveries:        4,
+		RedeliveryDelayMs:    1000,
+		MaxRedeliveryDelayMs: 3000,
+		QuarantineTopic:      "quarantine",
+		QuarantinePrefix:     "test:",
+		QuarantineTTLMs:      60000,
+	}, next, store, pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := &mq.Message{ID: "1", Topic: "orders", Payload: []byte("malformed"), Headers: map[string]string{"k": "v"}}
+
+	// Messages without ID are rejected.
+	if err = q.Handle(ctx, &mq.Message{Topic: "orders"}); !errors.Is(err, mq.ErrMissingID) {
+		t.Fatalf("Expect error %+v, got %+v", mq.ErrMissingID, err)
+	}
+
+	// Failed messages are redelivered with exponential delays.
+	errHandle := errors.New("malformed payload")
+	next.EXPECT().Handle(ctx, msg).Return(errHandle).Times(4)
+	for i, delay := range []time.Duration{time.Second, 2 * time.Second, 3 * time.Second} {
+		var re *mq.RedeliveryError
+		if err = q.Handle(ctx, msg); !errors.As(err, &re) || !errors.Is(err, errHandle) {
+			t.Fatalf("Expect redelivery error wrapping %+v, got %+v", errHandle, err)
+		}
+		if re.Deliveries != int64(i+1) || re.Delay != delay {
+			t.Fatalf("Expect delivery %d with delay %s, got %+v", i+1, delay, re)
+		}
+	}
+
+	// The message is quarantined after the maximum number of deliveries.
+	pub.EXPECT().Publish(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, m *mq.Message) error {
+		if m.ID != msg.ID || m.Topic != "quarantine" || string(m.Payload) != "malformed" || m.Headers["k"] != "v" ||
+			m.Headers[mq.HeaderQuarantineTopic] != "orders" || m.Headers[mq.HeaderQuarantineDeliveries] != "4" ||
+			m.Headers[mq.HeaderQuarantineError] != errHandle.Error() {
+			t.Errorf("Unexpected quarantined message %+v", m)
+		}
+		return nil
+	})
+	if err = q.Handle(ctx, msg); err != nil {
+		t.Fatal(err)
+	}
+
+	// The quarantined message is requeued to its original topic with the delivery count reset.
+	if err = q.Requeue(ctx, "orders", "2"); !errors.Is(err, mq.ErrNotQuarantined) {
+		t.Fatalf("Expect error %+v, got %+v", mq.ErrNotQuarantined, err)
+	}
+	errPublish := errors.New("publish failed")
+	pub.EXPECT().Publish(ctx, msg).Return(errPublish)
+	if err = q.Requeue(ctx, "orders", "1"); !errors.Is(err, errPublish) {
+		t.Fatalf("Expect error %+v, got %+v", errPublish, err)
+	}
+	pub.EXPECT().Publish(ctx, msg).Return(nil)
+	if err = q.Requeue(ctx, "orders", "1"); err != nil {
+		t.Fatal(err)
+	}
+	if err = q.Requeue(ctx, "orders", "1"); !errors.Is(err, mq.ErrNotQuarantined) {
+		t.Fatalf("Expect error %+v, got %+v", mq.ErrNotQuarantined, err)
+	}
+	var re *mq.RedeliveryError
+	next.EXPECT().Handle(ctx, msg).Return(errHandle)
+	if err = q.Handle(ctx, msg); !errors.As(err, &re) || re.Deliveries != 1 {
+		t.Fatalf("Expect first redelivery, got %+v", err)
+	}
+
+	// Successful messages reset the delivery count.
+	next.EXPECT().Handle(ctx, msg).Return(nil)
+	if err = q.Handle(ctx, msg); err != nil {
+		t.Fatal(err)
+	}
+	next.EXPECT().Handle(ctx, msg).Return(errHandle)
+	if err = q.Handle(ctx, msg); !errors.As(err, &re) || re.Deliveries != 1 {
+		t.Fatalf("Expect first redelivery, got %+v", err)
+	}
+
+	// The message is redelivered if it can't be quarantined.
+	other := &mq.Message{ID: "2", Topic: "orders"}
+	next.EXPECT().Handle(ctx, other).Return(errHandle).Times(4)
+	pub.EXPECT().Publish(ctx, gomock.Any()).Return(errPublish)
+	for range 3 {
+		_ = q.Handle(ctx, other)
+	}
+	if err = q.Handle(ctx, other); !errors.As(err, &re) || !errors.Is(err, errPublish) || re.Delay != 3*time.Second {
+		t.Fatalf("Expect redelivery error wrapping %+v, got %+v", errPublish, err)
+	}
+}
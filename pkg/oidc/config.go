@@ -0,0 +1,40 @@
+package oidc
+
+// Config defines the config model for oidc.
+type Config struct {
+	// Issuer is the issuer URL of the OpenID provider, which is used to discover the provider metadata.
+	Issuer string `json:"issuer" yaml:"issuer" toml:"issuer" xml:"issuer" env:"OIDC_ISSUER" default:""`
+
+	// ClientID is the client ID registered at the provider.
+	ClientID string `json:"client_id" yaml:"client_id" toml:"client_id" xml:"client_id" env:"OIDC_CLIENT_ID" default:""`
+
+	// ClientSecret is the client secret registered at the provider.
+	ClientSecret string `json:"client_secret" yaml:"client_secret" toml:"client_secret" xml:"client_secret" env:"OIDC_CLIENT_SECRET" default:"" secret:"true"` // nolint:lll
+
+	// RedirectURL is the URL the provider redirects to after authentication, which should be served by the callback
+	// handler.
+	RedirectURL string `json:"redirect_url" yaml:"redirect_url" toml:"redirect_url" xml:"redirect_url" env:"OIDC_REDIRECT_URL" default:""` // nolint:lll
+
+	// Scopes are the requested scopes.
+	Scopes []string `json:"scopes" yaml:"scopes" toml:"scopes" xml:"scopes" env:"OIDC_SCOPES" default:"[\"openid\",\"profile\",\"email\"]"` // nolint:lll
+
+	// JWKSCacheSec is how long the JSON Web Key Set of the provider is cached in seconds. The key set is also
+	// refreshed when an ID token is signed by an unknown key.
+	JWKSCacheSec int64 `json:"jwks_cache_sec" yaml:"jwks_cache_sec" toml:"jwks_cache_sec" xml:"jwks_cache_sec" env:"OIDC_JWKS_CACHE_SEC" default:"3600"` // nolint:lll
+
+	// LoginPath is the path of the login handler, which unauthenticated GET requests are redirected to by the
+	// middleware.
+	LoginPath string `json:"login_path" yaml:"login_path" toml:"login_path" xml:"login_path" env:"OIDC_LOGIN_PATH" default:"/auth/login"` // nolint:lll
+
+	// SessionCookie is the name of the session cookie.
+	SessionCookie string `json:"session_cookie" yaml:"session_cookie" toml:"session_cookie" xml:"session_cookie" env:"OIDC_SESSION_COOKIE" default:"oidc_session"` // nolint:lll
+
+	// SessionSecret is the secret key used to sign session cookies. It must not be empty.
+	SessionSecret string `json:"session_secret" yaml:"session_secret" toml:"session_secret" xml:"session_secret" env:"OIDC_SESSION_SECRET" default:"" secret:"true"` // nolint:lll
+
+	// SessionTTLSec is how long a session lasts in seconds.
+	SessionTTLSec int64 `json:"session_ttl_sec" yaml:"session_ttl_sec" toml:"session_ttl_sec" xml:"session_ttl_sec" env:"OIDC_SESSION_TTL_SEC" default:"86400"` // nolint:lll
+
+	// InsecureCookie disables the Secure attribute of cookies, which is useful for local development over HTTP.
+	InsecureCookie bool `json:"insecure_cookie" yaml:"insecure_cookie" toml:"insecure_cookie" xml:"insecure_cookie" env:"OIDC_INSECURE_COOKIE" default:"false"` // nolint:lll
+}
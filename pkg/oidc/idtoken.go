@@ -0,0 +1,168 @@
+package oidc
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+	"strings"
+	"time"
+)
+
+// ErrInvalidIDToken indicates that the ID token is malformed, has an invalid signature, or has invalid claims.
+var ErrInvalidIDToken = errors.New("invalid id token")
+
+// ecAlgs maps curves to the ECDSA algorithms using them.
+var ecAlgs = map[string]string{
+	"P-256": "ES256",
+	"P-384": "ES384",
+	"P-521": "ES512",
+}
+
+// leeway tolerates clock drift between the client and the provider when checking expiration.
+const leeway = time.Minute
+
+// Audience is the "aud" claim, which can be either a string or an array of strings.
+type Audience []string
+
+// UnmarshalJSON implements [json.Unmarshaler].
+func (a *Audience) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err == nil {
+		*a = Audience{s}
+		return nil
+	}
+	return json.Unmarshal(b, (*[]string)(a))
+}
+
+// Claims are the claims of an ID token.
+type Claims struct {
+	Issuer          string   `json:"iss"`
+	Subject         string   `json:"sub"`
+	Audience        Audience `json:"aud"`
+	AuthorizedParty string   `json:"azp,omitempty"`
+	ExpiresAt       int64    `json:"exp"`
+	IssuedAt        int64    `json:"iat"`
+	Nonce           string   `json:"nonce,omitempty"`
+	Email           string   `json:"email,omitempty"`
+	EmailVerified   bool     `json:"email_verified,omitempty"`
+	Name            string   `json:"name,omitempty"`
+}
+
+/*
+Verify verifies the signature and claims of an ID token.
+
+Params:
+  - ctx: The context used to fetch the JSON Web Key Set if needed.
+  - rawIDToken: The ID token.
+  - nonce: The nonce passed to [Client.AuthCodeURL]. The nonce claim is not checked if it's empty.
+
+Returns:
+  - *Claims: The claims.
+  - error: [ErrInvalidIDToken] if the ID token is invalid, or an error if the key set can't be fetched.
+*/
+func (c *Client) Verify(ctx context.Context, rawIDToken, nonce string) (*Claims, error) {
+	parts := strings.Split(rawIDToken, ".")
+	if len(parts) != 3 { // nolint:mnd
+		return nil, fmt.Errorf("%w: malformed", ErrInvalidIDToken)
+	}
+	header := struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}{}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, err
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidIDToken, err)
+	}
+	key, err := c.keys.get(ctx, header.Kid)
+	if err != nil {
+		return nil, err
+	}
+	if key == nil {
+		return nil, fmt.Errorf("%w: unknown key %q", ErrInvalidIDToken, header.Kid)
+	}
+	if err := verifySignature(header.Alg, key, parts[0]+"."+parts[1], sig); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidIDToken, err)
+	}
+
+	claims := &Claims{}
+	if err := decodeSegment(parts[1], claims); err != nil {
+		return nil, err
+	}
+	now := c.clk.Now()
+	switch {
+	case claims.Issuer != c.provider.Issuer:
+		return nil, fmt.Errorf("%w: issuer mismatch, got %s", ErrInvalidIDToken, claims.Issuer)
+	case !slices.Contains(claims.Audience, c.cfg.ClientID):
+		return nil, fmt.Errorf("%w: audience mismatch, got %v", ErrInvalidIDToken, claims.Audience)
+	case len(claims.Audience) > 1 && claims.AuthorizedParty == "":
+		return nil, fmt.Errorf("%w: missing authorized party for multiple audiences", ErrInvalidIDToken)
+	case claims.AuthorizedParty != "" && claims.AuthorizedParty != c.cfg.ClientID:
+		return nil, fmt.Errorf("%w: authorized party mismatch, got %s", ErrInvalidIDToken, claims.AuthorizedParty)
+	case !now.Before(time.Unix(claims.ExpiresAt, 0).Add(leeway)):
+		return nil, fmt.Errorf("%w: expired", ErrInvalidIDToken)
+	case nonce != "" && claims.Nonce != nonce:
+		return nil, fmt.Errorf("%w: nonce mismatch", ErrInvalidIDToken)
+	}
+	return claims, nil
+}
+
+func decodeSegment(seg string, v any) error {
+	b, err := base64.RawURLEncoding.DecodeString(seg)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidIDToken, err)
+	}
+	if err := json.Unmarshal(b, v); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidIDToken, err)
+	}
+	return nil
+}
+
+// verifySignature verifies a JWS signature. Only RSA PKCS #1 v1.5 and ECDSA algorithms are supported, since they are
+// what providers use in practice.
+func verifySignature(alg string, key crypto.PublicKey, signed string, sig []byte) error {
+	var hash crypto.Hash
+	switch alg {
+	case "RS256", "ES256":
+		hash = crypto.SHA256
+	case "RS384", "ES384":
+		hash = crypto.SHA384
+	case "RS512", "ES512":
+		hash = crypto.SHA512
+	default:
+		return fmt.Errorf("unsupported algorithm %q", alg)
+	}
+	h := hash.New()
+	h.Write([]byte(signed))
+	digest := h.Sum(nil)
+
+	switch key := key.(type) {
+	case *rsa.PublicKey:
+		if alg[0] != 'R' {
+			return fmt.Errorf("algorithm %s doesn't match RSA key", alg)
+		}
+		return rsa.VerifyPKCS1v15(key, hash, digest, sig)
+	case *ecdsa.PublicKey:
+		params := key.Curve.Params()
+		size := (params.BitSize + 7) / 8 // nolint:mnd
+		if alg != ecAlgs[params.Name] || len(sig) != 2*size {
+			return fmt.Errorf("algorithm %s doesn't match EC key", alg)
+		}
+		r, s := new(big.Int).SetBytes(sig[:size]), new(big.Int).SetBytes(sig[size:])
+		if !ecdsa.Verify(key, digest, r, s) {
+			return errors.New("ecdsa verification failed")
+		}
+		return nil
+	default:
+		return errors.New("unsupported key")
+	}
+}
@@ -0,0 +1,154 @@
+package oidc
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/sainnhe/go-common/pkg/constant"
+	"golang.org/x/sync/singleflight"
+)
+
+// minRefreshInterval limits how often the key set is refreshed because of unknown key IDs, so that tokens with random
+// key IDs can't make the client flood the provider.
+const minRefreshInterval = time.Minute
+
+// keySet caches the JSON Web Key Set of the provider.
+type keySet struct {
+	c         *Client
+	group     singleflight.Group
+	mu        sync.RWMutex
+	keys      map[string]crypto.PublicKey
+	fetchTime time.Time
+}
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+func newKeySet(c *Client) *keySet {
+	return &keySet{c: c}
+}
+
+// get returns the key with the given ID, refreshing the key set if it's expired or the key is unknown. Concurrent
+// refreshes are merged into one request, and the cached key set is kept if a refresh fails.
+func (s *keySet) get(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	s.mu.RLock()
+	keys, age := s.keys, s.c.clk.Since(s.fetchTime)
+	s.mu.RUnlock()
+	if keys != nil && age < time.Duration(s.c.cfg.JWKSCacheSec)*time.Second {
+		if key, ok := keys[kid]; ok || age < minRefreshInterval {
+			return key, nil
+		}
+	}
+
+	select {
+	case res := <-s.group.DoChan("", func() (any, error) { return nil, s.refresh(ctx) }):
+		if res.Err != nil {
+			if keys == nil {
+				return nil, res.Err
+			}
+			s.c.l.WarnContext(ctx, "Refresh JWKS failed, using the cached key set.", constant.LogAttrError, res.Err)
+		}
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.keys[kid], nil
+}
+
+// refresh fetches the key set and replaces the cached one if succeeded.
+func (s *keySet) refresh(ctx context.Context) error {
+	set := struct {
+		Keys []jwk `json:"keys"`
+	}{}
+	if err := s.c.getJSON(ctx, s.c.provider.JWKSURI, &set); err != nil {
+		return fmt.Errorf("fetch jwks: %w", err)
+	}
+	keys := map[string]crypto.PublicKey{}
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		key, err := k.publicKey()
+		if err != nil {
+			// Skip unsupported keys instead of failing the whole set.
+			continue
+		}
+		keys[k.Kid] = key
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys = keys
+	s.fetchTime = s.c.clk.Now()
+	return nil
+}
+
+func (k *jwk) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		if !e.IsInt64() {
+			return nil, errors.New("invalid exponent")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %s", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		if !curve.IsOnCurve(x, y) { // nolint:staticcheck
+			return nil, errors.New("point not on curve")
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %s", k.Kty)
+	}
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(b), nil
+}
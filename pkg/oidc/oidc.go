@@ -0,0 +1,243 @@
+/*
+Package oidc implements an OpenID Connect relying party using the authorization code flow with PKCE.
+
+The client discovers the provider metadata from the issuer, exchanges authorization codes and refresh tokens, and
+validates ID tokens against the JSON Web Key Set of the provider. It also provides HTTP handlers that establish
+sessions in signed cookies:
+
+	c, err := oidc.NewClient(ctx, cfg)
+	mux.Handle("GET /auth/login", c.LoginHandler())
+	mux.Handle("GET /auth/callback", c.CallbackHandler())
+	mux.Handle("POST /auth/logout", c.LogoutHandler())
+	mux.Handle("/", c.Middleware(app))
+
+	// In app:
+	claims, ok := oidc.ClaimsFromContext(r.Context())
+*/
+package oidc
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/sainnhe/go-common/pkg/clock"
+	"github.com/sainnhe/go-common/pkg/constant"
+	"github.com/sainnhe/go-common/pkg/log"
+)
+
+const pkgName = "github.com/sainnhe/go-common/pkg/oidc"
+
+var (
+	// ErrEmptySecret indicates that the session secret in config is empty.
+	ErrEmptySecret = errors.New("empty session secret")
+
+	// ErrDiscovery indicates that the provider metadata can't be discovered.
+	ErrDiscovery = errors.New("discovery failed")
+
+	// ErrTokenRequest indicates that the token endpoint returns an error.
+	ErrTokenRequest = errors.New("token request failed")
+)
+
+// Provider is the metadata of an OpenID provider.
+type Provider struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	UserinfoEndpoint      string `json:"userinfo_endpoint,omitempty"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// Token is the response of the token endpoint.
+type Token struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	IDToken      string `json:"id_token,omitempty"`
+	ExpiresIn    int64  `json:"expires_in,omitempty"`
+}
+
+// Client is the OpenID Connect client.
+type Client struct {
+	cfg      *Config
+	provider *Provider
+	hc       *http.Client
+	clk      clock.Clock
+	keys     *keySet
+	l        *slog.Logger
+}
+
+// Option is the option used to customize the client.
+type Option func(c *Client)
+
+// WithHTTPClient sets the HTTP client used to send requests to the provider. Defaults to [http.DefaultClient].
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) {
+		if hc != nil {
+			c.hc = hc
+		}
+	}
+}
+
+// WithClock sets the clock used to validate ID tokens and sessions. Defaults to [clock.Real].
+func WithClock(clk clock.Clock) Option {
+	return func(c *Client) {
+		if clk != nil {
+			c.clk = clk
+		}
+	}
+}
+
+/*
+NewClient initializes a new client by discovering the provider metadata from the issuer.
+
+Params:
+  - ctx: The context of the discovery request.
+  - cfg: The config.
+  - opts: The options.
+
+Returns:
+  - *Client: The client.
+  - error: [constant.ErrNilDeps] if cfg is nil, [ErrEmptySecret] if the session secret is empty, or [ErrDiscovery] if
+    the provider metadata can't be discovered.
+*/
+func NewClient(ctx context.Context, cfg *Config, opts ...Option) (*Client, error) {
+	if cfg == nil {
+		return nil, constant.ErrNilDeps
+	}
+	if cfg.SessionSecret == "" {
+		return nil, ErrEmptySecret
+	}
+	c := &Client{
+		cfg: cfg,
+		hc:  http.DefaultClient,
+		clk: clock.Real(),
+		l:   log.NewLogger(pkgName),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	// The discovered issuer must be identical to the configured one, see OpenID Connect Discovery 1.0 section 4.3.
+	c.provider = &Provider{}
+	discoveryURL := strings.TrimSuffix(cfg.Issuer, "/") + "/.well-known/openid-configuration"
+	if err := c.getJSON(ctx, discoveryURL, c.provider); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrDiscovery, err)
+	}
+	if c.provider.Issuer != cfg.Issuer {
+		return nil, fmt.Errorf("%w: issuer mismatch, got %s", ErrDiscovery, c.provider.Issuer)
+	}
+	c.keys = newKeySet(c)
+	return c, nil
+}
+
+// Provider returns the discovered provider metadata.
+func (c *Client) Provider() *Provider {
+	return c.provider
+}
+
+/*
+AuthCodeURL returns the URL of the authorization endpoint that starts the authorization code flow.
+
+Params:
+  - state: The opaque value used to prevent CSRF, which is returned to the callback as is.
+  - nonce: The value bound to the ID token to prevent replay attacks.
+  - verifier: The PKCE code verifier, which should be passed to [Client.Exchange] later.
+
+Returns:
+  - string: The URL.
+*/
+func (c *Client) AuthCodeURL(state, nonce, verifier string) string {
+	challenge := sha256.Sum256([]byte(verifier))
+	q := url.Values{}
+	q.Set("response_type", "code")
+	q.Set("client_id", c.cfg.ClientID)
+	q.Set("redirect_uri", c.cfg.RedirectURL)
+	q.Set("scope", strings.Join(c.cfg.Scopes, " "))
+	q.Set("state", state)
+	q.Set("nonce", nonce)
+	q.Set("code_challenge", base64.RawURLEncoding.EncodeToString(challenge[:]))
+	q.Set("code_challenge_method", "S256")
+	sep := "?"
+	if strings.Contains(c.provider.AuthorizationEndpoint, "?") {
+		sep = "&"
+	}
+	return c.provider.AuthorizationEndpoint + sep + q.Encode()
+}
+
+// Exchange exchanges an authorization code for tokens. [ErrTokenRequest] is returned if the provider rejects it.
+func (c *Client) Exchange(ctx context.Context, code, verifier string) (*Token, error) {
+	return c.requestToken(ctx, url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {c.cfg.RedirectURL},
+		"code_verifier": {verifier},
+	})
+}
+
+// Refresh exchanges a refresh token for new tokens. [ErrTokenRequest] is returned if the provider rejects it.
+func (c *Client) Refresh(ctx context.Context, refreshToken string) (*Token, error) {
+	return c.requestToken(ctx, url.Values{
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {refreshToken},
+	})
+}
+
+func (c *Client) requestToken(ctx context.Context, form url.Values) (*Token, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.provider.TokenEndpoint,
+		strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(url.QueryEscape(c.cfg.ClientID), url.QueryEscape(c.cfg.ClientSecret))
+	resp, err := c.hc.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close() // nolint:errcheck
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		e := struct {
+			Error       string `json:"error"`
+			Description string `json:"error_description"`
+		}{}
+		_ = json.Unmarshal(body, &e)
+		return nil, fmt.Errorf("%w: status %d: %s %s", ErrTokenRequest, resp.StatusCode, e.Error, e.Description)
+	}
+	t := &Token{}
+	if err := json.Unmarshal(body, t); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrTokenRequest, err)
+	}
+	return t, nil
+}
+
+func (c *Client) getJSON(ctx context.Context, u string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := c.hc.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close() // nolint:errcheck
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d from %s", resp.StatusCode, u)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
@@ -0,0 +1,337 @@
+package oidc_test
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sainnhe/go-common/pkg/clock/testclock"
+	"github.com/sainnhe/go-common/pkg/constant"
+	"github.com/sainnhe/go-common/pkg/oidc"
+)
+
+type provider struct {
+	*httptest.Server
+	rsaKey *rsa.PrivateKey
+	ecKey  *ecdsa.PrivateKey
+	clk    *testclock.Clock
+	codes  map[string][2]string // code -> [nonce, challenge]
+	issuer string               // Defaults to the server URL.
+	broken atomic.Bool          // Whether the JWKS endpoint fails.
+}
+
+func newProvider(t *testing.T, clk *testclock.Clock) *provider {
+	t.Helper()
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := &provider{rsaKey: rsaKey, ecKey: ecKey, clk: clk, codes: map[string][2]string{}}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", func(w http.ResponseWriter, _ *http.Request) {
+		_ = json.NewEncoder(w).Encode(&oidc.Provider{
+			Issuer:                p.iss(),
+			AuthorizationEndpoint: p.URL + "/authorize",
+			TokenEndpoint:         p.URL + "/token",
+			JWKSURI:               p.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("GET /jwks", func(w http.ResponseWriter, _ *http.Request) {
+		if p.broken.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		enc := base64.RawURLEncoding.EncodeToString
+		_ = json.NewEncoder(w).Encode(map[string]any{"keys": []map[string]string{
+			{"kty": "RSA", "kid": "rsa", "use": "sig", "n": enc(rsaKey.N.Bytes()),
+				"e": enc(big.NewInt(int64(rsaKey.E)).Bytes())},
+			{"kty": "EC", "kid": "ec", "crv": "P-256", "x": enc(ecKey.X.Bytes()), "y": enc(ecKey.Y.Bytes())},
+			{"kty": "oct", "kid": "hmac"},
+		}})
+	})
+	mux.HandleFunc("POST /token", func(w http.ResponseWriter, r *http.Request) {
+		if id, secret, _ := r.BasicAuth(); id != "client" || secret != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"error": "invalid_client"}`))
+			return
+		}
+		switch r.PostFormValue("grant_type") {
+		case "authorization_code":
+			code, ok := p.codes[r.PostFormValue("code")]
+			challenge := sha256.Sum256([]byte(r.PostFormValue("code_verifier")))
+			if !ok || code[1] != base64.RawURLEncoding.EncodeToString(challenge[:]) {
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = w.Write([]byte(`{"error": "invalid_grant"}`))
+				return
+			}
+			_ = json.NewEncoder(w).Encode(&oidc.Token{
+				AccessToken: "access",
+				IDToken:     p.sign(t, "RS256", "rsa", p.claims(code[0])),
+			})
+		case "refresh_token":
+			_ = json.NewEncoder(w).Encode(&oidc.Token{AccessToken: "refreshed"})
+		}
+	})
+	p.Server = httptest.NewServer(mux)
+	t.Cleanup(p.Close)
+	return p
+}
+
+func (p *provider) iss() string {
+	if p.issuer != "" {
+		return p.issuer
+	}
+	return p.URL
+}
+
+func (p *provider) claims(nonce string) map[string]any {
+	return map[string]any{
+		"iss":   p.iss(),
+		"sub":   "alice",
+		"aud":   "client",
+		"exp":   p.clk.Now().Add(time.Hour).Unix(),
+		"iat":   p.clk.Now().Unix(),
+		"nonce": nonce,
+		"email": "alice@example.com",
+	}
+}
+
+func (p *provider) sign(t *testing.T, alg, kid string, claims map[string]any) string {
+	t.Helper()
+
+	header, _ := json.Marshal(map[string]string{"alg": alg, "kid": kid})
+	payload, _ := json.Marshal(claims)
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signed))
+	var sig []byte
+	var err error
+	if alg == "RS256" {
+		sig, err = rsa.SignPKCS1v15(rand.Reader, p.rsaKey, crypto.SHA256, digest[:])
+	} else {
+		var r, s *big.Int
+		r, s, err = ecdsa.Sign(rand.Reader, p.ecKey, digest[:])
+		sig = append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+func newClient(t *testing.T) (*oidc.Client, *provider, *testclock.Clock) {
+	t.Helper()
+
+	clk := testclock.Freeze(time.Now())
+	p := newProvider(t, clk)
+	c, err := oidc.NewClient(context.Background(), &oidc.Config{
+		Issuer:        p.URL,
+		ClientID:      "client",
+		ClientSecret:  "secret",
+		RedirectURL:   "http://app/auth/callback",
+		Scopes:        []string{"openid", "email"},
+		JWKSCacheSec:  3600,
+		LoginPath:     "/auth/login",
+		SessionCookie: "session",
+		SessionSecret: "foo",
+		SessionTTLSec: 3600,
+	}, oidc.WithClock(clk))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c, p, clk
+}
+
+func TestNewClient(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	if _, err := oidc.NewClient(ctx, nil); !errors.Is(err, constant.ErrNilDeps) {
+		t.Fatalf("Expect error %+v, got %+v", constant.ErrNilDeps, err)
+	}
+	if _, err := oidc.NewClient(ctx, &oidc.Config{}); !errors.Is(err, oidc.ErrEmptySecret) {
+		t.Fatalf("Expect error %+v, got %+v", oidc.ErrEmptySecret, err)
+	}
+	p := newProvider(t, testclock.Freeze(time.Now()))
+	for _, issuer := range []string{p.URL + "/foo", "http://127.0.0.1:0"} {
+		if _, err := oidc.NewClient(ctx, &oidc.Config{Issuer: issuer, SessionSecret: "foo"}); !errors.Is(err,
+			oidc.ErrDiscovery) {
+			t.Fatalf("Expect error %+v, got %+v", oidc.ErrDiscovery, err)
+		}
+	}
+
+	// The issuer must match exactly, including the trailing slash.
+	p.issuer = p.URL + "/"
+	if _, err := oidc.NewClient(ctx, &oidc.Config{Issuer: p.URL + "/", SessionSecret: "foo"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := oidc.NewClient(ctx, &oidc.Config{Issuer: p.URL, SessionSecret: "foo"}); !errors.Is(err,
+		oidc.ErrDiscovery) {
+		t.Fatalf("Expect error %+v, got %+v", oidc.ErrDiscovery, err)
+	}
+}
+
+func TestClient_Verify(t *testing.T) {
+	t.Parallel()
+
+	c, p, clk := newClient(t)
+	ctx := context.Background()
+	for alg, kid := range map[string]string{"RS256": "rsa", "ES256": "ec"} {
+		claims, err := c.Verify(ctx, p.sign(t, alg, kid, p.claims("n")), "n")
+		if err != nil {
+			t.Fatalf("[%s] %+v", alg, err)
+		}
+		if claims.Subject != "alice" || claims.Email != "alice@example.com" {
+			t.Fatalf("[%s] Unexpected claims %+v", alg, claims)
+		}
+	}
+
+	valid := p.sign(t, "RS256", "rsa", p.claims("n"))
+	wrongAud := p.claims("n")
+	wrongAud["aud"] = []string{"other"}
+	multiAud := p.claims("n")
+	multiAud["aud"] = []string{"client", "other"}
+	wrongAzp := p.claims("n")
+	wrongAzp["azp"] = "other"
+	tests := []struct {
+		name  string
+		token string
+		nonce string
+	}{
+		{"Malformed", "foo", ""},
+		{"Nonce mismatch", valid, "m"},
+		{"Audience mismatch", p.sign(t, "RS256", "rsa", wrongAud), "n"},
+		{"Missing authorized party", p.sign(t, "RS256", "rsa", multiAud), "n"},
+		{"Authorized party mismatch", p.sign(t, "RS256", "rsa", wrongAzp), "n"},
+		{"Tampered", valid[:len(valid)-4] + "AAAA", "n"},
+		{"Algorithm mismatch", p.sign(t, "ES256", "rsa", p.claims("n")), "n"},
+		{"Unknown key", p.sign(t, "RS256", "foo", p.claims("n")), "n"},
+	}
+	for _, tt := range tests {
+		if _, err := c.Verify(ctx, tt.token, tt.nonce); !errors.Is(err, oidc.ErrInvalidIDToken) {
+			t.Fatalf("[%s] Expect error %+v, got %+v", tt.name, oidc.ErrInvalidIDToken, err)
+		}
+	}
+
+	multiAud["azp"] = "client"
+	if _, err := c.Verify(ctx, p.sign(t, "RS256", "rsa", multiAud), "n"); err != nil {
+		t.Fatal(err)
+	}
+
+	clk.Advance(2 * time.Hour)
+	if _, err := c.Verify(ctx, valid, "n"); !errors.Is(err, oidc.ErrInvalidIDToken) {
+		t.Fatalf("Expect error %+v, got %+v", oidc.ErrInvalidIDToken, err)
+	}
+
+	// The cached key set is kept if the refresh fails.
+	p.broken.Store(true)
+	if _, err := c.Verify(ctx, p.sign(t, "RS256", "rsa", p.claims("n")), "n"); err != nil {
+		t.Fatal(err)
+	}
+	c, p, _ = newClient(t)
+	p.broken.Store(true)
+	if _, err := c.Verify(ctx, p.sign(t, "RS256", "rsa", p.claims("n")), "n"); err == nil ||
+		errors.Is(err, oidc.ErrInvalidIDToken) {
+		t.Fatalf("Expect fetch error, got %+v", err)
+	}
+}
+
+func TestClient_flow(t *testing.T) {
+	t.Parallel()
+
+	c, p, _ := newClient(t)
+	ctx := context.Background()
+	app := c.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		claims, _ := oidc.ClaimsFromContext(r.Context())
+		_, _ = w.Write([]byte(claims.Subject))
+	}))
+
+	// Unauthenticated
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, httptest.NewRequestWithContext(ctx, http.MethodGet, "/foo?bar=1", nil))
+	if loc := w.Header().Get("Location"); w.Code != http.StatusFound || loc != "/auth/login?return_to=%2Ffoo%3Fbar%3D1" {
+		t.Fatalf("Unexpected response %d %s", w.Code, loc)
+	}
+	w = httptest.NewRecorder()
+	app.ServeHTTP(w, httptest.NewRequestWithContext(ctx, http.MethodPost, "/foo", nil))
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("Expect status %d, got %d", http.StatusUnauthorized, w.Code)
+	}
+
+	// Login
+	w = httptest.NewRecorder()
+	c.LoginHandler().ServeHTTP(w, httptest.NewRequestWithContext(ctx, http.MethodGet,
+		"/auth/login?return_to=/foo", nil))
+	authURL, err := url.Parse(w.Header().Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := authURL.Query()
+	if authURL.Path != "/authorize" || q.Get("client_id") != "client" || q.Get("scope") != "openid email" ||
+		q.Get("code_challenge_method") != "S256" {
+		t.Fatalf("Unexpected authorization URL %s", authURL)
+	}
+	p.codes["code"] = [2]string{q.Get("nonce"), q.Get("code_challenge")}
+	flowCookies := w.Result().Cookies() // nolint:bodyclose
+
+	// Callback with invalid state
+	r := httptest.NewRequestWithContext(ctx, http.MethodGet, "/auth/callback?code=code&state=foo", nil)
+	for _, cookie := range flowCookies {
+		r.AddCookie(cookie)
+	}
+	w = httptest.NewRecorder()
+	c.CallbackHandler().ServeHTTP(w, r)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("Expect status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+
+	// Callback
+	r = httptest.NewRequestWithContext(ctx, http.MethodGet, "/auth/callback?code=code&state="+q.Get("state"), nil)
+	for _, cookie := range flowCookies {
+		r.AddCookie(cookie)
+	}
+	w = httptest.NewRecorder()
+	c.CallbackHandler().ServeHTTP(w, r)
+	if loc := w.Header().Get("Location"); w.Code != http.StatusFound || loc != "/foo" {
+		t.Fatalf("Unexpected response %d %s: %s", w.Code, loc, w.Body)
+	}
+
+	// Authenticated
+	r = httptest.NewRequestWithContext(ctx, http.MethodGet, "/foo", nil)
+	for _, cookie := range w.Result().Cookies() { // nolint:bodyclose
+		if cookie.Name == "session" {
+			r.AddCookie(cookie)
+		}
+	}
+	w = httptest.NewRecorder()
+	app.ServeHTTP(w, r)
+	if w.Code != http.StatusOK || w.Body.String() != "alice" {
+		t.Fatalf("Unexpected response %d %s", w.Code, w.Body)
+	}
+
+	// Refresh
+	if token, err := c.Refresh(ctx, "refresh"); err != nil || token.AccessToken != "refreshed" {
+		t.Fatalf("Unexpected token %+v, err = %+v", token, err)
+	}
+	if _, err := c.Exchange(ctx, "unknown", "verifier"); !errors.Is(err, oidc.ErrTokenRequest) {
+		t.Fatalf("Expect error %+v, got %+v", oidc.ErrTokenRequest, err)
+	}
+}
@@ -0,0 +1,217 @@
+package oidc
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/sainnhe/go-common/pkg/constant"
+)
+
+// flowTTL is how long a login flow lasts before the user is redirected back to the callback.
+const flowTTL = 10 * time.Minute
+
+var errInvalidCookie = errors.New("invalid cookie")
+
+type contextKey struct{}
+
+// session is the payload of the session cookie.
+type session struct {
+	Claims     *Claims `json:"c"`
+	ExpireTime int64   `json:"e"`
+}
+
+// flow is the payload of the cookie that carries the state of a login flow.
+type flow struct {
+	State      string `json:"s"`
+	Nonce      string `json:"n"`
+	Verifier   string `json:"v"`
+	ReturnTo   string `json:"r"`
+	ExpireTime int64  `json:"e"`
+}
+
+// ClaimsFromContext returns the claims of the session established by [Client.Middleware].
+func ClaimsFromContext(ctx context.Context) (*Claims, bool) {
+	claims, ok := ctx.Value(contextKey{}).(*Claims)
+	return claims, ok
+}
+
+// LoginHandler returns the handler that starts a login flow by redirecting to the provider. The "return_to" query
+// parameter specifies the local path to redirect to after login.
+func (c *Client) LoginHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		f := &flow{
+			State:      randomString(),
+			Nonce:      randomString(),
+			Verifier:   randomString(),
+			ReturnTo:   localPath(r.URL.Query().Get("return_to")),
+			ExpireTime: c.clk.Now().Add(flowTTL).Unix(),
+		}
+		if err := c.setCookie(w, c.flowCookie(), f, flowTTL); err != nil {
+			c.l.ErrorContext(r.Context(), "Set flow cookie failed.", constant.LogAttrError, err)
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+		http.Redirect(w, r, c.AuthCodeURL(f.State, f.Nonce, f.Verifier), http.StatusFound)
+	})
+}
+
+// CallbackHandler returns the handler that completes a login flow and establishes a session. It should be served at
+// the redirect URL.
+func (c *Client) CallbackHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		logger := c.l.With(constant.LogAttrMethod, "Callback")
+		q := r.URL.Query()
+		if e := q.Get("error"); e != "" {
+			logger.WarnContext(ctx, "Authentication failed.", constant.LogAttrError, e,
+				"description", q.Get("error_description"))
+			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return
+		}
+		f := &flow{}
+		if err := c.readCookie(r, c.flowCookie(), f); err != nil || f.ExpireTime < c.clk.Now().Unix() ||
+			!hmac.Equal([]byte(f.State), []byte(q.Get("state"))) {
+			http.Error(w, "Invalid state.", http.StatusBadRequest)
+			return
+		}
+		c.clearCookie(w, c.flowCookie())
+
+		token, err := c.Exchange(ctx, q.Get("code"), f.Verifier)
+		if err != nil {
+			logger.WarnContext(ctx, "Exchange code failed.", constant.LogAttrError, err)
+			http.Error(w, http.StatusText(http.StatusBadGateway), http.StatusBadGateway)
+			return
+		}
+		claims, err := c.Verify(ctx, token.IDToken, f.Nonce)
+		if err != nil {
+			logger.WarnContext(ctx, "Verify ID token failed.", constant.LogAttrError, err)
+			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return
+		}
+		ttl := time.Duration(c.cfg.SessionTTLSec) * time.Second
+		s := &session{claims, c.clk.Now().Add(ttl).Unix()}
+		if err := c.setCookie(w, c.cfg.SessionCookie, s, ttl); err != nil {
+			logger.ErrorContext(ctx, "Set session cookie failed.", constant.LogAttrError, err)
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+		returnTo := f.ReturnTo
+		if returnTo == "" {
+			returnTo = "/"
+		}
+		http.Redirect(w, r, returnTo, http.StatusFound)
+	})
+}
+
+// LogoutHandler returns the handler that ends the session and redirects to "/". It doesn't log the user out of the
+// provider.
+func (c *Client) LogoutHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c.clearCookie(w, c.cfg.SessionCookie)
+		http.Redirect(w, r, "/", http.StatusFound)
+	})
+}
+
+// Middleware requires a session, and makes its claims available via [ClaimsFromContext]. Unauthenticated GET and HEAD
+// requests are redirected to the login path, and other requests are responded with 401 Unauthorized.
+func (c *Client) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s := &session{}
+		if err := c.readCookie(r, c.cfg.SessionCookie, s); err == nil && s.Claims != nil &&
+			s.ExpireTime > c.clk.Now().Unix() {
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), contextKey{}, s.Claims)))
+			return
+		}
+		if r.Method == http.MethodGet || r.Method == http.MethodHead {
+			http.Redirect(w, r, c.cfg.LoginPath+"?"+url.Values{"return_to": {r.URL.RequestURI()}}.Encode(),
+				http.StatusFound)
+			return
+		}
+		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+	})
+}
+
+func (c *Client) flowCookie() string {
+	return c.cfg.SessionCookie + "_flow"
+}
+
+// setCookie sets a cookie whose value is v encoded in JSON and signed with the session secret.
+func (c *Client) setCookie(w http.ResponseWriter, name string, v any, ttl time.Duration) error {
+	payload, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	http.SetCookie(w, &http.Cookie{
+		Name:     name,
+		Value:    encoded + "." + base64.RawURLEncoding.EncodeToString(c.sign(encoded)),
+		Path:     "/",
+		MaxAge:   int(ttl.Seconds()),
+		Secure:   !c.cfg.InsecureCookie,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+	return nil
+}
+
+// readCookie verifies the signature of a cookie set by setCookie, and decodes it into v.
+func (c *Client) readCookie(r *http.Request, name string, v any) error {
+	cookie, err := r.Cookie(name)
+	if err != nil {
+		return err
+	}
+	encoded, sig, ok := strings.Cut(cookie.Value, ".")
+	if !ok {
+		return errInvalidCookie
+	}
+	decodedSig, err := base64.RawURLEncoding.DecodeString(sig)
+	if err != nil || !hmac.Equal(decodedSig, c.sign(encoded)) {
+		return errInvalidCookie
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(payload, v)
+}
+
+func (c *Client) clearCookie(w http.ResponseWriter, name string) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     name,
+		Path:     "/",
+		MaxAge:   -1,
+		Secure:   !c.cfg.InsecureCookie,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+func (c *Client) sign(encoded string) []byte {
+	mac := hmac.New(sha256.New, []byte(c.cfg.SessionSecret))
+	mac.Write([]byte(encoded))
+	return mac.Sum(nil)
+}
+
+// randomString returns a random string with 256 bits of entropy.
+func randomString() string {
+	b := make([]byte, 32) // nolint:mnd
+	_, _ = rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// localPath returns p if it's a local path, or an empty string otherwise, to prevent open redirects.
+func localPath(p string) string {
+	if !strings.HasPrefix(p, "/") || strings.HasPrefix(p, "//") || strings.HasPrefix(p, "/\\") {
+		return ""
+	}
+	return p
+}
@@ -0,0 +1,180 @@
+package otel
+
+import (
+	"context"
+	"sync"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	otelmetric "go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/noop"
+	"go.opentelemetry.io/otel/sdk/log"
+	"go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"go.opentelemetry.io/otel/sdk/trace"
+)
+
+const (
+	// QueueFullPolicyDrop drops new telemetry when the batch queue is full.
+	QueueFullPolicyDrop = "drop"
+
+	// QueueFullPolicyBlock blocks the caller until there is room in the batch queue.
+	QueueFullPolicyBlock = "block"
+
+	// defaultQueueSize is the default queue size of batch processors in the SDK.
+	defaultQueueSize = 2048
+
+	signalLogs    = "logs"
+	signalSpans   = "spans"
+	signalMetrics = "metrics"
+
+	// signalKey is the attribute key of the signal of the dropped counter.
+	signalKey attribute.Key = "signal"
+)
+
+var (
+	droppedCounter     otelmetric.Int64Counter
+	droppedCounterOnce sync.Once
+)
+
+// getDroppedCounter returns the counter of dropped telemetry. It's created from the global meter provider, so that
+// the counts are exported once [New] sets the provider.
+func getDroppedCounter() otelmetric.Int64Counter {
+	droppedCounterOnce.Do(func() {
+		var err error
+		droppedCounter, err = otel.Meter("github.com/sainnhe/go-common/pkg/otel").Int64Counter("otel.sdk.dropped",
+			otelmetric.WithDescription("The number of telemetry items dropped because the batch queue was full or "+
+				"the export failed."),
+			otelmetric.WithUnit("{item}"))
+		if err != nil {
+			droppedCounter = noop.Int64Counter{}
+		}
+	})
+	return droppedCounter
+}
+
+// recordDropped adds n to the dropped counter of the given signal.
+func recordDropped(ctx context.Context, signal string, n int) {
+	if n <= 0 {
+		return
+	}
+	getDroppedCounter().Add(context.WithoutCancel(ctx), int64(n),
+		otelmetric.WithAttributes(signalKey.String(signal)))
+}
+
+// queueGate limits the number of items that are emitted but not yet exported to the size of the batch queue, so that
+// the behavior on a full queue can be controlled by the policy instead of the SDK.
+type queueGate struct {
+	sem    chan struct{}
+	block  bool
+	signal string
+}
+
+func newQueueGate(size int, policy, signal string) *queueGate {
+	if size <= 0 {
+		size = defaultQueueSize
+	}
+	return &queueGate{
+		sem:    make(chan struct{}, size),
+		block:  policy == QueueFullPolicyBlock,
+		signal: signal,
+	}
+}
+
+// acquire reserves a slot in the queue. It returns false and records a dropped item if there is no room, or if ctx is
+// done while blocking.
+func (g *queueGate) acquire(ctx context.Context) bool {
+	if g.block {
+		select {
+		case g.sem <- struct{}{}:
+			return true
+		case <-ctx.Done():
+		}
+	} else {
+		select {
+		case g.sem <- struct{}{}:
+			return true
+		default:
+		}
+	}
+	recordDropped(ctx, g.signal, 1)
+	return false
+}
+
+// release frees n slots after the items are exported. Failed exports are recorded as dropped.
+func (g *queueGate) release(ctx context.Context, n int, err error) {
+	for range n {
+		<-g.sem
+	}
+	if err != nil {
+		recordDropped(ctx, g.signal, n)
+	}
+}
+
+// gatedLogProcessor is a [log.Processor] that applies a [queueGate] before the records are queued.
+type gatedLogProcessor struct {
+	log.Processor
+	gate *queueGate
+}
+
+func (p *gatedLogProcessor) OnEmit(ctx context.Context, record *log.Record) error {
+	if !p.gate.acquire(ctx) {
+		return nil
+	}
+	return p.Processor.OnEmit(ctx, record)
+}
+
+// gatedLogExporter is a [log.Exporter] that releases the [queueGate] after the records are exported.
+type gatedLogExporter struct {
+	log.Exporter
+	gate *queueGate
+}
+
+func (e *gatedLogExporter) Export(ctx context.Context, records []log.Record) error {
+	err := e.Exporter.Export(ctx, records)
+	e.gate.release(ctx, len(records), err)
+	return err
+}
+
+// gatedSpanProcessor is a [trace.SpanProcessor] that applies a [queueGate] before the spans are queued.
+type gatedSpanProcessor struct {
+	trace.SpanProcessor
+	gate *queueGate
+}
+
+func (p *gatedSpanProcessor) OnEnd(s trace.ReadOnlySpan) {
+	// Unsampled spans are ignored by the batch processor, so they don't take slots.
+	if !s.SpanContext().IsSampled() || !p.gate.acquire(context.Background()) {
+		return
+	}
+	p.SpanProcessor.OnEnd(s)
+}
+
+// gatedSpanExporter is a [trace.SpanExporter] that releases the [queueGate] after the spans are exported.
+type gatedSpanExporter struct {
+	trace.SpanExporter
+	gate *queueGate
+}
+
+func (e *gatedSpanExporter) ExportSpans(ctx context.Context, spans []trace.ReadOnlySpan) error {
+	err := e.SpanExporter.ExportSpans(ctx, spans)
+	e.gate.release(ctx, len(spans), err)
+	return err
+}
+
+// countingMetricExporter is a [metric.Exporter] that records the metrics of failed exports as dropped.
+type countingMetricExporter struct {
+	metric.Exporter
+}
+
+func (e *countingMetricExporter) Export(ctx context.Context, rm *metricdata.ResourceMetrics) error {
+	err := e.Exporter.Export(ctx, rm)
+	if err != nil && rm != nil {
+		n := 0
+		for _, sm := range rm.ScopeMetrics {
+			n += len(sm.Metrics)
+		}
+		recordDropped(ctx, signalMetrics, n)
+	}
+	return err
+}
@@ -0,0 +1,99 @@
+package otel
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/sdk/log"
+)
+
+type countingLogProcessor struct {
+	log.Processor
+	emitted atomic.Int32
+}
+
+func (p *countingLogProcessor) OnEmit(_ context.Context, _ *log.Record) error {
+	p.emitted.Add(1)
+	return nil
+}
+
+type fakeLogExporter struct {
+	log.Exporter
+	err error
+}
+
+func (e *fakeLogExporter) Export(_ context.Context, _ []log.Record) error {
+	return e.err
+}
+
+func TestQueueGate_drop(t *testing.T) {
+	t.Parallel()
+
+	gate := newQueueGate(2, QueueFullPolicyDrop, signalLogs)
+	processor := &countingLogProcessor{}
+	gp := &gatedLogProcessor{processor, gate}
+	ge := &gatedLogExporter{&fakeLogExporter{}, gate}
+
+	ctx := context.Background()
+	for range 3 {
+		if err := gp.OnEmit(ctx, &log.Record{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := processor.emitted.Load(); n != 2 { // nolint:mnd
+		t.Fatalf("Expect 2 records to be emitted, got %d", n)
+	}
+
+	// Exporting frees the slots.
+	if err := ge.Export(ctx, make([]log.Record, 2)); err != nil {
+		t.Fatal(err)
+	}
+	if err := gp.OnEmit(ctx, &log.Record{}); err != nil {
+		t.Fatal(err)
+	}
+	if n := processor.emitted.Load(); n != 3 { // nolint:mnd
+		t.Fatalf("Expect 3 records to be emitted, got %d", n)
+	}
+
+	// Failed exports free the slots as well.
+	ge = &gatedLogExporter{&fakeLogExporter{err: errors.New("unavailable")}, gate}
+	if err := ge.Export(ctx, make([]log.Record, 1)); err == nil {
+		t.Fatal("Expect export error")
+	}
+	if len(gate.sem) != 0 {
+		t.Fatalf("Expect all slots to be freed, got %d in use", len(gate.sem))
+	}
+}
+
+func TestQueueGate_block(t *testing.T) {
+	t.Parallel()
+
+	gate := newQueueGate(1, QueueFullPolicyBlock, signalSpans)
+	if !gate.acquire(context.Background()) {
+		t.Fatal("Expect the first acquire to succeed")
+	}
+
+	// Blocking respects the context.
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if gate.acquire(ctx) {
+		t.Fatal("Expect acquire to fail after the context is done")
+	}
+
+	// Blocked callers continue once there is room.
+	acquired := make(chan bool)
+	go func() {
+		acquired <- gate.acquire(context.Background())
+	}()
+	gate.release(context.Background(), 1, nil)
+	if !<-acquired {
+		t.Fatal("Expect the blocked acquire to succeed")
+	}
+
+	if g := newQueueGate(0, "", signalLogs); cap(g.sem) != defaultQueueSize || g.block {
+		t.Fatalf("Expect default queue size and drop policy, got %d and block = %t", cap(g.sem), g.block)
+	}
+}
@@ -0,0 +1,361 @@
+package otel
+
+import (
+	"context"
+	"hash/fnv"
+	"strconv"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// defaultHashBuckets is the number of hash buckets used when [CardinalityConfig.HashBuckets] is not positive.
+const defaultHashBuckets = 64
+
+// NewGuardedMeterProvider wraps mp so that the attributes of all measurements are limited according to cfg. See
+// [CardinalityConfig] for more details. If cfg is nil or disabled, mp will be returned as is.
+//
+// [New] sets the guarded meter provider as the global meter provider if [MetricConfig.Cardinality] is enabled, so this
+// function is only needed for meter providers created elsewhere.
+func NewGuardedMeterProvider(mp metric.MeterProvider, cfg *CardinalityConfig) metric.MeterProvider {
+	if mp == nil || cfg == nil || !cfg.Enable {
+		return mp
+	}
+	g := &cardinalityGuard{
+		make(map[attribute.Key]struct{}, len(cfg.AllowedKeys)+1),
+		make(map[attribute.Key]struct{}, len(cfg.HashedKeys)),
+		uint64(cfg.HashBuckets),
+	}
+	if cfg.HashBuckets <= 0 {
+		g.buckets = defaultHashBuckets
+	}
+	g.allowed[signalKey] = struct{}{}
+	for _, k := range cfg.AllowedKeys {
+		g.allowed[attribute.Key(k)] = struct{}{}
+	}
+	for _, k := range cfg.HashedKeys {
+		g.hashed[attribute.Key(k)] = struct{}{}
+	}
+	return &guardedMeterProvider{mp, g}
+}
+
+// cardinalityGuard limits attributes.
+type cardinalityGuard struct {
+	allowed map[attribute.Key]struct{}
+	hashed  map[attribute.Key]struct{}
+	buckets uint64
+}
+
+// filter returns the limited attributes, and reports whether any attribute is changed.
+func (g *cardinalityGuard) filter(set attribute.Set) (attribute.Set, bool) {
+	changed := false
+	for iter := set.Iter(); iter.Next(); {
+		if _, ok := g.allowed[iter.Attribute().Key]; !ok {
+			changed = true
+			break
+		}
+	}
+	if !changed {
+		return set, false
+	}
+
+	kvs := make([]attribute.KeyValue, 0, set.Len())
+	for iter := set.Iter(); iter.Next(); {
+		kv := iter.Attribute()
+		if _, ok := g.allowed[kv.Key]; ok {
+			kvs = append(kvs, kv)
+		} else if _, ok := g.hashed[kv.Key]; ok {
+			h := fnv.New64a()
+			_, _ = h.Write([]byte(kv.Value.Emit()))
+			kvs = append(kvs, attribute.String(string(kv.Key), strconv.FormatUint(h.Sum64()%g.buckets, 10)))
+		}
+	}
+	return attribute.NewSet(kvs...), true
+}
+
+// guardOptions replaces the measurement options with the limited attributes if needed. The attributes are the only
+// configuration of measurement options, so nothing else is lost.
+func guardOptions[O any](g *cardinalityGuard, opts []O, attrs func([]O) attribute.Set) []O {
+	if len(opts) == 0 {
+		return opts
+	}
+	set, changed := g.filter(attrs(opts))
+	if !changed {
+		return opts
+	}
+	opt, _ := any(metric.WithAttributeSet(set)).(O)
+	return []O{opt}
+}
+
+func (g *cardinalityGuard) addOptions(opts []metric.AddOption) []metric.AddOption {
+	return guardOptions(g, opts, func(opts []metric.AddOption) attribute.Set {
+		return metric.NewAddConfig(opts).Attributes()
+	})
+}
+
+func (g *cardinalityGuard) recordOptions(opts []metric.RecordOption) []metric.RecordOption {
+	return guardOptions(g, opts, func(opts []metric.RecordOption) attribute.Set {
+		return metric.NewRecordConfig(opts).Attributes()
+	})
+}
+
+func (g *cardinalityGuard) observeOptions(opts []metric.ObserveOption) []metric.ObserveOption {
+	return guardOptions(g, opts, func(opts []metric.ObserveOption) attribute.Set {
+		return metric.NewObserveConfig(opts).Attributes()
+	})
+}
+
+func (g *cardinalityGuard) int64Callback(cb metric.Int64Callback) metric.Int64Callback {
+	return func(ctx context.Context, o metric.Int64Observer) error {
+		return cb(ctx, &guardedInt64Observer{o, g})
+	}
+}
+
+func (g *cardinalityGuard) float64Callback(cb metric.Float64Callback) metric.Float64Callback {
+	return func(ctx context.Context, o metric.Float64Observer) error {
+		return cb(ctx, &guardedFloat64Observer{o, g})
+	}
+}
+
+type guardedMeterProvider struct {
+	metric.MeterProvider
+	g *cardinalityGuard
+}
+
+func (p *guardedMeterProvider) Meter(name string, opts ...metric.MeterOption) metric.Meter {
+	return &guardedMeter{p.MeterProvider.Meter(name, opts...), p.g}
+}
+
+// guardedMeter wraps synchronous instruments, and the callbacks of asynchronous instruments.
+type guardedMeter struct {
+	metric.Meter
+	g *cardinalityGuard
+}
+
+func (m *guardedMeter) Int64Counter(name string, options ...metric.Int64CounterOption) (metric.Int64Counter, error) {
+	i, err := m.Meter.Int64Counter(name, options...)
+	return &guardedInt64Counter{i, m.g}, err
+}
+
+func (m *guardedMeter) Int64UpDownCounter(name string, options ...metric.Int64UpDownCounterOption) (
+	metric.Int64UpDownCounter, error) {
+	i, err := m.Meter.Int64UpDownCounter(name, options...)
+	return &guardedInt64UpDownCounter{i, m.g}, err
+}
+
+func (m *guardedMeter) Int64Histogram(name string, options ...metric.Int64HistogramOption) (
+	metric.Int64Histogram, error) {
+	i, err := m.Meter.Int64Histogram(name, options...)
+	return &guardedInt64Histogram{i, m.g}, err
+}
+
+func (m *guardedMeter) Int64Gauge(name string, options ...metric.Int64GaugeOption) (metric.Int64Gauge, error) {
+	i, err := m.Meter.Int64Gauge(name, options...)
+	return &guardedInt64Gauge{i, m.g}, err
+}
+
+func (m *guardedMeter) Float64Counter(name string, options ...metric.Float64CounterOption) (
+	metric.Float64Counter, error) {
+	i, err := m.Meter.Float64Counter(name, options...)
+	return &guardedFloat64Counter{i, m.g}, err
+}
+
+func (m *guardedMeter) Float64UpDownCounter(name string, options ...metric.Float64UpDownCounterOption) (
+	metric.Float64UpDownCounter, error) {
+	i, err := m.Meter.Float64UpDownCounter(name, options...)
+	return &guardedFloat64UpDownCounter{i, m.g}, err
+}
+
+func (m *guardedMeter) Float64Histogram(name string, options ...metric.Float64HistogramOption) (
+	metric.Float64Histogram, error) {
+	i, err := m.Meter.Float64Histogram(name, options...)
+	return &guardedFloat64Histogram{i, m.g}, err
+}
+
+func (m *guardedMeter) Float64Gauge(name string, options ...metric.Float64GaugeOption) (metric.Float64Gauge, error) {
+	i, err := m.Meter.Float64Gauge(name, options...)
+	return &guardedFloat64Gauge{i, m.g}, err
+}
+
+func (m *guardedMeter) Int64ObservableCounter(name string, options ...metric.Int64ObservableCounterOption) (
+	metric.Int64ObservableCounter, error) {
+	cfg := metric.NewInt64ObservableCounterConfig(options...)
+	opts := []metric.Int64ObservableCounterOption{metric.WithDescription(cfg.Description()), metric.WithUnit(cfg.Unit())}
+	for _, cb := range cfg.Callbacks() {
+		opts = append(opts, metric.WithInt64Callback(m.g.int64Callback(cb)))
+	}
+	return m.Meter.Int64ObservableCounter(name, opts...)
+}
+
+func (m *guardedMeter) Int64ObservableUpDownCounter(name string,
+	options ...metric.Int64ObservableUpDownCounterOption) (metric.Int64ObservableUpDownCounter, error) {
+	cfg := metric.NewInt64ObservableUpDownCounterConfig(options...)
+	opts := []metric.Int64ObservableUpDownCounterOption{
+		metric.WithDescription(cfg.Description()),
+		metric.WithUnit(cfg.Unit()),
+	}
+	for _, cb := range cfg.Callbacks() {
+		opts = append(opts, metric.WithInt64Callback(m.g.int64Callback(cb)))
+	}
+	return m.Meter.Int64ObservableUpDownCounter(name, opts...)
+}
+
+func (m *guardedMeter) Int64ObservableGauge(name string, options ...metric.Int64ObservableGaugeOption) (
+	metric.Int64ObservableGauge, error) {
+	cfg := metric.NewInt64ObservableGaugeConfig(options...)
+	opts := []metric.Int64ObservableGaugeOption{metric.WithDescription(cfg.Description()), metric.WithUnit(cfg.Unit())}
+	for _, cb := range cfg.Callbacks() {
+		opts = append(opts, metric.WithInt64Callback(m.g.int64Callback(cb)))
+	}
+	return m.Meter.Int64ObservableGauge(name, opts...)
+}
+
+func (m *guardedMeter) Float64ObservableCounter(name string, options ...metric.Float64ObservableCounterOption) (
+	metric.Float64ObservableCounter, error) {
+	cfg := metric.NewFloat64ObservableCounterConfig(options...)
+	opts := []metric.Float64ObservableCounterOption{
+		metric.WithDescription(cfg.Description()),
+		metric.WithUnit(cfg.Unit()),
+	}
+	for _, cb := range cfg.Callbacks() {
+		opts = append(opts, metric.WithFloat64Callback(m.g.float64Callback(cb)))
+	}
+	return m.Meter.Float64ObservableCounter(name, opts...)
+}
+
+func (m *guardedMeter) Float64ObservableUpDownCounter(name string,
+	options ...metric.Float64ObservableUpDownCounterOption) (metric.Float64ObservableUpDownCounter, error) {
+	cfg := metric.NewFloat64ObservableUpDownCounterConfig(options...)
+	opts := []metric.Float64ObservableUpDownCounterOption{
+		metric.WithDescription(cfg.Description()),
+		metric.WithUnit(cfg.Unit()),
+	}
+	for _, cb := range cfg.Callbacks() {
+		opts = append(opts, metric.WithFloat64Callback(m.g.float64Callback(cb)))
+	}
+	return m.Meter.Float64ObservableUpDownCounter(name, opts...)
+}
+
+func (m *guardedMeter) Float64ObservableGauge(name string, options ...metric.Float64ObservableGaugeOption) (
+	metric.Float64ObservableGauge, error) {
+	cfg := metric.NewFloat64ObservableGaugeConfig(options...)
+	opts := []metric.Float64ObservableGaugeOption{
+		metric.WithDescription(cfg.Description()),
+		metric.WithUnit(cfg.Unit()),
+	}
+	for _, cb := range cfg.Callbacks() {
+		opts = append(opts, metric.WithFloat64Callback(m.g.float64Callback(cb)))
+	}
+	return m.Meter.Float64ObservableGauge(name, opts...)
+}
+
+func (m *guardedMeter) RegisterCallback(f metric.Callback, instruments ...metric.Observable) (
+	metric.Registration, error) {
+	return m.Meter.RegisterCallback(func(ctx context.Context, o metric.Observer) error {
+		return f(ctx, &guardedObserver{o, m.g})
+	}, instruments...)
+}
+
+type guardedInt64Counter struct {
+	metric.Int64Counter
+	g *cardinalityGuard
+}
+
+func (i *guardedInt64Counter) Add(ctx context.Context, incr int64, options ...metric.AddOption) {
+	i.Int64Counter.Add(ctx, incr, i.g.addOptions(options)...)
+}
+
+type guardedInt64UpDownCounter struct {
+	metric.Int64UpDownCounter
+	g *cardinalityGuard
+}
+
+func (i *guardedInt64UpDownCounter) Add(ctx context.Context, incr int64, options ...metric.AddOption) {
+	i.Int64UpDownCounter.Add(ctx, incr, i.g.addOptions(options)...)
+}
+
+type guardedInt64Histogram struct {
+	metric.Int64Histogram
+	g *cardinalityGuard
+}
+
+func (i *guardedInt64Histogram) Record(ctx context.Context, incr int64, options ...metric.RecordOption) {
+	i.Int64Histogram.Record(ctx, incr, i.g.recordOptions(options)...)
+}
+
+type guardedInt64Gauge struct {
+	metric.Int64Gauge
+	g *cardinalityGuard
+}
+
+func (i *guardedInt64Gauge) Record(ctx context.Context, value int64, options ...metric.RecordOption) {
+	i.Int64Gauge.Record(ctx, value, i.g.recordOptions(options)...)
+}
+
+type guardedFloat64Counter struct {
+	metric.Float64Counter
+	g *cardinalityGuard
+}
+
+func (i *guardedFloat64Counter) Add(ctx context.Context, incr float64, options ...metric.AddOption) {
+	i.Float64Counter.Add(ctx, incr, i.g.addOptions(options)...)
+}
+
+type guardedFloat64UpDownCounter struct {
+	metric.Float64UpDownCounter
+	g *cardinalityGuard
+}
+
+func (i *guardedFloat64UpDownCounter) Add(ctx context.Context, incr float64, options ...metric.AddOption) {
+	i.Float64UpDownCounter.Add(ctx, incr, i.g.addOptions(options)...)
+}
+
+type guardedFloat64Histogram struct {
+	metric.Float64Histogram
+	g *cardinalityGuard
+}
+
+func (i *guardedFloat64Histogram) Record(ctx context.Context, incr float64, options ...metric.RecordOption) {
+	i.Float64Histogram.Record(ctx, incr, i.g.recordOptions(options)...)
+}
+
+type guardedFloat64Gauge struct {
+	metric.Float64Gauge
+	g *cardinalityGuard
+}
+
+func (i *guardedFloat64Gauge) Record(ctx context.Context, value float64, options ...metric.RecordOption) {
+	i.Float64Gauge.Record(ctx, value, i.g.recordOptions(options)...)
+}
+
+type guardedInt64Observer struct {
+	metric.Int64Observer
+	g *cardinalityGuard
+}
+
+func (o *guardedInt64Observer) Observe(value int64, options ...metric.ObserveOption) {
+	o.Int64Observer.Observe(value, o.g.observeOptions(options)...)
+}
+
+type guardedFloat64Observer struct {
+	metric.Float64Observer
+	g *cardinalityGuard
+}
+
+func (o *guardedFloat64Observer) Observe(value float64, options ...metric.ObserveOption) {
+	o.Float64Observer.Observe(value, o.g.observeOptions(options)...)
+}
+
+type guardedObserver struct {
+	metric.Observer
+	g *cardinalityGuard
+}
+
+func (o *guardedObserver) ObserveInt64(obsrv metric.Int64Observable, value int64, opts ...metric.ObserveOption) {
+	o.Observer.ObserveInt64(obsrv, value, o.g.observeOptions(opts)...)
+}
+
+func (o *guardedObserver) ObserveFloat64(obsrv metric.Float64Observable, value float64,
+	opts ...metric.ObserveOption) {
+	o.Observer.ObserveFloat64(obsrv, value, o.g.observeOptions(opts)...)
+}
@@ -0,0 +1,89 @@
+package otel_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/sainnhe/go-common/pkg/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+func TestNewGuardedMeterProvider(t *testing.T) {
+	t.Parallel()
+
+	reader := sdkmetric.NewManualReader()
+	mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+	if p := otel.NewGuardedMeterProvider(mp, &otel.CardinalityConfig{Enable: false}); p != mp {
+		t.Fatal("Expect the meter provider to be returned as is when disabled")
+	}
+	meter := otel.NewGuardedMeterProvider(mp, &otel.CardinalityConfig{
+		Enable:      true,
+		AllowedKeys: []string{"method"},
+		HashedKeys:  []string{"user_id"},
+		HashBuckets: 4, // nolint:mnd
+	}).Meter("test")
+
+	ctx := context.Background()
+	counter, err := meter.Int64Counter("requests")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, user := range []string{"1", "2", "3", "4", "5", "6", "7", "8", "9"} {
+		counter.Add(ctx, 1, metric.WithAttributes(
+			attribute.String("method", "GET"),
+			attribute.String("user_id", user),
+			attribute.String("url", "/users/"+user),
+		))
+	}
+	histogram, err := meter.Float64Histogram("latency")
+	if err != nil {
+		t.Fatal(err)
+	}
+	histogram.Record(ctx, 1, metric.WithAttributes(attribute.String("url", "/"), attribute.String("signal", "spans")))
+	_, err = meter.Int64ObservableGauge("connections", metric.WithInt64Callback(
+		func(_ context.Context, o metric.Int64Observer) error {
+			o.Observe(1, metric.WithAttributes(attribute.String("method", "GET"), attribute.String("peer", "x")))
+			return nil
+		}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rm := metricdata.ResourceMetrics{}
+	if err := reader.Collect(ctx, &rm); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(rm.ScopeMetrics[0].Metrics); n != 3 { // nolint:mnd
+		t.Fatalf("Expect 3 metrics, got %d", n)
+	}
+	for _, m := range rm.ScopeMetrics[0].Metrics {
+		switch data := m.Data.(type) {
+		case metricdata.Sum[int64]:
+			// 9 users are hashed into at most 4 buckets, and the URLs are dropped.
+			if len(data.DataPoints) == 0 || len(data.DataPoints) > 4 {
+				t.Fatalf("Expect 1 to 4 data points, got %d", len(data.DataPoints))
+			}
+			for _, dp := range data.DataPoints {
+				if _, ok := dp.Attributes.Value("url"); ok || dp.Attributes.Len() != 2 { // nolint:mnd
+					t.Fatalf("Unexpected attributes %v", dp.Attributes.Encoded(attribute.DefaultEncoder()))
+				}
+			}
+		case metricdata.Histogram[float64]:
+			// The signal is always allowed.
+			expected := attribute.NewSet(attribute.String("signal", "spans"))
+			if len(data.DataPoints) != 1 || !data.DataPoints[0].Attributes.Equals(&expected) {
+				t.Fatalf("Expect 1 data point with signal only, got %+v", data.DataPoints)
+			}
+		case metricdata.Gauge[int64]:
+			expected := attribute.NewSet(attribute.String("method", "GET"))
+			if len(data.DataPoints) != 1 || !data.DataPoints[0].Attributes.Equals(&expected) {
+				t.Fatalf("Expect 1 data point with method only, got %+v", data.DataPoints)
+			}
+		default:
+			t.Fatalf("Unexpected metric %s", m.Name)
+		}
+	}
+}
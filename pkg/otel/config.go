@@ -12,11 +12,19 @@ type Config struct {
 	EnableGzip bool `json:"enable_gzip" yaml:"enable_gzip" toml:"enable_gzip" xml:"enable_gzip" env:"OTEL_ENABLE_GZIP" default:"true"` // nolint:lll
 
 	// Headers specifies additional headers appended in each requests.
-	Headers map[string]string `json:"headers" yaml:"headers" toml:"headers" xml:"headers" env:"OTEL_HEADERS" default:"{}"`
+	// It's marked as secret since it usually contains authentication tokens.
+	Headers map[string]string `json:"headers" yaml:"headers" toml:"headers" xml:"headers" env:"OTEL_HEADERS" default:"{}" secret:"true"` // nolint:lll
 
 	// Attributes specifies the resource attributes.
 	Attributes map[string]string `json:"attributes" yaml:"attributes" toml:"attributes" xml:"attributes" env:"OTEL_ATTRIBUTES" default:"{}"` // nolint:lll
 
+	// RegisterCleanup specifies whether to register the cleanup function returned by [New] as a cleanup hook of the
+	// graceful package, so that telemetry data is flushed after the application has been shut down.
+	RegisterCleanup bool `json:"register_cleanup" yaml:"register_cleanup" toml:"register_cleanup" xml:"register_cleanup" env:"OTEL_REGISTER_CLEANUP" default:"true"` // nolint:lll
+
+	// Service is the service config used as resource attributes.
+	Service ServiceConfig `json:"service" yaml:"service" toml:"service" xml:"service"`
+
 	// Conn is the gRPC connection config.
 	Conn ConnConfig `json:"conn" yaml:"conn" toml:"conn" xml:"conn"`
 
@@ -31,6 +39,27 @@ type Config struct {
 
 	// Log is the log config.
 	Log LogConfig `json:"log" yaml:"log" toml:"log" xml:"log"`
+
+	// Profiling is the continuous profiling config.
+	Profiling ProfilingConfig `json:"profiling" yaml:"profiling" toml:"profiling" xml:"profiling"`
+}
+
+// ServiceConfig defines the config model for service resource attributes. Empty values are detected automatically, and
+// the standard OTEL_SERVICE_NAME and OTEL_RESOURCE_ATTRIBUTES environment variables take precedence over this config.
+type ServiceConfig struct {
+	// Name is the "service.name" attribute. Defaults to the last element of the main package path, or the executable
+	// name if the build info is unavailable.
+	Name string `json:"name" yaml:"name" toml:"name" xml:"name" env:"OTEL_SERVICE_NAME"`
+
+	// Namespace is the "service.namespace" attribute. It's omitted if empty.
+	Namespace string `json:"namespace" yaml:"namespace" toml:"namespace" xml:"namespace" env:"OTEL_SERVICE_NAMESPACE"`
+
+	// Version is the "service.version" attribute. Defaults to the main module version, or the VCS revision if the
+	// binary is built from a local checkout.
+	Version string `json:"version" yaml:"version" toml:"version" xml:"version" env:"OTEL_SERVICE_VERSION"`
+
+	// InstanceID is the "service.instance.id" attribute. Defaults to the host name.
+	InstanceID string `json:"instance_id" yaml:"instance_id" toml:"instance_id" xml:"instance_id" env:"OTEL_SERVICE_INSTANCE_ID"` // nolint:lll
 }
 
 // ConnConfig defines the config model for gRPC connection.
@@ -57,6 +86,11 @@ type BatchConfig struct {
 	// Processor will forcefully sends available data if this delay is reached, even if the current batch size does not
 	// reach BatchSize.
 	MaxDelayMs int `json:"max_delay_ms" yaml:"max_delay_ms" toml:"max_delay_ms" xml:"max_delay_ms" env:"OTEL_BATCH_MAX_DELAY_MS" default:"3000"` // nolint:lll
+
+	// QueueFullPolicy specifies the behavior when the waiting queue of logs or spans is full.
+	// Possible values are: "drop", which drops new items, or "block", which blocks the caller until there is room.
+	// Dropped items are counted by the "otel.sdk.dropped" metric.
+	QueueFullPolicy string `json:"queue_full_policy" yaml:"queue_full_policy" toml:"queue_full_policy" xml:"queue_full_policy" env:"OTEL_BATCH_QUEUE_FULL_POLICY" default:"drop"` // nolint:lll
 }
 
 // TraceConfig defines the config model for traces.
@@ -81,6 +115,29 @@ type MetricConfig struct {
 
 	// ReaderIntervalMs specifies the collecting interval of a periodic reader in milliseconds.
 	ReaderIntervalMs int `json:"reader_interval_ms" yaml:"reader_interval_ms" toml:"reader_interval_ms" xml:"reader_interval_ms" env:"OTEL_METRIC_READER_INTERVAL_MS" default:"60000"` // nolint:lll
+
+	// Cardinality is the cardinality guard config.
+	Cardinality CardinalityConfig `json:"cardinality" yaml:"cardinality" toml:"cardinality" xml:"cardinality"`
+}
+
+// CardinalityConfig defines the config model for metric cardinality guards, which limit the attributes recorded by
+// application code to protect collectors from cardinality explosions.
+//
+// When enabled, attributes whose keys are in AllowedKeys are kept as is, attributes whose keys are in HashedKeys are
+// replaced with hash buckets, and all other attributes are dropped. The "signal" attribute of the "otel.sdk.dropped"
+// counter is always kept, so that drops of different signals can be told apart.
+type CardinalityConfig struct {
+	// Enable specifies whether to enable cardinality guards.
+	Enable bool `json:"enable" yaml:"enable" toml:"enable" xml:"enable" env:"OTEL_METRIC_CARDINALITY_ENABLE" default:"false"` // nolint:lll
+
+	// AllowedKeys specifies the attribute keys that are kept as is.
+	AllowedKeys []string `json:"allowed_keys" yaml:"allowed_keys" toml:"allowed_keys" xml:"allowed_keys" env:"OTEL_METRIC_CARDINALITY_ALLOWED_KEYS" default:"[]"` // nolint:lll
+
+	// HashedKeys specifies the attribute keys whose values are replaced with the index of a hash bucket, e.g. user IDs.
+	HashedKeys []string `json:"hashed_keys" yaml:"hashed_keys" toml:"hashed_keys" xml:"hashed_keys" env:"OTEL_METRIC_CARDINALITY_HASHED_KEYS" default:"[]"` // nolint:lll
+
+	// HashBuckets specifies the number of hash buckets, which is the max number of distinct values of each hashed key.
+	HashBuckets int `json:"hash_buckets" yaml:"hash_buckets" toml:"hash_buckets" xml:"hash_buckets" env:"OTEL_METRIC_CARDINALITY_HASH_BUCKETS" default:"64"` // nolint:lll
 }
 
 // LogConfig defines the config model for logs.
@@ -88,3 +145,23 @@ type LogConfig struct {
 	// Path is the path of the log endpoint.
 	Path string `json:"path" yaml:"path" toml:"path" xml:"path" env:"OTEL_LOG_PATH" default:"/v1/logs"`
 }
+
+// ProfilingConfig defines the config model for continuous profiling, which pushes pprof profiles to a [Pyroscope]
+// compatible server.
+//
+// [Pyroscope]: https://grafana.com/oss/pyroscope/
+type ProfilingConfig struct {
+	// Enable specifies whether to enable continuous profiling.
+	Enable bool `json:"enable" yaml:"enable" toml:"enable" xml:"enable" env:"OTEL_PROFILING_ENABLE" default:"false"`
+
+	// Endpoint is the base URL of the profiling server. Profiles are pushed to its "/ingest" API.
+	Endpoint string `json:"endpoint" yaml:"endpoint" toml:"endpoint" xml:"endpoint" env:"OTEL_PROFILING_ENDPOINT" default:"http://localhost:4040"` // nolint:lll
+
+	// IntervalMs specifies the duration of each CPU profile, as well as the interval of pushing profiles in
+	// milliseconds.
+	IntervalMs int `json:"interval_ms" yaml:"interval_ms" toml:"interval_ms" xml:"interval_ms" env:"OTEL_PROFILING_INTERVAL_MS" default:"15000"` // nolint:lll
+
+	// Headers specifies additional headers appended in each push request.
+	// It's marked as secret since it usually contains authentication tokens.
+	Headers map[string]string `json:"headers" yaml:"headers" toml:"headers" xml:"headers" env:"OTEL_PROFILING_HEADERS" default:"{}" secret:"true"` // nolint:lll
+}
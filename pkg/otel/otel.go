@@ -9,9 +9,12 @@ import (
 	"crypto/x509"
 	"errors"
 	"fmt"
+	"runtime/debug"
+	"sync"
 	"time"
 
 	"github.com/sainnhe/go-common/pkg/constant"
+	"github.com/sainnhe/go-common/pkg/graceful"
 	clog "github.com/sainnhe/go-common/pkg/log"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
@@ -37,7 +40,14 @@ var ErrInvalidConfig = errors.New("invalid config")
 // [log.LoggerProvider], and sets them as the global propagator and providers.
 //
 // NOTE: The returned cleanup function will handle shutdown correctly, so you don't need to manually call the shutdown
-// functions of returned providers.
+// functions of returned providers. If [Config.RegisterCleanup] is true, the cleanup function is also registered via
+// [graceful.RegisterCleanupHook], so that it runs after the application hooks have drained, and calling it manually is
+// no longer needed. The cleanup function only takes effect once, so it's safe to call it multiple times.
+//
+// If [MetricConfig.Cardinality] is enabled, the global meter provider limits attributes as described in
+// [CardinalityConfig], while the returned meter provider doesn't.
+//
+// If [Config.Profiling] is enabled, CPU and heap profiles are pushed periodically as well until cleanup.
 func New(cfg *Config) (propagator propagation.TextMapPropagator, tracerProvider *trace.TracerProvider,
 	meterProvider *metric.MeterProvider, loggerProvider *log.LoggerProvider, cleanup func(), err error) {
 	// Check argument
@@ -59,6 +69,14 @@ func New(cfg *Config) (propagator propagation.TextMapPropagator, tracerProvider
 		return
 	}
 
+	// Check queue full policy
+	switch cfg.Batch.QueueFullPolicy {
+	case QueueFullPolicyDrop, QueueFullPolicyBlock, "":
+	default:
+		err = ErrInvalidConfig
+		return
+	}
+
 	// Base endpoint URL
 	baseEndpointURL := ""
 	if cfg.Conn.EnableTLS {
@@ -94,6 +112,7 @@ func New(cfg *Config) (propagator propagation.TextMapPropagator, tracerProvider
 		})
 	}
 	res, err := resource.New(ctx,
+		resource.WithAttributes(serviceAttributes(&cfg.Service)...),
 		resource.WithAttributes(attrs...),
 		resource.WithContainer(),
 		resource.WithFromEnv(),
@@ -136,32 +155,60 @@ func New(cfg *Config) (propagator propagation.TextMapPropagator, tracerProvider
 		return
 	}
 
+	// Profiler
+	var prof *profiler
+	if cfg.Profiling.Enable {
+		info, _ := debug.ReadBuildInfo()
+		prof, err = newProfiler(&cfg.Profiling, serviceName(&cfg.Service, info), timeout)
+		if err != nil {
+			return
+		}
+		prof.start()
+	}
+
 	// Set as global propagator and providers.
 	otel.SetTextMapPropagator(propagator)
 	otel.SetTracerProvider(tracerProvider)
-	otel.SetMeterProvider(meterProvider)
+	otel.SetMeterProvider(NewGuardedMeterProvider(meterProvider, &cfg.Metric.Cardinality))
 	global.SetLoggerProvider(loggerProvider)
 
 	// Cleanup
+	var cleanupOnce sync.Once
 	cleanup = func() {
-		ctx, cancel := context.WithTimeout(context.Background(), timeout)
-		defer cancel()
-		if err := errors.Join(
-			tracerProvider.ForceFlush(ctx),
-			tracerProvider.Shutdown(ctx),
-			meterProvider.ForceFlush(ctx),
-			meterProvider.Shutdown(ctx),
-			loggerProvider.ForceFlush(ctx),
-			loggerProvider.Shutdown(ctx),
-		); err != nil {
-			clog.NewLogger("github.com/sainnhe/go-common/pkg/otel").ErrorContext(
-				ctx, "Cleanup error.", constant.LogAttrError, err)
-		}
+		cleanupOnce.Do(func() {
+			shutdown(tracerProvider, meterProvider, loggerProvider, prof, timeout)
+		})
+	}
+	if cfg.RegisterCleanup {
+		graceful.RegisterCleanupHook(cleanup)
 	}
 
 	return
 }
 
+// shutdown flushes and shuts down the given providers and profiler, where prof can be nil.
+func shutdown(tracerProvider *trace.TracerProvider, meterProvider *metric.MeterProvider,
+	loggerProvider *log.LoggerProvider, prof *profiler, timeout time.Duration) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	var profErr error
+	if prof != nil {
+		profErr = prof.shutdown(ctx)
+	}
+	if err := errors.Join(
+		profErr,
+		tracerProvider.ForceFlush(ctx),
+		tracerProvider.Shutdown(ctx),
+		meterProvider.ForceFlush(ctx),
+		meterProvider.Shutdown(ctx),
+		loggerProvider.ForceFlush(ctx),
+		loggerProvider.Shutdown(ctx),
+	); err != nil {
+		clog.NewLogger("github.com/sainnhe/go-common/pkg/otel").ErrorContext(
+			ctx, "Cleanup error.", constant.LogAttrError, err)
+	}
+}
+
 func initTracerProvider(
 	ctx context.Context, cfg *Config, endpointURL string, timeout time.Duration, creds credentials.TransportCredentials,
 	res *resource.Resource) (provider *trace.TracerProvider, err error) {
@@ -194,12 +241,14 @@ func initTracerProvider(
 		providerOpts = append(providerOpts, trace.WithSampler(trace.AlwaysSample()))
 	}
 	if cfg.Batch.MaxSize > 0 {
-		providerOpts = append(providerOpts, trace.WithSpanProcessor(trace.NewBatchSpanProcessor(exporter,
+		gate := newQueueGate(cfg.Batch.QueueSize, cfg.Batch.QueueFullPolicy, signalSpans)
+		processor := trace.NewBatchSpanProcessor(&gatedSpanExporter{exporter, gate},
 			trace.WithMaxExportBatchSize(cfg.Batch.MaxSize),
 			trace.WithMaxQueueSize(cfg.Batch.QueueSize),
 			trace.WithBatchTimeout(time.Duration(cfg.Batch.MaxDelayMs)*time.Millisecond),
 			trace.WithExportTimeout(timeout),
-		)))
+		)
+		providerOpts = append(providerOpts, trace.WithSpanProcessor(&gatedSpanProcessor{processor, gate}))
 	} else {
 		providerOpts = append(providerOpts, trace.WithSpanProcessor(trace.NewSimpleSpanProcessor(exporter)))
 	}
@@ -254,7 +303,8 @@ func initMeterProvider(
 		metric.WithResource(res),
 		metric.WithReader(
 			metric.NewPeriodicReader(
-				exporter, metric.WithInterval(time.Duration(cfg.Metric.ReaderIntervalMs)*time.Millisecond),
+				&countingMetricExporter{exporter},
+				metric.WithInterval(time.Duration(cfg.Metric.ReaderIntervalMs)*time.Millisecond),
 			),
 		),
 	}
@@ -292,12 +342,14 @@ func initLoggerProvider(
 		log.WithResource(res),
 	}
 	if cfg.Batch.MaxSize > 0 {
-		providerOpts = append(providerOpts, log.WithProcessor(log.NewBatchProcessor(exporter,
+		gate := newQueueGate(cfg.Batch.QueueSize, cfg.Batch.QueueFullPolicy, signalLogs)
+		processor := log.NewBatchProcessor(&gatedLogExporter{exporter, gate},
 			log.WithExportMaxBatchSize(cfg.Batch.MaxSize),
 			log.WithMaxQueueSize(cfg.Batch.QueueSize),
 			log.WithExportInterval(time.Duration(cfg.Batch.MaxDelayMs)*time.Millisecond),
 			log.WithExportTimeout(timeout),
-		)))
+		)
+		providerOpts = append(providerOpts, log.WithProcessor(&gatedLogProcessor{processor, gate}))
 	} else {
 		providerOpts = append(providerOpts, log.WithProcessor(log.NewSimpleProcessor(exporter)))
 	}
@@ -124,6 +124,43 @@ func TestNew(t *testing.T) {
 			},
 			false,
 		},
+		{
+			"Block on full queue",
+			func() *otel.Config {
+				cfg, err := encoding.LoadConfig[otel.Config](nil, encoding.TypeNil)
+				if err != nil {
+					t.Fatal(err.Error())
+				}
+				cfg.Batch.QueueFullPolicy = otel.QueueFullPolicyBlock
+				return cfg
+			},
+			false,
+		},
+		{
+			"Invalid queue full policy",
+			func() *otel.Config {
+				cfg, err := encoding.LoadConfig[otel.Config](nil, encoding.TypeNil)
+				if err != nil {
+					t.Fatal(err.Error())
+				}
+				cfg.Batch.QueueFullPolicy = "nil"
+				return cfg
+			},
+			true,
+		},
+		{
+			"Invalid profiling config",
+			func() *otel.Config {
+				cfg, err := encoding.LoadConfig[otel.Config](nil, encoding.TypeNil)
+				if err != nil {
+					t.Fatal(err.Error())
+				}
+				cfg.Profiling.Enable = true
+				cfg.Profiling.IntervalMs = 0
+				return cfg
+			},
+			true,
+		},
 		{
 			"Invalid metric temporality",
 			func() *otel.Config {
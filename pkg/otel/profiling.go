@@ -0,0 +1,158 @@
+package otel
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"runtime/pprof"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sainnhe/go-common/pkg/constant"
+	clog "github.com/sainnhe/go-common/pkg/log"
+)
+
+// profiler collects CPU and heap profiles periodically and pushes them to a Pyroscope compatible server.
+type profiler struct {
+	client   *http.Client
+	url      string
+	name     string
+	headers  map[string]string
+	interval time.Duration
+	logger   *slog.Logger
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+// newProfiler initializes a new profiler, where name is the application name reported to the server.
+func newProfiler(cfg *ProfilingConfig, name string, timeout time.Duration) (*profiler, error) {
+	if len(cfg.Endpoint) == 0 || cfg.IntervalMs <= 0 {
+		return nil, ErrInvalidConfig
+	}
+	if _, err := url.Parse(cfg.Endpoint); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidConfig, err)
+	}
+	return &profiler{
+		&http.Client{Timeout: timeout},
+		strings.TrimSuffix(cfg.Endpoint, "/") + "/ingest",
+		name,
+		cfg.Headers,
+		time.Duration(cfg.IntervalMs) * time.Millisecond,
+		clog.NewLogger("github.com/sainnhe/go-common/pkg/otel"),
+		make(chan struct{}),
+		make(chan struct{}),
+	}, nil
+}
+
+// start starts profiling in background.
+func (p *profiler) start() {
+	go p.run()
+}
+
+// shutdown stops profiling, and waits until the last profiles are pushed or ctx is done.
+func (p *profiler) shutdown(ctx context.Context) error {
+	close(p.stop)
+	select {
+	case <-p.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (p *profiler) run() {
+	defer close(p.done)
+	for {
+		stopped := p.collect()
+		if stopped {
+			return
+		}
+	}
+}
+
+// collect profiles the CPU for an interval, or until the profiler is stopped, then pushes the CPU and heap profiles.
+// It reports whether the profiler is stopped.
+func (p *profiler) collect() (stopped bool) {
+	cpu := &bytes.Buffer{}
+	from := time.Now()
+	// Starting the CPU profile fails if it's already enabled elsewhere, e.g. via net/http/pprof, in which case only the
+	// heap profile is pushed.
+	cpuErr := pprof.StartCPUProfile(cpu)
+	if cpuErr != nil {
+		p.logger.Warn("Start CPU profile failed.", constant.LogAttrError, cpuErr)
+	}
+
+	timer := time.NewTimer(p.interval)
+	select {
+	case <-timer.C:
+	case <-p.stop:
+		timer.Stop()
+		stopped = true
+	}
+	if cpuErr == nil {
+		pprof.StopCPUProfile()
+	}
+	until := time.Now()
+
+	heap := &bytes.Buffer{}
+	if err := pprof.Lookup("heap").WriteTo(heap, 0); err != nil {
+		p.logger.Warn("Write heap profile failed.", constant.LogAttrError, err)
+		heap.Reset()
+	}
+
+	for _, profile := range []*bytes.Buffer{cpu, heap} {
+		if profile.Len() == 0 {
+			continue
+		}
+		if err := p.push(profile.Bytes(), from, until); err != nil {
+			p.logger.Error("Push profile failed.", constant.LogAttrError, err)
+		}
+	}
+	return
+}
+
+// push uploads a pprof profile via the ingest API.
+func (p *profiler) push(profile []byte, from, until time.Time) error {
+	body := &bytes.Buffer{}
+	w := multipart.NewWriter(body)
+	part, err := w.CreateFormFile("profile", "profile.pprof")
+	if err != nil {
+		return err
+	}
+	if _, err = part.Write(profile); err != nil {
+		return err
+	}
+	if err = w.Close(); err != nil {
+		return err
+	}
+
+	query := url.Values{}
+	query.Set("name", p.name)
+	query.Set("from", strconv.FormatInt(from.Unix(), 10))
+	query.Set("until", strconv.FormatInt(until.Unix(), 10))
+	query.Set("format", "pprof")
+	query.Set("spyName", "gospy")
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, p.url+"?"+query.Encode(), body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", w.FormDataContentType())
+	for k, v := range p.headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close() // nolint:errcheck
+	if resp.StatusCode >= http.StatusBadRequest {
+		return fmt.Errorf("unexpected status: %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package otel
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestProfiler(t *testing.T) {
+	t.Parallel()
+
+	type upload struct {
+		name    string
+		format  string
+		auth    string
+		profile int
+	}
+	uploads := make(chan upload, 16) // nolint:mnd
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		u := upload{
+			name:   r.URL.Query().Get("name"),
+			format: r.URL.Query().Get("format"),
+			auth:   r.Header.Get("Authorization"),
+		}
+		if r.URL.Path != "/ingest" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if file, _, err := r.FormFile("profile"); err == nil {
+			b, _ := io.ReadAll(file)
+			u.profile = len(b)
+		}
+		select {
+		case uploads <- u:
+		default:
+		}
+	}))
+	defer server.Close()
+
+	p, err := newProfiler(&ProfilingConfig{
+		Endpoint:   server.URL + "/",
+		IntervalMs: 50, // nolint:mnd
+		Headers:    map[string]string{"Authorization": "Bearer token"},
+	}, "svc", time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.start()
+
+	u := <-uploads
+	if u.name != "svc" || u.format != "pprof" || u.auth != "Bearer token" || u.profile == 0 {
+		t.Fatalf("Unexpected upload %+v", u)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := p.shutdown(ctx); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestNewProfiler_invalidConfig(t *testing.T) {
+	t.Parallel()
+
+	for _, cfg := range []*ProfilingConfig{
+		{Endpoint: "", IntervalMs: 1000},
+		{Endpoint: "http://localhost:4040", IntervalMs: 0},
+		{Endpoint: "://invalid", IntervalMs: 1000},
+	} {
+		if _, err := newProfiler(cfg, "svc", time.Second); !errors.Is(err, ErrInvalidConfig) {
+			t.Fatalf("Expect ErrInvalidConfig for %+v, got %+v", cfg, err)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package otel
+
+import (
+	"os"
+	"path/filepath"
+	"runtime/debug"
+
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+)
+
+// develVersion is the main module version reported by [debug.ReadBuildInfo] when built from a local checkout.
+const develVersion = "(devel)"
+
+// serviceAttributes returns the service resource attributes of cfg, where empty values are replaced with fallbacks:
+//
+//   - service.name: The last element of the main package path, or the executable name.
+//   - service.version: The main module version, or the VCS revision if built from a local checkout.
+//   - service.instance.id: The host name.
+//
+// The namespace doesn't have a fallback and is omitted if empty.
+func serviceAttributes(cfg *ServiceConfig) []attribute.KeyValue {
+	info, _ := debug.ReadBuildInfo()
+
+	name := serviceName(cfg, info)
+	version := cfg.Version
+	if len(version) == 0 {
+		version = defaultServiceVersion(info)
+	}
+	instanceID := cfg.InstanceID
+	if len(instanceID) == 0 {
+		instanceID, _ = os.Hostname()
+	}
+
+	attrs := make([]attribute.KeyValue, 0, 4) // nolint:mnd
+	if len(name) > 0 {
+		attrs = append(attrs, semconv.ServiceName(name))
+	}
+	if len(cfg.Namespace) > 0 {
+		attrs = append(attrs, semconv.ServiceNamespace(cfg.Namespace))
+	}
+	if len(version) > 0 {
+		attrs = append(attrs, semconv.ServiceVersion(version))
+	}
+	if len(instanceID) > 0 {
+		attrs = append(attrs, semconv.ServiceInstanceID(instanceID))
+	}
+	return attrs
+}
+
+// serviceName returns the configured service name, or the fallback if it's empty.
+func serviceName(cfg *ServiceConfig, info *debug.BuildInfo) string {
+	if len(cfg.Name) > 0 {
+		return cfg.Name
+	}
+	return defaultServiceName(info)
+}
+
+func defaultServiceName(info *debug.BuildInfo) string {
+	if info != nil && len(info.Path) > 0 {
+		return filepath.Base(info.Path)
+	}
+	if exe, err := os.Executable(); err == nil {
+		return filepath.Base(exe)
+	}
+	return ""
+}
+
+func defaultServiceVersion(info *debug.BuildInfo) string {
+	if info == nil {
+		return ""
+	}
+	if v := info.Main.Version; len(v) > 0 && v != develVersion {
+		return v
+	}
+	for _, setting := range info.Settings {
+		if setting.Key == "vcs.revision" {
+			return setting.Value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,76 @@
+package otel
+
+import (
+	"os"
+	"runtime/debug"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+)
+
+func TestServiceAttributes(t *testing.T) {
+	t.Parallel()
+
+	attrs := attribute.NewSet(serviceAttributes(&ServiceConfig{
+		Name:       "svc",
+		Namespace:  "ns",
+		Version:    "v1.2.3",
+		InstanceID: "instance",
+	})...)
+	expected := attribute.NewSet(
+		semconv.ServiceName("svc"),
+		semconv.ServiceNamespace("ns"),
+		semconv.ServiceVersion("v1.2.3"),
+		semconv.ServiceInstanceID("instance"),
+	)
+	if !attrs.Equals(&expected) {
+		t.Fatalf("Expect %v, got %v", expected.Encoded(attribute.DefaultEncoder()),
+			attrs.Encoded(attribute.DefaultEncoder()))
+	}
+
+	// Fallbacks
+	attrs = attribute.NewSet(serviceAttributes(&ServiceConfig{})...)
+	if v, ok := attrs.Value(semconv.ServiceNameKey); !ok || len(v.AsString()) == 0 {
+		t.Fatal("Expect service name fallback")
+	}
+	if _, ok := attrs.Value(semconv.ServiceNamespaceKey); ok {
+		t.Fatal("Expect no namespace")
+	}
+	if hostname, err := os.Hostname(); err == nil {
+		if v, _ := attrs.Value(semconv.ServiceInstanceIDKey); v.AsString() != hostname {
+			t.Fatalf("Expect instance ID %s, got %s", hostname, v.AsString())
+		}
+	}
+}
+
+func TestDefaultService(t *testing.T) {
+	t.Parallel()
+
+	info := &debug.BuildInfo{
+		Path: "example.com/team/cmd/server",
+		Main: debug.Module{Version: "v1.0.0"},
+	}
+	if name := defaultServiceName(info); name != "server" {
+		t.Errorf("Expect name server, got %s", name)
+	}
+	if name := defaultServiceName(nil); len(name) == 0 {
+		t.Error("Expect executable name")
+	}
+	if version := defaultServiceVersion(info); version != "v1.0.0" {
+		t.Errorf("Expect version v1.0.0, got %s", version)
+	}
+
+	info.Main.Version = develVersion
+	info.Settings = []debug.BuildSetting{{Key: "vcs.revision", Value: "abc123"}}
+	if version := defaultServiceVersion(info); version != "abc123" {
+		t.Errorf("Expect version abc123, got %s", version)
+	}
+	info.Settings = nil
+	if version := defaultServiceVersion(info); len(version) != 0 {
+		t.Errorf("Expect empty version, got %s", version)
+	}
+	if version := defaultServiceVersion(nil); len(version) != 0 {
+		t.Errorf("Expect empty version, got %s", version)
+	}
+}
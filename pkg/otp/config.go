@@ -0,0 +1,23 @@
+package otp
+
+// Config defines the config model for otp.
+type Config struct {
+	// Digits is the number of digits of codes.
+	Digits int `json:"digits" yaml:"digits" toml:"digits" xml:"digits" env:"OTP_DIGITS" default:"6"`
+
+	// PeriodSec is the time step of TOTP in seconds.
+	PeriodSec int64 `json:"period_sec" yaml:"period_sec" toml:"period_sec" xml:"period_sec" env:"OTP_PERIOD_SEC" default:"30"` // nolint:lll
+
+	// Skew is the number of time steps before and after the current one in which TOTP codes are still accepted, which
+	// tolerates clock drift between the server and the authenticator.
+	Skew int `json:"skew" yaml:"skew" toml:"skew" xml:"skew" env:"OTP_SKEW" default:"1"`
+
+	// CodeTTLSec is how long a one-time code is valid for in seconds.
+	CodeTTLSec int64 `json:"code_ttl_sec" yaml:"code_ttl_sec" toml:"code_ttl_sec" xml:"code_ttl_sec" env:"OTP_CODE_TTL_SEC" default:"300"` // nolint:lll
+
+	// Secret is the secret key used to hash one-time codes before they are stored. It must not be empty.
+	Secret string `json:"secret" yaml:"secret" toml:"secret" xml:"secret" env:"OTP_SECRET" default:"" secret:"true"` // nolint:lll
+
+	// Prefix is the prefix for redis keys. Use different keys in different scenarios to avoid conflicts.
+	Prefix string `json:"prefix" yaml:"prefix" toml:"prefix" xml:"prefix" env:"OTP_PREFIX" default:"otp"`
+}
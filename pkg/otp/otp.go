@@ -0,0 +1,298 @@
+//go:generate mockgen -write_package_comment=false -source=otp.go -destination=otp_mock.go -package otp
+
+/*
+Package otp implements one-time passwords for 2FA and passwordless flows.
+
+Two kinds of one-time passwords are supported:
+
+  - TOTP (RFC 6238), which is generated by authenticator apps from a secret shared with the server.
+  - One-time codes, which are generated by the server, stored in Valkey and sent to users via email or SMS.
+
+Verification attempts are rate limited by identifier via [limiter.Service] to prevent brute force:
+
+	svc, err := otp.NewService(cfg, rc, lim)
+	code, err := svc.Issue(ctx, "user:42")
+	// Send the code to the user, and then:
+	ok, err := svc.Verify(ctx, "user:42", input)
+*/
+package otp
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1" // nolint:gosec
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/redis/rueidis"
+	"github.com/sainnhe/go-common/pkg/clock"
+	"github.com/sainnhe/go-common/pkg/constant"
+	"github.com/sainnhe/go-common/pkg/limiter"
+)
+
+var (
+	// ErrTooManyAttempts indicates that the identifier has been rate limited.
+	ErrTooManyAttempts = errors.New("too many attempts")
+
+	// ErrInvalidSecret indicates that the TOTP secret is not valid base32.
+	ErrInvalidSecret = errors.New("invalid secret")
+
+	// ErrInvalidConfig indicates that the config is invalid.
+	ErrInvalidConfig = errors.New("invalid config")
+
+	// ErrEmptySecret indicates that the secret in config is empty.
+	ErrEmptySecret = errors.New("empty secret")
+)
+
+// maxDigits is the maximum number of digits, so that the modulus fits in int64.
+const maxDigits = 18
+
+// acceptCounterScript sets KEYS[1] to the TOTP counter ARGV[1] with the expiration of ARGV[2] seconds if it's greater
+// than the last accepted counter, and returns 1 if it's set or 0 otherwise.
+var acceptCounterScript = rueidis.NewLuaScript(`
+local last = tonumber(redis.call("GET", KEYS[1]))
+if last and last >= tonumber(ARGV[1]) then
+	return 0
+end
+redis.call("SET", KEYS[1], ARGV[1], "EX", ARGV[2])
+return 1`)
+
+// secretSize is the size of TOTP secrets in bytes, which is the output size of HMAC-SHA1 as recommended by RFC 4226.
+const secretSize = 20
+
+var b32 = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// Service is the otp service.
+type Service interface {
+	// NewSecret generates a new base32 encoded TOTP secret.
+	NewSecret() (string, error)
+
+	// KeyURI returns the otpauth:// URI of a TOTP secret, which is usually rendered as a QR code to be scanned by
+	// authenticator apps.
+	KeyURI(issuer, account, secret string) string
+
+	// ValidateTOTP validates a TOTP code generated from secret. A code is rejected if its time step is not after the one
+	// of the last accepted code of the identifier, so that it can't be replayed as required by RFC 6238 section 5.2.
+	// [ErrTooManyAttempts] is returned if the identifier has been rate limited.
+	ValidateTOTP(ctx context.Context, identifier, secret, code string) (bool, error)
+
+	// Issue issues a new one-time code for identifier, replacing the previous one if any.
+	Issue(ctx context.Context, identifier string) (string, error)
+
+	// Verify verifies a one-time code issued for identifier. The code is deleted once verified, so that it can only be
+	// used once.
+	// [ErrTooManyAttempts] is returned if the identifier has been rate limited.
+	Verify(ctx context.Context, identifier, code string) (bool, error)
+}
+
+type serviceImpl struct {
+	cfg *Config
+	rc  rueidis.Client
+	lim limiter.Service
+	clk clock.Clock
+}
+
+// Option is the option used to customize the otp service.
+type Option func(s *serviceImpl)
+
+// WithClock sets the clock used to generate TOTP codes. Defaults to [clock.Real].
+func WithClock(clk clock.Clock) Option {
+	return func(s *serviceImpl) {
+		if clk != nil {
+			s.clk = clk
+		}
+	}
+}
+
+// NewService initializes a new otp service. The limiter limits verification attempts by identifier.
+//
+// [ErrInvalidConfig] is returned if [Config.Digits] is not in 1..18, or [Config.PeriodSec], [Config.Skew] or
+// [Config.CodeTTLSec] is out of range, and [ErrEmptySecret] is returned if [Config.Secret] is empty.
+func NewService(cfg *Config, rc rueidis.Client, lim limiter.Service, opts ...Option) (Service, error) {
+	if cfg == nil || rc == nil || lim == nil {
+		return nil, constant.ErrNilDeps
+	}
+	if cfg.Digits < 1 || cfg.Digits > maxDigits || cfg.PeriodSec <= 0 || cfg.Skew < 0 || cfg.CodeTTLSec <= 0 {
+		return nil, ErrInvalidConfig
+	}
+	if cfg.Secret == "" {
+		return nil, ErrEmptySecret
+	}
+	s := &serviceImpl{
+		cfg,
+		rc,
+		lim,
+		clock.Real(),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s, nil
+}
+
+func (s *serviceImpl) NewSecret() (string, error) {
+	secret := make([]byte, secretSize)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return b32.EncodeToString(secret), nil
+}
+
+func (s *serviceImpl) KeyURI(issuer, account, secret string) string {
+	q := url.Values{}
+	q.Set("secret", secret)
+	q.Set("issuer", issuer)
+	q.Set("algorithm", "SHA1")
+	q.Set("digits", strconv.Itoa(s.cfg.Digits))
+	q.Set("period", strconv.FormatInt(s.cfg.PeriodSec, 10))
+	u := url.URL{
+		Scheme:   "otpauth",
+		Host:     "totp",
+		Path:     "/" + issuer + ":" + account,
+		RawQuery: q.Encode(),
+	}
+	return u.String()
+}
+
+func (s *serviceImpl) ValidateTOTP(ctx context.Context, identifier, secret, code string) (bool, error) {
+	key, err := b32.DecodeString(strings.ToUpper(strings.TrimRight(secret, "=")))
+	if err != nil {
+		return false, fmt.Errorf("%w: %w", ErrInvalidSecret, err)
+	}
+	if err := s.allow(ctx, identifier); err != nil {
+		return false, err
+	}
+	counter := s.clk.Now().Unix() / s.cfg.PeriodSec
+	for i := -s.cfg.Skew; i <= s.cfg.Skew; i++ {
+		expected := hotp(key, uint64(counter+int64(i)), s.cfg.Digits) // nolint:gosec
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			// The last accepted counter only needs to be kept until all codes it covers have expired.
+			ttl := s.cfg.PeriodSec * int64(2*s.cfg.Skew+2) // nolint:mnd
+			n, err := acceptCounterScript.Exec(ctx, s.rc, []string{s.getTOTPKey(identifier)},
+				[]string{strconv.FormatInt(counter+int64(i), 10), strconv.FormatInt(ttl, 10)}).AsInt64()
+			if err != nil {
+				return false, err
+			}
+			return n == 1, nil
+		}
+	}
+	return false, nil
+}
+
+func (s *serviceImpl) Issue(ctx context.Context, identifier string) (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(modulus(s.cfg.Digits)))
+	if err != nil {
+		return "", err
+	}
+	code := fmt.Sprintf("%0*d", s.cfg.Digits, n)
+	hash := s.hashCode(code)
+	if err = s.rc.Do(ctx, s.rc.B().
+		Set().
+		Key(s.getCodeKey(identifier, hash)).
+		Value("1").
+		ExSeconds(s.cfg.CodeTTLSec).
+		Build()).Error(); err != nil {
+		return "", err
+	}
+	// Replace the current code, and delete the previous one so that it can't be verified anymore.
+	prev, err := s.rc.Do(ctx, s.rc.B().
+		Set().
+		Key(s.getCurrentKey(identifier)).
+		Value(hash).
+		Get().
+		ExSeconds(s.cfg.CodeTTLSec).
+		Build()).ToString()
+	if err != nil && !rueidis.IsRedisNil(err) {
+		return "", err
+	}
+	if prev != "" && prev != hash {
+		if err = s.rc.Do(ctx, s.rc.B().Del().Key(s.getCodeKey(identifier, prev)).Build()).Error(); err != nil {
+			return "", err
+		}
+	}
+	return code, nil
+}
+
+func (s *serviceImpl) Verify(ctx context.Context, identifier, code string) (bool, error) {
+	if err := s.allow(ctx, identifier); err != nil {
+		return false, err
+	}
+	// Codes are stored by their hashes, so that a code is verified and deleted atomically, and only one wins if it's
+	// verified concurrently.
+	err := s.rc.Do(ctx, s.rc.B().Getdel().Key(s.getCodeKey(identifier, s.hashCode(code))).Build()).Error()
+	if rueidis.IsRedisNil(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (s *serviceImpl) allow(ctx context.Context, identifier string) error {
+	result, err := s.lim.Allow(ctx, s.getKey(identifier))
+	if err != nil {
+		return err
+	}
+	if !result.Allowed {
+		return ErrTooManyAttempts
+	}
+	return nil
+}
+
+func (s *serviceImpl) getKey(identifier string) string {
+	return fmt.Sprintf("%s:%s", s.cfg.Prefix, identifier)
+}
+
+// getCurrentKey returns the key storing the hash of the current one-time code of the identifier.
+func (s *serviceImpl) getCurrentKey(identifier string) string {
+	return fmt.Sprintf("%s:current:%s", s.cfg.Prefix, identifier)
+}
+
+// getCodeKey returns the key of a one-time code by its hash. The hash has a fixed length so that the key is not
+// ambiguous.
+func (s *serviceImpl) getCodeKey(identifier, hash string) string {
+	return fmt.Sprintf("%s:code:%s:%s", s.cfg.Prefix, hash, identifier)
+}
+
+// getTOTPKey returns the key storing the last accepted TOTP counter of the identifier.
+func (s *serviceImpl) getTOTPKey(identifier string) string {
+	return fmt.Sprintf("%s:totp:%s", s.cfg.Prefix, identifier)
+}
+
+// hotp generates an HOTP code as defined in RFC 4226.
+func hotp(key []byte, counter uint64, digits int) string {
+	mac := hmac.New(sha1.New, key)
+	_ = binary.Write(mac, binary.BigEndian, counter)
+	sum := mac.Sum(nil)
+	offset := sum[len(sum)-1] & 0x0f                                // nolint:mnd
+	v := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff // nolint:mnd
+	return fmt.Sprintf("%0*d", digits, int64(v)%modulus(digits))
+}
+
+// modulus returns 10 to the power of digits.
+func modulus(digits int) int64 {
+	mod := int64(1)
+	for range digits {
+		mod *= 10 // nolint:mnd
+	}
+	return mod
+}
+
+// hashCode hashes a one-time code with HMAC-SHA256 keyed by the secret, so that it's not stored in plain text and can't
+// be recovered by hashing all possible codes without the secret.
+func (s *serviceImpl) hashCode(code string) string {
+	mac := hmac.New(sha256.New, []byte(s.cfg.Secret))
+	mac.Write([]byte(code))
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,102 @@
+package otp
+
+import (
+	"context"
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/redis/rueidis"
+	"github.com/sainnhe/go-common/pkg/clock/testclock"
+	"github.com/sainnhe/go-common/pkg/limiter"
+)
+
+func TestHOTP(t *testing.T) {
+	t.Parallel()
+
+	// Test vectors from RFC 6238 Appendix B, using SHA1.
+	key := []byte("12345678901234567890")
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "94287082"},
+		{1111111109, "07081804"},
+		{1111111111, "14050471"},
+		{1234567890, "89005924"},
+		{2000000000, "69279037"},
+		{20000000000, "65353130"},
+	}
+	for _, tt := range tests {
+		if got := hotp(key, uint64(tt.unix/30), 8); got != tt.want { // nolint:gosec
+			t.Fatalf("Expect %s at %d, got %s", tt.want, tt.unix, got)
+		}
+	}
+}
+
+func TestValidateTOTP(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	clk := testclock.Freeze(time.Unix(1111111109, 0))
+	lim, err := limiter.NewService(&limiter.Config{
+		Enable:   true,
+		Backend:  limiter.BackendMemory,
+		Limit:    4,
+		WindowMs: 60000,
+	}, nil, limiter.WithClock(clk))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rc, err := rueidis.NewClient(rueidis.ClientOption{
+		InitAddress: []string{"localhost:6379"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rc.Close()
+	s := &serviceImpl{&Config{Digits: 8, PeriodSec: 30, Skew: 1, Prefix: "test_otp"}, rc, lim, clk}
+	secret := base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+	// Use unique identifiers since the last accepted counters are kept in Valkey.
+	foo := fmt.Sprintf("foo-%d", time.Now().UnixNano())
+	bar := fmt.Sprintf("bar-%d", time.Now().UnixNano())
+
+	if ok, err := s.ValidateTOTP(ctx, foo, secret, "07081804"); err != nil || !ok {
+		t.Fatalf("Expect valid, got ok = %t, err = %+v", ok, err)
+	}
+	if ok, err := s.ValidateTOTP(ctx, foo, secret, "07081804"); err != nil || ok {
+		t.Fatalf("Expect replayed code to be invalid, got ok = %t, err = %+v", ok, err)
+	}
+	clk.Advance(30 * time.Second)
+	if ok, err := s.ValidateTOTP(ctx, bar, strings.ToLower(secret), "07081804"); err != nil || !ok {
+		t.Fatalf("Expect valid within skew, got ok = %t, err = %+v", ok, err)
+	}
+	if ok, err := s.ValidateTOTP(ctx, foo, secret, "14050471"); err != nil || !ok {
+		t.Fatalf("Expect next time step to be valid, got ok = %t, err = %+v", ok, err)
+	}
+	clk.Advance(30 * time.Second)
+	if ok, err := s.ValidateTOTP(ctx, bar, secret, "07081804"); err != nil || ok {
+		t.Fatalf("Expect invalid, got ok = %t, err = %+v", ok, err)
+	}
+	if _, err := s.ValidateTOTP(ctx, foo, "!", "07081804"); !errors.Is(err, ErrInvalidSecret) {
+		t.Fatalf("Expect error %+v, got %+v", ErrInvalidSecret, err)
+	}
+	if ok, err := s.ValidateTOTP(ctx, foo, secret, "00000000"); err != nil || ok {
+		t.Fatalf("Expect invalid, got ok = %t, err = %+v", ok, err)
+	}
+	if _, err := s.ValidateTOTP(ctx, foo, secret, "00000000"); !errors.Is(err, ErrTooManyAttempts) {
+		t.Fatalf("Expect error %+v, got %+v", ErrTooManyAttempts, err)
+	}
+
+	secret, err = s.NewSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "otpauth://totp/Example:alice@example.com?algorithm=SHA1&digits=8&issuer=Example&period=30&secret=" +
+		secret; s.KeyURI("Example", "alice@example.com", secret) != want {
+		t.Fatalf("Expect %s, got %s", want, s.KeyURI("Example", "alice@example.com", secret))
+	}
+}
@@ -0,0 +1,100 @@
+package otp_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/redis/rueidis"
+	"github.com/sainnhe/go-common/pkg/constant"
+	"github.com/sainnhe/go-common/pkg/limiter"
+	"github.com/sainnhe/go-common/pkg/otp"
+)
+
+func TestNewService(t *testing.T) {
+	t.Parallel()
+
+	if _, err := otp.NewService(nil, nil, nil); !errors.Is(err, constant.ErrNilDeps) {
+		t.Fatalf("Expect error %+v, got %+v", constant.ErrNilDeps, err)
+	}
+
+	// The client is not used when the config is invalid.
+	rc := struct{ rueidis.Client }{}
+	lim, err := limiter.NewService(&limiter.Config{Backend: limiter.BackendMemory}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, cfg := range []*otp.Config{
+		{},
+		{Digits: 0, PeriodSec: 30, CodeTTLSec: 60},
+		{Digits: 19, PeriodSec: 30, CodeTTLSec: 60},
+		{Digits: 6, PeriodSec: 0, CodeTTLSec: 60},
+		{Digits: 6, PeriodSec: 30, Skew: -1, CodeTTLSec: 60},
+		{Digits: 6, PeriodSec: 30, CodeTTLSec: 0},
+	} {
+		if _, err = otp.NewService(cfg, rc, lim); !errors.Is(err, otp.ErrInvalidConfig) {
+			t.Fatalf("Expect error %+v for %+v, got %+v", otp.ErrInvalidConfig, cfg, err)
+		}
+	}
+	if _, err = otp.NewService(&otp.Config{Digits: 6, PeriodSec: 30, CodeTTLSec: 60}, rc, lim); !errors.Is(err,
+		otp.ErrEmptySecret) {
+		t.Fatalf("Expect error %+v, got %+v", otp.ErrEmptySecret, err)
+	}
+}
+
+func TestOTP(t *testing.T) {
+	t.Parallel()
+
+	rc, err := rueidis.NewClient(rueidis.ClientOption{
+		InitAddress: []string{"localhost:6379"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rc.Close()
+	lim, err := limiter.NewService(&limiter.Config{
+		Enable:   true,
+		Backend:  limiter.BackendMemory,
+		Limit:    3,
+		WindowMs: 60000,
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	svc, err := otp.NewService(&otp.Config{Digits: 6, PeriodSec: 30, CodeTTLSec: 60, Secret: "foo",
+		Prefix: "test_otp"}, rc, lim)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	// Issuing a new code invalidates the previous one.
+	prev, err := svc.Issue(ctx, "foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	code, err := svc.Issue(ctx, "foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if prev != code {
+		if ok, err := svc.Verify(ctx, "foo", prev); err != nil || ok {
+			t.Fatalf("Expect previous code to be invalid, got ok = %t, err = %+v", ok, err)
+		}
+	}
+	if len(code) != 6 {
+		t.Fatalf("Unexpected code %s", code)
+	}
+	if ok, err := svc.Verify(ctx, "foo", code+"0"); err != nil || ok {
+		t.Fatalf("Expect invalid, got ok = %t, err = %+v", ok, err)
+	}
+	if ok, err := svc.Verify(ctx, "foo", code); err != nil || !ok {
+		t.Fatalf("Expect valid, got ok = %t, err = %+v", ok, err)
+	}
+	if ok, err := svc.Verify(ctx, "foo", code); err != nil || ok {
+		t.Fatalf("Expect used code to be invalid, got ok = %t, err = %+v", ok, err)
+	}
+	if _, err := svc.Verify(ctx, "foo", code); !errors.Is(err, otp.ErrTooManyAttempts) {
+		t.Fatalf("Expect error %+v, got %+v", otp.ErrTooManyAttempts, err)
+	}
+}
@@ -0,0 +1,190 @@
+//go:generate mockgen -write_package_comment=false -source=partition.go -destination=partition_mock.go -package partition
+
+/*
+Package partition assigns identifiers to worker instances with consistent hashing, which is useful for stateful stream
+processing where each identifier like a user ID should be processed by a single instance at a time.
+
+Instances join a cluster via [membership.Service], and each of them computes the owners of identifiers from the alive
+members locally with rendezvous hashing, so that only the identifiers of joined or left members are moved:
+
+	m, cleanupMembership, err := membership.NewService(cfg, rc, hostname)
+	defer cleanupMembership()
+	s, cleanup, err := partition.NewService(m, partition.WithRebalance(func(prev, curr []string) {
+		// Flush the states of identifiers that are no longer owned.
+	}))
+	defer cleanup()
+
+	if s.Owns(userID) {
+		// Process the event.
+	}
+
+Since members are refreshed periodically, two members may consider themselves the owner of an identifier for up to
+[membership.Config.HeartbeatIntervalMs] during rebalancing. Use a distributed lock or fencing if this is unacceptable.
+
+[Jump] and [Rendezvous] can also be used directly to partition identifiers over a static set of shards or members.
+*/
+package partition
+
+import (
+	"hash/fnv"
+	"slices"
+	"sync"
+
+	"github.com/sainnhe/go-common/pkg/constant"
+	"github.com/sainnhe/go-common/pkg/membership"
+)
+
+// Jump returns the bucket of the key in [0, buckets) with jump consistent hashing, which moves only 1/buckets of the
+// keys when the number of buckets grows by one. It's suitable for numbered shards that are only added or removed at the
+// end. It returns -1 if buckets is not positive.
+func Jump(key uint64, buckets int) int {
+	if buckets <= 0 {
+		return -1
+	}
+	b, j := int64(-1), int64(0)
+	for j < int64(buckets) {
+		b = j
+		key = key*2862933555777941757 + 1                                 // nolint:mnd
+		j = int64(float64(b+1) * (float64(1<<31) / float64((key>>33)+1))) // nolint:mnd
+	}
+	return int(b)
+}
+
+// JumpString is like [Jump], but hashes a string key.
+func JumpString(key string, buckets int) int {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(key))
+	return Jump(h.Sum64(), buckets)
+}
+
+// Rendezvous returns the member that owns the key with rendezvous hashing, i.e. the member with the highest hash of the
+// member and the key. Only the keys of added or removed members are moved, regardless of the order of members. It
+// returns an empty string if there are no members.
+func Rendezvous(key string, members []string) string {
+	var owner string
+	var best uint64
+	for _, m := range members {
+		score := rendezvousScore(key, m)
+		if owner == "" || score > best || (score == best && m < owner) {
+			owner, best = m, score
+		}
+	}
+	return owner
+}
+
+func rendezvousScore(key, member string) uint64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(member))
+	_, _ = h.Write([]byte{0})
+	_, _ = h.Write([]byte(key))
+	// Mix the bits with the finalizer of SplitMix64, since FNV-1a distributes similar inputs poorly.
+	x := h.Sum64()
+	x = (x ^ (x >> 30)) * 0xbf58476d1ce4e5b9 // nolint:mnd
+	x = (x ^ (x >> 27)) * 0x94d049bb133111eb // nolint:mnd
+	return x ^ (x >> 31)                     // nolint:mnd
+}
+
+// Service is the partition service of a member.
+type Service interface {
+	// Members returns the names of alive members in lexicographical order, see [membership.Service.Members].
+	Members() []string
+
+	// Owner returns the member that owns the identifier, or an empty string if there are no alive members.
+	Owner(id string) string
+
+	// Owns reports whether the member itself owns the identifier.
+	Owns(id string) bool
+}
+
+type serviceImpl struct {
+	m         membership.Service
+	rebalance []func(prev, curr []string)
+
+	mu      sync.RWMutex
+	members []string
+}
+
+// Option is the option used to customize the partition service.
+type Option func(s *serviceImpl)
+
+// WithRebalance adds a callback that is called with the names of previous and current members when members change.
+// Callbacks are called one by one in the heartbeat goroutine of [membership.Service], so they should return quickly.
+func WithRebalance(fn func(prev, curr []string)) Option {
+	return func(s *serviceImpl) {
+		if fn != nil {
+			s.rebalance = append(s.rebalance, fn)
+		}
+	}
+}
+
+/*
+NewService initializes a new partition service.
+
+Params:
+  - m: The membership service of the member itself.
+  - opts: The options.
+
+Returns:
+  - Service: The partition service.
+  - func(): The cleanup function that stops watching members.
+  - error: [constant.ErrNilDeps] if m is nil.
+*/
+func NewService(m membership.Service, opts ...Option) (s Service, cleanup func(), err error) {
+	if m == nil {
+		err = constant.ErrNilDeps
+		return
+	}
+	impl := &serviceImpl{m: m}
+	for _, opt := range opts {
+		opt(impl)
+	}
+	// Watch before loading members, so that no change is missed in between.
+	cleanup = m.Watch(func(c membership.Change) {
+		impl.setMembers(names(c.Members))
+	})
+	impl.mu.Lock()
+	impl.members = names(m.Members())
+	impl.mu.Unlock()
+	s = impl
+	return
+}
+
+func (s *serviceImpl) Members() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return slices.Clone(s.members)
+}
+
+func (s *serviceImpl) Owner(id string) string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return Rendezvous(id, s.members)
+}
+
+func (s *serviceImpl) Owns(id string) bool {
+	return s.Owner(id) == s.m.Self().Name
+}
+
+// setMembers sets members and calls rebalance callbacks if they change.
+func (s *serviceImpl) setMembers(members []string) {
+	s.mu.Lock()
+	prev := s.members
+	s.members = members
+	s.mu.Unlock()
+	if slices.Equal(prev, members) {
+		return
+	}
+	for _, fn := range s.rebalance {
+		fn(slices.Clone(prev), slices.Clone(members))
+	}
+}
+
+// names returns the sorted names of members.
+func names(members []membership.Member) []string {
+	names := make([]string, 0, len(members))
+	for _, m := range members {
+		names = append(names, m.Name)
+	}
+	slices.Sort(names)
+	return names
+}
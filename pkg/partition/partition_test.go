@@ -0,0 +1,136 @@
+package partition_test
+
+import (
+	"errors"
+	"slices"
+	"strconv"
+	"testing"
+
+	"github.com/sainnhe/go-common/pkg/constant"
+	"github.com/sainnhe/go-common/pkg/membership"
+	"github.com/sainnhe/go-common/pkg/partition"
+	"go.uber.org/mock/gomock"
+)
+
+func TestJump(t *testing.T) {
+	t.Parallel()
+
+	if b := partition.Jump(1, 0); b != -1 {
+		t.Fatalf("Expect -1, got %d", b)
+	}
+	const keys = 10000
+	counts := make([]int, 10)
+	moved := 0
+	for i := range keys {
+		key := "user:" + strconv.Itoa(i)
+		b := partition.JumpString(key, 10)
+		if b < 0 || b >= 10 || b != partition.JumpString(key, 10) {
+			t.Fatalf("Unexpected bucket %d", b)
+		}
+		counts[b]++
+		// Keys are either kept or moved to the new bucket.
+		if b11 := partition.JumpString(key, 11); b11 != b {
+			if b11 != 10 {
+				t.Fatalf("Expect key to be moved to bucket 10, got %d", b11)
+			}
+			moved++
+		}
+	}
+	for _, c := range counts {
+		if c < keys/10*8/10 || c > keys/10*12/10 {
+			t.Fatalf("Unbalanced buckets %v", counts)
+		}
+	}
+	if moved < keys/11*8/10 || moved > keys/11*12/10 {
+		t.Fatalf("Expect about 1/11 keys to be moved, got %d", moved)
+	}
+}
+
+func TestRendezvous(t *testing.T) {
+	t.Parallel()
+
+	if owner := partition.Rendezvous("foo", nil); owner != "" {
+		t.Fatalf("Expect no owner, got %s", owner)
+	}
+	members := []string{"a", "b", "c", "d"}
+	reversed := []string{"d", "c", "b", "a"}
+	const keys = 10000
+	counts := map[string]int{}
+	for i := range keys {
+		key := "user:" + strconv.Itoa(i)
+		owner := partition.Rendezvous(key, members)
+		if owner != partition.Rendezvous(key, reversed) {
+			t.Fatal("Expect the owner to be independent of the order of members")
+		}
+		counts[owner]++
+		// Only the keys of the removed member are moved.
+		if newOwner := partition.Rendezvous(key, []string{"a", "b", "d"}); owner != "c" && newOwner != owner {
+			t.Fatalf("Expect key %s to stay at %s, got %s", key, owner, newOwner)
+		}
+	}
+	for _, m := range members {
+		if c := counts[m]; c < keys/4*8/10 || c > keys/4*12/10 {
+			t.Fatalf("Unbalanced members %v", counts)
+		}
+	}
+}
+
+func TestService(t *testing.T) {
+	t.Parallel()
+
+	if _, _, err := partition.NewService(nil); !errors.Is(err, constant.ErrNilDeps) {
+		t.Fatalf("Expect error %+v, got %+v", constant.ErrNilDeps, err)
+	}
+
+	ctrl := gomock.NewController(t)
+	m := membership.NewMockService(ctrl)
+	var watch func(c membership.Change)
+	cancelled := false
+	m.EXPECT().Watch(gomock.Any()).DoAndReturn(func(fn func(c membership.Change)) func() {
+		watch = fn
+		return func() { cancelled = true }
+	})
+	m.EXPECT().Members().Return([]membership.Member{{Name: "b", Index: 1}, {Name: "a", Index: 0}})
+	m.EXPECT().Self().Return(membership.Member{Name: "a", Index: 0}).AnyTimes()
+	rebalances := [][]string{}
+	s, cleanup, err := partition.NewService(m, partition.WithRebalance(func(prev, curr []string) {
+		rebalances = append(rebalances, prev, curr)
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if members := s.Members(); !slices.Equal(members, []string{"a", "b"}) {
+		t.Fatalf("Expect members [a b], got %v", members)
+	}
+	owned := 0
+	for i := range 100 {
+		id := strconv.Itoa(i)
+		if owner := s.Owner(id); owner != partition.Rendezvous(id, []string{"a", "b"}) {
+			t.Fatalf("Unexpected owner %s of %s", owner, id)
+		}
+		if s.Owns(id) {
+			owned++
+		}
+	}
+	if owned == 0 || owned == 100 {
+		t.Fatalf("Expect identifiers to be partitioned, got %d owned", owned)
+	}
+
+	// Others' identifiers are taken over after they leave.
+	watch(membership.Change{
+		Left:    []membership.Member{{Name: "b", Index: 1}},
+		Members: []membership.Member{{Name: "a", Index: 0}},
+	})
+	for i := range 100 {
+		if !s.Owns(strconv.Itoa(i)) {
+			t.Fatal("Expect a to own all identifiers")
+		}
+	}
+	if want := [][]string{{"a", "b"}, {"a"}}; !slices.EqualFunc(rebalances, want, slices.Equal) {
+		t.Fatalf("Expect rebalances %v, got %v", want, rebalances)
+	}
+	cleanup()
+	if !cancelled {
+		t.Fatal("Expect watch to be cancelled")
+	}
+}
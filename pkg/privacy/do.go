@@ -0,0 +1,87 @@
+package privacy
+
+import (
+	"slices"
+
+	"github.com/sainnhe/go-common/pkg/db"
+)
+
+/*
+RequestDO is the data object of data subject requests, which can be stored in a table like:
+
+	CREATE TABLE privacy_requests (
+		id BIGINT PRIMARY KEY AUTO_INCREMENT,
+		create_time DATETIME NOT NULL,
+		update_time DATETIME NOT NULL,
+		ext JSON NOT NULL,
+		kind VARCHAR(16) NOT NULL,
+		subject VARCHAR(255) NOT NULL,
+		actor VARCHAR(255) NOT NULL,
+		status VARCHAR(16) NOT NULL,
+		total BIGINT NOT NULL,
+		done BIGINT NOT NULL,
+		failed TEXT NOT NULL
+	);
+*/
+type RequestDO struct {
+	db.DO
+
+	// Kind is either [KindErase] or [KindExport].
+	Kind string `db:"kind"`
+
+	// Subject is the ID of the data subject.
+	Subject string `db:"subject"`
+
+	// Actor is the actor who made the request, see [WithActor].
+	Actor string `db:"actor"`
+
+	// Status is one of [StatusRunning], [StatusSucceeded] and [StatusFailed].
+	Status string `db:"status"`
+
+	// Total is the number of modules to process.
+	Total int64 `db:"total"`
+
+	// Done is the number of processed modules, including failed ones.
+	Done int64 `db:"done"`
+
+	// Failed are the comma-separated names of failed modules.
+	Failed string `db:"failed"`
+}
+
+// RequestDOCols contains the column names of [RequestDO].
+var RequestDOCols = append(slices.Clone(db.DOCols), "kind", "subject", "actor", "status", "total", "done", "failed")
+
+/*
+AuditDO is the data object of the audit trail, which records the result of each module for each request. It can be
+stored in a table like:
+
+	CREATE TABLE privacy_audits (
+		id BIGINT PRIMARY KEY AUTO_INCREMENT,
+		create_time DATETIME NOT NULL,
+		update_time DATETIME NOT NULL,
+		ext JSON NOT NULL,
+		request_id BIGINT NOT NULL,
+		module VARCHAR(255) NOT NULL,
+		status VARCHAR(16) NOT NULL,
+		error TEXT NOT NULL,
+		INDEX idx_request_id (request_id)
+	);
+*/
+type AuditDO struct {
+	db.DO
+
+	// RequestID is the ID of the request.
+	RequestID int64 `db:"request_id"`
+
+	// Module is the name of the module.
+	Module string `db:"module"`
+
+	// Status is either [StatusSucceeded] or [StatusFailed].
+	Status string `db:"status"`
+
+	// Error is the error message if the module failed.
+	Error string `db:"error"`
+}
+
+// AuditDOCols contains the column names of [AuditDO].
+var AuditDOCols = append(slices.Clone(db.DOCols), "request_id", "module", "status", "error")
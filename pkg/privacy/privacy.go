@@ -0,0 +1,265 @@
+//go:generate mockgen -write_package_comment=false -source=privacy.go -destination=privacy_mock.go -package privacy
+
+/*
+Package privacy orchestrates data subject requests, such as erasing or exporting all data of a user under GDPR.
+
+Personal data is usually scattered across modules and storages like databases, caches, blob storages and search
+indexes. Each module registers a [Handler] that knows how to erase and export its own data, and the [Service] runs all
+handlers for a subject, tracks the progress in a request record, and writes an audit trail:
+
+	requests, err := db.NewRepo[privacy.RequestDO](pool, "privacy_requests")
+	audits, err := db.NewRepo[privacy.AuditDO](pool, "privacy_audits")
+	svc, err := privacy.NewService(requests, audits, privacy.WithActor(operator))
+	err = svc.Register("orders", ordersHandler)
+	err = svc.Register("avatars", avatarsHandler)
+
+	// Erase all data of the user.
+	req, err := svc.Erase(ctx, userID)
+
+	// Query the progress from elsewhere.
+	req, err = svc.Request(ctx, req.ID)
+
+Handlers run one by one in the order of registration. A failed handler doesn't stop the others, and the request can be
+retried by making a new one, so handlers must be idempotent.
+
+Since request records and audit trails outlive the erased data, the subject should be an opaque ID rather than personal
+data like email addresses.
+*/
+package privacy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+
+	"github.com/sainnhe/go-common/pkg/constant"
+	"github.com/sainnhe/go-common/pkg/db"
+	"github.com/sainnhe/go-common/pkg/log"
+)
+
+const pkgName = "github.com/sainnhe/go-common/pkg/privacy"
+
+// Kinds of requests.
+const (
+	// KindErase is the kind of erasure requests.
+	KindErase = "erase"
+
+	// KindExport is the kind of export requests.
+	KindExport = "export"
+)
+
+// Statuses of requests and audits.
+const (
+	// StatusRunning indicates that the request is running.
+	StatusRunning = "running"
+
+	// StatusSucceeded indicates that all modules succeeded.
+	StatusSucceeded = "succeeded"
+
+	// StatusFailed indicates that some modules failed.
+	StatusFailed = "failed"
+)
+
+var (
+	// ErrDuplicateModule indicates that the module has been registered.
+	ErrDuplicateModule = errors.New("duplicate module")
+
+	// ErrRequestFailed indicates that some modules failed to process the request.
+	ErrRequestFailed = errors.New("request failed")
+)
+
+// Handler handles data subject requests of a module. Its methods must be idempotent, since failed requests are retried
+// as a whole.
+type Handler interface {
+	// Erase erases all data of the subject in the module. It should return nil if there is no data.
+	Erase(ctx context.Context, subject string) error
+
+	// Export exports all data of the subject in the module, which should be serializable to JSON. It should return nil
+	// if there is no data.
+	Export(ctx context.Context, subject string) (any, error)
+}
+
+// Service is the privacy service.
+type Service interface {
+	// Register registers the handler of a module. An error wrapping [ErrDuplicateModule] is returned if the module has
+	// been registered.
+	Register(module string, h Handler) error
+
+	// Erase erases all data of the subject by running [Handler.Erase] of all modules, and returns the request. The
+	// request record is updated after each module, so that the progress can be queried via [Service.Request].
+	// If some modules failed, the request is returned with an error wrapping [ErrRequestFailed].
+	Erase(ctx context.Context, subject string) (*RequestDO, error)
+
+	// Export exports all data of the subject by running [Handler.Export] of all modules, and returns the request and the
+	// data keyed by module names. If some modules failed, the data of the other modules is returned with an error
+	// wrapping [ErrRequestFailed].
+	Export(ctx context.Context, subject string) (*RequestDO, map[string]any, error)
+
+	// Request queries a request by ID. [sql.ErrNoRows] is returned if it doesn't exist.
+	Request(ctx context.Context, id int64) (*RequestDO, error)
+}
+
+type serviceImpl struct {
+	requests db.Repo[RequestDO]
+	audits   db.Repo[AuditDO]
+	actor    func(ctx context.Context) string
+	l        *slog.Logger
+
+	mu       sync.RWMutex
+	modules  []string
+	handlers map[string]Handler
+}
+
+// Option is the option used to customize the privacy service.
+type Option func(s *serviceImpl)
+
+// WithActor sets the function that returns the actor of requests from the context, like the authenticated operator,
+// which is recorded in [RequestDO.Actor]. Defaults to an empty actor.
+func WithActor(actor func(ctx context.Context) string) Option {
+	return func(s *serviceImpl) {
+		s.actor = actor
+	}
+}
+
+/*
+NewService initializes a new privacy service.
+
+Params:
+  - requests: The repo of request records, see [RequestDO] for the table structure.
+  - audits: The repo of the audit trail, see [AuditDO] for the table structure.
+  - opts: The options.
+
+Returns:
+  - Service: The privacy service.
+  - error: [constant.ErrNilDeps] if any repo is nil.
+*/
+func NewService(requests db.Repo[RequestDO], audits db.Repo[AuditDO], opts ...Option) (Service, error) {
+	if requests == nil || audits == nil {
+		return nil, constant.ErrNilDeps
+	}
+	s := &serviceImpl{
+		requests: requests,
+		audits:   audits,
+		l:        log.NewLogger(pkgName),
+		handlers: map[string]Handler{},
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s, nil
+}
+
+func (s *serviceImpl) Register(module string, h Handler) error {
+	if h == nil {
+		return constant.ErrNilDeps
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.handlers[module]; ok {
+		return fmt.Errorf("%w: %s", ErrDuplicateModule, module)
+	}
+	s.modules = append(s.modules, module)
+	s.handlers[module] = h
+	return nil
+}
+
+func (s *serviceImpl) Erase(ctx context.Context, subject string) (*RequestDO, error) {
+	return s.run(ctx, "Erase", KindErase, subject, func(_ string, h Handler) error {
+		return h.Erase(ctx, subject)
+	})
+}
+
+func (s *serviceImpl) Export(ctx context.Context, subject string) (*RequestDO, map[string]any, error) {
+	data := map[string]any{}
+	req, err := s.run(ctx, "Export", KindExport, subject, func(module string, h Handler) error {
+		v, err := h.Export(ctx, subject)
+		if err == nil && v != nil {
+			data[module] = v
+		}
+		return err
+	})
+	return req, data, err
+}
+
+func (s *serviceImpl) Request(ctx context.Context, id int64) (*RequestDO, error) {
+	return s.requests.QueryByID(ctx, id)
+}
+
+// run creates a request and runs handle with the handler of each module, where method is the name of the calling method
+// used in logs.
+func (s *serviceImpl) run(ctx context.Context, method, kind, subject string,
+	handle func(module string, h Handler) error) (*RequestDO, error) {
+	s.mu.RLock()
+	modules := append([]string(nil), s.modules...)
+	handlers := make([]Handler, 0, len(modules))
+	for _, m := range modules {
+		handlers = append(handlers, s.handlers[m])
+	}
+	s.mu.RUnlock()
+
+	req := &RequestDO{
+		Kind:    kind,
+		Subject: subject,
+		Status:  StatusRunning,
+		Total:   int64(len(modules)),
+	}
+	if s.actor != nil {
+		req.Actor = s.actor(ctx)
+	}
+	if err := s.requests.Insert(ctx, req); err != nil {
+		return nil, err
+	}
+
+	logger := s.l.With(constant.LogAttrMethod, method, "request_id", req.ID)
+	failed := []string{}
+	errs := []error{}
+	for i, module := range modules {
+		err := ctx.Err()
+		if err == nil {
+			err = handle(module, handlers[i])
+		}
+		audit := &AuditDO{RequestID: req.ID, Module: module, Status: StatusSucceeded}
+		if err != nil {
+			logger.ErrorContext(ctx, "Handle request failed.", "module", module, constant.LogAttrError, err)
+			failed = append(failed, module)
+			errs = append(errs, fmt.Errorf("%s: %w", module, err))
+			audit.Status = StatusFailed
+			audit.Error = err.Error()
+		}
+		// Use a context without cancellation so that the progress is recorded even if ctx is cancelled.
+		if err := s.audits.Insert(context.WithoutCancel(ctx), audit); err != nil {
+			return s.abort(ctx, logger, req, err)
+		}
+		req.Done++
+		req.Failed = strings.Join(failed, ",")
+		if err := s.requests.Update(context.WithoutCancel(ctx), req); err != nil {
+			return s.abort(ctx, logger, req, err)
+		}
+	}
+
+	req.Status = StatusSucceeded
+	if len(failed) > 0 {
+		req.Status = StatusFailed
+	}
+	if err := s.requests.Update(context.WithoutCancel(ctx), req); err != nil {
+		return s.abort(ctx, logger, req, err)
+	}
+	if len(errs) > 0 {
+		return req, fmt.Errorf("%w: %w", ErrRequestFailed, errors.Join(errs...))
+	}
+	return req, nil
+}
+
+// abort marks the request as failed after err occurs while recording its progress, so that it doesn't stay running
+// forever. It's best effort, since the repo that causes err may still be unavailable.
+func (s *serviceImpl) abort(ctx context.Context, logger *slog.Logger, req *RequestDO, err error) (*RequestDO, error) {
+	logger.ErrorContext(ctx, "Record progress failed. Aborting...", constant.LogAttrError, err)
+	req.Status = StatusFailed
+	if updateErr := s.requests.Update(context.WithoutCancel(ctx), req); updateErr != nil {
+		logger.ErrorContext(ctx, "Mark request as failed failed.", constant.LogAttrError, updateErr)
+	}
+	return req, err
+}
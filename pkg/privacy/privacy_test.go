@@ -0,0 +1,244 @@
+package privacy_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/sainnhe/go-common/pkg/constant"
+	"github.com/sainnhe/go-common/pkg/db"
+	"github.com/sainnhe/go-common/pkg/db/dbtest"
+	"github.com/sainnhe/go-common/pkg/privacy"
+	"go.uber.org/mock/gomock"
+)
+
+const (
+	requestsDDL = `CREATE TABLE privacy_requests (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	create_time DATETIME NOT NULL,
+	update_time DATETIME NOT NULL,
+	ext TEXT NOT NULL DEFAULT '',
+	kind TEXT NOT NULL,
+	subject TEXT NOT NULL,
+	actor TEXT NOT NULL,
+	status TEXT NOT NULL,
+	total INTEGER NOT NULL,
+	done INTEGER NOT NULL,
+	failed TEXT NOT NULL
+)`
+	auditsDDL = `CREATE TABLE privacy_audits (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	create_time DATETIME NOT NULL,
+	update_time DATETIME NOT NULL,
+	ext TEXT NOT NULL DEFAULT '',
+	request_id INTEGER NOT NULL,
+	module TEXT NOT NULL,
+	status TEXT NOT NULL,
+	error TEXT NOT NULL
+)`
+)
+
+type actorKey struct{}
+
+func TestNewService(t *testing.T) {
+	t.Parallel()
+
+	if _, err := privacy.NewService(nil, nil); !errors.Is(err, constant.ErrNilDeps) {
+		t.Fatalf("Expect error %+v, got %+v", constant.ErrNilDeps, err)
+	}
+
+	pool := dbtest.NewPool(t, requestsDDL, auditsDDL)
+	requests, err := db.NewRepo[privacy.RequestDO](pool, "privacy_requests")
+	if err != nil {
+		t.Fatal(err)
+	}
+	audits, err := db.NewRepo[privacy.AuditDO](pool, "privacy_audits")
+	if err != nil {
+		t.Fatal(err)
+	}
+	memoryAudits := db.NewMemoryRepo[privacy.AuditDO]()
+	t.Run("memory", func(t *testing.T) {
+		t.Parallel()
+
+		testService(t, db.NewMemoryRepo[privacy.RequestDO](), memoryAudits, func() []*privacy.AuditDO {
+			dos, err := memoryAudits.List(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			return dos
+		})
+	})
+	t.Run("sql", func(t *testing.T) {
+		t.Parallel()
+
+		testService(t, requests, audits, func() []*privacy.AuditDO {
+			dos := []*privacy.AuditDO{}
+			stmt := audits.StmtBuilder().BuildQueryCondStmt(privacy.AuditDOCols, nil, db.WithOrderBy(db.Asc("id")))
+			if err := pool.Select(&dos, stmt); err != nil {
+				t.Fatal(err)
+			}
+			return dos
+		})
+	})
+}
+
+// failingAuditRepo fails to insert audits.
+type failingAuditRepo struct {
+	db.Repo[privacy.AuditDO]
+	err error
+}
+
+func (r failingAuditRepo) Insert(context.Context, *privacy.AuditDO) error {
+	return r.err
+}
+
+func TestService_abort(t *testing.T) {
+	t.Parallel()
+
+	requests := db.NewMemoryRepo[privacy.RequestDO]()
+	failure := errors.New("database unavailable")
+	svc, err := privacy.NewService(requests, failingAuditRepo{db.NewMemoryRepo[privacy.AuditDO](), failure})
+	if err != nil {
+		t.Fatal(err)
+	}
+	orders := privacy.NewMockHandler(gomock.NewController(t))
+	if err = svc.Register("orders", orders); err != nil {
+		t.Fatal(err)
+	}
+	orders.EXPECT().Erase(gomock.Any(), "u1").Return(nil)
+
+	// The request is marked as failed instead of staying running.
+	ctx := context.Background()
+	req, err := svc.Erase(ctx, "u1")
+	if !errors.Is(err, failure) {
+		t.Fatalf("Expect error %+v, got %+v", failure, err)
+	}
+	if got, err := svc.Request(ctx, req.ID); err != nil || got.Status != privacy.StatusFailed {
+		t.Fatalf("Unexpected request %+v, %+v", got, err)
+	}
+}
+
+func testService(t *testing.T, requests db.Repo[privacy.RequestDO], audits db.Repo[privacy.AuditDO],
+	listAudits func() []*privacy.AuditDO) {
+	t.Helper()
+
+	svc, err := privacy.NewService(requests, audits, privacy.WithActor(func(ctx context.Context) string {
+		actor, _ := ctx.Value(actorKey{}).(string)
+		return actor
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctrl := gomock.NewController(t)
+	orders := privacy.NewMockHandler(ctrl)
+	avatars := privacy.NewMockHandler(ctrl)
+	if err := svc.Register("orders", orders); err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.Register("avatars", avatars); err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.Register("orders", avatars); !errors.Is(err, privacy.ErrDuplicateModule) {
+		t.Fatalf("Expect error %+v, got %+v", privacy.ErrDuplicateModule, err)
+	}
+	if err := svc.Register("nil", nil); !errors.Is(err, constant.ErrNilDeps) {
+		t.Fatalf("Expect error %+v, got %+v", constant.ErrNilDeps, err)
+	}
+	ctx := context.WithValue(context.Background(), actorKey{}, "admin")
+
+	// Erase
+	gomock.InOrder(
+		orders.EXPECT().Erase(gomock.Any(), "u1").Return(nil),
+		avatars.EXPECT().Erase(gomock.Any(), "u1").DoAndReturn(func(ctx context.Context, _ string) error {
+			// The progress is visible while the request is running.
+			req, err := svc.Request(ctx, 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if req.Status != privacy.StatusRunning || req.Done != 1 || req.Total != 2 {
+				t.Fatalf("Unexpected progress %+v", req)
+			}
+			return nil
+		}),
+	)
+	req, err := svc.Erase(ctx, "u1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if req.ID != 1 || req.Kind != privacy.KindErase || req.Subject != "u1" || req.Actor != "admin" ||
+		req.Status != privacy.StatusSucceeded || req.Done != 2 || req.Failed != "" {
+		t.Fatalf("Unexpected request %+v", req)
+	}
+	if got, err := svc.Request(ctx, req.ID); err != nil || got.Status != privacy.StatusSucceeded {
+		t.Fatalf("Unexpected request %+v, %+v", got, err)
+	}
+
+	// Failed modules don't stop the others.
+	failure := errors.New("connection refused")
+	orders.EXPECT().Erase(gomock.Any(), "u2").Return(failure)
+	avatars.EXPECT().Erase(gomock.Any(), "u2").Return(nil)
+	req, err = svc.Erase(ctx, "u2")
+	if !errors.Is(err, privacy.ErrRequestFailed) || !errors.Is(err, failure) {
+		t.Fatalf("Expect error %+v, got %+v", privacy.ErrRequestFailed, err)
+	}
+	if req.Status != privacy.StatusFailed || req.Done != 2 || req.Failed != "orders" {
+		t.Fatalf("Unexpected request %+v", req)
+	}
+
+	// Export
+	orders.EXPECT().Export(gomock.Any(), "u1").Return([]string{"o1"}, nil)
+	avatars.EXPECT().Export(gomock.Any(), "u1").Return(nil, nil)
+	req, data, err := svc.Export(ctx, "u1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if req.Kind != privacy.KindExport || req.Status != privacy.StatusSucceeded ||
+		!reflect.DeepEqual(data, map[string]any{"orders": []string{"o1"}}) {
+		t.Fatalf("Unexpected export %+v %v", req, data)
+	}
+
+	// The remaining modules are skipped and recorded as failed once ctx is cancelled.
+	cancelled, cancel := context.WithCancel(ctx)
+	orders.EXPECT().Erase(gomock.Any(), "u3").DoAndReturn(func(context.Context, string) error {
+		cancel()
+		return nil
+	})
+	req, err = svc.Erase(cancelled, "u3")
+	if !errors.Is(err, context.Canceled) || req.Status != privacy.StatusFailed || req.Failed != "avatars" {
+		t.Fatalf("Unexpected request %+v, %+v", req, err)
+	}
+	if got, err := svc.Request(ctx, req.ID); err != nil || got.Status != privacy.StatusFailed {
+		t.Fatalf("Unexpected request %+v, %+v", got, err)
+	}
+
+	if _, err := svc.Request(ctx, 100); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("Expect error %+v, got %+v", sql.ErrNoRows, err)
+	}
+
+	// Audit trail
+	type audit struct {
+		requestID int64
+		module    string
+		status    string
+		err       string
+	}
+	got := []audit{}
+	for _, a := range listAudits() {
+		got = append(got, audit{a.RequestID, a.Module, a.Status, a.Error})
+	}
+	want := []audit{
+		{1, "orders", privacy.StatusSucceeded, ""},
+		{1, "avatars", privacy.StatusSucceeded, ""},
+		{2, "orders", privacy.StatusFailed, "connection refused"},
+		{2, "avatars", privacy.StatusSucceeded, ""},
+		{3, "orders", privacy.StatusSucceeded, ""},
+		{3, "avatars", privacy.StatusSucceeded, ""},
+		{4, "orders", privacy.StatusSucceeded, ""},
+		{4, "avatars", privacy.StatusFailed, context.Canceled.Error()},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Expect audits %v, got %v", want, got)
+	}
+}
@@ -0,0 +1,35 @@
+package proxy
+
+// Config defines the config model for proxy.
+type Config struct {
+	// Upstreams are the base URLs of upstream servers like "http://10.0.0.1:8080/v1". The path of an upstream is
+	// prepended to the request path. Requests are balanced across healthy upstreams in round robin.
+	Upstreams []string `json:"upstreams" yaml:"upstreams" toml:"upstreams" xml:"upstreams" env:"PROXY_UPSTREAMS" default:"[]"` // nolint:lll
+
+	// StripPrefix is removed from the request path before it's forwarded, e.g. "/api/users" is forwarded as "/users"
+	// if it's "/api". Requests whose paths don't start with it are forwarded unchanged.
+	StripPrefix string `json:"strip_prefix" yaml:"strip_prefix" toml:"strip_prefix" xml:"strip_prefix" env:"PROXY_STRIP_PREFIX" default:""` // nolint:lll
+
+	// PreserveHost indicates whether the Host header of the request is forwarded. If disabled, the host of the upstream
+	// is used.
+	PreserveHost bool `json:"preserve_host" yaml:"preserve_host" toml:"preserve_host" xml:"preserve_host" env:"PROXY_PRESERVE_HOST" default:"false"` // nolint:lll
+
+	// MaxRetries is the maximum number of retries on other upstreams when an upstream can't be reached or responds with
+	// 502, 503 or 504. Only requests with idempotent methods and without bodies are retried, since bodies are streamed
+	// rather than buffered.
+	MaxRetries int `json:"max_retries" yaml:"max_retries" toml:"max_retries" xml:"max_retries" env:"PROXY_MAX_RETRIES" default:"2"` // nolint:lll
+
+	// FailureThreshold is the number of consecutive failures after which an upstream is considered unhealthy.
+	FailureThreshold int `json:"failure_threshold" yaml:"failure_threshold" toml:"failure_threshold" xml:"failure_threshold" env:"PROXY_FAILURE_THRESHOLD" default:"3"` // nolint:lll
+
+	// CooldownMs is how long an unhealthy upstream is skipped in milliseconds, after which it receives requests again.
+	CooldownMs int64 `json:"cooldown_ms" yaml:"cooldown_ms" toml:"cooldown_ms" xml:"cooldown_ms" env:"PROXY_COOLDOWN_MS" default:"10000"` // nolint:lll
+
+	// MaxRequestBodyBytes is the maximum size of request bodies. Larger requests are rejected with 413 Request Entity
+	// Too Large. Set to 0 to disable the limit.
+	MaxRequestBodyBytes int64 `json:"max_request_body_bytes" yaml:"max_request_body_bytes" toml:"max_request_body_bytes" xml:"max_request_body_bytes" env:"PROXY_MAX_REQUEST_BODY_BYTES" default:"10485760"` // nolint:lll
+
+	// MaxResponseBodyBytes is the maximum size of response bodies. Responses declaring a larger Content-Length are
+	// replaced with 502 Bad Gateway, and streamed responses exceeding it are aborted. Set to 0 to disable the limit.
+	MaxResponseBodyBytes int64 `json:"max_response_body_bytes" yaml:"max_response_body_bytes" toml:"max_response_body_bytes" xml:"max_response_body_bytes" env:"PROXY_MAX_RESPONSE_BODY_BYTES" default:"0"` // nolint:lll
+}
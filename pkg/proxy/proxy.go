@@ -0,0 +1,340 @@
+/*
+Package proxy implements a reverse proxy for lightweight API gateways, based on [httputil.ReverseProxy]:
+
+	h, err := proxy.NewHandler(cfg)
+	mux.Handle("/api/", h)
+
+Requests are balanced across the upstreams in [Config.Upstreams] in round robin. Upstreams are checked passively: an
+upstream that fails [Config.FailureThreshold] times in a row, i.e. it can't be reached or responds with 502, 503 or 504,
+is skipped for [Config.CooldownMs]. If all upstreams are unhealthy, they are all used, so that the proxy recovers as
+soon as any of them does.
+
+Request and response bodies are streamed rather than buffered, and their sizes can be limited by
+[Config.MaxRequestBodyBytes] and [Config.MaxResponseBodyBytes].
+*/
+package proxy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sainnhe/go-common/pkg/clock"
+	"github.com/sainnhe/go-common/pkg/constant"
+	"github.com/sainnhe/go-common/pkg/log"
+)
+
+const pkgName = "github.com/sainnhe/go-common/pkg/proxy"
+
+var (
+	// ErrNoUpstreams indicates that no upstream is configured.
+	ErrNoUpstreams = errors.New("no upstreams")
+
+	// ErrResponseTooLarge indicates that the response body exceeds [Config.MaxResponseBodyBytes].
+	ErrResponseTooLarge = errors.New("response body too large")
+)
+
+// upstream is an upstream server and its health.
+type upstream struct {
+	url *url.URL
+
+	mu             sync.Mutex
+	failures       int
+	unhealthyUntil time.Time
+}
+
+type handler struct {
+	cfg       *Config
+	upstreams []*upstream
+	next      atomic.Uint64
+	rt        http.RoundTripper
+	clk       clock.Clock
+	l         *slog.Logger
+	proxy     *httputil.ReverseProxy
+}
+
+// Option is the option used to customize the proxy.
+type Option func(h *handler)
+
+// WithTransport sets the transport used to send requests to upstreams. Defaults to [http.DefaultTransport].
+func WithTransport(rt http.RoundTripper) Option {
+	return func(h *handler) {
+		if rt != nil {
+			h.rt = rt
+		}
+	}
+}
+
+// WithClock sets the clock used to skip unhealthy upstreams. Defaults to [clock.Real].
+func WithClock(clk clock.Clock) Option {
+	return func(h *handler) {
+		if clk != nil {
+			h.clk = clk
+		}
+	}
+}
+
+/*
+NewHandler initializes a reverse proxy handler.
+
+Params:
+  - cfg: The config.
+  - opts: The options.
+
+Returns:
+  - http.Handler: The handler.
+  - error: [constant.ErrNilDeps] if cfg is nil, [ErrNoUpstreams] if no upstream is configured, or an error if an
+    upstream is not a valid absolute URL.
+*/
+func NewHandler(cfg *Config, opts ...Option) (http.Handler, error) {
+	if cfg == nil {
+		return nil, constant.ErrNilDeps
+	}
+	if len(cfg.Upstreams) == 0 {
+		return nil, ErrNoUpstreams
+	}
+	h := &handler{
+		cfg: cfg,
+		rt:  http.DefaultTransport,
+		clk: clock.Real(),
+		l:   log.NewLogger(pkgName),
+	}
+	for _, raw := range cfg.Upstreams {
+		u, err := url.Parse(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid upstream %q: %w", raw, err)
+		}
+		if u.Scheme == "" || u.Host == "" {
+			return nil, fmt.Errorf("invalid upstream %q: not an absolute URL", raw)
+		}
+		h.upstreams = append(h.upstreams, &upstream{url: u})
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	h.proxy = &httputil.ReverseProxy{
+		Rewrite:        h.rewrite,
+		Transport:      roundTripperFunc(h.roundTrip),
+		ModifyResponse: h.modifyResponse,
+		ErrorHandler:   h.handleError,
+	}
+	return h, nil
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if limit := h.cfg.MaxRequestBodyBytes; limit > 0 {
+		if r.ContentLength > limit {
+			http.Error(w, http.StatusText(http.StatusRequestEntityTooLarge), http.StatusRequestEntityTooLarge)
+			return
+		}
+		r.Body = http.MaxBytesReader(w, r.Body, limit)
+	}
+	h.proxy.ServeHTTP(w, r)
+}
+
+// rewrite strips the prefix from the request path. The upstream is chosen later in [handler.roundTrip], since it may
+// change between retries.
+func (h *handler) rewrite(pr *httputil.ProxyRequest) {
+	pr.SetXForwarded()
+	if h.cfg.StripPrefix == "" {
+		return
+	}
+	u := pr.Out.URL
+	if p, ok := strings.CutPrefix(u.EscapedPath(), h.cfg.StripPrefix); ok {
+		setPath(u, p)
+	}
+}
+
+// roundTrip sends the request to an upstream, and retries on other upstreams if it fails and can be retried.
+func (h *handler) roundTrip(req *http.Request) (*http.Response, error) {
+	retriable := isIdempotent(req.Method) && (req.Body == nil || req.Body == http.NoBody)
+	tried := make([]*upstream, 0, 1)
+	for {
+		u := h.pick(tried)
+		tried = append(tried, u)
+		out := req.Clone(req.Context())
+		out.URL.Scheme = u.url.Scheme
+		out.URL.Host = u.url.Host
+		setPath(out.URL, joinPath(u.url.EscapedPath(), req.URL.EscapedPath()))
+		if !h.cfg.PreserveHost {
+			out.Host = ""
+		}
+
+		resp, err := h.rt.RoundTrip(out)
+		var maxBytesErr *http.MaxBytesError
+		if err != nil && (req.Context().Err() != nil || errors.As(err, &maxBytesErr)) {
+			// The client has gone away or sent a body too large, which is not a failure of the upstream.
+			return nil, err
+		}
+		failed := err != nil || isUnavailable(resp.StatusCode)
+		h.report(u, failed)
+		if !failed || !retriable || len(tried) > h.cfg.MaxRetries {
+			return resp, err
+		}
+		if resp != nil {
+			_, _ = io.Copy(io.Discard, resp.Body)
+			_ = resp.Body.Close()
+		}
+		h.l.WarnContext(req.Context(), "Upstream failed. Retrying...", "upstream", u.url.String(),
+			constant.LogAttrError, failure(resp, err))
+	}
+}
+
+// pick picks the next upstream in round robin, preferring healthy ones that haven't been tried.
+func (h *handler) pick(tried []*upstream) *upstream {
+	n := len(h.upstreams)
+	start := int((h.next.Add(1) - 1) % uint64(n)) // nolint:gosec
+	now := h.clk.Now()
+	var untried *upstream
+	for i := range n {
+		u := h.upstreams[(start+i)%n]
+		if slices.Contains(tried, u) {
+			continue
+		}
+		if u.healthy(now) {
+			return u
+		}
+		if untried == nil {
+			untried = u
+		}
+	}
+	if untried != nil {
+		return untried
+	}
+	return h.upstreams[start]
+}
+
+// report records the result of a request to the upstream.
+func (h *handler) report(u *upstream, failed bool) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if !failed {
+		u.failures = 0
+		return
+	}
+	u.failures++
+	if h.cfg.FailureThreshold > 0 && u.failures >= h.cfg.FailureThreshold {
+		if u.failures == h.cfg.FailureThreshold {
+			h.l.Warn("Upstream is unhealthy.", "upstream", u.url.String())
+		}
+		u.unhealthyUntil = h.clk.Now().Add(time.Duration(h.cfg.CooldownMs) * time.Millisecond)
+	}
+}
+
+// modifyResponse limits the size of the response body.
+func (h *handler) modifyResponse(resp *http.Response) error {
+	limit := h.cfg.MaxResponseBodyBytes
+	if limit <= 0 {
+		return nil
+	}
+	if resp.ContentLength > limit {
+		return ErrResponseTooLarge
+	}
+	resp.Body = &limitedBody{resp.Body, limit}
+	return nil
+}
+
+// handleError responds to errors of upstreams.
+func (h *handler) handleError(w http.ResponseWriter, r *http.Request, err error) {
+	var maxBytesErr *http.MaxBytesError
+	switch {
+	case errors.As(err, &maxBytesErr):
+		http.Error(w, http.StatusText(http.StatusRequestEntityTooLarge), http.StatusRequestEntityTooLarge)
+	case errors.Is(err, context.Canceled) && r.Context().Err() != nil:
+		// The client has gone away, so there is no one to respond to.
+	default:
+		h.l.ErrorContext(r.Context(), "Proxy request failed.", constant.LogAttrError, err)
+		http.Error(w, http.StatusText(http.StatusBadGateway), http.StatusBadGateway)
+	}
+}
+
+// healthy reports whether the upstream is healthy at now.
+func (u *upstream) healthy(now time.Time) bool {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return !now.Before(u.unhealthyUntil)
+}
+
+// limitedBody is a response body that fails after reading more than n bytes.
+type limitedBody struct {
+	io.ReadCloser
+	n int64
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.n < 0 {
+		return 0, ErrResponseTooLarge
+	}
+	// Read one more byte than the limit to detect bodies exceeding it.
+	if int64(len(p)) > b.n+1 {
+		p = p[:b.n+1]
+	}
+	n, err := b.ReadCloser.Read(p)
+	b.n -= int64(n)
+	if b.n < 0 {
+		return n + int(b.n), ErrResponseTooLarge
+	}
+	return n, err
+}
+
+type roundTripperFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// setPath sets the escaped path of u.
+func setPath(u *url.URL, escaped string) {
+	p, err := url.PathUnescape(escaped)
+	if err != nil {
+		p = escaped
+	}
+	u.Path = p
+	u.RawPath = escaped
+}
+
+// joinPath joins the escaped paths with a single slash like [httputil.NewSingleHostReverseProxy].
+func joinPath(base, p string) string {
+	if base == "" {
+		base = "/"
+	}
+	switch baseSlash, pSlash := strings.HasSuffix(base, "/"), strings.HasPrefix(p, "/"); {
+	case baseSlash && pSlash:
+		return base + p[1:]
+	case !baseSlash && !pSlash:
+		return base + "/" + p
+	default:
+		return base + p
+	}
+}
+
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	default:
+		return false
+	}
+}
+
+func isUnavailable(code int) bool {
+	return code == http.StatusBadGateway || code == http.StatusServiceUnavailable || code == http.StatusGatewayTimeout
+}
+
+// failure returns the error of a failed attempt.
+func failure(resp *http.Response, err error) error {
+	if err != nil {
+		return err
+	}
+	return fmt.Errorf("unexpected status %s", resp.Status)
+}
@@ -0,0 +1,207 @@
+package proxy_test
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sainnhe/go-common/pkg/clock/testclock"
+	"github.com/sainnhe/go-common/pkg/constant"
+	"github.com/sainnhe/go-common/pkg/proxy"
+)
+
+func TestNewHandler(t *testing.T) {
+	t.Parallel()
+
+	if _, err := proxy.NewHandler(nil); !errors.Is(err, constant.ErrNilDeps) {
+		t.Fatalf("Expect error %+v, got %+v", constant.ErrNilDeps, err)
+	}
+	if _, err := proxy.NewHandler(&proxy.Config{}); !errors.Is(err, proxy.ErrNoUpstreams) {
+		t.Fatalf("Expect error %+v, got %+v", proxy.ErrNoUpstreams, err)
+	}
+	if _, err := proxy.NewHandler(&proxy.Config{Upstreams: []string{"localhost"}}); err == nil {
+		t.Fatal("Expect error for relative upstream")
+	}
+}
+
+func TestHandler_rewrite(t *testing.T) {
+	t.Parallel()
+
+	var got *http.Request
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		_, _ = io.WriteString(w, "ok")
+	}))
+	defer upstream.Close()
+	upstreamURL, _ := url.Parse(upstream.URL)
+
+	tests := []struct {
+		name         string
+		preserveHost bool
+		path         string
+		wantPath     string
+		wantHost     string
+	}{
+		{"strip prefix", false, "/api/users?page=2", "/v1/users", upstreamURL.Host},
+		{"escaped path", false, "/api/a%2Fb", "/v1/a%2Fb", upstreamURL.Host},
+		{"no prefix", false, "/users", "/v1/users", upstreamURL.Host},
+		{"preserve host", true, "/api/", "/v1/", "example.com"},
+	}
+	for _, tt := range tests {
+		h, err := proxy.NewHandler(&proxy.Config{
+			Upstreams:    []string{upstream.URL + "/v1"},
+			StripPrefix:  "/api",
+			PreserveHost: tt.preserveHost,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "http://example.com"+tt.path, nil))
+		if w.Code != http.StatusOK || w.Body.String() != "ok" {
+			t.Fatalf("[%s] Unexpected response %d %s", tt.name, w.Code, w.Body.String())
+		}
+		if got.URL.EscapedPath() != tt.wantPath || got.Host != tt.wantHost {
+			t.Fatalf("[%s] Expect %s %s, got %s %s", tt.name, tt.wantHost, tt.wantPath, got.Host,
+				got.URL.EscapedPath())
+		}
+		if _, query, _ := strings.Cut(tt.path, "?"); got.URL.RawQuery != query {
+			t.Fatalf("[%s] Expect query %s, got %s", tt.name, query, got.URL.RawQuery)
+		}
+		if got.Header.Get("X-Forwarded-Host") != "example.com" {
+			t.Fatalf("[%s] Unexpected X-Forwarded-Host %s", tt.name, got.Header.Get("X-Forwarded-Host"))
+		}
+	}
+}
+
+func TestHandler_retry(t *testing.T) {
+	t.Parallel()
+
+	hits := atomic.Int64{}
+	bad := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		hits.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer bad.Close()
+	h, err := proxy.NewHandler(&proxy.Config{Upstreams: []string{bad.URL}, MaxRetries: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Idempotent requests are retried until MaxRetries.
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if w.Code != http.StatusServiceUnavailable || hits.Load() != 3 {
+		t.Fatalf("Expect 503 after 3 attempts, got %d after %d attempts", w.Code, hits.Load())
+	}
+
+	// Non-idempotent requests are not retried.
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", strings.NewReader("hello")))
+	if w.Code != http.StatusServiceUnavailable || hits.Load() != 4 {
+		t.Fatalf("Expect 503 after 1 attempt, got %d after %d attempts", w.Code, hits.Load()-3)
+	}
+
+	// Unreachable upstreams are retried too.
+	unreachable := httptest.NewServer(http.NotFoundHandler())
+	unreachable.Close()
+	h, err = proxy.NewHandler(&proxy.Config{Upstreams: []string{unreachable.URL}, MaxRetries: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if w.Code != http.StatusBadGateway {
+		t.Fatalf("Expect 502, got %d", w.Code)
+	}
+}
+
+func TestHandler_health(t *testing.T) {
+	t.Parallel()
+
+	badHits := atomic.Int64{}
+	bad := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		badHits.Add(1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer bad.Close()
+	good := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer good.Close()
+	clk := testclock.Freeze(time.Now())
+	h, err := proxy.NewHandler(&proxy.Config{
+		Upstreams:        []string{bad.URL, good.URL},
+		MaxRetries:       1,
+		FailureThreshold: 2,
+		CooldownMs:       1000,
+	}, proxy.WithClock(clk))
+	if err != nil {
+		t.Fatal(err)
+	}
+	send := func() {
+		for range 10 {
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+			if w.Code != http.StatusNoContent {
+				t.Fatalf("Expect 204, got %d", w.Code)
+			}
+		}
+	}
+
+	// The bad upstream is skipped after FailureThreshold failures.
+	send()
+	if badHits.Load() != 2 {
+		t.Fatalf("Expect 2 hits, got %d", badHits.Load())
+	}
+
+	// It's tried again after the cooldown.
+	clk.Advance(time.Second)
+	send()
+	if badHits.Load() != 3 {
+		t.Fatalf("Expect 3 hits, got %d", badHits.Load())
+	}
+}
+
+func TestHandler_bodyLimit(t *testing.T) {
+	t.Parallel()
+
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(w, r.Body)
+	}))
+	defer upstream.Close()
+	h, err := proxy.NewHandler(&proxy.Config{
+		Upstreams:            []string{upstream.URL},
+		MaxRequestBodyBytes:  8,
+		MaxResponseBodyBytes: 4,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		body     io.Reader
+		wantCode int
+		wantBody string
+	}{
+		{"small", strings.NewReader("ok"), http.StatusOK, "ok"},
+		{"large request", strings.NewReader("hello world"), http.StatusRequestEntityTooLarge, ""},
+		{"large streamed request", io.MultiReader(strings.NewReader("hello world")), http.StatusRequestEntityTooLarge,
+			""},
+		{"large response", strings.NewReader("hello"), http.StatusBadGateway, ""},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", tt.body))
+		if w.Code != tt.wantCode || (tt.wantBody != "" && w.Body.String() != tt.wantBody) {
+			t.Fatalf("[%s] Expect %d %s, got %d %s", tt.name, tt.wantCode, tt.wantBody, w.Code, w.Body.String())
+		}
+	}
+}
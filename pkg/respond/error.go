@@ -0,0 +1,52 @@
+package respond
+
+import (
+	"encoding/xml"
+	"errors"
+	"net/http"
+
+	"github.com/sainnhe/go-common/pkg/constant"
+)
+
+// Coder is implemented by errors that carry an application error code, which is written to [ErrorEnvelope.Code].
+type Coder interface {
+	Code() int
+}
+
+// ErrorEnvelope is the body of error responses written by [Error].
+type ErrorEnvelope struct {
+	XMLName xml.Name `json:"-" xml:"error"`
+
+	// Code is the application error code. It's taken from the error if it implements [Coder], otherwise
+	// [constant.ErrCodeUnknown] is used.
+	Code int `json:"code" xml:"code"`
+
+	// Message is the error message.
+	Message string `json:"message" xml:"message"`
+}
+
+/*
+Error writes an [ErrorEnvelope] of err with [Write].
+
+Params:
+  - w: The response writer.
+  - r: The request.
+  - status: The status code.
+  - err: The error. For 5xx statuses, the message is replaced by the status text unless err implements [Coder], so that
+    internal details are not leaked to clients.
+
+Returns:
+  - error: The error of writing the response.
+*/
+func Error(w http.ResponseWriter, r *http.Request, status int, err error) error {
+	e := &ErrorEnvelope{Code: constant.ErrCodeUnknown, Message: http.StatusText(status)}
+	var coder Coder
+	switch {
+	case errors.As(err, &coder):
+		e.Code = coder.Code()
+		e.Message = err.Error()
+	case err != nil && status < http.StatusInternalServerError:
+		e.Message = err.Error()
+	}
+	return Write(w, r, status, e)
+}
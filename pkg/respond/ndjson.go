@@ -0,0 +1,45 @@
+package respond
+
+import (
+	"encoding/json"
+	"iter"
+	"net/http"
+)
+
+/*
+NDJSON streams the items of seq as newline delimited JSON, and flushes the response after each item so that clients can
+process items as soon as they are produced. Streaming stops when the request context is done.
+
+Params:
+  - w: The response writer.
+  - r: The request.
+  - status: The status code.
+  - seq: The items to write.
+
+Returns:
+  - error: The error of encoding or writing an item, or the error of the request context.
+*/
+func NDJSON[T any](w http.ResponseWriter, r *http.Request, status int, seq iter.Seq[T]) error {
+	h := w.Header()
+	h.Set("Content-Type", MediaTypeNDJSON)
+	h.Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	if r.Method == http.MethodHead {
+		return nil
+	}
+
+	ctx := r.Context()
+	rc := http.NewResponseController(w)
+	enc := json.NewEncoder(w)
+	for item := range seq {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := enc.Encode(item); err != nil {
+			return err
+		}
+		// Flushing is best effort since not all response writers support it.
+		_ = rc.Flush()
+	}
+	return nil
+}
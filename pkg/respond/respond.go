@@ -0,0 +1,166 @@
+/*
+Package respond implements helpers that write HTTP responses, including content negotiation, error envelopes and
+streaming NDJSON responses.
+
+[Write] chooses JSON or XML according to the Accept header of the request, and [Error] writes an [ErrorEnvelope] in
+the same way:
+
+	func getUser(w http.ResponseWriter, r *http.Request) {
+		u, err := s.GetUser(r.Context(), id)
+		if err != nil {
+			respond.Error(w, r, http.StatusNotFound, err)
+			return
+		}
+		respond.Write(w, r, http.StatusOK, u)
+	}
+*/
+package respond
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Media types supported by [Write].
+const (
+	MediaTypeJSON   = "application/json"
+	MediaTypeXML    = "application/xml"
+	MediaTypeNDJSON = "application/x-ndjson"
+)
+
+// offers are the media types that [Write] can produce, where the first one is the default.
+var offers = []string{MediaTypeJSON, MediaTypeXML}
+
+/*
+Negotiate chooses the best media type from offers according to the Accept header of r, following the quality values
+and the specificity of media ranges like "application/*".
+
+Params:
+  - r: The request.
+  - offers: The media types that can be produced, in the order of preference.
+
+Returns:
+  - string: The chosen media type. If the Accept header is missing, the first offer is returned. If no offer is
+    acceptable, an empty string is returned.
+*/
+func Negotiate(r *http.Request, offers ...string) string {
+	if len(offers) == 0 {
+		return ""
+	}
+	accept := r.Header.Values("Accept")
+	if len(accept) == 0 {
+		return offers[0]
+	}
+
+	best, bestQ := "", 0.0
+	for _, offer := range offers {
+		// q is the quality of the most specific media range that matches offer.
+		q, specificity := 0.0, -1
+		for _, value := range accept {
+			for mediaRange := range strings.SplitSeq(value, ",") {
+				typ, rangeQ := parseMediaRange(mediaRange)
+				if s := matchMediaRange(typ, offer); s > specificity {
+					q, specificity = rangeQ, s
+				}
+			}
+		}
+		if q > bestQ {
+			best, bestQ = offer, q
+		}
+	}
+	return best
+}
+
+// parseMediaRange parses a media range in the Accept header, and returns the media type and the quality.
+func parseMediaRange(mediaRange string) (string, float64) {
+	typ, params, _ := strings.Cut(mediaRange, ";")
+	q := 1.0
+	for param := range strings.SplitSeq(params, ";") {
+		key, val, _ := strings.Cut(strings.TrimSpace(param), "=")
+		if key == "q" {
+			if v, err := strconv.ParseFloat(val, 64); err == nil {
+				q = v
+			}
+		}
+	}
+	return strings.ToLower(strings.TrimSpace(typ)), q
+}
+
+// matchMediaRange returns the specificity of typ if it matches offer, or -1 if not.
+func matchMediaRange(typ, offer string) int {
+	switch {
+	case typ == offer:
+		return 2 // nolint:mnd
+	case typ == "*/*":
+		return 0
+	case strings.HasSuffix(typ, "/*") && strings.HasPrefix(offer, typ[:len(typ)-1]):
+		return 1
+	default:
+		return -1
+	}
+}
+
+/*
+Write writes v in JSON or XML according to the Accept header of r. JSON is used if both are acceptable or the Accept
+header is missing, and 406 Not Acceptable is written if neither is acceptable.
+
+Params:
+  - w: The response writer.
+  - r: The request.
+  - status: The status code.
+  - v: The value to write.
+
+Returns:
+  - error: The error of encoding or writing the response, which usually can only be logged since the status has been
+    written.
+*/
+func Write(w http.ResponseWriter, r *http.Request, status int, v any) error {
+	switch Negotiate(r, offers...) {
+	case MediaTypeJSON:
+		return JSON(w, r, status, v)
+	case MediaTypeXML:
+		return XML(w, r, status, v)
+	default:
+		w.Header().Set("Accept", strings.Join(offers, ", "))
+		http.Error(w, http.StatusText(http.StatusNotAcceptable), http.StatusNotAcceptable)
+		return nil
+	}
+}
+
+// JSON writes v in JSON. The body is omitted for HEAD requests.
+func JSON(w http.ResponseWriter, r *http.Request, status int, v any) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return err
+	}
+	return writeBody(w, r, status, MediaTypeJSON+"; charset=utf-8", b)
+}
+
+// XML writes v in XML with the XML header. The body is omitted for HEAD requests.
+func XML(w http.ResponseWriter, r *http.Request, status int, v any) error {
+	b, err := xml.Marshal(v)
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return err
+	}
+	return writeBody(w, r, status, MediaTypeXML+"; charset=utf-8", slices.Concat([]byte(xml.Header), b))
+}
+
+// writeBody writes the headers and the body.
+func writeBody(w http.ResponseWriter, r *http.Request, status int, contentType string, b []byte) error {
+	h := w.Header()
+	h.Set("Content-Type", contentType)
+	h.Set("Content-Length", strconv.Itoa(len(b)))
+	h.Add("Vary", "Accept")
+	w.WriteHeader(status)
+	if r.Method == http.MethodHead {
+		return nil
+	}
+	_, err := w.Write(b)
+	return err
+}
@@ -0,0 +1,162 @@
+package respond_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/sainnhe/go-common/pkg/respond"
+)
+
+type user struct {
+	Name string `json:"name" xml:"name"`
+}
+
+type codeError struct{}
+
+func (codeError) Error() string {
+	return "user not found"
+}
+
+func (codeError) Code() int {
+	return 10001 // nolint:mnd
+}
+
+func newRequest(method, accept string) *http.Request {
+	r := httptest.NewRequestWithContext(context.Background(), method, "/", nil)
+	if len(accept) > 0 {
+		r.Header.Set("Accept", accept)
+	}
+	return r
+}
+
+func TestNegotiate(t *testing.T) {
+	t.Parallel()
+
+	offers := []string{respond.MediaTypeJSON, respond.MediaTypeXML}
+	tests := []struct {
+		accept string
+		want   string
+	}{
+		{"", respond.MediaTypeJSON},
+		{"*/*", respond.MediaTypeJSON},
+		{"application/xml", respond.MediaTypeXML},
+		{"text/html, application/xml;q=0.9, */*;q=0.8", respond.MediaTypeXML},
+		{"application/json;q=0.5, application/xml", respond.MediaTypeXML},
+		{"application/*, application/json;q=0", respond.MediaTypeXML},
+		{"text/html", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.accept, func(t *testing.T) {
+			t.Parallel()
+
+			if got := respond.Negotiate(newRequest(http.MethodGet, tt.accept), offers...); got != tt.want {
+				t.Fatalf("Want %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestWrite(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		method string
+		accept string
+		status int
+		body   string
+	}{
+		{"JSON", http.MethodGet, "", http.StatusOK, `{"name":"Alice"}`},
+		{"XML", http.MethodGet, "application/xml", http.StatusOK,
+			`<?xml version="1.0" encoding="UTF-8"?>` + "\n<user><name>Alice</name></user>"},
+		{"HEAD", http.MethodHead, "", http.StatusOK, ""},
+		{"Not acceptable", http.MethodGet, "text/html", http.StatusNotAcceptable, "Not Acceptable\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			w := httptest.NewRecorder()
+			if err := respond.Write(w, newRequest(tt.method, tt.accept), http.StatusOK, &user{"Alice"}); err != nil {
+				t.Fatal(err)
+			}
+			if w.Code != tt.status || w.Body.String() != tt.body {
+				t.Fatalf("Unexpected response %d %s", w.Code, w.Body)
+			}
+		})
+	}
+
+	w := httptest.NewRecorder()
+	if err := respond.JSON(w, newRequest(http.MethodGet, ""), http.StatusOK, make(chan int)); err == nil ||
+		w.Code != http.StatusInternalServerError {
+		t.Fatalf("Unexpected response %d %+v", w.Code, err)
+	}
+}
+
+func TestError(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		status int
+		err    error
+		want   string
+	}{
+		{"Client error", http.StatusBadRequest, errors.New("invalid name"), `{"code":1,"message":"invalid name"}`},
+		{"Server error", http.StatusInternalServerError, errors.New("dial tcp: refused"),
+			`{"code":1,"message":"Internal Server Error"}`},
+		{"Coder", http.StatusNotFound, codeError{}, `{"code":10001,"message":"user not found"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			w := httptest.NewRecorder()
+			if err := respond.Error(w, newRequest(http.MethodGet, ""), tt.status, tt.err); err != nil {
+				t.Fatal(err)
+			}
+			if w.Code != tt.status || w.Body.String() != tt.want {
+				t.Fatalf("Unexpected response %d %s", w.Code, w.Body)
+			}
+		})
+	}
+
+	w := httptest.NewRecorder()
+	if err := respond.Error(w, newRequest(http.MethodGet, "application/xml"), http.StatusNotFound,
+		codeError{}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(w.Body.String(), "<error><code>10001</code><message>user not found</message></error>") {
+		t.Fatalf("Unexpected response %s", w.Body)
+	}
+}
+
+func TestNDJSON(t *testing.T) {
+	t.Parallel()
+
+	w := httptest.NewRecorder()
+	users := []user{{"Alice"}, {"Bob"}}
+	if err := respond.NDJSON(w, newRequest(http.MethodGet, ""), http.StatusOK, slices.Values(users)); err != nil {
+		t.Fatal(err)
+	}
+	if w.Header().Get("Content-Type") != respond.MediaTypeNDJSON || !w.Flushed ||
+		w.Body.String() != "{\"name\":\"Alice\"}\n{\"name\":\"Bob\"}\n" {
+		t.Fatalf("Unexpected response %v %s", w.Header(), w.Body)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	r := httptest.NewRequestWithContext(ctx, http.MethodGet, "/", nil)
+	if err := respond.NDJSON(httptest.NewRecorder(), r, http.StatusOK, slices.Values(users)); !errors.Is(err,
+		context.Canceled) {
+		t.Fatalf("Want %+v, got %+v", context.Canceled, err)
+	}
+}
@@ -0,0 +1,32 @@
+package retention
+
+// Config defines the config model for retention.
+type Config struct {
+	// Schedule is the cron expression of purging, see the schedule package for the syntax. Set to an empty string to
+	// disable scheduled purging, in which case [Service.Purge] should be called manually.
+	Schedule string `json:"schedule" yaml:"schedule" toml:"schedule" xml:"schedule" env:"RETENTION_SCHEDULE" default:"@daily"` // nolint:lll
+
+	// DryRun indicates whether to only count the rows to be purged without deleting them.
+	DryRun bool `json:"dry_run" yaml:"dry_run" toml:"dry_run" xml:"dry_run" env:"RETENTION_DRY_RUN" default:"false"`
+
+	// BatchSize is the maximum number of rows deleted by a statement. Rows are deleted in batches ordered by the "id"
+	// column, so tables must have an auto-incremented "id" column unless it's set to 0, in which case the rows of a
+	// table are deleted by a single statement.
+	BatchSize int `json:"batch_size" yaml:"batch_size" toml:"batch_size" xml:"batch_size" env:"RETENTION_BATCH_SIZE" default:"1000"` // nolint:lll
+
+	// Rules are the retention rules keyed by table names.
+	Rules map[string]Rule `json:"rules" yaml:"rules" toml:"rules" xml:"rules" env:"RETENTION_RULES" default:"{}"`
+}
+
+// Rule defines how long rows of a table are retained. Rows matching any of the limits are purged.
+type Rule struct {
+	// MaxAgeMs is the maximum age of rows in milliseconds. Set to 0 to disable it.
+	MaxAgeMs int64 `json:"max_age_ms" yaml:"max_age_ms" toml:"max_age_ms" xml:"max_age_ms"`
+
+	// TimeColumn is the column used to compute the age of rows. Defaults to "create_time".
+	TimeColumn string `json:"time_column" yaml:"time_column" toml:"time_column" xml:"time_column"`
+
+	// MaxCount is the maximum number of rows, beyond which the rows with the smallest IDs are purged. The table must
+	// have an auto-incremented "id" column. Set to 0 to disable it.
+	MaxCount int64 `json:"max_count" yaml:"max_count" toml:"max_count" xml:"max_count"`
+}
@@ -0,0 +1,304 @@
+//go:generate mockgen -write_package_comment=false -source=retention.go -destination=retention_mock.go -package retention
+
+/*
+Package retention enforces data retention policies by purging old rows from database tables.
+
+Rules are defined per table in [Config], for example in YAML:
+
+	schedule: "0 3 * * *"
+	dry_run: false
+	rules:
+	  audit_logs:
+	    max_age_ms: 7776000000 # 90 days
+	  notifications:
+	    max_age_ms: 2592000000 # 30 days
+	    time_column: read_time
+	    max_count: 1000000
+
+Rows are purged on the schedule in background, or manually via [Service.Purge]. In dry-run mode, the rows to be purged
+are counted but not deleted, which is useful to verify new rules before enabling them. Rows are deleted in batches of
+[Config.BatchSize] ordered by the "id" column, so that purging a large table doesn't hold locks for long or produce a
+huge transaction. The number of purged rows is recorded in the [MetricPurged] counter.
+*/
+package retention
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/sainnhe/go-common/pkg/clock"
+	"github.com/sainnhe/go-common/pkg/constant"
+	"github.com/sainnhe/go-common/pkg/db"
+	"github.com/sainnhe/go-common/pkg/log"
+	"github.com/sainnhe/go-common/pkg/schedule"
+	"github.com/sainnhe/go-common/pkg/util"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+const (
+	pkgName = "github.com/sainnhe/go-common/pkg/retention"
+
+	// MetricPurged is the name of the counter of purged rows, with the attributes "table" and "dry_run".
+	MetricPurged = "retention.purged"
+
+	defaultTimeColumn = "create_time"
+)
+
+// ErrInvalidRule indicates that a retention rule is invalid.
+var ErrInvalidRule = errors.New("invalid retention rule")
+
+// Result is the result of purging a table.
+type Result struct {
+	// Table is the table name.
+	Table string
+
+	// Rows is the number of purged rows, or the number of rows to be purged in dry-run mode.
+	Rows int64
+}
+
+// Service is the retention service.
+type Service interface {
+	// Purge purges the rows of all tables that exceed their retention rules, and returns the results in the order of
+	// table names. Tables are purged independently, so the results of succeeded tables are returned along with the
+	// joined errors of failed ones.
+	Purge(ctx context.Context) ([]Result, error)
+}
+
+type serviceImpl struct {
+	pool    *sqlx.DB
+	cfg     *Config
+	clk     clock.Clock
+	l       *slog.Logger
+	purged  metric.Int64Counter
+	purgers []*purger
+}
+
+// purger purges a table.
+type purger struct {
+	table      string
+	rule       Rule
+	sb         db.StmtBuilder
+	cutoffStmt string
+}
+
+// Option is the option used to customize the retention service.
+type Option func(s *serviceImpl)
+
+// WithClock sets the clock used to compute ages and wait for scheduled runs. Defaults to [clock.Real].
+func WithClock(clk clock.Clock) Option {
+	return func(s *serviceImpl) {
+		if clk != nil {
+			s.clk = clk
+		}
+	}
+}
+
+/*
+NewService initializes a new retention service.
+
+Params:
+  - cfg: The config.
+  - pool: The connection pool.
+  - opts: The options.
+
+Returns:
+  - Service: The retention service.
+  - func(): The cleanup function that stops scheduled purging, which is a no-op if [Config.Schedule] is empty. It's safe
+    to call it multiple times.
+  - error: [constant.ErrNilDeps] if any dependency is nil, [db.ErrUnsupportedDriver] if the driver is not supported,
+    [schedule.ErrInvalidExpr] if the schedule is invalid, or an error wrapping [ErrInvalidRule] if any rule is invalid.
+*/
+func NewService(cfg *Config, pool *sqlx.DB, opts ...Option) (s Service, cleanup func(), err error) {
+	if cfg == nil || pool == nil {
+		err = constant.ErrNilDeps
+		return
+	}
+	impl := &serviceImpl{
+		pool: pool,
+		cfg:  cfg,
+		clk:  clock.Real(),
+		l:    log.NewLogger(pkgName),
+	}
+	for _, opt := range opts {
+		opt(impl)
+	}
+	if impl.purged, err = otel.Meter(pkgName).Int64Counter(MetricPurged,
+		metric.WithDescription("The number of rows purged by retention rules."),
+		metric.WithUnit("{row}")); err != nil {
+		return
+	}
+
+	tables := make([]string, 0, len(cfg.Rules))
+	for table := range cfg.Rules {
+		tables = append(tables, table)
+	}
+	slices.Sort(tables)
+	for _, table := range tables {
+		rule := cfg.Rules[table]
+		if rule.MaxAgeMs < 0 || rule.MaxCount < 0 || (rule.MaxAgeMs == 0 && rule.MaxCount == 0) {
+			err = fmt.Errorf("%w: %s: no positive max_age_ms or max_count", ErrInvalidRule, table)
+			return
+		}
+		if rule.TimeColumn == "" {
+			rule.TimeColumn = defaultTimeColumn
+		}
+		sb := db.NewStmtBuilder(table, pool.DriverName())
+		if sb == nil {
+			err = db.ErrUnsupportedDriver
+			return
+		}
+		impl.purgers = append(impl.purgers, &purger{
+			table,
+			rule,
+			sb,
+			sb.BuildQueryCondStmt([]string{"id"}, nil, db.WithOrderBy(db.Desc("id")), db.WithLimit(1),
+				db.WithOffset(int(rule.MaxCount))),
+		})
+	}
+
+	cleanup = func() {}
+	if cfg.Schedule != "" {
+		var sched *schedule.Schedule
+		if sched, err = schedule.Parse(cfg.Schedule); err != nil {
+			return
+		}
+		stop := make(chan struct{})
+		done := make(chan struct{})
+		go impl.run(sched, stop, done)
+		var once sync.Once
+		cleanup = func() {
+			once.Do(func() { close(stop) })
+			<-done
+		}
+	}
+	s = impl
+	return
+}
+
+func (s *serviceImpl) Purge(ctx context.Context) ([]Result, error) {
+	results := make([]Result, 0, len(s.purgers))
+	errs := []error{}
+	for _, p := range s.purgers {
+		n, err := s.purge(ctx, p)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("purge %s: %w", p.table, err))
+			continue
+		}
+		results = append(results, Result{p.table, n})
+		s.purged.Add(ctx, n, metric.WithAttributes(
+			attribute.String("table", p.table),
+			attribute.Bool("dry_run", s.cfg.DryRun),
+		))
+	}
+	return results, errors.Join(errs...)
+}
+
+// purge purges the rows of a table that exceed the rule, or counts them in dry-run mode.
+func (s *serviceImpl) purge(ctx context.Context, p *purger) (int64, error) {
+	conds := []db.Cond{}
+	args := []any{}
+	if p.rule.MaxAgeMs > 0 {
+		conds = append(conds, db.Cmp(p.rule.TimeColumn, "<", db.Placeholder))
+		args = append(args, s.clk.Now().Add(-time.Duration(p.rule.MaxAgeMs)*time.Millisecond))
+	}
+	if p.rule.MaxCount > 0 {
+		// The ID of the newest row beyond the limit, which doesn't exist if the limit is not exceeded.
+		var cutoff int64
+		err := s.pool.GetContext(ctx, &cutoff, p.cutoffStmt)
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+		case err != nil:
+			return 0, err
+		default:
+			conds = append(conds, db.Cmp("id", "<=", db.Placeholder))
+			args = append(args, cutoff)
+		}
+	}
+	if len(conds) == 0 {
+		return 0, nil
+	}
+
+	cond := db.Or(conds...)
+	if s.cfg.DryRun {
+		var n int64
+		err := s.pool.GetContext(ctx, &n, p.sb.BuildQueryCondStmt([]string{db.Count("*")}, cond), args...)
+		return n, err
+	}
+	if s.cfg.BatchSize <= 0 {
+		result, err := s.pool.ExecContext(ctx, p.sb.BuildDeleteCondStmt(cond), args...)
+		if err != nil {
+			return 0, err
+		}
+		return result.RowsAffected()
+	}
+
+	// Delete in batches of ascending IDs, so that each statement only locks a bounded number of rows.
+	boundStmt := p.sb.BuildQueryCondStmt([]string{"id"}, cond, db.WithOrderBy(db.Asc("id")), db.WithLimit(1),
+		db.WithOffset(s.cfg.BatchSize-1))
+	batchStmt := p.sb.BuildDeleteCondStmt(db.And(cond, db.Cmp("id", "<=", db.Placeholder)))
+	lastStmt := p.sb.BuildDeleteCondStmt(cond)
+	var total int64
+	for {
+		if err := ctx.Err(); err != nil {
+			return total, err
+		}
+		// The ID of the last row in the batch, which doesn't exist if fewer rows are left.
+		var bound int64
+		stmt, stmtArgs, last := batchStmt, append(slices.Clone(args), 0), false
+		err := s.pool.GetContext(ctx, &bound, boundStmt, args...)
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			stmt, stmtArgs, last = lastStmt, args, true
+		case err != nil:
+			return total, err
+		default:
+			stmtArgs[len(stmtArgs)-1] = bound
+		}
+		result, err := s.pool.ExecContext(ctx, stmt, stmtArgs...)
+		if err != nil {
+			return total, err
+		}
+		n, err := result.RowsAffected()
+		if err != nil {
+			return total, err
+		}
+		total += n
+		if last || n == 0 {
+			return total, nil
+		}
+	}
+}
+
+// run purges tables on the schedule until stop is closed.
+func (s *serviceImpl) run(sched *schedule.Schedule, stop <-chan struct{}, done chan<- struct{}) {
+	defer close(done)
+	defer util.Recover()
+	for {
+		now := s.clk.Now()
+		next := sched.Next(now)
+		if next.IsZero() {
+			s.l.Warn("No next run time of the retention schedule. Stopping...")
+			return
+		}
+		select {
+		case <-stop:
+			return
+		case <-s.clk.After(next.Sub(now)):
+		}
+		results, err := s.Purge(context.Background())
+		if err != nil {
+			s.l.Error("Purge tables failed.", constant.LogAttrError, err, constant.LogAttrResult, results)
+			continue
+		}
+		s.l.Info("Tables purged.", constant.LogAttrResult, results, "dry_run", s.cfg.DryRun)
+	}
+}
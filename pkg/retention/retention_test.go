@@ -0,0 +1,158 @@
+package retention_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/sainnhe/go-common/pkg/clock/testclock"
+	"github.com/sainnhe/go-common/pkg/constant"
+	"github.com/sainnhe/go-common/pkg/db"
+	"github.com/sainnhe/go-common/pkg/db/dbtest"
+	"github.com/sainnhe/go-common/pkg/retention"
+	"github.com/sainnhe/go-common/pkg/schedule"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+const ddl = `CREATE TABLE %s (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	create_time DATETIME NOT NULL,
+	read_time DATETIME NOT NULL
+)`
+
+func TestNewService(t *testing.T) {
+	t.Parallel()
+
+	if _, _, err := retention.NewService(nil, nil); !errors.Is(err, constant.ErrNilDeps) {
+		t.Fatalf("Expect error %+v, got %+v", constant.ErrNilDeps, err)
+	}
+	pool := sqlx.NewDb(&sql.DB{}, "mysql")
+	tests := []struct {
+		cfg  *retention.Config
+		pool *sqlx.DB
+		err  error
+	}{
+		{&retention.Config{Rules: map[string]retention.Rule{"logs": {}}}, pool, retention.ErrInvalidRule},
+		{&retention.Config{Rules: map[string]retention.Rule{"logs": {MaxAgeMs: -1}}}, pool, retention.ErrInvalidRule},
+		{&retention.Config{Rules: map[string]retention.Rule{"logs": {MaxCount: 1}}}, sqlx.NewDb(&sql.DB{}, "unknown"),
+			db.ErrUnsupportedDriver},
+		{&retention.Config{Schedule: "* *"}, pool, schedule.ErrInvalidExpr},
+	}
+	for _, tt := range tests {
+		if _, _, err := retention.NewService(tt.cfg, tt.pool); !errors.Is(err, tt.err) {
+			t.Fatalf("Expect error %+v, got %+v", tt.err, err)
+		}
+	}
+	s, cleanup, err := retention.NewService(&retention.Config{Schedule: "@daily"}, pool)
+	if s == nil || cleanup == nil || err != nil {
+		t.Fatalf("Expect service and cleanup, got err = %+v", err)
+	}
+	cleanup()
+	cleanup()
+}
+
+func TestService(t *testing.T) { // nolint:paralleltest
+	reader := sdkmetric.NewManualReader()
+	otel.SetMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)))
+
+	pool := dbtest.NewPool(t, fmt.Sprintf(ddl, "logs"), fmt.Sprintf(ddl, "notifications"))
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	ctx := context.Background()
+	// Insert a row per day in the last 10 days, where read times are 5 days before create times.
+	for _, table := range []string{"logs", "notifications"} {
+		for i := 10; i > 0; i-- {
+			created := now.AddDate(0, 0, -i)
+			if _, err := pool.ExecContext(ctx, "INSERT INTO "+table+" (create_time, read_time) VALUES (?, ?)", created,
+				created.AddDate(0, 0, -5)); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	count := func(table string) int64 {
+		t.Helper()
+
+		var n int64
+		if err := pool.GetContext(ctx, &n, "SELECT COUNT(*) FROM "+table); err != nil {
+			t.Fatal(err)
+		}
+		return n
+	}
+
+	clk := testclock.Freeze(now.Add(-time.Minute))
+	cfg := &retention.Config{
+		Schedule:  "@daily",
+		DryRun:    true,
+		BatchSize: 3,
+		Rules: map[string]retention.Rule{
+			// Rows created more than 3.5 days ago, i.e. 7 rows.
+			"logs": {MaxAgeMs: (84 * time.Hour).Milliseconds()},
+			// Rows read more than 8.5 days ago, i.e. 7 rows, or beyond the newest 2 rows, i.e. 8 rows.
+			"notifications": {MaxAgeMs: (204 * time.Hour).Milliseconds(), TimeColumn: "read_time", MaxCount: 2},
+		},
+	}
+	s, cleanup, err := retention.NewService(cfg, pool, retention.WithClock(clk))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanup()
+
+	// Dry run
+	want := []retention.Result{{"logs", 7}, {"notifications", 8}}
+	results, err := s.Purge(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(results, want) {
+		t.Fatalf("Expect results %+v, got %+v", want, results)
+	}
+	if count("logs") != 10 || count("notifications") != 10 {
+		t.Fatal("Expect no rows to be deleted in dry-run mode")
+	}
+
+	// Scheduled run at midnight.
+	cfg.DryRun = false
+	clk.BlockUntil(1)
+	clk.Advance(time.Minute)
+	clk.BlockUntil(1)
+	if count("logs") != 3 || count("notifications") != 2 {
+		t.Fatalf("Expect 3 and 2 rows, got %d and %d", count("logs"), count("notifications"))
+	}
+
+	// Nothing to purge.
+	want = []retention.Result{{"logs", 0}, {"notifications", 0}}
+	if results, err = s.Purge(ctx); err != nil || !reflect.DeepEqual(results, want) {
+		t.Fatalf("Expect results %+v, got %+v, err = %+v", want, results, err)
+	}
+
+	// Metrics
+	rm := metricdata.ResourceMetrics{}
+	if err := reader.Collect(ctx, &rm); err != nil {
+		t.Fatal(err)
+	}
+	purged := map[attribute.Distinct]int64{}
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if m.Name != retention.MetricPurged {
+				continue
+			}
+			for _, dp := range m.Data.(metricdata.Sum[int64]).DataPoints { // nolint:errcheck
+				purged[dp.Attributes.Equivalent()] = dp.Value
+			}
+		}
+	}
+	key := func(table string, dryRun bool) attribute.Distinct {
+		set := attribute.NewSet(attribute.String("table", table), attribute.Bool("dry_run", dryRun))
+		return set.Equivalent()
+	}
+	if purged[key("logs", true)] != 7 || purged[key("logs", false)] != 7 || purged[key("notifications", false)] != 8 {
+		t.Fatalf("Unexpected metrics %+v", purged)
+	}
+}
@@ -0,0 +1,266 @@
+package rules
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// condition is a compiled [Condition].
+type condition struct {
+	Condition
+	path  []string
+	value any
+	re    *regexp.Regexp
+}
+
+func compileCondition(c Condition) (*condition, error) {
+	if c.Fact == "" {
+		return nil, errors.New("empty fact")
+	}
+	cond := &condition{c, strings.Split(c.Fact, "."), normalize(c.Value), nil}
+	cond.Op = strings.ToLower(c.Op)
+	switch cond.Op {
+	case "eq", "ne", "contains":
+	case "lt", "lte", "gt", "gte":
+		_, isNum := toFloat(cond.value)
+		_, isStr := toString(cond.value)
+		if !isNum && !isStr {
+			return nil, fmt.Errorf("%s requires a number or a string, got %v", cond.Op, c.Value)
+		}
+	case "in", "not_in":
+		if _, ok := cond.value.([]any); !ok {
+			return nil, fmt.Errorf("%s requires a list, got %v", cond.Op, c.Value)
+		}
+	case "prefix", "suffix":
+		if _, ok := toString(cond.value); !ok {
+			return nil, fmt.Errorf("%s requires a string, got %v", cond.Op, c.Value)
+		}
+	case "matches":
+		s, ok := toString(cond.value)
+		if !ok {
+			return nil, fmt.Errorf("matches requires a string, got %v", c.Value)
+		}
+		re, err := regexp.Compile(s)
+		if err != nil {
+			return nil, err
+		}
+		cond.re = re
+	case "exists":
+		if _, ok := cond.value.(bool); !ok && cond.value != nil {
+			return nil, fmt.Errorf("exists requires a bool, got %v", c.Value)
+		}
+	default:
+		return nil, fmt.Errorf("unknown operator %q", c.Op)
+	}
+	return cond, nil
+}
+
+// eval evaluates the condition with the fact, where ok reports whether the fact exists.
+func (c *condition) eval(got any, ok bool) bool {
+	if c.Op == "exists" {
+		return ok == (c.value != false)
+	}
+	if !ok {
+		return c.Op == "ne" || c.Op == "not_in"
+	}
+	switch c.Op {
+	case "eq":
+		return equal(got, c.value)
+	case "ne":
+		return !equal(got, c.value)
+	case "lt", "lte", "gt", "gte":
+		cmp, ok := compare(got, c.value)
+		return ok && (c.Op == "lt" && cmp < 0 || c.Op == "lte" && cmp <= 0 || c.Op == "gt" && cmp > 0 ||
+			c.Op == "gte" && cmp >= 0)
+	case "in", "not_in":
+		in := false
+		for _, v := range c.value.([]any) { // nolint:errcheck
+			if equal(got, v) {
+				in = true
+				break
+			}
+		}
+		return in == (c.Op == "in")
+	case "contains":
+		if s, ok := toString(got); ok {
+			sub, ok := toString(c.value)
+			return ok && strings.Contains(s, sub)
+		}
+		v := reflect.ValueOf(got)
+		if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+			return false
+		}
+		for i := range v.Len() {
+			if equal(v.Index(i).Interface(), c.value) {
+				return true
+			}
+		}
+		return false
+	case "prefix", "suffix":
+		s, ok := toString(got)
+		want, _ := toString(c.value)
+		return ok && (c.Op == "prefix" && strings.HasPrefix(s, want) || c.Op == "suffix" && strings.HasSuffix(s, want))
+	default: // matches
+		s, ok := toString(got)
+		return ok && c.re.MatchString(s)
+	}
+}
+
+// equal reports whether a and b are equal, where numbers and strings of different types are compared by value.
+func equal(a, b any) bool {
+	if af, ok := toFloat(a); ok {
+		bf, ok := toFloat(b)
+		return ok && af == bf
+	}
+	if as, ok := toString(a); ok {
+		bs, ok := toString(b)
+		return ok && as == bs
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// compare compares a with b, and reports whether they are comparable.
+func compare(a, b any) (int, bool) {
+	if t, ok := a.(time.Time); ok {
+		s, ok := toString(b)
+		if !ok {
+			return 0, false
+		}
+		bt, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			return 0, false
+		}
+		return t.Compare(bt), true
+	}
+	if af, ok := toFloat(a); ok {
+		bf, ok := toFloat(b)
+		if !ok {
+			return 0, false
+		}
+		switch {
+		case af < bf:
+			return -1, true
+		case af > bf:
+			return 1, true
+		default:
+			return 0, true
+		}
+	}
+	as, aok := toString(a)
+	bs, bok := toString(b)
+	return strings.Compare(as, bs), aok && bok
+}
+
+func toFloat(v any) (float64, bool) {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return float64(rv.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), true
+	default:
+		return 0, false
+	}
+}
+
+func toString(v any) (string, bool) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.String {
+		return "", false
+	}
+	return rv.String(), true
+}
+
+// resolve resolves the fact of the path, and reports whether it exists and is not nil.
+func resolve(facts any, path []string) (any, bool) {
+	v := reflect.ValueOf(facts)
+	for _, name := range path {
+		v = indirect(v)
+		switch v.Kind() {
+		case reflect.Map:
+			if v.Type().Key().Kind() != reflect.String {
+				return nil, false
+			}
+			v = v.MapIndex(reflect.ValueOf(name).Convert(v.Type().Key()))
+		case reflect.Struct:
+			v = fieldByName(v, name)
+		default:
+			return nil, false
+		}
+	}
+	if v = indirect(v); !v.IsValid() {
+		return nil, false
+	}
+	return v.Interface(), true
+}
+
+// indirect dereferences pointers and interfaces, and returns the zero value if any of them is nil.
+func indirect(v reflect.Value) reflect.Value {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return reflect.Value{}
+		}
+		v = v.Elem()
+	}
+	return v
+}
+
+// fieldByName finds the exported field by the name in its json tag, or by its name case-insensitively, including
+// fields promoted from exported embedded structs.
+func fieldByName(v reflect.Value, name string) reflect.Value {
+	typ := v.Type()
+	for i := range typ.NumField() {
+		f := typ.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		tag, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if tag == name || (tag == "" && strings.EqualFold(f.Name, name)) {
+			return v.Field(i)
+		}
+	}
+	for i := range typ.NumField() {
+		if f := typ.Field(i); f.Anonymous && f.IsExported() && f.Type.Kind() == reflect.Struct {
+			if field := fieldByName(v.Field(i), name); field.IsValid() {
+				return field
+			}
+		}
+	}
+	return reflect.Value{}
+}
+
+// normalize converts maps with non-string keys decoded from YAML to maps with string keys recursively.
+func normalize(v any) any {
+	switch v := v.(type) {
+	case map[any]any:
+		m := make(map[string]any, len(v))
+		for k, val := range v {
+			m[fmt.Sprint(k)] = normalize(val)
+		}
+		return m
+	case map[string]any:
+		return normalizeMap(v)
+	case []any:
+		s := make([]any, len(v))
+		for i, val := range v {
+			s[i] = normalize(val)
+		}
+		return s
+	default:
+		return v
+	}
+}
+
+func normalizeMap(m map[string]any) map[string]any {
+	normalized := make(map[string]any, len(m))
+	for k, v := range m {
+		normalized[k] = normalize(v)
+	}
+	return normalized
+}
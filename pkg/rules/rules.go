@@ -0,0 +1,367 @@
+//go:generate mockgen -write_package_comment=false -source=rules.go -destination=rules_mock.go -package rules
+
+/*
+Package rules implements a rules engine that evaluates decision tables against facts, for example for pricing, routing
+and fraud checks.
+
+Rule sets are loaded from documents in any format supported by the encoding package, for example YAML:
+
+	rule_sets:
+	  - name: pricing
+	    hit_policy: first
+	    rules:
+	      - name: vip
+	        when:
+	          - {fact: user.tier, op: eq, value: vip}
+	          - {fact: order.amount, op: gte, value: 100}
+	        then: {discount: 0.2}
+	    default: {discount: 0}
+
+Then they are evaluated against facts, which are structs or maps:
+
+	e := rules.NewEngine()
+	err := e.Watch(ctx, &encoding.FileSource{Path: "rules.yaml"}, encoding.TypeYAML, time.Minute)
+	d, err := e.Evaluate(ctx, "pricing", facts)
+	discount := d.Output["discount"]
+
+Rule sets are swapped atomically when they are reloaded, so evaluations are never interrupted. Each [Decision] records
+which rules fired and why the others didn't, which is also added to the active span as an [EventEvaluated] event.
+*/
+package rules
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/sainnhe/go-common/pkg/clock"
+	"github.com/sainnhe/go-common/pkg/constant"
+	"github.com/sainnhe/go-common/pkg/encoding"
+	"github.com/sainnhe/go-common/pkg/log"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	pkgName = "github.com/sainnhe/go-common/pkg/rules"
+
+	// HitPolicyFirst outputs the first matched rule, which is the default hit policy.
+	HitPolicyFirst = "first"
+
+	// HitPolicyCollect outputs all matched rules, whose outputs are merged in order so that later rules override
+	// earlier ones.
+	HitPolicyCollect = "collect"
+
+	// EventEvaluated is the name of the span event added when a rule set is evaluated.
+	EventEvaluated = "rules.evaluated"
+)
+
+var (
+	// ErrRuleSetNotFound indicates that the rule set doesn't exist.
+	ErrRuleSetNotFound = errors.New("rule set not found")
+
+	// ErrInvalidRuleSet indicates that a rule set is invalid.
+	ErrInvalidRuleSet = errors.New("invalid rule set")
+)
+
+// Document is a document that contains rule sets.
+type Document struct {
+	// RuleSets are the rule sets.
+	RuleSets []RuleSet `json:"rule_sets" yaml:"rule_sets" toml:"rule_sets"`
+}
+
+// RuleSet is a decision table.
+type RuleSet struct {
+	// Name is the unique name of the rule set.
+	Name string `json:"name" yaml:"name" toml:"name"`
+
+	// HitPolicy is either [HitPolicyFirst] or [HitPolicyCollect]. Defaults to [HitPolicyFirst].
+	HitPolicy string `json:"hit_policy" yaml:"hit_policy" toml:"hit_policy"`
+
+	// Rules are the rules, which are evaluated in order.
+	Rules []Rule `json:"rules" yaml:"rules" toml:"rules"`
+
+	// Default is the output if no rule matches.
+	Default map[string]any `json:"default" yaml:"default" toml:"default"`
+}
+
+// Rule is a rule of a decision table.
+type Rule struct {
+	// Name is the name of the rule, which is used in traces. Defaults to "#n" where n is the 1-based index.
+	Name string `json:"name" yaml:"name" toml:"name"`
+
+	// When are the conditions, which must all be satisfied for the rule to match. A rule without conditions always
+	// matches.
+	When []Condition `json:"when" yaml:"when" toml:"when"`
+
+	// Then is the output if the rule matches.
+	Then map[string]any `json:"then" yaml:"then" toml:"then"`
+}
+
+/*
+Condition compares a fact with a value.
+
+The following operators are supported:
+
+  - eq, ne: Equal or not equal. Numbers of different types are compared by value.
+  - lt, lte, gt, gte: Compare numbers, strings, or times with RFC 3339 strings.
+  - in, not_in: Whether the fact equals any of the values in a list.
+  - contains: Whether the string fact contains the value, or the list fact contains an element equal to the value.
+  - prefix, suffix: Whether the string fact starts or ends with the value.
+  - matches: Whether the string fact matches the regular expression.
+  - exists: Whether the fact exists and is not nil. The value is optional, and false negates the condition.
+*/
+type Condition struct {
+	// Fact is the dot-separated path of the fact like "order.amount". Map keys are matched exactly, and struct fields
+	// are matched by the names in json tags or the field names case-insensitively.
+	Fact string `json:"fact" yaml:"fact" toml:"fact"`
+
+	// Op is the operator.
+	Op string `json:"op" yaml:"op" toml:"op"`
+
+	// Value is the value to compare with.
+	Value any `json:"value" yaml:"value" toml:"value"`
+}
+
+// Decision is the result of evaluating a rule set.
+type Decision struct {
+	// RuleSet is the name of the rule set.
+	RuleSet string
+
+	// Fired are the names of matched rules in order, which is empty if the default output is used.
+	Fired []string
+
+	// Output is the output of matched rules, or the default output if no rule matches. It's never nil.
+	Output map[string]any
+
+	// Trace records the evaluation of each rule in order. Rules after the first matched one are not evaluated under
+	// [HitPolicyFirst].
+	Trace []Trace
+}
+
+// Trace is the evaluation record of a rule.
+type Trace struct {
+	// Rule is the name of the rule.
+	Rule string
+
+	// Matched reports whether the rule matched.
+	Matched bool
+
+	// Reason describes the first unsatisfied condition, which is empty if the rule matched.
+	Reason string
+}
+
+// Engine is the rules engine.
+type Engine interface {
+	// Set validates the rule sets and replaces all rule sets with them. If any rule set is invalid, an error wrapping
+	// [ErrInvalidRuleSet] is returned and the current rule sets are kept.
+	Set(sets []RuleSet) error
+
+	// Load parses a [Document] with [encoding.LoadConfig] and replaces all rule sets with its rule sets like [Set].
+	Load(content []byte, typ encoding.Type) error
+
+	// Watch loads a [Document] from source, and then reloads it in background every interval until ctx is done. Errors
+	// of the initial load are returned, while later errors are logged and the current rule sets are kept.
+	Watch(ctx context.Context, source encoding.ConfigSource, typ encoding.Type, interval time.Duration) error
+
+	// Evaluate evaluates the rule set against facts, which are structs, maps or pointers to them.
+	// [ErrRuleSetNotFound] is returned if the rule set doesn't exist.
+	Evaluate(ctx context.Context, ruleSet string, facts any) (*Decision, error)
+}
+
+type engineImpl struct {
+	sets atomic.Pointer[map[string]*ruleSet]
+	clk  clock.Clock
+	l    *slog.Logger
+}
+
+// Option is the option used to customize the rules engine.
+type Option func(e *engineImpl)
+
+// WithClock sets the clock used to wait between reloads in [Engine.Watch]. Defaults to [clock.Real].
+func WithClock(clk clock.Clock) Option {
+	return func(e *engineImpl) {
+		if clk != nil {
+			e.clk = clk
+		}
+	}
+}
+
+// NewEngine initializes a new rules engine without rule sets.
+func NewEngine(opts ...Option) Engine {
+	e := &engineImpl{
+		clk: clock.Real(),
+		l:   log.NewLogger(pkgName),
+	}
+	for _, opt := range opts {
+		opt(e)
+	}
+	e.sets.Store(&map[string]*ruleSet{})
+	return e
+}
+
+func (e *engineImpl) Set(sets []RuleSet) error {
+	compiled := make(map[string]*ruleSet, len(sets))
+	for i := range sets {
+		set, err := compileRuleSet(&sets[i])
+		if err != nil {
+			return err
+		}
+		if _, ok := compiled[set.name]; ok {
+			return fmt.Errorf("%w: duplicate name %q", ErrInvalidRuleSet, set.name)
+		}
+		compiled[set.name] = set
+	}
+	e.sets.Store(&compiled)
+	return nil
+}
+
+func (e *engineImpl) Load(content []byte, typ encoding.Type) error {
+	doc, err := encoding.LoadConfig[Document](content, typ)
+	if err != nil {
+		return err
+	}
+	return e.Set(doc.RuleSets)
+}
+
+func (e *engineImpl) Watch(ctx context.Context, source encoding.ConfigSource, typ encoding.Type,
+	interval time.Duration) error {
+	if source == nil {
+		return constant.ErrNilDeps
+	}
+	content, err := source.Load(ctx)
+	if err != nil {
+		return err
+	}
+	if err := e.Load(content, typ); err != nil {
+		return err
+	}
+
+	logger := e.l.With(constant.LogAttrMethod, "Watch")
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-e.clk.After(interval):
+			}
+			next, err := source.Load(ctx)
+			if err != nil {
+				if ctx.Err() == nil {
+					logger.ErrorContext(ctx, "Load rule sets failed.", constant.LogAttrError, err)
+				}
+				continue
+			}
+			if bytes.Equal(next, content) {
+				continue
+			}
+			// Remember the content even if it's invalid, so that the same error is only logged once.
+			content = next
+			if err := e.Load(content, typ); err != nil {
+				logger.ErrorContext(ctx, "Reload rule sets failed. Keeping current rule sets...",
+					constant.LogAttrError, err)
+				continue
+			}
+			logger.InfoContext(ctx, "Rule sets reloaded.")
+		}
+	}()
+	return nil
+}
+
+func (e *engineImpl) Evaluate(ctx context.Context, name string, facts any) (*Decision, error) {
+	set, ok := (*e.sets.Load())[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrRuleSetNotFound, name)
+	}
+
+	d := &Decision{name, []string{}, map[string]any{}, make([]Trace, 0, len(set.rules))}
+	for _, r := range set.rules {
+		reason := r.match(facts)
+		d.Trace = append(d.Trace, Trace{r.name, reason == "", reason})
+		if reason != "" {
+			continue
+		}
+		d.Fired = append(d.Fired, r.name)
+		maps.Copy(d.Output, r.then)
+		if set.hitPolicy == HitPolicyFirst {
+			break
+		}
+	}
+	if len(d.Fired) == 0 {
+		maps.Copy(d.Output, set.defaultOutput)
+	}
+
+	if span := trace.SpanFromContext(ctx); span.IsRecording() {
+		span.AddEvent(EventEvaluated, trace.WithAttributes(
+			attribute.String("rule_set", name),
+			attribute.StringSlice("fired", d.Fired),
+		))
+	}
+	return d, nil
+}
+
+// ruleSet is a compiled [RuleSet].
+type ruleSet struct {
+	name          string
+	hitPolicy     string
+	rules         []*rule
+	defaultOutput map[string]any
+}
+
+// rule is a compiled [Rule].
+type rule struct {
+	name  string
+	conds []*condition
+	then  map[string]any
+}
+
+func compileRuleSet(s *RuleSet) (*ruleSet, error) {
+	invalid := func(format string, args ...any) error {
+		return fmt.Errorf("%w: %s: %s", ErrInvalidRuleSet, s.Name, fmt.Sprintf(format, args...))
+	}
+	if strings.TrimSpace(s.Name) == "" {
+		return nil, fmt.Errorf("%w: empty name", ErrInvalidRuleSet)
+	}
+	set := &ruleSet{s.Name, strings.ToLower(s.HitPolicy), make([]*rule, 0, len(s.Rules)), normalizeMap(s.Default)}
+	switch set.hitPolicy {
+	case "":
+		set.hitPolicy = HitPolicyFirst
+	case HitPolicyFirst, HitPolicyCollect:
+	default:
+		return nil, invalid("unknown hit policy %q", s.HitPolicy)
+	}
+	for i, r := range s.Rules {
+		compiled := &rule{r.Name, make([]*condition, 0, len(r.When)), normalizeMap(r.Then)}
+		if compiled.name == "" {
+			compiled.name = fmt.Sprintf("#%d", i+1)
+		}
+		for _, c := range r.When {
+			cond, err := compileCondition(c)
+			if err != nil {
+				return nil, invalid("rule %s: %s", compiled.name, err)
+			}
+			compiled.conds = append(compiled.conds, cond)
+		}
+		set.rules = append(set.rules, compiled)
+	}
+	return set, nil
+}
+
+// match returns the description of the first unsatisfied condition, or an empty string if the rule matches.
+func (r *rule) match(facts any) string {
+	for _, c := range r.conds {
+		if got, ok := resolve(facts, c.path); !c.eval(got, ok) {
+			if !ok {
+				return fmt.Sprintf("%s %s %v: fact not found", c.Fact, c.Op, c.value)
+			}
+			return fmt.Sprintf("%s %s %v: got %v", c.Fact, c.Op, c.value, got)
+		}
+	}
+	return ""
+}
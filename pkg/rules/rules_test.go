@@ -0,0 +1,297 @@
+package rules_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/sainnhe/go-common/pkg/clock/testclock"
+	"github.com/sainnhe/go-common/pkg/constant"
+	"github.com/sainnhe/go-common/pkg/encoding"
+	"github.com/sainnhe/go-common/pkg/rules"
+)
+
+const testRules = `
+rule_sets:
+  - name: pricing
+    rules:
+      - name: vip
+        when:
+          - {fact: user.tier, op: eq, value: vip}
+          - {fact: order.amount, op: gte, value: 100}
+        then: {discount: 0.2, tags: {reason: vip}}
+      - name: big order
+        when:
+          - {fact: order.amount, op: gt, value: 500}
+        then: {discount: 0.1}
+    default: {discount: 0}
+  - name: fraud
+    hit_policy: collect
+    rules:
+      - name: blocked country
+        when:
+          - {fact: order.country, op: in, value: [KP, IR]}
+        then: {block: true}
+      - name: new account
+        when:
+          - {fact: user.created, op: gt, value: "2026-01-01T00:00:00Z"}
+        then: {review: true, score: 50}
+      - name: high score
+        when:
+          - {fact: user.score, op: exists, value: false}
+        then: {score: 80}
+`
+
+type user struct {
+	Tier    string    `json:"tier"`
+	Created time.Time `json:"created"`
+	Score   *int      `json:"score"`
+}
+
+type Order struct {
+	Amount  float64
+	Country string
+}
+
+type facts struct {
+	Request
+	User *user `json:"user"`
+}
+
+type Request struct {
+	Order Order `json:"order"`
+}
+
+func TestEngine_Evaluate(t *testing.T) {
+	t.Parallel()
+
+	e := rules.NewEngine()
+	if err := e.Load([]byte(testRules), encoding.TypeYAML); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	tests := []struct {
+		name   string
+		set    string
+		facts  any
+		fired  []string
+		output map[string]any
+	}{
+		{
+			"first match",
+			"pricing",
+			&facts{Request{Order{Amount: 600, Country: "US"}}, &user{Tier: "vip"}},
+			[]string{"vip"},
+			map[string]any{"discount": 0.2, "tags": map[string]any{"reason": "vip"}},
+		},
+		{
+			"second match",
+			"pricing",
+			map[string]any{"user": map[string]string{"tier": "basic"}, "order": map[string]any{"amount": 501}},
+			[]string{"big order"},
+			map[string]any{"discount": 0.1},
+		},
+		{
+			"default",
+			"pricing",
+			facts{},
+			[]string{},
+			map[string]any{"discount": 0},
+		},
+		{
+			"collect",
+			"fraud",
+			facts{Request{Order{Country: "IR"}}, &user{Created: time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC)}},
+			[]string{"blocked country", "new account", "high score"},
+			map[string]any{"block": true, "review": true, "score": 80},
+		},
+	}
+	for _, tt := range tests {
+		d, err := e.Evaluate(ctx, tt.set, tt.facts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(d.Fired, tt.fired) || !reflect.DeepEqual(d.Output, tt.output) {
+			t.Fatalf("%s: expect %v %v, got %v %v", tt.name, tt.fired, tt.output, d.Fired, d.Output)
+		}
+	}
+
+	d, err := e.Evaluate(ctx, "pricing", facts{Request{Order{Amount: 50}}, &user{Tier: "vip"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []rules.Trace{
+		{Rule: "vip", Reason: "order.amount gte 100: got 50"},
+		{Rule: "big order", Reason: "order.amount gt 500: got 50"},
+	}
+	if d.RuleSet != "pricing" || !reflect.DeepEqual(d.Trace, want) {
+		t.Fatalf("Unexpected trace %+v", d.Trace)
+	}
+	if d, err = e.Evaluate(ctx, "pricing", nil); err != nil || d.Trace[0].Reason != "user.tier eq vip: fact not found" {
+		t.Fatalf("Unexpected decision %+v, %+v", d, err)
+	}
+
+	if _, err := e.Evaluate(ctx, "none", nil); !errors.Is(err, rules.ErrRuleSetNotFound) {
+		t.Fatalf("Expect error %+v, got %+v", rules.ErrRuleSetNotFound, err)
+	}
+}
+
+func TestEngine_operators(t *testing.T) {
+	t.Parallel()
+
+	type name string
+	facts := map[string]any{
+		"name":  name("alice"),
+		"age":   int8(30),
+		"tags":  []string{"a", "b"},
+		"nil":   nil,
+		"since": time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+	tests := []struct {
+		cond rules.Condition
+		want bool
+	}{
+		{rules.Condition{Fact: "name", Op: "eq", Value: "alice"}, true},
+		{rules.Condition{Fact: "name", Op: "ne", Value: "alice"}, false},
+		{rules.Condition{Fact: "missing", Op: "ne", Value: "alice"}, true},
+		{rules.Condition{Fact: "age", Op: "eq", Value: 30.0}, true},
+		{rules.Condition{Fact: "age", Op: "lt", Value: 30}, false},
+		{rules.Condition{Fact: "age", Op: "lte", Value: 30}, true},
+		{rules.Condition{Fact: "name", Op: "gt", Value: "bob"}, false},
+		{rules.Condition{Fact: "name", Op: "gt", Value: 1}, false},
+		{rules.Condition{Fact: "since", Op: "gte", Value: "2026-01-01T00:00:00Z"}, true},
+		{rules.Condition{Fact: "since", Op: "lt", Value: "2025-01-01T00:00:00Z"}, false},
+		{rules.Condition{Fact: "age", Op: "in", Value: []any{10, 30}}, true},
+		{rules.Condition{Fact: "age", Op: "not_in", Value: []any{10, 30}}, false},
+		{rules.Condition{Fact: "missing", Op: "not_in", Value: []any{10, 30}}, true},
+		{rules.Condition{Fact: "name", Op: "contains", Value: "lic"}, true},
+		{rules.Condition{Fact: "tags", Op: "contains", Value: "b"}, true},
+		{rules.Condition{Fact: "tags", Op: "contains", Value: "c"}, false},
+		{rules.Condition{Fact: "age", Op: "contains", Value: "3"}, false},
+		{rules.Condition{Fact: "name", Op: "prefix", Value: "al"}, true},
+		{rules.Condition{Fact: "name", Op: "suffix", Value: "al"}, false},
+		{rules.Condition{Fact: "name", Op: "matches", Value: "^a.*e$"}, true},
+		{rules.Condition{Fact: "age", Op: "matches", Value: "30"}, false},
+		{rules.Condition{Fact: "name", Op: "exists"}, true},
+		{rules.Condition{Fact: "nil", Op: "exists"}, false},
+		{rules.Condition{Fact: "nil", Op: "exists", Value: false}, true},
+		{rules.Condition{Fact: "name.first", Op: "exists"}, false},
+	}
+	e := rules.NewEngine()
+	for _, tt := range tests {
+		sets := []rules.RuleSet{{Name: "test", Rules: []rules.Rule{{When: []rules.Condition{tt.cond}}}}}
+		if err := e.Set(sets); err != nil {
+			t.Fatal(err)
+		}
+		d, err := e.Evaluate(context.Background(), "test", facts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := len(d.Fired) == 1; got != tt.want {
+			t.Fatalf("Expect %+v to be %t, got %t", tt.cond, tt.want, got)
+		}
+		if tt.want && d.Fired[0] != "#1" {
+			t.Fatalf("Unexpected rule name %s", d.Fired[0])
+		}
+	}
+}
+
+func TestEngine_Set(t *testing.T) {
+	t.Parallel()
+
+	e := rules.NewEngine()
+	valid := rules.RuleSet{Name: "valid"}
+	if err := e.Set([]rules.RuleSet{valid}); err != nil {
+		t.Fatal(err)
+	}
+	for _, sets := range [][]rules.RuleSet{
+		{{}},
+		{valid, valid},
+		{{Name: "x", HitPolicy: "any"}},
+		{{Name: "x", Rules: []rules.Rule{{When: []rules.Condition{{Op: "eq"}}}}}},
+		{{Name: "x", Rules: []rules.Rule{{When: []rules.Condition{{Fact: "a", Op: "like"}}}}}},
+		{{Name: "x", Rules: []rules.Rule{{When: []rules.Condition{{Fact: "a", Op: "lt", Value: true}}}}}},
+		{{Name: "x", Rules: []rules.Rule{{When: []rules.Condition{{Fact: "a", Op: "in", Value: "a"}}}}}},
+		{{Name: "x", Rules: []rules.Rule{{When: []rules.Condition{{Fact: "a", Op: "prefix", Value: 1}}}}}},
+		{{Name: "x", Rules: []rules.Rule{{When: []rules.Condition{{Fact: "a", Op: "matches", Value: "("}}}}}},
+		{{Name: "x", Rules: []rules.Rule{{When: []rules.Condition{{Fact: "a", Op: "exists", Value: "yes"}}}}}},
+	} {
+		if err := e.Set(append([]rules.RuleSet{valid}, sets...)); err == nil {
+			t.Fatalf("Expect %+v to be invalid", sets)
+		}
+	}
+	// The current rule sets are kept.
+	if _, err := e.Evaluate(context.Background(), "valid", nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.Load([]byte("rule_sets: ["), encoding.TypeYAML); err == nil {
+		t.Fatal("Expect error when loading invalid YAML")
+	}
+}
+
+func TestEngine_Watch(t *testing.T) {
+	t.Parallel()
+
+	clk := testclock.Freeze(time.Now())
+	e := rules.NewEngine(rules.WithClock(clk))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if err := e.Watch(ctx, nil, encoding.TypeYAML, time.Minute); !errors.Is(err, constant.ErrNilDeps) {
+		t.Fatalf("Expect error %+v, got %+v", constant.ErrNilDeps, err)
+	}
+	path := filepath.Join(t.TempDir(), "rules.yaml")
+	source := &encoding.FileSource{Path: path}
+	if err := e.Watch(ctx, source, encoding.TypeYAML, time.Minute); !errors.Is(err,
+		encoding.ErrConfigSourceNotFound) {
+		t.Fatalf("Expect error %+v, got %+v", encoding.ErrConfigSourceNotFound, err)
+	}
+
+	write := func(content string) {
+		t.Helper()
+
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	output := func() any {
+		t.Helper()
+
+		d, err := e.Evaluate(ctx, "flag", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return d.Output["enabled"]
+	}
+	write("rule_sets: [{name: flag, default: {enabled: false}}]")
+	if err := e.Watch(ctx, source, encoding.TypeYAML, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if got := output(); got != false {
+		t.Fatalf("Expect false, got %v", got)
+	}
+
+	// reload waits for the next poll, and returns after the watcher starts waiting again.
+	reload := func() {
+		clk.BlockUntil(1)
+		clk.Advance(time.Minute)
+		clk.BlockUntil(1)
+	}
+	write("rule_sets: [{name: flag, default: {enabled: true}}]")
+	reload()
+	if got := output(); got != true {
+		t.Fatalf("Expect true, got %v", got)
+	}
+
+	// Invalid content is ignored.
+	write("rule_sets: [{name: flag, hit_policy: any}]")
+	reload()
+	if got := output(); got != true {
+		t.Fatalf("Expect true, got %v", got)
+	}
+}
@@ -0,0 +1,330 @@
+/*
+Package schedule parses cron expressions and computes their run times, so that config-driven schedules can be validated
+at startup.
+
+An expression consists of 5 fields separated by spaces:
+
+	┌───────────── minute (0-59)
+	│ ┌─────────── hour (0-23)
+	│ │ ┌───────── day of month (1-31)
+	│ │ │ ┌─────── month (1-12 or JAN-DEC)
+	│ │ │ │ ┌───── day of week (0-7 or SUN-SAT, where both 0 and 7 are Sunday)
+	│ │ │ │ │
+	* * * * *
+
+Each field is a comma separated list of "*", "N" or "N-M", optionally followed by a step like "*\/15" or "1-30/2". "?"
+is an alias of "*" in the day of month and day of week fields. Like Vixie cron, if both day fields are restricted, i.e.
+neither of them starts with "*" or "?", a day matches if either of them matches, otherwise it must match both. So
+"0 0 *\/2 * MON" runs on Mondays that are odd days of the month.
+
+The descriptors "@yearly" (or "@annually"), "@monthly", "@weekly", "@daily" (or "@midnight") and "@hourly" are also
+supported, and the expression can be prefixed with "CRON_TZ=<location> " or "TZ=<location> " to override the location.
+
+Since [encoding.LoadConfig] doesn't validate config values, schedules loaded from config should be validated by calling
+[Parse] right after loading, for example:
+
+	cfg, err := encoding.LoadConfig[Config](content, encoding.TypeYAML)
+	if err != nil {
+		return err
+	}
+	if _, err := schedule.Parse(cfg.CleanupSchedule); err != nil {
+		return fmt.Errorf("invalid cleanup schedule: %w", err)
+	}
+
+[encoding.LoadConfig]: https://pkg.go.dev/github.com/sainnhe/go-common/pkg/encoding#LoadConfig
+*/
+package schedule
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidExpr indicates that a cron expression is invalid. The detailed error is a [*ParseError].
+var ErrInvalidExpr = errors.New("invalid cron expression")
+
+// ParseError describes an invalid cron expression with the position of the error.
+type ParseError struct {
+	// Expr is the expression being parsed.
+	Expr string
+
+	// Pos is the byte offset in Expr where the error occurs.
+	Pos int
+
+	// Field is the name of the field where the error occurs, which is empty if the error is not specific to a field.
+	Field string
+
+	// Msg describes the error.
+	Msg string
+}
+
+func (e *ParseError) Error() string {
+	if len(e.Field) == 0 {
+		return fmt.Sprintf("%s %q at position %d: %s", ErrInvalidExpr, e.Expr, e.Pos, e.Msg)
+	}
+	return fmt.Sprintf("%s %q at position %d: %s: %s", ErrInvalidExpr, e.Expr, e.Pos, e.Field, e.Msg)
+}
+
+// Unwrap returns [ErrInvalidExpr], so that [errors.Is] can be used to check the error.
+func (e *ParseError) Unwrap() error {
+	return ErrInvalidExpr
+}
+
+// maxSearchYears is the number of years to search for the next run time before giving up.
+const maxSearchYears = 5
+
+// Schedule is a parsed cron expression.
+type Schedule struct {
+	expr    string
+	loc     *time.Location
+	minute  uint64
+	hour    uint64
+	dom     uint64
+	month   uint64
+	dow     uint64
+	domStar bool
+	dowStar bool
+}
+
+// Option is the option used to customize [Parse].
+type Option func(s *Schedule)
+
+// WithLocation sets the location used to interpret the expression. It's overridden by the "CRON_TZ=" or "TZ=" prefix
+// of the expression. Defaults to [time.Local].
+func WithLocation(loc *time.Location) Option {
+	return func(s *Schedule) {
+		if loc != nil {
+			s.loc = loc
+		}
+	}
+}
+
+// field describes the bounds and names of a field.
+type field struct {
+	name     string
+	min      int
+	max      int
+	names    map[string]int
+	allowAny bool
+}
+
+var fields = [...]field{
+	{"minute", 0, 59, nil, false},
+	{"hour", 0, 23, nil, false},
+	{"day of month", 1, 31, nil, true},
+	{"month", 1, 12, map[string]int{
+		"JAN": 1, "FEB": 2, "MAR": 3, "APR": 4, "MAY": 5, "JUN": 6,
+		"JUL": 7, "AUG": 8, "SEP": 9, "OCT": 10, "NOV": 11, "DEC": 12,
+	}, false},
+	{"day of week", 0, 7, map[string]int{
+		"SUN": 0, "MON": 1, "TUE": 2, "WED": 3, "THU": 4, "FRI": 5, "SAT": 6,
+	}, true},
+}
+
+var descriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// Parse parses a cron expression. If the expression is invalid, a [*ParseError] wrapping [ErrInvalidExpr] will be
+// returned.
+func Parse(expr string, opts ...Option) (*Schedule, error) {
+	s := &Schedule{expr: expr, loc: time.Local}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	// Parse the location prefix.
+	spec, offset := expr, 0
+	for _, prefix := range []string{"CRON_TZ=", "TZ="} {
+		if !strings.HasPrefix(expr, prefix) {
+			continue
+		}
+		name, rest, _ := strings.Cut(expr[len(prefix):], " ")
+		loc, err := time.LoadLocation(name)
+		if err != nil {
+			return nil, &ParseError{expr, len(prefix), "", fmt.Sprintf("unknown location %q", name)}
+		}
+		s.loc = loc
+		offset = len(expr) - len(rest)
+		spec = rest
+		break
+	}
+
+	// Expand descriptors, in which case errors can only occur at the descriptor itself.
+	tokens, starts := splitFields(spec)
+	if len(tokens) == 1 && strings.HasPrefix(tokens[0], "@") {
+		desc, ok := descriptors[strings.ToLower(tokens[0])]
+		if !ok {
+			return nil, &ParseError{expr, offset + starts[0], "", fmt.Sprintf("unknown descriptor %q", tokens[0])}
+		}
+		tokens, _ = splitFields(desc)
+		starts = make([]int, len(tokens))
+	}
+	if len(tokens) != len(fields) {
+		return nil, &ParseError{expr, len(expr), "",
+			fmt.Sprintf("expect %d fields, got %d", len(fields), len(tokens))}
+	}
+
+	masks := [...]*uint64{&s.minute, &s.hour, &s.dom, &s.month, &s.dow}
+	for i, token := range tokens {
+		mask, pos, msg := parseField(token, fields[i])
+		if len(msg) > 0 {
+			return nil, &ParseError{expr, offset + starts[i] + pos, fields[i].name, msg}
+		}
+		*masks[i] = mask
+	}
+
+	// Sunday can be either 0 or 7.
+	if s.dow&(1<<7) != 0 {
+		s.dow = s.dow&^(1<<7) | 1
+	}
+	// Like Vixie cron, a day field starting with "*" doesn't count as restricted even if it has a step, e.g. "*/2".
+	s.domStar = strings.HasPrefix(tokens[2], "*") || strings.HasPrefix(tokens[2], "?")
+	s.dowStar = strings.HasPrefix(tokens[4], "*") || strings.HasPrefix(tokens[4], "?")
+	return s, nil
+}
+
+// splitFields splits s by spaces, and returns the fields along with their byte offsets.
+func splitFields(s string) (tokens []string, starts []int) {
+	start := -1
+	for i, r := range s + " " {
+		if r == ' ' || r == '\t' {
+			if start >= 0 {
+				tokens = append(tokens, s[start:i])
+				starts = append(starts, start)
+				start = -1
+			}
+		} else if start < 0 {
+			start = i
+		}
+	}
+	return
+}
+
+// parseField parses a field into a bit mask. If the field is invalid, the offset of the error in the field and the
+// error message will be returned.
+func parseField(token string, f field) (mask uint64, pos int, msg string) {
+	for part := range strings.SplitSeq(token, ",") {
+		rangeStr, stepStr, hasStep := strings.Cut(part, "/")
+		lo, hi := f.min, f.max
+		if rangeStr != "*" && (rangeStr != "?" || !f.allowAny) {
+			loStr, hiStr, isRange := strings.Cut(rangeStr, "-")
+			var ok bool
+			if lo, ok = parseValue(loStr, f); !ok {
+				return 0, pos, fmt.Sprintf("invalid value %q, expect %d-%d", loStr, f.min, f.max)
+			}
+			hi = lo
+			if isRange {
+				if hi, ok = parseValue(hiStr, f); !ok {
+					return 0, pos + len(loStr) + 1,
+						fmt.Sprintf("invalid value %q, expect %d-%d", hiStr, f.min, f.max)
+				}
+				if hi < lo {
+					return 0, pos, fmt.Sprintf("invalid range %q, start is greater than end", rangeStr)
+				}
+			} else if hasStep {
+				// "N/step" means from N to the maximum.
+				hi = f.max
+			}
+		}
+		step := 1
+		if hasStep {
+			var err error
+			if step, err = strconv.Atoi(stepStr); err != nil || step <= 0 {
+				return 0, pos + len(rangeStr) + 1, fmt.Sprintf("invalid step %q", stepStr)
+			}
+		}
+		for v := lo; v <= hi; v += step {
+			mask |= 1 << v
+		}
+		pos += len(part) + 1
+	}
+	return mask, 0, ""
+}
+
+// parseValue parses a number or a name of the field.
+func parseValue(s string, f field) (int, bool) {
+	if v, ok := f.names[strings.ToUpper(s)]; ok {
+		return v, true
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil || v < f.min || v > f.max {
+		return 0, false
+	}
+	return v, true
+}
+
+// String returns the original expression.
+func (s *Schedule) String() string {
+	return s.expr
+}
+
+// Location returns the location used to interpret the expression.
+func (s *Schedule) Location() *time.Location {
+	return s.loc
+}
+
+// Next returns the first run time strictly after from, in the location of the schedule. If there is no run time within
+// 5 years, for example "0 0 30 2 *", the zero time will be returned.
+//
+// When clocks are turned forward for daylight saving time, run times in the skipped hour are skipped. When clocks are
+// turned back, run times in the repeated hour match twice.
+func (s *Schedule) Next(from time.Time) time.Time {
+	t := from.In(s.loc).Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(maxSearchYears, 0, 0)
+	for t.Before(limit) {
+		var next time.Time
+		switch {
+		case s.month&(1<<t.Month()) == 0:
+			next = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, s.loc)
+		case !s.matchDay(t):
+			next = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, s.loc)
+		case s.hour&(1<<t.Hour()) == 0:
+			next = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, s.loc)
+		case s.minute&(1<<t.Minute()) == 0:
+			next = t.Add(time.Minute)
+		default:
+			return t
+		}
+		// The time package may normalize a time in the skipped hour to an earlier time, in which case move forward
+		// minute by minute until the skipped hour is passed.
+		if !next.After(t) {
+			next = t.Add(time.Minute)
+		}
+		t = next
+	}
+	return time.Time{}
+}
+
+// NextN returns the next n run times after from, which is useful for previewing a schedule. Fewer run times will be
+// returned if there are no more run times within 5 years of the last one.
+func (s *Schedule) NextN(from time.Time, n int) []time.Time {
+	times := make([]time.Time, 0, max(n, 0))
+	for range n {
+		from = s.Next(from)
+		if from.IsZero() {
+			break
+		}
+		times = append(times, from)
+	}
+	return times
+}
+
+// matchDay reports whether the day of t matches the day of month and day of week fields.
+func (s *Schedule) matchDay(t time.Time) bool {
+	domMatch := s.dom&(1<<t.Day()) != 0
+	dowMatch := s.dow&(1<<t.Weekday()) != 0
+	if s.domStar || s.dowStar {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
@@ -0,0 +1,124 @@
+package schedule_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/sainnhe/go-common/pkg/schedule"
+)
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		expr  string
+		pos   int
+		field string
+	}{
+		{"* * * *", 7, ""},
+		{"60 * * * *", 0, "minute"},
+		{"0 1-24 * * *", 4, "hour"},
+		{"0 0 0 * *", 4, "day of month"},
+		{"0 0 * FOO *", 6, "month"},
+		{"0 0 * * 1,MON,8", 14, "day of week"},
+		{"*/0 * * * *", 2, "minute"},
+		{"0 5-1 * * *", 2, "hour"},
+		{"? * * * *", 0, "minute"},
+		{"@every", 0, ""},
+		{"TZ=Mars/Olympus * * * * *", 3, ""},
+		{"CRON_TZ=UTC  0 25 * * *", 15, "hour"},
+	}
+	for _, tt := range tests {
+		_, err := schedule.Parse(tt.expr)
+		if !errors.Is(err, schedule.ErrInvalidExpr) {
+			t.Fatalf("Expect error %+v for %q, got %+v", schedule.ErrInvalidExpr, tt.expr, err)
+		}
+		var parseErr *schedule.ParseError
+		if !errors.As(err, &parseErr) || parseErr.Pos != tt.pos || parseErr.Field != tt.field {
+			t.Fatalf("Expect position %d and field %q for %q, got %+v", tt.pos, tt.field, tt.expr, err)
+		}
+	}
+
+	for _, expr := range []string{"*/15 0-6/2 ? JAN-mar SUN,7", "@Daily", "  0 0\t1 * *  "} {
+		if _, err := schedule.Parse(expr); err != nil {
+			t.Fatalf("Expect %q to be valid, got %+v", expr, err)
+		}
+	}
+}
+
+func TestSchedule_Next(t *testing.T) {
+	t.Parallel()
+
+	from := time.Date(2025, 1, 31, 10, 30, 15, 0, time.UTC) // Friday
+	tests := []struct {
+		expr string
+		want []time.Time
+	}{
+		{"*/20 * * * *", []time.Time{
+			time.Date(2025, 1, 31, 10, 40, 0, 0, time.UTC),
+			time.Date(2025, 1, 31, 11, 0, 0, 0, time.UTC),
+		}},
+		{"0 9 * * MON-FRI", []time.Time{
+			time.Date(2025, 2, 3, 9, 0, 0, 0, time.UTC),
+			time.Date(2025, 2, 4, 9, 0, 0, 0, time.UTC),
+		}},
+		{"@monthly", []time.Time{
+			time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC),
+			time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC),
+		}},
+		// Either day field matches if both are restricted.
+		{"0 0 15 * 0", []time.Time{
+			time.Date(2025, 2, 2, 0, 0, 0, 0, time.UTC),
+			time.Date(2025, 2, 9, 0, 0, 0, 0, time.UTC),
+			time.Date(2025, 2, 15, 0, 0, 0, 0, time.UTC),
+		}},
+		// Both day fields must match if either starts with "*", even with a step.
+		{"0 0 */2 * 1", []time.Time{
+			time.Date(2025, 2, 3, 0, 0, 0, 0, time.UTC),
+			time.Date(2025, 2, 17, 0, 0, 0, 0, time.UTC),
+			time.Date(2025, 3, 3, 0, 0, 0, 0, time.UTC),
+		}},
+		{"0 0 29 2 *", []time.Time{
+			time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC),
+		}},
+		{"0 0 30 2 *", []time.Time{}},
+	}
+	for _, tt := range tests {
+		s, err := schedule.Parse(tt.expr, schedule.WithLocation(time.UTC))
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := s.NextN(from, len(tt.want))
+		if len(got) != len(tt.want) {
+			t.Fatalf("Expect %v for %q, got %v", tt.want, tt.expr, got)
+		}
+		for i := range got {
+			if !got[i].Equal(tt.want[i]) {
+				t.Fatalf("Expect %v for %q, got %v", tt.want, tt.expr, got)
+			}
+		}
+	}
+}
+
+func TestSchedule_Location(t *testing.T) {
+	t.Parallel()
+
+	s, err := schedule.Parse("CRON_TZ=America/New_York 30 2 * * *", schedule.WithLocation(time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Location().String() != "America/New_York" || s.String() != "CRON_TZ=America/New_York 30 2 * * *" {
+		t.Fatalf("Unexpected schedule %s in %s", s, s.Location())
+	}
+
+	// 02:30 doesn't exist on 2025-03-09, so it's skipped.
+	got := s.NextN(time.Date(2025, 3, 8, 0, 0, 0, 0, time.UTC), 2)
+	want := []time.Time{
+		time.Date(2025, 3, 8, 7, 30, 0, 0, time.UTC),
+		time.Date(2025, 3, 10, 6, 30, 0, 0, time.UTC),
+	}
+	if len(got) != 2 || !got[0].Equal(want[0]) || !got[1].Equal(want[1]) {
+		t.Fatalf("Expect %v, got %v", want, got)
+	}
+}
@@ -0,0 +1,11 @@
+package scim
+
+// Config defines the config model for scim.
+type Config struct {
+	// BaseURL is the base URL of the SCIM endpoints like "https://example.com/scim/v2", which is used to build the
+	// "meta.location" attribute of resources. If empty, the location is omitted.
+	BaseURL string `json:"base_url" yaml:"base_url" toml:"base_url" xml:"base_url" env:"SCIM_BASE_URL" default:""`
+
+	// MaxResults is the max number of resources returned in a list response, which caps the "count" parameter.
+	MaxResults int `json:"max_results" yaml:"max_results" toml:"max_results" xml:"max_results" env:"SCIM_MAX_RESULTS" default:"200"` // nolint:lll
+}
@@ -0,0 +1,490 @@
+package scim
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Filter is a parsed SCIM filter, see RFC 7644 section 3.4.2.2.
+//
+// The filter is one of [*AttrExpr], [*LogExpr], [*NotExpr] and [*ValuePathExpr], which can be inspected by stores to
+// narrow down queries.
+type Filter interface {
+	// Match reports whether the resource matches the filter.
+	Match(r Resource) bool
+}
+
+// AttrPath is an attribute path like "userName", "name.familyName" or
+// "urn:ietf:params:scim:schemas:extension:enterprise:2.0:User:employeeNumber".
+type AttrPath struct {
+	// URN is the schema URN, which is empty if the path is not fully qualified.
+	URN string
+
+	// Attr is the attribute name.
+	Attr string
+
+	// SubAttr is the sub-attribute name, which is empty if the path doesn't have one.
+	SubAttr string
+}
+
+// AttrExpr compares an attribute with a value, like `userName eq "bjensen"` or `title pr`.
+type AttrExpr struct {
+	// Path is the attribute path.
+	Path AttrPath
+
+	// Op is the lower-case operator, one of "eq", "ne", "co", "sw", "ew", "gt", "ge", "lt", "le" and "pr".
+	Op string
+
+	// Value is the compared value, which is a string, a float64, a bool or nil. It's nil for "pr".
+	Value any
+}
+
+// LogExpr joins two filters with a logical operator.
+type LogExpr struct {
+	// Op is the lower-case operator, either "and" or "or".
+	Op string
+
+	// Left is the left operand.
+	Left Filter
+
+	// Right is the right operand.
+	Right Filter
+}
+
+// NotExpr negates a filter.
+type NotExpr struct {
+	// Filter is the negated filter.
+	Filter Filter
+}
+
+// ValuePathExpr matches the values of a multi-valued attribute, like `emails[type eq "work"]`.
+type ValuePathExpr struct {
+	// Path is the path of the multi-valued attribute.
+	Path AttrPath
+
+	// Filter is the filter applied to each value, whose attribute paths are relative to the value.
+	Filter Filter
+}
+
+var compareOps = map[string]bool{
+	"eq": true, "ne": true, "co": true, "sw": true, "ew": true, "gt": true, "ge": true, "lt": true, "le": true,
+}
+
+/*
+ParseFilter parses a SCIM filter.
+
+Params:
+  - s: The filter, e.g. `userName eq "bjensen" and not (emails[type eq "work"])`.
+
+Returns:
+  - Filter: The parsed filter.
+  - error: An [*Error] with the "invalidFilter" type if the filter is invalid.
+*/
+func ParseFilter(s string) (Filter, error) {
+	p := &parser{tokens: tokenize(s)}
+	f, err := p.parseOr()
+	if err == nil && p.pos < len(p.tokens) {
+		err = fmt.Errorf("unexpected %q", p.tokens[p.pos])
+	}
+	if err != nil {
+		return nil, &Error{http.StatusBadRequest, ErrTypeInvalidFilter, err.Error()}
+	}
+	return f, nil
+}
+
+// Match implements [Filter].
+func (e *AttrExpr) Match(r Resource) bool {
+	values := e.Path.values(r)
+	switch e.Op {
+	case "pr":
+		for _, v := range values {
+			if present(v) {
+				return true
+			}
+		}
+		return false
+	case "ne":
+		return !(&AttrExpr{e.Path, "eq", e.Value}).Match(r)
+	case "eq":
+		if e.Value == nil && len(values) == 0 {
+			return true
+		}
+	}
+	for _, v := range values {
+		if compare(v, e.Op, e.Value) {
+			return true
+		}
+	}
+	return false
+}
+
+// Match implements [Filter].
+func (e *LogExpr) Match(r Resource) bool {
+	if e.Op == "and" {
+		return e.Left.Match(r) && e.Right.Match(r)
+	}
+	return e.Left.Match(r) || e.Right.Match(r)
+}
+
+// Match implements [Filter].
+func (e *NotExpr) Match(r Resource) bool {
+	return !e.Filter.Match(r)
+}
+
+// Match implements [Filter].
+func (e *ValuePathExpr) Match(r Resource) bool {
+	for _, v := range e.Path.values(r) {
+		if m, ok := v.(map[string]any); ok && e.Filter.Match(m) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseAttrPath parses an attribute path.
+func parseAttrPath(s string) (AttrPath, error) {
+	p := AttrPath{}
+	if len(s) > 4 && strings.EqualFold(s[:4], "urn:") { // nolint:mnd
+		i := strings.LastIndexByte(s, ':')
+		p.URN, s = s[:i], s[i+1:]
+	}
+	p.Attr, p.SubAttr, _ = strings.Cut(s, ".")
+	if !validAttrName(p.Attr) || (p.SubAttr != "" && !validAttrName(p.SubAttr)) {
+		return p, fmt.Errorf("invalid attribute path %q", s)
+	}
+	return p, nil
+}
+
+// validAttrName reports whether s is a valid attribute name as defined in RFC 7643 section 2.1.
+func validAttrName(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i, c := range s {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z':
+		case i == 0 && c == '$': // e.g. $ref
+		case i > 0 && (c >= '0' && c <= '9' || c == '-' || c == '_'):
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// String returns the attribute path in the SCIM syntax.
+func (p AttrPath) String() string {
+	s := p.Attr
+	if p.URN != "" {
+		s = p.URN + ":" + s
+	}
+	if p.SubAttr != "" {
+		s += "." + p.SubAttr
+	}
+	return s
+}
+
+// container returns the object that contains the attribute, which is the extension object if the path is qualified
+// by an extension schema, or the resource itself otherwise. If the extension object doesn't exist, it's created if
+// create is true, or nil is returned otherwise.
+func (p AttrPath) container(r Resource, create bool) map[string]any {
+	if p.URN == "" || strings.EqualFold(p.URN, SchemaUser) || strings.EqualFold(p.URN, SchemaGroup) {
+		return r
+	}
+	key := findKey(r, p.URN)
+	if ext, ok := r[key].(map[string]any); ok || !create {
+		return ext
+	}
+	ext := map[string]any{}
+	r[key] = ext
+	return ext
+}
+
+// values returns the values of the attribute, where multi-valued attributes are flattened.
+func (p AttrPath) values(r Resource) []any {
+	v := lookup(p.container(r, false), p.Attr)
+	if p.SubAttr == "" {
+		return flatten(v)
+	}
+	values := []any{}
+	for _, elem := range flatten(v) {
+		if m, ok := elem.(map[string]any); ok {
+			values = append(values, flatten(lookup(m, p.SubAttr))...)
+		}
+	}
+	return values
+}
+
+// lookup returns the value of key in m, where keys are case-insensitive as defined in RFC 7643 section 2.1.
+func lookup(m map[string]any, key string) any {
+	if v, ok := m[key]; ok {
+		return v
+	}
+	for k, v := range m {
+		if strings.EqualFold(k, key) {
+			return v
+		}
+	}
+	return nil
+}
+
+// findKey returns the key in m that matches key case-insensitively, or key itself if there's none.
+func findKey(m map[string]any, key string) string {
+	if _, ok := m[key]; ok {
+		return key
+	}
+	for k := range m {
+		if strings.EqualFold(k, key) {
+			return k
+		}
+	}
+	return key
+}
+
+func flatten(v any) []any {
+	switch v := v.(type) {
+	case nil:
+		return nil
+	case []any:
+		return v
+	default:
+		return []any{v}
+	}
+}
+
+func present(v any) bool {
+	switch v := v.(type) {
+	case nil:
+		return false
+	case string:
+		return v != ""
+	case []any:
+		return len(v) > 0
+	case map[string]any:
+		return len(v) > 0
+	default:
+		return true
+	}
+}
+
+// compare compares an attribute value with a filter value. Strings are compared case-insensitively.
+func compare(v any, op string, want any) bool {
+	switch want := want.(type) {
+	case string:
+		s, ok := v.(string)
+		if !ok {
+			return false
+		}
+		s, want = strings.ToLower(s), strings.ToLower(want)
+		switch op {
+		case "eq":
+			return s == want
+		case "co":
+			return strings.Contains(s, want)
+		case "sw":
+			return strings.HasPrefix(s, want)
+		case "ew":
+			return strings.HasSuffix(s, want)
+		default:
+			return compareOrdered(strings.Compare(s, want), op)
+		}
+	case float64:
+		f, ok := v.(float64)
+		if !ok {
+			return false
+		}
+		if op == "eq" {
+			return f == want
+		}
+		return compareOrdered(cmpFloat(f, want), op)
+	default:
+		return op == "eq" && reflect.DeepEqual(v, want)
+	}
+}
+
+func cmpFloat(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func compareOrdered(c int, op string) bool {
+	switch op {
+	case "gt":
+		return c > 0
+	case "ge":
+		return c >= 0
+	case "lt":
+		return c < 0
+	case "le":
+		return c <= 0
+	default:
+		return false
+	}
+}
+
+// tokenize splits a filter into tokens, which are brackets, JSON strings, and words separated by spaces.
+func tokenize(s string) []string {
+	tokens := []string{}
+	for i := 0; i < len(s); {
+		switch c := s[i]; {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '(' || c == ')' || c == '[' || c == ']':
+			tokens = append(tokens, s[i:i+1])
+			i++
+		case c == '"':
+			j := i + 1
+			for j < len(s) && s[j] != '"' {
+				if s[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			j = min(j+1, len(s))
+			tokens = append(tokens, s[i:j])
+			i = j
+		default:
+			j := i
+			for j < len(s) && !strings.ContainsRune(" \t\n\r()[]\"", rune(s[j])) {
+				j++
+			}
+			tokens = append(tokens, s[i:j])
+			i = j
+		}
+	}
+	return tokens
+}
+
+type parser struct {
+	tokens []string
+	pos    int
+}
+
+func (p *parser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return ""
+}
+
+func (p *parser) next() string {
+	t := p.peek()
+	p.pos++
+	return t
+}
+
+func (p *parser) expect(t string) error {
+	if got := p.next(); got != t {
+		return fmt.Errorf("expect %q, got %q", t, got)
+	}
+	return nil
+}
+
+func (p *parser) parseOr() (Filter, error) {
+	return p.parseLogical("or", p.parseAnd)
+}
+
+func (p *parser) parseAnd() (Filter, error) {
+	return p.parseLogical("and", p.parseUnary)
+}
+
+func (p *parser) parseLogical(op string, operand func() (Filter, error)) (Filter, error) {
+	left, err := operand()
+	if err != nil {
+		return nil, err
+	}
+	for strings.EqualFold(p.peek(), op) {
+		p.pos++
+		right, err := operand()
+		if err != nil {
+			return nil, err
+		}
+		left = &LogExpr{op, left, right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseUnary() (Filter, error) {
+	switch t := p.next(); {
+	case t == "(":
+		f, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		return f, p.expect(")")
+	case strings.EqualFold(t, "not"):
+		if err := p.expect("("); err != nil {
+			return nil, err
+		}
+		f, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		return &NotExpr{f}, p.expect(")")
+	case t == "":
+		return nil, fmt.Errorf("unexpected end of filter")
+	default:
+		path, err := parseAttrPath(t)
+		if err != nil {
+			return nil, err
+		}
+		if p.peek() == "[" {
+			if path.SubAttr != "" {
+				return nil, fmt.Errorf("unexpected sub-attribute in value path %q", t)
+			}
+			p.pos++
+			f, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			return &ValuePathExpr{path, f}, p.expect("]")
+		}
+		return p.parseCompare(path)
+	}
+}
+
+func (p *parser) parseCompare(path AttrPath) (Filter, error) {
+	op := strings.ToLower(p.next())
+	if op == "pr" {
+		return &AttrExpr{path, op, nil}, nil
+	}
+	if !compareOps[op] {
+		return nil, fmt.Errorf("unknown operator %q", op)
+	}
+	t := p.next()
+	var v any
+	switch lower := strings.ToLower(t); {
+	case strings.HasPrefix(t, `"`):
+		var s string
+		if err := json.Unmarshal([]byte(t), &s); err != nil {
+			return nil, fmt.Errorf("invalid string %s", t)
+		}
+		v = s
+	case lower == "true" || lower == "false":
+		v = lower == "true"
+	case lower == "null":
+		if op != "eq" && op != "ne" {
+			return nil, fmt.Errorf("operator %q doesn't accept null", op)
+		}
+	default:
+		f, err := strconv.ParseFloat(t, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value %q", t)
+		}
+		v = f
+	}
+	if _, ok := v.(bool); ok && op != "eq" && op != "ne" {
+		return nil, fmt.Errorf("operator %q doesn't accept booleans", op)
+	}
+	return &AttrExpr{path, op, v}, nil
+}
@@ -0,0 +1,98 @@
+package scim_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/sainnhe/go-common/pkg/scim"
+)
+
+const testUser = `{
+	"schemas": ["urn:ietf:params:scim:schemas:core:2.0:User"],
+	"id": "2819c223",
+	"userName": "bjensen@example.com",
+	"name": {"familyName": "Jensen", "givenName": "Barbara"},
+	"title": "Tour Guide",
+	"active": true,
+	"loginCount": 42,
+	"emails": [
+		{"value": "bjensen@example.com", "type": "work", "primary": true},
+		{"value": "babs@jensen.org", "type": "home"}
+	],
+	"urn:ietf:params:scim:schemas:extension:enterprise:2.0:User": {"employeeNumber": "701984"}
+}`
+
+func decodeResource(t *testing.T, s string) scim.Resource {
+	t.Helper()
+
+	r := scim.Resource{}
+	if err := json.Unmarshal([]byte(s), &r); err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+func TestParseFilter(t *testing.T) {
+	t.Parallel()
+
+	r := decodeResource(t, testUser)
+	tests := []struct {
+		filter string
+		want   bool
+	}{
+		{`userName eq "bjensen@example.com"`, true},
+		{`USERNAME EQ "BJENSEN@EXAMPLE.COM"`, true},
+		{`userName ne "bjensen@example.com"`, false},
+		{`userName co "jensen"`, true},
+		{`userName sw "bjensen"`, true},
+		{`userName ew ".org"`, false},
+		{`name.familyName eq "Jensen"`, true},
+		{`urn:ietf:params:scim:schemas:core:2.0:User:name.givenName eq "Barbara"`, true},
+		{`urn:ietf:params:scim:schemas:extension:enterprise:2.0:User:employeeNumber eq "701984"`, true},
+		{`title pr`, true},
+		{`nickName pr`, false},
+		{`active eq true`, true},
+		{`active eq false`, false},
+		{`loginCount gt 40 and loginCount le 42`, true},
+		{`loginCount lt 42`, false},
+		{`emails.value eq "babs@jensen.org"`, true},
+		{`emails[type eq "work" and value co "@example.com"]`, true},
+		{`emails[type eq "work" and value co "@jensen.org"]`, false},
+		{`userName eq "x" or title eq "Tour Guide"`, true},
+		{`not (title eq "Tour Guide")`, false},
+		{`title eq "x" or not (userName eq "x") and active eq true`, true},
+		{`(title eq "x" or userName eq "x") and active eq true`, false},
+		{`nickName eq null`, true},
+		{`title eq null`, false},
+	}
+	for _, tt := range tests {
+		f, err := scim.ParseFilter(tt.filter)
+		if err != nil {
+			t.Fatalf("Parse %q failed: %+v", tt.filter, err)
+		}
+		if got := f.Match(r); got != tt.want {
+			t.Fatalf("Expect %q to match %t, got %t", tt.filter, tt.want, got)
+		}
+	}
+
+	for _, filter := range []string{
+		``,
+		`userName`,
+		`userName eq`,
+		`userName xx "a"`,
+		`userName eq "a" and`,
+		`(userName eq "a"`,
+		`emails[type eq "work"`,
+		`userName eq "a" "b"`,
+		`user$Name eq "a"`,
+		`userName eq unquoted`,
+	} {
+		_, err := scim.ParseFilter(filter)
+		e := &scim.Error{}
+		if !errors.As(err, &e) || e.Status != http.StatusBadRequest || e.Type != scim.ErrTypeInvalidFilter {
+			t.Fatalf("Expect invalid filter error for %q, got %+v", filter, err)
+		}
+	}
+}
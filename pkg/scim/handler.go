@@ -0,0 +1,399 @@
+package scim
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sainnhe/go-common/pkg/constant"
+	"github.com/sainnhe/go-common/pkg/log"
+)
+
+// maxBodySize is the max size of request bodies.
+const maxBodySize = 1 << 20
+
+// resourceType describes the endpoint of a resource type.
+type resourceType struct {
+	name     string
+	endpoint string
+	schema   string
+	nameAttr string
+}
+
+var resourceTypes = []*resourceType{
+	{ResourceTypeUser, "Users", SchemaUser, "userName"},
+	{ResourceTypeGroup, "Groups", SchemaGroup, "displayName"},
+}
+
+type handler struct {
+	cfg   *Config
+	store Store
+	l     *slog.Logger
+}
+
+/*
+NewHandler initializes a handler that serves the following SCIM endpoints, where {type} is either "Users" or "Groups":
+
+  - GET /ServiceProviderConfig
+  - GET /{type}: List resources with the "filter", "startIndex" and "count" query parameters.
+  - POST /{type}: Create a resource.
+  - GET /{type}/{id}: Get a resource.
+  - PUT /{type}/{id}: Replace a resource.
+  - PATCH /{type}/{id}: Modify a resource, see [Patch].
+  - DELETE /{type}/{id}: Delete a resource.
+
+The "userName" attribute of users must be unique case-insensitively, and the "password" attribute is discarded since
+authentication is expected to be done by the identity provider. Requests with the If-Match header are rejected with 412
+Precondition Failed if the resource has been modified.
+
+Params:
+  - cfg: The config.
+  - store: The store of resources.
+
+Returns:
+  - http.Handler: The handler.
+  - error: [constant.ErrNilDeps] if cfg or store is nil.
+*/
+func NewHandler(cfg *Config, store Store) (http.Handler, error) {
+	if cfg == nil || store == nil {
+		return nil, constant.ErrNilDeps
+	}
+	h := &handler{cfg, store, log.NewLogger(pkgName)}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /ServiceProviderConfig", h.serviceProviderConfig)
+	for _, rt := range resourceTypes {
+		mux.HandleFunc("GET /"+rt.endpoint, func(w http.ResponseWriter, r *http.Request) {
+			h.list(w, r, rt)
+		})
+		mux.HandleFunc("POST /"+rt.endpoint, func(w http.ResponseWriter, r *http.Request) {
+			h.create(w, r, rt)
+		})
+		mux.HandleFunc("GET /"+rt.endpoint+"/{id}", func(w http.ResponseWriter, r *http.Request) {
+			h.get(w, r, rt)
+		})
+		mux.HandleFunc("PUT /"+rt.endpoint+"/{id}", func(w http.ResponseWriter, r *http.Request) {
+			h.modify(w, r, rt, h.replace)
+		})
+		mux.HandleFunc("PATCH /"+rt.endpoint+"/{id}", func(w http.ResponseWriter, r *http.Request) {
+			h.modify(w, r, rt, h.patch)
+		})
+		mux.HandleFunc("DELETE /"+rt.endpoint+"/{id}", func(w http.ResponseWriter, r *http.Request) {
+			h.delete(w, r, rt)
+		})
+	}
+	return mux, nil
+}
+
+func (h *handler) serviceProviderConfig(w http.ResponseWriter, _ *http.Request) {
+	supported := func(ok bool) map[string]any {
+		return map[string]any{"supported": ok}
+	}
+	h.write(w, http.StatusOK, map[string]any{
+		"schemas":        []string{SchemaServiceProviderConfig},
+		"patch":          supported(true),
+		"bulk":           map[string]any{"supported": false, "maxOperations": 0, "maxPayloadSize": 0},
+		"filter":         map[string]any{"supported": true, "maxResults": h.cfg.MaxResults},
+		"changePassword": supported(false),
+		"sort":           supported(false),
+		"etag":           supported(true),
+		"authenticationSchemes": []map[string]any{{
+			"type":        "oauthbearertoken",
+			"name":        "OAuth Bearer Token",
+			"description": "Authentication scheme using the OAuth Bearer Token Standard",
+		}},
+	})
+}
+
+func (h *handler) list(w http.ResponseWriter, r *http.Request, rt *resourceType) {
+	query := r.URL.Query()
+	var filter Filter
+	if s := query.Get("filter"); s != "" {
+		f, err := ParseFilter(s)
+		if err != nil {
+			h.writeError(w, r, err)
+			return
+		}
+		filter = f
+	}
+	startIndex, count := 1, h.cfg.MaxResults
+	if i, err := strconv.Atoi(query.Get("startIndex")); err == nil && i > 1 {
+		startIndex = i
+	}
+	if c, err := strconv.Atoi(query.Get("count")); err == nil && c >= 0 && c < count {
+		count = c
+	}
+
+	dos, err := h.store.List(r.Context(), rt.name, filter)
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	matched := []Resource{}
+	for _, d := range dos {
+		res, err := h.render(rt, d)
+		if err != nil {
+			h.writeError(w, r, err)
+			return
+		}
+		if filter == nil || filter.Match(res) {
+			matched = append(matched, res)
+		}
+	}
+	lo := min(startIndex-1, len(matched))
+	page := matched[lo:min(lo+count, len(matched))]
+	h.write(w, http.StatusOK, map[string]any{
+		"schemas":      []string{SchemaListResponse},
+		"totalResults": len(matched),
+		"startIndex":   startIndex,
+		"itemsPerPage": len(page),
+		"Resources":    page,
+	})
+}
+
+func (h *handler) create(w http.ResponseWriter, r *http.Request, rt *resourceType) {
+	res := Resource{}
+	if err := decode(w, r, &res); err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	d := &ResourceDO{ResourceType: rt.name}
+	if err := h.fill(r.Context(), rt, d, res); err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	if err := h.store.Insert(r.Context(), d); err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	h.writeResource(w, r, http.StatusCreated, rt, d)
+}
+
+func (h *handler) get(w http.ResponseWriter, r *http.Request, rt *resourceType) {
+	d, err := h.load(r, rt)
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	h.writeResource(w, r, http.StatusOK, rt, d)
+}
+
+// modify loads the resource, checks the If-Match header, applies the modification and saves the resource.
+func (h *handler) modify(w http.ResponseWriter, r *http.Request, rt *resourceType,
+	apply func(w http.ResponseWriter, r *http.Request, res Resource) (Resource, error)) {
+	d, err := h.load(r, rt)
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	if err := checkVersion(r, d); err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	res := Resource{}
+	if err := json.Unmarshal([]byte(d.Data), &res); err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	if res, err = apply(w, r, res); err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	if err := h.fill(r.Context(), rt, d, res); err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	if err := h.store.Update(r.Context(), d); err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	h.writeResource(w, r, http.StatusOK, rt, d)
+}
+
+// replace replaces the resource with the request body.
+func (h *handler) replace(w http.ResponseWriter, r *http.Request, _ Resource) (Resource, error) {
+	res := Resource{}
+	if err := decode(w, r, &res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// patch applies the operations in the request body to the resource.
+func (h *handler) patch(w http.ResponseWriter, r *http.Request, res Resource) (Resource, error) {
+	body := struct {
+		Operations []PatchOp `json:"Operations"`
+	}{}
+	if err := decode(w, r, &body); err != nil {
+		return nil, err
+	}
+	if err := Patch(res, body.Operations); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (h *handler) delete(w http.ResponseWriter, r *http.Request, rt *resourceType) {
+	d, err := h.load(r, rt)
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	if err := checkVersion(r, d); err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	if err := h.store.Delete(r.Context(), d); err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// load loads the resource identified by the path.
+func (h *handler) load(r *http.Request, rt *resourceType) (*ResourceDO, error) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		return nil, ErrNotFound
+	}
+	d, err := h.store.QueryByID(r.Context(), id)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && d.ResourceType != rt.name) {
+		return nil, ErrNotFound
+	}
+	return d, err
+}
+
+// fill validates the resource and fills it into d.
+func (h *handler) fill(ctx context.Context, rt *resourceType, d *ResourceDO, res Resource) error {
+	for _, attr := range []string{"id", "meta", "schemas", "password"} {
+		delete(res, findKey(res, attr))
+	}
+	name, _ := lookup(res, rt.nameAttr).(string)
+	if strings.TrimSpace(name) == "" {
+		return &Error{http.StatusBadRequest, ErrTypeInvalidValue, rt.nameAttr + " is required"}
+	}
+	if rt.name == ResourceTypeUser && !strings.EqualFold(name, d.Name) {
+		dos, err := h.store.List(ctx, rt.name, &AttrExpr{AttrPath{Attr: rt.nameAttr}, "eq", name})
+		if err != nil {
+			return err
+		}
+		if slices.ContainsFunc(dos, func(other *ResourceDO) bool {
+			return other.ID != d.ID && other.Name == strings.ToLower(name)
+		}) {
+			return &Error{http.StatusConflict, ErrTypeUniqueness, fmt.Sprintf("%s %q is already taken", rt.nameAttr, name)}
+		}
+	}
+	data, err := json.Marshal(res)
+	if err != nil {
+		return err
+	}
+	d.ExternalID, _ = lookup(res, "externalId").(string)
+	d.Name = strings.ToLower(name)
+	d.Data = string(data)
+	return nil
+}
+
+// render renders the resource with the "id", "schemas" and "meta" attributes.
+func (h *handler) render(rt *resourceType, d *ResourceDO) (Resource, error) {
+	res := Resource{}
+	if err := json.Unmarshal([]byte(d.Data), &res); err != nil {
+		return nil, err
+	}
+	id := strconv.FormatInt(d.ID, 10)
+	schemas := []string{rt.schema}
+	for k, v := range res {
+		if _, ok := v.(map[string]any); ok && strings.HasPrefix(strings.ToLower(k), "urn:") {
+			schemas = append(schemas, k)
+		}
+	}
+	slices.Sort(schemas[1:])
+	meta := map[string]any{
+		"resourceType": rt.name,
+		"created":      d.CreateTime.UTC().Format(time.RFC3339),
+		"lastModified": d.UpdateTime.UTC().Format(time.RFC3339),
+		"version":      version(d),
+	}
+	if location := h.location(rt, d); location != "" {
+		meta["location"] = location
+	}
+	res["id"] = id
+	res["schemas"] = schemas
+	res["meta"] = meta
+	return res, nil
+}
+
+// location returns the URL of the resource, or an empty string if [Config.BaseURL] is empty.
+func (h *handler) location(rt *resourceType, d *ResourceDO) string {
+	if h.cfg.BaseURL == "" {
+		return ""
+	}
+	return strings.TrimSuffix(h.cfg.BaseURL, "/") + "/" + rt.endpoint + "/" + strconv.FormatInt(d.ID, 10)
+}
+
+// version returns the weak entity tag of the resource.
+func version(d *ResourceDO) string {
+	return fmt.Sprintf(`W/"%d"`, d.UpdateTime.UnixNano())
+}
+
+// checkVersion checks the If-Match header.
+func checkVersion(r *http.Request, d *ResourceDO) error {
+	ifMatch := r.Header.Get("If-Match")
+	if ifMatch == "" || ifMatch == "*" || slices.ContainsFunc(strings.Split(ifMatch, ","), func(tag string) bool {
+		return strings.TrimSpace(tag) == version(d)
+	}) {
+		return nil
+	}
+	return &Error{http.StatusPreconditionFailed, "", "resource has been modified"}
+}
+
+// decode decodes the request body.
+func decode(w http.ResponseWriter, r *http.Request, v any) error {
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodySize)).Decode(v); err != nil {
+		return &Error{http.StatusBadRequest, ErrTypeInvalidSyntax, err.Error()}
+	}
+	return nil
+}
+
+// writeResource writes the rendered resource with the Location and ETag headers.
+func (h *handler) writeResource(w http.ResponseWriter, r *http.Request, code int, rt *resourceType, d *ResourceDO) {
+	res, err := h.render(rt, d)
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	if location := h.location(rt, d); location != "" {
+		w.Header().Set("Location", location)
+	}
+	w.Header().Set("ETag", version(d))
+	h.write(w, code, res)
+}
+
+// writeError writes the error response. Errors other than [*Error] and [ErrNotFound] are logged and written as 500
+// Internal Server Error.
+func (h *handler) writeError(w http.ResponseWriter, r *http.Request, err error) {
+	e := &Error{}
+	switch {
+	case errors.As(err, &e):
+	case errors.Is(err, ErrNotFound):
+		e = &Error{http.StatusNotFound, "", err.Error()}
+	default:
+		h.l.ErrorContext(r.Context(), "Handle SCIM request failed.", constant.LogAttrMethod, r.Method,
+			constant.LogAttrError, err)
+		e = &Error{http.StatusInternalServerError, "", http.StatusText(http.StatusInternalServerError)}
+	}
+	h.write(w, e.Status, e.response())
+}
+
+// write writes v in JSON with the status code.
+func (h *handler) write(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", ContentType)
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(v)
+}
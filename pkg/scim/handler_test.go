@@ -0,0 +1,232 @@
+package scim_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/sainnhe/go-common/pkg/constant"
+	"github.com/sainnhe/go-common/pkg/db/dbtest"
+	"github.com/sainnhe/go-common/pkg/scim"
+)
+
+const ddl = `CREATE TABLE scim_resources (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	create_time DATETIME NOT NULL,
+	update_time DATETIME NOT NULL,
+	ext TEXT NOT NULL DEFAULT '',
+	resource_type TEXT NOT NULL,
+	external_id TEXT NOT NULL,
+	name TEXT NOT NULL,
+	data TEXT NOT NULL
+)`
+
+func TestNewHandler(t *testing.T) {
+	t.Parallel()
+
+	if _, err := scim.NewHandler(nil, nil); !errors.Is(err, constant.ErrNilDeps) {
+		t.Fatalf("Expect error %+v, got %+v", constant.ErrNilDeps, err)
+	}
+
+	sqlStore, err := scim.NewSQLStore(dbtest.NewPool(t, ddl), "scim_resources")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, store := range map[string]scim.Store{
+		"memory": scim.NewMemoryStore(),
+		"sql":    sqlStore,
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			testHandler(t, store)
+		})
+	}
+}
+
+func testHandler(t *testing.T, store scim.Store) {
+	t.Helper()
+
+	h, err := scim.NewHandler(&scim.Config{BaseURL: "https://example.com/scim/v2/", MaxResults: 2}, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	serve := func(method, path, body string, header ...string) (*httptest.ResponseRecorder, map[string]any) {
+		t.Helper()
+
+		r := httptest.NewRequestWithContext(context.Background(), method, path, strings.NewReader(body))
+		for i := 0; i+1 < len(header); i += 2 {
+			r.Header.Set(header[i], header[i+1])
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		if w.Code != http.StatusNoContent && w.Header().Get("Content-Type") != scim.ContentType {
+			t.Fatalf("Unexpected content type %q", w.Header().Get("Content-Type"))
+		}
+		resp := map[string]any{}
+		if w.Body.Len() > 0 {
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return w, resp
+	}
+	expectError := func(w *httptest.ResponseRecorder, resp map[string]any, code int, errType string) {
+		t.Helper()
+
+		if w.Code != code || resp["status"] != strconv.Itoa(code) ||
+			(errType != "" && resp["scimType"] != errType) {
+			t.Fatalf("Expect error %d %s, got %d %s", code, errType, w.Code, w.Body)
+		}
+	}
+
+	// Create
+	w, user := serve(http.MethodPost, "/Users", `{
+		"schemas": ["urn:ietf:params:scim:schemas:core:2.0:User"],
+		"externalId": "00u1",
+		"userName": "BJensen@example.com",
+		"password": "secret",
+		"emails": [{"value": "bjensen@example.com", "type": "work"}]
+	}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expect status %d, got %d %s", http.StatusCreated, w.Code, w.Body)
+	}
+	id, _ := user["id"].(string)
+	meta, _ := user["meta"].(map[string]any)
+	if id == "" || user["password"] != nil || meta["resourceType"] != scim.ResourceTypeUser ||
+		meta["location"] != "https://example.com/scim/v2/Users/"+id || w.Header().Get("Location") != meta["location"] ||
+		w.Header().Get("ETag") != meta["version"] {
+		t.Fatalf("Unexpected response %v %s", w.Header(), w.Body)
+	}
+	w, resp := serve(http.MethodPost, "/Users", `{"userName": "bjensen@EXAMPLE.com"}`)
+	expectError(w, resp, http.StatusConflict, scim.ErrTypeUniqueness)
+	if w, resp := serve(http.MethodPost, "/Users", `{"userName": "other", "id": "`+id+`"}`); w.Code !=
+		http.StatusCreated || resp["id"] == id {
+		t.Fatalf("Unexpected response %d %s", w.Code, w.Body)
+	}
+	w, resp = serve(http.MethodPost, "/Users", `{"displayName": "no userName"}`)
+	expectError(w, resp, http.StatusBadRequest, scim.ErrTypeInvalidValue)
+	w, resp = serve(http.MethodPost, "/Users", `{`)
+	expectError(w, resp, http.StatusBadRequest, scim.ErrTypeInvalidSyntax)
+	w, group := serve(http.MethodPost, "/Groups", `{
+		"displayName": "Tour Guides",
+		"members": [{"value": "`+id+`"}],
+		"urn:example:params:scim:schemas:extension:Group": {"owner": "bjensen"}
+	}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expect status %d, got %d %s", http.StatusCreated, w.Code, w.Body)
+	}
+	if schemas, _ := group["schemas"].([]any); len(schemas) != 2 || schemas[0] != scim.SchemaGroup {
+		t.Fatalf("Unexpected schemas %v", group["schemas"])
+	}
+	groupID, _ := group["id"].(string)
+
+	// Get
+	if w, resp := serve(http.MethodGet, "/Users/"+id, ""); w.Code != http.StatusOK || resp["userName"] !=
+		"BJensen@example.com" {
+		t.Fatalf("Unexpected response %d %s", w.Code, w.Body)
+	}
+	for _, path := range []string{"/Users/x", "/Users/9999", "/Groups/" + id} {
+		w, resp := serve(http.MethodGet, path, "")
+		expectError(w, resp, http.StatusNotFound, "")
+	}
+
+	// List
+	list := func(query string) (int, []string) {
+		t.Helper()
+
+		w, resp := serve(http.MethodGet, "/Users?"+query, "")
+		if w.Code != http.StatusOK {
+			t.Fatalf("Unexpected response %d %s", w.Code, w.Body)
+		}
+		total, _ := resp["totalResults"].(float64)
+		ids := []string{}
+		for _, r := range resp["Resources"].([]any) { // nolint:errcheck
+			ids = append(ids, r.(map[string]any)["id"].(string)) // nolint:errcheck
+		}
+		return int(total), ids
+	}
+	tests := []struct {
+		query string
+		total int
+		ids   int
+	}{
+		{"", 2, 2},
+		{"count=1", 2, 1},
+		{"count=0", 2, 0},
+		{"startIndex=2&count=10", 2, 1},
+		{"startIndex=3", 2, 0},
+		{"startIndex=9223372036854775807", 2, 0},
+		{"filter=" + url.QueryEscape(`userName eq "bjensen@example.com"`), 1, 1},
+		{"filter=" + url.QueryEscape(`id eq "`+id+`" and emails[type eq "work"]`), 1, 1},
+		{"filter=" + url.QueryEscape(`id eq "x"`), 0, 0},
+		{"filter=" + url.QueryEscape(`externalId eq "00u1"`), 1, 1},
+		{"filter=" + url.QueryEscape(`meta.resourceType eq "User"`), 2, 2},
+		{"filter=" + url.QueryEscape(`userName sw "other"`), 1, 1},
+	}
+	for _, tt := range tests {
+		if total, ids := list(tt.query); total != tt.total || len(ids) != tt.ids {
+			t.Fatalf("Expect %d/%d results for %q, got %d/%d", tt.ids, tt.total, tt.query, len(ids), total)
+		}
+	}
+	w, resp = serve(http.MethodGet, "/Users?filter="+url.QueryEscape(`userName eq`), "")
+	expectError(w, resp, http.StatusBadRequest, scim.ErrTypeInvalidFilter)
+
+	// Patch
+	w, resp = serve(http.MethodPatch, "/Users/"+id, `{
+		"schemas": ["urn:ietf:params:scim:api:messages:2.0:PatchOp"],
+		"Operations": [
+			{"op": "add", "path": "name", "value": {"givenName": "Barbara"}},
+			{"op": "replace", "path": "emails[type eq \"work\"].value", "value": "babs@example.com"},
+			{"op": "replace", "path": "userName", "value": "babs@example.com"}
+		]
+	}`)
+	if w.Code != http.StatusOK || resp["userName"] != "babs@example.com" ||
+		resp["name"].(map[string]any)["givenName"] != "Barbara" { // nolint:errcheck
+		t.Fatalf("Unexpected response %d %s", w.Code, w.Body)
+	}
+	if total, _ := list("filter=" + url.QueryEscape(`userName eq "babs@example.com"`)); total != 1 {
+		t.Fatalf("Expect user to be renamed")
+	}
+	w, resp = serve(http.MethodPatch, "/Users/"+id, `{"Operations": [{"op": "replace", "path": "emails[type eq \"x\"]",
+		"value": {}}]}`)
+	expectError(w, resp, http.StatusBadRequest, scim.ErrTypeNoTarget)
+	w, resp = serve(http.MethodPatch, "/Users/"+id, `{"Operations": [{"op": "replace", "path": "userName",
+		"value": "OTHER"}]}`)
+	expectError(w, resp, http.StatusConflict, scim.ErrTypeUniqueness)
+	w, resp = serve(http.MethodPatch, "/Groups/"+groupID, `{"Operations": [{"op": "remove",
+		"path": "members[value eq \"`+id+`\"]"}]}`)
+	if w.Code != http.StatusOK || len(resp["members"].([]any)) != 0 { // nolint:errcheck
+		t.Fatalf("Unexpected response %d %s", w.Code, w.Body)
+	}
+
+	// Replace
+	w, resp = serve(http.MethodPut, "/Users/"+id, `{"userName": "bjensen", "active": false}`, "If-Match", `W/"1"`)
+	expectError(w, resp, http.StatusPreconditionFailed, "")
+	w, _ = serve(http.MethodGet, "/Users/"+id, "")
+	etag := w.Header().Get("ETag")
+	w, resp = serve(http.MethodPut, "/Users/"+id, `{"userName": "bjensen", "active": false}`, "If-Match", etag)
+	if w.Code != http.StatusOK || resp["userName"] != "bjensen" || resp["active"] != false || resp["emails"] != nil ||
+		resp["id"] != id {
+		t.Fatalf("Unexpected response %d %s", w.Code, w.Body)
+	}
+
+	// Delete
+	if w, _ := serve(http.MethodDelete, "/Users/"+id, ""); w.Code != http.StatusNoContent {
+		t.Fatalf("Expect status %d, got %d", http.StatusNoContent, w.Code)
+	}
+	w, resp = serve(http.MethodDelete, "/Users/"+id, "")
+	expectError(w, resp, http.StatusNotFound, "")
+
+	// Service provider config
+	if w, resp := serve(http.MethodGet, "/ServiceProviderConfig", ""); w.Code != http.StatusOK ||
+		resp["filter"].(map[string]any)["maxResults"] != float64(2) { // nolint:errcheck
+		t.Fatalf("Unexpected response %d %s", w.Code, w.Body)
+	}
+}
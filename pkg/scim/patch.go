@@ -0,0 +1,267 @@
+package scim
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// PatchOp is an operation of a PATCH request, see RFC 7644 section 3.5.2.
+type PatchOp struct {
+	// Op is the operation, one of "add", "replace" and "remove", which is case-insensitive.
+	Op string `json:"op"`
+
+	// Path is the attribute path like `members[value eq "2819c223"].display`, which is optional for "add" and
+	// "replace".
+	Path string `json:"path,omitempty"`
+
+	// Value is the value to add or replace with.
+	Value any `json:"value,omitempty"`
+}
+
+// patchPath is a parsed PATCH path.
+type patchPath struct {
+	AttrPath
+
+	// filter is the value filter, which is nil if the path doesn't have one.
+	filter Filter
+
+	// valueSubAttr is the sub-attribute after the value filter.
+	valueSubAttr string
+}
+
+/*
+Patch applies PATCH operations to a resource in place.
+
+Params:
+  - r: The resource.
+  - ops: The operations.
+
+Returns:
+  - error: An [*Error] if an operation is invalid or has no target.
+*/
+func Patch(r Resource, ops []PatchOp) error {
+	for _, op := range ops {
+		if err := patch(r, op); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func patch(r Resource, op PatchOp) error {
+	kind := strings.ToLower(op.Op)
+	if kind != "add" && kind != "replace" && kind != "remove" {
+		return &Error{http.StatusBadRequest, ErrTypeInvalidSyntax, fmt.Sprintf("unknown operation %q", op.Op)}
+	}
+	if op.Path == "" {
+		if kind == "remove" {
+			return &Error{http.StatusBadRequest, ErrTypeNoTarget, "path is required for remove"}
+		}
+		value, ok := op.Value.(map[string]any)
+		if !ok {
+			return &Error{http.StatusBadRequest, ErrTypeInvalidValue, "value must be an object without path"}
+		}
+		for k, v := range value {
+			// Keys are usually attribute names or extension schemas, but some clients use attribute paths like
+			// "name.givenName" as well.
+			_, isExt := v.(map[string]any)
+			if p, err := parseAttrPath(k); err == nil && !(isExt && p.URN != "") && (p.URN != "" || p.SubAttr != "") {
+				_ = patchAttr(p.container(r, true), p, kind, v)
+				continue
+			}
+			setAttr(r, k, v, kind == "add")
+		}
+		return nil
+	}
+
+	p, err := parsePatchPath(op.Path)
+	if err != nil {
+		return err
+	}
+	obj := p.container(r, kind != "remove")
+	if p.filter == nil {
+		return patchAttr(obj, p.AttrPath, kind, op.Value)
+	}
+	return patchValues(obj, p, kind, op.Value)
+}
+
+// patchAttr applies an operation to an attribute without a value filter.
+func patchAttr(obj map[string]any, p AttrPath, kind string, value any) error {
+	if obj == nil {
+		return nil
+	}
+	p.URN = ""
+	if p.SubAttr != "" {
+		key := findKey(obj, p.Attr)
+		switch parent := obj[key].(type) {
+		case map[string]any:
+			obj = parent
+		case []any:
+			// Apply to every value of the multi-valued attribute.
+			for _, elem := range parent {
+				if m, ok := elem.(map[string]any); ok {
+					_ = patchAttr(m, AttrPath{Attr: p.SubAttr}, kind, value)
+				}
+			}
+			return nil
+		default:
+			if kind == "remove" {
+				return nil
+			}
+			m := map[string]any{}
+			obj[key] = m
+			obj = m
+		}
+		p = AttrPath{Attr: p.SubAttr}
+	}
+
+	key := findKey(obj, p.Attr)
+	if kind != "remove" {
+		setAttr(obj, key, value, kind == "add")
+		return nil
+	}
+	// Some clients remove values of multi-valued attributes by specifying them in the value, e.g.
+	// {"op": "remove", "path": "members", "value": [{"value": "2819c223"}]}.
+	existing, isArray := obj[key].([]any)
+	values, hasValues := value.([]any)
+	if !isArray || !hasValues {
+		delete(obj, key)
+		return nil
+	}
+	obj[key] = slices.DeleteFunc(existing, func(elem any) bool {
+		return slices.ContainsFunc(values, func(v any) bool {
+			return sameValue(elem, v)
+		})
+	})
+	return nil
+}
+
+// patchValues applies an operation to the values of a multi-valued attribute that match the value filter.
+func patchValues(obj map[string]any, p *patchPath, kind string, value any) error {
+	if obj == nil {
+		return nil
+	}
+	key := findKey(obj, p.Attr)
+	values, _ := obj[key].([]any)
+	matched := []int{}
+	for i, v := range values {
+		if m, ok := v.(map[string]any); ok && p.filter.Match(m) {
+			matched = append(matched, i)
+		}
+	}
+	if len(matched) == 0 {
+		if kind == "remove" {
+			return nil
+		}
+		return &Error{http.StatusBadRequest, ErrTypeNoTarget, fmt.Sprintf("no value matches %s", p.AttrPath)}
+	}
+
+	switch {
+	case kind == "remove" && p.valueSubAttr == "":
+		for _, i := range slices.Backward(matched) {
+			values = slices.Delete(values, i, i+1)
+		}
+		obj[key] = values
+	case p.valueSubAttr != "":
+		for _, i := range matched {
+			m, _ := values[i].(map[string]any)
+			sub := findKey(m, p.valueSubAttr)
+			if kind == "remove" {
+				delete(m, sub)
+			} else {
+				setAttr(m, sub, value, kind == "add")
+			}
+		}
+	case kind == "replace":
+		for _, i := range matched {
+			values[i] = value
+		}
+	default:
+		value, ok := value.(map[string]any)
+		if !ok {
+			return &Error{http.StatusBadRequest, ErrTypeInvalidValue, "value must be an object"}
+		}
+		for _, i := range matched {
+			m, _ := values[i].(map[string]any)
+			for k, v := range value {
+				setAttr(m, k, v, true)
+			}
+		}
+	}
+	return nil
+}
+
+// setAttr sets an attribute. Sub-attributes of complex attributes are merged, and values are appended to multi-valued
+// attributes if add is true.
+func setAttr(obj map[string]any, key string, value any, add bool) {
+	key = findKey(obj, key)
+	switch existing := obj[key].(type) {
+	case map[string]any:
+		if m, ok := value.(map[string]any); ok {
+			for k, v := range m {
+				setAttr(existing, k, v, add)
+			}
+			return
+		}
+	case []any:
+		if add {
+			for _, v := range flatten(value) {
+				if !slices.ContainsFunc(existing, func(e any) bool { return sameValue(e, v) }) {
+					existing = append(existing, v)
+				}
+			}
+			obj[key] = existing
+			return
+		}
+	}
+	obj[key] = value
+}
+
+// sameValue reports whether two values of a multi-valued attribute are the same, which are compared by their "value"
+// sub-attributes if they are complex.
+func sameValue(a, b any) bool {
+	am, aok := a.(map[string]any)
+	bm, bok := b.(map[string]any)
+	if aok && bok {
+		if av, bv := lookup(am, "value"), lookup(bm, "value"); av != nil || bv != nil {
+			return reflect.DeepEqual(av, bv)
+		}
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// parsePatchPath parses a PATCH path as defined in RFC 7644 section 3.5.2.
+func parsePatchPath(s string) (*patchPath, error) {
+	invalid := func(err error) error {
+		return &Error{http.StatusBadRequest, ErrTypeInvalidPath, err.Error()}
+	}
+	attr, rest, hasFilter := strings.Cut(s, "[")
+	path, err := parseAttrPath(attr)
+	if err != nil {
+		return nil, invalid(err)
+	}
+	p := &patchPath{AttrPath: path}
+	if !hasFilter {
+		return p, nil
+	}
+	if path.SubAttr != "" {
+		return nil, invalid(fmt.Errorf("unexpected sub-attribute in value path %q", s))
+	}
+	i := strings.LastIndexByte(rest, ']')
+	if i < 0 {
+		return nil, invalid(fmt.Errorf("missing ] in %q", s))
+	}
+	if p.filter, err = ParseFilter(rest[:i]); err != nil {
+		return nil, invalid(err)
+	}
+	if sub := rest[i+1:]; sub != "" {
+		if !strings.HasPrefix(sub, ".") || !validAttrName(sub[1:]) {
+			return nil, invalid(fmt.Errorf("invalid sub-attribute %q", sub))
+		}
+		p.valueSubAttr = sub[1:]
+	}
+	return p, nil
+}
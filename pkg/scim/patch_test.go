@@ -0,0 +1,120 @@
+package scim_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/sainnhe/go-common/pkg/scim"
+)
+
+func TestPatch(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		ops  string
+		want string
+	}{
+		{
+			"replace attribute",
+			`[{"op": "Replace", "path": "title", "value": "Manager"}]`,
+			`{"title": "Manager"}`,
+		},
+		{
+			"replace sub-attribute",
+			`[{"op": "replace", "path": "name.givenName", "value": "Babs"}]`,
+			`{"name": {"familyName": "Jensen", "givenName": "Babs"}}`,
+		},
+		{
+			"replace without path",
+			`[{"op": "replace", "value": {"active": false, "name.familyName": "Smith",
+				"urn:ietf:params:scim:schemas:extension:enterprise:2.0:User": {"department": "Sales"}}}]`,
+			`{"active": false, "name": {"familyName": "Smith", "givenName": "Barbara"},
+				"urn:ietf:params:scim:schemas:extension:enterprise:2.0:User": {"employeeNumber": "701984",
+				"department": "Sales"}}`,
+		},
+		{
+			"replace extension attribute",
+			`[{"op": "replace",
+				"path": "urn:ietf:params:scim:schemas:extension:enterprise:2.0:User:employeeNumber",
+				"value": "1"}]`,
+			`{"urn:ietf:params:scim:schemas:extension:enterprise:2.0:User": {"employeeNumber": "1"}}`,
+		},
+		{
+			"add to multi-valued attribute",
+			`[{"op": "add", "path": "emails", "value": [{"value": "babs@jensen.org", "type": "home"},
+				{"value": "b@example.org", "type": "other"}]}]`,
+			`{"emails": [{"value": "bjensen@example.com", "type": "work", "primary": true},
+				{"value": "babs@jensen.org", "type": "home"}, {"value": "b@example.org", "type": "other"}]}`,
+		},
+		{
+			"replace filtered sub-attribute",
+			`[{"op": "replace", "path": "emails[type eq \"work\"].value", "value": "b@example.com"}]`,
+			`{"emails": [{"value": "b@example.com", "type": "work", "primary": true},
+				{"value": "babs@jensen.org", "type": "home"}]}`,
+		},
+		{
+			"remove filtered values",
+			`[{"op": "remove", "path": "emails[type eq \"home\"]"}]`,
+			`{"emails": [{"value": "bjensen@example.com", "type": "work", "primary": true}]}`,
+		},
+		{
+			"remove values by value",
+			`[{"op": "remove", "path": "emails", "value": [{"value": "bjensen@example.com"}]}]`,
+			`{"emails": [{"value": "babs@jensen.org", "type": "home"}]}`,
+		},
+		{
+			"remove attribute",
+			`[{"op": "remove", "path": "name.givenName"}, {"op": "remove", "path": "title"}]`,
+			`{"name": {"familyName": "Jensen"}, "title": null}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := decodeResource(t, testUser)
+			ops := []scim.PatchOp{}
+			if err := json.Unmarshal([]byte(tt.ops), &ops); err != nil {
+				t.Fatal(err)
+			}
+			if err := scim.Patch(r, ops); err != nil {
+				t.Fatal(err)
+			}
+			// Attributes not mentioned in want are unchanged, and null means removed.
+			want := decodeResource(t, testUser)
+			for k, v := range decodeResource(t, tt.want) {
+				if v == nil {
+					delete(want, k)
+				} else {
+					want[k] = v
+				}
+			}
+			if !reflect.DeepEqual(r, want) {
+				t.Fatalf("Expect %+v, got %+v", want, r)
+			}
+		})
+	}
+
+	for _, tt := range []struct {
+		op      scim.PatchOp
+		errType string
+	}{
+		{scim.PatchOp{Op: "move", Path: "title"}, scim.ErrTypeInvalidSyntax},
+		{scim.PatchOp{Op: "remove"}, scim.ErrTypeNoTarget},
+		{scim.PatchOp{Op: "replace", Value: "x"}, scim.ErrTypeInvalidValue},
+		{scim.PatchOp{Op: "replace", Path: "emails[type eq \"other\"]", Value: "x"}, scim.ErrTypeNoTarget},
+		{scim.PatchOp{Op: "replace", Path: "emails[type eq]", Value: "x"}, scim.ErrTypeInvalidPath},
+		{scim.PatchOp{Op: "replace", Path: "emails[type eq \"work\"", Value: "x"}, scim.ErrTypeInvalidPath},
+		{scim.PatchOp{Op: "replace", Path: "emails[type eq \"work\"]value", Value: "x"}, scim.ErrTypeInvalidPath},
+	} {
+		err := scim.Patch(decodeResource(t, testUser), []scim.PatchOp{tt.op})
+		e := &scim.Error{}
+		if !errors.As(err, &e) || e.Status != http.StatusBadRequest || e.Type != tt.errType {
+			t.Fatalf("Expect %s error for %+v, got %+v", tt.errType, tt.op, err)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+/*
+Package scim implements a SCIM 2.0 service provider, see RFC 7643 and RFC 7644, so that identity providers like Okta
+and Microsoft Entra ID can provision users and groups of an enterprise directory.
+
+Resources are stored as JSON documents in a pluggable [Store] built on [db.Repo], and [NewHandler] serves the Users and
+Groups endpoints with filtering, pagination and PATCH:
+
+	store, err := scim.NewSQLStore(pool, "scim_resources")
+	h, err := scim.NewHandler(cfg, store)
+
+	mux := http.NewServeMux()
+	mux.Handle("/scim/v2/", authn(http.StripPrefix("/scim/v2", h)))
+
+The handler doesn't authenticate requests, which should be done by a middleware, typically with the bearer token
+configured in the identity provider.
+*/
+package scim
+
+import (
+	"errors"
+	"strconv"
+)
+
+const pkgName = "github.com/sainnhe/go-common/pkg/scim"
+
+// Schema URNs defined in RFC 7643 and RFC 7644.
+const (
+	SchemaUser                  = "urn:ietf:params:scim:schemas:core:2.0:User"
+	SchemaGroup                 = "urn:ietf:params:scim:schemas:core:2.0:Group"
+	SchemaEnterpriseUser        = "urn:ietf:params:scim:schemas:extension:enterprise:2.0:User"
+	SchemaServiceProviderConfig = "urn:ietf:params:scim:schemas:core:2.0:ServiceProviderConfig"
+	SchemaListResponse          = "urn:ietf:params:scim:api:messages:2.0:ListResponse"
+	SchemaPatchOp               = "urn:ietf:params:scim:api:messages:2.0:PatchOp"
+	SchemaError                 = "urn:ietf:params:scim:api:messages:2.0:Error"
+)
+
+// Resource types.
+const (
+	ResourceTypeUser  = "User"
+	ResourceTypeGroup = "Group"
+)
+
+// Error types defined in RFC 7644 section 3.12, which are set to the "scimType" attribute of error responses.
+const (
+	ErrTypeInvalidFilter = "invalidFilter"
+	ErrTypeInvalidSyntax = "invalidSyntax"
+	ErrTypeInvalidPath   = "invalidPath"
+	ErrTypeInvalidValue  = "invalidValue"
+	ErrTypeNoTarget      = "noTarget"
+	ErrTypeUniqueness    = "uniqueness"
+)
+
+// ContentType is the media type of SCIM messages.
+const ContentType = "application/scim+json"
+
+// ErrNotFound indicates that the resource doesn't exist.
+var ErrNotFound = errors.New("resource not found")
+
+// Resource is a SCIM resource decoded from JSON, whose attribute names are case-insensitive.
+type Resource map[string]any
+
+// Error is a SCIM error, which is written as the error response defined in RFC 7644 section 3.12.
+type Error struct {
+	// Status is the HTTP status code.
+	Status int
+
+	// Type is the SCIM error type like [ErrTypeInvalidFilter], which is empty if not applicable.
+	Type string
+
+	// Detail is the human-readable message.
+	Detail string
+}
+
+// Error implements error.
+func (e *Error) Error() string {
+	if e.Type == "" {
+		return e.Detail
+	}
+	return e.Type + ": " + e.Detail
+}
+
+// errorResponse is the JSON representation of [Error].
+type errorResponse struct {
+	Schemas []string `json:"schemas"`
+	Status  string   `json:"status"`
+	Type    string   `json:"scimType,omitempty"`
+	Detail  string   `json:"detail,omitempty"`
+}
+
+func (e *Error) response() *errorResponse {
+	return &errorResponse{[]string{SchemaError}, strconv.Itoa(e.Status), e.Type, e.Detail}
+}
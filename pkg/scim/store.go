@@ -0,0 +1,190 @@
+package scim
+
+import (
+	"context"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/sainnhe/go-common/pkg/db"
+)
+
+/*
+ResourceDO is the data object of SCIM resources, which can be stored in a table like:
+
+	CREATE TABLE scim_resources (
+		id BIGINT PRIMARY KEY AUTO_INCREMENT,
+		create_time DATETIME NOT NULL,
+		update_time DATETIME NOT NULL,
+		ext JSON NOT NULL,
+		resource_type VARCHAR(16) NOT NULL,
+		external_id VARCHAR(255) NOT NULL,
+		name VARCHAR(255) NOT NULL,
+		data JSON NOT NULL,
+		INDEX idx_name (resource_type, name),
+		INDEX idx_external_id (resource_type, external_id)
+	);
+*/
+type ResourceDO struct {
+	db.DO
+
+	// ResourceType is the resource type, either [ResourceTypeUser] or [ResourceTypeGroup].
+	ResourceType string `db:"resource_type"`
+
+	// ExternalID is the "externalId" attribute, which is the identifier of the resource in the identity provider.
+	ExternalID string `db:"external_id"`
+
+	// Name is the lower-cased "userName" attribute of users or "displayName" attribute of groups.
+	Name string `db:"name"`
+
+	// Data is the resource in JSON, excluding the "id", "meta" and "schemas" attributes.
+	Data string `db:"data"`
+}
+
+// ResourceDOCols contains the column names of [ResourceDO].
+var ResourceDOCols = append(slices.Clone(db.DOCols), "resource_type", "external_id", "name", "data")
+
+// Store is the storage of SCIM resources.
+type Store interface {
+	db.Repo[ResourceDO]
+
+	// List lists resources of the given type that match the filter in the order of IDs. Resources that don't match the
+	// filter may be returned as well, since the handler applies the filter again. If filter is nil, all resources of the
+	// given type are listed.
+	List(ctx context.Context, resourceType string, filter Filter) ([]*ResourceDO, error)
+}
+
+type sqlStore struct {
+	*db.SQLRepo[ResourceDO]
+	pool *sqlx.DB
+}
+
+/*
+NewSQLStore initializes a new [Store] backed by [db.SQLRepo], which narrows down queries with the "eq" comparisons of
+"id", "externalId", "userName" and "displayName" that are joined by "and" at the top level of filters.
+
+Params:
+  - pool: The connection pool.
+  - tbl: The table name, see [ResourceDO] for the table structure.
+  - opts: The options of the repo.
+
+Returns:
+  - Store: The store.
+  - error: An error if the repo can't be initialized.
+*/
+func NewSQLStore(pool *sqlx.DB, tbl string, opts ...db.RepoOption) (Store, error) {
+	repo, err := db.NewRepo[ResourceDO](pool, tbl, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &sqlStore{repo, pool}, nil
+}
+
+func (s *sqlStore) List(ctx context.Context, resourceType string, filter Filter) ([]*ResourceDO, error) {
+	conds, ok := eqConds(resourceType, filter)
+	if !ok {
+		return nil, nil
+	}
+	where := []db.Cond{db.KV{Key: "resource_type", Val: db.Placeholder}}
+	args := []any{resourceType}
+	for _, c := range conds {
+		where = append(where, db.KV{Key: c.col, Val: db.Placeholder})
+		args = append(args, c.val)
+	}
+	stmt := s.StmtBuilder().BuildQueryCondStmt(ResourceDOCols, db.And(where...), db.WithOrderBy(db.Asc("id")))
+	dos := []*ResourceDO{}
+	if err := s.pool.SelectContext(ctx, &dos, stmt, args...); err != nil {
+		return nil, err
+	}
+	return dos, nil
+}
+
+type memoryStore struct {
+	*db.MemoryRepo[ResourceDO]
+}
+
+// NewMemoryStore initializes a new [Store] backed by [db.MemoryRepo], which is intended to be used in tests.
+func NewMemoryStore() Store {
+	return &memoryStore{db.NewMemoryRepo[ResourceDO]()}
+}
+
+func (s *memoryStore) List(ctx context.Context, resourceType string, filter Filter) ([]*ResourceDO, error) {
+	conds, ok := eqConds(resourceType, filter)
+	if !ok {
+		return nil, nil
+	}
+	return s.MemoryRepo.List(ctx, db.WithMemoryFilter(func(d *ResourceDO) bool {
+		return d.ResourceType == resourceType && !slices.ContainsFunc(conds, func(c eqCond) bool {
+			return d.column(c.col) != c.val
+		})
+	}))
+}
+
+// column returns the value of an indexed column.
+func (d *ResourceDO) column(col string) any {
+	switch col {
+	case "id":
+		return d.ID
+	case "external_id":
+		return d.ExternalID
+	default:
+		return d.Name
+	}
+}
+
+// eqCond is an equality condition of a column.
+type eqCond struct {
+	col string
+	val any
+}
+
+// eqConds extracts the equality conditions of indexed columns from the top-level "and" chain of the filter. If false is
+// returned, the filter doesn't match any resource.
+func eqConds(resourceType string, filter Filter) ([]eqCond, bool) {
+	nameAttr := "userName"
+	if resourceType == ResourceTypeGroup {
+		nameAttr = "displayName"
+	}
+	conds := []eqCond{}
+	var walk func(f Filter) bool
+	walk = func(f Filter) bool {
+		switch f := f.(type) {
+		case *LogExpr:
+			if f.Op == "and" {
+				return walk(f.Left) && walk(f.Right)
+			}
+		case *AttrExpr:
+			value, ok := f.Value.(string)
+			if f.Op != "eq" || !ok || f.Path.SubAttr != "" ||
+				(f.Path.URN != "" && !strings.EqualFold(f.Path.URN, schemaOf(resourceType))) {
+				return true
+			}
+			switch {
+			case strings.EqualFold(f.Path.Attr, "id"):
+				id, err := strconv.ParseInt(value, 10, 64)
+				if err != nil {
+					return false
+				}
+				conds = append(conds, eqCond{"id", id})
+			case strings.EqualFold(f.Path.Attr, "externalId"):
+				conds = append(conds, eqCond{"external_id", value})
+			case strings.EqualFold(f.Path.Attr, nameAttr):
+				conds = append(conds, eqCond{"name", strings.ToLower(value)})
+			}
+		}
+		return true
+	}
+	if filter != nil && !walk(filter) {
+		return nil, false
+	}
+	return conds, true
+}
+
+// schemaOf returns the core schema of the resource type.
+func schemaOf(resourceType string) string {
+	if resourceType == ResourceTypeGroup {
+		return SchemaGroup
+	}
+	return SchemaUser
+}
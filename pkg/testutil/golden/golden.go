@@ -0,0 +1,151 @@
+// Package golden implements helpers that compare test outputs against golden files.
+//
+// Golden files are stored in the "testdata" directory of the package under test with the ".golden" extension. Run
+// tests with the GOLDEN_UPDATE environment variable set to "1" to create or update them:
+//
+//	GOLDEN_UPDATE=1 go test ./...
+//
+// This package doesn't register any flags, so that importing it doesn't clash with flags of other packages. If the test
+// binary defines a boolean -update flag, for example in a _test.go file of the package under test, it's honored too:
+//
+//	var _ = flag.Bool("update", false, "update golden files")
+//
+//	go test ./pkg/db -update
+package golden
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+)
+
+// Option is the option used to customize the comparison.
+type Option func(opts *options)
+
+type options struct {
+	dir       string
+	json      bool
+	redactors []func([]byte) []byte
+}
+
+// WithDir sets the directory of golden files. Defaults to "testdata".
+func WithDir(dir string) Option {
+	return func(opts *options) {
+		opts.dir = dir
+	}
+}
+
+// WithJSON normalizes the output as JSON before comparison, so that the formatting and the order of object keys don't
+// matter.
+func WithJSON() Option {
+	return func(opts *options) {
+		opts.json = true
+	}
+}
+
+// WithRedactor adds a hook that redacts volatile or sensitive parts of the output, such as timestamps and tokens.
+// Redactors are applied in order before the JSON normalization.
+func WithRedactor(redactor func(b []byte) []byte) Option {
+	return func(opts *options) {
+		if redactor != nil {
+			opts.redactors = append(opts.redactors, redactor)
+		}
+	}
+}
+
+// WithRedactRegexp replaces all matches of re with repl, see [regexp.Regexp.ReplaceAll].
+func WithRedactRegexp(re *regexp.Regexp, repl string) Option {
+	return WithRedactor(func(b []byte) []byte {
+		return re.ReplaceAll(b, []byte(repl))
+	})
+}
+
+// Path returns the path of the golden file with the given name.
+func Path(name string, opts ...Option) string {
+	return filepath.Join(newOptions(opts).dir, name+".golden")
+}
+
+// Updating reports whether golden files should be updated instead of compared, i.e. the GOLDEN_UPDATE environment
+// variable is "1", or the -update flag defined by the test binary is true.
+func Updating() bool {
+	if os.Getenv("GOLDEN_UPDATE") == "1" {
+		return true
+	}
+	f := flag.Lookup("update")
+	return f != nil && f.Value.String() == "true"
+}
+
+// Assert compares got with the content of the golden file with the given name, and fails the test if they differ.
+// If [Updating] returns true, the golden file will be overwritten with got instead.
+func Assert(t testing.TB, name string, got []byte, opts ...Option) {
+	t.Helper()
+
+	o := newOptions(opts)
+	got = normalize(t, got, o)
+	path := filepath.Join(o.dir, name+".golden")
+
+	if Updating() {
+		if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil { // nolint:mnd
+			t.Fatalf("Create golden file directory failed: %+v", err)
+		}
+		if err := os.WriteFile(path, got, 0600); err != nil { // nolint:mnd
+			t.Fatalf("Update golden file failed: %+v", err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path) // nolint:gosec
+	if err != nil {
+		t.Fatalf("Read golden file failed: %+v\nRun tests with GOLDEN_UPDATE=1 to create it.", err)
+	}
+	if !bytes.Equal(want, got) {
+		t.Fatalf("Output doesn't match golden file %s\nWant:\n%s\nGot:\n%s", path, want, got)
+	}
+}
+
+// AssertString is like [Assert] but takes a string.
+func AssertString(t testing.TB, name, got string, opts ...Option) {
+	t.Helper()
+	Assert(t, name, []byte(got), opts...)
+}
+
+// AssertJSON marshals v as JSON and compares it via [Assert] with [WithJSON] enabled.
+func AssertJSON(t testing.TB, name string, v any, opts ...Option) {
+	t.Helper()
+	b, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("Marshal JSON failed: %+v", err)
+	}
+	Assert(t, name, b, append(opts, WithJSON())...)
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{dir: "testdata"}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+func normalize(t testing.TB, b []byte, o *options) []byte {
+	t.Helper()
+	for _, redactor := range o.redactors {
+		b = redactor(b)
+	}
+	if !o.json {
+		return b
+	}
+	var v any
+	if err := json.Unmarshal(b, &v); err != nil {
+		t.Fatalf("Normalize JSON failed: %+v", err)
+	}
+	normalized, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		t.Fatalf("Normalize JSON failed: %+v", err)
+	}
+	return append(normalized, '\n')
+}
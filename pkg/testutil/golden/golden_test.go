@@ -0,0 +1,116 @@
+package golden_test
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"testing"
+
+	"github.com/sainnhe/go-common/pkg/testutil/golden"
+)
+
+func TestAssert(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "text.golden"), []byte("token=<redacted>"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "json.golden"),
+		[]byte("{\n  \"a\": 1,\n  \"b\": [\n    true\n  ]\n}\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if p := golden.Path("text", golden.WithDir(dir)); p != filepath.Join(dir, "text.golden") {
+		t.Fatalf("Got %s", p)
+	}
+
+	golden.AssertString(t, "text", "token=abc123",
+		golden.WithDir(dir),
+		golden.WithRedactRegexp(regexp.MustCompile(`token=\w+`), "token=<redacted>"),
+		golden.WithRedactor(nil),
+	)
+	golden.Assert(t, "json", []byte(`{"b":[true],"a":1}`), golden.WithDir(dir), golden.WithJSON())
+	golden.AssertJSON(t, "json", map[string]any{"a": 1, "b": []bool{true}}, golden.WithDir(dir))
+}
+
+var update = flag.Bool("update", false, "update golden files")
+
+func TestUpdating(t *testing.T) { // nolint:paralleltest
+	if *update || os.Getenv("GOLDEN_UPDATE") == "1" {
+		t.Skip("Golden files are being updated")
+	}
+
+	if golden.Updating() {
+		t.Fatal("Expect not updating by default")
+	}
+	t.Setenv("GOLDEN_UPDATE", "1")
+	if !golden.Updating() {
+		t.Fatal("Expect updating with GOLDEN_UPDATE=1")
+	}
+	t.Setenv("GOLDEN_UPDATE", "")
+	if err := flag.Set("update", "true"); err != nil {
+		t.Fatal(err)
+	}
+	defer flag.Set("update", "false") // nolint:errcheck
+	if !golden.Updating() {
+		t.Fatal("Expect updating with -update")
+	}
+}
+
+func TestAssert_mismatch(t *testing.T) {
+	t.Parallel()
+
+	if golden.Updating() {
+		t.Skip("Golden files are being updated")
+	}
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "text.golden"), []byte("want"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		f    func(tb testing.TB)
+	}{
+		{"Mismatch", func(tb testing.TB) { golden.AssertString(tb, "text", "got", golden.WithDir(dir)) }},
+		{"Missing", func(tb testing.TB) { golden.AssertString(tb, "none", "got", golden.WithDir(dir)) }},
+		{"Invalid JSON", func(tb testing.TB) {
+			golden.AssertString(tb, "text", "{", golden.WithDir(dir), golden.WithJSON())
+		}},
+		{"Unmarshalable", func(tb testing.TB) { golden.AssertJSON(tb, "text", make(chan int), golden.WithDir(dir)) }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ft := &fakeTB{TB: t}
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				tt.f(ft)
+			}()
+			<-done
+			if !ft.failed {
+				t.Fatal("Expect failure")
+			}
+		})
+	}
+}
+
+// fakeTB records failures instead of failing the test.
+type fakeTB struct {
+	testing.TB
+	failed bool
+}
+
+func (f *fakeTB) Helper() {}
+
+func (f *fakeTB) Fatalf(_ string, _ ...any) {
+	f.failed = true
+	runtime.Goexit()
+}
@@ -0,0 +1,328 @@
+// Package vcr implements an HTTP test harness that records HTTP interactions to disk and replays them in later runs,
+// so that tests for third-party API wrappers are hermetic and fast.
+//
+// A [Recorder] is an [http.RoundTripper]. In record mode, requests are sent via the real transport and the
+// interactions are saved to a cassette file when [Recorder.Stop] is called. In replay mode, responses are served from
+// the cassette and no real requests will be sent.
+//
+// Sensitive headers like "Authorization" are scrubbed before saving, and custom scrubbers can be added via
+// [WithScrubber] to remove secrets from URLs and bodies.
+package vcr
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+// Mode is the mode of a [Recorder].
+type Mode int
+
+const (
+	// ModeAuto replays interactions if the cassette exists, and records them otherwise.
+	ModeAuto Mode = 0
+
+	// ModeRecord always sends real requests and overwrites the cassette.
+	ModeRecord Mode = 1
+
+	// ModeReplay always replays interactions and fails if no interaction matches.
+	ModeReplay Mode = 2
+)
+
+// ScrubbedValue is the value used to replace scrubbed header values.
+const ScrubbedValue = "[SCRUBBED]"
+
+// ErrNoInteraction indicates that no recorded interaction matches the request in replay mode.
+var ErrNoInteraction = errors.New("no matching interaction")
+
+// DefaultScrubbedHeaders contains the headers that are scrubbed by default.
+var DefaultScrubbedHeaders = []string{
+	"Authorization",
+	"Cookie",
+	"Set-Cookie",
+	"Proxy-Authorization",
+	"X-Api-Key",
+	"X-Auth-Token",
+}
+
+// Request is a recorded HTTP request. The body is encoded in base64 in the cassette, so that binary and compressed
+// bodies are kept intact.
+type Request struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Header http.Header `json:"header"`
+	Body   []byte      `json:"body"`
+}
+
+// Response is a recorded HTTP response. The body is encoded in base64 in the cassette.
+type Response struct {
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header"`
+	Body       []byte      `json:"body"`
+}
+
+// Interaction is a recorded pair of request and response.
+type Interaction struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+// Matcher reports whether a recorded request matches an incoming one.
+type Matcher func(recorded, incoming *Request) bool
+
+// DefaultMatcher matches requests by method, URL and body.
+func DefaultMatcher(recorded, incoming *Request) bool {
+	return recorded.Method == incoming.Method && recorded.URL == incoming.URL &&
+		bytes.Equal(recorded.Body, incoming.Body)
+}
+
+// Option is the option used to customize a [Recorder].
+type Option func(r *Recorder)
+
+// WithMode sets the mode. Defaults to [ModeAuto], and can be overridden by setting the VCR_MODE environment variable to
+// "record" or "replay".
+func WithMode(mode Mode) Option {
+	return func(r *Recorder) {
+		r.mode = mode
+	}
+}
+
+// WithTransport sets the real transport used in record mode. Defaults to [http.DefaultTransport].
+func WithTransport(transport http.RoundTripper) Option {
+	return func(r *Recorder) {
+		if transport != nil {
+			r.transport = transport
+		}
+	}
+}
+
+// WithMatcher sets the matcher used in replay mode. Defaults to [DefaultMatcher].
+func WithMatcher(matcher Matcher) Option {
+	return func(r *Recorder) {
+		if matcher != nil {
+			r.matcher = matcher
+		}
+	}
+}
+
+// WithScrubber adds a hook that removes secrets from an interaction before it's saved. Scrubbers are also applied to
+// incoming requests before matching, so that scrubbed values can still be matched in replay mode.
+func WithScrubber(scrubber func(i *Interaction)) Option {
+	return func(r *Recorder) {
+		if scrubber != nil {
+			r.scrubbers = append(r.scrubbers, scrubber)
+		}
+	}
+}
+
+// WithScrubbedHeaders replaces the headers that are scrubbed. Defaults to [DefaultScrubbedHeaders].
+func WithScrubbedHeaders(headers ...string) Option {
+	return func(r *Recorder) {
+		r.headers = headers
+	}
+}
+
+// Recorder records and replays HTTP interactions.
+type Recorder struct {
+	path         string
+	mode         Mode
+	transport    http.RoundTripper
+	matcher      Matcher
+	scrubbers    []func(i *Interaction)
+	headers      []string
+	interactions []*Interaction
+	used         []bool
+	mu           sync.Mutex
+}
+
+// New initializes a new [Recorder] that uses the cassette file at the given path.
+func New(path string, opts ...Option) (*Recorder, error) {
+	r := &Recorder{
+		path:      path,
+		mode:      ModeAuto,
+		transport: http.DefaultTransport,
+		matcher:   DefaultMatcher,
+		headers:   DefaultScrubbedHeaders,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	switch os.Getenv("VCR_MODE") {
+	case "record":
+		r.mode = ModeRecord
+	case "replay":
+		r.mode = ModeReplay
+	}
+
+	// Load cassette
+	content, err := os.ReadFile(path) // nolint:gosec
+	switch {
+	case err == nil && r.mode != ModeRecord:
+		if err = json.Unmarshal(content, &r.interactions); err != nil {
+			return nil, fmt.Errorf("parse cassette %s: %w", path, err)
+		}
+		r.mode = ModeReplay
+		r.used = make([]bool, len(r.interactions))
+	case err == nil || errors.Is(err, os.ErrNotExist):
+		if r.mode == ModeReplay {
+			return nil, fmt.Errorf("cassette %s: %w", path, os.ErrNotExist)
+		}
+		r.mode = ModeRecord
+	default:
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// NewForTest initializes a new [Recorder] that uses the cassette "testdata/cassettes/<name>.json", and stops it when
+// the test finishes. The test fails if the recorder can't be initialized or stopped.
+func NewForTest(t testing.TB, name string, opts ...Option) *Recorder {
+	t.Helper()
+	r, err := New(filepath.Join("testdata", "cassettes", name+".json"), opts...)
+	if err != nil {
+		t.Fatalf("Initialize recorder failed: %+v", err)
+	}
+	t.Cleanup(func() {
+		if err := r.Stop(); err != nil {
+			t.Errorf("Stop recorder failed: %+v", err)
+		}
+	})
+	return r
+}
+
+// Mode returns the effective mode, which is either [ModeRecord] or [ModeReplay].
+func (r *Recorder) Mode() Mode {
+	return r.mode
+}
+
+// Client returns an HTTP client that uses this recorder as the transport.
+func (r *Recorder) Client() *http.Client {
+	return &http.Client{Transport: r}
+}
+
+// RoundTrip implements [http.RoundTripper]. The request is not modified except that its body is consumed and closed,
+// and a clone of it is sent in record mode.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	// Read request body
+	reqBody, err := readBody(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	incoming := &Interaction{Request: Request{
+		Method: req.Method,
+		URL:    req.URL.String(),
+		Header: req.Header.Clone(),
+		Body:   reqBody,
+	}}
+
+	if r.mode == ModeReplay {
+		r.scrub(incoming)
+		return r.replay(req, &incoming.Request)
+	}
+
+	// Send real request
+	out := req.Clone(req.Context())
+	if reqBody != nil {
+		out.Body = io.NopCloser(bytes.NewReader(reqBody))
+	}
+	rsp, err := r.transport.RoundTrip(out)
+	if err != nil {
+		return nil, err
+	}
+	rspBody, err := readBody(rsp.Body)
+	if err != nil {
+		return nil, err
+	}
+	rsp.Body = io.NopCloser(bytes.NewReader(rspBody))
+	rsp.Request = req
+	incoming.Response = Response{
+		StatusCode: rsp.StatusCode,
+		Header:     rsp.Header.Clone(),
+		Body:       bytes.Clone(rspBody),
+	}
+	r.scrub(incoming)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.interactions = append(r.interactions, incoming)
+	return rsp, nil
+}
+
+// Stop saves recorded interactions to the cassette in record mode. It does nothing in replay mode.
+func (r *Recorder) Stop() error {
+	if r.mode != ModeRecord {
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	content, err := json.MarshalIndent(r.interactions, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(r.path), 0750); err != nil { // nolint:mnd
+		return err
+	}
+	return os.WriteFile(r.path, append(content, '\n'), 0600) // nolint:mnd
+}
+
+func (r *Recorder) replay(req *http.Request, incoming *Request) (*http.Response, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, interaction := range r.interactions {
+		if r.used[i] || !r.matcher(&interaction.Request, incoming) {
+			continue
+		}
+		r.used[i] = true
+		code := interaction.Response.StatusCode
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", code, http.StatusText(code)),
+			StatusCode:    code,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        interaction.Response.Header.Clone(),
+			Body:          io.NopCloser(bytes.NewReader(interaction.Response.Body)),
+			ContentLength: int64(len(interaction.Response.Body)),
+			Request:       req,
+		}, nil
+	}
+	return nil, fmt.Errorf("%w: %s %s", ErrNoInteraction, incoming.Method, incoming.URL)
+}
+
+func (r *Recorder) scrub(i *Interaction) {
+	for _, h := range r.headers {
+		if i.Request.Header.Get(h) != "" {
+			i.Request.Header.Set(h, ScrubbedValue)
+		}
+		if i.Response.Header.Get(h) != "" {
+			i.Response.Header.Set(h, ScrubbedValue)
+		}
+	}
+	for _, scrubber := range r.scrubbers {
+		scrubber(i)
+	}
+}
+
+// readBody reads the whole body and closes it.
+func readBody(body io.ReadCloser) ([]byte, error) {
+	if body == nil || body == http.NoBody {
+		return nil, nil
+	}
+	b, err := io.ReadAll(body)
+	if err != nil {
+		_ = body.Close()
+		return nil, err
+	}
+	return b, body.Close()
+}
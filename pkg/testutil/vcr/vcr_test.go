@@ -0,0 +1,203 @@
+package vcr_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sainnhe/go-common/pkg/testutil/vcr"
+)
+
+func TestRecorder(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Set-Cookie", "session=secret")
+		_, _ = w.Write([]byte(r.Method + " " + r.URL.Path + " " + string(body) + " " + r.URL.Query().Get("key")))
+	}))
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	scrubKey := vcr.WithScrubber(func(i *vcr.Interaction) {
+		i.Request.URL = strings.ReplaceAll(i.Request.URL, "key=secret", "key=xxx")
+	})
+
+	// Record
+	rec, err := vcr.New(path, scrubKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec.Mode() != vcr.ModeRecord {
+		t.Fatalf("Want record mode, got %d", rec.Mode())
+	}
+	got1 := send(t, rec.Client(), http.MethodGet, srv.URL+"/foo?key=secret", "")
+	got2 := send(t, rec.Client(), http.MethodPost, srv.URL+"/bar", "body")
+	if err = rec.Stop(); err != nil {
+		t.Fatal(err)
+	}
+	srv.Close()
+
+	cassette, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(cassette), "Bearer token") ||
+		strings.Contains(string(cassette), "session=secret") ||
+		strings.Contains(string(cassette), "key=secret") {
+		t.Fatalf("Secrets are not scrubbed:\n%s", cassette)
+	}
+
+	// Replay
+	rep, err := vcr.New(path, scrubKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rep.Mode() != vcr.ModeReplay {
+		t.Fatalf("Want replay mode, got %d", rep.Mode())
+	}
+	if got := send(t, rep.Client(), http.MethodPost, srv.URL+"/bar", "body"); got != got2 {
+		t.Fatalf("Want %s, got %s", got2, got)
+	}
+	if got := send(t, rep.Client(), http.MethodGet, srv.URL+"/foo?key=secret", ""); got != got1 {
+		t.Fatalf("Want %s, got %s", got1, got)
+	}
+	if err = rep.Stop(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Interactions are used only once
+	req, _ := http.NewRequestWithContext(context.Background(), http.MethodPost, srv.URL+"/bar",
+		strings.NewReader("body"))
+	rsp, err := rep.Client().Do(req)
+	if err == nil {
+		_ = rsp.Body.Close()
+	}
+	if !errors.Is(err, vcr.ErrNoInteraction) {
+		t.Fatalf("Want %+v, got %+v", vcr.ErrNoInteraction, err)
+	}
+}
+
+func TestRecorder_binaryBody(t *testing.T) {
+	t.Parallel()
+
+	body := []byte{0x1f, 0x8b, 0x08, 0x00, 0xff, 0xfe}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		_, _ = w.Write(append(b, body...))
+	}))
+	path := filepath.Join(t.TempDir(), "cassette.json")
+
+	rec, err := vcr.New(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, srv.URL, bytes.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	reqBody := req.Body
+	got1 := roundTrip(t, rec, req)
+	if req.Body != reqBody {
+		t.Fatal("Request body is replaced")
+	}
+	if err = rec.Stop(); err != nil {
+		t.Fatal(err)
+	}
+	srv.Close()
+
+	rep, err := vcr.New(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, err = http.NewRequestWithContext(context.Background(), http.MethodPost, srv.URL, bytes.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got2 := roundTrip(t, rep, req); !bytes.Equal(got1, got2) || !bytes.Equal(got2, append(body, body...)) {
+		t.Fatalf("Want %x, got %x", got1, got2)
+	}
+}
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+
+	_, err := vcr.New(filepath.Join(dir, "none.json"), vcr.WithMode(vcr.ModeReplay))
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("Want %+v, got %+v", os.ErrNotExist, err)
+	}
+
+	invalid := filepath.Join(dir, "invalid.json")
+	if err := os.WriteFile(invalid, []byte("{"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := vcr.New(invalid); err == nil {
+		t.Fatal("Expect error, got nil")
+	}
+
+	rec, err := vcr.New(invalid,
+		vcr.WithMode(vcr.ModeRecord),
+		vcr.WithTransport(http.DefaultTransport),
+		vcr.WithMatcher(vcr.DefaultMatcher),
+		vcr.WithScrubbedHeaders("Authorization"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec.Mode() != vcr.ModeRecord {
+		t.Fatalf("Want record mode, got %d", rec.Mode())
+	}
+}
+
+func TestNewForTest(t *testing.T) {
+	t.Parallel()
+
+	rec := vcr.NewForTest(t, "example", vcr.WithMode(vcr.ModeReplay))
+	if got := send(t, rec.Client(), http.MethodGet, "https://example.com/", ""); got != "hello" {
+		t.Fatalf("Want hello, got %s", got)
+	}
+}
+
+func send(t *testing.T, client *http.Client, method, url, body string) string {
+	t.Helper()
+
+	req, err := http.NewRequestWithContext(context.Background(), method, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "Bearer token")
+	rsp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rsp.Body.Close() // nolint:errcheck
+
+	b, err := io.ReadAll(rsp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+func roundTrip(t *testing.T, rt http.RoundTripper, req *http.Request) []byte {
+	t.Helper()
+
+	rsp, err := rt.RoundTrip(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rsp.Body.Close() // nolint:errcheck
+
+	b, err := io.ReadAll(rsp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
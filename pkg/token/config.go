@@ -0,0 +1,13 @@
+package token
+
+// Config defines the config model for token.
+type Config struct {
+	// Secret is the secret key used to sign tokens. It must not be empty.
+	Secret string `json:"secret" yaml:"secret" toml:"secret" xml:"secret" env:"TOKEN_SECRET" default:"" secret:"true"` // nolint:lll
+
+	// TTLSec is how long a token is valid for in seconds. It must be positive.
+	TTLSec int64 `json:"ttl_sec" yaml:"ttl_sec" toml:"ttl_sec" xml:"ttl_sec" env:"TOKEN_TTL_SEC" default:"900"`
+
+	// Prefix is the prefix for redis keys. Use different keys in different scenarios to avoid conflicts.
+	Prefix string `json:"prefix" yaml:"prefix" toml:"prefix" xml:"prefix" env:"TOKEN_PREFIX" default:"token"`
+}
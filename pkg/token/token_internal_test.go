@@ -0,0 +1,55 @@
+package token
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sainnhe/go-common/pkg/clock/testclock"
+)
+
+func TestToken_parse(t *testing.T) {
+	t.Parallel()
+
+	clk := testclock.Freeze(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC))
+	s := &serviceImpl{&Config{Secret: "foo", TTLSec: 60, Prefix: "token"}, nil, clk}
+	token, err := s.Issue("download", map[string]string{"file": "a.txt"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	claims, err := s.parse(token, "download")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims.ID == "" || claims.Data["file"] != "a.txt" || !claims.ExpireTime.Equal(clk.Now().Add(time.Minute)) {
+		t.Fatalf("Unexpected claims %+v", claims)
+	}
+
+	other := &serviceImpl{&Config{Secret: "bar", TTLSec: 60}, nil, clk}
+	encoded, _, _ := strings.Cut(token, ".")
+	tests := []struct {
+		name    string
+		s       *serviceImpl
+		token   string
+		purpose string
+		want    error
+	}{
+		{"Wrong purpose", s, token, "verify_email", ErrInvalidToken},
+		{"Wrong secret", other, token, "download", ErrInvalidToken},
+		{"Missing signature", s, encoded, "download", ErrInvalidToken},
+		{"Tampered payload", s, "e30" + token[3:], "download", ErrInvalidToken},
+		{"Malformed payload", s, "!." + token[len(encoded)+1:], "download", ErrInvalidToken},
+	}
+	for _, tt := range tests {
+		if _, err := tt.s.parse(tt.token, tt.purpose); !errors.Is(err, tt.want) {
+			t.Fatalf("[%s] Expect error %+v, got %+v", tt.name, tt.want, err)
+		}
+	}
+
+	clk.Advance(time.Minute)
+	if _, err := s.parse(token, "download"); !errors.Is(err, ErrExpiredToken) {
+		t.Fatalf("Expect error %+v, got %+v", ErrExpiredToken, err)
+	}
+}
@@ -0,0 +1,80 @@
+package token_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/redis/rueidis"
+	"github.com/sainnhe/go-common/pkg/constant"
+	"github.com/sainnhe/go-common/pkg/token"
+)
+
+func TestNewService(t *testing.T) {
+	t.Parallel()
+
+	if _, err := token.NewService(nil, nil); !errors.Is(err, constant.ErrNilDeps) {
+		t.Fatalf("Expect error %+v, got %+v", constant.ErrNilDeps, err)
+	}
+}
+
+func TestToken(t *testing.T) {
+	t.Parallel()
+
+	rc, err := rueidis.NewClient(rueidis.ClientOption{
+		InitAddress: []string{"localhost:6379"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rc.Close()
+
+	if _, err := token.NewService(&token.Config{}, rc); !errors.Is(err, token.ErrEmptySecret) {
+		t.Fatalf("Expect error %+v, got %+v", token.ErrEmptySecret, err)
+	}
+	if _, err := token.NewService(&token.Config{Secret: "foo"}, rc); !errors.Is(err, token.ErrInvalidConfig) {
+		t.Fatalf("Expect error %+v, got %+v", token.ErrInvalidConfig, err)
+	}
+	svc, err := token.NewService(&token.Config{Secret: "foo", TTLSec: 60, Prefix: "test_token"}, rc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	// Single use
+	tk, err := svc.Issue("verify_email", map[string]string{"user_id": "42"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims, err := svc.Verify(ctx, tk, "verify_email"); err != nil || claims.Data["user_id"] != "42" {
+		t.Fatalf("Unexpected claims %+v, err = %+v", claims, err)
+	}
+	if _, err := svc.Consume(ctx, tk, "verify_email"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.Consume(ctx, tk, "verify_email"); !errors.Is(err, token.ErrUsedToken) {
+		t.Fatalf("Expect error %+v, got %+v", token.ErrUsedToken, err)
+	}
+	if _, err := svc.Verify(ctx, tk, "verify_email"); !errors.Is(err, token.ErrUsedToken) {
+		t.Fatalf("Expect error %+v, got %+v", token.ErrUsedToken, err)
+	}
+
+	// Revocation
+	tk, err = svc.Issue("download", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, err := svc.Verify(ctx, tk, "download")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.Revoke(ctx, claims.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.Verify(ctx, tk, "download"); !errors.Is(err, token.ErrRevokedToken) {
+		t.Fatalf("Expect error %+v, got %+v", token.ErrRevokedToken, err)
+	}
+	if _, err := svc.Consume(ctx, tk, "download"); !errors.Is(err, token.ErrRevokedToken) {
+		t.Fatalf("Expect error %+v, got %+v", token.ErrRevokedToken, err)
+	}
+}
@@ -0,0 +1,144 @@
+package transcode
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// errInvalidTemplate indicates that a path template is invalid.
+var errInvalidTemplate = errors.New("invalid path template")
+
+// segment is a segment of a path template.
+type segment struct {
+	literal string
+	// wildcard is 1 for "*", which matches a single segment, and 2 for "**", which matches the remaining segments.
+	wildcard int
+}
+
+// variable is a variable of a path template, which binds segments [start, end) to a field.
+type variable struct {
+	fieldPath  []string
+	start, end int
+}
+
+// pathTemplate is a parsed path template of an HTTP rule, like "/v1/{name=shelves/*}/books:search".
+type pathTemplate struct {
+	segments []segment
+	vars     []variable
+	verb     string
+}
+
+// binding is a value bound to a field by a path variable.
+type binding struct {
+	fieldPath []string
+	value     string
+}
+
+// parseTemplate parses a path template with the syntax of google.api.HttpRule.
+func parseTemplate(s string) (*pathTemplate, error) {
+	rest, ok := strings.CutPrefix(s, "/")
+	if !ok {
+		return nil, fmt.Errorf("%w %q: must start with /", errInvalidTemplate, s)
+	}
+	t := &pathTemplate{}
+	if i := strings.LastIndexByte(rest, ':'); i >= 0 && !strings.ContainsAny(rest[i:], "/}") {
+		t.verb = rest[i+1:]
+		rest = rest[:i]
+	}
+	for {
+		if strings.HasPrefix(rest, "{") {
+			end := strings.IndexByte(rest, '}')
+			if end < 0 {
+				return nil, fmt.Errorf("%w %q: unclosed variable", errInvalidTemplate, s)
+			}
+			field, pattern, hasPattern := strings.Cut(rest[1:end], "=")
+			if !hasPattern {
+				pattern = "*"
+			}
+			start := len(t.segments)
+			for _, p := range strings.Split(pattern, "/") {
+				t.segments = append(t.segments, parseSegment(p))
+			}
+			t.vars = append(t.vars, variable{strings.Split(field, "."), start, len(t.segments)})
+			rest = rest[end+1:]
+		} else {
+			end := strings.IndexByte(rest, '/')
+			if end < 0 {
+				end = len(rest)
+			}
+			t.segments = append(t.segments, parseSegment(rest[:end]))
+			rest = rest[end:]
+		}
+		if rest == "" {
+			break
+		}
+		if rest[0] != '/' {
+			return nil, fmt.Errorf("%w %q: unexpected %q", errInvalidTemplate, s, rest[0])
+		}
+		rest = rest[1:]
+	}
+	for i, seg := range t.segments {
+		if seg.wildcard == 2 && i != len(t.segments)-1 { // nolint:mnd
+			return nil, fmt.Errorf("%w %q: ** must be the last segment", errInvalidTemplate, s)
+		}
+	}
+	return t, nil
+}
+
+func parseSegment(s string) segment {
+	switch s {
+	case "*":
+		return segment{wildcard: 1}
+	case "**":
+		return segment{wildcard: 2} // nolint:mnd
+	default:
+		return segment{literal: s}
+	}
+}
+
+// match matches the escaped path against the template, and returns the bindings of variables.
+func (t *pathTemplate) match(path string) ([]binding, bool) {
+	if t.verb != "" {
+		var ok bool
+		if path, ok = strings.CutSuffix(path, ":"+t.verb); !ok {
+			return nil, false
+		}
+	}
+	parts := strings.Split(strings.TrimPrefix(path, "/"), "/")
+	n := len(t.segments)
+	multi := n > 0 && t.segments[n-1].wildcard == 2 // nolint:mnd
+	if (multi && len(parts) < n-1) || (!multi && len(parts) != n) {
+		return nil, false
+	}
+	for i, seg := range t.segments {
+		switch seg.wildcard {
+		case 0:
+			if p, err := url.PathUnescape(parts[i]); err != nil || p != seg.literal {
+				return nil, false
+			}
+		case 1:
+			if parts[i] == "" {
+				return nil, false
+			}
+		}
+	}
+	bindings := make([]binding, 0, len(t.vars))
+	for _, v := range t.vars {
+		end := v.end
+		if multi && end == n {
+			end = len(parts)
+		}
+		values := make([]string, 0, end-v.start)
+		for _, p := range parts[v.start:end] {
+			p, err := url.PathUnescape(p)
+			if err != nil {
+				return nil, false
+			}
+			values = append(values, p)
+		}
+		bindings = append(bindings, binding{v.fieldPath, strings.Join(values, "/")})
+	}
+	return bindings, true
+}
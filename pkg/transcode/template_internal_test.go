@@ -0,0 +1,46 @@
+package transcode
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParseTemplate(t *testing.T) {
+	t.Parallel()
+
+	for _, s := range []string{"v1/books", "/v1/{name", "/v1/**/books", "/v1/{name}x"} {
+		if _, err := parseTemplate(s); !errors.Is(err, errInvalidTemplate) {
+			t.Fatalf("Expect error %+v for %q, got %+v", errInvalidTemplate, s, err)
+		}
+	}
+
+	tests := []struct {
+		template string
+		path     string
+		want     []binding
+		ok       bool
+	}{
+		{"/v1/books", "/v1/books", []binding{}, true},
+		{"/v1/books", "/v1/books/1", nil, false},
+		{"/v1/books/{id}", "/v1/books/a%2Fb", []binding{{[]string{"id"}, "a/b"}}, true},
+		{"/v1/books/{id}", "/v1/books/", nil, false},
+		{"/v1/{book.name=shelves/*/books/*}", "/v1/shelves/1/books/2",
+			[]binding{{[]string{"book", "name"}, "shelves/1/books/2"}}, true},
+		{"/v1/{name=files/**}", "/v1/files/a/b/c", []binding{{[]string{"name"}, "files/a/b/c"}}, true},
+		{"/v1/{name=files/**}", "/v1/files", []binding{{[]string{"name"}, "files"}}, true},
+		{"/v1/books/{id}:publish", "/v1/books/1:publish", []binding{{[]string{"id"}, "1"}}, true},
+		{"/v1/books/{id}:publish", "/v1/books/1", nil, false},
+		{"/v1/*/books", "/v1/shelf/books", []binding{}, true},
+	}
+	for _, tt := range tests {
+		tmpl, err := parseTemplate(tt.template)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, ok := tmpl.match(tt.path)
+		if ok != tt.ok || (ok && !reflect.DeepEqual(got, tt.want)) {
+			t.Fatalf("Expect %v %v for %s %s, got %v %v", tt.want, tt.ok, tt.template, tt.path, got, ok)
+		}
+	}
+}
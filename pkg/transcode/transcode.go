@@ -0,0 +1,513 @@
+/*
+Package transcode exposes unary gRPC methods as REST endpoints according to their google.api.http annotations, so that
+small services can serve both gRPC and HTTP/JSON clients without running a separate gateway:
+
+	h := transcode.NewHandler(transcode.WithUnaryInterceptors(interceptors...))
+	pb.RegisterLibraryServer(grpcServer, impl)
+	pb.RegisterLibraryServer(h, impl)
+	mux.Handle("/v1/", middleware(h))
+
+[Handler] implements [grpc.ServiceRegistrar], so that the generated registration functions can be used. Requests are
+decoded into request messages with the path variables, query parameters and body as specified by the HTTP rules, passed
+through the unary interceptors, and the responses are encoded as JSON. Errors are responded with the HTTP status codes
+mapped from gRPC status codes and google.rpc.Status bodies. Since it's a plain [http.Handler], it can also be wrapped
+with HTTP middleware.
+
+Incoming metadata is populated from request headers, and headers and trailers set via [grpc.SetHeader] and
+[grpc.SetTrailer] are responded as HTTP headers. Streaming methods are not supported.
+*/
+package transcode
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/sainnhe/go-common/pkg/constant"
+	"github.com/sainnhe/go-common/pkg/log"
+	"google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+)
+
+const pkgName = "github.com/sainnhe/go-common/pkg/transcode"
+
+// route is an HTTP binding of a unary method.
+type route struct {
+	httpMethod   string
+	template     *pathTemplate
+	body         string
+	responseBody string
+	fullMethod   string
+	srv          any
+	handler      grpc.MethodHandler
+}
+
+// Handler is an HTTP handler that transcodes requests to registered gRPC services.
+type Handler struct {
+	interceptors []grpc.UnaryServerInterceptor
+	files        *protoregistry.Files
+	l            *slog.Logger
+
+	mu     sync.RWMutex
+	routes []*route
+}
+
+var _ grpc.ServiceRegistrar = (*Handler)(nil)
+
+// Option is the option used to customize the handler.
+type Option func(h *Handler)
+
+// WithUnaryInterceptors adds unary interceptors that are run in order around each method, like
+// [grpc.ChainUnaryInterceptor].
+func WithUnaryInterceptors(interceptors ...grpc.UnaryServerInterceptor) Option {
+	return func(h *Handler) {
+		h.interceptors = append(h.interceptors, interceptors...)
+	}
+}
+
+// WithFiles sets the registry used to look up the descriptors of services. Defaults to
+// [protoregistry.GlobalFiles].
+func WithFiles(files *protoregistry.Files) Option {
+	return func(h *Handler) {
+		if files != nil {
+			h.files = files
+		}
+	}
+}
+
+// NewHandler initializes a new transcoding handler. Services should be registered via [Handler.RegisterService]
+// before serving requests.
+func NewHandler(opts ...Option) *Handler {
+	h := &Handler{
+		files: protoregistry.GlobalFiles,
+		l:     log.NewLogger(pkgName),
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+// RegisterService registers the HTTP bindings of the unary methods of a service. Methods without google.api.http
+// annotations are skipped, and invalid bindings are logged and skipped. Routes are matched in the order of
+// registration.
+func (h *Handler) RegisterService(desc *grpc.ServiceDesc, impl any) {
+	l := h.l.With("service", desc.ServiceName)
+	d, err := h.files.FindDescriptorByName(protoreflect.FullName(desc.ServiceName))
+	if err != nil {
+		l.Error("Find service descriptor failed.", constant.LogAttrError, err)
+		return
+	}
+	sd, ok := d.(protoreflect.ServiceDescriptor)
+	if !ok {
+		l.Error("Find service descriptor failed.", constant.LogAttrError, fmt.Errorf("%s is not a service", d.FullName()))
+		return
+	}
+	routes := []*route{}
+	for _, m := range desc.Methods {
+		md := sd.Methods().ByName(protoreflect.Name(m.MethodName))
+		if md == nil {
+			l.Error("Find method descriptor failed.", constant.LogAttrMethod, m.MethodName)
+			continue
+		}
+		rule, ok := proto.GetExtension(md.Options(), annotations.E_Http).(*annotations.HttpRule)
+		if !ok || rule == nil {
+			continue
+		}
+		fullMethod := fmt.Sprintf("/%s/%s", desc.ServiceName, m.MethodName)
+		for _, r := range append([]*annotations.HttpRule{rule}, rule.GetAdditionalBindings()...) {
+			rt, err := newRoute(r, md)
+			if err != nil {
+				l.Error("Invalid HTTP rule.", constant.LogAttrMethod, m.MethodName, constant.LogAttrError, err)
+				continue
+			}
+			rt.fullMethod, rt.srv, rt.handler = fullMethod, impl, m.Handler
+			routes = append(routes, rt)
+		}
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.routes = append(h.routes, routes...)
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rt, bindings, allowed := h.match(r.Method, r.URL.EscapedPath())
+	if rt == nil {
+		if len(allowed) > 0 {
+			w.Header().Set("Allow", strings.Join(allowed, ", "))
+			h.writeError(w, r, status.Error(codes.Unimplemented, "method not allowed"), http.StatusMethodNotAllowed)
+			return
+		}
+		h.writeError(w, r, status.Error(codes.NotFound, "no route"), 0)
+		return
+	}
+
+	stream := &transportStream{method: rt.fullMethod, header: metadata.MD{}, trailer: metadata.MD{}}
+	ctx := grpc.NewContextWithServerTransportStream(r.Context(), stream)
+	ctx = metadata.NewIncomingContext(ctx, incomingMetadata(r))
+	dec := func(v any) error {
+		msg, ok := v.(proto.Message)
+		if !ok {
+			return status.Errorf(codes.Internal, "unexpected request type %T", v)
+		}
+		if err := decodeRequest(r, rt, bindings, msg); err != nil {
+			return status.Error(codes.InvalidArgument, err.Error())
+		}
+		return nil
+	}
+	resp, err := rt.handler(rt.srv, ctx, dec, h.interceptor())
+	writeMetadata(w, stream.header, stream.trailer)
+	if err != nil {
+		h.writeError(w, r, err, 0)
+		return
+	}
+	msg, ok := resp.(proto.Message)
+	if !ok {
+		h.writeError(w, r, status.Errorf(codes.Internal, "unexpected response type %T", resp), 0)
+		return
+	}
+	if rt.responseBody != "" {
+		m := msg.ProtoReflect()
+		msg = m.Get(m.Descriptor().Fields().ByName(protoreflect.Name(rt.responseBody))).Message().Interface()
+	}
+	b, err := protojson.Marshal(msg)
+	if err != nil {
+		h.writeError(w, r, status.Error(codes.Internal, err.Error()), 0)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(b)
+}
+
+// match returns the first route matching the method and the escaped path, and the bindings of its path variables. If
+// no route matches, it returns the methods of the routes matching the path.
+func (h *Handler) match(method, path string) (*route, []binding, []string) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	allowed := []string{}
+	for _, rt := range h.routes {
+		bindings, ok := rt.template.match(path)
+		if !ok {
+			continue
+		}
+		if rt.httpMethod == method {
+			return rt, bindings, nil
+		}
+		if !slices.Contains(allowed, rt.httpMethod) {
+			allowed = append(allowed, rt.httpMethod)
+		}
+	}
+	return nil, nil, allowed
+}
+
+// interceptor returns the chained interceptor, or nil if there are no interceptors.
+func (h *Handler) interceptor() grpc.UnaryServerInterceptor {
+	if len(h.interceptors) == 0 {
+		return nil
+	}
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		var next func(i int) grpc.UnaryHandler
+		next = func(i int) grpc.UnaryHandler {
+			if i == len(h.interceptors) {
+				return handler
+			}
+			return func(ctx context.Context, req any) (any, error) {
+				return h.interceptors[i](ctx, req, info, next(i+1))
+			}
+		}
+		return next(0)(ctx, req)
+	}
+}
+
+// writeError responds with the status of err. If code is 0, it's mapped from the gRPC status code.
+func (h *Handler) writeError(w http.ResponseWriter, r *http.Request, err error, code int) {
+	st := status.Convert(err)
+	if code == 0 {
+		code = HTTPStatusFromCode(st.Code())
+	}
+	if code >= http.StatusInternalServerError {
+		h.l.ErrorContext(r.Context(), "Transcoded call failed.", constant.LogAttrError, err)
+	}
+	b, marshalErr := protojson.Marshal(st.Proto())
+	if marshalErr != nil {
+		b = []byte(fmt.Sprintf(`{"code":%d,"message":%q}`, st.Code(), st.Message()))
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_, _ = w.Write(b)
+}
+
+// HTTPStatusFromCode returns the HTTP status code corresponding to the gRPC status code, as specified by
+// google.rpc.Code.
+func HTTPStatusFromCode(code codes.Code) int {
+	switch code {
+	case codes.OK:
+		return http.StatusOK
+	case codes.Canceled:
+		return 499 // nolint:mnd
+	case codes.InvalidArgument, codes.FailedPrecondition, codes.OutOfRange:
+		return http.StatusBadRequest
+	case codes.DeadlineExceeded:
+		return http.StatusGatewayTimeout
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.AlreadyExists, codes.Aborted:
+		return http.StatusConflict
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests
+	case codes.Unimplemented:
+		return http.StatusNotImplemented
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// newRoute initializes a route of the HTTP rule of the method.
+func newRoute(rule *annotations.HttpRule, md protoreflect.MethodDescriptor) (*route, error) {
+	rt := &route{body: rule.GetBody(), responseBody: rule.GetResponseBody()}
+	var path string
+	switch p := rule.GetPattern().(type) {
+	case *annotations.HttpRule_Get:
+		rt.httpMethod, path = http.MethodGet, p.Get
+	case *annotations.HttpRule_Put:
+		rt.httpMethod, path = http.MethodPut, p.Put
+	case *annotations.HttpRule_Post:
+		rt.httpMethod, path = http.MethodPost, p.Post
+	case *annotations.HttpRule_Delete:
+		rt.httpMethod, path = http.MethodDelete, p.Delete
+	case *annotations.HttpRule_Patch:
+		rt.httpMethod, path = http.MethodPatch, p.Patch
+	case *annotations.HttpRule_Custom:
+		rt.httpMethod, path = p.Custom.GetKind(), p.Custom.GetPath()
+	default:
+		return nil, errors.New("missing pattern")
+	}
+	t, err := parseTemplate(path)
+	if err != nil {
+		return nil, err
+	}
+	rt.template = t
+	if rt.body != "" && rt.body != "*" {
+		if fd := md.Input().Fields().ByName(protoreflect.Name(rt.body)); fd == nil || fd.Message() == nil ||
+			fd.IsList() || fd.IsMap() {
+			return nil, fmt.Errorf("body %q is not a message field", rt.body)
+		}
+	}
+	if rt.responseBody != "" {
+		if fd := md.Output().Fields().ByName(protoreflect.Name(rt.responseBody)); fd == nil || fd.Message() == nil ||
+			fd.IsList() || fd.IsMap() {
+			return nil, fmt.Errorf("response body %q is not a message field", rt.responseBody)
+		}
+	}
+	return rt, nil
+}
+
+// decodeRequest decodes the body, query parameters and path variables into msg in order, so that path variables take
+// precedence.
+func decodeRequest(r *http.Request, rt *route, bindings []binding, msg proto.Message) error {
+	m := msg.ProtoReflect()
+	if rt.body != "" {
+		b, err := io.ReadAll(r.Body)
+		if err != nil {
+			return err
+		}
+		if len(b) > 0 {
+			target := msg
+			if rt.body != "*" {
+				target = m.Mutable(m.Descriptor().Fields().ByName(protoreflect.Name(rt.body))).Message().Interface()
+			}
+			if err = (protojson.UnmarshalOptions{DiscardUnknown: true}).Unmarshal(b, target); err != nil {
+				return err
+			}
+		}
+	}
+	if rt.body != "*" {
+		for key, values := range r.URL.Query() {
+			// Unknown parameters are ignored, since they may be consumed by middleware.
+			if err := setField(m, strings.Split(key, "."), values); err != nil && !errors.Is(err, errUnknownField) {
+				return fmt.Errorf("query parameter %q: %w", key, err)
+			}
+		}
+	}
+	for _, b := range bindings {
+		if err := setField(m, b.fieldPath, []string{b.value}); err != nil {
+			return fmt.Errorf("path variable %q: %w", strings.Join(b.fieldPath, "."), err)
+		}
+	}
+	return nil
+}
+
+// errUnknownField indicates that a field doesn't exist.
+var errUnknownField = errors.New("unknown field")
+
+// setField sets the field at the path of m to the values, which are parsed according to the type of the field.
+func setField(m protoreflect.Message, path []string, values []string) error {
+	for i, name := range path {
+		fds := m.Descriptor().Fields()
+		fd := fds.ByName(protoreflect.Name(name))
+		if fd == nil {
+			fd = fds.ByJSONName(name)
+		}
+		if fd == nil {
+			return fmt.Errorf("%w %q", errUnknownField, name)
+		}
+		if i < len(path)-1 {
+			if fd.Message() == nil || fd.IsList() || fd.IsMap() {
+				return fmt.Errorf("field %q is not a message", name)
+			}
+			m = m.Mutable(fd).Message()
+			continue
+		}
+		switch {
+		case fd.IsMap():
+			return fmt.Errorf("map field %q is not supported", name)
+		case fd.IsList():
+			list := m.Mutable(fd).List()
+			for _, s := range values {
+				v, err := parseValue(fd, s, list.NewElement)
+				if err != nil {
+					return err
+				}
+				list.Append(v)
+			}
+		case len(values) > 0:
+			v, err := parseValue(fd, values[len(values)-1], func() protoreflect.Value { return m.NewField(fd) })
+			if err != nil {
+				return err
+			}
+			m.Set(fd, v)
+		}
+	}
+	return nil
+}
+
+// parseValue parses s as a value of the field. Message fields like google.protobuf.Timestamp are parsed from their
+// JSON string representations into the message returned by newValue.
+func parseValue(fd protoreflect.FieldDescriptor, s string, newValue func() protoreflect.Value) (protoreflect.Value,
+	error) {
+	switch fd.Kind() {
+	case protoreflect.BoolKind:
+		v, err := strconv.ParseBool(s)
+		return protoreflect.ValueOfBool(v), err
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		v, err := strconv.ParseInt(s, 10, 32)
+		return protoreflect.ValueOfInt32(int32(v)), err
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		v, err := strconv.ParseInt(s, 10, 64)
+		return protoreflect.ValueOfInt64(v), err
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		v, err := strconv.ParseUint(s, 10, 32)
+		return protoreflect.ValueOfUint32(uint32(v)), err
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		v, err := strconv.ParseUint(s, 10, 64)
+		return protoreflect.ValueOfUint64(v), err
+	case protoreflect.FloatKind:
+		v, err := strconv.ParseFloat(s, 32)
+		return protoreflect.ValueOfFloat32(float32(v)), err
+	case protoreflect.DoubleKind:
+		v, err := strconv.ParseFloat(s, 64)
+		return protoreflect.ValueOfFloat64(v), err
+	case protoreflect.StringKind:
+		return protoreflect.ValueOfString(s), nil
+	case protoreflect.BytesKind:
+		v, err := base64.StdEncoding.DecodeString(s)
+		if err != nil {
+			v, err = base64.URLEncoding.DecodeString(s)
+		}
+		return protoreflect.ValueOfBytes(v), err
+	case protoreflect.EnumKind:
+		if ev := fd.Enum().Values().ByName(protoreflect.Name(s)); ev != nil {
+			return protoreflect.ValueOfEnum(ev.Number()), nil
+		}
+		v, err := strconv.ParseInt(s, 10, 32)
+		return protoreflect.ValueOfEnum(protoreflect.EnumNumber(v)), err
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		v := newValue()
+		return v, protojson.Unmarshal([]byte(strconv.Quote(s)), v.Message().Interface())
+	default:
+		return protoreflect.Value{}, fmt.Errorf("unsupported kind %s", fd.Kind())
+	}
+}
+
+// incomingMetadata returns the metadata of the request headers, excluding hop-by-hop headers.
+func incomingMetadata(r *http.Request) metadata.MD {
+	md := metadata.MD{}
+	for key, values := range r.Header {
+		switch key {
+		case "Connection", "Keep-Alive", "Proxy-Connection", "Te", "Trailer", "Transfer-Encoding", "Upgrade",
+			"Content-Length":
+			continue
+		}
+		md.Append(key, values...)
+	}
+	md.Set(":authority", r.Host)
+	return md
+}
+
+// writeMetadata writes the headers and trailers set by the method as HTTP headers.
+func writeMetadata(w http.ResponseWriter, mds ...metadata.MD) {
+	for _, md := range mds {
+		for key, values := range md {
+			switch key {
+			case "content-type", "content-length":
+				continue
+			}
+			for _, v := range values {
+				w.Header().Add(key, v)
+			}
+		}
+	}
+}
+
+// transportStream collects the headers and trailers set by the method via [grpc.SetHeader] and [grpc.SetTrailer].
+type transportStream struct {
+	method string
+
+	mu      sync.Mutex
+	header  metadata.MD
+	trailer metadata.MD
+}
+
+func (s *transportStream) Method() string {
+	return s.method
+}
+
+func (s *transportStream) SetHeader(md metadata.MD) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.header = metadata.Join(s.header, md)
+	return nil
+}
+
+func (s *transportStream) SendHeader(md metadata.MD) error {
+	return s.SetHeader(md)
+}
+
+func (s *transportStream) SetTrailer(md metadata.MD) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.trailer = metadata.Join(s.trailer, md)
+	return nil
+}
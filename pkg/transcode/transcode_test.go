@@ -0,0 +1,339 @@
+package transcode_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/sainnhe/go-common/pkg/transcode"
+	"google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+	_ "google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// newLibrary builds the descriptor of the following service, so that no generated code is needed:
+//
+//	service Library {
+//	  rpc GetBook(GetBookRequest) returns (Book) {
+//	    option (google.api.http) = { get: "/v1/{name=shelves/*/books/*}" };
+//	  }
+//	  rpc ListBooks(ListBooksRequest) returns (ListBooksResponse) {
+//	    option (google.api.http) = {
+//	      get: "/v1/{parent=shelves/*}/books"
+//	      additional_bindings { get: "/v1/books:search" }
+//	    };
+//	  }
+//	  rpc CreateBook(CreateBookRequest) returns (Book) {
+//	    option (google.api.http) = { post: "/v1/{parent=shelves/*}/books" body: "book" };
+//	  }
+//	  rpc Ping(GetBookRequest) returns (Book);
+//	}
+func newLibrary(t *testing.T) *protoregistry.Files {
+	t.Helper()
+
+	field := func(name string, number int32, typ descriptorpb.FieldDescriptorProto_Type, typeName string,
+		repeated bool) *descriptorpb.FieldDescriptorProto {
+		label := descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL
+		if repeated {
+			label = descriptorpb.FieldDescriptorProto_LABEL_REPEATED
+		}
+		f := &descriptorpb.FieldDescriptorProto{
+			Name:   proto.String(name),
+			Number: proto.Int32(number),
+			Type:   typ.Enum(),
+			Label:  label.Enum(),
+		}
+		if typeName != "" {
+			f.TypeName = proto.String(typeName)
+		}
+		return f
+	}
+	method := func(name, in, out string, rule *annotations.HttpRule) *descriptorpb.MethodDescriptorProto {
+		m := &descriptorpb.MethodDescriptorProto{
+			Name:       proto.String(name),
+			InputType:  proto.String(".test.library." + in),
+			OutputType: proto.String(".test.library." + out),
+		}
+		if rule != nil {
+			m.Options = &descriptorpb.MethodOptions{}
+			proto.SetExtension(m.Options, annotations.E_Http, rule)
+		}
+		return m
+	}
+	const (
+		typeString  = descriptorpb.FieldDescriptorProto_TYPE_STRING
+		typeInt32   = descriptorpb.FieldDescriptorProto_TYPE_INT32
+		typeEnum    = descriptorpb.FieldDescriptorProto_TYPE_ENUM
+		typeMessage = descriptorpb.FieldDescriptorProto_TYPE_MESSAGE
+	)
+	fdp := &descriptorpb.FileDescriptorProto{
+		Name:       proto.String("test/library.proto"),
+		Package:    proto.String("test.library"),
+		Syntax:     proto.String("proto3"),
+		Dependency: []string{"google/protobuf/timestamp.proto"},
+		EnumType: []*descriptorpb.EnumDescriptorProto{{
+			Name: proto.String("Kind"),
+			Value: []*descriptorpb.EnumValueDescriptorProto{
+				{Name: proto.String("KIND_UNSPECIFIED"), Number: proto.Int32(0)},
+				{Name: proto.String("KIND_NOVEL"), Number: proto.Int32(1)},
+			},
+		}},
+		MessageType: []*descriptorpb.DescriptorProto{
+			{Name: proto.String("Book"), Field: []*descriptorpb.FieldDescriptorProto{
+				field("name", 1, typeString, "", false),
+				field("title", 2, typeString, "", false),
+				field("publish_time", 3, typeMessage, ".google.protobuf.Timestamp", false),
+			}},
+			{Name: proto.String("GetBookRequest"), Field: []*descriptorpb.FieldDescriptorProto{
+				field("name", 1, typeString, "", false),
+			}},
+			{Name: proto.String("ListBooksRequest"), Field: []*descriptorpb.FieldDescriptorProto{
+				field("parent", 1, typeString, "", false),
+				field("page_size", 2, typeInt32, "", false),
+				field("tags", 3, typeString, "", true),
+				field("kind", 4, typeEnum, ".test.library.Kind", false),
+				field("filter", 5, typeMessage, ".test.library.Book", false),
+			}},
+			{Name: proto.String("ListBooksResponse"), Field: []*descriptorpb.FieldDescriptorProto{
+				field("books", 1, typeMessage, ".test.library.Book", true),
+			}},
+			{Name: proto.String("CreateBookRequest"), Field: []*descriptorpb.FieldDescriptorProto{
+				field("parent", 1, typeString, "", false),
+				field("book", 2, typeMessage, ".test.library.Book", false),
+			}},
+		},
+		Service: []*descriptorpb.ServiceDescriptorProto{{
+			Name: proto.String("Library"),
+			Method: []*descriptorpb.MethodDescriptorProto{
+				method("GetBook", "GetBookRequest", "Book", &annotations.HttpRule{
+					Pattern: &annotations.HttpRule_Get{Get: "/v1/{name=shelves/*/books/*}"},
+				}),
+				method("ListBooks", "ListBooksRequest", "ListBooksResponse", &annotations.HttpRule{
+					Pattern: &annotations.HttpRule_Get{Get: "/v1/{parent=shelves/*}/books"},
+					AdditionalBindings: []*annotations.HttpRule{{
+						Pattern: &annotations.HttpRule_Get{Get: "/v1/books:search"},
+					}},
+				}),
+				method("CreateBook", "CreateBookRequest", "Book", &annotations.HttpRule{
+					Pattern: &annotations.HttpRule_Post{Post: "/v1/{parent=shelves/*}/books"},
+					Body:    "book",
+				}),
+				method("Ping", "GetBookRequest", "Book", nil),
+			},
+		}},
+	}
+	fd, err := protodesc.NewFile(fdp, protoregistry.GlobalFiles)
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := &protoregistry.Files{}
+	if err = files.RegisterFile(fd); err != nil {
+		t.Fatal(err)
+	}
+	return files
+}
+
+// unary returns a method handler like the generated ones, which decodes requests into dynamic messages.
+func unary(files *protoregistry.Files, method string,
+	fn func(ctx context.Context, req *dynamicpb.Message) (proto.Message, error)) grpc.MethodDesc {
+	return grpc.MethodDesc{
+		MethodName: method,
+		Handler: func(srv any, ctx context.Context, dec func(any) error,
+			interceptor grpc.UnaryServerInterceptor) (any, error) {
+			d, _ := files.FindDescriptorByName(protoreflect.FullName("test.library.Library." + method))
+			req := dynamicpb.NewMessage(d.(protoreflect.MethodDescriptor).Input())
+			if err := dec(req); err != nil {
+				return nil, err
+			}
+			handler := func(ctx context.Context, req any) (any, error) {
+				return fn(ctx, req.(*dynamicpb.Message))
+			}
+			if interceptor == nil {
+				return handler(ctx, req)
+			}
+			return interceptor(ctx, req, &grpc.UnaryServerInfo{Server: srv, FullMethod: "/test.library.Library/" +
+				method}, handler)
+		},
+	}
+}
+
+func newMessage(files *protoregistry.Files, name string) *dynamicpb.Message {
+	d, _ := files.FindDescriptorByName(protoreflect.FullName("test.library." + name))
+	return dynamicpb.NewMessage(d.(protoreflect.MessageDescriptor))
+}
+
+func get(m protoreflect.Message, name string) protoreflect.Value {
+	return m.Get(m.Descriptor().Fields().ByName(protoreflect.Name(name)))
+}
+
+func set(m protoreflect.Message, name, value string) {
+	m.Set(m.Descriptor().Fields().ByName(protoreflect.Name(name)), protoreflect.ValueOfString(value))
+}
+
+func TestHandler(t *testing.T) {
+	t.Parallel()
+
+	files := newLibrary(t)
+	mu := sync.Mutex{}
+	var listReq *dynamicpb.Message
+	order := []string{}
+	interceptor := func(name string) grpc.UnaryServerInterceptor {
+		return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+			mu.Lock()
+			order = append(order, name+info.FullMethod)
+			mu.Unlock()
+			if md, _ := metadata.FromIncomingContext(ctx); len(md.Get("x-deny")) > 0 {
+				return nil, status.Error(codes.PermissionDenied, "denied")
+			}
+			return handler(ctx, req)
+		}
+	}
+	h := transcode.NewHandler(transcode.WithFiles(files),
+		transcode.WithUnaryInterceptors(interceptor("1"), interceptor("2")))
+	h.RegisterService(&grpc.ServiceDesc{
+		ServiceName: "test.library.Library",
+		Methods: []grpc.MethodDesc{
+			unary(files, "GetBook", func(ctx context.Context, req *dynamicpb.Message) (proto.Message, error) {
+				name := get(req, "name").String()
+				if strings.HasSuffix(name, "/404") {
+					return nil, status.Error(codes.NotFound, "book not found")
+				}
+				_ = grpc.SetHeader(ctx, metadata.Pairs("x-book", name))
+				book := newMessage(files, "Book")
+				set(book, "name", name)
+				return book, nil
+			}),
+			unary(files, "ListBooks", func(_ context.Context, req *dynamicpb.Message) (proto.Message, error) {
+				mu.Lock()
+				listReq = req
+				mu.Unlock()
+				return newMessage(files, "ListBooksResponse"), nil
+			}),
+			unary(files, "CreateBook", func(_ context.Context, req *dynamicpb.Message) (proto.Message, error) {
+				book := get(req, "book").Message()
+				set(book, "name", get(req, "parent").String()+"/books/1")
+				return book.Interface(), nil
+			}),
+			unary(files, "Ping", func(context.Context, *dynamicpb.Message) (proto.Message, error) {
+				return newMessage(files, "Book"), nil
+			}),
+		},
+	}, nil)
+
+	serve := func(method, target, body string, header http.Header) (*httptest.ResponseRecorder, map[string]any) {
+		r := httptest.NewRequest(method, target, strings.NewReader(body))
+		for k, v := range header {
+			r.Header[k] = v
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		result := map[string]any{}
+		if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil {
+			t.Fatalf("Unmarshal %s %s response failed: %+v", method, target, err)
+		}
+		return w, result
+	}
+
+	// Path variables are bound, and interceptors and headers set by methods are applied.
+	w, result := serve(http.MethodGet, "/v1/shelves/1/books/2", "", nil)
+	if w.Code != http.StatusOK || result["name"] != "shelves/1/books/2" || w.Header().Get("X-Book") != result["name"] {
+		t.Fatalf("Unexpected response %d %v %v", w.Code, result, w.Header())
+	}
+	if want := []string{"1/test.library.Library/GetBook", "2/test.library.Library/GetBook"}; !slices.Equal(order,
+		want) {
+		t.Fatalf("Expect interceptors %v, got %v", want, order)
+	}
+
+	// Errors are mapped to HTTP status codes.
+	w, result = serve(http.MethodGet, "/v1/shelves/1/books/404", "", nil)
+	if w.Code != http.StatusNotFound || result["message"] != "book not found" {
+		t.Fatalf("Unexpected response %d %v", w.Code, result)
+	}
+	w, _ = serve(http.MethodGet, "/v1/shelves/1/books/2", "", http.Header{"X-Deny": {"1"}})
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("Expect 403, got %d", w.Code)
+	}
+
+	// Query parameters are bound by proto or JSON names, while path variables take precedence.
+	w, _ = serve(http.MethodGet, "/v1/shelves/1/books?pageSize=10&tags=a&tags=b&kind=KIND_NOVEL&parent=2&"+
+		"filter.publish_time=2026-01-01T00:00:00Z&utm_source=test", "", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expect 200, got %d", w.Code)
+	}
+	tags := get(listReq, "tags").List()
+	publishTime := get(get(listReq, "filter").Message(), "publish_time").Message()
+	if get(listReq, "parent").String() != "shelves/1" || get(listReq, "page_size").Int() != 10 ||
+		tags.Len() != 2 || tags.Get(1).String() != "b" || get(listReq, "kind").Enum() != 1 ||
+		get(publishTime, "seconds").Int() != 1767225600 {
+		t.Fatalf("Unexpected request %v", listReq)
+	}
+	w, result = serve(http.MethodGet, "/v1/shelves/1/books?page_size=abc", "", nil)
+	if w.Code != http.StatusBadRequest || result["code"] != float64(codes.InvalidArgument) {
+		t.Fatalf("Unexpected response %d %v", w.Code, result)
+	}
+
+	// Additional bindings with custom verbs.
+	w, _ = serve(http.MethodGet, "/v1/books:search?page_size=5", "", nil)
+	if w.Code != http.StatusOK || get(listReq, "page_size").Int() != 5 || get(listReq, "parent").String() != "" {
+		t.Fatalf("Unexpected response %d %v", w.Code, listReq)
+	}
+
+	// The body is bound to a field, ignoring unknown fields.
+	w, result = serve(http.MethodPost, "/v1/shelves/1/books", `{"title":"Go","unknown":1}`, nil)
+	if w.Code != http.StatusOK || result["name"] != "shelves/1/books/1" || result["title"] != "Go" {
+		t.Fatalf("Unexpected response %d %v", w.Code, result)
+	}
+	w, _ = serve(http.MethodPost, "/v1/shelves/1/books", `{`, nil)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("Expect 400, got %d", w.Code)
+	}
+
+	// Unknown routes and methods.
+	w, _ = serve(http.MethodDelete, "/v1/shelves/1/books", "", nil)
+	if w.Code != http.StatusMethodNotAllowed || w.Header().Get("Allow") != "GET, POST" {
+		t.Fatalf("Unexpected response %d %v", w.Code, w.Header())
+	}
+	w, _ = serve(http.MethodGet, "/v2/shelves/1/books", "", nil)
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("Expect 404, got %d", w.Code)
+	}
+}
+
+func TestHTTPStatusFromCode(t *testing.T) {
+	t.Parallel()
+
+	tests := map[codes.Code]int{
+		codes.OK:                 http.StatusOK,
+		codes.Canceled:           499,
+		codes.InvalidArgument:    http.StatusBadRequest,
+		codes.DeadlineExceeded:   http.StatusGatewayTimeout,
+		codes.NotFound:           http.StatusNotFound,
+		codes.AlreadyExists:      http.StatusConflict,
+		codes.PermissionDenied:   http.StatusForbidden,
+		codes.Unauthenticated:    http.StatusUnauthorized,
+		codes.ResourceExhausted:  http.StatusTooManyRequests,
+		codes.FailedPrecondition: http.StatusBadRequest,
+		codes.Unimplemented:      http.StatusNotImplemented,
+		codes.Unavailable:        http.StatusServiceUnavailable,
+		codes.DataLoss:           http.StatusInternalServerError,
+	}
+	for code, want := range tests {
+		if got := transcode.HTTPStatusFromCode(code); got != want {
+			t.Fatalf("Expect %d for %s, got %d", want, code, got)
+		}
+	}
+}